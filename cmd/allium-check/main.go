@@ -4,23 +4,47 @@
 // Usage:
 //
 //	allium-check [flags] file1.allium.json [file2.allium.json ...]
+//	allium-check --root dir [flags]
+//	allium-check --against previous.allium.json [flags] file.allium.json
+//	allium-check [flags] - < file.allium.json
+//	allium-check --list-rules [--format json]
+//	allium-check explain [--format json] RULE-12 [WARN-05 ...]
 //
 // Exit codes:
 //
 //	0  All files are valid (no errors; warnings may be present unless --strict)
-//	1  One or more files have validation errors (or warnings with --strict)
+//	1  One or more files have validation errors (or warnings with --strict,
+//	   more warnings than --max-warnings, or findings of --error-on rules)
 //	2  Input or parse error (missing file, invalid JSON, bad flags)
 package main
 
 import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
+	"math"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/foundry-zero/allium/internal/annotate"
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/bundle"
 	"github.com/foundry-zero/allium/internal/checker"
+	"github.com/foundry-zero/allium/internal/config"
+	"github.com/foundry-zero/allium/internal/fix"
 	"github.com/foundry-zero/allium/internal/report"
+	"github.com/foundry-zero/allium/internal/schema"
+	"github.com/foundry-zero/allium/internal/semantic"
+	"github.com/foundry-zero/allium/internal/template"
 )
 
 const version = "0.1.0"
@@ -30,13 +54,52 @@ func main() {
 }
 
 func run(args []string) int {
+	if len(args) > 0 && args[0] == "explain" {
+		return runExplain(args[1:])
+	}
+
 	fs := flag.NewFlagSet("allium-check", flag.ContinueOnError)
 
-	formatFlag := fs.String("format", "text", "Output format: text or json")
+	formatFlag := fs.String("format", "text", "Output format: text, json, sarif, html, github (workflow annotations) or gitlab (Code Quality report)")
 	quiet := fs.Bool("quiet", false, "Suppress warnings (show errors only)")
 	strict := fs.Bool("strict", false, "Treat warnings as errors")
+	maxWarnings := fs.Int("max-warnings", -1, "Exit 1 when the inputs have more than `n` warnings in total; negative for no limit")
+	errorOn := fs.String("error-on", "", "Exit 1 when any finding of the listed rules is reported, selected as for --rules (e.g., WARN-05,WARN-12)")
+	group := fs.Bool("group", false, "Report findings with the same rule and message once, with all of their locations (text and json formats)")
+	noColor := fs.Bool("no-color", false, "Write text output to a terminal without colors or severity icons (as does setting NO_COLOR)")
+	groupLimit := fs.Int("group-limit", 10, "With --group, list at most `n` locations per finding and count the rest (0 lists all)")
 	schemaOnly := fs.Bool("schema-only", false, "Run schema validation only, skip semantic passes")
-	rulesFlag := fs.String("rules", "", "Comma-separated rule numbers or range (e.g., 7,8,9 or 7-9)")
+	rulesFlag := fs.String("rules", "", "Comma-separated rule numbers, ranges, IDs or categories to check, each optionally excluded with a leading - (e.g., 7-9, WARN-05, statemachine, all,-WARN-02)")
+	pathFlag := fs.String("path", "", "Only report findings within this JSONPath subtree (e.g., '$.rules[12]')")
+	workspace := fs.Bool("workspace", false, "Validate all input files together, resolving use_declarations across them")
+	bundleFlag := fs.String("bundle", "", "Validate the specs of a bundle, a `path` to a directory, .tar, .tar.gz, .tgz or .zip with an allium-bundle.json manifest, as a workspace, resolving use_declarations through the manifest")
+	root := fs.String("root", "", "Discover .allium.json files under `dir` and validate them as a workspace")
+	resolveFrom := fs.String("resolve-from", "", "Fetch the specs of use_declaration coordinates that name no input from a registry, a `dir` or http(s) URL, and validate as a workspace")
+	importGraph := fs.String("import-graph", "", "Print the workspace import graph as `format` dot or json instead of the findings")
+	derivedOrder := fs.Bool("derived-order", false, "Print the evaluation order of each entity's and value type's derived values as JSON instead of the findings")
+	relationshipMetrics := fs.Bool("relationship-metrics", false, "Print the fan-out, fan-in and reference depth of each entity as JSON instead of the findings")
+	coverage := fs.Bool("coverage", false, "Print each surface guarantee with the rules that keep it as JSON instead of the findings")
+	capabilities := fs.String("capabilities", "", "Print, as `format` json or csv instead of the findings, the surfaces each actor reaches, the triggers it can invoke and the fields and entities they read and write")
+	emitStateMachines := fs.String("emit-state-machines", "", "Write the state machine of each entity, with the rules causing each transition, to `file` as JSON")
+	functionsFlag := fs.String("functions", "", "Load domain-specific function signatures from a JSON manifest `file`")
+	againstFlag := fs.String("against", "", "Report changes that break consumers of the previous version in `file` (RULE-42)")
+	templateFlag := fs.String("template", "", "Check that specs follow the sections, names and prefixes of the template in `file` (RULE-43)")
+	schemaFlag := fs.String("schema", "", "Validate against the JSON schema in `file`, such as a pre-release or extended schema, instead of the embedded one")
+	configFlag := fs.String("config", "", "Load project configuration from `file` instead of discovering .alliumcheck.json above each input file")
+	noConfig := fs.Bool("no-config", false, "Do not discover .alliumcheck.json project configuration")
+	listRules := fs.Bool("list-rules", false, "Print the catalog of rules and warnings (text or json) and exit")
+	explain := fs.Bool("explain", false, "Explain the rule of each finding under it: why it matters and how a valid spec looks (text format)")
+	outputFlag := fs.String("output", "", "Write the output to `file` instead of stdout")
+	outputDir := fs.String("output-dir", "", "Write one report per input file to `dir`, named after the spec (e.g. auth.report.json)")
+	fixFlag := fs.Bool("fix", false, "Apply the fixes suggested by findings to the input files, then report the remaining findings")
+	fixDryRun := fs.Bool("fix-dry-run", false, "Print the fixes suggested by findings as a unified diff instead of the findings")
+	annotateFlag := fs.Bool("annotate", false, "Write findings to a sidecar .annotations.json file next to each spec, keeping review status from earlier runs")
+	execPerFinding := fs.String("exec-per-finding", "", "Run `command` for each reported finding, substituting {file}, {rule}, {severity}, {path}, {line} and {message} in its words")
+	execPerFile := fs.String("exec-per-file", "", "Run `command` once per input file with its JSON report on standard input, substituting {file} in its words")
+	stdinFlag := fs.Bool("stdin", false, "Read a spec from standard input, as the input \"-\" does")
+	stdinFilename := fs.String("stdin-filename", "", "Report the spec read from standard input as `file`, which also locates its project configuration (default <stdin>)")
+	maxFileSize := fs.String("max-file-size", "256MB", "Report input files larger than `size` (e.g. 512KB, 64MB, 1GB) as input errors instead of checking them; 0 for no limit")
+	verbose := fs.Bool("verbose", false, "Report progress per file and a table of the time spent in each phase on stderr, and record timings in JSON reports")
 	showVersion := fs.Bool("version", false, "Print version and exit")
 
 	if err := fs.Parse(args); err != nil {
@@ -49,16 +112,125 @@ func run(args []string) int {
 		return 0
 	}
 
+	if *listRules {
+		if err := printRules(*formatFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		return 0
+	}
+
 	files := fs.Args()
-	if len(files) == 0 {
+	if *importGraph != "" {
+		if *importGraph != "dot" && *importGraph != "json" {
+			fmt.Fprintf(os.Stderr, "Error: invalid --import-graph format %q (use dot or json)\n", *importGraph)
+			return 2
+		}
+		*workspace = true
+	}
+	if *capabilities != "" && *capabilities != "json" && *capabilities != "csv" {
+		fmt.Fprintf(os.Stderr, "Error: invalid --capabilities format %q (use json or csv)\n", *capabilities)
+		return 2
+	}
+	var views []string
+	for _, v := range []struct {
+		flag string
+		set  bool
+	}{{"--import-graph", *importGraph != ""}, {"--derived-order", *derivedOrder}, {"--relationship-metrics", *relationshipMetrics}, {"--coverage", *coverage}, {"--capabilities", *capabilities != ""}, {"--fix-dry-run", *fixDryRun}} {
+		if v.set {
+			views = append(views, v.flag)
+		}
+	}
+	if len(views) > 1 {
+		fmt.Fprintf(os.Stderr, "Error: %s cannot be combined with %s\n", views[0], views[1])
+		return 2
+	}
+	if *root != "" {
+		discovered, err := checker.DiscoverSpecs(*root)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		files = append(files, discovered...)
+		*workspace = true
+	}
+	if *bundleFlag != "" {
+		if len(files) > 0 || *stdinFlag {
+			fmt.Fprintln(os.Stderr, "Error: --bundle cannot be combined with input files, --root or --stdin")
+			return 2
+		}
+		if *annotateFlag || *fixFlag || *fixDryRun {
+			fmt.Fprintln(os.Stderr, "Error: --annotate, --fix and --fix-dry-run cannot be combined with --bundle")
+			return 2
+		}
+		*workspace = true
+	}
+	if *resolveFrom != "" {
+		if *bundleFlag != "" {
+			fmt.Fprintln(os.Stderr, "Error: --resolve-from cannot be combined with --bundle, whose manifest lists its coordinates")
+			return 2
+		}
+		if !strings.HasPrefix(*resolveFrom, "http://") && !strings.HasPrefix(*resolveFrom, "https://") {
+			if info, err := os.Stat(*resolveFrom); err != nil || !info.IsDir() {
+				fmt.Fprintf(os.Stderr, "Error: --resolve-from %s is neither a directory nor an http(s) URL\n", *resolveFrom)
+				return 2
+			}
+		}
+		*workspace = true
+	}
+	if *stdinFlag && !slices.Contains(files, stdinInput) {
+		files = append(files, stdinInput)
+	}
+	src := &sources{stdinName: "<stdin>"}
+	if *stdinFilename != "" {
+		src.stdinName = *stdinFilename
+	}
+	if n := countInputs(files, stdinInput); n > 0 {
+		if n > 1 || *workspace {
+			fmt.Fprintln(os.Stderr, "Error: standard input can be read once, and not with --workspace or --root")
+			return 2
+		}
+		if *annotateFlag || *fixFlag {
+			fmt.Fprintln(os.Stderr, "Error: --annotate and --fix cannot be combined with standard input")
+			return 2
+		}
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: read standard input: %v\n", err)
+			return 2
+		}
+		src.stdin = data
+	} else if *stdinFilename != "" {
+		fmt.Fprintln(os.Stderr, "Error: --stdin-filename requires --stdin or the input \"-\"")
+		return 2
+	}
+	if len(files) == 0 && *bundleFlag == "" {
 		fmt.Fprintln(os.Stderr, "Error: no input files specified")
 		fs.Usage()
 		return 2
 	}
 
 	// Validate format flag
-	if *formatFlag != "text" && *formatFlag != "json" {
-		fmt.Fprintf(os.Stderr, "Error: invalid format %q (use text or json)\n", *formatFlag)
+	if _, ok := reportExtensions[*formatFlag]; !ok {
+		fmt.Fprintf(os.Stderr, "Error: invalid format %q (use text, json, sarif, html, github or gitlab)\n", *formatFlag)
+		return 2
+	}
+	if *group && *formatFlag != "text" && *formatFlag != "json" {
+		fmt.Fprintf(os.Stderr, "Error: --group cannot be combined with --format %s\n", *formatFlag)
+		return 2
+	}
+	if *explain && *formatFlag != "text" {
+		fmt.Fprintf(os.Stderr, "Error: --explain cannot be combined with --format %s\n", *formatFlag)
+		return 2
+	}
+	if *groupLimit < 0 {
+		fmt.Fprintf(os.Stderr, "Error: invalid --group-limit %d (must not be negative)\n", *groupLimit)
+		return 2
+	}
+
+	maxSize, err := parseSize(*maxFileSize)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid --max-file-size value: %v\n", err)
 		return 2
 	}
 
@@ -69,56 +241,397 @@ func run(args []string) int {
 		return 2
 	}
 
-	// Create checker
-	c, err := checker.NewChecker()
+	errorOnIDs, err := parseRuleFilter(*errorOn)
 	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: invalid --error-on value: %v\n", err)
+		return 2
+	}
+
+	if *pathFlag != "" && !strings.HasPrefix(*pathFlag, "$") {
+		fmt.Fprintf(os.Stderr, "Error: invalid --path value %q (must be a JSONPath starting with '$')\n", *pathFlag)
+		return 2
+	}
+
+	// A partial run would drop the review status of findings it did not report.
+	if *annotateFlag && (*schemaOnly || ruleFilter != nil || *pathFlag != "") {
+		fmt.Fprintln(os.Stderr, "Error: --annotate cannot be combined with --schema-only, --rules or --path")
+		return 2
+	}
+
+	if *outputFlag != "" && *outputDir != "" {
+		fmt.Fprintln(os.Stderr, "Error: --output cannot be combined with --output-dir")
+		return 2
+	}
+	if *outputDir != "" && len(views) > 0 {
+		fmt.Fprintf(os.Stderr, "Error: --output-dir cannot be combined with %s\n", views[0])
+		return 2
+	}
+
+	if (*fixFlag || *fixDryRun) && *workspace {
+		fmt.Fprintln(os.Stderr, "Error: --fix and --fix-dry-run cannot be combined with --workspace or --root")
+		return 2
+	}
+	if *fixFlag && *fixDryRun {
+		fmt.Fprintln(os.Stderr, "Error: --fix cannot be combined with --fix-dry-run")
+		return 2
+	}
+
+	if *againstFlag != "" && (*workspace || len(files) != 1) {
+		fmt.Fprintln(os.Stderr, "Error: --against takes a single input file and cannot be combined with --workspace or --root")
+		return 2
+	}
+
+	var findingCmd, fileCmd []string
+	for _, c := range []struct {
+		flag  string
+		value string
+		words *[]string
+	}{{"--exec-per-finding", *execPerFinding, &findingCmd}, {"--exec-per-file", *execPerFile, &fileCmd}} {
+		if c.value == "" {
+			continue
+		}
+		if *c.words, err = splitCommand(c.value); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid %s value: %v\n", c.flag, err)
+			return 2
+		}
+	}
+
+	if *configFlag != "" && *noConfig {
+		fmt.Fprintln(os.Stderr, "Error: --config cannot be combined with --no-config")
+		return 2
+	}
+
+	var cfg *config.Config
+	if *configFlag != "" {
+		cfg, err = config.Load(*configFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+	}
+
+	var functions *semantic.FunctionRegistry
+	if *functionsFlag != "" {
+		functions, err = semantic.LoadFunctionManifest(*functionsFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+	}
+
+	var previous *ast.Spec
+	if *againstFlag != "" {
+		previous, err = ast.LoadSpec(*againstFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+	}
+
+	var tmpl *template.Template
+	if *templateFlag != "" {
+		tmpl, err = template.Load(*templateFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+	}
+
+	// Create checker
+	var c *checker.Checker
+	if *schemaFlag != "" {
+		sv, err := schema.NewSchemaValidatorFromFile(*schemaFlag)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		c = checker.NewCheckerWithSchema(sv)
+	} else if c, err = checker.NewChecker(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		return 2
 	}
 
 	opts := checker.CheckOptions{
-		SchemaOnly: *schemaOnly,
-		RuleFilter: ruleFilter,
-		Strict:     *strict,
+		SchemaOnly:     *schemaOnly,
+		RuleIDs:        ruleFilter,
+		Strict:         *strict,
+		PathFilter:     *pathFlag,
+		Config:         cfg,
+		Functions:      functions,
+		Against:        previous,
+		Template:       tmpl,
+		DiscoverConfig: *configFlag == "" && !*noConfig,
+		Timings:        *verbose,
+		MaxFileSize:    maxSize,
+	}
+	if *resolveFrom != "" {
+		opts.Resolver = checker.NewResolver(*resolveFrom, maxSize)
 	}
 
-	exitCode := 0
-	for _, path := range files {
-		r := c.Check(path, opts)
+	var reports []*report.Report
+	var graph *checker.ImportGraph
+	var fixes []byte // unified diffs of the fixes, for --fix-dry-run
+	if *bundleFlag != "" {
+		b, err := bundle.Open(*bundleFlag, maxSize)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		src.bundle = b
+		reports, graph = c.CheckBundleGraph(b, opts)
+		if *verbose {
+			for i, r := range reports {
+				printProgress(os.Stderr, i, len(reports), r)
+			}
+		}
+	} else if *workspace {
+		reports, graph = c.CheckWorkspaceGraph(files, opts)
+		if *verbose {
+			for i, r := range reports {
+				printProgress(os.Stderr, i, len(reports), r)
+			}
+		}
+	} else {
+		add := func(r *report.Report) {
+			reports = append(reports, r)
+			if *verbose {
+				printProgress(os.Stderr, len(reports)-1, len(files), r)
+			}
+		}
+		for _, path := range files {
+			name := path
+			if path == stdinInput {
+				name = src.stdinName
+			}
+			if *fixFlag || *fixDryRun {
+				if data, err := src.read(name); err == nil {
+					fixed, err := fixSpec(c, name, data, opts, *fixFlag)
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+						return 2
+					}
+					fixes = append(fixes, fix.Diff("a/"+name, "b/"+name, data, fixed)...)
+					add(c.CheckSource(name, fixed, opts))
+					continue
+				}
+				// An unreadable file is reported as an INPUT error below.
+			}
+			if path == stdinInput {
+				add(c.CheckSource(src.stdinName, src.stdin, opts))
+				continue
+			}
+			add(c.Check(path, opts))
+		}
+	}
+
+	if *verbose {
+		defer printTimings(os.Stderr, reports)
+	}
+
+	if *annotateFlag {
+		if err := writeAnnotations(reports); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+	}
+	if *emitStateMachines != "" {
+		if err := writeStateMachines(*emitStateMachines, reports, src.read, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+	}
 
+	exitCode := 0
+	warnings := 0
+	var shown []*report.Report
+	for _, r := range reports {
 		// Determine exit code for this file
 		if hasInputError(r) {
 			exitCode = max(exitCode, 2)
 		} else if r.HasErrors() {
 			exitCode = max(exitCode, 1)
-		} else if *strict && r.HasWarnings() {
+		} else if *strict && r.HasWarnings() || hasFindingOf(r, errorOnIDs) {
 			exitCode = max(exitCode, 1)
 		}
+		warnings += r.Summary.WarningCount
 
 		// Output: if --quiet, suppress warnings but still show errors
 		if *quiet {
-			if r.HasErrors() {
-				filtered := report.NewReport(r.File)
-				filtered.SchemaValid = r.SchemaValid
-				for _, e := range r.Errors {
-					filtered.AddFinding(e)
-				}
-				if err := printReport(filtered, *formatFlag); err != nil {
-					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-					return 2
-				}
+			filtered := report.NewReport(r.File)
+			filtered.SchemaValid = r.SchemaValid
+			for _, e := range r.Errors {
+				filtered.AddFinding(e)
 			}
-		} else {
-			if err := printReport(r, *formatFlag); err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-				return 2
+			for _, f := range r.Suppressed {
+				filtered.AddSuppressed(f)
 			}
+			r = filtered
+		}
+		shown = append(shown, r)
+	}
+	if *maxWarnings >= 0 && warnings > *maxWarnings {
+		fmt.Fprintf(os.Stderr, "%d warnings exceed --max-warnings %d\n", warnings, *maxWarnings)
+		exitCode = max(exitCode, 1)
+	}
+
+	if *outputDir != "" {
+		if err := writeReportFiles(shown, *outputDir, *root, *formatFlag, groupFor(*group, *groupLimit), report.TextOptions{Explain: explainFor(*explain)}, src.read); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		if err := runExecHooks(shown, findingCmd, fileCmd); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
 		}
+		return exitCode
+	}
+
+	var buf bytes.Buffer
+	var out io.Writer = os.Stdout
+	textOpts := report.TextOptions{Explain: explainFor(*explain)}
+	if *outputFlag != "" {
+		out = &buf
+	} else if isTerminal(os.Stdout) {
+		textOpts.Source = src.read
+		textOpts.Color = !*noColor && os.Getenv("NO_COLOR") == ""
+	}
+	switch {
+	case *importGraph != "":
+		err = printImportGraph(out, graph, *importGraph)
+	case *derivedOrder:
+		err = printDerivedOrder(out, reports, src.read)
+	case *relationshipMetrics:
+		err = printRelationshipMetrics(out, reports, src.read)
+	case *coverage:
+		err = printGuaranteeCoverage(out, reports, src.read)
+	case *capabilities != "":
+		err = printCapabilities(out, reports, src.read, *capabilities)
+	case *fixDryRun:
+		_, err = out.Write(fixes)
+	case *formatFlag == "sarif":
+		// SARIF is a single log covering every file.
+		err = printSARIF(out, shown)
+	case *formatFlag == "html":
+		// Like SARIF, the HTML report is a single page covering every file.
+		err = printHTML(out, shown, src.read)
+	case *formatFlag == "github":
+		_, err = io.WriteString(out, report.FormatGitHub(shown))
+	case *formatFlag == "gitlab":
+		// A Code Quality report is a single array of every file's issues.
+		err = printGitLab(out, shown)
+	default:
+		for _, r := range shown {
+			if *quiet && !r.HasErrors() {
+				continue
+			}
+			if err = printReport(out, r, *formatFlag, groupFor(*group, *groupLimit), textOpts); err != nil {
+				break
+			}
+		}
+	}
+	if err == nil && *outputFlag != "" {
+		err = os.WriteFile(*outputFlag, buf.Bytes(), 0644)
+	}
+	if err == nil {
+		err = runExecHooks(shown, findingCmd, fileCmd)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
 	}
 
 	return exitCode
 }
 
+// stdinInput is the input name that reads a spec from standard input.
+const stdinInput = "-"
+
+// sources reads the content of inputs by the file name of their report. The
+// spec read from standard input is served under the name it is reported as,
+// and the members of a bundle under bundle.MemberPath.
+type sources struct {
+	stdinName string
+	stdin     []byte // nil unless standard input was read
+	bundle    *bundle.Bundle
+}
+
+func (s *sources) read(path string) ([]byte, error) {
+	if s.stdin != nil && path == s.stdinName {
+		return s.stdin, nil
+	}
+	if b := s.bundle; b != nil {
+		if path == b.MemberPath(bundle.ManifestName) {
+			return b.ManifestData, nil
+		}
+		for _, m := range b.Members {
+			if path == b.MemberPath(m.Name) && m.Data != nil {
+				return m.Data, nil
+			}
+		}
+	}
+	return os.ReadFile(path)
+}
+
+// printProgress writes a line reporting that the i-th of n reports is done,
+// with its counts and the time its check took.
+func printProgress(w io.Writer, i, n int, r *report.Report) {
+	fmt.Fprintf(w, "[%d/%d] %s: %d errors, %d warnings in %s\n", i+1, n, r.File,
+		r.Summary.ErrorCount, r.Summary.WarningCount, r.Elapsed().Round(time.Microsecond))
+}
+
+// printTimings writes a table of the time spent in each phase across the
+// reports, in the order the phases first ran, with its share of the total.
+func printTimings(w io.Writer, reports []*report.Report) {
+	var phases []string
+	totals := make(map[string]time.Duration)
+	var total time.Duration
+	for _, r := range reports {
+		for _, t := range r.Timings {
+			if _, ok := totals[t.Phase]; !ok {
+				phases = append(phases, t.Phase)
+			}
+			totals[t.Phase] += t.Duration
+			total += t.Duration
+		}
+	}
+	fmt.Fprintf(w, "\nTimings for %d files, %s in total:\n", len(reports), total.Round(time.Microsecond))
+	fmt.Fprintf(w, "  %-20s %12s %7s\n", "phase", "time", "share")
+	for _, p := range phases {
+		share := 0.0
+		if total > 0 {
+			share = 100 * float64(totals[p]) / float64(total)
+		}
+		fmt.Fprintf(w, "  %-20s %12s %6.1f%%\n", p, totals[p].Round(time.Microsecond), share)
+	}
+}
+
+// isTerminal reports whether f is a terminal rather than a file or pipe, so
+// that text output may be colored and annotated with source snippets.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// countInputs returns how many times name appears in files.
+func countInputs(files []string, name string) int {
+	n := 0
+	for _, f := range files {
+		if f == name {
+			n++
+		}
+	}
+	return n
+}
+
+// loadSpec parses the spec reported as path, reading it with read.
+func loadSpec(path string, read func(string) ([]byte, error)) (*ast.Spec, error) {
+	data, err := read(path)
+	if err != nil {
+		return nil, err
+	}
+	return ast.ParseSpec(data)
+}
+
 // hasInputError returns true if the report contains an INPUT error.
 func hasInputError(r *report.Report) bool {
 	for _, e := range r.Errors {
@@ -129,54 +642,790 @@ func hasInputError(r *report.Report) bool {
 	return false
 }
 
-// printReport outputs the report in the specified format.
-func printReport(r *report.Report, format string) error {
+// hasFindingOf reports whether r has an unsuppressed finding of any of the
+// rules ids, which --error-on fails the run for whatever their severity.
+func hasFindingOf(r *report.Report, ids []string) bool {
+	for _, f := range r.Findings() {
+		if slices.Contains(ids, f.Rule) {
+			return true
+		}
+	}
+	return false
+}
+
+// writeAnnotations updates the sidecar annotation file of every spec that
+// could be read and parsed.
+func writeAnnotations(reports []*report.Report) error {
+	for _, r := range reports {
+		if hasInputError(r) {
+			continue
+		}
+		path := annotate.SidecarPath(r.File)
+		prev, err := annotate.Load(path)
+		if err != nil {
+			return err
+		}
+		if err := annotate.Save(path, annotate.Merge(r, prev)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fixSpec applies the fixes suggested by the findings of the spec in data,
+// reported as name, and returns the fixed spec. With write, it replaces the
+// file and lists the fixes on stderr.
+func fixSpec(c *checker.Checker, name string, data []byte, opts checker.CheckOptions, write bool) ([]byte, error) {
+	fixed, applied, err := fix.Fix(data, func(d []byte) *report.Report { return c.CheckSource(name, d, opts) })
+	if err != nil {
+		return nil, fmt.Errorf("fix %s: %w", name, err)
+	}
+	if !write || len(applied) == 0 {
+		return fixed, nil
+	}
+	info, err := os.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(name, fixed, info.Mode().Perm()); err != nil {
+		return nil, err
+	}
+	for _, s := range applied {
+		fmt.Fprintf(os.Stderr, "Fixed %s: %s\n", name, s.Description)
+	}
+	return fixed, nil
+}
+
+// splitCommand splits an --exec-per-finding or --exec-per-file command into
+// words at unquoted whitespace. Single and double quotes group text into one
+// word and are removed; nothing is escaped or expanded, as no shell runs the
+// command.
+func splitCommand(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	for _, c := range s {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				word.WriteRune(c)
+			}
+		case c == '\'' || c == '"':
+			quote, inWord = c, true
+		case c == ' ' || c == '\t' || c == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(c)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("empty command")
+	}
+	return words, nil
+}
+
+// runExecHooks runs findingCmd for every reported error and warning, and
+// fileCmd once per report with the report as JSON on its standard input.
+// Either may be nil.
+func runExecHooks(reports []*report.Report, findingCmd, fileCmd []string) error {
+	for _, r := range reports {
+		if fileCmd != nil {
+			data, err := report.FormatJSON(r)
+			if err != nil {
+				return err
+			}
+			if err := runCommand(fileCmd, strings.NewReplacer("{file}", r.File), data); err != nil {
+				return err
+			}
+		}
+		if findingCmd == nil {
+			continue
+		}
+		for _, f := range r.Findings() {
+			vars := strings.NewReplacer(
+				"{file}", r.File,
+				"{rule}", f.Rule,
+				"{severity}", f.Severity.String(),
+				"{path}", f.Location.Path,
+				"{line}", strconv.Itoa(f.Location.Line),
+				"{message}", f.Message,
+			)
+			if err := runCommand(findingCmd, vars, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// runCommand runs words as a command after substituting vars in each word,
+// so a value containing spaces stays a single argument. The command's output
+// goes to stderr, keeping stdout for the report.
+func runCommand(words []string, vars *strings.Replacer, stdin []byte) error {
+	argv := make([]string, len(words))
+	for i, w := range words {
+		argv[i] = vars.Replace(w)
+	}
+	cmd := exec.Command(argv[0], argv[1:]...)
+	if stdin != nil {
+		cmd.Stdin = bytes.NewReader(stdin)
+	}
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("run %s: %w", argv[0], err)
+	}
+	return nil
+}
+
+// printImportGraph outputs the workspace import graph in the given format.
+func printImportGraph(out io.Writer, g *checker.ImportGraph, format string) error {
+	if format == "dot" {
+		_, err := io.WriteString(out, g.DOT())
+		return err
+	}
+	data, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode import graph: %w", err)
+	}
+	_, err = fmt.Fprintln(out, string(data))
+	return err
+}
+
+// fileDerivedOrder is the derived value evaluation order of one spec file.
+type fileDerivedOrder struct {
+	File         string                  `json:"file"`
+	Declarations []semantic.DerivedOrder `json:"declarations"`
+}
+
+// printDerivedOrder outputs, as JSON, the derived value evaluation order of
+// every spec that could be read and parsed.
+func printDerivedOrder(out io.Writer, reports []*report.Report, read func(string) ([]byte, error)) error {
+	orders := []fileDerivedOrder{}
+	for _, r := range reports {
+		if hasInputError(r) {
+			continue
+		}
+		spec, err := loadSpec(r.File, read)
+		if err != nil {
+			continue
+		}
+		decls := semantic.DerivedValueOrders(spec)
+		if decls == nil {
+			decls = []semantic.DerivedOrder{}
+		}
+		orders = append(orders, fileDerivedOrder{File: r.File, Declarations: decls})
+	}
+	data, err := json.MarshalIndent(orders, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode derived value order: %w", err)
+	}
+	_, err = fmt.Fprintln(out, string(data))
+	return err
+}
+
+// fileRelationshipMetrics is the relationship graph metrics of one spec file.
+type fileRelationshipMetrics struct {
+	File     string                         `json:"file"`
+	Entities []semantic.RelationshipMetrics `json:"entities"`
+}
+
+// printRelationshipMetrics outputs, as JSON, the relationship graph metrics
+// of every spec that could be read and parsed.
+func printRelationshipMetrics(out io.Writer, reports []*report.Report, read func(string) ([]byte, error)) error {
+	metrics := []fileRelationshipMetrics{}
+	for _, r := range reports {
+		if hasInputError(r) {
+			continue
+		}
+		spec, err := loadSpec(r.File, read)
+		if err != nil {
+			continue
+		}
+		entities := semantic.RelationshipGraphMetrics(spec)
+		if entities == nil {
+			entities = []semantic.RelationshipMetrics{}
+		}
+		metrics = append(metrics, fileRelationshipMetrics{File: r.File, Entities: entities})
+	}
+	data, err := json.MarshalIndent(metrics, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode relationship metrics: %w", err)
+	}
+	_, err = fmt.Fprintln(out, string(data))
+	return err
+}
+
+// fileGuaranteeCoverage is the guarantee coverage of one spec file.
+type fileGuaranteeCoverage struct {
+	File       string                       `json:"file"`
+	Guarantees []semantic.GuaranteeCoverage `json:"guarantees"`
+	Uncovered  int                          `json:"uncovered"`
+}
+
+// printGuaranteeCoverage outputs, as JSON, the coverage of the surface
+// guarantees of every spec that could be read and parsed.
+func printGuaranteeCoverage(out io.Writer, reports []*report.Report, read func(string) ([]byte, error)) error {
+	files := []fileGuaranteeCoverage{}
+	for _, r := range reports {
+		if hasInputError(r) {
+			continue
+		}
+		spec, err := loadSpec(r.File, read)
+		if err != nil {
+			continue
+		}
+		fc := fileGuaranteeCoverage{File: r.File, Guarantees: semantic.GuaranteeCoverages(spec)}
+		if fc.Guarantees == nil {
+			fc.Guarantees = []semantic.GuaranteeCoverage{}
+		}
+		for _, g := range fc.Guarantees {
+			if !g.Covered {
+				fc.Uncovered++
+			}
+		}
+		files = append(files, fc)
+	}
+	data, err := json.MarshalIndent(files, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode guarantee coverage: %w", err)
+	}
+	_, err = fmt.Fprintln(out, string(data))
+	return err
+}
+
+// fileCapabilities is the actor capability matrix of one spec file.
+type fileCapabilities struct {
+	File   string                       `json:"file"`
+	Actors []semantic.ActorCapabilities `json:"actors"`
+}
+
+// printCapabilities outputs the actor capability matrix of every spec that
+// could be read and parsed, as JSON or as CSV with a row per file, actor,
+// surface providing a trigger, trigger and field or entity the trigger
+// reads, writes, creates or removes. An actor reaching no surface, a
+// reachable surface providing no trigger and a trigger with no effects still
+// get a row, with the columns they lack left empty.
+func printCapabilities(out io.Writer, reports []*report.Report, read func(string) ([]byte, error), format string) error {
+	files := []fileCapabilities{}
+	for _, r := range reports {
+		if hasInputError(r) {
+			continue
+		}
+		spec, err := loadSpec(r.File, read)
+		if err != nil {
+			continue
+		}
+		files = append(files, fileCapabilities{File: r.File, Actors: semantic.ActorCapabilityMatrix(spec, semantic.BuildSymbolTable(spec))})
+	}
+	if format == "json" {
+		data, err := json.MarshalIndent(files, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encode capabilities: %w", err)
+		}
+		_, err = fmt.Fprintln(out, string(data))
+		return err
+	}
+
+	w := csv.NewWriter(out)
+	w.Write([]string{"file", "actor", "surface", "trigger", "access", "target"})
+	for _, fc := range files {
+		for _, ac := range fc.Actors {
+			providing := make(map[string]bool)
+			for _, tc := range ac.Triggers {
+				var accesses [][2]string
+				for _, a := range []struct {
+					access  string
+					targets []string
+				}{{"read", tc.Reads}, {"write", tc.Writes}, {"create", tc.Creates}, {"remove", tc.Removes}} {
+					for _, target := range a.targets {
+						accesses = append(accesses, [2]string{a.access, target})
+					}
+				}
+				if len(accesses) == 0 {
+					accesses = [][2]string{{"", ""}}
+				}
+				for _, surface := range tc.Surfaces {
+					providing[surface] = true
+					for _, a := range accesses {
+						w.Write([]string{fc.File, ac.Actor, surface, tc.Trigger, a[0], a[1]})
+					}
+				}
+			}
+			for _, s := range ac.Surfaces {
+				if !providing[s.Surface] {
+					w.Write([]string{fc.File, ac.Actor, s.Surface, "", "", ""})
+				}
+			}
+			if len(ac.Surfaces) == 0 {
+				w.Write([]string{fc.File, ac.Actor, "", "", "", ""})
+			}
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// fileStateMachines is the entity state machines of one spec file.
+type fileStateMachines struct {
+	File          string                  `json:"file"`
+	StateMachines []semantic.StateMachine `json:"state_machines"`
+}
+
+// writeStateMachines writes, as JSON to path, the state machines of every
+// spec that could be read and parsed.
+func writeStateMachines(path string, reports []*report.Report, read func(string) ([]byte, error), opts checker.CheckOptions) error {
+	files := []fileStateMachines{}
+	for _, r := range reports {
+		if hasInputError(r) {
+			continue
+		}
+		spec, err := loadSpec(r.File, read)
+		if err != nil {
+			continue
+		}
+		machines, err := checker.StateMachines(r.File, spec, opts)
+		if err != nil {
+			continue // reported as an INPUT error when checking the file
+		}
+		if machines == nil {
+			machines = []semantic.StateMachine{}
+		}
+		files = append(files, fileStateMachines{File: r.File, StateMachines: machines})
+	}
+	data, err := json.MarshalIndent(files, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode state machines: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("write state machines: %w", err)
+	}
+	return nil
+}
+
+// printRules outputs the rule catalog, one line per rule in text format.
+func printRules(format string) error {
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(checker.Rules(), "", "  ")
+		if err != nil {
+			return fmt.Errorf("encode rule catalog: %w", err)
+		}
+		fmt.Println(string(data))
+	case "text":
+		for _, r := range checker.Rules() {
+			line := fmt.Sprintf("%-8s %-7s %-14s %s", r.ID, r.Severity, r.Category, r.Title)
+			if !r.Implemented {
+				line += " (not implemented)"
+			}
+			fmt.Println(line)
+		}
+	default:
+		return fmt.Errorf("invalid --list-rules format %q (use text or json)", format)
+	}
+	return nil
+}
+
+// runExplain runs the explain subcommand, printing the catalog entry of each
+// rule its arguments select, as for --rules, with why the rule matters, spec
+// snippets breaking and following it, and how to suppress its findings.
+func runExplain(args []string) int {
+	fs := flag.NewFlagSet("allium-check explain", flag.ContinueOnError)
+	formatFlag := fs.String("format", "text", "Output format: text or json")
+	if err := fs.Parse(args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if *formatFlag != "text" && *formatFlag != "json" {
+		fmt.Fprintf(os.Stderr, "Error: invalid format %q (use text or json)\n", *formatFlag)
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "Error: explain takes the rule IDs, numbers or categories to explain (e.g., RULE-12)")
+		return 2
+	}
+
+	var rules []checker.RuleInfo
+	for _, arg := range fs.Args() {
+		ids, err := selectRules(arg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		for _, id := range ids {
+			r, ok := checker.LookupRule(id)
+			if !ok {
+				fmt.Fprintf(os.Stderr, "Error: %s is not in the rule catalog (see --list-rules)\n", id)
+				return 2
+			}
+			rules = append(rules, r)
+		}
+	}
+
+	if *formatFlag == "json" {
+		data, err := json.MarshalIndent(rules, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: encode rule catalog: %v\n", err)
+			return 2
+		}
+		fmt.Println(string(data))
+		return 0
+	}
+	for i, r := range rules {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Print(explainRule(r))
+	}
+	return 0
+}
+
+// explainRule returns the text explain prints for r.
+func explainRule(r checker.RuleInfo) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %s\n", r.ID, r.Title)
+	fmt.Fprintf(&b, "Severity: %s, category: %s\n", r.Severity, r.Category)
+	b.WriteString("\n" + wrapText(plainText(r.Description), "", 78))
+	section := func(title, text string, prose bool) {
+		if prose {
+			text = wrapText(text, "  ", 78)
+		} else {
+			text = indentText(text, "  ")
+		}
+		fmt.Fprintf(&b, "\n%s:\n%s", title, text)
+	}
+	section("Why it matters", r.Rationale, true)
+	section("Invalid", r.Invalid, false)
+	section("Valid", r.Valid, false)
+	section("Suppressing", suppressionGuidance(r), true)
+	return b.String()
+}
+
+// explainFor returns the TextOptions.Explain of --explain: why each
+// finding's rule matters and a spec snippet following it.
+func explainFor(enabled bool) func(string) string {
+	if !enabled {
+		return nil
+	}
+	return func(id string) string {
+		r, ok := checker.LookupRule(id)
+		if !ok || r.Rationale == "" {
+			return ""
+		}
+		return "why: " + r.Rationale + "\nvalid:\n" + indentText(r.Valid, "  ")
+	}
+}
+
+// suppressionGuidance says how findings of r can be silenced, or why they
+// cannot.
+func suppressionGuidance(r checker.RuleInfo) string {
+	switch {
+	case r.Category == "Structural":
+		return fmt.Sprintf("The JSON Schema enforces %s, and breaking it is reported as a SCHEMA error, which no suppression or configuration silences; correct the spec instead.", r.ID)
+	case !r.Implemented:
+		return fmt.Sprintf("%s is not checked yet, so it is never reported.", r.ID)
+	}
+	return fmt.Sprintf(`To accept one finding, add {"rule": "%[1]s", "path": "<its path>", "reason": "..."} to the spec's suppressions; a path also covers the findings within it, and a suppression matching nothing is reported as WARN-21. To change the rule for a project, set "severity": {"%[1]s": "off"} in .alliumcheck.json, or "error", "warning", "info" or "hint" to report it at another severity. To leave it out of one run, pass --rules all,-%[1]s.`, r.ID)
+}
+
+// plainText drops the backticks of Markdown code spans from catalog text.
+func plainText(s string) string {
+	return strings.ReplaceAll(s, "`", "")
+}
+
+// wrapText fills the words of text into lines of at most width columns,
+// each starting with indent, and ends it with a newline.
+func wrapText(text, indent string, width int) string {
+	var b strings.Builder
+	line := indent
+	for _, word := range strings.Fields(text) {
+		if line != indent && len(line)+1+len(word) > width {
+			b.WriteString(line + "\n")
+			line = indent
+		}
+		if line != indent {
+			line += " "
+		}
+		line += word
+	}
+	b.WriteString(line + "\n")
+	return b.String()
+}
+
+// indentText starts each line of text with indent and ends it with a
+// newline.
+func indentText(text, indent string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		b.WriteString(indent + line + "\n")
+	}
+	return b.String()
+}
+
+// printReport outputs the report in the specified format. With a group of
+// zero or more, findings are grouped, listing at most group locations each
+// (all when zero); a negative group reports them one by one. Text output is
+// colored and shows source snippets as opts asks.
+func printReport(out io.Writer, r *report.Report, format string, group int, opts report.TextOptions) error {
 	switch format {
 	case "json":
 		data, err := report.FormatJSON(r)
+		if group >= 0 {
+			data, err = report.FormatJSONGrouped(r, group)
+		}
 		if err != nil {
 			return err
 		}
-		fmt.Println(string(data))
+		_, err = fmt.Fprintln(out, string(data))
+		return err
 	case "text":
-		fmt.Print(report.FormatText(r))
+		text := report.FormatTextWith(r, opts)
+		if group >= 0 {
+			text = report.FormatTextGroupedWith(r, group, opts)
+		}
+		_, err := io.WriteString(out, text)
+		return err
+	}
+	return nil
+}
+
+// groupFor returns the group argument of printReport for the --group and
+// --group-limit flags: the location limit when grouping, or -1 when not.
+func groupFor(group bool, limit int) int {
+	if !group {
+		return -1
+	}
+	return limit
+}
+
+// printSARIF outputs a single SARIF log covering every report.
+func printSARIF(out io.Writer, reports []*report.Report) error {
+	data, err := report.FormatSARIF(reports, version)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(out, string(data))
+	return err
+}
+
+// printGitLab outputs a single GitLab Code Quality report covering every
+// report.
+func printGitLab(out io.Writer, reports []*report.Report) error {
+	data, err := report.FormatGitLab(reports)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(out, string(data))
+	return err
+}
+
+// printHTML outputs a single HTML page covering every report, with snippets
+// of each spec around its findings.
+func printHTML(out io.Writer, reports []*report.Report, read func(string) ([]byte, error)) error {
+	titles := make(map[string]string)
+	for _, r := range checker.Rules() {
+		titles[r.ID] = r.Title
+	}
+	_, err := out.Write(report.FormatHTML(reports, report.HTMLOptions{
+		ToolVersion: version,
+		RuleTitles:  titles,
+		Source:      read,
+	}))
+	return err
+}
+
+// reportExtensions maps each --format to the extension of the files
+// --output-dir writes.
+var reportExtensions = map[string]string{"text": ".txt", "json": ".json", "sarif": ".sarif", "html": ".html", "github": ".github.txt", "gitlab": ".codequality.json"}
+
+// writeReportFiles writes each report to its own file under dir, as
+// reportPath names it, creating directories as needed. group and opts are
+// as for printReport.
+func writeReportFiles(reports []*report.Report, dir, root, format string, group int, opts report.TextOptions, read func(string) ([]byte, error)) error {
+	written := make(map[string]string)
+	for _, r := range reports {
+		path := reportPath(dir, root, r.File, format)
+		if prev, ok := written[path]; ok {
+			return fmt.Errorf("reports for %s and %s would both be written to %s", prev, r.File, path)
+		}
+		written[path] = r.File
+	}
+	for _, r := range reports {
+		var buf bytes.Buffer
+		var err error
+		switch format {
+		case "sarif":
+			err = printSARIF(&buf, []*report.Report{r})
+		case "html":
+			err = printHTML(&buf, []*report.Report{r}, read)
+		case "github":
+			_, err = io.WriteString(&buf, report.FormatGitHub([]*report.Report{r}))
+		case "gitlab":
+			err = printGitLab(&buf, []*report.Report{r})
+		default:
+			err = printReport(&buf, r, format, group, opts)
+		}
+		if err != nil {
+			return err
+		}
+		path := reportPath(dir, root, r.File, format)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-// parseRuleFilter parses a comma-separated list of rule numbers or ranges.
-// Examples: "7,8,9", "7-9", "1,3,7-9,22"
-func parseRuleFilter(s string) ([]int, error) {
+// reportPath returns where --output-dir writes the report of spec: its
+// file name without the .allium.json suffix, followed by ".report" and the
+// format's extension, so that auth.allium.json is reported in
+// auth.report.json. Specs under root keep their directory relative to it.
+func reportPath(dir, root, spec, format string) string {
+	name := filepath.Base(spec)
+	if root != "" {
+		if rel, err := filepath.Rel(root, spec); err == nil && !strings.HasPrefix(rel, "..") {
+			name = rel
+		}
+	}
+	name = strings.TrimSuffix(strings.TrimSuffix(name, ".json"), ".allium")
+	return filepath.Join(dir, name+".report"+reportExtensions[format])
+}
+
+// parseRuleFilter parses a comma-separated list of rule selectors into the
+// sorted rule and warning IDs they select. A selector is a rule number or
+// range ("7", "7-9"), an ID ("RULE-12", "WARN-05"), a catalog category
+// ("reference", "statemachine", matched without case, spaces or a plural
+// "s"), "rules", "warnings" or "all". A leading "-" excludes what the
+// selector names; a list starting with an exclusion starts from "all".
+// Examples: "7,8,9", "1,3,7-9,22", "references,WARN-05", "all,-WARN-02"
+func parseRuleFilter(s string) ([]string, error) {
 	if s == "" {
 		return nil, nil
 	}
 
-	var rules []int
-	for _, part := range strings.Split(s, ",") {
+	selected := make(map[string]bool)
+	for i, part := range strings.Split(s, ",") {
 		part = strings.TrimSpace(part)
-		if strings.Contains(part, "-") {
-			bounds := strings.SplitN(part, "-", 2)
-			lo, err := strconv.Atoi(strings.TrimSpace(bounds[0]))
-			if err != nil {
-				return nil, fmt.Errorf("invalid range start %q", bounds[0])
-			}
-			hi, err := strconv.Atoi(strings.TrimSpace(bounds[1]))
-			if err != nil {
-				return nil, fmt.Errorf("invalid range end %q", bounds[1])
-			}
-			if lo > hi {
-				return nil, fmt.Errorf("invalid range %d-%d", lo, hi)
-			}
-			for i := lo; i <= hi; i++ {
-				rules = append(rules, i)
-			}
-		} else {
-			n, err := strconv.Atoi(part)
-			if err != nil {
-				return nil, fmt.Errorf("invalid rule number %q", part)
+		exclude := strings.HasPrefix(part, "-")
+		if exclude {
+			part = strings.TrimSpace(part[1:])
+			if i == 0 {
+				for _, r := range checker.Rules() {
+					selected[r.ID] = true
+				}
 			}
-			rules = append(rules, n)
+		}
+		ids, err := selectRules(part)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			selected[id] = !exclude
 		}
 	}
+
+	var rules []string
+	for id, ok := range selected {
+		if ok {
+			rules = append(rules, id)
+		}
+	}
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("%q selects no rules", s)
+	}
+	slices.Sort(rules)
 	return rules, nil
 }
+
+// sizeUnits are the suffixes parseSize accepts, in bytes.
+var sizeUnits = map[string]int64{"": 1, "B": 1, "KB": 1 << 10, "MB": 1 << 20, "GB": 1 << 30}
+
+// parseSize parses a --max-file-size value: a whole number of bytes,
+// optionally followed by B, KB, MB or GB (in any case), counted in powers
+// of 1024 as the checker reports sizes.
+func parseSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	digits := strings.TrimRightFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	unit, ok := sizeUnits[strings.ToUpper(strings.TrimSpace(s[len(digits):]))]
+	if !ok || digits == "" || strings.ContainsFunc(digits, func(r rune) bool { return r < '0' || r > '9' }) {
+		return 0, fmt.Errorf("%q is not a size (use bytes or a whole number with KB, MB or GB)", s)
+	}
+	n, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil || n > math.MaxInt64/unit {
+		return 0, fmt.Errorf("%q is too large", s)
+	}
+	return n * unit, nil
+}
+
+// ruleID matches a rule or warning ID, whose number need not be zero-padded.
+var ruleID = regexp.MustCompile(`^(?i)(RULE|WARN)-([0-9]+)$`)
+
+// selectRules returns the IDs named by a single --rules selector.
+// Rule numbers and IDs need not be in the catalog; categories must be.
+func selectRules(sel string) ([]string, error) {
+	if m := ruleID.FindStringSubmatch(sel); m != nil {
+		n, _ := strconv.Atoi(m[2])
+		return []string{fmt.Sprintf("%s-%02d", strings.ToUpper(m[1]), n)}, nil
+	}
+	if lo, hi, ok := strings.Cut(sel, "-"); ok {
+		from, err := strconv.Atoi(strings.TrimSpace(lo))
+		if err != nil {
+			return nil, fmt.Errorf("invalid range start %q", lo)
+		}
+		to, err := strconv.Atoi(strings.TrimSpace(hi))
+		if err != nil {
+			return nil, fmt.Errorf("invalid range end %q", hi)
+		}
+		if from > to {
+			return nil, fmt.Errorf("invalid range %d-%d", from, to)
+		}
+		var ids []string
+		for n := from; n <= to; n++ {
+			ids = append(ids, fmt.Sprintf("RULE-%02d", n))
+		}
+		return ids, nil
+	}
+	if n, err := strconv.Atoi(sel); err == nil {
+		return []string{fmt.Sprintf("RULE-%02d", n)}, nil
+	}
+
+	key := categoryKey(sel)
+	var ids []string
+	for _, r := range checker.Rules() {
+		switch {
+		case key == "all",
+			key == "rule" && r.Severity == report.SeverityError,
+			key == "warning" && strings.HasPrefix(r.ID, "WARN-"),
+			key == categoryKey(r.Category):
+			ids = append(ids, r.ID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("invalid rule number, ID or category %q", sel)
+	}
+	return ids, nil
+}
+
+// categoryKey folds a category name for comparison: "State Machine",
+// "statemachine" and "statemachines" share a key.
+func categoryKey(s string) string {
+	s = strings.ToLower(strings.ReplaceAll(s, " ", ""))
+	return strings.TrimSuffix(s, "s")
+}
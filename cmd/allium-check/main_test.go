@@ -1,9 +1,21 @@
 package main
 
 import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/foundry-zero/allium/internal/annotate"
+	"github.com/foundry-zero/allium/internal/checker"
+	"github.com/foundry-zero/allium/internal/report"
+	"github.com/foundry-zero/allium/internal/semantic"
 )
 
 var refExample = filepath.Join("..", "..", "schemas", "v1", "examples", "password-auth.allium.json")
@@ -50,6 +62,57 @@ func TestRunInvalidSchema(t *testing.T) {
 	}
 }
 
+func TestRunSchemaOverride(t *testing.T) {
+	published := filepath.Join("..", "..", "schemas", "v1", "allium-spec.json")
+	if code := run([]string{"--schema", published, refExample}); code != 0 {
+		t.Errorf("run(--schema published) = %d, want 0", code)
+	}
+	if code := run([]string{"--schema", filepath.Join(t.TempDir(), "missing.json"), refExample}); code != 2 {
+		t.Errorf("run(--schema missing) = %d, want 2", code)
+	}
+
+	// A schema that forbids the reference example's version rejects it.
+	strict := filepath.Join(t.TempDir(), "strict.schema.json")
+	if err := os.WriteFile(strict, []byte(`{"properties": {"version": {"const": "2"}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if code := run([]string{"--schema", strict, refExample}); code != 1 {
+		t.Errorf("run(--schema strict) = %d, want 1", code)
+	}
+}
+
+func TestRunMaxFileSize(t *testing.T) {
+	if code := run([]string{"--max-file-size", "1KB", refExample}); code != 2 {
+		t.Errorf("run(over --max-file-size) = %d, want 2", code)
+	}
+	if code := run([]string{"--max-file-size", "0", refExample}); code != 0 {
+		t.Errorf("run(--max-file-size 0) = %d, want 0", code)
+	}
+	if code := run([]string{"--max-file-size", "lots", refExample}); code != 2 {
+		t.Errorf("run(invalid --max-file-size) = %d, want 2", code)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	for s, want := range map[string]int64{
+		"0":      0,
+		"4096":   4096,
+		"512B":   512,
+		"64kb":   64 << 10,
+		"256MB":  256 << 20,
+		" 2 GB ": 2 << 30,
+	} {
+		if got, err := parseSize(s); err != nil || got != want {
+			t.Errorf("parseSize(%q) = %d, %v; want %d", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"", "MB", "1.5GB", "-1MB", "12TB", "10 apples", "99999999999GB"} {
+		if _, err := parseSize(s); err == nil {
+			t.Errorf("parseSize(%q) should fail", s)
+		}
+	}
+}
+
 func TestRunNoArgs(t *testing.T) {
 	code := run([]string{})
 	if code != 2 {
@@ -108,6 +171,88 @@ func TestRunStrict(t *testing.T) {
 	}
 }
 
+func TestRunGroup(t *testing.T) {
+	dir := t.TempDir()
+	spec := filepath.Join(dir, "order.allium.json")
+	buyer := `{"kind": "entity_ref", "entity": "Buyer"}`
+	if err := os.WriteFile(spec, []byte(`{"version": "1", "file": "order.allium", "entities": [{"name": "Order", "fields": [`+
+		`{"name": "a", "type": `+buyer+`}, {"name": "b", "type": `+buyer+`}, {"name": "c", "type": `+buyer+`}]}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(dir, "report.txt")
+	if code := run([]string{"--no-config", "--group", "--group-limit", "2", "--output", out, spec}); code != 1 {
+		t.Errorf("run(--group) = %d, want 1", code)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"[RULE-01] error: Entity 'Buyer' referenced but not declared (3 locations)", "... and 1 more", "3 errors, 1 warnings"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("grouped output missing %q:\n%s", want, data)
+		}
+	}
+
+	if code := run([]string{"--no-config", "--group", "--format", "json", "--output", out, spec}); code != 1 {
+		t.Errorf("run(--group --format json) = %d, want 1", code)
+	}
+	if data, err := os.ReadFile(out); err != nil || !strings.Contains(string(data), `"groups"`) || !strings.Contains(string(data), `"count": 3`) {
+		t.Errorf("expected grouped JSON, got %s (%v)", data, err)
+	}
+
+	for _, args := range [][]string{
+		{"--group", "--format", "sarif", spec},
+		{"--group", "--group-limit", "-1", spec},
+	} {
+		if code := run(args); code != 2 {
+			t.Errorf("run(%v) = %d, want 2", args, code)
+		}
+	}
+}
+
+func TestRunExitPolicy(t *testing.T) {
+	// The reference example has four warnings: WARN-16, WARN-20, WARN-22 and
+	// WARN-31.
+	for _, tt := range []struct {
+		args []string
+		want int
+	}{
+		{[]string{"--max-warnings", "4"}, 0},
+		{[]string{"--max-warnings", "3"}, 1},
+		{[]string{"--max-warnings", "3", "--quiet"}, 1},
+		{[]string{"--error-on", "WARN-16"}, 1},
+		{[]string{"--error-on", "WARN-05,WARN-12"}, 0},
+		{[]string{"--error-on", "WARN-16", "--rules", "all,-WARN-16"}, 0},
+		{[]string{"--error-on", "WARN-99x"}, 2},
+	} {
+		args := append(append([]string{"--no-config"}, tt.args...), refExample)
+		if code := run(args); code != tt.want {
+			t.Errorf("run(%v) = %d, want %d", tt.args, code, tt.want)
+		}
+	}
+}
+
+func TestRunPathFilter(t *testing.T) {
+	// All reference-example warnings are under $.rules, so --strict passes
+	// when only the entities are selected.
+	code := run([]string{"--strict", "--path", "$.entities", refExample})
+	if code != 0 {
+		t.Errorf("run(--strict --path $.entities) = %d, want 0", code)
+	}
+
+	code = run([]string{"--strict", "--path", "$.rules[4]", refExample})
+	if code != 1 {
+		t.Errorf("run(--strict --path $.rules[4]) = %d, want 1 (WARN-16 fires)", code)
+	}
+}
+
+func TestRunInvalidPath(t *testing.T) {
+	code := run([]string{"--path", "rules[4]", refExample})
+	if code != 2 {
+		t.Errorf("run(--path rules[4]) = %d, want 2", code)
+	}
+}
+
 func TestRunMultipleFiles(t *testing.T) {
 	// One valid (schema-only), one nonexistent. Should return exit code 2 (max).
 	code := run([]string{"--schema-only", refExample, "/nonexistent.json"})
@@ -128,19 +273,24 @@ func TestParseRuleFilter(t *testing.T) {
 	tests := []struct {
 		name    string
 		input   string
-		want    []int
+		want    []string
 		wantErr bool
 	}{
 		{"empty", "", nil, false},
-		{"single", "7", []int{7}, false},
-		{"multiple", "1,3,22", []int{1, 3, 22}, false},
-		{"range", "7-9", []int{7, 8, 9}, false},
-		{"mixed", "1,7-9,22", []int{1, 7, 8, 9, 22}, false},
-		{"spaces", " 1 , 3 ", []int{1, 3}, false},
+		{"single", "7", []string{"RULE-07"}, false},
+		{"multiple", "1,3,22", []string{"RULE-01", "RULE-03", "RULE-22"}, false},
+		{"range", "7-9", []string{"RULE-07", "RULE-08", "RULE-09"}, false},
+		{"mixed", "1,7-9,22", []string{"RULE-01", "RULE-07", "RULE-08", "RULE-09", "RULE-22"}, false},
+		{"spaces", " 1 , 3 ", []string{"RULE-01", "RULE-03"}, false},
+		{"ids", "RULE-12,warn-5", []string{"RULE-12", "WARN-05"}, false},
+		{"category", "statemachine", []string{"RULE-07", "RULE-08", "RULE-09", "WARN-18"}, false},
+		{"category with space", "Sum Types", []string{"RULE-16", "RULE-17", "RULE-18", "RULE-19"}, false},
+		{"exclusion", "7-9,-8", []string{"RULE-07", "RULE-09"}, false},
 		{"invalid number", "abc", nil, true},
 		{"invalid range start", "abc-5", nil, true},
 		{"invalid range end", "5-abc", nil, true},
 		{"reversed range", "9-7", nil, true},
+		{"nothing selected", "7,-7", nil, true},
 	}
 
 	for _, tt := range tests {
@@ -150,21 +300,924 @@ func TestParseRuleFilter(t *testing.T) {
 				t.Errorf("parseRuleFilter(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
 				return
 			}
-			if !tt.wantErr && !equalSlice(got, tt.want) {
+			if !tt.wantErr && !slices.Equal(got, tt.want) {
 				t.Errorf("parseRuleFilter(%q) = %v, want %v", tt.input, got, tt.want)
 			}
 		})
 	}
 }
 
-func equalSlice(a, b []int) bool {
-	if len(a) != len(b) {
-		return false
+func TestParseRuleFilterGroups(t *testing.T) {
+	all := len(checker.Rules())
+	var warnings int
+	for _, r := range checker.Rules() {
+		if strings.HasPrefix(r.ID, "WARN-") {
+			warnings++
+		}
+	}
+	for _, tt := range []struct {
+		input string
+		want  int
+	}{
+		{"all", all},
+		{"all,-WARN-02", all - 1},
+		{"-WARN-02", all - 1},
+		{"warnings", warnings},
+		{"rules", all - warnings},
+		{"warnings,-completeness", warnings - 2},
+	} {
+		got, err := parseRuleFilter(tt.input)
+		if err != nil || len(got) != tt.want {
+			t.Errorf("parseRuleFilter(%q) selects %d rules (%v), want %d", tt.input, len(got), err, tt.want)
+		}
+		if slices.Contains(got, "WARN-02") && strings.Contains(tt.input, "-WARN-02") {
+			t.Errorf("parseRuleFilter(%q) kept WARN-02", tt.input)
+		}
+	}
+}
+
+func TestRunRuleSelection(t *testing.T) {
+	// The reference example has only warnings: WARN-16, WARN-20, WARN-22 and
+	// WARN-31.
+	for args, want := range map[string]int{
+		"warnings":                1,
+		"WARN-16":                 1,
+		"references,statemachine": 0,
+		"all,-WARN-16,-WARN-20,-WARN-22,-WARN-31": 0,
+		"-warnings":      0,
+		"nosuchcategory": 2,
+	} {
+		if code := run([]string{"--strict", "--no-config", "--rules", args, refExample}); code != want {
+			t.Errorf("run(--strict --rules %s) = %d, want %d", args, code, want)
+		}
+	}
+}
+
+func TestRunWorkspace(t *testing.T) {
+	dir := t.TempDir()
+	billing := `{"version": "1", "file": "billing.allium",
+  "entities": [{"name": "Invoice", "fields": [{"name": "total", "type": {"kind": "primitive", "value": "Integer"}}]}]}`
+	orders := `{"version": "1", "file": "orders.allium",
+  "use_declarations": [{"coordinate": "./billing.allium", "alias": "billing"}],
+  "external_entities": [{"name": "Invoice", "fields": []}]}`
+	if err := os.WriteFile(filepath.Join(dir, "billing.allium.json"), []byte(billing), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "orders.allium.json"), []byte(orders), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Checked alone in workspace mode, the import cannot resolve.
+	code := run([]string{"--workspace", "--rules", "35", filepath.Join(dir, "orders.allium.json")})
+	if code != 1 {
+		t.Errorf("run(--workspace, unresolved import) = %d, want 1", code)
+	}
+
+	code = run([]string{"--root", dir, "--rules", "35"})
+	if code != 0 {
+		t.Errorf("run(--root) = %d, want 0", code)
+	}
+}
+
+func TestRunBundle(t *testing.T) {
+	dir := t.TempDir()
+	billing := `{"version": "1", "file": "billing.allium",
+  "entities": [{"name": "Invoice", "fields": [{"name": "total", "type": {"kind": "primitive", "value": "Integer"}}]}]}`
+	orders := `{"version": "1", "file": "orders.allium",
+  "use_declarations": [{"coordinate": "github.com/acme/billing@1.2.0", "alias": "billing"}],
+  "external_entities": [{"name": "Invoice", "fields": []}]}`
+	manifest := `{"specs": [
+  {"coordinate": "github.com/acme/billing@1.2.0", "path": "billing.allium.json"},
+  {"coordinate": "github.com/acme/orders@1.0.0", "path": "orders.allium.json"}]}`
+	for name, content := range map[string]string{"billing.allium.json": billing, "orders.allium.json": orders, "allium-bundle.json": manifest} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if code := run([]string{"--bundle", dir, "--rules", "35,59"}); code != 0 {
+		t.Errorf("run(--bundle) = %d, want 0", code)
+	}
+
+	// A member the manifest does not list is an error.
+	if err := os.WriteFile(filepath.Join(dir, "extra.allium.json"), []byte(`{"version": "1"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if code := run([]string{"--bundle", dir, "--rules", "59"}); code != 1 {
+		t.Errorf("run(--bundle, unlisted member) = %d, want 1", code)
+	}
+
+	for _, args := range [][]string{
+		{"--bundle", dir, refExample},
+		{"--bundle", dir, "--fix"},
+		{"--bundle", filepath.Join(dir, "billing.allium.json")},
+		{"--bundle", t.TempDir()},
+	} {
+		if code := run(args); code != 2 {
+			t.Errorf("run(%v) = %d, want 2", args, code)
+		}
+	}
+}
+
+func TestRunResolveFrom(t *testing.T) {
+	registry := t.TempDir()
+	billing := `{"version": "1", "file": "billing.allium",
+  "entities": [{"name": "Invoice", "fields": [{"name": "total", "type": {"kind": "primitive", "value": "Integer"}}]}]}`
+	if err := os.MkdirAll(filepath.Join(registry, "org.example"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(registry, "org.example", "billing@1.2.0.allium.json"), []byte(billing), 0644); err != nil {
+		t.Fatal(err)
+	}
+	orders := filepath.Join(t.TempDir(), "orders.allium.json")
+	if err := os.WriteFile(orders, []byte(`{"version": "1", "file": "orders.allium",
+  "use_declarations": [{"coordinate": "org.example:billing@1.2.0", "alias": "billing"}],
+  "external_entities": [{"name": "Invoice", "fields": []}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if code := run([]string{"--workspace", "--rules", "35", orders}); code != 1 {
+		t.Errorf("run(--workspace, unfetched import) = %d, want 1", code)
+	}
+	if code := run([]string{"--resolve-from", registry, "--rules", "35", orders}); code != 0 {
+		t.Errorf("run(--resolve-from) = %d, want 0", code)
+	}
+	for _, args := range [][]string{
+		{"--resolve-from", filepath.Join(registry, "missing"), orders},
+		{"--resolve-from", registry, "--bundle", registry},
+	} {
+		if code := run(args); code != 2 {
+			t.Errorf("run(%v) = %d, want 2", args, code)
+		}
+	}
+}
+
+func TestRunSARIFFormat(t *testing.T) {
+	code := run([]string{"--format", "sarif", "--schema-only", refExample, refExample})
+	if code != 0 {
+		t.Errorf("run(--format sarif --schema-only valid) = %d, want 0", code)
+	}
+}
+
+func TestRunCIFormats(t *testing.T) {
+	dir := t.TempDir()
+	for format, want := range map[string]string{
+		"github": "::warning file=" + filepath.ToSlash(refExample) + ",line=",
+		"gitlab": `"check_name": "WARN-16"`,
+	} {
+		out := filepath.Join(dir, format)
+		if code := run([]string{"--no-config", "--format", format, "--output", out, refExample}); code != 0 {
+			t.Errorf("run(--format %s) = %d, want 0", format, code)
+		}
+		data, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(data), want) {
+			t.Errorf("--format %s output does not contain %q:\n%.300s", format, want, data)
+		}
+	}
+}
+
+func TestRunHTMLFormat(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "report.html")
+	if code := run([]string{"--no-config", "--format", "html", "--output", out, refExample}); code != 0 {
+		t.Errorf("run(--format html) = %d, want 0", code)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"<!DOCTYPE html>", refExample, "WARN-16: Temporal trigger on optional field", "<pre>"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("HTML report does not contain %q:\n%.300s", want, data)
+		}
+	}
+}
+
+func TestRunOutput(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "report.json")
+	if code := run([]string{"--no-config", "--format", "json", "--output", out, refExample}); code != 0 {
+		t.Errorf("run(--output) = %d, want 0", code)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"file": "`+refExample+`"`) || !strings.Contains(string(data), `"WARN-16"`) {
+		t.Errorf("unexpected report in %s:\n%.300s", out, data)
+	}
+
+	for _, args := range [][]string{
+		{"--output", out, "--output-dir", t.TempDir(), refExample},
+		{"--output-dir", t.TempDir(), "--derived-order", refExample},
+		{"--output", filepath.Join(t.TempDir(), "missing", "report.txt"), refExample},
+	} {
+		if code := run(args); code != 2 {
+			t.Errorf("run(%v) = %d, want 2", args, code)
+		}
+	}
+}
+
+func TestRunOutputDir(t *testing.T) {
+	root := t.TempDir()
+	data, err := os.ReadFile(refExample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"auth.allium.json", filepath.Join("legacy", "auth.allium.json")} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Discovered specs keep their directory, so same-named specs do not clash.
+	// The reference example's import does not resolve in this workspace.
+	dir := t.TempDir()
+	if code := run([]string{"--no-config", "--rules", "warnings", "--format", "sarif", "--output-dir", dir, "--root", root}); code != 0 {
+		t.Errorf("run(--output-dir --root) = %d, want 0", code)
+	}
+	for _, name := range []string{"auth.report.sarif", filepath.Join("legacy", "auth.report.sarif")} {
+		if data, err := os.ReadFile(filepath.Join(dir, name)); err != nil || !strings.Contains(string(data), `"ruleId": "WARN-16"`) {
+			t.Errorf("expected a SARIF report in %s: %v", name, err)
+		}
+	}
+
+	// Named on the command line, they would.
+	args := []string{"--no-config", "--output-dir", t.TempDir(), filepath.Join(root, "auth.allium.json"), filepath.Join(root, "legacy", "auth.allium.json")}
+	if code := run(args); code != 2 {
+		t.Errorf("run(--output-dir, clashing names) = %d, want 2", code)
+	}
+
+	dir = t.TempDir()
+	if code := run([]string{"--no-config", "--quiet", "--output-dir", dir, refExample}); code != 0 {
+		t.Errorf("run(--output-dir) = %d, want 0", code)
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "password-auth.report.txt")); err != nil || !strings.Contains(string(data), "0 errors, 0 warnings") {
+		t.Errorf("expected a quiet text report: %v\n%s", err, data)
+	}
+}
+
+func TestRunAgainst(t *testing.T) {
+	data, err := os.ReadFile(refExample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = bytes.Replace(data, []byte(`"name": "password_hash"`), []byte(`"name": "secret_hash"`), 1)
+	changed := filepath.Join(t.TempDir(), "auth.allium.json")
+	if err := os.WriteFile(changed, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if code := run([]string{"--no-config", "--against", refExample, refExample}); code != 0 {
+		t.Errorf("run(--against same) = %d, want 0", code)
+	}
+	out := filepath.Join(t.TempDir(), "report.txt")
+	if code := run([]string{"--no-config", "--against", refExample, "--output", out, changed}); code != 1 {
+		t.Errorf("run(--against previous) = %d, want 1", code)
+	}
+	if report, _ := os.ReadFile(out); !strings.Contains(string(report), "[RULE-42] error: Breaking change from previous version: removed field User.password_hash") {
+		t.Errorf("expected RULE-42 in report:\n%s", report)
+	}
+
+	for _, args := range [][]string{
+		{"--against", "missing.allium.json", refExample},
+		{"--against", refExample, refExample, changed},
+		{"--against", refExample, "--workspace", refExample},
+	} {
+		if code := run(args); code != 2 {
+			t.Errorf("run(%v) = %d, want 2", args, code)
+		}
+	}
+}
+
+func TestRunAnnotate(t *testing.T) {
+	data, err := os.ReadFile(refExample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := filepath.Join(t.TempDir(), "password-auth.allium.json")
+	if err := os.WriteFile(spec, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	sidecar := annotate.SidecarPath(spec)
+
+	if code := run([]string{"--annotate", "--quiet", spec}); code != 0 {
+		t.Fatalf("run(--annotate) = %d, want 0", code)
+	}
+	f, err := annotate.Load(sidecar)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// --quiet hides the four warnings from output but not from the sidecar.
+	if len(f.Annotations) != 4 {
+		t.Fatalf("expected 4 annotations, got %+v", f.Annotations)
+	}
+
+	f.Annotations[0].Status = annotate.StatusAccepted
+	f.Annotations[0].Note = "reviewed"
+	if err := annotate.Save(sidecar, f); err != nil {
+		t.Fatal(err)
+	}
+	if code := run([]string{"--annotate", spec}); code != 0 {
+		t.Fatalf("second run(--annotate) = %d, want 0", code)
+	}
+	f2, err := annotate.Load(sidecar)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := f2.Lookup(f.Annotations[0].Fingerprint); a == nil || a.Status != annotate.StatusAccepted || a.Note != "reviewed" {
+		t.Errorf("review status lost across runs: %+v", a)
+	}
+}
+
+func TestRunAnnotatePartialRun(t *testing.T) {
+	for _, args := range [][]string{
+		{"--annotate", "--rules", "7", refExample},
+		{"--annotate", "--path", "$.rules", refExample},
+		{"--annotate", "--schema-only", refExample},
+	} {
+		if code := run(args); code != 2 {
+			t.Errorf("run(%v) = %d, want 2", args, code)
+		}
+	}
+}
+
+func TestRunConfigCritical(t *testing.T) {
+	dir := t.TempDir()
+	cfg := filepath.Join(dir, ".alliumcheck.json")
+	// The config lives beside a copy of the reference example, whose warnings
+	// become errors once the file is marked critical.
+	data, err := os.ReadFile(refExample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := filepath.Join(dir, "payments", "auth.allium.json")
+	if err := os.MkdirAll(filepath.Dir(spec), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(spec, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(cfg, []byte(`{"critical": ["payments/**"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if code := run([]string{"--config", cfg, spec}); code != 1 {
+		t.Errorf("run(--config, critical spec) = %d, want 1", code)
+	}
+	if code := run([]string{"--config", cfg, refExample}); code != 0 {
+		t.Errorf("run(--config, non-critical spec) = %d, want 0", code)
+	}
+
+	if err := os.WriteFile(cfg, []byte(`{"critical": "payments/**"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if code := run([]string{"--config", cfg, spec}); code != 2 {
+		t.Errorf("run(--config, invalid config) = %d, want 2", code)
+	}
+}
+
+func TestRunConfigDiscovery(t *testing.T) {
+	dir := t.TempDir()
+	data, err := os.ReadFile(refExample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := filepath.Join(dir, "specs", "auth.allium.json")
+	if err := os.MkdirAll(filepath.Dir(spec), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(spec, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	// The config above the spec raises one of its warnings to an error.
+	if err := os.WriteFile(filepath.Join(dir, ".alliumcheck.json"), []byte(`{"severity": {"WARN-16": "error"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	empty := filepath.Join(t.TempDir(), "empty.json")
+	if err := os.WriteFile(empty, []byte(`{}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if code := run([]string{spec}); code != 1 {
+		t.Errorf("run(discovered config) = %d, want 1", code)
+	}
+	if code := run([]string{"--no-config", spec}); code != 0 {
+		t.Errorf("run(--no-config) = %d, want 0", code)
+	}
+	if code := run([]string{"--config", empty, spec}); code != 0 {
+		t.Errorf("run(--config overriding discovery) = %d, want 0", code)
+	}
+	if code := run([]string{"--config", empty, "--no-config", spec}); code != 2 {
+		t.Errorf("run(--config --no-config) = %d, want 2", code)
+	}
+}
+
+func TestRunListRules(t *testing.T) {
+	for _, format := range []string{"text", "json"} {
+		if code := run([]string{"--list-rules", "--format", format}); code != 0 {
+			t.Errorf("run(--list-rules --format %s) = %d, want 0", format, code)
+		}
+	}
+	if code := run([]string{"--list-rules", "--format", "sarif"}); code != 2 {
+		t.Errorf("run(--list-rules --format sarif) = %d, want 2", code)
+	}
+}
+
+func TestRunExplain(t *testing.T) {
+	for _, args := range [][]string{{"RULE-12"}, {"12", "warn-5"}, {"--format", "json", "statemachine"}} {
+		if code := run(append([]string{"explain"}, args...)); code != 0 {
+			t.Errorf("run(explain %q) = %d, want 0", args, code)
+		}
+	}
+	for _, args := range [][]string{{}, {"RULE-99"}, {"nonsense"}, {"--format", "sarif", "RULE-12"}} {
+		if code := run(append([]string{"explain"}, args...)); code != 2 {
+			t.Errorf("run(explain %q) = %d, want 2", args, code)
+		}
+	}
+
+	r, _ := checker.LookupRule("RULE-55")
+	text := explainRule(r)
+	for _, want := range []string{
+		"RULE-55: Optional value read without a null check\n",
+		"Why it matters:\n  An optional value may be absent",
+		"Invalid:\n  requires: order.coupon.discount > 0",
+		"Valid:\n  requires: exists order.coupon",
+		`{"rule": "RULE-55", "path": "<its path>"`,
+		"--rules all,-RULE-55",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("explanation lacks %q:\n%s", want, text)
+		}
+	}
+	r, _ = checker.LookupRule("RULE-02")
+	if text := explainRule(r); !strings.Contains(text, "reported as a SCHEMA") || strings.Contains(text, "suppressions") {
+		t.Errorf("structural rule explanation:\n%s", text)
+	}
+}
+
+func TestRunExplainFlag(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "report.txt")
+	if code := run([]string{"--no-config", "--explain", "--output", out, refExample}); code != 0 {
+		t.Errorf("run(--explain) = %d, want 0", code)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "Unused given binding 'email_service' at $.given[0] (line 15, column 5)\n    why: A parameter or binding that nothing reads") {
+		t.Errorf("expected WARN-31 to be explained under its finding:\n%s", data)
+	}
+	if code := run([]string{"--no-config", "--explain", "--format", "json", refExample}); code != 2 {
+		t.Errorf("run(--explain --format json) = %d, want 2", code)
+	}
+}
+
+func TestRunImportGraph(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"billing.allium.json": `{"version": "1", "file": "billing.allium",
+  "entities": [{"name": "Invoice", "fields": [{"name": "total", "type": {"kind": "primitive", "value": "Integer"}}]}]}`,
+		"orders.allium.json": `{"version": "1", "file": "orders.allium",
+  "use_declarations": [{"coordinate": "./billing.allium", "alias": "billing"}],
+  "external_entities": [{"name": "Invoice", "fields": []}]}`,
+		".alliumcheck.json": `{"layers": {"core": ["billing.allium.json"], "feature": ["orders.allium.json"]},
+  "layering": [{"from": "core", "must_not_import": ["feature"]}]}`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := filepath.Join(dir, ".alliumcheck.json")
+
+	for _, format := range []string{"dot", "json"} {
+		if code := run([]string{"--import-graph", format, "--config", cfg, "--root", dir}); code != 0 {
+			t.Errorf("run(--import-graph %s) = %d, want 0", format, code)
+		}
+	}
+	if code := run([]string{"--import-graph", "svg", "--root", dir}); code != 2 {
+		t.Errorf("run(--import-graph svg) = %d, want 2", code)
+	}
+
+	// Reversing the rule makes the orders -> billing import a violation.
+	if err := os.WriteFile(cfg, []byte(`{"layers": {"core": ["billing.allium.json"], "feature": ["orders.allium.json"]},
+  "layering": [{"from": "feature", "must_not_import": ["core"]}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if code := run([]string{"--config", cfg, "--root", dir}); code != 1 {
+		t.Errorf("run(--root, layering violation) = %d, want 1", code)
+	}
+}
+
+func TestRunDerivedOrder(t *testing.T) {
+	if code := run([]string{"--derived-order", refExample}); code != 0 {
+		t.Errorf("run(--derived-order) = %d, want 0", code)
+	}
+	if code := run([]string{"--derived-order", "--import-graph", "json", refExample}); code != 2 {
+		t.Errorf("run(--derived-order --import-graph) = %d, want 2", code)
+	}
+	if code := run([]string{"--derived-order", "nonexistent.allium.json"}); code != 2 {
+		t.Errorf("run(--derived-order missing file) = %d, want 2", code)
+	}
+}
+
+// withStdin runs f with standard input reading the file at path.
+func withStdin(t *testing.T, path string, f func()) {
+	t.Helper()
+	in, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	saved := os.Stdin
+	os.Stdin = in
+	defer func() { os.Stdin = saved }()
+	f()
+}
+
+func TestRunStdin(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "report.json")
+	withStdin(t, refExample, func() {
+		if code := run([]string{"--no-config", "--format", "json", "--output", out, "-"}); code != 0 {
+			t.Errorf("run(-) = %d, want 0", code)
+		}
+	})
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"file": "\u003cstdin\u003e"`) || !strings.Contains(string(data), `"WARN-16"`) {
+		t.Errorf("unexpected report:\n%.300s", data)
+	}
+
+	withStdin(t, refExample, func() {
+		args := []string{"--no-config", "--format", "json", "--output", out, "--stdin", "--stdin-filename", "specs/auth.allium.json"}
+		if code := run(args); code != 0 {
+			t.Errorf("run(--stdin --stdin-filename) = %d, want 0", code)
+		}
+	})
+	data, err = os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"file": "specs/auth.allium.json"`) || !strings.Contains(string(data), `"line"`) {
+		t.Errorf("unexpected report:\n%.300s", data)
+	}
+
+	// The spec is read once, so views that re-read inputs see its content.
+	withStdin(t, refExample, func() {
+		args := []string{"--derived-order", "--output", out, "--stdin-filename", "nonexistent.allium.json", "-"}
+		if code := run(args); code != 0 {
+			t.Errorf("run(--derived-order -) = %d, want 0", code)
+		}
+	})
+	data, err = os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"file": "nonexistent.allium.json"`) {
+		t.Errorf("unexpected derived order:\n%.300s", data)
+	}
+
+	for _, args := range [][]string{
+		{"-", "-"},
+		{"--workspace", "-"},
+		{"--annotate", "-"},
+		{"--stdin-filename", "auth.allium.json", refExample},
+	} {
+		withStdin(t, refExample, func() {
+			if code := run(args); code != 2 {
+				t.Errorf("run(%v) = %d, want 2", args, code)
+			}
+		})
+	}
+}
+
+func TestRunTemplate(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := filepath.Join(dir, "template.json")
+	if err := os.WriteFile(tmpl, []byte(`{"required": {"actors": ["Admin"]}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if code := run([]string{"--no-config", "--template", tmpl, refExample}); code != 1 {
+		t.Errorf("run(--template) = %d, want 1", code)
+	}
+
+	bad := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(bad, []byte(`{"required_sections": ["entity"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if code := run([]string{"--template", bad, refExample}); code != 2 {
+		t.Errorf("run(--template invalid) = %d, want 2", code)
+	}
+}
+
+func TestRunRelationshipMetrics(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "metrics.json")
+	if code := run([]string{"--no-config", "--relationship-metrics", "--output", out, refExample}); code != 0 {
+		t.Errorf("run(--relationship-metrics) = %d, want 0", code)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"name": "User"`) || !strings.Contains(string(data), `"fan_out"`) {
+		t.Errorf("unexpected metrics:\n%.300s", data)
+	}
+	if code := run([]string{"--relationship-metrics", "--derived-order", refExample}); code != 2 {
+		t.Errorf("run(--relationship-metrics --derived-order) = %d, want 2", code)
+	}
+}
+
+func TestRunCoverage(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "coverage.json")
+	if code := run([]string{"--no-config", "--coverage", "--output", out, refExample}); code != 0 {
+		t.Errorf("run(--coverage) = %d, want 0", code)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The reference example's guarantees are descriptive, so none is covered.
+	if !strings.Contains(string(data), `"name": "NoSessionRequired"`) || !strings.Contains(string(data), `"uncovered": 2`) {
+		t.Errorf("unexpected coverage:\n%.300s", data)
+	}
+	if code := run([]string{"--coverage", "--relationship-metrics", refExample}); code != 2 {
+		t.Errorf("run(--coverage --relationship-metrics) = %d, want 2", code)
+	}
+}
+
+func TestRunCapabilities(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "capabilities.json")
+	if code := run([]string{"--no-config", "--capabilities", "json", "--output", out, refExample}); code != 0 {
+		t.Errorf("run(--capabilities json) = %d, want 0", code)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files []struct {
+		Actors []semantic.ActorCapabilities `json:"actors"`
+	}
+	if err := json.Unmarshal(data, &files); err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || len(files[0].Actors) != 2 || !slices.Contains(files[0].Actors[1].Writes, "User.status") {
+		t.Errorf("unexpected capabilities:\n%.300s", data)
+	}
+
+	out = filepath.Join(dir, "capabilities.csv")
+	if code := run([]string{"--no-config", "--capabilities", "csv", "--output", out, refExample}); code != 0 {
+		t.Errorf("run(--capabilities csv) = %d, want 0", code)
+	}
+	data, err = os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(rows[0], []string{"file", "actor", "surface", "trigger", "access", "target"}) {
+		t.Errorf("header = %q", rows[0])
+	}
+	want := []string{refExample, "Visitor", "Authentication", "UserLogsIn", "write", "User.status"}
+	if !slices.ContainsFunc(rows, func(row []string) bool { return slices.Equal(row, want) }) {
+		t.Errorf("expected row %q in:\n%q", want, rows)
 	}
-	for i := range a {
-		if a[i] != b[i] {
-			return false
+
+	if code := run([]string{"--capabilities", "xml", refExample}); code != 2 {
+		t.Errorf("run(--capabilities xml) = %d, want 2", code)
+	}
+	if code := run([]string{"--capabilities", "json", "--coverage", refExample}); code != 2 {
+		t.Errorf("run(--capabilities --coverage) = %d, want 2", code)
+	}
+}
+
+func TestRunEmitStateMachines(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "machines.json")
+	if code := run([]string{"--no-config", "--emit-state-machines", out, "--output", filepath.Join(dir, "report.txt"), refExample}); code != 0 {
+		t.Errorf("run(--emit-state-machines) = %d, want 0", code)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var files []struct {
+		StateMachines []semantic.StateMachine `json:"state_machines"`
+	}
+	if err := json.Unmarshal(data, &files); err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || len(files[0].StateMachines) == 0 {
+		t.Fatalf("unexpected state machines:\n%.300s", data)
+	}
+	user := files[0].StateMachines[0]
+	if user.Entity != "User" || !slices.ContainsFunc(user.Transitions, func(tr semantic.Transition) bool {
+		return tr.From == "active" && tr.To == "locked" && slices.Equal(tr.Rules, []string{"LoginFailure"})
+	}) {
+		t.Errorf("expected LoginFailure to lock active users, got %+v", user)
+	}
+
+	if code := run([]string{"--emit-state-machines", filepath.Join(dir, "missing", "machines.json"), refExample}); code != 2 {
+		t.Errorf("run(--emit-state-machines into a missing directory) = %d, want 2", code)
+	}
+}
+
+func TestRunFunctionManifest(t *testing.T) {
+	dir := t.TempDir()
+	manifest := filepath.Join(dir, "functions.json")
+	// The reference example calls verify(password, user.password_hash);
+	// redeclaring verify with one parameter makes that call invalid.
+	if err := os.WriteFile(manifest, []byte(`{"functions": [{"name": "verify", "parameters": ["String"], "returns": "Boolean"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if code := run([]string{"--functions", manifest, refExample}); code != 1 {
+		t.Errorf("run(--functions, mismatched verify) = %d, want 1", code)
+	}
+
+	if err := os.WriteFile(manifest, []byte(`{"functions": [{"name": "verify", "parameters": ["Text"]}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if code := run([]string{"--functions", manifest, refExample}); code != 2 {
+		t.Errorf("run(--functions, invalid manifest) = %d, want 2", code)
+	}
+}
+
+func TestPrintTimings(t *testing.T) {
+	a, b := report.NewReport("a.allium.json"), report.NewReport("b.allium.json")
+	a.AddTiming("schema", 3*time.Millisecond)
+	a.AddTiming("references", time.Millisecond)
+	b.AddTiming("schema", 4*time.Millisecond)
+	b.AddTiming("warnings", 2*time.Millisecond)
+	b.AddFinding(report.NewWarning("WARN-04", "unused", report.Location{}))
+
+	var progress bytes.Buffer
+	printProgress(&progress, 1, 2, b)
+	if want := "[2/2] b.allium.json: 0 errors, 1 warnings in 6ms\n"; progress.String() != want {
+		t.Errorf("progress = %q, want %q", progress.String(), want)
+	}
+
+	var out bytes.Buffer
+	printTimings(&out, []*report.Report{a, b})
+	want := `
+Timings for 2 files, 10ms in total:
+  phase                        time   share
+  schema                        7ms   70.0%
+  references                    1ms   10.0%
+  warnings                      2ms   20.0%
+`
+	if out.String() != want {
+		t.Errorf("timings =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestIsTerminal(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "out.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if isTerminal(f) {
+		t.Error("a regular file is not a terminal")
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	if isTerminal(w) {
+		t.Error("a pipe is not a terminal")
+	}
+}
+
+func TestSplitCommand(t *testing.T) {
+	got, err := splitCommand(`notify --title "Allium {rule}" '{message}'  x`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"notify", "--title", "Allium {rule}", "{message}", "x"}; !slices.Equal(got, want) {
+		t.Errorf("splitCommand = %q, want %q", got, want)
+	}
+	for _, s := range []string{"", "  ", `notify "open`} {
+		if _, err := splitCommand(s); err == nil {
+			t.Errorf("splitCommand(%q) succeeded", s)
+		}
+	}
+}
+
+func TestRunExecHooks(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh")
+	}
+	dir := t.TempDir()
+	log := filepath.Join(dir, "findings.log")
+	perFinding := `sh -c 'echo "$1 $2 $3" >> ` + log + `' hook {rule} {severity} {path}`
+	perFile := `sh -c 'cat > ` + filepath.Join(dir, "report.json") + `'`
+	if code := run([]string{"--no-config", "--quiet", "--exec-per-finding", perFinding, "--exec-per-file", perFile, refExample}); code != 0 {
+		t.Errorf("run(--exec-per-finding --quiet) = %d, want 0", code)
+	}
+	if _, err := os.Stat(log); !os.IsNotExist(err) {
+		t.Errorf("--quiet should run no per-finding command for warnings, stat: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "report.json"))
+	if err != nil || !strings.Contains(string(data), `"file": "`+refExample+`"`) {
+		t.Errorf("unexpected per-file input (%v):\n%.300s", err, data)
+	}
+
+	if code := run([]string{"--no-config", "--rules", "WARN-16", "--exec-per-finding", perFinding, refExample}); code != 0 {
+		t.Errorf("run(--exec-per-finding) = %d, want 0", code)
+	}
+	data, err = os.ReadFile(log)
+	if err != nil || !strings.HasPrefix(string(data), "WARN-16 warning $.") || strings.Count(string(data), "\n") != 1 {
+		t.Errorf("unexpected per-finding log (%v):\n%s", err, data)
+	}
+
+	for _, args := range [][]string{
+		{"--exec-per-finding", `notify "{rule}`, refExample},
+		{"--no-config", "--exec-per-file", "sh -c 'exit 3'", refExample},
+	} {
+		if code := run(args); code != 2 {
+			t.Errorf("run(%v) = %d, want 2", args, code)
+		}
+	}
+}
+
+func TestRunFix(t *testing.T) {
+	data, err := os.ReadFile(refExample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := "            \"values\": [\"active\", \"locked\", \"deactivated\"]\n          }\n        },\n"
+	if !bytes.Contains(data, []byte(status)) {
+		t.Fatal("reference example has changed")
+	}
+	// A second field with the same inline enum as User.status (WARN-19).
+	data = bytes.Replace(data, []byte(status), []byte(status+
+		"        {\n          \"name\": \"previous_status\",\n"+
+		"          \"type\": { \"kind\": \"inline_enum\", \"values\": [\"locked\", \"active\", \"deactivated\"] }\n        },\n"), 1)
+	// Register and the system_user default must set it (RULE-45, RULE-47).
+	attempts := "\"failed_login_attempts\": { \"kind\": \"literal\", \"type\": \"integer\", \"value\": 0 }"
+	data = bytes.ReplaceAll(data, []byte(attempts), []byte(
+		"\"previous_status\": { \"kind\": \"literal\", \"type\": \"enum_value\", \"value\": \"active\" }, "+attempts))
+	spec := filepath.Join(t.TempDir(), "auth.allium.json")
+	if err := os.WriteFile(spec, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(t.TempDir(), "fix.diff")
+	if code := run([]string{"--no-config", "--fix-dry-run", "--output", out, spec}); code != 0 {
+		t.Errorf("run(--fix-dry-run) = %d, want 0", code)
+	}
+	diff, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"--- a/" + spec + "\n",
+		"+      \"name\": \"UserStatus\",\n",
+		"-          \"type\": { \"kind\": \"inline_enum\", \"values\": [\"locked\", \"active\", \"deactivated\"] }\n" +
+			"+          \"type\": { \"kind\": \"named_enum\", \"name\": \"UserStatus\" }\n",
+	} {
+		if !strings.Contains(string(diff), want) {
+			t.Errorf("diff missing %q:\n%s", want, diff)
+		}
+	}
+	if unchanged, _ := os.ReadFile(spec); !bytes.Equal(unchanged, data) {
+		t.Error("--fix-dry-run changed the spec")
+	}
+
+	if code := run([]string{"--no-config", "--fix", "--format", "json", "--output", out, spec}); code != 0 {
+		t.Errorf("run(--fix) = %d, want 0", code)
+	}
+	fixed, err := os.ReadFile(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(fixed), `"type": { "kind": "named_enum", "name": "UserStatus" }`) {
+		t.Errorf("unexpected fixed spec:\n%s", fixed)
+	}
+	if report, _ := os.ReadFile(out); strings.Contains(string(report), "WARN-19") {
+		t.Errorf("the report should cover the fixed spec:\n%s", report)
+	}
+
+	for _, args := range [][]string{
+		{"--fix", "--fix-dry-run", spec},
+		{"--fix", "--workspace", spec},
+		{"--fix", "-"},
+		{"--fix-dry-run", "--derived-order", spec},
+	} {
+		if code := run(args); code != 2 {
+			t.Errorf("run(%v) = %d, want 2", args, code)
 		}
 	}
-	return true
 }
@@ -0,0 +1,138 @@
+// Command allium-diff compares two versions of an Allium specification file
+// (.allium.json) and reports the entities, fields, enumeration values,
+// rules, triggers and surfaces that were added, removed or changed, split
+// into changes that break consumers of the old version and those that do not.
+//
+// Usage:
+//
+//	allium-diff [--format text|json] [--breaking] old.allium.json new.allium.json
+//
+// Exit codes:
+//
+//	0  No breaking changes
+//	1  One or more breaking changes
+//	2  Bad flags, or a file could not be read or parsed
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/diff"
+)
+
+const version = "0.1.0"
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout))
+}
+
+// result is the JSON output of allium-diff.
+type result struct {
+	Old     string        `json:"old"`
+	New     string        `json:"new"`
+	Changes []diff.Change `json:"changes"`
+	Summary summary       `json:"summary"`
+}
+
+type summary struct {
+	Changes  int `json:"changes"`
+	Breaking int `json:"breaking"`
+}
+
+func run(args []string, out io.Writer) int {
+	fs := flag.NewFlagSet("allium-diff", flag.ContinueOnError)
+
+	format := fs.String("format", "text", "Output format: text or json")
+	breakingOnly := fs.Bool("breaking", false, "Only report breaking changes")
+	showVersion := fs.Bool("version", false, "Print version and exit")
+
+	if err := fs.Parse(args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	if *showVersion {
+		fmt.Fprintf(out, "allium-diff %s\n", version)
+		return 0
+	}
+
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q (use text or json)\n", *format)
+		return 2
+	}
+	if fs.NArg() != 2 {
+		fmt.Fprintln(os.Stderr, "Error: expected an old and a new .allium.json file")
+		return 2
+	}
+
+	old, err := ast.LoadSpec(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	new, err := ast.LoadSpec(fs.Arg(1))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	changes := diff.Compare(old, new)
+	breaking := diff.Breaking(changes)
+	if *breakingOnly {
+		changes = breaking
+	}
+
+	if *format == "json" {
+		r := result{Old: fs.Arg(0), New: fs.Arg(1), Changes: changes,
+			Summary: summary{Changes: len(changes), Breaking: len(breaking)}}
+		if r.Changes == nil {
+			r.Changes = []diff.Change{}
+		}
+		data, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		fmt.Fprintln(out, string(data))
+	} else {
+		writeText(out, changes, len(breaking))
+	}
+
+	if len(breaking) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// writeText lists the breaking changes, then the others, then a summary.
+func writeText(out io.Writer, changes []diff.Change, breaking int) {
+	for _, group := range []struct {
+		title    string
+		breaking bool
+	}{{"Breaking changes:", true}, {"Non-breaking changes:", false}} {
+		var lines []string
+		for _, c := range changes {
+			if c.Breaking == group.breaking {
+				lines = append(lines, "  "+c.String())
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		fmt.Fprintln(out, group.title)
+		for _, l := range lines {
+			fmt.Fprintln(out, l)
+		}
+		fmt.Fprintln(out)
+	}
+	if len(changes) == 0 {
+		fmt.Fprintln(out, "No changes")
+		return
+	}
+	fmt.Fprintf(out, "%d changes, %d breaking\n", len(changes), breaking)
+}
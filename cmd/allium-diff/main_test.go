@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var refExample = filepath.Join("..", "..", "schemas", "v1", "examples", "password-auth.allium.json")
+
+// writeChangedExample writes the reference example with the User entity's
+// password_hash field removed and a nickname field added, and returns its
+// path.
+func writeChangedExample(t *testing.T) string {
+	t.Helper()
+	data, err := os.ReadFile(refExample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = bytes.Replace(data, []byte(`"name": "password_hash"`), []byte(`"name": "nickname"`), 1)
+	path := filepath.Join(t.TempDir(), "auth.allium.json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunVersion(t *testing.T) {
+	var out bytes.Buffer
+	if code := run([]string{"--version"}, &out); code != 0 {
+		t.Errorf("run(--version) = %d, want 0", code)
+	}
+	if !strings.Contains(out.String(), "allium-diff "+version) {
+		t.Errorf("unexpected version output %q", out.String())
+	}
+}
+
+func TestRunBadArguments(t *testing.T) {
+	for _, args := range [][]string{
+		{"--nope", refExample, refExample},
+		{"--format", "sarif", refExample, refExample},
+		{refExample},
+		{refExample, refExample, refExample},
+		{refExample, "missing.allium.json"},
+	} {
+		if code := run(args, &bytes.Buffer{}); code != 2 {
+			t.Errorf("run(%v) = %d, want 2", args, code)
+		}
+	}
+}
+
+func TestRunNoChanges(t *testing.T) {
+	var out bytes.Buffer
+	if code := run([]string{refExample, refExample}, &out); code != 0 {
+		t.Errorf("run(same, same) = %d, want 0", code)
+	}
+	if out.String() != "No changes\n" {
+		t.Errorf("unexpected output %q", out.String())
+	}
+}
+
+func TestRunText(t *testing.T) {
+	changed := writeChangedExample(t)
+
+	var out bytes.Buffer
+	if code := run([]string{refExample, changed}, &out); code != 1 {
+		t.Errorf("run(old, new) = %d, want 1 for a breaking change", code)
+	}
+	want := "Breaking changes:\n  removed field User.password_hash at $.entities[0].fields[1]\n\n" +
+		"Non-breaking changes:\n  added field User.nickname at $.entities[0].fields[1]\n\n" +
+		"2 changes, 1 breaking\n"
+	if out.String() != want {
+		t.Errorf("output:\n%s\nwant:\n%s", out.String(), want)
+	}
+
+	// The reverse is breaking too; --breaking drops the addition.
+	out.Reset()
+	if code := run([]string{"--breaking", changed, refExample}, &out); code != 1 {
+		t.Errorf("run(--breaking new, old) = %d, want 1", code)
+	}
+	if strings.Contains(out.String(), "Non-breaking") || !strings.Contains(out.String(), "removed field User.nickname") {
+		t.Errorf("unexpected --breaking output:\n%s", out.String())
+	}
+}
+
+func TestRunJSON(t *testing.T) {
+	changed := writeChangedExample(t)
+
+	var out bytes.Buffer
+	run([]string{"--format", "json", refExample, changed}, &out)
+	var r result
+	if err := json.Unmarshal(out.Bytes(), &r); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, out.String())
+	}
+	if r.Old != refExample || r.New != changed || r.Summary != (summary{Changes: 2, Breaking: 1}) || len(r.Changes) != 2 {
+		t.Errorf("unexpected result %+v", r)
+	}
+	if c := r.Changes[0]; c.Kind != "removed" || c.Element != "field" || c.Name != "User.password_hash" || !c.Breaking {
+		t.Errorf("unexpected first change %+v", c)
+	}
+
+	out.Reset()
+	run([]string{"--format", "json", refExample, refExample}, &out)
+	if !strings.Contains(out.String(), `"changes": []`) {
+		t.Errorf("expected an empty changes array, got:\n%s", out.String())
+	}
+}
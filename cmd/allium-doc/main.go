@@ -0,0 +1,72 @@
+// Command allium-doc generates reference documentation for an Allium
+// specification file (.allium.json): a table of fields for each entity and
+// other type, a summary of each rule's trigger, requires and ensures
+// clauses, the contract of each surface, and a state diagram of each entity's
+// status field.
+//
+// Usage:
+//
+//	allium-doc [--format markdown|html] file.allium.json
+//
+// Exit codes:
+//
+//	0  The documentation was written to stdout
+//	2  Bad flags, or the file could not be read or parsed
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/docgen"
+	"github.com/foundry-zero/allium/internal/semantic"
+)
+
+const version = "0.1.0"
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout))
+}
+
+func run(args []string, out io.Writer) int {
+	fs := flag.NewFlagSet("allium-doc", flag.ContinueOnError)
+
+	format := fs.String("format", "markdown", "Output format: markdown or html")
+	showVersion := fs.Bool("version", false, "Print version and exit")
+
+	if err := fs.Parse(args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	if *showVersion {
+		fmt.Fprintf(out, "allium-doc %s\n", version)
+		return 0
+	}
+
+	if *format != "markdown" && *format != "html" {
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q (use markdown or html)\n", *format)
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Error: expected exactly one .allium.json file")
+		return 2
+	}
+
+	spec, err := ast.LoadSpec(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	doc := docgen.Build(spec, semantic.BuildSymbolTable(spec))
+
+	if *format == "html" {
+		io.WriteString(out, doc.HTML())
+	} else {
+		io.WriteString(out, doc.Markdown())
+	}
+	return 0
+}
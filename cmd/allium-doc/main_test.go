@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var refExample = filepath.Join("..", "..", "schemas", "v1", "examples", "password-auth.allium.json")
+
+func TestRunVersion(t *testing.T) {
+	var out bytes.Buffer
+	if code := run([]string{"--version"}, &out); code != 0 {
+		t.Errorf("run(--version) = %d, want 0", code)
+	}
+	if !strings.Contains(out.String(), "allium-doc "+version) {
+		t.Errorf("unexpected version output %q", out.String())
+	}
+}
+
+func TestRunBadArguments(t *testing.T) {
+	for _, args := range [][]string{
+		{"--nope", refExample},
+		{"--format", "pdf", refExample},
+		{},
+		{refExample, refExample},
+		{"missing.allium.json"},
+	} {
+		if code := run(args, &bytes.Buffer{}); code != 2 {
+			t.Errorf("run(%v) = %d, want 2", args, code)
+		}
+	}
+}
+
+func TestRunFormats(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{refExample}, "# password-auth.allium\n"},
+		{[]string{"--format", "markdown", refExample}, "# password-auth.allium\n"},
+		{[]string{"--format", "html", refExample}, "<!DOCTYPE html>\n"},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		if code := run(tt.args, &out); code != 0 {
+			t.Errorf("run(%v) = %d, want 0", tt.args, code)
+		}
+		if !strings.HasPrefix(out.String(), tt.want) {
+			t.Errorf("run(%v) output does not start with %q:\n%.200s", tt.args, tt.want, out.String())
+		}
+	}
+}
@@ -0,0 +1,163 @@
+// Command allium-gen generates code from an Allium specification file
+// (.allium.json) for the systems that implement it or exchange its data.
+// With --lang go it writes Go types: a struct for each entity, external
+// entity, value type and variant, a string type with constants for each
+// enumeration, and a payload struct for each trigger, with JSON tags
+// carrying the spec's names. With --openapi it writes an OpenAPI 3.1
+// document mapping each surface to a group of paths: a GET operation
+// returning what the surface exposes and a POST operation per action it
+// provides, taking the trigger's arguments as its request body. With
+// --jsonschema it writes a JSON Schema file per declared type into the
+// directory given by -o, for validating the payloads other systems exchange.
+//
+// Usage:
+//
+//	allium-gen --lang go [--package name] [-o out.go] file.allium.json
+//	allium-gen --openapi [-o openapi.json] file.allium.json
+//	allium-gen --jsonschema -o dir file.allium.json
+//
+// Exit codes:
+//
+//	0  The code was written to stdout or the output file or directory
+//	2  Bad flags, or the file could not be read, parsed or written
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/token"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/codegen"
+	"github.com/foundry-zero/allium/internal/semantic"
+)
+
+const version = "0.1.0"
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout))
+}
+
+func run(args []string, out io.Writer) int {
+	fs := flag.NewFlagSet("allium-gen", flag.ContinueOnError)
+
+	lang := fs.String("lang", "", "Target language: go")
+	openAPI := fs.Bool("openapi", false, "Generate an OpenAPI document for the spec's surfaces")
+	jsonSchema := fs.Bool("jsonschema", false, "Generate a JSON Schema per declared type into the -o directory")
+	pkg := fs.String("package", "", "Go package `name` (default: derived from the spec file name)")
+	output := fs.String("o", "", "Write to `file` instead of stdout; with --jsonschema, the directory to write to")
+	showVersion := fs.Bool("version", false, "Print version and exit")
+
+	if err := fs.Parse(args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	if *showVersion {
+		fmt.Fprintf(out, "allium-gen %s\n", version)
+		return 0
+	}
+
+	modes := 0
+	for _, on := range []bool{*lang != "", *openAPI, *jsonSchema} {
+		if on {
+			modes++
+		}
+	}
+	switch {
+	case modes > 1:
+		fmt.Fprintln(os.Stderr, "Error: --lang, --openapi and --jsonschema are mutually exclusive")
+		return 2
+	case modes == 0 || *lang != "" && *lang != "go":
+		fmt.Fprintf(os.Stderr, "Error: unknown language %q (use go, or --openapi or --jsonschema)\n", *lang)
+		return 2
+	case *jsonSchema && *output == "":
+		fmt.Fprintln(os.Stderr, "Error: --jsonschema needs an output directory (-o dir)")
+		return 2
+	}
+	if *pkg != "" && !token.IsIdentifier(*pkg) {
+		fmt.Fprintf(os.Stderr, "Error: invalid package name %q\n", *pkg)
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Error: expected exactly one .allium.json file")
+		return 2
+	}
+
+	spec, err := ast.LoadSpec(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	st := semantic.BuildSymbolTable(spec)
+	if *jsonSchema {
+		return writeJSONSchemas(spec, st, *output)
+	}
+	var src []byte
+	if *openAPI {
+		src, err = codegen.OpenAPI(spec, st)
+	} else {
+		if *pkg == "" {
+			*pkg = packageName(fs.Arg(0))
+		}
+		src, err = codegen.Go(spec, st, *pkg)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	if *output == "" {
+		out.Write(src)
+		return 0
+	}
+	if err := os.WriteFile(*output, src, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	return 0
+}
+
+// writeJSONSchemas writes the JSON Schema file of each type of spec into
+// dir, creating it if needed.
+func writeJSONSchemas(spec *ast.Spec, st *semantic.SymbolTable, dir string) int {
+	files, err := codegen.JSONSchemas(spec, st)
+	if err == nil {
+		err = os.MkdirAll(dir, 0755)
+	}
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		if err != nil {
+			break
+		}
+		err = os.WriteFile(filepath.Join(dir, name), files[name], 0644)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	return 0
+}
+
+// packageName derives a Go package name from a spec file name, keeping the
+// lower-cased letters and digits of its base: "password-auth.allium.json"
+// gives "passwordauth". It falls back to "spec".
+func packageName(path string) string {
+	base := strings.TrimSuffix(filepath.Base(path), ".allium.json")
+	var b strings.Builder
+	for _, r := range strings.ToLower(base) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) && b.Len() > 0) {
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() == 0 || token.IsKeyword(b.String()) {
+		return "spec"
+	}
+	return b.String()
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var refExample = filepath.Join("..", "..", "schemas", "v1", "examples", "password-auth.allium.json")
+
+func TestRunVersion(t *testing.T) {
+	var out bytes.Buffer
+	if code := run([]string{"--version"}, &out); code != 0 {
+		t.Errorf("run(--version) = %d, want 0", code)
+	}
+	if !strings.Contains(out.String(), "allium-gen "+version) {
+		t.Errorf("unexpected version output %q", out.String())
+	}
+}
+
+func TestRunGo(t *testing.T) {
+	var out bytes.Buffer
+	if code := run([]string{"--lang", "go", refExample}, &out); code != 0 {
+		t.Fatalf("run = %d, want 0", code)
+	}
+	for _, want := range []string{"package passwordauth", "type User struct {", "type UserLogsIn struct {"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output lacks %q", want)
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "auth.go")
+	if code := run([]string{"--lang", "go", "--package", "auth", "-o", path, refExample}, &bytes.Buffer{}); code != 0 {
+		t.Fatalf("run -o = %d, want 0", code)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte("package auth\n")) {
+		t.Errorf("written file lacks the package clause:\n%s", data)
+	}
+}
+
+func TestRunOpenAPI(t *testing.T) {
+	var out bytes.Buffer
+	if code := run([]string{"--openapi", refExample}, &out); code != 0 {
+		t.Fatalf("run(--openapi) = %d, want 0", code)
+	}
+	for _, want := range []string{`"openapi": "3.1.0"`, `"/authentication/user-logs-in"`} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output lacks %s", want)
+		}
+	}
+}
+
+func TestRunJSONSchema(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "schemas")
+	if code := run([]string{"--jsonschema", "-o", dir, refExample}, &bytes.Buffer{}); code != 0 {
+		t.Fatalf("run(--jsonschema) = %d, want 0", code)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "Session.schema.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte(`"$ref": "User.schema.json"`)) {
+		t.Errorf("Session schema lacks the user reference:\n%s", data)
+	}
+}
+
+func TestRunBadArguments(t *testing.T) {
+	for _, args := range [][]string{
+		{"--nope", refExample},
+		{refExample},
+		{"--lang", "rust", refExample},
+		{"--openapi", "--lang", "go", refExample},
+		{"--openapi", "--jsonschema", "-o", "out", refExample},
+		{"--jsonschema", refExample},
+		{"--lang", "go", "--package", "not-valid", refExample},
+		{"--lang", "go"},
+		{"--lang", "go", "missing.allium.json"},
+	} {
+		if code := run(args, &bytes.Buffer{}); code != 2 {
+			t.Errorf("run(%v) = %d, want 2", args, code)
+		}
+	}
+}
+
+func TestPackageName(t *testing.T) {
+	for _, tt := range []struct{ in, want string }{
+		{"specs/password-auth.allium.json", "passwordauth"},
+		{"Orders.allium.json", "orders"},
+		{"2024-billing.allium.json", "billing"},
+		{"type.allium.json", "spec"},
+		{"---.allium.json", "spec"},
+	} {
+		if got := packageName(tt.in); got != tt.want {
+			t.Errorf("packageName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
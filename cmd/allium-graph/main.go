@@ -0,0 +1,81 @@
+// Command allium-graph renders diagrams of an Allium specification file
+// (.allium.json) for design reviews: the graph of entities and the
+// references between them, or the state machine of each entity's status
+// field as inferred by the validator.
+//
+// Usage:
+//
+//	allium-graph [--view entities|states] [--format dot|mermaid] file.allium.json
+//
+// Exit codes:
+//
+//	0  The diagram was written to stdout
+//	2  Bad flags, or the file could not be read or parsed
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/diagram"
+	"github.com/foundry-zero/allium/internal/semantic"
+)
+
+const version = "0.1.0"
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout))
+}
+
+func run(args []string, out io.Writer) int {
+	fs := flag.NewFlagSet("allium-graph", flag.ContinueOnError)
+
+	view := fs.String("view", "entities", "Diagram to render: entities or states")
+	format := fs.String("format", "dot", "Output format: dot or mermaid")
+	showVersion := fs.Bool("version", false, "Print version and exit")
+
+	if err := fs.Parse(args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	if *showVersion {
+		fmt.Fprintf(out, "allium-graph %s\n", version)
+		return 0
+	}
+
+	if *view != "entities" && *view != "states" {
+		fmt.Fprintf(os.Stderr, "Error: unknown view %q (use entities or states)\n", *view)
+		return 2
+	}
+	if *format != "dot" && *format != "mermaid" {
+		fmt.Fprintf(os.Stderr, "Error: unknown format %q (use dot or mermaid)\n", *format)
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Error: expected exactly one .allium.json file")
+		return 2
+	}
+
+	spec, err := ast.LoadSpec(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	st := semantic.BuildSymbolTable(spec)
+
+	switch {
+	case *view == "entities" && *format == "dot":
+		io.WriteString(out, diagram.Entities(spec, st).DOT())
+	case *view == "entities":
+		io.WriteString(out, diagram.Entities(spec, st).Mermaid())
+	case *format == "dot":
+		io.WriteString(out, diagram.StatesDOT(semantic.StateMachines(spec, st)))
+	default:
+		io.WriteString(out, diagram.StatesMermaid(semantic.StateMachines(spec, st)))
+	}
+	return 0
+}
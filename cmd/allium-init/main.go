@@ -0,0 +1,172 @@
+// Command allium-init scaffolds a new Allium specification file
+// (.allium.json): metadata, an enumeration, an entity with a status
+// lifecycle, the rules that drive it, an actor and a surface, named after
+// flags or answers to prompts. The spec passes schema and semantic
+// validation as generated, so editing starts from a clean check.
+//
+// Usage:
+//
+//	allium-init [-i] [--entity Task] [--actor Member] [--actor-entity User] [--scope tasks] [--description TEXT] [--force] [file.allium.json]
+//
+// The spec is written to file, by default <scope>.allium.json, or to stdout
+// when file is "-".
+//
+// Exit codes:
+//
+//	0  The spec was written
+//	1  The generated spec did not validate; nothing was written
+//	2  Bad flags or names, the file exists, or it could not be written
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/foundry-zero/allium/internal/checker"
+	"github.com/foundry-zero/allium/internal/report"
+	"github.com/foundry-zero/allium/internal/scaffold"
+)
+
+const version = "0.1.0"
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout))
+}
+
+func run(args []string, in io.Reader, out io.Writer) int {
+	fs := flag.NewFlagSet("allium-init", flag.ContinueOnError)
+
+	def := scaffold.Defaults()
+	interactive := fs.Bool("i", false, "Prompt for each name on stdin, offering the flag values as defaults")
+	entity := fs.String("entity", def.Entity, "Name of the sample `entity`, in PascalCase")
+	actor := fs.String("actor", def.Actor, "Name of the `actor` working on the entity, in PascalCase")
+	actorEntity := fs.String("actor-entity", def.ActorEntity, "Name of the `entity` identifying the actor, in PascalCase")
+	scope := fs.String("scope", "", "Metadata `scope` (default: the file name, or the plural of the entity)")
+	description := fs.String("description", def.Description, "Metadata `description`")
+	force := fs.Bool("force", false, "Overwrite the file if it exists")
+	showVersion := fs.Bool("version", false, "Print version and exit")
+
+	if err := fs.Parse(args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	if *showVersion {
+		fmt.Fprintf(out, "allium-init %s\n", version)
+		return 0
+	}
+	if fs.NArg() > 1 {
+		fmt.Fprintln(os.Stderr, "Error: expected at most one output file")
+		return 2
+	}
+	path := fs.Arg(0)
+
+	opts := scaffold.Options{Entity: *entity, Actor: *actor, ActorEntity: *actorEntity, Scope: *scope, Description: *description}
+	if opts.Scope == "" && path != "" && path != "-" {
+		opts.Scope = strings.TrimSuffix(filepath.Base(path), ".allium.json")
+	}
+	if *interactive {
+		if err := prompt(in, &opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+	}
+	if path != "" && path != "-" {
+		opts.File = strings.TrimSuffix(filepath.Base(path), ".json")
+	}
+
+	data, err := scaffold.Generate(opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if path == "" {
+		// Name the file after the marker Generate derived from the scope.
+		var spec struct {
+			File string `json:"file"`
+		}
+		if err := json.Unmarshal(data, &spec); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		path = spec.File + ".json"
+	}
+
+	// The skeleton is meant to validate whatever it is named; check that it
+	// does before handing it over.
+	c, err := checker.NewChecker()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if r := c.CheckSource(path, data, checker.CheckOptions{}); len(r.Findings()) > 0 {
+		fmt.Fprintln(os.Stderr, "Error: the generated spec does not validate:")
+		fmt.Fprint(os.Stderr, report.FormatText(r))
+		return 1
+	}
+
+	if path == "-" {
+		out.Write(data)
+		return 0
+	}
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !*force {
+		flags |= os.O_EXCL
+	}
+	f, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		if os.IsExist(err) {
+			fmt.Fprintf(os.Stderr, "Error: %s already exists (use --force to overwrite it)\n", path)
+		} else {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		return 2
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s\n", path)
+	return 0
+}
+
+// prompt asks for each name of opts on stderr and reads the answers from
+// in, one per line. An empty answer, or the end of input, keeps the current
+// value.
+func prompt(in io.Reader, opts *scaffold.Options) error {
+	scanner := bufio.NewScanner(in)
+	for _, q := range []struct {
+		label string
+		value *string
+	}{
+		{"Entity", &opts.Entity},
+		{"Actor", &opts.Actor},
+		{"Entity identifying the actor", &opts.ActorEntity},
+		{"Scope", &opts.Scope},
+		{"Description", &opts.Description},
+	} {
+		if *q.value == "" {
+			fmt.Fprintf(os.Stderr, "%s: ", q.label)
+		} else {
+			fmt.Fprintf(os.Stderr, "%s [%s]: ", q.label, *q.value)
+		}
+		if !scanner.Scan() {
+			fmt.Fprintln(os.Stderr)
+			return scanner.Err()
+		}
+		if answer := strings.TrimSpace(scanner.Text()); answer != "" {
+			*q.value = answer
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/foundry-zero/allium/internal/checker"
+)
+
+// checkFile validates the spec at path and fails the test on any finding.
+func checkFile(t *testing.T, path string) {
+	t.Helper()
+	c, err := checker.NewChecker()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := c.Check(path, checker.CheckOptions{})
+	for _, f := range r.Findings() {
+		t.Errorf("%s: unexpected %s %s: %s", path, f.Severity, f.Rule, f.Message)
+	}
+}
+
+func TestRunVersion(t *testing.T) {
+	var out bytes.Buffer
+	if code := run([]string{"--version"}, strings.NewReader(""), &out); code != 0 {
+		t.Errorf("run(--version) = %d, want 0", code)
+	}
+	if !strings.Contains(out.String(), "allium-init "+version) {
+		t.Errorf("unexpected version output %q", out.String())
+	}
+}
+
+func TestRunWritesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orders.allium.json")
+	args := []string{"--entity", "Order", "--actor", "Buyer", "--actor-entity", "Customer", path}
+	if code := run(args, strings.NewReader(""), &bytes.Buffer{}); code != 0 {
+		t.Fatalf("run(%v) = %d, want 0", args, code)
+	}
+	checkFile(t, path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"file": "orders.allium"`, `"scope": "orders"`, `"CompleteOrder"`, `"BuyerCreatesOrder"`} {
+		if !bytes.Contains(data, []byte(want)) {
+			t.Errorf("generated spec lacks %s", want)
+		}
+	}
+
+	if code := run(args, strings.NewReader(""), &bytes.Buffer{}); code != 2 {
+		t.Errorf("second run without --force = %d, want 2", code)
+	}
+	if code := run(append([]string{"--force"}, args...), strings.NewReader(""), &bytes.Buffer{}); code != 0 {
+		t.Errorf("second run with --force = %d, want 0", code)
+	}
+}
+
+func TestRunStdout(t *testing.T) {
+	var out bytes.Buffer
+	if code := run([]string{"-"}, strings.NewReader(""), &out); code != 0 {
+		t.Fatalf("run(-) = %d, want 0", code)
+	}
+	if !strings.Contains(out.String(), `"file": "tasks.allium"`) {
+		t.Errorf("stdout lacks the default file marker:\n%s", out.String())
+	}
+}
+
+func TestRunInteractive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shop.allium.json")
+	in := strings.NewReader("PurchaseOrder\nBuyer\nCustomer\n\n")
+	if code := run([]string{"-i", path}, in, &bytes.Buffer{}); code != 0 {
+		t.Fatalf("run(-i) = %d, want 0", code)
+	}
+	checkFile(t, path)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"scope": "shop"`, `"PurchaseOrder"`, `"purchase_orders"`, `"Describe what this specification covers."`} {
+		if !bytes.Contains(data, []byte(want)) {
+			t.Errorf("generated spec lacks %s", want)
+		}
+	}
+}
+
+func TestRunBadArguments(t *testing.T) {
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"--nope"},
+		{"a.allium.json", "b.allium.json"},
+		{"--entity", "task", filepath.Join(dir, "a.allium.json")},
+		{"--entity", "User", filepath.Join(dir, "b.allium.json")},
+		{"--entity", "Priority", filepath.Join(dir, "c.allium.json")},
+	} {
+		if code := run(args, strings.NewReader(""), &bytes.Buffer{}); code != 2 {
+			t.Errorf("run(%v) = %d, want 2", args, code)
+		}
+	}
+}
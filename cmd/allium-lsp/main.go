@@ -0,0 +1,60 @@
+// Command allium-lsp is a Language Server Protocol server for Allium
+// specification files (.allium.json). It communicates over stdin/stdout and
+// provides diagnostics, hover, and go-to-definition.
+//
+// Usage:
+//
+//	allium-lsp [flags]
+//
+// Exit codes:
+//
+//	0  The client sent shutdown and exit, or closed the connection
+//	1  The client sent exit without shutdown, or the connection failed
+//	2  Bad flags or checker initialization error
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/foundry-zero/allium/internal/checker"
+	"github.com/foundry-zero/allium/internal/lsp"
+)
+
+const version = "0.1.0"
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout))
+}
+
+func run(args []string, in io.Reader, out io.Writer) int {
+	fs := flag.NewFlagSet("allium-lsp", flag.ContinueOnError)
+
+	schemaOnly := fs.Bool("schema-only", false, "Run schema validation only, skip semantic passes")
+	showVersion := fs.Bool("version", false, "Print version and exit")
+
+	if err := fs.Parse(args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	if *showVersion {
+		fmt.Fprintf(out, "allium-lsp %s\n", version)
+		return 0
+	}
+
+	c, err := checker.NewChecker()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	s := lsp.NewServer(c, checker.CheckOptions{SchemaOnly: *schemaOnly, DiscoverConfig: true}, version)
+	if err := s.Serve(in, out); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
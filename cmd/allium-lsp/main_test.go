@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func frame(body string) string {
+	return fmt.Sprintf("Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+func TestRunVersion(t *testing.T) {
+	var out bytes.Buffer
+	if code := run([]string{"--version"}, strings.NewReader(""), &out); code != 0 {
+		t.Errorf("run(--version) = %d, want 0", code)
+	}
+	if !strings.Contains(out.String(), "allium-lsp "+version) {
+		t.Errorf("unexpected version output %q", out.String())
+	}
+}
+
+func TestRunBadFlag(t *testing.T) {
+	if code := run([]string{"--nope"}, strings.NewReader(""), &bytes.Buffer{}); code != 2 {
+		t.Errorf("run(--nope) = %d, want 2", code)
+	}
+}
+
+func TestRunSession(t *testing.T) {
+	in := frame(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`) +
+		frame(`{"jsonrpc":"2.0","id":2,"method":"shutdown"}`) +
+		frame(`{"jsonrpc":"2.0","method":"exit"}`)
+	var out bytes.Buffer
+	if code := run(nil, strings.NewReader(in), &out); code != 0 {
+		t.Errorf("run(session) = %d, want 0", code)
+	}
+	if !strings.Contains(out.String(), `"hoverProvider":true`) {
+		t.Errorf("initialize response missing capabilities:\n%s", out.String())
+	}
+}
+
+func TestRunExitWithoutShutdown(t *testing.T) {
+	in := frame(`{"jsonrpc":"2.0","method":"exit"}`)
+	if code := run(nil, strings.NewReader(in), &bytes.Buffer{}); code != 1 {
+		t.Errorf("run(exit without shutdown) = %d, want 1", code)
+	}
+}
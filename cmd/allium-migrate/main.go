@@ -0,0 +1,108 @@
+// Command allium-migrate rewrites an Allium specification file
+// (.allium.json) written against an older schema version so that it
+// conforms to a newer one, and validates the result against the schema.
+//
+// Usage:
+//
+//	allium-migrate [--from VERSION] [--to VERSION] [-o out.allium.json] file.allium.json
+//	allium-migrate --list
+//
+// Exit codes:
+//
+//	0  The migrated spec was written and conforms to the schema
+//	1  The migrated spec does not conform to the schema; nothing was written
+//	2  Bad flags, or the file could not be read, parsed or migrated
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/foundry-zero/allium/internal/migrate"
+	"github.com/foundry-zero/allium/internal/schema"
+)
+
+const version = "0.1.0"
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout))
+}
+
+func run(args []string, out io.Writer) int {
+	fs := flag.NewFlagSet("allium-migrate", flag.ContinueOnError)
+
+	from := fs.String("from", "", "Schema `version` of the input (default: the document's version marker)")
+	to := fs.String("to", migrate.Latest, "Schema `version` to migrate to")
+	output := fs.String("o", "", "Write the migrated spec to `file` instead of stdout")
+	list := fs.Bool("list", false, "List the available migrations and exit")
+	showVersion := fs.Bool("version", false, "Print version and exit")
+
+	if err := fs.Parse(args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+
+	if *showVersion {
+		fmt.Fprintf(out, "allium-migrate %s\n", version)
+		return 0
+	}
+
+	p := migrate.NewPipeline()
+	if *list {
+		for _, m := range p.Migrations() {
+			fmt.Fprintf(out, "%s -> %s: %s\n", m.From, m.To, m.Description)
+		}
+		return 0
+	}
+
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Error: expected exactly one .allium.json file")
+		return 2
+	}
+	target := migrate.NormalizeVersion(*to)
+	if supported := schema.SupportedVersions(); !slices.Contains(supported, target) {
+		fmt.Fprintf(os.Stderr, "Error: cannot validate version %s (schemas exist for versions %s)\n", *to, strings.Join(supported, ", "))
+		return 2
+	}
+
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	migrated, applied, err := p.Migrate(data, *from, *to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s: %v\n", fs.Arg(0), err)
+		return 2
+	}
+
+	sv, err := schema.NewSchemaValidator()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if errs := sv.ValidateBytes(migrated); len(errs) > 0 {
+		fmt.Fprintf(os.Stderr, "%s: migrated spec does not conform to schema version %s:\n", fs.Arg(0), target)
+		for _, se := range errs {
+			fmt.Fprintf(os.Stderr, "  %s\n", se.Message)
+		}
+		return 1
+	}
+
+	for _, m := range applied {
+		fmt.Fprintf(os.Stderr, "%s: migrated %s -> %s (%s)\n", fs.Arg(0), m.From, m.To, m.Description)
+	}
+	if *output == "" {
+		out.Write(migrated)
+		return 0
+	}
+	if err := os.WriteFile(*output, migrated, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var refExample = filepath.Join("..", "..", "schemas", "v1", "examples", "password-auth.allium.json")
+
+// writeOldExample writes the reference example with a 0.4.0 version marker
+// and returns its path.
+func writeOldExample(t *testing.T) string {
+	t.Helper()
+	data, err := os.ReadFile(refExample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = bytes.Replace(data, []byte(`"version": "1"`), []byte(`"version": "0.4.0"`), 1)
+	path := filepath.Join(t.TempDir(), "auth.allium.json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunVersion(t *testing.T) {
+	var out bytes.Buffer
+	if code := run([]string{"--version"}, &out); code != 0 {
+		t.Errorf("run(--version) = %d, want 0", code)
+	}
+	if !strings.Contains(out.String(), "allium-migrate "+version) {
+		t.Errorf("unexpected version output %q", out.String())
+	}
+}
+
+func TestRunList(t *testing.T) {
+	var out bytes.Buffer
+	if code := run([]string{"--list"}, &out); code != 0 {
+		t.Errorf("run(--list) = %d, want 0", code)
+	}
+	if !strings.Contains(out.String(), "0.4 -> 1: ") {
+		t.Errorf("unexpected --list output %q", out.String())
+	}
+}
+
+func TestRunBadArguments(t *testing.T) {
+	old := writeOldExample(t)
+	for _, args := range [][]string{
+		{"--nope", old},
+		{},
+		{old, old},
+		{"missing.allium.json"},
+		{"--to", "2", old},
+		{"--from", "0.3", old},
+	} {
+		if code := run(args, &bytes.Buffer{}); code != 2 {
+			t.Errorf("run(%v) = %d, want 2", args, code)
+		}
+	}
+}
+
+func TestRunMigrate(t *testing.T) {
+	old := writeOldExample(t)
+
+	var out bytes.Buffer
+	if code := run([]string{"--from", "0.4", "--to", "1", old}, &out); code != 0 {
+		t.Fatalf("run(--from 0.4 --to 1) = %d, want 0", code)
+	}
+	if !strings.Contains(out.String(), `"version": "1"`) {
+		t.Errorf("expected migrated spec on stdout, got:\n%.200s", out.String())
+	}
+
+	dest := filepath.Join(t.TempDir(), "migrated.allium.json")
+	out.Reset()
+	if code := run([]string{"-o", dest, old}, &out); code != 0 {
+		t.Fatalf("run(-o) = %d, want 0", code)
+	}
+	if out.Len() != 0 {
+		t.Errorf("expected nothing on stdout with -o, got %q", out.String())
+	}
+	if data, err := os.ReadFile(dest); err != nil || !bytes.Contains(data, []byte(`"version": "1"`)) {
+		t.Errorf("expected migrated spec in %s: %v", dest, err)
+	}
+}
+
+func TestRunMigratedSpecInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "broken.allium.json")
+	if err := os.WriteFile(path, []byte(`{"version": "0.4.0", "file": "broken.allium", "entities": [{"fields": []}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dest := filepath.Join(t.TempDir(), "out.allium.json")
+	if code := run([]string{"-o", dest, path}, &bytes.Buffer{}); code != 1 {
+		t.Errorf("run(invalid result) = %d, want 1", code)
+	}
+	if _, err := os.Stat(dest); err == nil {
+		t.Error("expected nothing written for an invalid result")
+	}
+}
@@ -0,0 +1,139 @@
+// Package annotate maintains sidecar annotation files that record checker
+// findings next to a spec, keyed by finding fingerprint, together with the
+// review status reviewers assign to each finding. Review status is carried
+// over from one run to the next for findings whose fingerprint is unchanged.
+package annotate
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/foundry-zero/allium/internal/report"
+)
+
+// Version is the sidecar file format version.
+const Version = "1"
+
+// Review statuses. New findings start open; reviewers mark them accepted
+// (intentional, no change planned) or deferred (to be fixed later).
+const (
+	StatusOpen     = "open"
+	StatusAccepted = "accepted"
+	StatusDeferred = "deferred"
+)
+
+// Annotation is the record of one finding in a sidecar file.
+type Annotation struct {
+	Fingerprint string          `json:"fingerprint"`
+	Rule        string          `json:"rule"`
+	Severity    report.Severity `json:"severity"`
+	Message     string          `json:"message"`
+	Path        string          `json:"path"`
+	Line        int             `json:"line,omitempty"`
+	Column      int             `json:"column,omitempty"`
+	Status      string          `json:"status"`
+	Note        string          `json:"note,omitempty"`
+}
+
+// File is the content of a sidecar annotation file.
+type File struct {
+	Version     string       `json:"version"`
+	Spec        string       `json:"spec"` // file name of the annotated spec, in the same directory
+	Annotations []Annotation `json:"annotations"`
+}
+
+// SidecarPath returns the annotation file path for the spec at specPath:
+// "billing.allium.json" is annotated by "billing.allium.annotations.json".
+func SidecarPath(specPath string) string {
+	return strings.TrimSuffix(specPath, ".json") + ".annotations.json"
+}
+
+// Load reads the sidecar file at path. A missing file yields an empty File
+// and no error, so that the first run on a spec starts from scratch.
+func Load(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &File{Version: Version}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read annotations: %w", err)
+	}
+	var f File
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse annotations %s: %w", path, err)
+	}
+	if f.Version != Version {
+		return nil, fmt.Errorf("annotations %s: unsupported version %q", path, f.Version)
+	}
+	for i, a := range f.Annotations {
+		switch a.Status {
+		case StatusOpen, StatusAccepted, StatusDeferred:
+		default:
+			return nil, fmt.Errorf("annotations %s: annotation %d has unknown status %q", path, i, a.Status)
+		}
+	}
+	return &f, nil
+}
+
+// Save writes f to path as indented JSON.
+func Save(path string, f *File) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode annotations: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write annotations: %w", err)
+	}
+	return nil
+}
+
+// Lookup returns the annotation with the given fingerprint, or nil. It is
+// safe to call on a nil File.
+func (f *File) Lookup(fingerprint string) *Annotation {
+	if f == nil {
+		return nil
+	}
+	for i := range f.Annotations {
+		if f.Annotations[i].Fingerprint == fingerprint {
+			return &f.Annotations[i]
+		}
+	}
+	return nil
+}
+
+// Merge builds the annotation file for r. Each unsuppressed finding becomes an
+// annotation; those already present in prev keep their status and note, and
+// the rest start open. Annotations in prev whose finding no longer occurs are
+// dropped. Suppressed findings are not annotated.
+func Merge(r *report.Report, prev *File) *File {
+	out := &File{Version: Version, Spec: filepath.Base(r.File), Annotations: []Annotation{}}
+	seen := make(map[string]bool)
+	for _, finding := range r.Findings() {
+		fp := finding.Fingerprint()
+		if seen[fp] {
+			continue
+		}
+		seen[fp] = true
+		a := Annotation{
+			Fingerprint: fp,
+			Rule:        finding.Rule,
+			Severity:    finding.Severity,
+			Message:     finding.Message,
+			Path:        finding.Location.Path,
+			Line:        finding.Location.Line,
+			Column:      finding.Location.Column,
+			Status:      StatusOpen,
+		}
+		if old := prev.Lookup(fp); old != nil {
+			a.Status = old.Status
+			a.Note = old.Note
+		}
+		out.Annotations = append(out.Annotations, a)
+	}
+	return out
+}
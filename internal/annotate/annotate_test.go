@@ -0,0 +1,115 @@
+package annotate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/foundry-zero/allium/internal/report"
+)
+
+func testReport() *report.Report {
+	r := report.NewReport("specs/billing.allium.json")
+	r.AddFinding(report.NewError("RULE-01", "Entity 'Buyer' referenced but not declared",
+		report.Location{File: "specs/billing.allium.json", Path: "$.entities[0].fields[0].type", Line: 5, Column: 40}))
+	r.AddFinding(report.NewWarning("WARN-02", "Open questions present",
+		report.Location{File: "specs/billing.allium.json", Path: "$.open_questions"}))
+	r.AddSuppressed(report.NewWarning("WARN-20", "Emission 'x' has no consumer",
+		report.Location{File: "specs/billing.allium.json", Path: "$.rules[0]"}))
+	return r
+}
+
+func TestSidecarPath(t *testing.T) {
+	tests := map[string]string{
+		"billing.allium.json":     "billing.allium.annotations.json",
+		"dir/billing.allium.json": "dir/billing.allium.annotations.json",
+		"billing":                 "billing.annotations.json",
+	}
+	for in, want := range tests {
+		if got := SidecarPath(in); got != want {
+			t.Errorf("SidecarPath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestMerge_NewFindingsStartOpen(t *testing.T) {
+	f := Merge(testReport(), nil)
+	if f.Version != Version || f.Spec != "billing.allium.json" {
+		t.Errorf("unexpected header: %+v", f)
+	}
+	if len(f.Annotations) != 2 {
+		t.Fatalf("expected 2 annotations (suppressed findings excluded), got %d", len(f.Annotations))
+	}
+	a := f.Annotations[0]
+	if a.Rule != "RULE-01" || a.Status != StatusOpen || a.Line != 5 || a.Column != 40 {
+		t.Errorf("unexpected annotation: %+v", a)
+	}
+	if a.Fingerprint != testReport().Errors[0].Fingerprint() {
+		t.Errorf("fingerprint = %q", a.Fingerprint)
+	}
+}
+
+func TestMerge_KeepsReviewStatus(t *testing.T) {
+	r := testReport()
+	prev := &File{Version: Version, Annotations: []Annotation{
+		{Fingerprint: r.Errors[0].Fingerprint(), Rule: "RULE-01", Status: StatusDeferred, Note: "tracked in billing backlog"},
+		{Fingerprint: "0000000000000000", Rule: "RULE-07", Status: StatusAccepted},
+	}}
+
+	f := Merge(r, prev)
+	if len(f.Annotations) != 2 {
+		t.Fatalf("expected stale annotation to be dropped, got %+v", f.Annotations)
+	}
+	if a := f.Lookup(r.Errors[0].Fingerprint()); a == nil || a.Status != StatusDeferred || a.Note != "tracked in billing backlog" {
+		t.Errorf("review status not carried over: %+v", a)
+	}
+	if a := f.Lookup(r.Warnings[0].Fingerprint()); a == nil || a.Status != StatusOpen {
+		t.Errorf("new finding should be open: %+v", a)
+	}
+}
+
+func TestLoadSave_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "billing.allium.annotations.json")
+
+	f, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load of missing file: %v", err)
+	}
+	if len(f.Annotations) != 0 {
+		t.Errorf("expected empty annotations, got %+v", f.Annotations)
+	}
+
+	want := Merge(testReport(), nil)
+	want.Annotations[1].Status = StatusAccepted
+	if err := Save(path, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Annotations) != 2 || got.Annotations[1].Status != StatusAccepted || got.Annotations[0].Severity != report.SeverityError {
+		t.Errorf("round trip mismatch: %+v", got)
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	cases := map[string]string{
+		"bad-json":    `{`,
+		"bad-version": `{"version": "2", "annotations": []}`,
+		"bad-status":  `{"version": "1", "annotations": [{"fingerprint": "ab", "status": "ignored"}]}`,
+	}
+	for name, content := range cases {
+		path := filepath.Join(dir, name+".json")
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil {
+			t.Errorf("%s: expected error", name)
+		} else if name == "bad-status" && !strings.Contains(err.Error(), "ignored") {
+			t.Errorf("%s: error should name the status: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package ast
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/foundry-zero/allium/internal/srcmap"
+)
+
+// Document is a spec file decoded once into generic JSON values, with the
+// source span of every value. Schema validation reads Value and Spec builds
+// the AST from it, so a large file is decoded a single time rather than once
+// for each.
+type Document struct {
+	// Value is the decoded document: objects are map[string]any, arrays
+	// []any and numbers json.Number, which keeps integers exact.
+	Value any
+
+	// SourceMap records where each value is in the source.
+	SourceMap srcmap.Map
+
+	data []byte
+}
+
+// DecodeDocument decodes JSON held in memory into a Document. The source is
+// scanned once, recording positions as the values are built, after a check
+// that it is a single well-formed JSON value.
+func DecodeDocument(data []byte) (*Document, error) {
+	if !json.Valid(data) {
+		// Decode again only to describe the error.
+		var v any
+		err := json.Unmarshal(data, &v)
+		if err == nil {
+			err = errors.New("invalid JSON")
+		}
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+	value, sourceMap, err := srcmap.Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to index spec positions: %w", err)
+	}
+	return &Document{Value: value, SourceMap: sourceMap, data: data}, nil
+}
+
+// Spec builds the spec the document holds, as ParseSpec does from its
+// source. Fields are matched to object keys exactly and keys the AST does
+// not know are ignored, so a document that satisfies the schema always
+// converts; other documents fail at the first value of the wrong kind.
+func (d *Document) Spec() (*Spec, error) {
+	var spec Spec
+	dec := &documentDecoder{doc: d}
+	if err := dec.decode(d.Value, reflect.ValueOf(&spec).Elem()); err != nil {
+		return nil, fmt.Errorf("failed to parse spec JSON: %w", err)
+	}
+	spec.SourceMap = d.SourceMap
+	return &spec, nil
+}
+
+var rawMessageType = reflect.TypeFor[json.RawMessage]()
+
+// documentDecoder assigns the generic values of a Document to AST structs,
+// tracking the path of the value being decoded so that raw messages can be
+// copied from the source and errors can say where they are.
+type documentDecoder struct {
+	doc  *Document
+	path []pathSegment
+}
+
+// pathSegment is an object key or, when index is not negative, an array
+// index.
+type pathSegment struct {
+	key   string
+	index int
+}
+
+// pathString returns the JSONPath of the value being decoded, in the form of
+// srcmap keys.
+func (d *documentDecoder) pathString() string {
+	var b strings.Builder
+	b.WriteString("$")
+	for _, seg := range d.path {
+		if seg.index < 0 {
+			b.WriteString("." + seg.key)
+		} else {
+			b.WriteString("[" + strconv.Itoa(seg.index) + "]")
+		}
+	}
+	return b.String()
+}
+
+func (d *documentDecoder) errorf(format string, args ...any) error {
+	return fmt.Errorf("%s: %s", d.pathString(), fmt.Sprintf(format, args...))
+}
+
+// decode assigns the JSON value v to dst.
+func (d *documentDecoder) decode(v any, dst reflect.Value) error {
+	t := dst.Type()
+	if t == rawMessageType {
+		span, ok := d.doc.SourceMap[d.pathString()]
+		if !ok {
+			return d.errorf("value is not in the source map")
+		}
+		dst.SetBytes(bytes.Clone(span.Text(d.doc.data)))
+		return nil
+	}
+	if v == nil {
+		// As with encoding/json, null leaves the value as it is.
+		return nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		if dst.IsNil() {
+			dst.Set(reflect.New(t.Elem()))
+		}
+		return d.decode(v, dst.Elem())
+	case reflect.Struct:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return d.mismatch(v, t)
+		}
+		fields := structFields(t)
+		for key, fv := range obj {
+			i, ok := fields[key]
+			if !ok {
+				continue
+			}
+			d.path = append(d.path, pathSegment{key: key, index: -1})
+			err := d.decode(fv, dst.Field(i))
+			d.path = d.path[:len(d.path)-1]
+			if err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		obj, ok := v.(map[string]any)
+		if !ok || t.Key().Kind() != reflect.String {
+			return d.mismatch(v, t)
+		}
+		m := reflect.MakeMapWithSize(t, len(obj))
+		for key, ev := range obj {
+			elem := reflect.New(t.Elem()).Elem()
+			d.path = append(d.path, pathSegment{key: key, index: -1})
+			err := d.decode(ev, elem)
+			d.path = d.path[:len(d.path)-1]
+			if err != nil {
+				return err
+			}
+			m.SetMapIndex(reflect.ValueOf(key).Convert(t.Key()), elem)
+		}
+		dst.Set(m)
+	case reflect.Slice:
+		arr, ok := v.([]any)
+		if !ok {
+			return d.mismatch(v, t)
+		}
+		s := reflect.MakeSlice(t, len(arr), len(arr))
+		for i, ev := range arr {
+			d.path = append(d.path, pathSegment{index: i})
+			err := d.decode(ev, s.Index(i))
+			d.path = d.path[:len(d.path)-1]
+			if err != nil {
+				return err
+			}
+		}
+		dst.Set(s)
+	case reflect.String:
+		s, ok := v.(string)
+		if !ok {
+			return d.mismatch(v, t)
+		}
+		dst.SetString(s)
+	case reflect.Bool:
+		b, ok := v.(bool)
+		if !ok {
+			return d.mismatch(v, t)
+		}
+		dst.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, ok := v.(json.Number)
+		if !ok {
+			return d.mismatch(v, t)
+		}
+		i, err := strconv.ParseInt(n.String(), 10, t.Bits())
+		if err != nil {
+			return d.errorf("number %s does not fit %s", n, t)
+		}
+		dst.SetInt(i)
+	case reflect.Float32, reflect.Float64:
+		n, ok := v.(json.Number)
+		if !ok {
+			return d.mismatch(v, t)
+		}
+		f, err := strconv.ParseFloat(n.String(), t.Bits())
+		if err != nil {
+			return d.errorf("number %s does not fit %s", n, t)
+		}
+		dst.SetFloat(f)
+	case reflect.Interface:
+		dst.Set(reflect.ValueOf(v))
+	default:
+		return d.errorf("cannot decode into %s", t)
+	}
+	return nil
+}
+
+// mismatch reports a JSON value of the wrong kind for t.
+func (d *documentDecoder) mismatch(v any, t reflect.Type) error {
+	kind := "value"
+	switch v.(type) {
+	case map[string]any:
+		kind = "object"
+	case []any:
+		kind = "array"
+	case string:
+		kind = "string"
+	case json.Number:
+		kind = "number"
+	case bool:
+		kind = "boolean"
+	}
+	return d.errorf("cannot decode %s into %s", kind, t)
+}
+
+// fieldIndexes caches structFields by type.
+var fieldIndexes sync.Map // reflect.Type -> map[string]int
+
+// structFields maps the JSON keys of t's fields, as named by their json
+// tags, to the fields' indexes. Fields tagged "-" and unexported fields are
+// left out.
+func structFields(t reflect.Type) map[string]int {
+	if fields, ok := fieldIndexes.Load(t); ok {
+		return fields.(map[string]int)
+	}
+	fields := make(map[string]int, t.NumField())
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = i
+	}
+	fieldIndexes.Store(t, fields)
+	return fields
+}
@@ -0,0 +1,149 @@
+package ast
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDocumentSpec_MatchesParseSpec(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("..", "..", "schemas", "v1", "examples", "*.allium.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	broken, _ := filepath.Glob(filepath.Join("..", "..", "schemas", "v1", "examples", "broken", "*.allium.json"))
+	paths = append(paths, broken...)
+	if len(paths) == 0 {
+		t.Fatal("no example specs found")
+	}
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := ParseSpec(data)
+			if err != nil {
+				t.Skipf("ParseSpec: %v", err)
+			}
+			doc, err := DecodeDocument(data)
+			if err != nil {
+				t.Fatalf("DecodeDocument: %v", err)
+			}
+			got, err := doc.Spec()
+			if err != nil {
+				t.Fatalf("Spec: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Error("Document.Spec differs from ParseSpec")
+			}
+		})
+	}
+}
+
+func TestDocumentSpec_RawValues(t *testing.T) {
+	data := []byte(`{"version": "1", "rules": [{"name": "R", "ensures": [
+		{"kind": "state_change", "value": {"kind": "literal", "type": "integer", "value": 12345678901234567890}}]}]}`)
+	doc, err := DecodeDocument(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := doc.Value.(map[string]any)["version"]; n != "1" {
+		t.Errorf("version = %v", n)
+	}
+	spec, err := doc.Spec()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Raw values are copied from the source, so large numbers stay exact.
+	want := `{"kind": "literal", "type": "integer", "value": 12345678901234567890}`
+	if got := string(spec.Rules[0].Ensures[0].Value); got != want {
+		t.Errorf("value = %s, want %s", got, want)
+	}
+	var lit Expression
+	if err := json.Unmarshal(spec.Rules[0].Ensures[0].Value, &lit); err != nil || string(lit.LitValue) != "12345678901234567890" {
+		t.Errorf("literal = %s (%v)", lit.LitValue, err)
+	}
+}
+
+func TestDecodeDocument_Invalid(t *testing.T) {
+	for name, data := range map[string]string{
+		"syntax":      `{invalid json}`,
+		"truncated":   `{"version": "1"`,
+		"two values":  `{} {}`,
+		"trailing":    `{"version": "1"} x`,
+		"empty input": ``,
+	} {
+		if _, err := DecodeDocument([]byte(data)); err == nil || !strings.Contains(err.Error(), "failed to parse JSON") {
+			t.Errorf("%s: expected a parse error, got %v", name, err)
+		}
+	}
+}
+
+func TestDocumentSpec_WrongKind(t *testing.T) {
+	doc, err := DecodeDocument([]byte(`{"version": "1", "rules": [{"name": 3}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = doc.Spec()
+	if err == nil || !strings.Contains(err.Error(), "$.rules[0].name: cannot decode number into string") {
+		t.Errorf("expected an error locating the bad value, got %v", err)
+	}
+}
+
+// largeSpec returns the reference example with its rules repeated n times.
+func largeSpec(b *testing.B, n int) []byte {
+	b.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "..", "schemas", "v1", "examples", "password-auth.allium.json"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		b.Fatal(err)
+	}
+	rules := doc["rules"].([]any)
+	for range n - 1 {
+		doc["rules"] = append(doc["rules"].([]any), rules...)
+	}
+	data, err = json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		b.Fatal(err)
+	}
+	return data
+}
+
+// BenchmarkLoad compares decoding a large spec once into a Document, for
+// both schema validation and the AST, with decoding it once for each.
+func BenchmarkLoad(b *testing.B) {
+	data := largeSpec(b, 200)
+	b.Run("document", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		b.ReportAllocs()
+		for b.Loop() {
+			doc, err := DecodeDocument(data)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := doc.Spec(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("twice", func(b *testing.B) {
+		b.SetBytes(int64(len(data)))
+		b.ReportAllocs()
+		for b.Loop() {
+			var value any
+			if err := json.Unmarshal(data, &value); err != nil {
+				b.Fatal(err)
+			}
+			if _, err := ParseSpec(data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -4,19 +4,33 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+
+	"github.com/foundry-zero/allium/internal/srcmap"
 )
 
-// LoadSpec reads and parses an Allium specification JSON file into a Spec.
+// LoadSpec reads and parses an Allium specification JSON file into a Spec,
+// recording the source span of every value in Spec.SourceMap.
 func LoadSpec(path string) (*Spec, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read spec file: %w", err)
 	}
+	return ParseSpec(data)
+}
 
+// ParseSpec parses Allium specification JSON held in memory, as read by
+// LoadSpec from disk or received from an editor.
+func ParseSpec(data []byte) (*Spec, error) {
 	var spec Spec
 	if err := json.Unmarshal(data, &spec); err != nil {
 		return nil, fmt.Errorf("failed to parse spec JSON: %w", err)
 	}
 
+	sourceMap, err := srcmap.Build(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to index spec positions: %w", err)
+	}
+	spec.SourceMap = sourceMap
+
 	return &spec, nil
 }
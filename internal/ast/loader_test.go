@@ -184,3 +184,19 @@ func TestLoadSpec_EmptySpec(t *testing.T) {
 		t.Errorf("expected file 'test.allium', got %q", spec.File)
 	}
 }
+
+func TestLoadSpec_IndexesPositions(t *testing.T) {
+	examplePath := filepath.Join("..", "..", "schemas", "v1", "examples", "password-auth.allium.json")
+
+	spec, err := LoadSpec(examplePath)
+	if err != nil {
+		t.Fatalf("LoadSpec returned error: %v", err)
+	}
+	span, ok := spec.SourceMap["$.entities[0]"]
+	if !ok {
+		t.Fatal("expected a span for $.entities[0]")
+	}
+	if span.Start.Line <= 1 || span.Start.Column < 1 || span.End.Line <= span.Start.Line {
+		t.Errorf("unexpected span for $.entities[0]: %+v", span)
+	}
+}
@@ -1,7 +1,11 @@
 // Package ast defines the Go types for deserializing Allium specification JSON AST files.
 package ast
 
-import "encoding/json"
+import (
+	"encoding/json"
+
+	"github.com/foundry-zero/allium/internal/srcmap"
+)
 
 // Spec is the top-level representation of an Allium specification file.
 type Spec struct {
@@ -12,6 +16,7 @@ type Spec struct {
 	Given            []GivenBinding   `json:"given"`
 	ExternalEntities []ExternalEntity `json:"external_entities"`
 	ValueTypes       []ValueType      `json:"value_types"`
+	TypeAliases      []TypeAlias      `json:"type_aliases,omitempty"`
 	Enumerations     []Enumeration    `json:"enumerations"`
 	Entities         []Entity         `json:"entities"`
 	Variants         []Variant        `json:"variants"`
@@ -22,12 +27,32 @@ type Spec struct {
 	Surfaces         []Surface        `json:"surfaces"`
 	Deferred         []Deferred       `json:"deferred"`
 	OpenQuestions    []string         `json:"open_questions"`
+	Suppressions     []Suppression    `json:"suppressions,omitempty"`
+
+	// SourceMap records where each value is in the source file.
+	// It is populated by LoadSpec and nil for specs built in memory.
+	SourceMap srcmap.Map `json:"-"`
+}
+
+// Suppression marks findings of a rule or warning as intentional, either
+// across the whole file or within the subtree at Path.
+type Suppression struct {
+	Rule   string `json:"rule"`             // e.g. "RULE-08" or "WARN-20"
+	Path   string `json:"path,omitempty"`   // JSONPath subtree, e.g. "$.rules[3]"
+	Reason string `json:"reason,omitempty"` // why the finding is acceptable
 }
 
 // Metadata holds optional file-level metadata.
 type Metadata struct {
-	Scope       string `json:"scope,omitempty"`
-	Description string `json:"description,omitempty"`
+	Scope       string   `json:"scope,omitempty"`
+	Description string   `json:"description,omitempty"`
+	SpecVersion string   `json:"spec_version,omitempty"` // semantic version of the spec, e.g. "1.4.0"
+	Authors     []string `json:"authors,omitempty"`
+	ReviewedAt  string   `json:"reviewed_at,omitempty"` // date or RFC 3339 timestamp
+
+	// SensitiveFields marks members holding sensitive data, by type and then
+	// member, with the actors allowed to see each through surfaces.
+	SensitiveFields map[string]map[string][]string `json:"sensitive_fields,omitempty"`
 }
 
 // UseDeclaration represents an imported external spec.
@@ -63,11 +88,27 @@ type Enumeration struct {
 
 // Entity is a domain concept with identity and lifecycle.
 type Entity struct {
-	Name          string         `json:"name"`
-	Fields        []Field        `json:"fields"`
-	Relationships []Relationship `json:"relationships,omitempty"`
-	Projections   []Projection   `json:"projections,omitempty"`
-	DerivedValues []DerivedValue `json:"derived_values,omitempty"`
+	Name          string             `json:"name"`
+	Fields        []Field            `json:"fields"`
+	Relationships []Relationship     `json:"relationships,omitempty"`
+	Projections   []Projection       `json:"projections,omitempty"`
+	DerivedValues []DerivedValue     `json:"derived_values,omitempty"`
+	Retention     *Retention         `json:"retention,omitempty"`
+	Unique        []UniqueConstraint `json:"unique,omitempty"`
+}
+
+// Retention declares how long entity instances are kept before expiry.
+// Expired instances are removed, or moved to ArchivalState when set.
+type Retention struct {
+	Duration      string `json:"duration"`                 // e.g. "90.days"
+	From          string `json:"from,omitempty"`           // Timestamp field the period starts from
+	ArchivalState string `json:"archival_state,omitempty"` // status value for archived instances
+}
+
+// UniqueConstraint declares that no two instances of an entity share the
+// same values for Fields.
+type UniqueConstraint struct {
+	Fields []string `json:"fields"`
 }
 
 // Variant is one alternative in a sum type.
@@ -83,14 +124,20 @@ type Field struct {
 	Type FieldType `json:"type"`
 }
 
+// TypeAlias names a field type so it can be reused across fields.
+type TypeAlias struct {
+	Name string    `json:"name"`
+	Type FieldType `json:"type"`
+}
+
 // FieldType represents the type of a field, discriminated by Kind.
-// Kind is one of: primitive, entity_ref, inline_enum, named_enum, optional, set, list.
+// Kind is one of: primitive, entity_ref, inline_enum, named_enum, optional, set, list, alias.
 type FieldType struct {
 	Kind    string     `json:"kind"`
 	Value   string     `json:"value,omitempty"`   // primitive: "String", "Integer", etc.
 	Entity  string     `json:"entity,omitempty"`  // entity_ref
 	Values  []string   `json:"values,omitempty"`  // inline_enum
-	Name    string     `json:"name,omitempty"`    // named_enum
+	Name    string     `json:"name,omitempty"`    // named_enum, alias
 	Inner   *FieldType `json:"inner,omitempty"`   // optional
 	Element *FieldType `json:"element,omitempty"` // set, list
 }
@@ -329,10 +376,14 @@ type ProvideArgument struct {
 	Expression *Expression `json:"expression,omitempty"`
 }
 
-// Guarantee is a constraint that must hold across a boundary.
+// Guarantee is a constraint that must hold across a boundary. It may state
+// the constraint as an expression over the surface's bindings, and name the
+// rules that keep it.
 type Guarantee struct {
-	Name        string `json:"name"`
-	Description string `json:"description,omitempty"`
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Expression  *Expression `json:"expression,omitempty"`
+	Rules       []string    `json:"rules,omitempty"`
 }
 
 // RelatedItem references an associated surface reachable from the current one.
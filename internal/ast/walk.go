@@ -0,0 +1,277 @@
+package ast
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"strconv"
+)
+
+// Walk calls fn for expr and every expression below it, each node before its
+// children and children in the order of Children. When fn returns false the
+// children of that node are skipped. Walk keeps its own stack rather than
+// recursing, so generated specs with deeply nested expressions cannot exhaust
+// the goroutine stack.
+func Walk(expr *Expression, fn func(*Expression) bool) {
+	if expr == nil {
+		return
+	}
+	stack := []*Expression{expr}
+	for len(stack) > 0 {
+		e := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if !fn(e) {
+			continue
+		}
+		n := len(stack)
+		stack = e.appendChildren(stack)
+		slices.Reverse(stack[n:])
+	}
+}
+
+// Path is a JSONPath built a segment at a time. Extending a path is constant
+// time and shares the segments above it; the path is only spelled out by
+// String. Walks can then track the path of every node they visit, however
+// deeply nested, and format only the paths they report.
+type Path struct {
+	parent *Path
+	key    string
+	index  int // -1 unless the segment is indexed, as in "arguments[2]"
+}
+
+// NewPath returns the path base, such as "$.rules[0].requires[1]", for
+// extending with Field and Index.
+func NewPath(base string) *Path {
+	return &Path{key: base, index: -1}
+}
+
+// Field returns the path of the member key below p, e.g. "<p>.left".
+func (p *Path) Field(key string) *Path {
+	return &Path{parent: p, key: key, index: -1}
+}
+
+// Index returns the path of element i of the member key below p, e.g.
+// "<p>.arguments[2]".
+func (p *Path) Index(key string, i int) *Path {
+	return &Path{parent: p, key: key, index: i}
+}
+
+// String returns the path spelled out, e.g. "$.rules[0].requires[1].left".
+func (p *Path) String() string {
+	var segs []*Path
+	n := 0
+	for s := p; s != nil; s = s.parent {
+		segs = append(segs, s)
+		n += len(s.key) + 8
+	}
+	buf := make([]byte, 0, n)
+	for i := len(segs) - 1; i >= 0; i-- {
+		s := segs[i]
+		if s.parent != nil {
+			buf = append(buf, '.')
+		}
+		buf = append(buf, s.key...)
+		if s.index >= 0 {
+			buf = append(buf, '[')
+			buf = strconv.AppendInt(buf, int64(s.index), 10)
+			buf = append(buf, ']')
+		}
+	}
+	return string(buf)
+}
+
+// Visitor is called for each expression a walk reaches, with the path of the
+// expression. Returning false skips the expression's children.
+type Visitor func(e *Expression, path *Path) bool
+
+// WalkExpression is Walk with path tracking: it calls fn for expr, at path,
+// and every expression below it, at the path of its JSON key below path, e.g.
+// "$.rules[0].requires[1].left" or ".arguments[0]". Join lookup fields are
+// visited at ".fields.<name>".
+func WalkExpression(expr *Expression, path *Path, fn Visitor) {
+	if expr == nil {
+		return
+	}
+	type frame struct {
+		expr *Expression
+		path *Path
+	}
+	stack := []frame{{expr, path}}
+	for len(stack) > 0 {
+		f := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if !fn(f.expr, f.path) {
+			continue
+		}
+		n := len(stack)
+		f.expr.eachChild(func(key string, i int, c *Expression) {
+			p := f.path.Field(key)
+			if i >= 0 {
+				p = f.path.Index(key, i)
+			}
+			stack = append(stack, frame{c, p})
+		})
+		slices.Reverse(stack[n:])
+	}
+}
+
+// Children returns the expressions directly below e: its single-valued
+// children, then function arguments, set literal elements and join lookup
+// field values, the last in field name order.
+func (e *Expression) Children() []*Expression {
+	return e.appendChildren(nil)
+}
+
+// appendChildren appends the children of e to children in the order of
+// Children.
+func (e *Expression) appendChildren(children []*Expression) []*Expression {
+	for _, c := range e.singleChildren() {
+		if c.expr != nil {
+			children = append(children, c.expr)
+		}
+	}
+	for i := range e.FuncArguments {
+		children = append(children, &e.FuncArguments[i])
+	}
+	for i := range e.Elements {
+		children = append(children, &e.Elements[i])
+	}
+	if len(e.Fields) == 0 {
+		return children
+	}
+	for _, name := range slices.Sorted(maps.Keys(e.Fields)) {
+		v := e.Fields[name]
+		children = append(children, &v)
+	}
+	return children
+}
+
+// childExpression is a single-valued child of an expression and its JSON key.
+type childExpression struct {
+	key  string
+	expr *Expression
+}
+
+// singleChildren returns the single-valued children of e, nil or not, in the
+// order of Children.
+func (e *Expression) singleChildren() [10]childExpression {
+	return [10]childExpression{
+		{"object", e.Object},
+		{"left", e.Left},
+		{"right", e.Right},
+		{"target", e.Target},
+		{"operand", e.Operand},
+		{"collection", e.Collection},
+		{"lambda", e.Lambda},
+		{"condition", e.Condition},
+		{"body", e.Body},
+		{"element", e.Element},
+	}
+}
+
+// eachChild calls fn for each child of e in the order of Children, with its
+// JSON key and, for function arguments and set literal elements, its index.
+// The index of any other child is -1; a join lookup field's key is
+// "fields.<name>".
+func (e *Expression) eachChild(fn func(key string, i int, c *Expression)) {
+	for _, c := range e.singleChildren() {
+		if c.expr != nil {
+			fn(c.key, -1, c.expr)
+		}
+	}
+	for i := range e.FuncArguments {
+		fn("arguments", i, &e.FuncArguments[i])
+	}
+	for i := range e.Elements {
+		fn("elements", i, &e.Elements[i])
+	}
+	if len(e.Fields) == 0 {
+		return
+	}
+	for _, name := range slices.Sorted(maps.Keys(e.Fields)) {
+		v := e.Fields[name]
+		fn("fields."+name, -1, &v)
+	}
+}
+
+// ClauseExpression is an expression held directly by an ensures clause and
+// its JSONPath.
+type ClauseExpression struct {
+	Expr *Expression
+	Path string
+}
+
+// Expressions returns the expressions held directly by ec, the clause at
+// path, leaving out those of nested clauses: its target, condition,
+// collection and value, then its fields and arguments in name order. A value
+// is included when it decodes as an expression; an entity_creation value
+// decodes as one whose fields are those of the created entity.
+func (ec *EnsuresClause) Expressions(path string) []ClauseExpression {
+	var exprs []ClauseExpression
+	for _, c := range []ClauseExpression{
+		{ec.Target, path + ".target"},
+		{ec.Condition, path + ".condition"},
+		{ec.Collection, path + ".collection"},
+	} {
+		if c.Expr != nil {
+			exprs = append(exprs, c)
+		}
+	}
+	if len(ec.Value) > 0 {
+		var value Expression
+		if err := json.Unmarshal(ec.Value, &value); err == nil && value.Kind != "" {
+			exprs = append(exprs, ClauseExpression{&value, path + ".value"})
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(ec.Fields)) {
+		v := ec.Fields[name]
+		exprs = append(exprs, ClauseExpression{&v, path + ".fields." + name})
+	}
+	for _, name := range slices.Sorted(maps.Keys(ec.Arguments)) {
+		v := ec.Arguments[name]
+		exprs = append(exprs, ClauseExpression{&v, path + ".arguments." + name})
+	}
+	return exprs
+}
+
+// WalkClauses calls fn for ec, the clause at path, and every clause nested in
+// it, each clause before its then, else and body clauses, in that order.
+// Returning false skips the nested clauses of that clause.
+func WalkClauses(ec *EnsuresClause, path string, fn func(ec *EnsuresClause, path string) bool) {
+	if !fn(ec, path) {
+		return
+	}
+	for _, nested := range []struct {
+		key     string
+		clauses []EnsuresClause
+	}{{"then", ec.Then}, {"else", ec.Else}, {"body", ec.Body}} {
+		for i := range nested.clauses {
+			WalkClauses(&nested.clauses[i], IndexPath(path, nested.key, i), fn)
+		}
+	}
+}
+
+// WalkEnsures calls fn, as WalkExpression does, for every expression in ec,
+// the clause at path, and the clauses nested in it, clause by clause in the
+// order of WalkClauses and within a clause in the order of Expressions.
+func WalkEnsures(ec *EnsuresClause, path string, fn Visitor) {
+	WalkClauses(ec, path, func(c *EnsuresClause, p string) bool {
+		for _, e := range c.Expressions(p) {
+			WalkExpression(e.Expr, NewPath(e.Path), fn)
+		}
+		return true
+	})
+}
+
+// IndexPath appends an indexed segment to a JSONPath, e.g.
+// IndexPath("$.rules[0]", "ensures", 2) returns "$.rules[0].ensures[2]".
+func IndexPath(base, key string, i int) string {
+	buf := make([]byte, 0, len(base)+len(key)+8)
+	buf = append(buf, base...)
+	buf = append(buf, '.')
+	buf = append(buf, key...)
+	buf = append(buf, '[')
+	buf = strconv.AppendInt(buf, int64(i), 10)
+	buf = append(buf, ']')
+	return string(buf)
+}
@@ -0,0 +1,207 @@
+package ast
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func field(name string) *Expression {
+	return &Expression{Kind: "field_access", Field: name}
+}
+
+func TestWalkOrder(t *testing.T) {
+	expr := &Expression{Kind: "boolean_logic", Operator: "and",
+		Left: &Expression{Kind: "not", Operand: field("a")},
+		Right: &Expression{Kind: "join_lookup", Entity: "Membership", Fields: map[string]Expression{
+			"workspace": *field("c"), "user": *field("b"),
+		}},
+	}
+	var got []string
+	Walk(expr, func(e *Expression) bool {
+		got = append(got, e.Kind+":"+e.Field)
+		return true
+	})
+	want := []string{"boolean_logic:", "not:", "field_access:a", "join_lookup:", "field_access:b", "field_access:c"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Walk visited %v, want %v", got, want)
+	}
+}
+
+func TestWalkSkipsChildren(t *testing.T) {
+	expr := &Expression{Kind: "comparison", Left: &Expression{Kind: "not", Operand: field("a")}, Right: field("b")}
+	var got []string
+	Walk(expr, func(e *Expression) bool {
+		got = append(got, e.Kind+":"+e.Field)
+		return e.Kind != "not"
+	})
+	want := []string{"comparison:", "not:", "field_access:b"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Walk visited %v, want %v", got, want)
+	}
+	Walk(nil, func(*Expression) bool {
+		t.Error("Walk called fn for a nil expression")
+		return true
+	})
+}
+
+func TestIndexPath(t *testing.T) {
+	tests := []struct {
+		base, field string
+		i           int
+		want        string
+	}{
+		{"$", "rules", 0, "$.rules[0]"},
+		{"$.rules[3]", "ensures", 12, "$.rules[3].ensures[12]"},
+		{"$.rules[3].ensures[0]", "then", 1, "$.rules[3].ensures[0].then[1]"},
+	}
+	for _, tt := range tests {
+		if got := IndexPath(tt.base, tt.field, tt.i); got != tt.want {
+			t.Errorf("IndexPath(%q, %q, %d) = %q, want %q", tt.base, tt.field, tt.i, got, tt.want)
+		}
+	}
+}
+
+func TestPath(t *testing.T) {
+	base := NewPath("$.rules[3]")
+	for _, tt := range []struct {
+		path *Path
+		want string
+	}{
+		{base, "$.rules[3]"},
+		{base.Index("ensures", 12), "$.rules[3].ensures[12]"},
+		{base.Index("requires", 0).Field("left").Index("arguments", 1), "$.rules[3].requires[0].left.arguments[1]"},
+		{base.Field("fields.user"), "$.rules[3].fields.user"},
+	} {
+		if got := tt.path.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestWalkExpressionPaths(t *testing.T) {
+	expr := &Expression{Kind: "boolean_logic", Operator: "or",
+		Left: &Expression{Kind: "function_call", FuncName: "now", FuncArguments: []Expression{*field("a"), *field("b")}},
+		Right: &Expression{Kind: "collection_op", Operation: "any", Collection: field("sessions"),
+			Lambda: &Expression{Kind: "lambda", Parameter: "s", Body: &Expression{Kind: "join_lookup", Entity: "Membership",
+				Fields: map[string]Expression{"user": *field("s")}}}},
+	}
+	var got []string
+	WalkExpression(expr, NewPath("$.rules[0].requires[1]"), func(e *Expression, path *Path) bool {
+		got = append(got, path.String())
+		return e.Kind != "lambda" || e.Parameter != "skip"
+	})
+	want := []string{
+		"$.rules[0].requires[1]",
+		"$.rules[0].requires[1].left",
+		"$.rules[0].requires[1].left.arguments[0]",
+		"$.rules[0].requires[1].left.arguments[1]",
+		"$.rules[0].requires[1].right",
+		"$.rules[0].requires[1].right.collection",
+		"$.rules[0].requires[1].right.lambda",
+		"$.rules[0].requires[1].right.lambda.body",
+		"$.rules[0].requires[1].right.lambda.body.fields.user",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("WalkExpression visited\n%v\nwant\n%v", got, want)
+	}
+
+	expr.Right.Lambda.Parameter = "skip"
+	got = nil
+	WalkExpression(expr, NewPath("$"), func(e *Expression, path *Path) bool {
+		got = append(got, path.String())
+		return e.Kind != "lambda" || e.Parameter != "skip"
+	})
+	if last := got[len(got)-1]; last != "$.right.lambda" {
+		t.Errorf("WalkExpression visited %q after a skipped lambda", last)
+	}
+}
+
+func TestWalkEnsures(t *testing.T) {
+	ec := &EnsuresClause{Kind: "conditional", Condition: field("ok"),
+		Then: []EnsuresClause{{Kind: "trigger_emission", Name: "Sent",
+			Arguments: map[string]Expression{"to": *field("b"), "by": *field("a")}}},
+		Else: []EnsuresClause{{Kind: "iteration", Binding: "s", Collection: field("sessions"),
+			Body: []EnsuresClause{{Kind: "state_change", Target: field("status"),
+				Value: json.RawMessage(`{"kind": "literal", "type": "enum_value", "value": "closed"}`)}}}},
+	}
+	var got []string
+	WalkEnsures(ec, "$.rules[0].ensures[0]", func(e *Expression, path *Path) bool {
+		got = append(got, path.String())
+		return true
+	})
+	want := []string{
+		"$.rules[0].ensures[0].condition",
+		"$.rules[0].ensures[0].then[0].arguments.by",
+		"$.rules[0].ensures[0].then[0].arguments.to",
+		"$.rules[0].ensures[0].else[0].collection",
+		"$.rules[0].ensures[0].else[0].body[0].target",
+		"$.rules[0].ensures[0].else[0].body[0].value",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("WalkEnsures visited\n%v\nwant\n%v", got, want)
+	}
+
+	var clauses []string
+	WalkClauses(ec, "$", func(c *EnsuresClause, path string) bool {
+		clauses = append(clauses, path)
+		return c.Kind != "iteration"
+	})
+	if want := []string{"$", "$.then[0]", "$.else[0]"}; !slices.Equal(clauses, want) {
+		t.Errorf("WalkClauses visited %v, want %v", clauses, want)
+	}
+}
+
+func TestEnsuresExpressionsSkipsNonExpressionValue(t *testing.T) {
+	ec := &EnsuresClause{Kind: "state_change", Target: field("count"), Value: json.RawMessage(`3`)}
+	if exprs := ec.Expressions("$"); len(exprs) != 1 || exprs[0].Path != "$.target" {
+		t.Errorf("Expressions = %v, want only the target", exprs)
+	}
+}
+
+// deepExpression returns a chain of n negations around a field access, the
+// shape that exhausts a recursive walker.
+func deepExpression(n int) *Expression {
+	e := field("x")
+	for range n {
+		e = &Expression{Kind: "not", Operand: e}
+	}
+	return e
+}
+
+// wideExpression returns a set literal of n field accesses.
+func wideExpression(n int) *Expression {
+	e := &Expression{Kind: "set_literal", Elements: make([]Expression, n)}
+	for i := range e.Elements {
+		e.Elements[i] = *field("x")
+	}
+	return e
+}
+
+func TestWalkDeepExpression(t *testing.T) {
+	const depth = 1_000_000
+	nodes := 0
+	Walk(deepExpression(depth), func(*Expression) bool {
+		nodes++
+		return true
+	})
+	if nodes != depth+1 {
+		t.Errorf("Walk visited %d nodes, want %d", nodes, depth+1)
+	}
+}
+
+// BenchmarkWalk walks 10k-node expressions nested as deeply and as widely as
+// they can be.
+func BenchmarkWalk(b *testing.B) {
+	for name, expr := range map[string]*Expression{
+		"deep": deepExpression(10_000),
+		"wide": wideExpression(10_000),
+	} {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				Walk(expr, func(*Expression) bool { return true })
+			}
+		})
+	}
+}
@@ -0,0 +1,293 @@
+// Package bundle reads spec bundles: a directory, tar archive (optionally
+// gzipped) or zip archive holding a workspace of .allium.json files and a
+// manifest listing the coordinate each member is published under.
+package bundle
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ManifestName is the name of a bundle's manifest. Member paths are relative
+// to the directory holding it, which need not be the archive's root: an
+// archive of a single directory is read from within that directory.
+const ManifestName = "allium-bundle.json"
+
+// Manifest lists the specs of a bundle.
+type Manifest struct {
+	// Name optionally identifies the bundle, e.g. "acme-billing".
+	Name string `json:"name,omitempty"`
+	// Specs maps the coordinates that use_declarations import to members.
+	Specs []Entry `json:"specs"`
+}
+
+// Entry publishes the member at Path under Coordinate, such as
+// "github.com/acme/billing@1.2.0" for "billing.allium.json".
+type Entry struct {
+	Coordinate string `json:"coordinate"`
+	Path       string `json:"path"`
+}
+
+// Member is a spec file in a bundle.
+type Member struct {
+	// Name is the member's slash-separated path relative to the manifest.
+	Name string
+	// Data is the member's content, or nil when Size is over the limit
+	// passed to Open.
+	Data []byte
+	// Size is the member's size in bytes.
+	Size int64
+}
+
+// Bundle is an opened spec bundle.
+type Bundle struct {
+	// Path is the directory or archive the bundle was opened from.
+	Path string
+	// Manifest is the decoded manifest and ManifestData its source.
+	Manifest     Manifest
+	ManifestData []byte
+	// Members are the bundle's .allium.json files, by name.
+	Members []Member
+}
+
+// MemberPath returns the path under which to report a member: its name
+// within the bundle joined to the bundle's path, as in
+// "specs.tgz/billing.allium.json".
+func (b *Bundle) MemberPath(name string) string {
+	return filepath.Join(b.Path, filepath.FromSlash(name))
+}
+
+// Member returns the member with the given name, which is cleaned first so
+// that "./billing.allium.json" finds "billing.allium.json", or nil if there
+// is none.
+func (b *Bundle) Member(name string) *Member {
+	name = path.Clean(name)
+	i, ok := slices.BinarySearchFunc(b.Members, name, func(m Member, name string) int {
+		return strings.Compare(m.Name, name)
+	})
+	if !ok {
+		return nil
+	}
+	return &b.Members[i]
+}
+
+// file is a regular file found while reading a bundle, read lazily so that
+// files other than specs and the manifest are never loaded.
+type file struct {
+	name string
+	size int64
+	read func() ([]byte, error)
+}
+
+// Open reads the bundle at path: a directory, a .tar, .tar.gz or .tgz
+// archive, or a .zip archive. Members larger than maxSize bytes, when it is
+// positive, are listed without their content. Open fails if the bundle has
+// no manifest, or more than one at the shallowest level, if the manifest is
+// not valid JSON, or if an archive entry's path would leave the bundle.
+func Open(bundlePath string, maxSize int64) (*Bundle, error) {
+	info, err := os.Stat(bundlePath)
+	if err != nil {
+		return nil, fmt.Errorf("open bundle: %w", err)
+	}
+	var files []file
+	var closeFn func() error
+	switch lower := strings.ToLower(bundlePath); {
+	case info.IsDir():
+		files, err = dirFiles(bundlePath)
+	case strings.HasSuffix(lower, ".zip"):
+		files, closeFn, err = zipFiles(bundlePath)
+	case strings.HasSuffix(lower, ".tar"), strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		files, err = tarFiles(bundlePath, !strings.HasSuffix(lower, ".tar"), maxSize)
+	default:
+		return nil, fmt.Errorf("open bundle %s: unsupported format (use a directory, .tar, .tar.gz, .tgz or .zip)", bundlePath)
+	}
+	if closeFn != nil {
+		defer closeFn()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open bundle %s: %w", bundlePath, err)
+	}
+
+	b, err := load(files, maxSize)
+	if err != nil {
+		return nil, fmt.Errorf("open bundle %s: %w", bundlePath, err)
+	}
+	b.Path = bundlePath
+	return b, nil
+}
+
+// load finds the manifest among files and reads it and the spec files
+// beside or beneath it.
+func load(files []file, maxSize int64) (*Bundle, error) {
+	var manifests []file
+	for _, f := range files {
+		if path.Base(f.name) == ManifestName {
+			manifests = append(manifests, f)
+		}
+	}
+	if len(manifests) == 0 {
+		return nil, fmt.Errorf("no %s manifest", ManifestName)
+	}
+	depth := func(f file) int { return strings.Count(f.name, "/") }
+	slices.SortFunc(manifests, func(a, b file) int { return depth(a) - depth(b) })
+	if len(manifests) > 1 && depth(manifests[0]) == depth(manifests[1]) {
+		return nil, fmt.Errorf("more than one %s manifest (%s and %s)", ManifestName, manifests[0].name, manifests[1].name)
+	}
+
+	b := &Bundle{}
+	data, err := manifests[0].read()
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", manifests[0].name, err)
+	}
+	if err := json.Unmarshal(data, &b.Manifest); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", manifests[0].name, err)
+	}
+	b.ManifestData = data
+
+	root := path.Dir(manifests[0].name)
+	for _, f := range files {
+		name := f.name
+		if root != "." {
+			var ok bool
+			if name, ok = strings.CutPrefix(name, root+"/"); !ok {
+				continue
+			}
+		}
+		if !strings.HasSuffix(name, ".allium.json") {
+			continue
+		}
+		m := Member{Name: name, Size: f.size}
+		if maxSize <= 0 || f.size <= maxSize {
+			if m.Data, err = f.read(); err != nil {
+				return nil, fmt.Errorf("read %s: %w", f.name, err)
+			}
+		}
+		b.Members = append(b.Members, m)
+	}
+	slices.SortFunc(b.Members, func(a, b Member) int { return strings.Compare(a.Name, b.Name) })
+	return b, nil
+}
+
+// entryName cleans the name of an archive entry, rejecting names that are
+// absolute or climb out of the archive.
+func entryName(name string) (string, error) {
+	clean := path.Clean(strings.ReplaceAll(name, "\\", "/"))
+	if path.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("entry %q is outside the bundle", name)
+	}
+	return clean, nil
+}
+
+// dirFiles lists the regular files beneath dir.
+func dirFiles(dir string) ([]file, error) {
+	var files []file
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, file{name: filepath.ToSlash(rel), size: info.Size(), read: func() ([]byte, error) {
+			return os.ReadFile(p)
+		}})
+		return nil
+	})
+	return files, err
+}
+
+// zipFiles lists the regular files in a zip archive, which must stay open
+// until they have been read; the returned function closes it.
+func zipFiles(archive string) ([]file, func() error, error) {
+	zr, err := zip.OpenReader(archive)
+	if err != nil {
+		return nil, nil, err
+	}
+	var files []file
+	for _, zf := range zr.File {
+		if !zf.Mode().IsRegular() {
+			continue
+		}
+		name, err := entryName(zf.Name)
+		if err != nil {
+			zr.Close()
+			return nil, nil, err
+		}
+		size := int64(zf.UncompressedSize64)
+		files = append(files, file{name: name, size: size, read: func() ([]byte, error) {
+			rc, err := zf.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer rc.Close()
+			// The declared size bounds the read, so a forged header
+			// cannot make it unbounded.
+			return io.ReadAll(io.LimitReader(rc, size))
+		}})
+	}
+	return files, zr.Close, nil
+}
+
+// tarFiles reads the regular files of a tar archive, gunzipping it first
+// when compressed. A tar archive can only be read in order, so manifests
+// and specs are read as they are met; other files and specs over maxSize
+// are skipped.
+func tarFiles(archive string, compressed bool, maxSize int64) ([]file, error) {
+	f, err := os.Open(archive)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if compressed {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var files []file
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		name, err := entryName(hdr.Name)
+		if err != nil {
+			return nil, err
+		}
+		var data []byte
+		manifest, spec := path.Base(name) == ManifestName, strings.HasSuffix(name, ".allium.json")
+		if manifest || spec && (maxSize <= 0 || hdr.Size <= maxSize) {
+			if data, err = io.ReadAll(tr); err != nil {
+				return nil, err
+			}
+		}
+		files = append(files, file{name: name, size: hdr.Size, read: func() ([]byte, error) { return data, nil }})
+	}
+}
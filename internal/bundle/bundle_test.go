@@ -0,0 +1,190 @@
+package bundle
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const manifest = `{"name": "acme", "specs": [{"coordinate": "acme:billing@1", "path": "billing.allium.json"}]}`
+
+// files are the contents of a bundle, by slash-separated name.
+type files map[string]string
+
+func writeDir(t *testing.T, fs files) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range fs {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func writeTar(t *testing.T, name string, fs files, compress bool) string {
+	t.Helper()
+	var buf bytes.Buffer
+	var gz *gzip.Writer
+	tw := tar.NewWriter(&buf)
+	if compress {
+		gz = gzip.NewWriter(&buf)
+		tw = tar.NewWriter(gz)
+	}
+	for _, n := range sortedNames(fs) {
+		if err := tw.WriteHeader(&tar.Header{Name: n, Mode: 0644, Size: int64(len(fs[n])), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(fs[n])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func writeZip(t *testing.T, fs files) string {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, n := range sortedNames(fs) {
+		w, err := zw.Create(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(fs[n])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "specs.zip")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func sortedNames(fs files) []string {
+	var names []string
+	for n := range fs {
+		names = append(names, n)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func memberNames(b *Bundle) []string {
+	var names []string
+	for _, m := range b.Members {
+		names = append(names, m.Name)
+	}
+	return names
+}
+
+func TestOpen(t *testing.T) {
+	fs := files{
+		"specs/allium-bundle.json":          manifest,
+		"specs/billing.allium.json":         `{"version": "1"}`,
+		"specs/orders/orders.allium.json":   `{"version": "1"}`,
+		"specs/README.md":                   "not a spec",
+		"specs/fixtures/allium-bundle.json": `{"specs": []}`,
+	}
+	for name, path := range map[string]string{
+		"directory": writeDir(t, fs),
+		"tar":       writeTar(t, "specs.tar", fs, false),
+		"tgz":       writeTar(t, "specs.tgz", fs, true),
+		"tar.gz":    writeTar(t, "specs.tar.gz", fs, true),
+		"zip":       writeZip(t, fs),
+	} {
+		t.Run(name, func(t *testing.T) {
+			b, err := Open(path, 0)
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			if b.Path != path || b.Manifest.Name != "acme" || len(b.Manifest.Specs) != 1 {
+				t.Errorf("unexpected bundle %s with manifest %+v", b.Path, b.Manifest)
+			}
+			if want := []string{"billing.allium.json", "orders/orders.allium.json"}; !slices.Equal(memberNames(b), want) {
+				t.Errorf("members = %v, want %v", memberNames(b), want)
+			}
+			if m := b.Member("./billing.allium.json"); m == nil || string(m.Data) != `{"version": "1"}` {
+				t.Errorf("Member(./billing.allium.json) = %+v", m)
+			}
+			if b.Member("missing.allium.json") != nil {
+				t.Error("expected no member for a missing name")
+			}
+		})
+	}
+}
+
+func TestOpen_MaxSize(t *testing.T) {
+	fs := files{
+		"allium-bundle.json":  manifest,
+		"billing.allium.json": `{"version": "1", "file": "billing.allium"}`,
+		"small.allium.json":   `{}`,
+	}
+	for name, path := range map[string]string{"directory": writeDir(t, fs), "tgz": writeTar(t, "specs.tgz", fs, true), "zip": writeZip(t, fs)} {
+		b, err := Open(path, 10)
+		if err != nil {
+			t.Fatalf("%s: Open: %v", name, err)
+		}
+		if m := b.Member("billing.allium.json"); m == nil || m.Data != nil || m.Size != int64(len(fs["billing.allium.json"])) {
+			t.Errorf("%s: expected the large member without data, got %+v", name, m)
+		}
+		if m := b.Member("small.allium.json"); m == nil || string(m.Data) != "{}" {
+			t.Errorf("%s: expected the small member with data, got %+v", name, m)
+		}
+	}
+}
+
+func TestOpen_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{"missing", filepath.Join(t.TempDir(), "none.tgz"), "no such file"},
+		{"format", writeDir(t, files{"specs.rar": ""}) + "/specs.rar", "unsupported format"},
+		{"no manifest", writeDir(t, files{"billing.allium.json": "{}"}), "no allium-bundle.json manifest"},
+		{"two manifests", writeDir(t, files{"a/allium-bundle.json": manifest, "b/allium-bundle.json": manifest}), "more than one"},
+		{"invalid manifest", writeDir(t, files{"allium-bundle.json": "{"}), "parse allium-bundle.json"},
+		{"escaping entry", writeTar(t, "evil.tar", files{"../allium-bundle.json": manifest}, false), "outside the bundle"},
+		{"absolute entry", writeTar(t, "evil.tar", files{"/etc/allium-bundle.json": manifest}, false), "outside the bundle"},
+	}
+	for _, tt := range tests {
+		_, err := Open(tt.path, 0)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected an error containing %q, got %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+func TestMemberPath(t *testing.T) {
+	b := &Bundle{Path: "specs.tgz"}
+	if got, want := b.MemberPath("orders/orders.allium.json"), filepath.Join("specs.tgz", "orders", "orders.allium.json"); got != want {
+		t.Errorf("MemberPath = %q, want %q", got, want)
+	}
+}
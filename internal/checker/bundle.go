@@ -0,0 +1,117 @@
+package checker
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/bundle"
+	"github.com/foundry-zero/allium/internal/report"
+	"github.com/foundry-zero/allium/internal/semantic"
+	"github.com/foundry-zero/allium/internal/srcmap"
+)
+
+// bundleRules lists the rule numbers covered by the manifest check.
+var bundleRules = []int{59}
+
+// CheckBundle validates the members of a bundle as one workspace, as
+// CheckWorkspace does, except that use_declaration coordinates resolve
+// through the bundle's manifest, and checks the manifest against the members
+// (RULE-59). The first report is the manifest's, followed by one per member
+// in name order, labelled with bundle.MemberPath.
+func (c *Checker) CheckBundle(b *bundle.Bundle, opts CheckOptions) []*report.Report {
+	reports, _ := c.CheckBundleGraph(b, opts)
+	return reports
+}
+
+// CheckBundleGraph is CheckBundle that also returns the bundle's import
+// graph.
+func (c *Checker) CheckBundleGraph(b *bundle.Bundle, opts CheckOptions) ([]*report.Report, *ImportGraph) {
+	paths := make([]string, len(b.Members))
+	checks := make([]*fileCheck, len(b.Members))
+	for i, m := range b.Members {
+		paths[i] = b.MemberPath(m.Name)
+		if m.Data == nil {
+			r := report.NewReport(paths[i])
+			r.AddFinding(fileTooLarge(paths[i], m.Size, opts.MaxFileSize))
+			checks[i] = &fileCheck{report: r, opts: opts}
+			continue
+		}
+		checks[i] = c.checkSource(paths[i], m.Data, opts)
+	}
+
+	ws := semantic.NewWorkspace()
+	members := addMembers(ws, paths, checks)
+	byName := make(map[string]*semantic.WorkspaceMember, len(members))
+	for i, m := range b.Members {
+		byName[m.Name] = members[i]
+	}
+	ws.Coordinates = make(map[string]*semantic.WorkspaceMember)
+	for _, e := range b.Manifest.Specs {
+		if _, ok := ws.Coordinates[e.Coordinate]; !ok && e.Coordinate != "" {
+			ws.Coordinates[e.Coordinate] = byName[path.Clean(e.Path)]
+		}
+	}
+	graph := crossCheck(ws, members, checks, opts)
+
+	reports := []*report.Report{c.checkManifest(b, opts)}
+	for _, fc := range checks {
+		reports = append(reports, fc.finish())
+	}
+	return reports, graph
+}
+
+// checkManifest reports the manifest entries that do not match the bundle's
+// members, and the members it does not list (RULE-59).
+func (c *Checker) checkManifest(b *bundle.Bundle, opts CheckOptions) *report.Report {
+	file := b.MemberPath(bundle.ManifestName)
+	// The manifest's check has a stand-in spec so that findings are
+	// filtered, located and escalated like those of a member.
+	sourceMap, _ := srcmap.Build(b.ManifestData)
+	fc := &fileCheck{
+		report:       report.NewReport(file),
+		spec:         &ast.Spec{File: file, SourceMap: sourceMap},
+		opts:         opts,
+		suppressions: newSuppressionSet(nil),
+	}
+	fc.report.SchemaValid = true
+	if opts.SchemaOnly || !opts.selectsPass(bundleRules) {
+		return fc.finish()
+	}
+
+	finding := func(p, format string, args ...any) report.Finding {
+		return report.NewError("RULE-59", fmt.Sprintf(format, args...), report.Location{File: file, Path: p})
+	}
+	listed := make(map[string]bool)
+	first := make(map[string]int)
+	for i, e := range b.Manifest.Specs {
+		entry := fmt.Sprintf("$.specs[%d]", i)
+		switch {
+		case e.Coordinate == "":
+			fc.add(finding(entry, "Manifest entry for '%s' has no coordinate", e.Path))
+		case e.Path == "":
+			fc.add(finding(entry, "Manifest entry '%s' has no path", e.Coordinate))
+		}
+		if e.Coordinate != "" {
+			if j, ok := first[e.Coordinate]; ok {
+				fc.add(finding(entry+".coordinate", "Coordinate '%s' is listed more than once; the first entry, $.specs[%d], is used", e.Coordinate, j))
+			} else {
+				first[e.Coordinate] = i
+			}
+		}
+		if e.Path == "" {
+			continue
+		}
+		if m := b.Member(e.Path); m != nil {
+			listed[m.Name] = true
+		} else {
+			fc.add(finding(entry+".path", "Manifest entry '%s' lists '%s', which is not a spec in the bundle", e.Coordinate, path.Clean(e.Path)))
+		}
+	}
+	for _, m := range b.Members {
+		if !listed[m.Name] {
+			fc.add(finding("$.specs", "Bundle member '%s' is not listed in the manifest", m.Name))
+		}
+	}
+	return fc.finish()
+}
@@ -0,0 +1,143 @@
+package checker
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/foundry-zero/allium/internal/bundle"
+	"github.com/foundry-zero/allium/internal/report"
+)
+
+const bundleOrdersSpec = `{
+  "version": "1",
+  "file": "orders.allium",
+  "use_declarations": [
+    {"coordinate": "github.com/acme/billing@1.2.0", "alias": "billing"},
+    {"coordinate": "github.com/acme/tax@2.0.0", "alias": "tax"}
+  ],
+  "external_entities": [{"name": "Invoice", "fields": []}]
+}`
+
+const bundleManifest = `{
+  "name": "acme",
+  "specs": [
+    {"coordinate": "github.com/acme/billing@1.2.0", "path": "./billing.allium.json"},
+    {"coordinate": "github.com/acme/shipping@1.0.0", "path": "shipping.allium.json"},
+    {"coordinate": "github.com/acme/billing@1.2.0", "path": "orders.allium.json"},
+    {"path": "orders.allium.json"}
+  ]
+}`
+
+// ruleFindings returns the paths and messages of r's findings of rule.
+func ruleFindings(r *report.Report, rule string) []string {
+	var got []string
+	for _, f := range r.Findings() {
+		if f.Rule == rule {
+			got = append(got, f.Location.Path+": "+f.Message)
+		}
+	}
+	return got
+}
+
+func TestCheckBundle(t *testing.T) {
+	dir := writeWorkspace(t, map[string]string{
+		"allium-bundle.json":  bundleManifest,
+		"billing.allium.json": billingSpec,
+		"orders.allium.json":  bundleOrdersSpec,
+		"extra.allium.json":   `{"version": "1", "file": "extra.allium"}`,
+	})
+	b, err := bundle.Open(dir, 0)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	c, err := NewChecker()
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+
+	reports := c.CheckBundle(b, CheckOptions{})
+	var files []string
+	for _, r := range reports {
+		files = append(files, r.File)
+	}
+	want := []string{
+		filepath.Join(dir, "allium-bundle.json"),
+		filepath.Join(dir, "billing.allium.json"),
+		filepath.Join(dir, "extra.allium.json"),
+		filepath.Join(dir, "orders.allium.json"),
+	}
+	if !slices.Equal(files, want) {
+		t.Fatalf("reports = %v, want %v", files, want)
+	}
+
+	if got, want := ruleFindings(reports[0], "RULE-59"), []string{
+		"$.specs[1].path: Manifest entry 'github.com/acme/shipping@1.0.0' lists 'shipping.allium.json', which is not a spec in the bundle",
+		"$.specs[2].coordinate: Coordinate 'github.com/acme/billing@1.2.0' is listed more than once; the first entry, $.specs[0], is used",
+		"$.specs[3]: Manifest entry for 'orders.allium.json' has no coordinate",
+		"$.specs: Bundle member 'extra.allium.json' is not listed in the manifest",
+	}; !slices.Equal(got, want) {
+		t.Errorf("manifest RULE-59 = %q, want %q", got, want)
+	}
+	if reports[0].Errors[0].Location.Line == 0 {
+		t.Error("expected manifest findings to have lines")
+	}
+
+	// The listed coordinate resolves through the manifest, so its external
+	// entity is checked against billing; the unlisted one is RULE-59.
+	orders := reports[3]
+	if got := ruleFindings(orders, "RULE-35"); len(got) != 0 {
+		t.Errorf("unexpected RULE-35 in orders: %q", got)
+	}
+	if got, want := ruleFindings(orders, "RULE-59"), []string{
+		"$.use_declarations[1].coordinate: Use declaration 'tax' coordinate 'github.com/acme/tax@2.0.0' is not listed in the bundle manifest",
+	}; !slices.Equal(got, want) {
+		t.Errorf("orders RULE-59 = %q, want %q", got, want)
+	}
+
+	// Rule selection skips the manifest check.
+	reports = c.CheckBundle(b, CheckOptions{RuleIDs: []string{"RULE-35"}})
+	if len(reports[0].Errors) != 0 || len(ruleFindings(reports[3], "RULE-59")) != 0 {
+		t.Errorf("expected no RULE-59 when only RULE-35 is selected, got %v and %v", reports[0].Errors, reports[3].Errors)
+	}
+}
+
+func TestCheckBundle_ListedMemberMissing(t *testing.T) {
+	dir := writeWorkspace(t, map[string]string{
+		"allium-bundle.json": `{"specs": [{"coordinate": "github.com/acme/billing@1.2.0", "path": "billing.allium.json"},
+			{"coordinate": "orders", "path": "orders.allium.json"}]}`,
+		"orders.allium.json": bundleOrdersSpec,
+	})
+	b, err := bundle.Open(dir, 0)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	c, err := NewChecker()
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+	orders := c.CheckBundle(b, CheckOptions{})[1]
+	// A listed coordinate without its member does not resolve, but is listed.
+	if got := ruleFindings(orders, "RULE-35"); len(got) != 1 || got[0][:33] != "$.use_declarations[0].coordinate:" {
+		t.Errorf("expected RULE-35 for the listed billing coordinate, got %q", got)
+	}
+}
+
+func TestCheckBundle_MaxFileSize(t *testing.T) {
+	dir := writeWorkspace(t, map[string]string{
+		"allium-bundle.json":  `{"specs": [{"coordinate": "billing", "path": "billing.allium.json"}]}`,
+		"billing.allium.json": billingSpec,
+	})
+	b, err := bundle.Open(dir, 16)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	c, err := NewChecker()
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+	billing := c.CheckBundle(b, CheckOptions{MaxFileSize: 16})[1]
+	if len(billing.Errors) != 1 || billing.Errors[0].Rule != "INPUT" {
+		t.Errorf("expected an INPUT error for the large member, got %v", billing.Errors)
+	}
+}
@@ -3,18 +3,31 @@
 package checker
 
 import (
+	"cmp"
 	"fmt"
 	"os"
 	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/config"
 	"github.com/foundry-zero/allium/internal/report"
 	"github.com/foundry-zero/allium/internal/schema"
 	"github.com/foundry-zero/allium/internal/semantic"
+	"github.com/foundry-zero/allium/internal/template"
 )
 
 // PassFunc is a semantic validation pass that inspects a parsed spec
 // and returns any findings (errors or warnings).
+//
+// The passes selected for a file run concurrently over the same spec and
+// symbol table, so a pass must not modify either: no assigning their fields,
+// sorting their slices in place, or writing to their maps. A pass that needs
+// a modified copy clones it first, and any state it keeps is local to the
+// call.
 type PassFunc func(*ast.Spec, *semantic.SymbolTable) []report.Finding
 
 // CheckOptions controls which validation passes to run.
@@ -22,6 +35,54 @@ type CheckOptions struct {
 	SchemaOnly bool  // Only run JSON Schema validation, skip semantic passes.
 	RuleFilter []int // If non-empty, only run passes covering these rule numbers.
 	Strict     bool  // Treat warnings as errors for exit-code purposes.
+
+	// RuleIDs, if non-empty, restricts reported findings to these rule and
+	// warning IDs (e.g. "RULE-12", "WARN-05"). Passes covering none of them
+	// are skipped. It may be combined with RuleFilter.
+	RuleIDs []string
+
+	// PathFilter, if set, is a JSONPath (e.g. "$.rules[12]") restricting
+	// reported findings to that subtree. Passes still see the whole document,
+	// so declarations elsewhere continue to resolve.
+	PathFilter string
+
+	// Config, if set, is the project configuration. It overrides the
+	// severity of the rules it lists, warnings in files it marks critical
+	// are reported as errors, its initial states seed RULE-07, and its
+	// terminal states are exempt from RULE-08.
+	Config *config.Config
+
+	// DiscoverConfig, when Config is nil, looks up the project configuration
+	// of each file with config.Discover, walking up from its directory. An
+	// invalid discovered configuration is reported as an INPUT error.
+	DiscoverConfig bool
+
+	// Functions, if set, replaces the built-in function registry used to
+	// check function calls (RULE-40) and type their results.
+	Functions *semantic.FunctionRegistry
+
+	// Against, if set, is the previous version of the spec being checked.
+	// Changes from it that break its consumers are reported as RULE-42
+	// errors.
+	Against *ast.Spec
+
+	// Template, if set, is the skeleton specs must follow. Departures from
+	// it are reported as RULE-43 errors.
+	Template *template.Template
+
+	// Timings records on each report how long decoding, schema validation,
+	// parsing, building the symbol table and each semantic pass took.
+	Timings bool
+
+	// MaxFileSize, if positive, is the size in bytes above which a file is
+	// reported as an INPUT error rather than read and checked.
+	MaxFileSize int64
+
+	// Resolver, if set, fetches the specs of use_declaration coordinates
+	// that do not resolve within a workspace, so that imports of published
+	// specs are checked too (RULE-35). Only CheckWorkspace uses it; a
+	// bundle's coordinates resolve through its manifest alone.
+	Resolver CoordinateResolver
 }
 
 // passEntry binds a named semantic pass to the rule numbers it covers.
@@ -44,9 +105,16 @@ func NewChecker() (*Checker, error) {
 	if err != nil {
 		return nil, fmt.Errorf("initialize schema validator: %w", err)
 	}
+	return NewCheckerWithSchema(sv), nil
+}
+
+// NewCheckerWithSchema creates a Checker that validates files with sv, such
+// as a validator of a pre-release schema from
+// schema.NewSchemaValidatorFromFile, and has all semantic passes registered.
+func NewCheckerWithSchema(sv *schema.SchemaValidator) *Checker {
 	c := &Checker{sv: sv}
 	registerPasses(c)
-	return c, nil
+	return c
 }
 
 // RegisterPass adds a semantic validation pass to the checker.
@@ -59,55 +127,388 @@ func (c *Checker) RegisterPass(name string, rules []int, fn PassFunc) {
 // It runs schema validation first, then semantic passes (if the schema is valid
 // and SchemaOnly is not set).
 func (c *Checker) Check(path string, opts CheckOptions) *report.Report {
-	r := report.NewReport(path)
+	return c.check(path, opts).finish()
+}
+
+// CheckSource validates spec content held in memory, such as an unsaved editor
+// buffer. The path is used only to label the report and its findings.
+func (c *Checker) CheckSource(path string, data []byte, opts CheckOptions) *report.Report {
+	return c.checkSource(path, data, opts).finish()
+}
+
+// CheckSpec runs the semantic passes over a spec built in memory, such as
+// one assembled by a generator or test harness before it is serialized. There
+// is no schema validation step, so the spec is assumed to satisfy the schema
+// and the report's SchemaValid is set; SchemaOnly skips every pass. The
+// report is labelled with spec.File, and findings have paths but no lines.
+func (c *Checker) CheckSpec(spec *ast.Spec, opts CheckOptions) *report.Report {
+	r := report.NewReport(spec.File)
+	r.SchemaValid = true
+	fc := &fileCheck{report: r, opts: opts}
+	if !opts.SchemaOnly {
+		c.runPasses(fc, spec)
+	}
+	return fc.finish()
+}
+
+// fileCheck is the in-progress validation of one file. Semantic findings go
+// through add so that path filtering, suppressions and line lookup apply
+// uniformly; finish completes the report.
+type fileCheck struct {
+	report       *report.Report
+	spec         *ast.Spec // nil when the file could not be loaded or the schema was invalid
+	opts         CheckOptions
+	suppressions *suppressionSet
+}
+
+// add records a semantic finding against the file's spec.
+func (fc *fileCheck) add(f report.Finding) {
+	if !pathMatchesFilter(f.Location.Path, fc.opts.PathFilter) {
+		return
+	}
+	if len(fc.opts.RuleIDs) > 0 && !slices.Contains(fc.opts.RuleIDs, f.Rule) {
+		return
+	}
+	f = locateFinding(f, fc.spec.SourceMap)
+	// Matching marks the suppression used even when the rule is turned off,
+	// so that it is not reported as stale.
+	suppressed := fc.suppressions.match(f)
+	if fc.opts.Config.SeverityOf(f.Rule) == config.SeverityOff {
+		return
+	}
+	if suppressed {
+		fc.report.AddSuppressed(f)
+		return
+	}
+	fc.report.AddFinding(fc.escalate(f))
+}
+
+// timed records the time since start as the duration of phase, when the
+// options ask for timings.
+func (fc *fileCheck) timed(phase string, start time.Time) {
+	if fc.opts.Timings {
+		fc.report.AddTiming(phase, time.Since(start))
+	}
+}
+
+// escalate applies the project configuration's severity override for the
+// finding's rule, then raises a warning to an error when the file is
+// critical.
+func (fc *fileCheck) escalate(f report.Finding) report.Finding {
+	switch fc.opts.Config.SeverityOf(f.Rule) {
+	case config.SeverityError:
+		f.Severity = report.SeverityError
+	case config.SeverityWarning:
+		f.Severity = report.SeverityWarning
+	case config.SeverityInfo:
+		f.Severity = report.SeverityInfo
+	case config.SeverityHint:
+		f.Severity = report.SeverityHint
+	}
+	if f.Severity == report.SeverityWarning && fc.opts.Config.IsCritical(fc.report.File) {
+		f.Severity = report.SeverityError
+	}
+	return f
+}
+
+// finish reports suppressions that matched nothing and returns the report.
+// Unused suppressions are only reported when every pass ran, since a filtered
+// run cannot tell whether a suppression would have matched.
+func (fc *fileCheck) finish() *report.Report {
+	if fc.spec != nil && len(fc.opts.RuleFilter) == 0 && len(fc.opts.RuleIDs) == 0 {
+		for _, f := range fc.suppressions.unused(fc.spec.File) {
+			if pathMatchesFilter(f.Location.Path, fc.opts.PathFilter) && fc.opts.Config.SeverityOf(f.Rule) != config.SeverityOff {
+				fc.report.AddFinding(fc.escalate(locateFinding(f, fc.spec.SourceMap)))
+			}
+		}
+	}
+	return fc.report
+}
 
+// check runs schema and semantic validation for a single file.
+func (c *Checker) check(path string, opts CheckOptions) *fileCheck {
 	// Verify the file is accessible before attempting validation.
-	if _, err := os.Stat(path); err != nil {
+	info, err := os.Stat(path)
+	if err != nil {
+		r := report.NewReport(path)
 		r.AddFinding(report.NewError("INPUT", fmt.Sprintf("file not found: %s", path),
 			report.Location{File: path}))
-		return r
+		return &fileCheck{report: r, opts: opts}
+	}
+	if opts.MaxFileSize > 0 && info.Size() > opts.MaxFileSize {
+		r := report.NewReport(path)
+		r.AddFinding(fileTooLarge(path, info.Size(), opts.MaxFileSize))
+		return &fileCheck{report: r, opts: opts}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		r := report.NewReport(path)
+		r.AddFinding(report.NewError("INPUT", fmt.Sprintf("failed to read file: %v", err),
+			report.Location{File: path}))
+		return &fileCheck{report: r, opts: opts}
+	}
+
+	return c.checkSource(path, data, opts)
+}
+
+// checkSource runs schema and semantic validation over the content of path.
+func (c *Checker) checkSource(path string, data []byte, opts CheckOptions) *fileCheck {
+	fc, spec := c.loadSource(path, data, opts)
+	if spec != nil {
+		c.runPasses(fc, spec)
+	}
+	return fc
+}
+
+// loadSource validates the content of path against the schema and parses it.
+// The spec is nil when the content cannot be loaded, the schema is invalid
+// or the options ask for schema validation only.
+func (c *Checker) loadSource(path string, data []byte, opts CheckOptions) (*fileCheck, *ast.Spec) {
+	r := report.NewReport(path)
+	fc := &fileCheck{report: r, opts: opts}
+
+	if opts.Config == nil && opts.DiscoverConfig {
+		cfg, err := discoverConfig(path)
+		if err != nil {
+			r.AddFinding(report.NewError("INPUT", err.Error(), report.Location{File: path}))
+			return fc, nil
+		}
+		fc.opts.Config = cfg
+	}
+
+	if opts.MaxFileSize > 0 && int64(len(data)) > opts.MaxFileSize {
+		r.AddFinding(fileTooLarge(path, int64(len(data)), opts.MaxFileSize))
+		return fc, nil
+	}
+
+	// The source is decoded once; schema validation and the AST share it.
+	start := time.Now()
+	doc, err := ast.DecodeDocument(data)
+	fc.timed("decode", start)
+	if err != nil {
+		r.AddFinding(report.NewError("INPUT", err.Error(), report.Location{File: path}))
+		return fc, nil
 	}
 
 	// --- Phase 1: JSON Schema validation ---
-	schemaErrors := c.sv.Validate(path)
+	start = time.Now()
+	schemaErrors := c.sv.ValidateDocument(doc.Value)
+	fc.timed("schema", start)
 	r.SchemaValid = len(schemaErrors) == 0
 
 	for _, se := range schemaErrors {
-		rule := "SCHEMA"
-		if se.ParseError {
-			rule = "INPUT"
+		if !pathMatchesFilter(se.Path, opts.PathFilter) {
+			continue
 		}
-		r.AddFinding(report.NewError(rule, se.Message,
-			report.Location{File: path, Path: se.Path}))
+		r.AddFinding(locateFinding(report.NewError("SCHEMA", se.Message,
+			report.Location{File: path, Path: se.Path}), doc.SourceMap))
 	}
 
 	if !r.SchemaValid || opts.SchemaOnly {
-		return r
+		return fc, nil
 	}
 
 	// --- Phase 2: Load AST ---
-	spec, err := ast.LoadSpec(path)
+	start = time.Now()
+	spec, err := doc.Spec()
+	fc.timed("parse", start)
 	if err != nil {
 		r.AddFinding(report.NewError("INPUT", fmt.Sprintf("failed to load spec: %v", err),
 			report.Location{File: path}))
-		return r
+		return fc, nil
+	}
+
+	return fc, spec
+}
+
+// fileTooLarge reports a file of size bytes that is over the limit of
+// CheckOptions.MaxFileSize.
+func fileTooLarge(path string, size, limit int64) report.Finding {
+	return report.NewError("INPUT", fmt.Sprintf("file is %s, over the maximum file size of %s; split the spec or raise the limit",
+		formatSize(size), formatSize(limit)), report.Location{File: path})
+}
+
+// formatSize renders a size in bytes in the largest unit in which it is at
+// least 1, as in "512 bytes" or "12.5 MB".
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d bytes", n)
+	}
+	value, suffix := float64(n)/unit, "KB"
+	for _, next := range []string{"MB", "GB", "TB"} {
+		if value < unit {
+			break
+		}
+		value, suffix = value/unit, next
+	}
+	return strconv.FormatFloat(value, 'f', 1, 64) + " " + suffix
+}
+
+// discoverConfig loads the project configuration found by walking up from
+// path's directory, or returns nil if there is none.
+func discoverConfig(path string) (*config.Config, error) {
+	cfgPath, err := config.Discover(path)
+	if err != nil || cfgPath == "" {
+		return nil, err
+	}
+	return config.Load(cfgPath)
+}
+
+// StateMachines returns the state machines of the spec loaded from path (see
+// semantic.StateMachines). They take the initial and terminal states of the
+// options' project configuration or, with DiscoverConfig, of the
+// configuration found above path.
+func StateMachines(path string, spec *ast.Spec, opts CheckOptions) ([]semantic.StateMachine, error) {
+	if opts.Config == nil && opts.DiscoverConfig {
+		cfg, err := discoverConfig(path)
+		if err != nil {
+			return nil, err
+		}
+		opts.Config = cfg
 	}
+	return semantic.StateMachines(spec, symbolTable(spec, opts)), nil
+}
+
+// runPasses builds the symbol table for spec and runs the semantic passes
+// selected by the options, recording their findings on fc.
+func (c *Checker) runPasses(fc *fileCheck, spec *ast.Spec) {
+	start := time.Now()
+	st := symbolTable(spec, fc.opts)
+	fc.timed("symbols", start)
+	c.runPassesWith(fc, spec, st, nil)
+}
 
-	// --- Phase 3: Build symbol table ---
+// symbolTable builds the symbol table for spec, with the function registry
+// and project configuration of the options.
+func symbolTable(spec *ast.Spec, opts CheckOptions) *semantic.SymbolTable {
 	st := semantic.BuildSymbolTable(spec)
+	if opts.Functions != nil {
+		st.Functions = opts.Functions
+	}
+	if opts.Config != nil {
+		st.TerminalStates = opts.Config.TerminalStates
+		st.InitialStates = opts.Config.InitialStates
+		st.SensitiveFields = opts.Config.SensitiveFields
+		if n := opts.Config.Naming; n != nil {
+			st.Naming = &semantic.NamingConventions{
+				Fields:            n.Fields,
+				EnumValues:        n.EnumValues,
+				Triggers:          n.Triggers,
+				SurfaceSuffix:     n.SurfaceSuffix,
+				NoEntityShadowing: n.NoEntityShadowing,
+			}
+		}
+	}
+	return st
+}
+
+// runPassesWith runs the semantic passes selected by the options over spec
+// and its symbol table st, recording their findings on fc. A pass with an
+// entry in cached is not run; the findings cached for it are recorded
+// instead. The others run concurrently, and findings are recorded in order
+// of rule and path whichever finishes first. It returns the findings of
+// every selected pass by pass name.
+func (c *Checker) runPassesWith(fc *fileCheck, spec *ast.Spec, st *semantic.SymbolTable, cached map[string][]report.Finding) map[string][]report.Finding {
+	fc.spec = spec
+	fc.suppressions = newSuppressionSet(spec.Suppressions)
+
+	// Each selected pass that has no cached findings runs in its own
+	// goroutine; see PassFunc for what passes may and may not do.
+	type result struct {
+		findings []report.Finding
+		elapsed  time.Duration
+		ran      bool
+	}
+	results := make([]result, len(c.passes))
+	var wg sync.WaitGroup
+	for i, p := range c.passes {
+		if !fc.opts.selectsPass(p.Rules) {
+			continue
+		}
+		if findings, ok := cached[p.Name]; ok {
+			results[i] = result{findings: findings}
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			findings := p.Fn(spec, st)
+			results[i] = result{findings: findings, elapsed: time.Since(start), ran: true}
+		}()
+	}
+	wg.Wait()
+
+	byPass := make(map[string][]report.Finding, len(c.passes))
+	var all []report.Finding
+	for i, p := range c.passes {
+		if !fc.opts.selectsPass(p.Rules) {
+			continue
+		}
+		res := results[i]
+		if res.ran && fc.opts.Timings {
+			fc.report.AddTiming(p.Name, res.elapsed)
+		}
+		byPass[p.Name] = res.findings
+		all = append(all, res.findings...)
+	}
+
+	if fc.opts.Against != nil && fc.opts.selectsPass(compatibilityRules) {
+		start := time.Now()
+		all = append(all, compatibilityFindings(fc.opts.Against, spec)...)
+		fc.timed("compatibility", start)
+	}
+	if fc.opts.Template != nil && fc.opts.selectsPass(templateRules) {
+		start := time.Now()
+		all = append(all, templateFindings(fc.opts.Template, spec)...)
+		fc.timed("template", start)
+	}
+
+	// Passes finish in any order, so findings are recorded by rule and path,
+	// keeping the order each pass reported them in otherwise.
+	slices.SortStableFunc(all, func(a, b report.Finding) int {
+		if n := strings.Compare(a.Rule, b.Rule); n != 0 {
+			return n
+		}
+		return comparePaths(a.Location.Path, b.Location.Path)
+	})
+	for _, f := range all {
+		fc.add(f)
+	}
+	return byPass
+}
 
-	// --- Phase 4: Run semantic passes ---
-	for _, p := range c.passes {
-		if !passMatchesFilter(p.Rules, opts.RuleFilter) {
+// comparePaths orders JSONPaths as strings, except that runs of digits are
+// compared as numbers, so that $.rules[2] comes before $.rules[10].
+func comparePaths(a, b string) int {
+	for a != "" && b != "" {
+		da, db := leadingDigits(a), leadingDigits(b)
+		if da > 0 && db > 0 {
+			na, _ := strconv.Atoi(a[:da])
+			nb, _ := strconv.Atoi(b[:db])
+			if na != nb {
+				return cmp.Compare(na, nb)
+			}
+			a, b = a[da:], b[db:]
 			continue
 		}
-		findings := p.Fn(spec, st)
-		for _, f := range findings {
-			r.AddFinding(f)
+		if a[0] != b[0] {
+			return cmp.Compare(a[0], b[0])
 		}
+		a, b = a[1:], b[1:]
 	}
+	return cmp.Compare(len(a), len(b))
+}
 
-	return r
+// leadingDigits returns the number of ASCII digits s starts with.
+func leadingDigits(s string) int {
+	n := 0
+	for n < len(s) && s[n] >= '0' && s[n] <= '9' {
+		n++
+	}
+	return n
 }
 
 // passMatchesFilter returns true if any of the pass's rules are in the filter,
@@ -124,13 +525,75 @@ func passMatchesFilter(passRules []int, filter []int) bool {
 	return false
 }
 
+// selectsPass reports whether a pass covering the given rule numbers runs
+// under both rule filters. A pass covering no rule numbers reports warnings,
+// so RuleIDs selects it when it names any warning.
+func (o CheckOptions) selectsPass(passRules []int) bool {
+	if !passMatchesFilter(passRules, o.RuleFilter) {
+		return false
+	}
+	if len(o.RuleIDs) == 0 {
+		return true
+	}
+	if len(passRules) == 0 {
+		return slices.ContainsFunc(o.RuleIDs, func(id string) bool { return strings.HasPrefix(id, "WARN-") })
+	}
+	for _, n := range passRules {
+		if slices.Contains(o.RuleIDs, fmt.Sprintf("RULE-%02d", n)) {
+			return true
+		}
+	}
+	return false
+}
+
+// pathMatchesFilter returns true if path lies within the subtree rooted at
+// filter, or if the filter is empty. Both may be JSONPaths or JSON Pointers.
+func pathMatchesFilter(path, filter string) bool {
+	if filter == "" {
+		return true
+	}
+	path, filter = normalizePath(path), normalizePath(filter)
+	if filter == "$" {
+		return true
+	}
+	if !strings.HasPrefix(path, filter) {
+		return false
+	}
+	rest := path[len(filter):]
+	return rest == "" || rest[0] == '.' || rest[0] == '['
+}
+
+// normalizePath converts a JSON Pointer to JSONPath form so that schema and
+// semantic finding paths can be compared. An empty path denotes the root.
+func normalizePath(path string) string {
+	switch {
+	case path == "":
+		return "$"
+	case strings.HasPrefix(path, "/"):
+		return pointerToJSONPath(path)
+	default:
+		return strings.TrimSuffix(path, ".")
+	}
+}
+
 // registerPasses wires up all available semantic passes.
 func registerPasses(c *Checker) {
 	c.RegisterPass("references", []int{1, 3, 22, 27, 28, 30, 31, 35}, semantic.CheckReferences)
-	c.RegisterPass("uniqueness", []int{6, 23, 26}, semantic.CheckUniqueness)
+	c.RegisterPass("uniqueness", []int{6, 23, 26, 38, 52}, semantic.CheckUniqueness)
 	c.RegisterPass("statemachines", []int{7, 8, 9}, semantic.CheckStateMachines)
-	c.RegisterPass("expressions", []int{10, 11, 12, 13, 14}, semantic.CheckExpressions)
+	c.RegisterPass("expressions", []int{10, 11, 12, 13, 14, 40, 49, 54, 58}, semantic.CheckExpressions)
 	c.RegisterPass("sumtypes", []int{16, 17, 18, 19}, semantic.CheckSumTypes)
-	c.RegisterPass("surfaces", []int{29, 32, 33, 34}, semantic.CheckSurfaces)
+	c.RegisterPass("surfaces", []int{29, 32, 33, 34, 50}, semantic.CheckSurfaces)
+	c.RegisterPass("retention", []int{36}, semantic.CheckRetention)
+	c.RegisterPass("aliases", []int{37}, semantic.CheckTypeAliases)
+	c.RegisterPass("triggers", []int{41, 44}, semantic.CheckTriggers)
+	c.RegisterPass("creations", []int{45, 47}, semantic.CheckCreations)
+	c.RegisterPass("statechanges", []int{46, 54, 57}, semantic.CheckStateChanges)
+	c.RegisterPass("relationships", []int{48}, semantic.CheckRelationships)
+	c.RegisterPass("actors", []int{51}, semantic.CheckActors)
+	c.RegisterPass("temporal", []int{53}, semantic.CheckTemporalConditions)
+	c.RegisterPass("nullability", []int{55, 56}, semantic.CheckNullability)
+	c.RegisterPass("metadata", []int{60}, semantic.CheckMetadata)
+	c.RegisterPass("sensitive", []int{62}, semantic.CheckSensitiveExposure)
 	c.RegisterPass("warnings", nil, semantic.CheckWarnings)
 }
@@ -1,8 +1,12 @@
 package checker
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/foundry-zero/allium/internal/ast"
@@ -26,12 +30,18 @@ func TestCheckReferenceExample(t *testing.T) {
 
 	// The reference example should pass all validations with no errors.
 	// WARN-16 is expected: temporal trigger on optional field User.locked_until.
+	// WARN-20 is expected: UserInformed is emitted for an external notifier and
+	// has no consuming rule in this spec.
+	// WARN-22 is expected: LoginFailure compares failed_login_attempts with the
+	// limit after incrementing it.
+	// WARN-31 is expected: the email_service given binding names the external
+	// notifier, but no expression reads it.
 	for _, e := range r.Errors {
 		t.Errorf("unexpected error: [%s] %s at %s", e.Rule, e.Message, e.Location.Path)
 	}
 	for _, w := range r.Warnings {
-		if w.Rule == "WARN-16" {
-			continue // expected: temporal trigger on optional field
+		if w.Rule == "WARN-16" || w.Rule == "WARN-20" || w.Rule == "WARN-22" || w.Rule == "WARN-31" {
+			continue // expected, see above
 		}
 		t.Errorf("unexpected warning: [%s] %s at %s", w.Rule, w.Message, w.Location.Path)
 	}
@@ -94,6 +104,187 @@ func TestCheckRuleFilter(t *testing.T) {
 	}
 }
 
+func TestCheckRuleIDs(t *testing.T) {
+	c, err := NewChecker()
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+
+	// The reference example reports WARN-16, WARN-20, WARN-22 and WARN-31.
+	r := c.Check(refExample, CheckOptions{RuleIDs: []string{"WARN-20"}})
+	if len(r.Errors) != 0 || len(r.Warnings) != 1 || r.Warnings[0].Rule != "WARN-20" {
+		t.Errorf("expected only WARN-20, got %v %v", r.Errors, r.Warnings)
+	}
+
+	// Rule IDs alone skip the warnings pass.
+	r = c.Check(refExample, CheckOptions{RuleIDs: []string{"RULE-07", "RULE-41"}})
+	if len(r.Errors)+len(r.Warnings) != 0 {
+		t.Errorf("expected no findings, got %v %v", r.Errors, r.Warnings)
+	}
+
+	if !(CheckOptions{RuleIDs: []string{"RULE-08"}}).selectsPass([]int{7, 8, 9}) {
+		t.Error("expected the state machine pass to run for RULE-08")
+	}
+	if (CheckOptions{RuleIDs: []string{"RULE-08"}, RuleFilter: []int{1}}).selectsPass([]int{7, 8, 9}) {
+		t.Error("expected RuleFilter and RuleIDs to both apply")
+	}
+}
+
+func TestCheckTimings(t *testing.T) {
+	c, err := NewChecker()
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+
+	if r := c.Check(refExample, CheckOptions{}); r.Timings != nil {
+		t.Errorf("expected no timings unless asked for, got %v", r.Timings)
+	}
+
+	r := c.Check(refExample, CheckOptions{Timings: true, RuleFilter: []int{7, 8, 9}})
+	var phases []string
+	for _, tm := range r.Timings {
+		phases = append(phases, tm.Phase)
+	}
+	if want := []string{"decode", "schema", "parse", "symbols", "statemachines"}; !slices.Equal(phases, want) {
+		t.Errorf("phases = %v, want %v", phases, want)
+	}
+	if r.Elapsed() <= 0 {
+		t.Errorf("expected a positive elapsed time, got %s", r.Elapsed())
+	}
+
+	r = c.Check(refExample, CheckOptions{Timings: true, SchemaOnly: true})
+	if len(r.Timings) != 2 || r.Timings[1].Phase != "schema" {
+		t.Errorf("expected only decoding and the schema timed, got %v", r.Timings)
+	}
+}
+
+func TestCheckMaxFileSize(t *testing.T) {
+	c, err := NewChecker()
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+	info, err := os.Stat(refExample)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := c.Check(refExample, CheckOptions{MaxFileSize: 1024})
+	want := fmt.Sprintf("file is %s, over the maximum file size of 1.0 KB; split the spec or raise the limit", formatSize(info.Size()))
+	if len(r.Errors) != 1 || r.Errors[0].Rule != "INPUT" || r.Errors[0].Message != want {
+		t.Errorf("expected a single INPUT error %q, got %v", want, r.Errors)
+	}
+	if r := c.Check(refExample, CheckOptions{MaxFileSize: info.Size()}); r.Summary.ErrorCount != 0 {
+		t.Errorf("expected a file at the limit to be checked, got %v", r.Errors)
+	}
+
+	data, err := os.ReadFile(refExample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := c.CheckSource("buffer.allium.json", data, CheckOptions{MaxFileSize: 1024}); len(r.Errors) != 1 || r.Errors[0].Rule != "INPUT" {
+		t.Errorf("expected CheckSource to apply the limit, got %v", r.Errors)
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	for n, want := range map[int64]string{
+		0:             "0 bytes",
+		1023:          "1023 bytes",
+		1024:          "1.0 KB",
+		1536:          "1.5 KB",
+		64 << 20:      "64.0 MB",
+		3 << 30:       "3.0 GB",
+		5<<40 + 1<<39: "5.5 TB",
+	} {
+		if got := formatSize(n); got != want {
+			t.Errorf("formatSize(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestComparePaths(t *testing.T) {
+	for _, tt := range []struct {
+		a, b string
+		want int
+	}{
+		{"$.rules[2]", "$.rules[10]", -1},
+		{"$.rules[10].ensures[0]", "$.rules[9]", 1},
+		{"$.rules[2]", "$.rules[2].requires[0]", -1},
+		{"$.entities[0]", "$.rules[0]", -1},
+		{"$.rules[02]", "$.rules[2]", 0},
+		{"", "$", -1},
+	} {
+		if got := comparePaths(tt.a, tt.b); got != tt.want {
+			t.Errorf("comparePaths(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// TestCheckDeterministicOrder checks that concurrent passes record findings
+// in the same order on every run.
+func TestCheckDeterministicOrder(t *testing.T) {
+	c, err := NewChecker()
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+	spec := scaledReferenceExample(t, 5)
+	first := c.CheckSpec(spec, CheckOptions{}).Findings()
+	if len(first) == 0 {
+		t.Fatal("expected findings on the scaled example")
+	}
+	for i := 1; i < len(first); i++ {
+		a, b := first[i-1], first[i]
+		if a.Severity == b.Severity && (a.Rule > b.Rule || a.Rule == b.Rule && comparePaths(a.Location.Path, b.Location.Path) > 0) {
+			t.Errorf("finding %d (%s at %s) is out of order after %s at %s", i, b.Rule, b.Location.Path, a.Rule, a.Location.Path)
+		}
+	}
+	for range 10 {
+		if again := c.CheckSpec(spec, CheckOptions{}).Findings(); !slices.EqualFunc(first, again, func(a, b report.Finding) bool {
+			return a.Rule == b.Rule && a.Message == b.Message && a.Location == b.Location
+		}) {
+			t.Fatal("findings differ between runs")
+		}
+	}
+}
+
+// scaledReferenceExample returns the reference example with its rules and
+// surfaces repeated n times, to approximate a large spec.
+func scaledReferenceExample(tb testing.TB, n int) *ast.Spec {
+	tb.Helper()
+	spec, err := ast.LoadSpec(refExample)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	rules, surfaces := spec.Rules, spec.Surfaces
+	for range n - 1 {
+		spec.Rules = append(spec.Rules, rules...)
+		spec.Surfaces = append(spec.Surfaces, surfaces...)
+	}
+	return spec
+}
+
+// BenchmarkCheckSpec runs every semantic pass over the reference example
+// scaled 100x, with the passes on one thread and on all of them.
+func BenchmarkCheckSpec(b *testing.B) {
+	c, err := NewChecker()
+	if err != nil {
+		b.Fatalf("NewChecker: %v", err)
+	}
+	spec := scaledReferenceExample(b, 100)
+	for _, bb := range []struct {
+		name  string
+		procs int
+	}{{"serial", 1}, {"parallel", runtime.NumCPU()}} {
+		b.Run(bb.name, func(b *testing.B) {
+			defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(bb.procs))
+			b.ReportAllocs()
+			for b.Loop() {
+				c.CheckSpec(spec, CheckOptions{})
+			}
+		})
+	}
+}
+
 func TestCheckNonexistentFile(t *testing.T) {
 	c, err := NewChecker()
 	if err != nil {
@@ -144,6 +335,30 @@ func TestCheckSchemaErrors(t *testing.T) {
 	}
 }
 
+func TestCheckSchemaErrorSuggestion(t *testing.T) {
+	c, err := NewChecker()
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+
+	src := `{"version": "1", "file": "test.allium", "entities": [{"name": "User", "fields": [
+		{"name": "email", "type": {"kind": "primitive", "value": "Strng"}}]}]}`
+	r := c.CheckSource("typo.allium.json", []byte(src), CheckOptions{})
+
+	found := false
+	for _, e := range r.Errors {
+		if e.Location.Path == "/entities/0/fields/0/type/value" {
+			found = true
+			if !strings.Contains(e.Message, "did you mean 'String'?") {
+				t.Errorf("expected suggestion in message, got %q", e.Message)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("expected a schema error at the primitive type, got %v", r.Errors)
+	}
+}
+
 func TestCheckSchemaErrorsSkipSemantic(t *testing.T) {
 	c, err := NewChecker()
 	if err != nil {
@@ -202,3 +417,126 @@ func TestPassMatchesFilter(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckSource(t *testing.T) {
+	c, err := NewChecker()
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join("..", "..", "schemas", "v1", "examples", "password-auth.allium.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromDisk := c.Check(filepath.Join("..", "..", "schemas", "v1", "examples", "password-auth.allium.json"), CheckOptions{})
+	fromSource := c.CheckSource("buffer.allium.json", data, CheckOptions{})
+	if fromSource.File != "buffer.allium.json" {
+		t.Errorf("expected report labelled with the given path, got %q", fromSource.File)
+	}
+	if fromSource.Summary != fromDisk.Summary {
+		t.Errorf("CheckSource summary %+v differs from Check %+v", fromSource.Summary, fromDisk.Summary)
+	}
+
+	r := c.CheckSource("broken.allium.json", []byte(`{"version": `), CheckOptions{})
+	if len(r.Errors) != 1 || r.Errors[0].Rule != "INPUT" {
+		t.Errorf("expected a single INPUT error for unparseable source, got %v", r.Errors)
+	}
+}
+
+func TestCheckSpec(t *testing.T) {
+	c, err := NewChecker()
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+
+	spec, err := ast.LoadSpec(refExample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromDisk := c.Check(refExample, CheckOptions{})
+	inMemory := c.CheckSpec(spec, CheckOptions{})
+	if inMemory.Summary != fromDisk.Summary {
+		t.Errorf("CheckSpec summary %+v differs from Check %+v", inMemory.Summary, fromDisk.Summary)
+	}
+	if !inMemory.SchemaValid {
+		t.Error("expected SchemaValid=true for an in-memory spec")
+	}
+
+	built := &ast.Spec{
+		Version: "1",
+		File:    "generated.allium",
+		Entities: []ast.Entity{{
+			Name: "Order",
+			Fields: []ast.Field{
+				{Name: "total", Type: ast.FieldType{Kind: "primitive", Value: "Integer"}},
+				{Name: "customer", Type: ast.FieldType{Kind: "entity_ref", Entity: "Customer"}},
+			},
+		}},
+	}
+	r := c.CheckSpec(built, CheckOptions{})
+	if r.File != "generated.allium" {
+		t.Errorf("expected report labelled with spec.File, got %q", r.File)
+	}
+	found := false
+	for _, e := range r.Errors {
+		if e.Rule == "RULE-01" && strings.HasPrefix(e.Location.Path, "$.entities[0].fields[1]") {
+			found = true
+			if e.Location.Line != 0 {
+				t.Errorf("expected no line for an in-memory spec, got %d", e.Location.Line)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("expected RULE-01 for the undeclared entity, got %v", r.Errors)
+	}
+
+	if r := c.CheckSpec(built, CheckOptions{SchemaOnly: true}); len(r.Errors)+len(r.Warnings) != 0 {
+		t.Errorf("expected no findings with SchemaOnly, got %v %v", r.Errors, r.Warnings)
+	}
+}
+
+func TestPathMatchesFilter(t *testing.T) {
+	tests := []struct {
+		path   string
+		filter string
+		want   bool
+	}{
+		{"$.rules[1]", "", true},
+		{"", "", true},
+		{"$.rules[1].ensures[0]", "$", true},
+		{"$.rules[1]", "$.rules[1]", true},
+		{"$.rules[1].ensures[0]", "$.rules[1]", true},
+		{"$.rules[12]", "$.rules[1]", false},
+		{"$.rules_extra", "$.rules", false},
+		{"$.rules[1]", "$.rules", true},
+		{"$.entities[0]", "$.rules[1]", false},
+		{"", "$.rules[1]", false},
+		{"/rules/1/trigger", "$.rules[1]", true},
+		{"/rules/10", "$.rules[1]", false},
+		{"$.rules[1].trigger", "/rules/1", true},
+	}
+
+	for _, tt := range tests {
+		if got := pathMatchesFilter(tt.path, tt.filter); got != tt.want {
+			t.Errorf("pathMatchesFilter(%q, %q) = %v, want %v", tt.path, tt.filter, got, tt.want)
+		}
+	}
+}
+
+func TestCheckPathFilter(t *testing.T) {
+	c, err := NewChecker()
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+
+	path := filepath.Join("..", "..", "schemas", "v1", "examples", "password-auth.allium.json")
+	r := c.Check(path, CheckOptions{PathFilter: "$.rules[4]"})
+	if len(r.Warnings) != 1 || r.Warnings[0].Rule != "WARN-16" {
+		t.Errorf("expected only WARN-16 within $.rules[4], got %v", r.Warnings)
+	}
+
+	r = c.Check(path, CheckOptions{PathFilter: "$.entities"})
+	if r.HasErrors() || r.HasWarnings() {
+		t.Errorf("expected no findings within $.entities, got %v %v", r.Errors, r.Warnings)
+	}
+}
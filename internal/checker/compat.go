@@ -0,0 +1,75 @@
+package checker
+
+import (
+	"fmt"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/diff"
+	"github.com/foundry-zero/allium/internal/report"
+	"github.com/foundry-zero/allium/internal/semver"
+)
+
+// compatibilityRules lists the rule numbers covered by the comparison with
+// the previous version of a spec.
+var compatibilityRules = []int{42, 61}
+
+// compatibilityFindings reports each change from previous to spec that breaks
+// consumers of previous as a RULE-42 error. A change is located in spec; a
+// removal is located at what it was removed from, and its message gives its
+// path in previous. A spec version that does not follow from previous's is
+// reported as RULE-61.
+func compatibilityFindings(previous, spec *ast.Spec) []report.Finding {
+	breaking := diff.Breaking(diff.Compare(previous, spec))
+	findings := versionFindings(previous, spec, len(breaking))
+	for _, c := range breaking {
+		msg := "Breaking change from previous version: " + c.Description()
+		path := c.Path
+		if c.Kind == diff.Removed {
+			msg += fmt.Sprintf(" (was at %s)", c.Path)
+			path = c.Within
+		}
+		findings = append(findings, report.NewError("RULE-42", msg,
+			report.Location{File: spec.File, Path: path}))
+	}
+	return findings
+}
+
+// versionFindings checks metadata.spec_version against that of previous
+// (RULE-61): it must be present if previous has one, must not be lower, and
+// must be a major increment when the spec has breaking changes from
+// previous. Versions that are not semantic versions are left to RULE-60.
+func versionFindings(previous, spec *ast.Spec, breaking int) []report.Finding {
+	if previous.Metadata.SpecVersion == "" {
+		return nil
+	}
+	old, err := semver.Parse(previous.Metadata.SpecVersion)
+	if err != nil {
+		return nil
+	}
+	if spec.Metadata.SpecVersion == "" {
+		return []report.Finding{report.NewError("RULE-61",
+			fmt.Sprintf("Spec version is missing; the previous version is %s", old),
+			report.Location{File: spec.File, Path: "$.metadata"})}
+	}
+	v, err := semver.Parse(spec.Metadata.SpecVersion)
+	if err != nil {
+		return nil
+	}
+
+	loc := report.Location{File: spec.File, Path: "$.metadata.spec_version"}
+	switch {
+	case v.Compare(old) < 0:
+		return []report.Finding{report.NewError("RULE-61",
+			fmt.Sprintf("Spec version %s is lower than the previous version %s", v, old), loc)}
+	case breaking > 0 && !old.IsMajorIncrement(v):
+		changes := "breaking changes"
+		if breaking == 1 {
+			changes = "a breaking change"
+		}
+		next := old.NextMajor().String()
+		return []report.Finding{report.NewError("RULE-61",
+			fmt.Sprintf("Spec version %s has %s from the previous version %s, but is not a major version increment (%s or later)", v, changes, old, next), loc,
+		).WithSuggestion(fmt.Sprintf("Release as version %s", next), report.ReplaceEdit(loc.Path, next))}
+	}
+	return nil
+}
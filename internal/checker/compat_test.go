@@ -0,0 +1,109 @@
+package checker
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/foundry-zero/allium/internal/ast"
+)
+
+func TestCheckAgainst(t *testing.T) {
+	c, err := NewChecker()
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+	previous, err := ast.LoadSpec(refExample)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := c.Check(refExample, CheckOptions{Against: previous})
+	if len(r.Errors) != 0 {
+		t.Errorf("expected no errors against an identical version, got %v", r.Errors)
+	}
+
+	// Rename User.password_hash: its removal breaks consumers, the addition
+	// does not.
+	data, err := os.ReadFile(refExample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = bytes.Replace(data, []byte(`"name": "password_hash"`), []byte(`"name": "secret_hash"`), 1)
+	path := filepath.Join(t.TempDir(), "auth.allium.json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r = c.Check(path, CheckOptions{Against: previous, RuleIDs: []string{"RULE-42"}})
+	if len(r.Errors) != 1 || len(r.Warnings) != 0 {
+		t.Fatalf("expected one RULE-42 error, got %v %v", r.Errors, r.Warnings)
+	}
+	f := r.Errors[0]
+	want := "Breaking change from previous version: removed field User.password_hash (was at $.entities[0].fields[1])"
+	if f.Rule != "RULE-42" || f.Message != want || f.Location.Path != "$.entities[0]" || f.Location.Line == 0 {
+		t.Errorf("unexpected finding %+v", f)
+	}
+
+	r = c.Check(path, CheckOptions{Against: previous, RuleIDs: []string{"WARN-20"}})
+	if len(r.Errors) != 0 {
+		t.Errorf("expected RULE-42 to be filtered out, got %v", r.Errors)
+	}
+}
+
+func TestCheckAgainstSpecVersion(t *testing.T) {
+	c, err := NewChecker()
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+	data, err := os.ReadFile(refExample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	withVersion := func(data []byte, version string) []byte {
+		if version == "" {
+			return data
+		}
+		return bytes.Replace(data, []byte(`"scope": "authentication",`), []byte(`"scope": "authentication", "spec_version": "`+version+`",`), 1)
+	}
+	previous, err := ast.ParseSpec(withVersion(data, "1.4.2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Renaming User.password_hash is a breaking change.
+	renamed := bytes.Replace(data, []byte(`"name": "password_hash"`), []byte(`"name": "secret_hash"`), 1)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		version string
+		want    string
+	}{
+		{"unchanged", data, "1.4.2", ""},
+		{"patch", data, "1.4.3", ""},
+		{"lower", data, "1.4.1", "Spec version 1.4.1 is lower than the previous version 1.4.2"},
+		{"missing", data, "", "Spec version is missing; the previous version is 1.4.2"},
+		{"breaking minor", renamed, "1.5.0", "Spec version 1.5.0 has a breaking change from the previous version 1.4.2, but is not a major version increment (2.0.0 or later)"},
+		{"breaking major", renamed, "2.0.0", ""},
+		{"breaking pre-release", renamed, "2.0.0-rc.1", ""},
+		{"invalid", renamed, "2.0", ""}, // RULE-60
+	}
+	for _, tt := range tests {
+		r := c.CheckSource("auth.allium.json", withVersion(tt.data, tt.version), CheckOptions{Against: previous, RuleIDs: []string{"RULE-61"}})
+		switch {
+		case tt.want == "" && len(r.Errors) != 0:
+			t.Errorf("%s: expected no RULE-61, got %v", tt.name, r.Errors)
+		case tt.want != "" && (len(r.Errors) != 1 || r.Errors[0].Message != tt.want):
+			t.Errorf("%s: expected %q, got %v", tt.name, tt.want, r.Errors)
+		}
+	}
+
+	r := c.CheckSource("auth.allium.json", withVersion(renamed, "1.5.0"), CheckOptions{Against: previous, RuleIDs: []string{"RULE-61"}})
+	if len(r.Errors) != 1 || r.Errors[0].Location.Path != "$.metadata.spec_version" || r.Errors[0].Location.Line == 0 {
+		t.Fatalf("unexpected findings %v", r.Errors)
+	}
+	if s := r.Errors[0].Suggestions; len(s) != 1 || string(s[0].Edits[0].Value) != `"2.0.0"` {
+		t.Errorf("expected a suggestion to release 2.0.0, got %+v", s)
+	}
+}
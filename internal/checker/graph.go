@@ -0,0 +1,128 @@
+package checker
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/foundry-zero/allium/internal/config"
+	"github.com/foundry-zero/allium/internal/diagram"
+	"github.com/foundry-zero/allium/internal/report"
+	"github.com/foundry-zero/allium/internal/semantic"
+)
+
+// ImportGraph is the module graph of a workspace: one node per spec, including
+// those fetched to resolve imports, and one edge per use_declaration.
+type ImportGraph struct {
+	Nodes []ImportNode `json:"nodes"`
+	Edges []ImportEdge `json:"edges"`
+}
+
+// ImportNode is a spec in the workspace.
+type ImportNode struct {
+	Path   string   `json:"path"`
+	File   string   `json:"file"`             // the spec's declared file name
+	Layers []string `json:"layers,omitempty"` // layers from the project configuration
+}
+
+// ImportEdge is a use_declaration. To is empty when the coordinate does not
+// resolve to a workspace spec.
+type ImportEdge struct {
+	From       string   `json:"from"`
+	To         string   `json:"to,omitempty"`
+	Alias      string   `json:"alias"`
+	Coordinate string   `json:"coordinate"`
+	Violations []string `json:"violations,omitempty"` // layering rules the import breaks
+	index      int      // position in the importing spec's use_declarations
+}
+
+// buildImportGraph builds the import graph of ws, classifying specs into
+// layers and checking imports against the layering rules of cfg.
+func buildImportGraph(ws *semantic.Workspace, cfg *config.Config) *ImportGraph {
+	g := &ImportGraph{Nodes: []ImportNode{}, Edges: []ImportEdge{}}
+	layers := make(map[*semantic.WorkspaceMember][]string, len(ws.Members))
+	for _, m := range append(slices.Clip(ws.Members), ws.External...) {
+		layers[m] = cfg.LayersOf(m.Path)
+		g.Nodes = append(g.Nodes, ImportNode{Path: m.Path, File: m.Spec.File, Layers: layers[m]})
+	}
+	for _, m := range ws.Members {
+		for i, u := range m.Spec.UseDeclarations {
+			e := ImportEdge{From: m.Path, Alias: u.Alias, Coordinate: u.Coordinate, index: i}
+			if target := ws.Resolve(m.Path, u.Coordinate); target != nil {
+				e.To = target.Path
+				e.Violations = cfg.ForbiddenImports(layers[m], layers[target])
+			}
+			g.Edges = append(g.Edges, e)
+		}
+	}
+	return g
+}
+
+// checkLayering reports RULE-39 for each import by m that breaks a layering
+// rule.
+func checkLayering(g *ImportGraph, m *semantic.WorkspaceMember) []report.Finding {
+	var findings []report.Finding
+	for _, e := range g.Edges {
+		if e.From != m.Path {
+			continue
+		}
+		for _, v := range e.Violations {
+			findings = append(findings, report.NewError(
+				"RULE-39",
+				fmt.Sprintf("Use declaration '%s' imports '%s', but %s", e.Alias, e.Coordinate, v),
+				report.Location{File: m.Spec.File, Path: fmt.Sprintf("$.use_declarations[%d].coordinate", e.index)},
+			))
+		}
+	}
+	return findings
+}
+
+// DOT renders the graph in Graphviz DOT syntax. Specs are grouped into
+// clusters by their first layer, unresolved imports point at dashed
+// placeholder nodes, and imports that break layering rules are drawn red.
+func (g *ImportGraph) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph imports {\n")
+	b.WriteString("  node [shape=box];\n")
+
+	clusters := make(map[string][]ImportNode)
+	var order []string
+	for _, n := range g.Nodes {
+		layer := ""
+		if len(n.Layers) > 0 {
+			layer = n.Layers[0]
+		}
+		if _, ok := clusters[layer]; !ok {
+			order = append(order, layer)
+		}
+		clusters[layer] = append(clusters[layer], n)
+	}
+	for i, layer := range order {
+		indent := "  "
+		if layer != "" {
+			fmt.Fprintf(&b, "  subgraph cluster_%d {\n    label=%s;\n", i, diagram.QuoteDOT(layer))
+			indent = "    "
+		}
+		for _, n := range clusters[layer] {
+			fmt.Fprintf(&b, "%s%s [label=%s];\n", indent, diagram.QuoteDOT(n.Path), diagram.QuoteDOT(n.File))
+		}
+		if layer != "" {
+			b.WriteString("  }\n")
+		}
+	}
+
+	for _, e := range g.Edges {
+		to := e.To
+		if to == "" {
+			to = e.Coordinate
+			fmt.Fprintf(&b, "  %s [style=dashed];\n", diagram.QuoteDOT(to))
+		}
+		attrs := "label=" + diagram.QuoteDOT(e.Alias)
+		if len(e.Violations) > 0 {
+			attrs += ", color=red, tooltip=" + diagram.QuoteDOT(strings.Join(e.Violations, "; "))
+		}
+		fmt.Fprintf(&b, "  %s -> %s [%s];\n", diagram.QuoteDOT(e.From), diagram.QuoteDOT(to), attrs)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
@@ -0,0 +1,90 @@
+package checker
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/foundry-zero/allium/internal/config"
+)
+
+// layeredWorkspace writes a core spec importing a feature spec and a config
+// forbidding core from importing features. It returns the spec paths (core
+// first) and the loaded config.
+func layeredWorkspace(t *testing.T) ([]string, *config.Config) {
+	t.Helper()
+	coreOrders := strings.Replace(ordersSpec, "./billing.allium", "../features/billing.allium", 1)
+	dir := writeWorkspace(t, map[string]string{
+		"core/orders.allium.json":      coreOrders,
+		"features/billing.allium.json": billingSpec,
+		".alliumcheck.json": `{
+  "layers": {"core": ["core/**"], "feature": ["features/**"]},
+  "layering": [{"from": "core", "must_not_import": ["feature"]}]
+}`,
+	})
+	cfg, err := config.Load(filepath.Join(dir, ".alliumcheck.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []string{filepath.Join(dir, "core", "orders.allium.json"), filepath.Join(dir, "features", "billing.allium.json")}, cfg
+}
+
+func TestCheckWorkspaceLayering(t *testing.T) {
+	c, err := NewChecker()
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+	paths, cfg := layeredWorkspace(t)
+
+	reports, graph := c.CheckWorkspaceGraph(paths, CheckOptions{Config: cfg})
+	if len(reports[0].Errors) != 1 {
+		t.Fatalf("expected one RULE-39 error in core spec, got %v", reports[0].Errors)
+	}
+	e := reports[0].Errors[0]
+	if e.Rule != "RULE-39" || e.Location.Path != "$.use_declarations[0].coordinate" {
+		t.Errorf("unexpected finding: [%s] at %s", e.Rule, e.Location.Path)
+	}
+	if e.Message != "Use declaration 'billing' imports '../features/billing.allium', but layer 'core' must not import layer 'feature'" {
+		t.Errorf("message = %q", e.Message)
+	}
+	if reports[1].HasErrors() {
+		t.Errorf("unexpected errors in feature spec: %v", reports[1].Errors)
+	}
+
+	if len(graph.Nodes) != 2 || len(graph.Edges) != 1 {
+		t.Fatalf("unexpected graph: %+v", graph)
+	}
+	if graph.Nodes[0].Layers[0] != "core" || graph.Edges[0].To != paths[1] {
+		t.Errorf("unexpected graph: %+v", graph)
+	}
+	dot := graph.DOT()
+	for _, want := range []string{`label="core"`, `-> "` + paths[1] + `" [label="billing", color=red`} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT output missing %q:\n%s", want, dot)
+		}
+	}
+
+	// Without the config, or with RULE-39 filtered out, nothing is reported.
+	for _, opts := range []CheckOptions{{}, {Config: cfg, RuleFilter: []int{35}}} {
+		if reports := c.CheckWorkspace(paths, opts); reports[0].HasErrors() {
+			t.Errorf("unexpected errors with %+v: %v", opts, reports[0].Errors)
+		}
+	}
+}
+
+func TestImportGraphUnresolved(t *testing.T) {
+	dir := writeWorkspace(t, map[string]string{"orders.allium.json": ordersSpec})
+	c, err := NewChecker()
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+	path := filepath.Join(dir, "orders.allium.json")
+
+	_, graph := c.CheckWorkspaceGraph([]string{path}, CheckOptions{})
+	if len(graph.Edges) != 1 || graph.Edges[0].To != "" {
+		t.Fatalf("expected one unresolved edge, got %+v", graph.Edges)
+	}
+	if !strings.Contains(graph.DOT(), `"./billing.allium" [style=dashed];`) {
+		t.Errorf("expected dashed placeholder for unresolved import:\n%s", graph.DOT())
+	}
+}
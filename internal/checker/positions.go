@@ -0,0 +1,70 @@
+package checker
+
+import (
+	"strings"
+
+	"github.com/foundry-zero/allium/internal/report"
+	"github.com/foundry-zero/allium/internal/srcmap"
+)
+
+// locateFinding fills in the line and column range of a finding from its JSON
+// path, and those of its related locations. Locations that already carry a
+// line, or whose path cannot be resolved, are left unchanged.
+func locateFinding(f report.Finding, source srcmap.Map) report.Finding {
+	if source == nil {
+		return f
+	}
+	f.Location = locate(f.Location, source)
+	if len(f.Related) > 0 {
+		related := make([]report.RelatedLocation, len(f.Related))
+		for i, r := range f.Related {
+			related[i] = report.RelatedLocation{Message: r.Message, Location: locate(r.Location, source)}
+		}
+		f.Related = related
+	}
+	return f
+}
+
+// locate fills in the line and column of loc from its path, unless they
+// are already known.
+func locate(loc report.Location, source srcmap.Map) report.Location {
+	if loc.Line > 0 || loc.Path == "" {
+		return loc
+	}
+	if span, ok := source.Lookup(normalizePath(loc.Path)); ok {
+		loc.Line = span.Start.Line
+		loc.Column = span.Start.Column
+		loc.EndLine = span.End.Line
+		loc.EndColumn = span.End.Column
+	}
+	return loc
+}
+
+// pointerToJSONPath converts a JSON Pointer as reported by the schema
+// validator ("/rules/0/ensures") to the JSONPath form used by semantic
+// findings ("$.rules[0].ensures").
+func pointerToJSONPath(pointer string) string {
+	var b strings.Builder
+	b.WriteString("$")
+	for _, seg := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		if seg == "" {
+			continue
+		}
+		seg = strings.NewReplacer("~1", "/", "~0", "~").Replace(seg)
+		if isIndex(seg) {
+			b.WriteString("[" + seg + "]")
+		} else {
+			b.WriteString("." + seg)
+		}
+	}
+	return b.String()
+}
+
+func isIndex(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
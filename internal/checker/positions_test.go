@@ -0,0 +1,137 @@
+package checker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/report"
+	"github.com/foundry-zero/allium/internal/semantic"
+	"github.com/foundry-zero/allium/internal/srcmap"
+)
+
+func TestPointerToJSONPath(t *testing.T) {
+	tests := []struct {
+		pointer string
+		want    string
+	}{
+		{"", "$"},
+		{"/", "$"},
+		{"/rules/0/ensures", "$.rules[0].ensures"},
+		{"/entities/2/fields/10/type", "$.entities[2].fields[10].type"},
+		{"/config/a~1b/c~0d", "$.config.a/b.c~d"},
+	}
+	for _, tt := range tests {
+		if got := pointerToJSONPath(tt.pointer); got != tt.want {
+			t.Errorf("pointerToJSONPath(%q) = %q, want %q", tt.pointer, got, tt.want)
+		}
+	}
+}
+
+func TestLocateFinding(t *testing.T) {
+	source := srcmap.Map{
+		"$":          {Start: srcmap.Pos{Line: 1, Column: 1}, End: srcmap.Pos{Line: 9, Column: 2}},
+		"$.rules":    {Start: srcmap.Pos{Line: 2, Column: 12}, End: srcmap.Pos{Line: 8, Column: 4}},
+		"$.rules[0]": {Start: srcmap.Pos{Line: 3, Column: 5}, End: srcmap.Pos{Line: 7, Column: 6}},
+	}
+
+	tests := []struct {
+		name            string
+		loc             report.Location
+		line, col       int
+		endLine, endCol int
+	}{
+		{"json path", report.Location{Path: "$.rules[0]"}, 3, 5, 7, 6},
+		{"json pointer", report.Location{Path: "/rules/0"}, 3, 5, 7, 6},
+		{"nearest ancestor", report.Location{Path: "$.rules[0].requires[1]"}, 3, 5, 7, 6},
+		{"existing line kept", report.Location{Path: "$.rules[0]", Line: 9}, 9, 0, 0, 0},
+		{"no path", report.Location{}, 0, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc := locateFinding(report.NewError("RULE-01", "msg", tt.loc), source).Location
+			if loc.Line != tt.line || loc.Column != tt.col || loc.EndLine != tt.endLine || loc.EndColumn != tt.endCol {
+				t.Errorf("got %d:%d-%d:%d, want %d:%d-%d:%d", loc.Line, loc.Column, loc.EndLine, loc.EndColumn,
+					tt.line, tt.col, tt.endLine, tt.endCol)
+			}
+		})
+	}
+}
+
+func TestLocateFinding_Related(t *testing.T) {
+	source := srcmap.Map{
+		"$.rules":    {Start: srcmap.Pos{Line: 2, Column: 12}, End: srcmap.Pos{Line: 8, Column: 4}},
+		"$.rules[0]": {Start: srcmap.Pos{Line: 3, Column: 5}, End: srcmap.Pos{Line: 7, Column: 6}},
+	}
+	f := report.NewError("RULE-01", "msg", report.Location{Path: "$.rules"}).
+		WithRelated("rule", report.Location{Path: "$.rules[0].requires[1]"})
+	if loc := locateFinding(f, source).Related[0].Location; loc.Line != 3 || loc.Column != 5 {
+		t.Errorf("related location = %d:%d, want 3:5", loc.Line, loc.Column)
+	}
+	if f.Related[0].Location.Line != 0 {
+		t.Error("locateFinding modified the related locations of its argument")
+	}
+}
+
+func TestCheckFindingsHaveLineAndColumn(t *testing.T) {
+	c, err := NewChecker()
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+	c.RegisterPass("locate", []int{99}, func(spec *ast.Spec, _ *semantic.SymbolTable) []report.Finding {
+		return []report.Finding{report.NewError("RULE-99", "second entity",
+			report.Location{File: spec.File, Path: "$.entities[1]"})}
+	})
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "located.allium.json")
+	field := `{"name": "id", "type": {"kind": "primitive", "value": "String"}}`
+	data := "{\n  \"version\": \"1\",\n  \"file\": \"located.allium\",\n  \"entities\": [\n" +
+		"    {\"name\": \"A\", \"fields\": [" + field + "]},\n" +
+		"    {\"name\": \"B\", \"fields\": [" + field + "]}\n  ]\n}\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := c.Check(path, CheckOptions{RuleFilter: []int{99}})
+	if len(r.Errors) != 1 {
+		t.Fatalf("expected 1 error, got %v", r.Errors)
+	}
+	if loc := r.Errors[0].Location; loc.Line != 6 || loc.Column != 5 || loc.EndLine != 6 || loc.EndColumn != 96 {
+		t.Errorf("expected finding at 6:5-6:96, got %d:%d-%d:%d", loc.Line, loc.Column, loc.EndLine, loc.EndColumn)
+	}
+}
+
+func TestCheckSchemaErrorsHaveLines(t *testing.T) {
+	c, err := NewChecker()
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "bad-schema.allium.json")
+	data := "{\n  \"version\": \"1\",\n  \"file\": \"test.allium\",\n" +
+		"  \"entities\": [\n    {\"name\": \"A\", \"fields\": []}\n  ]\n}\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := c.Check(path, CheckOptions{})
+	if !r.HasErrors() {
+		t.Fatal("expected schema errors")
+	}
+	located := false
+	for _, e := range r.Errors {
+		if e.Location.Path != "/entities/0/fields" {
+			continue
+		}
+		located = true
+		if e.Location.Line != 5 {
+			t.Errorf("schema error %q at line %d, want 5", e.Message, e.Location.Line)
+		}
+	}
+	if !located {
+		t.Errorf("expected a schema error at /entities/0/fields, got %v", r.Errors)
+	}
+}
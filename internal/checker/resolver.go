@@ -0,0 +1,182 @@
+package checker
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/semantic"
+)
+
+// ErrCoordinateNotFound is returned, wrapped, by a CoordinateResolver that
+// has no spec for a coordinate.
+var ErrCoordinateNotFound = errors.New("coordinate not found")
+
+// CoordinateResolver fetches the spec that a use_declaration coordinate,
+// such as "org.example:payments@1.2.0", names from outside the workspace.
+type CoordinateResolver interface {
+	// Resolve returns the source of the spec published under coordinate and
+	// the path or URL it was read from, to report it under. It returns an
+	// error wrapping ErrCoordinateNotFound when there is no such spec.
+	Resolve(coordinate string) (source string, data []byte, err error)
+}
+
+// NewResolver returns an HTTPResolver for an http or https URL and a
+// DirResolver for anything else. Specs over maxSize bytes, when it is
+// positive, are not fetched.
+func NewResolver(from string, maxSize int64) CoordinateResolver {
+	if strings.HasPrefix(from, "http://") || strings.HasPrefix(from, "https://") {
+		return &HTTPResolver{BaseURL: from, MaxSize: maxSize}
+	}
+	return &DirResolver{Dir: from, MaxSize: maxSize}
+}
+
+// CoordinatePath returns the slash-separated path at which a registry
+// publishes the spec for coordinate: its name with colons as separators,
+// followed by "@" and its version if it has one, and ".allium.json". So
+// "org.example:payments@1.2.0" is at "org.example/payments@1.2.0.allium.json"
+// and "github.com/acme/billing" at "github.com/acme/billing.allium.json".
+// Coordinates with empty, "." or ".." segments are rejected.
+func CoordinatePath(coordinate string) (string, error) {
+	name, version, _ := strings.Cut(coordinate, "@")
+	segments := strings.Split(strings.ReplaceAll(name, ":", "/"), "/")
+	for _, s := range segments {
+		if s == "" || s == "." || s == ".." || strings.Contains(s, `\`) {
+			return "", fmt.Errorf("invalid coordinate %q for a registry", coordinate)
+		}
+	}
+	if strings.ContainsAny(version, `/\:@`) {
+		return "", fmt.Errorf("invalid coordinate %q for a registry", coordinate)
+	}
+	p := path.Join(segments...)
+	if version != "" {
+		p += "@" + version
+	}
+	return p + ".allium.json", nil
+}
+
+// DirResolver resolves coordinates to the files of a registry directory,
+// laid out as CoordinatePath describes.
+type DirResolver struct {
+	Dir string
+	// MaxSize, if positive, is the size in bytes above which a spec is not
+	// read.
+	MaxSize int64
+}
+
+// Resolve implements CoordinateResolver.
+func (r *DirResolver) Resolve(coordinate string) (string, []byte, error) {
+	p, err := CoordinatePath(coordinate)
+	if err != nil {
+		return "", nil, err
+	}
+	file := filepath.Join(r.Dir, filepath.FromSlash(p))
+	info, err := os.Stat(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return file, nil, fmt.Errorf("%w: no %s", ErrCoordinateNotFound, file)
+	}
+	if err != nil {
+		return file, nil, err
+	}
+	if r.MaxSize > 0 && info.Size() > r.MaxSize {
+		return file, nil, fmt.Errorf("%s is %s, over the maximum file size of %s", file, formatSize(info.Size()), formatSize(r.MaxSize))
+	}
+	data, err := os.ReadFile(file)
+	return file, data, err
+}
+
+// HTTPResolver resolves coordinates by fetching them from a registry over
+// HTTP, with GET requests for CoordinatePath below BaseURL. A 404 or 410
+// response means the registry has no such spec.
+type HTTPResolver struct {
+	BaseURL string
+	// Client makes the requests; nil uses a client with a 30 second timeout.
+	Client *http.Client
+	// MaxSize, if positive, is the size in bytes above which a response is
+	// not read.
+	MaxSize int64
+}
+
+// defaultHTTPClient is the client of an HTTPResolver without one.
+var defaultHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+// Resolve implements CoordinateResolver.
+func (r *HTTPResolver) Resolve(coordinate string) (string, []byte, error) {
+	p, err := CoordinatePath(coordinate)
+	if err != nil {
+		return "", nil, err
+	}
+	u, err := url.JoinPath(r.BaseURL, p)
+	if err != nil {
+		return "", nil, err
+	}
+	client := r.Client
+	if client == nil {
+		client = defaultHTTPClient
+	}
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return u, nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return u, nil, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return u, nil, fmt.Errorf("%w: GET %s: %s", ErrCoordinateNotFound, u, resp.Status)
+	case resp.StatusCode != http.StatusOK:
+		return u, nil, fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+
+	body := io.Reader(resp.Body)
+	if r.MaxSize > 0 {
+		body = io.LimitReader(resp.Body, r.MaxSize+1)
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return u, nil, fmt.Errorf("GET %s: %w", u, err)
+	}
+	if r.MaxSize > 0 && int64(len(data)) > r.MaxSize {
+		return u, nil, fmt.Errorf("GET %s: response is over the maximum file size of %s", u, formatSize(r.MaxSize))
+	}
+	return u, data, nil
+}
+
+// fetchImports fetches, with opts.Resolver, the specs of the coordinates
+// that members of ws import and that do not resolve within it, adding them
+// to ws. A coordinate the resolver has no spec for stays unresolved; other
+// failures, including specs that do not parse, are recorded for the
+// importers to report.
+func fetchImports(ws *semantic.Workspace, opts CheckOptions) {
+	if opts.Resolver == nil || opts.SchemaOnly || !opts.selectsPass(workspaceRules) {
+		return
+	}
+	for _, coordinate := range ws.Unresolved() {
+		source, data, err := opts.Resolver.Resolve(coordinate)
+		if errors.Is(err, ErrCoordinateNotFound) {
+			continue
+		}
+		if err != nil {
+			ws.FetchFailed(coordinate, err)
+			continue
+		}
+		spec, err := ast.ParseSpec(data)
+		if err != nil {
+			ws.FetchFailed(coordinate, fmt.Errorf("%s: %w", source, err))
+			continue
+		}
+		ws.AddFetched(coordinate, source, spec)
+	}
+}
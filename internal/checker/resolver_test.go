@@ -0,0 +1,149 @@
+package checker
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const registryOrdersSpec = `{
+  "version": "1",
+  "file": "orders.allium",
+  "use_declarations": [
+    {"coordinate": "org.example:billing@1.2.0", "alias": "billing"},
+    {"coordinate": "org.example:broken@1.0.0", "alias": "broken"}
+  ],
+  "external_entities": [{"name": "Invoice", "fields": []}]
+}`
+
+func TestCoordinatePath(t *testing.T) {
+	tests := []struct {
+		coordinate string
+		want       string
+	}{
+		{"org.example:payments@1.2.0", "org.example/payments@1.2.0.allium.json"},
+		{"github.com/acme/billing@1.2.0", "github.com/acme/billing@1.2.0.allium.json"},
+		{"github.com/acme/billing", "github.com/acme/billing.allium.json"},
+		{"billing", "billing.allium.json"},
+		{"../billing@1", ""},
+		{"org.example::billing", ""},
+		{"org.example:billing@1/../../x", ""},
+		{`org.example:..\billing`, ""},
+	}
+	for _, tt := range tests {
+		got, err := CoordinatePath(tt.coordinate)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("CoordinatePath(%q) = %q, want an error", tt.coordinate, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("CoordinatePath(%q) = %q, %v, want %q", tt.coordinate, got, err, tt.want)
+		}
+	}
+}
+
+func TestDirResolver(t *testing.T) {
+	dir := writeWorkspace(t, map[string]string{
+		"org.example/billing@1.2.0.allium.json": billingSpec,
+	})
+	r := NewResolver(dir, 0)
+	source, data, err := r.Resolve("org.example:billing@1.2.0")
+	if err != nil || string(data) != billingSpec {
+		t.Fatalf("Resolve = %q, %v", data, err)
+	}
+	if want := filepath.Join(dir, "org.example", "billing@1.2.0.allium.json"); source != want {
+		t.Errorf("source = %q, want %q", source, want)
+	}
+	if _, _, err := r.Resolve("org.example:billing@2.0.0"); !errors.Is(err, ErrCoordinateNotFound) {
+		t.Errorf("expected ErrCoordinateNotFound for a missing version, got %v", err)
+	}
+	if _, _, err := NewResolver(dir, 16).Resolve("org.example:billing@1.2.0"); err == nil || !strings.Contains(err.Error(), "over the maximum file size of 16 bytes") {
+		t.Errorf("expected a size error, got %v", err)
+	}
+}
+
+func TestHTTPResolver(t *testing.T) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.Path)
+		switch r.URL.Path {
+		case "/registry/org.example/billing@1.2.0.allium.json":
+			w.Write([]byte(billingSpec))
+		case "/registry/org.example/down@1.0.0.allium.json":
+			http.Error(w, "down", http.StatusServiceUnavailable)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	r := NewResolver(srv.URL+"/registry/", 0)
+	if _, ok := r.(*HTTPResolver); !ok {
+		t.Fatalf("NewResolver(URL) = %T, want *HTTPResolver", r)
+	}
+	source, data, err := r.Resolve("org.example:billing@1.2.0")
+	if err != nil || string(data) != billingSpec {
+		t.Fatalf("Resolve = %q, %v", data, err)
+	}
+	if want := srv.URL + "/registry/org.example/billing@1.2.0.allium.json"; source != want {
+		t.Errorf("source = %q, want %q", source, want)
+	}
+	if _, _, err := r.Resolve("org.example:missing@1.0.0"); !errors.Is(err, ErrCoordinateNotFound) {
+		t.Errorf("expected ErrCoordinateNotFound for a 404, got %v", err)
+	}
+	if _, _, err := r.Resolve("org.example:down@1.0.0"); err == nil || errors.Is(err, ErrCoordinateNotFound) || !strings.Contains(err.Error(), "503") {
+		t.Errorf("expected a 503 error, got %v", err)
+	}
+	if _, _, err := NewResolver(srv.URL+"/registry", 16).Resolve("org.example:billing@1.2.0"); err == nil || !strings.Contains(err.Error(), "over the maximum file size") {
+		t.Errorf("expected a size error, got %v", err)
+	}
+	if _, _, err := r.Resolve("../secrets"); err == nil {
+		t.Error("expected an error for an invalid coordinate")
+	}
+	if len(requests) != 4 {
+		t.Errorf("expected 4 requests, got %q", requests)
+	}
+}
+
+func TestCheckWorkspaceResolver(t *testing.T) {
+	registry := writeWorkspace(t, map[string]string{
+		"org.example/billing@1.2.0.allium.json": billingSpec,
+		"org.example/broken@1.0.0.allium.json":  `{"version": `,
+	})
+	dir := writeWorkspace(t, map[string]string{"orders.allium.json": registryOrdersSpec})
+	c, err := NewChecker()
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+	paths := []string{filepath.Join(dir, "orders.allium.json")}
+
+	// Without a resolver neither import resolves.
+	reports := c.CheckWorkspace(paths, CheckOptions{RuleIDs: []string{"RULE-35"}})
+	if n := len(ruleFindings(reports[0], "RULE-35")); n != 2 {
+		t.Errorf("expected 2 RULE-35 without a resolver, got %d", n)
+	}
+
+	reports, graph := c.CheckWorkspaceGraph(paths, CheckOptions{RuleIDs: []string{"RULE-35"}, Resolver: NewResolver(registry, 0)})
+	got := ruleFindings(reports[0], "RULE-35")
+	if len(got) != 1 || !strings.HasPrefix(got[0], "$.use_declarations[1].coordinate: Use declaration 'broken' coordinate 'org.example:broken@1.0.0' could not be fetched: ") {
+		t.Errorf("expected only the broken import to be reported, got %q", got)
+	}
+
+	fetched := filepath.Join(registry, "org.example", "billing@1.2.0.allium.json")
+	var nodes []string
+	for _, n := range graph.Nodes {
+		nodes = append(nodes, n.Path)
+	}
+	if !slices.Equal(nodes, []string{paths[0], fetched}) {
+		t.Errorf("graph nodes = %q", nodes)
+	}
+	if graph.Edges[0].To != fetched || graph.Edges[1].To != "" {
+		t.Errorf("graph edges = %+v", graph.Edges)
+	}
+}
@@ -0,0 +1,517 @@
+package checker
+
+import (
+	"slices"
+
+	"github.com/foundry-zero/allium/internal/report"
+)
+
+// RuleInfo describes a rule or warning the checker may report.
+type RuleInfo struct {
+	ID          string          `json:"id"` // e.g. "RULE-08" or "WARN-16"
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	Category    string          `json:"category"` // e.g. "State Machine"
+	Severity    report.Severity `json:"severity"` // before any project configuration override
+
+	// Implemented is false for rules that are documented but not yet
+	// checked, so they are never reported.
+	Implemented bool `json:"implemented"`
+
+	// Rationale says why the rule matters, and Invalid and Valid are spec
+	// snippets, in Allium source syntax or JSON, that break it and follow it.
+	Rationale string `json:"rationale"`
+	Invalid   string `json:"invalid"`
+	Valid     string `json:"valid"`
+}
+
+// Rules returns the catalog of every rule and warning, rules first, each in
+// numeric order. Structural rules are enforced by the JSON Schema and are
+// reported as SCHEMA errors rather than under their own ID.
+func Rules() []RuleInfo {
+	return slices.Clone(ruleCatalog)
+}
+
+// LookupRule returns the catalog entry for id, or false if there is none.
+func LookupRule(id string) (RuleInfo, bool) {
+	i := slices.IndexFunc(ruleCatalog, func(r RuleInfo) bool { return r.ID == id })
+	if i < 0 {
+		return RuleInfo{}, false
+	}
+	return ruleCatalog[i], true
+}
+
+// ruleCatalog mirrors docs/VALIDATION-RULES.md; TestRuleCatalog keeps the two
+// in step.
+var ruleCatalog = []RuleInfo{
+	{ID: "RULE-01", Title: "Entity referenced but not declared", Category: "Reference", Severity: report.SeverityError, Implemented: true,
+		Description: "An `entity_ref` type references an entity name that does not appear in `entities`, `external_entities`, or `use_declarations`.",
+		Rationale:   "A reference to an undeclared entity leaves the field without a type, so nothing that reads it, and no code generated from it, knows what it holds.",
+		Invalid:     "entity Session {\n    owner: Usr    -- no entity Usr is declared\n}",
+		Valid:       "entity Session {\n    owner: User\n}"},
+	{ID: "RULE-02", Title: "Every field must declare a type", Category: "Structural", Severity: report.SeverityError, Implemented: true,
+		Description: "Every entity field must include a `type` property with a valid `FieldType` discriminator.",
+		Rationale:   "A field without a type cannot be validated, stored or generated, so the schema rejects it.",
+		Invalid:     `{ "name": "email" }`,
+		Valid:       `{ "name": "email", "type": { "kind": "primitive", "value": "String" } }`},
+	{ID: "RULE-03", Title: "Relationship target entity not declared", Category: "Reference", Severity: report.SeverityError, Implemented: true,
+		Description: "A relationship's `target_entity` does not match any declared entity.",
+		Rationale:   "A relationship to an undeclared entity cannot be navigated, so every expression reading it is meaningless.",
+		Invalid:     "entity User {\n    sessions: Sesion with user = this\n}",
+		Valid:       "entity User {\n    sessions: Session with user = this\n}"},
+	{ID: "RULE-04", Title: "Every rule must have a trigger and non-empty ensures", Category: "Structural", Severity: report.SeverityError, Implemented: true,
+		Description: "Rules require both a `trigger` object and an `ensures` array with at least one clause.",
+		Rationale:   "A rule without a trigger never fires and one without ensures changes nothing, so either describes no behaviour.",
+		Invalid:     `{ "name": "DoNothing", "trigger": { "kind": "external_stimulus", "name": "noop" }, "ensures": [] }`,
+		Valid:       `{ "name": "CloseOrder", "trigger": { "kind": "external_stimulus", "name": "CloseOrder" }, "ensures": [{ "kind": "trigger_emission", "name": "OrderClosed" }] }`},
+	{ID: "RULE-05", Title: "Trigger kind must be one of 7 valid kinds", Category: "Structural", Severity: report.SeverityError, Implemented: true,
+		Description: "The trigger kind must be one of the kinds the schema declares.",
+		Rationale:   "Tools reading the spec only understand the declared trigger kinds, so a rule with any other kind has no defined way to fire.",
+		Invalid:     `{ "kind": "custom_trigger", "name": "my_trigger" }`,
+		Valid:       `{ "kind": "external_stimulus", "name": "MyTrigger" }`},
+	{ID: "RULE-06", Title: "Rules sharing a trigger must have compatible parameters", Category: "Uniqueness", Severity: report.SeverityError, Implemented: true,
+		Description: "When multiple rules share the same trigger name, their parameter signatures (count and positional types) must be compatible, and each parameter must be optional in all of them or in none.",
+		Rationale:   "A trigger is one event with one set of parameters: rules that disagree on them cannot all receive it, and a rule requiring a parameter that another treats as optional may fire without it.",
+		Invalid:     "rule SubmitForm {\n    when: UserSubmitsForm(user, form)\n    ...\n}\nrule AuditForm {\n    when: UserSubmitsForm(user, form, note)\n    ...\n}",
+		Valid:       "rule SubmitForm {\n    when: UserSubmitsForm(user, form, note?)\n    ...\n}\nrule AuditForm {\n    when: UserSubmitsForm(user, form, note?)\n    ...\n}"},
+	{ID: "RULE-07", Title: "Unreachable status enum value", Category: "State Machine", Severity: report.SeverityError, Implemented: true,
+		Description: "A status enum value cannot be reached from any creation point via the transition graph.",
+		Rationale:   "A status value that nothing can reach is dead weight in the state machine, and often a sign that the rule moving entities into it is missing.",
+		Invalid:     "entity Order {\n    status: pending | active | archived\n}\n-- no rule creates an Order as archived or sets order.status = archived",
+		Valid:       "rule ArchiveOrder {\n    when: ArchiveOrder(order)\n    requires: order.status = active\n    ensures: order.status = archived\n}"},
+	{ID: "RULE-08", Title: "Dead-end state with no outgoing transition", Category: "State Machine", Severity: report.SeverityError, Implemented: true,
+		Description: "A reachable, non-creation status value has no outgoing transitions.",
+		Rationale:   "An entity that enters a state it can never leave is stuck there, which usually means a rule such as an unblock or a timeout is missing.",
+		Invalid:     "entity Task {\n    status: open | blocked | done\n}\n-- rules move tasks from open to blocked and to done, but none out of blocked",
+		Valid:       "rule UnblockTask {\n    when: UnblockTask(task)\n    requires: task.status = blocked\n    ensures: task.status = open\n}"},
+	{ID: "RULE-09", Title: "Undeclared status value in assignment", Category: "State Machine", Severity: report.SeverityError, Implemented: true,
+		Description: "An ensures clause assigns a value to a status field that is not declared in the corresponding enum.",
+		Rationale:   "Assigning a value the enum does not declare puts the entity in a state the rest of the spec knows nothing about.",
+		Invalid:     "entity Order {\n    status: pending | active | completed\n}\n...\nensures: order.status = cancelled",
+		Valid:       "entity Order {\n    status: pending | active | completed | cancelled\n}\n...\nensures: order.status = cancelled"},
+	{ID: "RULE-10", Title: "Cycle detected in derived value dependencies", Category: "Expression", Severity: report.SeverityError, Implemented: true,
+		Description: "Derived values form a dependency cycle.",
+		Rationale:   "Values defined in terms of each other have no order in which they can be computed.",
+		Invalid:     "entity Order {\n    total: subtotal + tax\n    tax: total * 0.2\n}",
+		Valid:       "entity Order {\n    total: subtotal + tax\n    tax: subtotal * 0.2\n}"},
+	{ID: "RULE-11", Title: "Identifier not in scope", Category: "Expression", Severity: report.SeverityError, Implemented: true,
+		Description: "A field access path references a root identifier that is not available in the current scope.",
+		Rationale:   "A name that nothing binds has no value, so the condition or effect reading it is undefined.",
+		Invalid:     "rule ShipOrder {\n    when: ShipOrder(order)\n    requires: ordr.status = paid\n    ensures: order.status = shipped\n}",
+		Valid:       "rule ShipOrder {\n    when: ShipOrder(order)\n    requires: order.status = paid\n    ensures: order.status = shipped\n}"},
+	{ID: "RULE-12", Title: "Type mismatch in expression", Category: "Expression", Severity: report.SeverityError, Implemented: true,
+		Description: "An expression uses incompatible types in a comparison or arithmetic operation.",
+		Rationale:   "Comparing or combining values of different types is always false or meaningless, and usually hides the wrong field or literal.",
+		Invalid:     `requires: order.total > "100"    -- total is Integer`,
+		Valid:       "requires: order.total > 100"},
+	{ID: "RULE-13", Title: "Collection operation missing explicit lambda parameter", Category: "Expression", Severity: report.SeverityError, Implemented: true,
+		Description: "An `any`, `all`, or similar collection operation does not declare an explicit `lambda_param` for the iteration variable.",
+		Rationale:   "An explicit parameter names the element each test is about, so the predicate cannot be confused with the bindings around it.",
+		Invalid:     "requires: order.items.any(quantity > 10)",
+		Valid:       "requires: order.items.any(item => item.quantity > 10)"},
+	{ID: "RULE-14", Title: "Cannot compare inline enums from different fields", Category: "Expression", Severity: report.SeverityError, Implemented: true,
+		Description: "Inline enum fields from different declarations cannot be compared because they have no shared type identity.",
+		Rationale:   "Two inline enums are separate types even when their values overlap, so comparing them compares unrelated values.",
+		Invalid:     "entity Order {\n    status: pending | active\n}\nentity Task {\n    state: pending | done\n}\n...\nrequires: order.status = task.state",
+		Valid:       "enum Progress { pending | active | done }\nentity Order {\n    status: Progress\n}\nentity Task {\n    state: Progress\n}\n...\nrequires: order.status = task.state"},
+	{ID: "RULE-15", Title: "Discriminator variant names must be PascalCase", Category: "Structural", Severity: report.SeverityError, Implemented: true,
+		Description: "Variant names in entity discriminators must follow PascalCase naming (e.g., `Branch`, `Leaf`).",
+		Rationale:   "Variant names are type names, and PascalCase keeps them apart from field names and enum values.",
+		Invalid:     `{ "discriminator": ["branch", "leaf"] }`,
+		Valid:       `{ "discriminator": ["Branch", "Leaf"] }`},
+	{ID: "RULE-16", Title: "Discriminator variant has no matching variant declaration", Category: "Sum Type", Severity: report.SeverityError, Implemented: true,
+		Description: "An entity's discriminator lists a variant name, but no corresponding `variant X : Entity` declaration exists.",
+		Rationale:   "A discriminator value without a variant declaration leaves the instances of that kind without a shape.",
+		Invalid:     "entity Node {\n    kind: Branch | Leaf\n}\nvariant Branch : Node {\n    children: List<Node>\n}",
+		Valid:       "entity Node {\n    kind: Branch | Leaf\n}\nvariant Branch : Node {\n    children: List<Node>\n}\nvariant Leaf : Node {\n    data: String\n}"},
+	{ID: "RULE-17", Title: "Variant not listed in base entity discriminator", Category: "Sum Type", Severity: report.SeverityError, Implemented: true,
+		Description: "A variant declaration references a base entity, but the variant name does not appear in that entity's discriminator.",
+		Rationale:   "The discriminator decides which variant an instance is, so a variant it does not list can never have instances.",
+		Invalid:     "entity Node {\n    kind: Branch | Leaf\n}\nvariant Stem : Node {\n    ...\n}",
+		Valid:       "entity Node {\n    kind: Branch | Leaf | Stem\n}\nvariant Stem : Node {\n    ...\n}"},
+	{ID: "RULE-18", Title: "Variant field accessed without type guard", Category: "Sum Type", Severity: report.SeverityError, Implemented: true,
+		Description: "A rule accesses a field that is specific to a variant without narrowing the type via a type guard (e.g., a requires clause checking the discriminator value).",
+		Rationale:   "Only instances of a variant have its fields, so reading one without checking the kind fails for every other variant.",
+		Invalid:     "rule CountChildren {\n    when: CountChildren(node)\n    ensures: Report.created(size: node.children.count)\n}",
+		Valid:       "rule CountChildren {\n    when: CountChildren(node)\n    requires: node.kind = Branch\n    ensures: Report.created(size: node.children.count)\n}"},
+	{ID: "RULE-19", Title: "Must use variant name for creation when discriminator exists", Category: "Sum Type", Severity: report.SeverityError, Implemented: true,
+		Description: "When an entity has a discriminator, creation must use a specific variant name instead of the base entity name.",
+		Rationale:   "Creating the base entity leaves the discriminator unset, so the new instance is none of its variants.",
+		Invalid:     "ensures: Node.created(parent: parent)",
+		Valid:       "ensures: Leaf.created(parent: parent, data: data)"},
+	{ID: "RULE-20", Title: "Enumeration values must be non-empty", Category: "Structural", Severity: report.SeverityError, Implemented: true,
+		Description: "Named enumerations must declare at least one value.",
+		Rationale:   "An enumeration without values cannot hold any value, so no field of its type can ever be set.",
+		Invalid:     `{ "name": "Status", "values": [] }`,
+		Valid:       `{ "name": "Status", "values": ["open", "closed"] }`},
+	{ID: "RULE-21", Title: "Variant declaration requires name and base_entity", Category: "Structural", Severity: report.SeverityError, Implemented: true,
+		Description: "Every variant declaration must specify both a `name` and the `base_entity` it belongs to.",
+		Rationale:   "A variant without a base entity is attached to nothing, so it can be neither selected nor created.",
+		Invalid:     `{ "name": "Branch" }`,
+		Valid:       `{ "name": "Branch", "base_entity": "Node" }`},
+	{ID: "RULE-22", Title: "Given binding type not declared", Category: "Reference", Severity: report.SeverityError, Implemented: true,
+		Description: "A `given` binding references a type (entity or value type) that is not declared.",
+		Rationale:   "A given binding of an undeclared type gives every rule using it a value of unknown shape.",
+		Invalid:     "given {\n    pipeline: HiringPipline\n}",
+		Valid:       "given {\n    pipeline: HiringPipeline\n}"},
+	{ID: "RULE-23", Title: "Duplicate given binding name", Category: "Uniqueness", Severity: report.SeverityError, Implemented: true,
+		Description: "Two `given` bindings declare the same name.",
+		Rationale:   "Two given bindings with one name make every reference to the name ambiguous.",
+		Invalid:     "given {\n    current_user: User\n    current_user: Admin\n}",
+		Valid:       "given {\n    current_user: User\n    current_admin: Admin\n}"},
+	{ID: "RULE-24", Title: "Given binding requires name and type", Category: "Structural", Severity: report.SeverityError, Implemented: true,
+		Description: "Each `given` binding must declare a name and a type reference.",
+		Rationale:   "A given binding without a name cannot be referenced, and one without a type has no shape, so the schema rejects both.",
+		Invalid:     `{ "name": "current_user" }`,
+		Valid:       `{ "name": "current_user", "type": "User" }`},
+	{ID: "RULE-25", Title: "Config parameter requires name, type, and default_value", Category: "Structural", Severity: report.SeverityError, Implemented: true,
+		Description: "Every config parameter must specify name, type, and a default value.",
+		Rationale:   "A configuration parameter is only tunable when its type is known, and only usable when it has a value before anyone tunes it.",
+		Invalid:     `{ "name": "max_retries", "type": { "kind": "primitive", "value": "Integer" } }`,
+		Valid:       `{ "name": "max_retries", "type": { "kind": "primitive", "value": "Integer" }, "default_value": { "kind": "literal", "type": "Integer", "value": 3 } }`},
+	{ID: "RULE-26", Title: "Duplicate config parameter name", Category: "Uniqueness", Severity: report.SeverityError, Implemented: true,
+		Description: "Two `config` parameters declare the same name.",
+		Rationale:   "Two parameters with one name make config references ambiguous, and each may be tuned to a different value.",
+		Invalid:     "config {\n    max_retries: Integer = 3\n    max_retries: Integer = 5\n}",
+		Valid:       "config {\n    max_retries: Integer = 3\n}"},
+	{ID: "RULE-27", Title: "Config parameter referenced but not declared", Category: "Reference", Severity: report.SeverityError, Implemented: true,
+		Description: "An expression references a config parameter name that does not appear in the `config` array.",
+		Rationale:   "A reference to an undeclared parameter has no value and cannot be tuned.",
+		Invalid:     "config {\n    max_login_attempts: Integer = 5\n}\n...\nrequires: user.failed_login_attempts < config.max_attempts",
+		Valid:       "requires: user.failed_login_attempts < config.max_login_attempts"},
+	{ID: "RULE-28", Title: "Surface facing type not declared", Category: "Reference", Severity: report.SeverityError, Implemented: true,
+		Description: "A surface's `facing` clause references a type that does not match any declared entity or actor.",
+		Rationale:   "A surface facing an undeclared type has no defined audience, so who may use it is unknown.",
+		Invalid:     "surface Dashboard {\n    facing viewer: Admn\n    ...\n}",
+		Valid:       "surface Dashboard {\n    facing viewer: Admin\n    ...\n}"},
+	{ID: "RULE-29", Title: "Unreachable path in surface exposes", Category: "Surface", Severity: report.SeverityError, Implemented: true,
+		Description: "An `exposes` entry references a field path that is not reachable from the surface's `facing`, `context`, or `let` bindings.",
+		Rationale:   "The surface can only show what its bindings lead to, so an exposed path it cannot reach names data it has no way to show.",
+		Invalid:     "surface OrderView {\n    facing viewer: Customer\n    context order: Order\n    exposes:\n        product.name\n}",
+		Valid:       "surface OrderView {\n    facing viewer: Customer\n    context order: Order\n    exposes:\n        order.product.name\n}"},
+	{ID: "RULE-30", Title: "Surface provides trigger not declared", Category: "Reference", Severity: report.SeverityError, Implemented: true,
+		Description: "A surface `provides` clause references a trigger name that does not match any declared rule's trigger.",
+		Rationale:   "An action whose trigger no rule handles does nothing when it is used.",
+		Invalid:     "provides:\n    CancelOrdr(viewer, order)",
+		Valid:       "provides:\n    CancelOrder(viewer, order)    -- handled by rule CancelOrder"},
+	{ID: "RULE-31", Title: "Surface related surface name not declared", Category: "Reference", Severity: report.SeverityError, Implemented: true,
+		Description: "A surface `related` clause references a surface name that is not declared.",
+		Rationale:   "A link to an undeclared surface leads nowhere.",
+		Invalid:     "related:\n    OrderDetial(order)",
+		Valid:       "related:\n    OrderDetail(order)"},
+	{ID: "RULE-32", Title: "Unused binding in surface", Category: "Surface", Severity: report.SeverityError, Implemented: true,
+		Description: "A `facing` or `context` binding is declared but never referenced in the surface body (exposes, provides, related, or let bindings).",
+		Rationale:   "A surface that never reads a binding does not depend on who faces it or what it is about, which usually means an exposes or provides entry is missing.",
+		Invalid:     "surface Help {\n    facing viewer: User\n    exposes:\n        config.support_email\n}",
+		Valid:       "surface Help {\n    facing viewer: User\n    exposes:\n        viewer.email\n        config.support_email\n}"},
+	{ID: "RULE-33", Title: "Invalid when condition reference in surface", Category: "Surface", Severity: report.SeverityError, Implemented: true,
+		Description: "A `when` condition in a provides or exposes clause references a field that is not reachable from the surface's facing or context bindings.",
+		Rationale:   "A condition reading a name the surface does not bind cannot be evaluated, so whether the item is offered is undefined.",
+		Invalid:     "provides:\n    CancelOrder(viewer, order) when ordr.status = pending",
+		Valid:       "provides:\n    CancelOrder(viewer, order) when order.status = pending"},
+	{ID: "RULE-34", Title: "Cannot iterate over non-collection type", Category: "Surface", Severity: report.SeverityError, Implemented: true,
+		Description: "A `for_each` provides clause targets a field that is not a collection type (e.g., iterating over a String or Integer).",
+		Rationale:   "Iteration repeats its body once per element, so it needs a collection to iterate over.",
+		Invalid:     "provides:\n    for item in order.note:    -- note is a String\n        RemoveItem(viewer, item)",
+		Valid:       "provides:\n    for item in order.items:\n        RemoveItem(viewer, item)"},
+	{ID: "RULE-35", Title: "Use declaration imports unresolvable type", Category: "Reference", Severity: report.SeverityError, Implemented: true,
+		Description: "A `use_declaration` imports a type that cannot be resolved from the referenced external specification.",
+		Rationale:   "An import that does not resolve leaves the types it should provide undeclared, so the spec cannot be checked against the spec it depends on.",
+		Invalid:     "use \"org.example:billing@1.2.0\" as billing\nexternal entity Invoce {\n    ...\n}    -- billing declares Invoice",
+		Valid:       "use \"org.example:billing@1.2.0\" as billing\nexternal entity Invoice {\n    ...\n}"},
+	{ID: "RULE-36", Title: "Retention policy not realized by a temporal rule", Category: "Retention", Severity: report.SeverityError, Implemented: true,
+		Description: "An entity declares a `retention` policy, but the spec does not implement it.",
+		Rationale:   "A retention policy that no rule enforces is a promise the behaviour does not keep, which matters for data protection.",
+		Invalid:     "{ \"name\": \"Session\", \"retention\": { \"duration\": \"30.days\", \"from\": \"created_at\" } }\n-- and no temporal rule removes expired sessions",
+		Valid:       "rule ExpireSession {\n    when: session: Session.created_at + 30.days <= now\n    ensures: not exists session\n}"},
+	{ID: "RULE-37", Title: "Type alias duplicated, shadowing a type, or circular", Category: "Type Alias", Severity: report.SeverityError, Implemented: true,
+		Description: "A type alias must have a unique name, must not shadow a declared type, and must not refer to itself directly or through other aliases.",
+		Rationale:   "A duplicate or shadowing alias makes one name mean two types, and a circular alias never resolves to a type at all.",
+		Invalid:     "\"type_aliases\": [\n  { \"name\": \"Contact\", \"type\": { \"kind\": \"alias\", \"name\": \"ContactList\" } },\n  { \"name\": \"ContactList\", \"type\": { \"kind\": \"list\", \"element\": { \"kind\": \"alias\", \"name\": \"Contact\" } } }\n]",
+		Valid:       "\"type_aliases\": [\n  { \"name\": \"Contact\", \"type\": { \"kind\": \"entity_ref\", \"entity\": \"Person\" } },\n  { \"name\": \"ContactList\", \"type\": { \"kind\": \"list\", \"element\": { \"kind\": \"alias\", \"name\": \"Contact\" } } }\n]"},
+	{ID: "RULE-38", Title: "Unique constraint names an undeclared field", Category: "Uniqueness", Severity: report.SeverityError, Implemented: true,
+		Description: "An entity's `unique` constraints declare sets of fields whose combined values identify at most one instance.",
+		Rationale:   "A uniqueness constraint on fields the entity does not have constrains nothing.",
+		Invalid:     `{ "name": "User", "fields": [{ "name": "email", ... }], "unique": [{ "fields": ["username"] }] }`,
+		Valid:       `{ "name": "User", "fields": [{ "name": "email", ... }], "unique": [{ "fields": ["email"] }] }`},
+	{ID: "RULE-39", Title: "Import violates layering rule", Category: "Layering", Severity: report.SeverityError, Implemented: true,
+		Description: "A `use_declaration` resolves to a spec in a layer that the importing spec's layer must not import.",
+		Rationale:   "Layers keep lower levels of an architecture independent of higher ones, and an import in the wrong direction couples them.",
+		Invalid:     "-- core/orders.allium, in the core layer, which must not import the feature layer\nuse \"../features/billing.allium\" as billing",
+		Valid:       "-- the shared declarations move to a layer both may import\nuse \"../shared/billing-types.allium\" as billing"},
+	{ID: "RULE-40", Title: "Function call does not match its signature", Category: "Expression", Severity: report.SeverityError, Implemented: true,
+		Description: "A call to a registered black box function passes the wrong number of arguments, or an argument whose type the parameter does not accept.",
+		Rationale:   "A call that does not match the function's signature cannot be implemented as written.",
+		Invalid:     "requires: length(user.email, 8) > 0",
+		Valid:       "requires: length(user.email) > 8"},
+	{ID: "RULE-41", Title: "Trigger entity or field not declared", Category: "Reference", Severity: report.SeverityError, Implemented: true,
+		Description: "A trigger bound to an entity names an entity that is not declared, or watches a field, value or condition that the entity does not declare with a suitable type.",
+		Rationale:   "A trigger watching an undeclared entity, field or value never fires, and the state machine checks lose the transition it stands for.",
+		Invalid:     "when: user: User.state transitions_to locked    -- User declares status",
+		Valid:       "when: user: User.status transitions_to locked"},
+	{ID: "RULE-42", Title: "Breaking change from previous version", Category: "Compatibility", Severity: report.SeverityError, Implemented: true,
+		Description: "Compared with a previous version of the spec (`--against`), a change removes or narrows something that consumers of the previous version rely on.",
+		Rationale:   "Consumers of the previous version rely on what it declares, so removing or narrowing any of it breaks them.",
+		Invalid:     "-- previous version: entity User { password_hash: String }\nentity User {\n    secret_hash: String\n}",
+		Valid:       "entity User {\n    password_hash: String    -- kept until the next major version\n    secret_hash: String\n}"},
+	{ID: "RULE-43", Title: "Spec departs from template", Category: "Template", Severity: report.SeverityError, Implemented: true,
+		Description: "Checked against a spec template (`--template`), the spec lacks a required section or declaration, populates a section the template does not allow, or declares a name without one of the template's prefixes.",
+		Rationale:   "A template keeps a project's specs organized alike, so readers and tools find declarations where they expect them.",
+		Invalid:     "-- template prefixes: { \"rules\": [\"Admin\", \"Customer\"] }\nrule ShipOrder {\n    ...\n}",
+		Valid:       "rule AdminShipsOrder {\n    ...\n}"},
+	{ID: "RULE-44", Title: "Trigger emission arguments do not match parameters", Category: "Reference", Severity: report.SeverityError, Implemented: true,
+		Description: "A `trigger_emission` passes an argument the emitted chained trigger does not declare, or omits a parameter that is not optional.",
+		Rationale:   "The rules consuming a chained trigger read its parameters, so an emission supplying other arguments leaves them without the values they need.",
+		Invalid:     "ensures: AccountLockTriggered(account: user)\n...\nrule NotifySecurityTeam {\n    when: AccountLockTriggered(user)\n    ...\n}",
+		Valid:       "ensures: AccountLockTriggered(user: user)"},
+	{ID: "RULE-45", Title: "Entity creation fields do not match the entity", Category: "Reference", Severity: report.SeverityError, Implemented: true,
+		Description: "An `entity_creation` names an undeclared entity, sets a field its entity does not declare, or omits a field that is neither optional nor a collection.",
+		Rationale:   "An instance created without its required fields is incomplete, and values for fields the entity does not have are lost.",
+		Invalid:     "entity Session {\n    user: User\n    expires_at: Timestamp\n}\n...\nensures: Session.created(user: user, expires: now + 1.day)",
+		Valid:       "ensures: Session.created(user: user, expires_at: now + 1.day)"},
+	{ID: "RULE-46", Title: "State change target field not declared", Category: "Reference", Severity: report.SeverityError, Implemented: true,
+		Description: "A `state_change` assigns a field that the entity of its target's binding does not declare, or a derived value of it.",
+		Rationale:   "An assignment to a field the entity does not have changes nothing, and the state machine checks ignore it.",
+		Invalid:     "ensures: user.satus = locked",
+		Valid:       "ensures: user.status = locked"},
+	{ID: "RULE-47", Title: "Default instance does not match its entity", Category: "Reference", Severity: report.SeverityError, Implemented: true,
+		Description: "A `default` instance names an undeclared entity, sets a field the entity does not declare or to a value of the wrong type, or omits a field that is neither optional nor a collection.",
+		Rationale:   "The rest of the spec relies on a default instance existing as declared, which it cannot if it is not a valid instance of its entity.",
+		Invalid:     "entity User {\n    email: String\n    failed_login_attempts: Integer\n}\ndefault User system_user = { email: \"system@internal\", failed_login_attempts: \"0\" }",
+		Valid:       `default User system_user = { email: "system@internal", failed_login_attempts: 0 }`},
+	{ID: "RULE-48", Title: "Relationship foreign key does not refer back", Category: "Reference", Severity: report.SeverityError, Implemented: true,
+		Description: "A relationship's `foreign_key` names no field of its target that refers to the declaring entity, nor, for cardinality `one`, a field of the declaring entity that refers to the target.",
+		Rationale:   "A relationship is navigated through its foreign key, so a key that does not refer back to the declaring entity selects the wrong instances or none.",
+		Invalid:     "entity User {\n    sessions: Session with owner = this\n}\nentity Session {\n    user: User\n}",
+		Valid:       "entity User {\n    sessions: Session with user = this\n}\nentity Session {\n    user: User\n}"},
+	{ID: "RULE-49", Title: "Collection used as a single value, or single value as a collection", Category: "Expression", Severity: report.SeverityError, Implemented: true,
+		Description: "A `count`, `any`, `all`, `first`, `last` or `where` operation is applied to a value that is not a collection, or a comparison or arithmetic operand is a collection.",
+		Rationale:   "Treating a collection as one value, or one value as a collection, leaves the expression without a meaning.",
+		Invalid:     "requires: user.sessions > 3",
+		Valid:       "requires: user.sessions.count > 3"},
+	{ID: "RULE-50", Title: "Guarantee references undeclared rule or field", Category: "Surface", Severity: report.SeverityError, Implemented: true,
+		Description: "A surface guarantee names a rule that is not declared, or its expression reads a name the surface does not bind or a member its entity does not declare.",
+		Rationale:   "A guarantee is backed by the rules and fields it names, so if they do not exist the surface promises something no behaviour keeps.",
+		Invalid:     "guarantee: LockedAccountsCannotLogIn    -- its rules name LoginSuccesful, which is not declared",
+		Valid:       "guarantee: LockedAccountsCannotLogIn    -- its rules name LoginSuccessful and LoginAttemptWhileLocked"},
+	{ID: "RULE-51", Title: "Actor within scope not satisfied", Category: "Actor", Severity: report.SeverityError, Implemented: true,
+		Description: "An actor's `within` names no declared entity, its `identified_by` condition reads a name outside its entity's members, `this`, `within`, given bindings and config, or a surface facing it lacks a context of the `within` type.",
+		Rationale:   "An actor scoped within an entity only makes sense where that entity is known, so the scope must be declared and every surface facing the actor must supply it.",
+		Invalid:     "actor WorkspaceAdmin {\n    within: Worksapce\n    identified_by: User where WorkspaceMembership{user: this, workspace: within}.can_admin = true\n}",
+		Valid:       "actor WorkspaceAdmin {\n    within: Workspace\n    identified_by: User where WorkspaceMembership{user: this, workspace: within}.can_admin = true\n}"},
+	{ID: "RULE-52", Title: "Duplicate declaration or member name", Category: "Uniqueness", Severity: report.SeverityError, Implemented: true,
+		Description: "Two declarations of one kind, two types of any kind, two members of an entity, external entity, variant or value type, or two values of an enumeration share a name.",
+		Rationale:   "Two declarations or members with one name make every reference to the name ambiguous.",
+		Invalid:     "entity Money {\n    amount: Decimal\n}\nvalue Money {\n    currency: String\n}",
+		Valid:       "entity Payment {\n    amount: Decimal\n}\nvalue Money {\n    currency: String\n}"},
+	{ID: "RULE-53", Title: "Temporal trigger condition never scheduled", Category: "Expression", Severity: report.SeverityError, Implemented: true,
+		Description: "A temporal trigger's condition reads a Timestamp field of its entity but never orders one, or a time or duration computed from one, against `now` with `<`, `<=`, `>` or `>=`, so it does not become true as time passes.",
+		Rationale:   "A temporal trigger fires when the passing of time makes its condition true, which only happens when the condition orders a time against now.",
+		Invalid:     "when: invitation: Invitation.expires_at = now    -- true for an instant no scheduler observes",
+		Valid:       "when: invitation: Invitation.expires_at <= now"},
+	{ID: "RULE-54", Title: "Enum value not declared by the field's enum", Category: "Expression", Severity: report.SeverityError, Implemented: true,
+		Description: "An enum value literal is compared with, tested for membership against, or assigned by a `state_change` to a field whose enum does not declare it. Status fields assigned in ensures clauses are covered by RULE-09.",
+		Rationale:   "A value the field's enum does not declare never matches, so the comparison is always false and the assignment invalid.",
+		Invalid:     "entity Order {\n    priority: low | normal | high\n}\n...\nrequires: order.priority = urgnet",
+		Valid:       "requires: order.priority = high"},
+	{ID: "RULE-55", Title: "Optional value read without a null check", Category: "Expression", Severity: report.SeverityError, Implemented: true,
+		Description: "A field access reads a member of an optional field or binding where it is not known to be present: no enclosing condition, requires clause or when condition checks it with `exists` or `!= null`, and it is not the subject of a null test or the left of `??`. Optional parameters of external stimulus and chained triggers are checked the same way, except where passed on as an optional field or parameter.",
+		Rationale:   "An optional value may be absent, and reading it, or through it, without a check gives null where the rule expects a value.",
+		Invalid:     "requires: order.coupon.discount > 0    -- coupon is Coupon?",
+		Valid:       "requires: exists order.coupon and order.coupon.discount > 0"},
+	{ID: "RULE-56", Title: "Null comparison on a value that cannot be null", Category: "Expression", Severity: report.SeverityError, Implemented: true,
+		Description: "A field that is neither optional nor read through an optional value is compared with null, so the comparison is always false (`=`) or always true (`!=`).",
+		Rationale:   "A null check on a value that cannot be null is always true or always false, which usually means the field was meant to be optional.",
+		Invalid:     "entity User {\n    email: String\n}\n...\nrequires: user.email = null",
+		Valid:       "entity User {\n    email: String?\n}\n...\nrequires: user.email = null"},
+	{ID: "RULE-57", Title: "Ensures clause mutates an external entity", Category: "Reference", Severity: report.SeverityError, Implemented: true,
+		Description: "A `state_change` or `set_mutation` targets a field of an external entity, or an `entity_removal` removes one. External entities are managed by the spec that owns them, which a rule asks for the change with a `trigger_emission`.",
+		Rationale:   "External entities are owned by another spec, and changing them here bypasses that spec's rules.",
+		Invalid:     "external entity Payment {\n    state: pending | settled | refunded\n}\n...\nensures: payment.state = refunded",
+		Valid:       "ensures: RefundRequested(payment: payment)    -- handled by the spec owning Payment"},
+	{ID: "RULE-58", Title: "Condition is not Boolean", Category: "Expression", Severity: report.SeverityError, Implemented: true,
+		Description: "A `requires` clause, for clause condition, conditional ensures condition, surface context or `when` condition, or actor `identified_by` condition, or an operand of `and`, `or` or `not` within one, has a known type other than Boolean, such as an enum field read as `requires: order.status`.",
+		Rationale:   "A condition decides whether something happens, so a value that is not true or false leaves that undecided.",
+		Invalid:     "requires: order.status",
+		Valid:       "requires: order.status = pending"},
+	{ID: "RULE-59", Title: "Bundle manifest does not match its members", Category: "Bundle", Severity: report.SeverityError, Implemented: true,
+		Description: "In a bundle (`--bundle`), a manifest entry lacks a coordinate or path, repeats a coordinate, or names a file the bundle does not contain; a member is not listed in the manifest; or a `use_declaration` imports a coordinate the manifest does not list.",
+		Rationale:   "A bundle is published as one unit, so its manifest must describe exactly the specs it holds and the coordinates they import.",
+		Invalid:     "{ \"name\": \"acme\", \"specs\": [{ \"coordinate\": \"github.com/acme/orders@1.0.0\", \"path\": \"orders.allium.json\" }] }\n-- orders.allium.json imports github.com/acme/tax@2.0.0",
+		Valid:       "{ \"name\": \"acme\", \"specs\": [\n  { \"coordinate\": \"github.com/acme/orders@1.0.0\", \"path\": \"orders.allium.json\" },\n  { \"coordinate\": \"github.com/acme/tax@2.0.0\", \"path\": \"tax.allium.json\" }\n] }"},
+	{ID: "RULE-60", Title: "Spec metadata is malformed", Category: "Metadata", Severity: report.SeverityError, Implemented: true,
+		Description: "The spec's `metadata.spec_version` is not a semantic version, an entry of `metadata.authors` is empty or repeated, or `metadata.reviewed_at` is neither a date nor an RFC 3339 timestamp.",
+		Rationale:   "Tools compare, order and audit specs by their metadata, which they can only do when it is well formed.",
+		Invalid:     `"metadata": { "spec_version": "v1.4", "reviewed_at": "last week" }`,
+		Valid:       `"metadata": { "spec_version": "1.4.0", "reviewed_at": "2026-03-01" }`},
+	{ID: "RULE-61", Title: "Spec version does not reflect its changes", Category: "Compatibility", Severity: report.SeverityError, Implemented: true,
+		Description: "Compared with a previous version of the spec (`--against`), `metadata.spec_version` is missing or lower than the previous version's, or the spec has breaking changes without a major version increment.",
+		Rationale:   "The version tells consumers whether an upgrade is safe, and a breaking change without a major increment tells them it is when it is not.",
+		Invalid:     "\"metadata\": { \"spec_version\": \"1.5.0\" }\n-- the previous version is 1.4.2, and this one renames User.password_hash",
+		Valid:       `"metadata": { "spec_version": "2.0.0" }`},
+	{ID: "RULE-62", Title: "Surface exposes sensitive field", Category: "Surface", Severity: report.SeverityError, Implemented: true,
+		Description: "A surface exposes a field marked sensitive in `metadata.sensitive_fields` or the project configuration to an actor not allowed to see it, directly, through a derived value computed from it, or through an exposed entity whose fields and relationships lead to it.",
+		Rationale:   "Showing sensitive data to an actor not allowed to see it is a data leak, whether the surface exposes it directly or through values computed from it.",
+		Invalid:     "-- sensitive_fields: { \"User\": { \"password_hash\": [\"Admin\"] } }\nsurface Profile {\n    facing visitor: Visitor\n    exposes:\n        visitor.password_hash\n}",
+		Valid:       "surface Profile {\n    facing visitor: Visitor\n    exposes:\n        visitor.email\n}"},
+	{ID: "WARN-01", Title: "External entity has no governing spec", Category: "Reference", Severity: report.SeverityWarning, Implemented: true,
+		Description: "An external entity is declared but not associated with any `use_declaration` import.",
+		Rationale:   "Without a governing spec, nothing checks that the shape declared here matches the system that owns the entity.",
+		Invalid:     "external entity Payment {\n    amount: Decimal\n}",
+		Valid:       "use \"org.example:payments@1.0.0\" as payments\nexternal entity Payment {\n    amount: Decimal\n}"},
+	{ID: "WARN-02", Title: "Open questions present", Category: "Completeness", Severity: report.SeverityInfo, Implemented: true,
+		Description: "The spec contains unresolved open questions.",
+		Rationale:   "Open questions are design decisions still to be made, and the behaviour depending on them may change.",
+		Invalid:     `open question "Should locked accounts unlock on their own?"`,
+		Valid:       "-- resolved: accounts unlock after config.lockout_duration\nrule UnlockAccount {\n    ...\n}"},
+	{ID: "WARN-03", Title: "Deferred spec has no location hint", Category: "Completeness", Severity: report.SeverityWarning, Implemented: true,
+		Description: "A deferred specification entry has a null or empty `location_hint`.",
+		Rationale:   "Without a location hint, readers cannot find where the deferred behaviour is or will be specified.",
+		Invalid:     "deferred InterviewerMatching.suggest",
+		Valid:       "deferred InterviewerMatching.suggest    -- see: detailed/interviewer-matching.allium"},
+	{ID: "WARN-04", Title: "Unused entity or field", Category: "Usage", Severity: report.SeverityWarning, Implemented: true,
+		Description: "An entity or field is declared but never referenced by any rule, surface, relationship, or other entity.",
+		Rationale:   "A declaration nothing uses is either dead weight or a sign that the behaviour using it is missing.",
+		Invalid:     "entity Archive {\n    ...\n}    -- no rule, surface or relationship mentions Archive",
+		Valid:       "rule ArchiveOrder {\n    when: ArchiveOrder(order)\n    ensures: Archive.created(order: order)\n}"},
+	{ID: "WARN-05", Title: "Rule can never fire (contradictory requires)", Category: "Rule Logic", Severity: report.SeverityWarning, Implemented: true,
+		Description: "A rule's requires clauses are mutually exclusive, making the rule impossible to trigger.",
+		Rationale:   "A rule whose requires cannot all hold at once never fires, so the behaviour it describes never happens.",
+		Invalid:     "requires: order.total > 100\nrequires: order.total <= 50",
+		Valid:       "requires: order.total > 100"},
+	{ID: "WARN-06", Title: "Temporal rule has no re-firing guard", Category: "Rule Logic", Severity: report.SeverityWarning, Implemented: true,
+		Description: "A temporal trigger has no requires clause to prevent it from firing repeatedly on the same entity.",
+		Rationale:   "A temporal condition stays true once time has made it true, so without a guard the rule fires again for the same entity.",
+		Invalid:     "rule InvitationExpires {\n    when: invitation: Invitation.expires_at <= now\n    ensures: invitation.status = expired\n}",
+		Valid:       "rule InvitationExpires {\n    when: invitation: Invitation.expires_at <= now\n    requires: invitation.status = pending\n    ensures: invitation.status = expired\n}"},
+	{ID: "WARN-07", Title: "Surface exposes unused field", Category: "Surface", Severity: report.SeverityWarning, Implemented: true,
+		Description: "A surface exposes a field that is not used by any rule in the system.",
+		Rationale:   "Data a surface shows but no rule uses may be stale or meaningless, or point at behaviour the spec does not describe yet.",
+		Invalid:     "exposes:\n    order.archived_at    -- no rule reads or writes archived_at",
+		Valid:       "exposes:\n    order.status"},
+	{ID: "WARN-08", Title: "Provides has impossible when condition", Category: "Surface", Severity: report.SeverityWarning, Implemented: true,
+		Description: "A surface provides action has a `when` condition that can never be true, so the action is never offered.",
+		Rationale:   "An action whose condition can never hold is never offered, so the behaviour behind it cannot be reached from the surface.",
+		Invalid:     "provides:\n    ShipOrder(viewer, order) when order.status = pending and order.status = shipped",
+		Valid:       "provides:\n    ShipOrder(viewer, order) when order.status = pending"},
+	{ID: "WARN-09", Title: "Unused actor", Category: "Usage", Severity: report.SeverityWarning, Implemented: true,
+		Description: "An actor is declared but never referenced in any surface `facing` clause.",
+		Rationale:   "An actor that no surface faces has no way to interact with the system, so either a surface is missing or the actor is not needed.",
+		Invalid:     "actor AdminUser {\n    identified_by: User where role = admin\n}    -- no surface faces AdminUser",
+		Valid:       "surface AdminDashboard {\n    facing admin: AdminUser\n    ...\n}"},
+	{ID: "WARN-10", Title: "Entity creation without duplicate guard on a unique constraint", Category: "Uniqueness", Severity: report.SeverityWarning, Implemented: true,
+		Description: "A rule creates an entity that declares a `unique` constraint without first checking that no instance with the same values for the constrained fields exists.",
+		Rationale:   "Creating an entity without checking its unique fields may create the duplicate its constraint forbids.",
+		Invalid:     "rule Register {\n    when: UserRegisters(email)\n    ensures: User.created(email: email)\n}",
+		Valid:       "rule Register {\n    when: UserRegisters(email)\n    requires: not exists User{email: email}\n    ensures: User.created(email: email)\n}"},
+	{ID: "WARN-11", Title: "Provides condition weaker than rule requires", Category: "Surface", Severity: report.SeverityWarning, Implemented: false,
+		Description: "A surface provides a trigger with a `when` condition that is strictly weaker than the corresponding rule's `requires` clause, meaning the action may be presented when the rule cannot actually fire.",
+		Rationale:   "An action offered when its rule cannot fire fails when it is used.",
+		Invalid:     "provides:\n    ActivateTask(viewer, task) when task.status != done\n-- rule ActivateTask requires task.status = pending",
+		Valid:       "provides:\n    ActivateTask(viewer, task) when task.status = pending"},
+	{ID: "WARN-12", Title: "Overlapping preconditions on shared trigger", Category: "Rule Logic", Severity: report.SeverityWarning, Implemented: true,
+		Description: "Two rules sharing the same trigger have requires clauses that could both be true simultaneously, creating ambiguity about which rule fires.",
+		Rationale:   "When two rules can both fire for one event, which one applies, or whether both do, is ambiguous.",
+		Invalid:     "rule ApproveSmall {\n    when: ApproveOrder(order)\n    requires: order.amount < 1000\n    ...\n}\nrule ApproveLarge {\n    when: ApproveOrder(order)\n    requires: order.amount > 500\n    ...\n}",
+		Valid:       "rule ApproveLarge {\n    when: ApproveOrder(order)\n    requires: order.amount >= 1000\n    ...\n}"},
+	{ID: "WARN-13", Title: "Derived value references out-of-entity field", Category: "Expression", Severity: report.SeverityWarning, Implemented: true,
+		Description: "A derived value on an entity or value type references a name that the owner does not declare and cannot reach through its relationships.",
+		Rationale:   "A derived value is computed from its owner's members, so a name outside them has no value for the instance.",
+		Invalid:     "entity Order {\n    customer: Customer\n    discounted: total * (1 - discount_rate)    -- discount_rate is a field of Customer\n}",
+		Valid:       "entity Order {\n    customer: Customer\n    discounted: total * (1 - customer.discount_rate)\n}"},
+	{ID: "WARN-14", Title: "Trivial actor identified_by condition", Category: "Actor", Severity: report.SeverityWarning, Implemented: true,
+		Description: "An actor's `identified_by` condition always evaluates to true or always to false.",
+		Rationale:   "A condition that always holds makes every instance of the entity the actor, and one that never holds makes it no one.",
+		Invalid:     "actor Admin {\n    identified_by: User where 1 = 2\n}",
+		Valid:       "actor Admin {\n    identified_by: User where role = admin\n}"},
+	{ID: "WARN-15", Title: "All-conditional ensures with empty path", Category: "Rule Logic", Severity: report.SeverityWarning, Implemented: true,
+		Description: "All ensures clauses in a rule are inside conditionals, and at least one branch produces no effects.",
+		Rationale:   "When every effect is conditional and some path has none, the rule can fire and change nothing, which is usually an oversight.",
+		Invalid:     "ensures:\n    if order.total > 100:\n        order.discount = 10\n    else:\n        -- nothing",
+		Valid:       "ensures:\n    if order.total > 100:\n        order.discount = 10\n    else:\n        order.discount = 0"},
+	{ID: "WARN-16", Title: "Temporal trigger on optional field", Category: "Rule Logic", Severity: report.SeverityWarning, Implemented: true,
+		Description: "A temporal trigger references an optional field (`T?`) which may be absent, preventing the trigger from ever firing.",
+		Rationale:   "A temporal condition on an absent value is never true, so the rule never fires for entities without the value.",
+		Invalid:     "entity Subscription {\n    expires_at: Timestamp?\n}\n...\nwhen: subscription: Subscription.expires_at <= now",
+		Valid:       "entity Subscription {\n    expires_at: Timestamp\n}\n...\nwhen: subscription: Subscription.expires_at <= now"},
+	{ID: "WARN-17", Title: "Raw entity type used when actors available", Category: "Surface", Severity: report.SeverityWarning, Implemented: true,
+		Description: "A surface uses a raw entity type in its `facing` clause when actor declarations exist for that entity.",
+		Rationale:   "Facing the raw entity lets every instance of it use the surface, bypassing the access control its actors express.",
+		Invalid:     "surface AdminPanel {\n    facing user: User    -- AdminUser is identified_by User\n    ...\n}",
+		Valid:       "surface AdminPanel {\n    facing admin: AdminUser\n    ...\n}"},
+	{ID: "WARN-18", Title: "transitions_to fires on creation value", Category: "State Machine", Severity: report.SeverityWarning, Implemented: true,
+		Description: "A `transitions_to` trigger fires on a status value that entities can be created with, meaning the trigger may fire during creation rather than only on transitions.",
+		Rationale:   "A transition trigger is meant for changes of state, but entities created in the value may fire it too, running the rule for new entities unexpectedly.",
+		Invalid:     "when: order: Order.status transitions_to active\n-- and a rule ensures Order.created(status: active, ...)",
+		Valid:       "when: order: Order.status transitions_to active\nrequires: order.activated_by != null    -- only set by a transition"},
+	{ID: "WARN-19", Title: "Multiple identical inline enums suggest named enum", Category: "Style", Severity: report.SeverityWarning, Implemented: true,
+		Description: "The same entity has multiple fields with identical inline enum literal sets, suggesting a named enumeration would be clearer.",
+		Rationale:   "Fields with the same inline values are separate types, so they cannot be compared and drift apart as values are added.",
+		Invalid:     "entity Issue {\n    priority: low | medium | high\n    severity: low | medium | high\n}",
+		Valid:       "enum Level { low | medium | high }\nentity Issue {\n    priority: Level\n    severity: Level\n}"},
+	{ID: "WARN-20", Title: "Emitted trigger has no consumer", Category: "Rule Logic", Severity: report.SeverityWarning, Implemented: true,
+		Description: "A rule emits a chained trigger (`trigger_emission`) that no rule declares as its `chained` trigger, so the emission has no effect.",
+		Rationale:   "An emission that no rule consumes has no effect, which often means the trigger name is misspelt.",
+		Invalid:     "ensures: OrderShiped(order: order)    -- rule Notify listens for OrderShipped",
+		Valid:       "ensures: OrderShipped(order: order)"},
+	{ID: "WARN-21", Title: "Suppression matches no finding", Category: "Suppression", Severity: report.SeverityWarning, Implemented: true,
+		Description: "An entry in the top-level `suppressions` section silences nothing.",
+		Rationale:   "A suppression that silences nothing is stale, and would hide a new finding at the same place.",
+		Invalid:     "\"suppressions\": [{ \"rule\": \"WARN-20\", \"path\": \"$.rules[3]\", \"reason\": \"consumed by billing\" }]\n-- rule 3 no longer emits an unconsumed trigger",
+		Valid:       `"suppressions": []`},
+	{ID: "WARN-22", Title: "Ensures clause depends on an earlier effect", Category: "Rule Logic", Severity: report.SeverityWarning, Implemented: true,
+		Description: "An ensures clause reads a field changed, or a binding removed, by an earlier clause of the same rule.",
+		Rationale:   "The clauses of a rule take effect together, so reading a value an earlier clause changes sees the old or the new value depending on the implementation.",
+		Invalid:     "ensures: user.failed_login_attempts = user.failed_login_attempts + 1\nensures:\n    if user.failed_login_attempts >= config.max_login_attempts:\n        user.status = locked",
+		Valid:       "ensures: user.failed_login_attempts = user.failed_login_attempts + 1\nensures:\n    if user.failed_login_attempts + 1 >= config.max_login_attempts:\n        user.status = locked"},
+	{ID: "WARN-23", Title: "Trigger parameter shares a global name", Category: "Rule Logic", Severity: report.SeverityWarning, Implemented: true,
+		Description: "A trigger parameter has the same name as a given binding, config parameter or default instance, so references to the name in the rule are ambiguous.",
+		Rationale:   "A parameter named like a given binding, config parameter or default instance hides it, so readers cannot tell which one the rule means.",
+		Invalid:     "default User admin = { ... }\nrule GrantAccess {\n    when: AdminGrantsAccess(admin, user)\n    ...\n}",
+		Valid:       "rule GrantAccess {\n    when: AdminGrantsAccess(granting_admin, user)\n    ...\n}"},
+	{ID: "WARN-24", Title: "Required references form a cycle", Category: "Reference", Severity: report.SeverityWarning, Implemented: true,
+		Description: "Entities reference each other through non-optional fields, so no instance of any of them can be created before the others exist.",
+		Rationale:   "When each entity in a cycle needs another to exist first, none of them can be created.",
+		Invalid:     "entity Order {\n    buyer: User\n}\nentity User {\n    first_order: Order\n}",
+		Valid:       "entity Order {\n    buyer: User\n}\nentity User {\n    first_order: Order?\n}"},
+	{ID: "WARN-25", Title: "Surface cannot supply a required trigger entity", Category: "Surface", Severity: report.SeverityWarning, Implemented: true,
+		Description: "A surface provides an external stimulus whose rule requires fields of an entity parameter that the surface neither passes nor binds or exposes for its actor.",
+		Rationale:   "The actor has to supply every entity the rule requires, so an action whose surface gives no way to supply one cannot be used.",
+		Invalid:     "surface OrderView {\n    facing customer: Customer\n    context order: Order\n    provides:\n        CancelOrder(order, invoice)\n}",
+		Valid:       "surface OrderView {\n    facing customer: Customer\n    context order: Order\n    provides:\n        CancelOrder(order, invoice: order.invoice)\n}"},
+	{ID: "WARN-26", Title: "Conditional does not handle every enum value", Category: "Rule Logic", Severity: report.SeverityWarning, Implemented: true,
+		Description: "A chain of ensures conditionals branches on the values of an enum field, with no final else, and leaves some value the rule can see unhandled.",
+		Rationale:   "A rule that branches on an enum but handles only some of its values does nothing for the rest, which is usually an oversight.",
+		Invalid:     "ensures:\n    if order.status = pending:\n        ...\n    else if order.status = shipped:\n        ...\n-- Order.status is pending | shipped | delivered",
+		Valid:       "ensures:\n    if order.status = pending:\n        ...\n    else if order.status = shipped:\n        ...\n    else:\n        ..."},
+	{ID: "WARN-27", Title: "Relationship pair declared inconsistently", Category: "Reference", Severity: report.SeverityWarning, Implemented: true,
+		Description: "Two relationships of an entity are read through the same foreign key, but one has cardinality `one` and the other `many`.",
+		Rationale:   "One foreign key read as both a single instance and a collection contradicts itself about how many instances there are.",
+		Invalid:     "entity User {\n    session: Session with user = this\n    sessions: Session with user = this\n}",
+		Valid:       "entity User {\n    sessions: Session with user = this\n}"},
+	{ID: "WARN-28", Title: "Name departs from the project's naming conventions", Category: "Naming", Severity: report.SeverityWarning, Implemented: true,
+		Description: "A field, enum value, trigger, surface or binding name does not follow a convention enabled in the project configuration's `naming` section.",
+		Rationale:   "Names that follow the project's conventions make specs easier to read and search across the project.",
+		Invalid:     "entity User {\n    createdAt: Timestamp    -- naming.fields is snake_case\n}",
+		Valid:       "entity User {\n    created_at: Timestamp\n}"},
+	{ID: "WARN-29", Title: "Binding shadows a name in scope", Category: "Rule Logic", Severity: report.SeverityWarning, Implemented: true,
+		Description: "A for, let, iteration or lambda binding in a rule has the name of a given binding, config parameter, default instance, trigger parameter or enclosing binding, which it hides.",
+		Rationale:   "A binding that hides another name makes references to it ambiguous to readers.",
+		Invalid:     "rule CloseOrders {\n    when: OrdersClosed(order)\n    ensures:\n        for order in order.customer.orders:\n            order.status = closed\n}",
+		Valid:       "rule CloseOrders {\n    when: OrdersClosed(order)\n    ensures:\n        for open_order in order.customer.orders:\n            open_order.status = closed\n}"},
+	{ID: "WARN-30", Title: "Conditional branch can never be taken", Category: "Rule Logic", Severity: report.SeverityWarning, Implemented: true,
+		Description: "A conditional ensures clause's condition can never hold, or can never fail, given the rule's requires and the enclosing conditions, so its then or else branch never runs.",
+		Rationale:   "A branch that can never run is dead code, and often a sign that its condition or the rule's requires is wrong.",
+		Invalid:     "requires: order.status = pending\nensures:\n    if order.status = shipped:\n        ...",
+		Valid:       "requires: order.status in {pending, shipped}\nensures:\n    if order.status = shipped:\n        ..."},
+	{ID: "WARN-31", Title: "Unused config parameter or given binding", Category: "Usage", Severity: report.SeverityWarning, Implemented: true,
+		Description: "A config parameter or given binding is declared but no expression in the spec references it.",
+		Rationale:   "A parameter or binding that nothing reads is dead weight, or a sign that the behaviour using it is missing.",
+		Invalid:     "config {\n    max_attempts: Integer = 5\n}    -- nothing reads config.max_attempts",
+		Valid:       "config {\n    max_attempts: Integer = 5\n}\n...\nrequires: user.failed_attempts < config.max_attempts"},
+	{ID: "WARN-32", Title: "Decimal value assigned to an Integer field", Category: "Expression", Severity: report.SeverityWarning, Implemented: true,
+		Description: "A state change or entity creation in a rule sets an Integer field to a Decimal value, such as the result of arithmetic mixing Integer and Decimal, losing its fractional part.",
+		Rationale:   "An Integer field cannot hold a fraction, so assigning it a Decimal silently drops one.",
+		Invalid:     "entity Order {\n    total: Integer\n    points: Integer\n}\n...\nensures: order.points = order.total * 0.1",
+		Valid:       "entity Order {\n    total: Integer\n    points: Decimal\n}\n...\nensures: order.points = order.total * 0.1"},
+}
@@ -0,0 +1,129 @@
+package checker
+
+import (
+	goast "go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/foundry-zero/allium/internal/report"
+)
+
+// reportedIDs returns the rule and warning IDs that appear as string
+// literals in the non-test Go files under root, other than the catalog.
+func reportedIDs(t *testing.T, root string) map[string]bool {
+	t.Helper()
+	id := regexp.MustCompile(`^(RULE|WARN)-[0-9]{2}$`)
+	ids := make(map[string]bool)
+	catalog, err := filepath.Abs("rules.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return err
+		}
+		if abs, _ := filepath.Abs(path); abs == catalog {
+			return nil
+		}
+		f, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+		goast.Inspect(f, func(n goast.Node) bool {
+			if lit, ok := n.(*goast.BasicLit); ok && lit.Kind == token.STRING {
+				if v, err := strconv.Unquote(lit.Value); err == nil && id.MatchString(v) {
+					ids[v] = true
+				}
+			}
+			return true
+		})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ids
+}
+
+// TestRuleCatalog checks the catalog against the tables of
+// docs/VALIDATION-RULES.md and against the IDs the validator's source can
+// report.
+func TestRuleCatalog(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "..", "docs", "VALIDATION-RULES.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	type row struct{ title, category string }
+	documented := make(map[string]row)
+	for _, m := range regexp.MustCompile(`(?m)^\| (RULE-\d+) \| error \| (.*?) \| (.*?) \|$`).FindAllStringSubmatch(string(data), -1) {
+		documented[m[1]] = row{m[2], m[3]}
+	}
+	for _, m := range regexp.MustCompile(`(?m)^\| (WARN-\d+) \| (.*?) \| (.*?) \|$`).FindAllStringSubmatch(string(data), -1) {
+		documented[m[1]] = row{m[2], m[3]}
+	}
+
+	rules := Rules()
+	if len(rules) != len(documented) {
+		t.Errorf("catalog has %d entries, docs/VALIDATION-RULES.md documents %d", len(rules), len(documented))
+	}
+	reported := reportedIDs(t, "..")
+	for i, r := range rules {
+		if i > 0 && r.ID <= rules[i-1].ID {
+			t.Errorf("catalog entry %s is out of order after %s", r.ID, rules[i-1].ID)
+		}
+		doc, ok := documented[r.ID]
+		if !ok {
+			t.Errorf("%s is not documented in docs/VALIDATION-RULES.md", r.ID)
+		} else if doc.title != r.Title || doc.category != r.Category {
+			t.Errorf("%s: catalog has %q (%s), docs have %q (%s)", r.ID, r.Title, r.Category, doc.title, doc.category)
+		}
+		if r.Description == "" {
+			t.Errorf("%s has no description", r.ID)
+		}
+		if r.Rationale == "" || r.Invalid == "" || r.Valid == "" || r.Invalid == r.Valid {
+			t.Errorf("%s needs a rationale and distinct invalid and valid snippets for explain", r.ID)
+		}
+
+		wantSeverity := report.SeverityError
+		switch {
+		case r.ID == "WARN-02":
+			wantSeverity = report.SeverityInfo
+		case strings.HasPrefix(r.ID, "WARN-"):
+			wantSeverity = report.SeverityWarning
+		}
+		if r.Severity != wantSeverity {
+			t.Errorf("%s has severity %s, want %s", r.ID, r.Severity, wantSeverity)
+		}
+
+		// Structural rules are enforced by the schema, not reported by ID.
+		if r.Category == "Structural" {
+			continue
+		}
+		if r.Implemented != reported[r.ID] {
+			t.Errorf("%s: catalog says implemented=%v, but the source reports it: %v", r.ID, r.Implemented, reported[r.ID])
+		}
+	}
+	for id := range reported {
+		if _, ok := LookupRule(id); !ok {
+			t.Errorf("source reports %s, which is not in the catalog", id)
+		}
+	}
+}
+
+func TestLookupRule(t *testing.T) {
+	r, ok := LookupRule("RULE-08")
+	if !ok || r.Title != "Dead-end state with no outgoing transition" || r.Category != "State Machine" {
+		t.Errorf("LookupRule(RULE-08) = %+v, %v", r, ok)
+	}
+	if _, ok := LookupRule("RULE-99"); ok {
+		t.Error("expected no entry for RULE-99")
+	}
+}
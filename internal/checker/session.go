@@ -0,0 +1,161 @@
+package checker
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/foundry-zero/allium/internal/report"
+	"github.com/foundry-zero/allium/internal/semantic"
+)
+
+// entrySections are the top-level spec sections that only some passes read.
+// Every pass reads every other section: the version and file, and the
+// declarations of types, names and defaults that specs resolve against.
+var entrySections = []string{"rules", "surfaces", "actors", "deferred", "open_questions", "suppressions"}
+
+// passSections lists, for built-in passes that do not read every entry
+// section, those they do read, whether directly or through the helpers they
+// call. Passes not listed, including any registered with RegisterPass, are
+// taken to read every section. TestPassSectionsCoverWhatPassesRead checks
+// the list against what passes report as sections change.
+var passSections = map[string][]string{
+	"statemachines": {"rules"},
+	"sumtypes":      {"rules"},
+	"surfaces":      {"rules", "surfaces", "actors"},
+	"retention":     {"rules"},
+	"aliases":       {},
+	"triggers":      {"rules"},
+	"creations":     {"rules"},
+	"statechanges":  {"rules"},
+	"relationships": {},
+	"actors":        {"surfaces", "actors"},
+	"temporal":      {"rules"},
+	"nullability":   {"rules", "surfaces", "actors"},
+	"sensitive":     {"surfaces", "actors"},
+}
+
+// impliedSections lists, for entry sections, those that passes reading them
+// read as well: a surface's facing binding is typed as the entity identifying
+// the actor it names, so reading a surface reads actors.
+var impliedSections = map[string][]string{
+	"surfaces": {"actors"},
+}
+
+// unindexedSections are the top-level sections the symbol table does not
+// index, so a symbol table stays valid when only they change.
+var unindexedSections = []string{"version", "file", "defaults", "deferred", "open_questions", "suppressions"}
+
+// Session checks successive versions of specs, such as the buffers of an
+// editor or files under watch, re-running after each edit only the passes
+// whose inputs changed. For each file it caches the sections of the spec
+// last checked, its symbol table and the findings of every pass; a pass is
+// run again when a section of the spec it reads differs from the cached
+// version, and its cached findings are reported otherwise. Reports are the same as those of
+// CheckSource.
+//
+// A Session is not safe for concurrent use.
+type Session struct {
+	c     *Checker
+	opts  CheckOptions
+	files map[string]*sessionFile
+}
+
+// sessionFile is what a Session caches for one file.
+type sessionFile struct {
+	opts     CheckOptions // with the discovered configuration, if any
+	sections map[string]json.RawMessage
+	st       *semantic.SymbolTable       // of the spec last checked
+	findings map[string][]report.Finding // by pass name
+}
+
+// NewSession returns a Session checking specs with opts. With
+// DiscoverConfig, the configuration of each file is looked up the first time
+// it is checked.
+func (c *Checker) NewSession(opts CheckOptions) *Session {
+	return &Session{c: c, opts: opts, files: make(map[string]*sessionFile)}
+}
+
+// Check validates the content of the file at path, as CheckSource does,
+// reusing what the session cached when it last checked path.
+func (s *Session) Check(path string, data []byte) *report.Report {
+	opts := s.opts
+	prev := s.files[path]
+	if prev != nil {
+		opts = prev.opts
+	}
+	fc, spec := s.c.loadSource(path, data, opts)
+	if spec == nil {
+		delete(s.files, path)
+		return fc.finish()
+	}
+
+	// A spec that loaded is a JSON object. Were it not, every section would
+	// count as changed.
+	var sections map[string]json.RawMessage
+	_ = json.Unmarshal(data, &sections)
+
+	var cached map[string][]report.Finding
+	var st *semantic.SymbolTable
+	if prev != nil {
+		changed := changedSections(prev.sections, sections)
+		cached = make(map[string][]report.Finding, len(prev.findings))
+		for name, findings := range prev.findings {
+			if !passReadsAny(name, changed) {
+				cached[name] = findings
+			}
+		}
+		if !slices.ContainsFunc(changed, func(k string) bool { return !slices.Contains(unindexedSections, k) }) {
+			st = prev.st
+		}
+	}
+	if st == nil {
+		start := time.Now()
+		st = symbolTable(spec, fc.opts)
+		fc.timed("symbols", start)
+	}
+
+	findings := s.c.runPassesWith(fc, spec, st, cached)
+	s.files[path] = &sessionFile{opts: fc.opts, sections: sections, st: st, findings: findings}
+	return fc.finish()
+}
+
+// Forget drops what the session cached for path, such as when an editor
+// closes the file.
+func (s *Session) Forget(path string) {
+	delete(s.files, path)
+}
+
+// changedSections returns the top-level sections that differ between two
+// versions of a spec, including those present in only one.
+func changedSections(old, cur map[string]json.RawMessage) []string {
+	var changed []string
+	for k, v := range cur {
+		if !bytes.Equal(old[k], v) {
+			changed = append(changed, k)
+		}
+	}
+	for k := range old {
+		if _, ok := cur[k]; !ok {
+			changed = append(changed, k)
+		}
+	}
+	return changed
+}
+
+// passReadsAny reports whether the pass named name reads any of sections,
+// including through the sections it is listed as reading.
+func passReadsAny(name string, sections []string) bool {
+	reads, known := passSections[name]
+	var implied []string
+	for _, k := range reads {
+		implied = append(implied, impliedSections[k]...)
+	}
+	for _, k := range sections {
+		if !known || !slices.Contains(entrySections, k) || slices.Contains(reads, k) || slices.Contains(implied, k) {
+			return true
+		}
+	}
+	return false
+}
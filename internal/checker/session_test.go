@@ -0,0 +1,414 @@
+package checker
+
+import (
+	"encoding/json"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/report"
+	"github.com/foundry-zero/allium/internal/semantic"
+)
+
+// specSections reads the spec at path as its top-level sections.
+func specSections(t *testing.T, path string) map[string]json.RawMessage {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sections map[string]json.RawMessage
+	if err := json.Unmarshal(data, &sections); err != nil {
+		t.Fatal(err)
+	}
+	return sections
+}
+
+func marshalSections(t *testing.T, sections map[string]json.RawMessage) []byte {
+	t.Helper()
+	data, err := json.MarshalIndent(sections, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// TestSessionMatchesCheckSource edits the reference example one entry
+// section at a time, emptying it or taking it from a broken example, and
+// checks that the session reports what a full check of the edited spec does.
+func TestSessionMatchesCheckSource(t *testing.T) {
+	c, err := NewChecker()
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+	ref := specSections(t, refExample)
+	donors, err := filepath.Glob(filepath.Join("..", "..", "schemas", "v1", "examples", "broken", "*.allium.json"))
+	if err != nil || len(donors) == 0 {
+		t.Fatalf("no broken examples: %v", err)
+	}
+
+	for _, section := range entrySections {
+		replacements := map[string]json.RawMessage{"empty": json.RawMessage(`[]`)}
+		for _, donor := range donors {
+			if v, ok := specSections(t, donor)[section]; ok {
+				replacements[filepath.Base(donor)] = v
+			}
+		}
+		for name, v := range replacements {
+			t.Run(section+"/"+name, func(t *testing.T) {
+				edited := make(map[string]json.RawMessage, len(ref))
+				for k, rv := range ref {
+					edited[k] = rv
+				}
+				edited[section] = v
+				data := marshalSections(t, edited)
+
+				s := c.NewSession(CheckOptions{})
+				s.Check("spec.allium.json", marshalSections(t, ref))
+				got := s.Check("spec.allium.json", data)
+				want := c.CheckSource("spec.allium.json", data, CheckOptions{})
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("session report differs from a full check\ngot:  %+v\nwant: %+v", got, want)
+				}
+			})
+		}
+	}
+}
+
+func TestSessionSkipsUnaffectedPasses(t *testing.T) {
+	c, err := NewChecker()
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+	// Passes run concurrently, so the record of which ran is guarded.
+	var mu sync.Mutex
+	var ran []string
+	for i, p := range c.passes {
+		c.passes[i].Fn = func(spec *ast.Spec, st *semantic.SymbolTable) []report.Finding {
+			mu.Lock()
+			ran = append(ran, p.Name)
+			mu.Unlock()
+			return p.Fn(spec, st)
+		}
+	}
+	ref := specSections(t, refExample)
+	s := c.NewSession(CheckOptions{})
+	s.Check("spec.allium.json", marshalSections(t, ref))
+	if len(ran) != len(c.passes) {
+		t.Fatalf("first check ran %v, want every pass", ran)
+	}
+
+	ran = nil
+	ref["surfaces"] = json.RawMessage(`[]`)
+	r := s.Check("spec.allium.json", marshalSections(t, ref))
+	for _, skipped := range []string{"statemachines", "sumtypes", "retention", "triggers", "creations", "statechanges"} {
+		if slices.Contains(ran, skipped) {
+			t.Errorf("editing surfaces re-ran %s", skipped)
+		}
+	}
+	for _, rerun := range []string{"surfaces", "actors", "warnings"} {
+		if !slices.Contains(ran, rerun) {
+			t.Errorf("editing surfaces did not re-run %s (ran %v)", rerun, ran)
+		}
+	}
+	if len(r.Warnings) == 0 {
+		t.Error("expected the cached warnings of skipped passes to be reported")
+	}
+
+	ran = nil
+	s.Check("spec.allium.json", marshalSections(t, ref))
+	if len(ran) != 0 {
+		t.Errorf("an unchanged spec re-ran %v", ran)
+	}
+
+	s.Forget("spec.allium.json")
+	s.Check("spec.allium.json", marshalSections(t, ref))
+	if len(ran) != len(c.passes) {
+		t.Errorf("after Forget, ran %v, want every pass", ran)
+	}
+}
+
+func TestSessionSchemaInvalidEdit(t *testing.T) {
+	c, err := NewChecker()
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+	ref := specSections(t, refExample)
+	s := c.NewSession(CheckOptions{})
+	s.Check("spec.allium.json", marshalSections(t, ref))
+
+	if r := s.Check("spec.allium.json", []byte(`{"version": "1", "entities": [`)); r.SchemaValid || !r.HasErrors() {
+		t.Errorf("expected an invalid edit to be reported, got %+v", r)
+	}
+	data := marshalSections(t, ref)
+	if got, want := s.Check("spec.allium.json", data), c.CheckSource("spec.allium.json", data, CheckOptions{}); !reflect.DeepEqual(got, want) {
+		t.Errorf("after an invalid edit, got %+v, want %+v", got, want)
+	}
+}
+
+func TestPassSectionsNameRegisteredPasses(t *testing.T) {
+	c, err := NewChecker()
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+	for name, sections := range passSections {
+		if !slices.ContainsFunc(c.passes, func(p passEntry) bool { return p.Name == name }) {
+			t.Errorf("passSections lists %s, which is not a registered pass", name)
+		}
+		for _, k := range sections {
+			if !slices.Contains(entrySections, k) {
+				t.Errorf("pass %s reads %s, which every pass reads", name, k)
+			}
+		}
+	}
+	for section, implied := range impliedSections {
+		for _, k := range append([]string{section}, implied...) {
+			if !slices.Contains(entrySections, k) {
+				t.Errorf("impliedSections lists %s, which every pass reads", k)
+			}
+		}
+	}
+}
+
+// addGuest adds to a spec the Guest entity, whose email, unlike a User's,
+// is optional.
+func addGuest(t *testing.T, sections map[string]json.RawMessage) {
+	t.Helper()
+	var entities []json.RawMessage
+	if err := json.Unmarshal(sections["entities"], &entities); err != nil {
+		t.Fatal(err)
+	}
+	entities = append(entities, json.RawMessage(`{"name": "Guest", "fields": [
+		{"name": "email", "type": {"kind": "optional", "inner": {"kind": "primitive", "value": "String"}}}
+	], "relationships": [], "projections": [], "derived_values": []}`))
+	sections["entities"], _ = json.Marshal(entities)
+}
+
+// compareVisitorEmailWithNull makes the PasswordReset surface of the
+// reference example expose whether the visitor's email is null.
+func compareVisitorEmailWithNull(t *testing.T, sections map[string]json.RawMessage) {
+	t.Helper()
+	var surfaces []map[string]any
+	if err := json.Unmarshal(sections["surfaces"], &surfaces); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range surfaces {
+		if s["name"] == "PasswordReset" {
+			var compared any
+			_ = json.Unmarshal([]byte(`{"expression": {"kind": "comparison", "operator": "!=",
+				"left": {"kind": "field_access", "object": {"kind": "field_access", "object": null, "field": "visitor"}, "field": "email"},
+				"right": {"kind": "literal", "type": "null", "value": null}}}`), &compared)
+			s["exposes"] = append(s["exposes"].([]any), compared)
+		}
+	}
+	sections["surfaces"], _ = json.Marshal(surfaces)
+}
+
+// markUserEmailSensitive marks a User's email in the metadata of a spec as
+// visible only to AuthenticatedUser.
+func markUserEmailSensitive(t *testing.T, sections map[string]json.RawMessage) {
+	t.Helper()
+	var metadata map[string]any
+	if err := json.Unmarshal(sections["metadata"], &metadata); err != nil {
+		t.Fatal(err)
+	}
+	metadata["sensitive_fields"] = map[string]any{"User": map[string]any{"email": []string{"AuthenticatedUser"}}}
+	sections["metadata"], _ = json.Marshal(metadata)
+}
+
+// repointActor checks the reference example, with the Guest entity added and
+// edit applied, in a session, then points the Visitor actor at Guest,
+// editing only the actors section. It checks that the first report has a
+// finding of rule, that the second does not, and that the session reports
+// what a full check of the edited spec does.
+func repointActor(t *testing.T, rule string, edit func(*testing.T, map[string]json.RawMessage)) {
+	t.Helper()
+	c, err := NewChecker()
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+	sections := specSections(t, refExample)
+	addGuest(t, sections)
+	edit(t, sections)
+
+	hasRule := func(r *report.Report) bool {
+		return slices.ContainsFunc(append(r.Errors, r.Warnings...), func(f report.Finding) bool { return f.Rule == rule })
+	}
+	s := c.NewSession(CheckOptions{})
+	if r := s.Check("spec.allium.json", marshalSections(t, sections)); !hasRule(r) {
+		t.Fatalf("expected %s before the actor edit, got %+v", rule, r)
+	}
+
+	var actors []map[string]any
+	if err := json.Unmarshal(sections["actors"], &actors); err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range actors {
+		if a["name"] == "Visitor" {
+			a["identified_by"] = map[string]any{"entity": "Guest", "condition": map[string]any{"kind": "literal", "type": "boolean", "value": true}}
+		}
+	}
+	sections["actors"], _ = json.Marshal(actors)
+	data := marshalSections(t, sections)
+	got := s.Check("spec.allium.json", data)
+	want := c.CheckSource("spec.allium.json", data, CheckOptions{})
+	if !want.SchemaValid || hasRule(want) {
+		t.Fatalf("expected no %s once Visitor is a Guest, got %+v", rule, want)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("session report differs from a full check\ngot:  %+v\nwant: %+v", got, want)
+	}
+}
+
+// TestSessionActorEditNullability compares the Visitor's email with null: a
+// User's email is not optional (RULE-56), a Guest's is.
+func TestSessionActorEditNullability(t *testing.T) {
+	repointActor(t, "RULE-56", compareVisitorEmailWithNull)
+}
+
+// TestSessionActorEditSensitive marks a User's email as visible only to
+// AuthenticatedUser: exposing it to a Visitor is a leak (RULE-62), exposing
+// a Guest's email is not.
+func TestSessionActorEditSensitive(t *testing.T) {
+	repointActor(t, "RULE-62", markUserEmailSensitive)
+}
+
+// TestPassSectionsCoverWhatPassesRead checks passSections against what the
+// passes do read. For every example it edits each entry section in turn,
+// emptying it, taking it from another example, dropping each element and
+// replacing each string in it with another the spec holds under the same
+// key, and checks that every pass the session would not re-run reports on a
+// fresh check of the edited spec what it reported on the example.
+func TestPassSectionsCoverWhatPassesRead(t *testing.T) {
+	c, err := NewChecker()
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+	broken, err := filepath.Glob(filepath.Join("..", "..", "schemas", "v1", "examples", "broken", "*.allium.json"))
+	if err != nil || len(broken) == 0 {
+		t.Fatalf("no broken examples: %v", err)
+	}
+	examples := append([]string{refExample}, broken...)
+
+	const path = "spec.allium.json"
+	for _, example := range examples {
+		base := specSections(t, example)
+		if example == refExample {
+			// Give a Visitor an entity to stand for other than a User, and
+			// passes reading surfaces something to report on the Visitor.
+			addGuest(t, base)
+			compareVisitorEmailWithNull(t, base)
+			markUserEmailSensitive(t, base)
+		}
+		baseData := marshalSections(t, base)
+		s := c.NewSession(CheckOptions{})
+		if s.Check(path, baseData); s.files[path] == nil {
+			continue
+		}
+		// What the session reports for a pass it does not re-run.
+		cached := s.files[path].findings
+		var whole any
+		_ = json.Unmarshal(baseData, &whole)
+		values := make(map[string][]string)
+		stringValues(whole, "", values)
+
+		for _, section := range entrySections {
+			edits := []json.RawMessage{json.RawMessage(`[]`)}
+			for _, donor := range examples {
+				if v, ok := specSections(t, donor)[section]; ok && donor != example {
+					edits = append(edits, v)
+				}
+			}
+			var elems []any
+			_ = json.Unmarshal(base[section], &elems)
+			for i := range elems {
+				edits = append(edits, mustMarshal(t, slices.Delete(slices.Clone(elems), i, i+1)))
+			}
+			for _, v := range stringEdits(elems, section, values) {
+				edits = append(edits, mustMarshal(t, v))
+			}
+
+			for _, edit := range edits {
+				edited := maps.Clone(base)
+				edited[section] = edit
+				fresh := c.NewSession(CheckOptions{})
+				if fresh.Check(path, marshalSections(t, edited)); fresh.files[path] == nil {
+					continue
+				}
+				for _, p := range c.passes {
+					if passReadsAny(p.Name, []string{section}) {
+						continue
+					}
+					if got, want := cached[p.Name], fresh.files[path].findings[p.Name]; !reflect.DeepEqual(got, want) {
+						t.Errorf("%s: pass %s reads %s, which passSections does not list: after %s\ngot:  %+v\nwant: %+v",
+							filepath.Base(example), p.Name, section, edit, got, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+func mustMarshal(t *testing.T, v any) json.RawMessage {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// stringValues records, by key, the distinct strings v holds, sorted. The
+// strings of an array are recorded under the array's key.
+func stringValues(v any, key string, values map[string][]string) {
+	switch v := v.(type) {
+	case string:
+		if i, found := slices.BinarySearch(values[key], v); !found {
+			values[key] = slices.Insert(values[key], i, v)
+		}
+	case []any:
+		for _, e := range v {
+			stringValues(e, key, values)
+		}
+	case map[string]any:
+		for k, e := range v {
+			stringValues(e, k, values)
+		}
+	}
+}
+
+// stringEdits returns copies of v, each with one of its strings replaced by
+// the next, in sorted order, of the strings values holds under its key.
+// Kinds are left alone: a node of another kind is one the schema rejects.
+func stringEdits(v any, key string, values map[string][]string) []any {
+	var out []any
+	switch v := v.(type) {
+	case string:
+		if alts := values[key]; len(alts) > 1 && key != "kind" {
+			i, _ := slices.BinarySearch(alts, v)
+			out = append(out, alts[(i+1)%len(alts)])
+		}
+	case []any:
+		for i, e := range v {
+			for _, alt := range stringEdits(e, key, values) {
+				c := slices.Clone(v)
+				c[i] = alt
+				out = append(out, c)
+			}
+		}
+	case map[string]any:
+		for _, k := range slices.Sorted(maps.Keys(v)) {
+			for _, alt := range stringEdits(v[k], k, values) {
+				c := maps.Clone(v)
+				c[k] = alt
+				out = append(out, c)
+			}
+		}
+	}
+	return out
+}
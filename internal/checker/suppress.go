@@ -0,0 +1,53 @@
+package checker
+
+import (
+	"fmt"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/report"
+)
+
+// suppressionSet applies a spec's suppressions to its findings and tracks
+// which suppressions matched at least one finding.
+type suppressionSet struct {
+	entries []ast.Suppression
+	used    []bool
+}
+
+func newSuppressionSet(entries []ast.Suppression) *suppressionSet {
+	return &suppressionSet{entries: entries, used: make([]bool, len(entries))}
+}
+
+// match reports whether f is silenced by any suppression, marking every
+// suppression that covers it as used.
+func (s *suppressionSet) match(f report.Finding) bool {
+	matched := false
+	for i, sup := range s.entries {
+		if sup.Rule == f.Rule && pathMatchesFilter(f.Location.Path, sup.Path) {
+			s.used[i] = true
+			matched = true
+		}
+	}
+	return matched
+}
+
+// unused returns a WARN-21 finding for each suppression that matched nothing,
+// so that stale suppressions are removed once the underlying issue is fixed.
+func (s *suppressionSet) unused(file string) []report.Finding {
+	var findings []report.Finding
+	for i, sup := range s.entries {
+		if s.used[i] {
+			continue
+		}
+		scope := ""
+		if sup.Path != "" {
+			scope = " within " + sup.Path
+		}
+		findings = append(findings, report.NewWarning(
+			"WARN-21",
+			fmt.Sprintf("Suppression of %s%s matches no finding", sup.Rule, scope),
+			report.Location{File: file, Path: fmt.Sprintf("$.suppressions[%d]", i)},
+		))
+	}
+	return findings
+}
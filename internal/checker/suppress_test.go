@@ -0,0 +1,330 @@
+package checker
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/foundry-zero/allium/internal/config"
+	"github.com/foundry-zero/allium/internal/report"
+)
+
+// writeSuppressedExample writes the reference example with the given
+// suppressions added and returns its path.
+func writeSuppressedExample(t *testing.T, suppressions []map[string]string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "..", "schemas", "v1", "examples", "password-auth.allium.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	doc["suppressions"] = suppressions
+	data, err = json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := writeWorkspace(t, map[string]string{"auth.allium.json": string(data)})
+	return filepath.Join(dir, "auth.allium.json")
+}
+
+func TestCheckSuppressions(t *testing.T) {
+	c, err := NewChecker()
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+
+	// The reference example raises WARN-16 at $.rules[4].trigger, WARN-20
+	// at $.rules[3].ensures[0].name, WARN-22 at $.rules[2].ensures[1] and
+	// WARN-31 at $.given[0].
+	path := writeSuppressedExample(t, []map[string]string{
+		{"rule": "WARN-16", "reason": "locked_until is always set when locked"},
+		{"rule": "WARN-20", "path": "$.rules[3]"},
+		{"rule": "WARN-22", "path": "$.rules[2]", "reason": "the limit is checked after counting this attempt"},
+		{"rule": "WARN-31", "path": "$.given[0]"},
+	})
+
+	r := c.Check(path, CheckOptions{})
+	if r.HasWarnings() || r.HasErrors() {
+		t.Errorf("expected all findings suppressed, got %v %v", r.Errors, r.Warnings)
+	}
+	if r.Summary.SuppressedCount != 4 || len(r.Suppressed) != 4 {
+		t.Errorf("expected 4 suppressed findings, got %d (%v)", r.Summary.SuppressedCount, r.Suppressed)
+	}
+	for _, f := range r.Suppressed {
+		if f.Location.Line == 0 {
+			t.Errorf("suppressed finding %s should keep its location", f.Rule)
+		}
+	}
+}
+
+func TestCheckSuppressionScope(t *testing.T) {
+	c, err := NewChecker()
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+
+	// A path outside the finding's subtree does not suppress it, and is
+	// reported as unused.
+	path := writeSuppressedExample(t, []map[string]string{
+		{"rule": "WARN-20", "path": "$.rules[1]"},
+	})
+
+	r := c.Check(path, CheckOptions{})
+	var warn20, warn21 int
+	for _, w := range r.Warnings {
+		switch w.Rule {
+		case "WARN-20":
+			warn20++
+		case "WARN-21":
+			warn21++
+			if w.Location.Path != "$.suppressions[0]" {
+				t.Errorf("WARN-21 at %q, want $.suppressions[0]", w.Location.Path)
+			}
+		}
+	}
+	if warn20 != 1 || warn21 != 1 {
+		t.Errorf("expected WARN-20 and WARN-21 once each, got %v", r.Warnings)
+	}
+	if r.Summary.SuppressedCount != 0 {
+		t.Errorf("expected nothing suppressed, got %d", r.Summary.SuppressedCount)
+	}
+}
+
+func TestCheckUnusedSuppressionNeedsAllPasses(t *testing.T) {
+	c, err := NewChecker()
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+
+	path := writeSuppressedExample(t, []map[string]string{
+		{"rule": "RULE-08"},
+	})
+
+	for _, opts := range []CheckOptions{{RuleFilter: []int{1}}, {SchemaOnly: true}} {
+		r := c.Check(path, opts)
+		for _, w := range r.Warnings {
+			if w.Rule == "WARN-21" {
+				t.Errorf("WARN-21 should not be reported for a partial run (%+v)", opts)
+			}
+		}
+	}
+
+	r := c.Check(path, CheckOptions{})
+	found := false
+	for _, w := range r.Warnings {
+		if w.Rule == "WARN-21" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected WARN-21 for the unused RULE-08 suppression, got %v", r.Warnings)
+	}
+}
+
+func TestCheckCriticalEscalation(t *testing.T) {
+	c, err := NewChecker()
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+	path := writeSuppressedExample(t, []map[string]string{
+		{"rule": "WARN-16", "reason": "locked_until is always set when locked"},
+	})
+	cfgPath := filepath.Join(filepath.Dir(path), ".alliumcheck.json")
+	if err := os.WriteFile(cfgPath, []byte(`{"critical": ["auth.allium.json"]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(cfgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := c.Check(path, CheckOptions{Config: cfg})
+	if r.HasWarnings() {
+		t.Errorf("expected every warning escalated, got %v", r.Warnings)
+	}
+	if len(r.Errors) != 3 {
+		t.Fatalf("expected WARN-20, WARN-22 and WARN-31 as errors, got %v", r.Errors)
+	}
+	for _, e := range r.Errors {
+		if e.Severity != report.SeverityError {
+			t.Errorf("%s has severity %s", e.Rule, e.Severity)
+		}
+	}
+	// Suppressed findings stay suppressed.
+	if len(r.Suppressed) != 1 || r.Suppressed[0].Severity != report.SeverityWarning {
+		t.Errorf("expected WARN-16 suppressed as a warning, got %v", r.Suppressed)
+	}
+}
+
+func TestCheckSeverityOverrides(t *testing.T) {
+	c, err := NewChecker()
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+	path := writeSuppressedExample(t, []map[string]string{
+		{"rule": "WARN-22", "reason": "the limit is checked after counting this attempt"},
+	})
+	cfgPath := filepath.Join(filepath.Dir(path), ".alliumcheck.json")
+	if err := os.WriteFile(cfgPath, []byte(`{"severity": {"WARN-16": "error", "WARN-20": "off", "WARN-22": "off", "WARN-31": "off"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(cfgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := c.Check(path, CheckOptions{Config: cfg})
+	if len(r.Errors) != 1 || r.Errors[0].Rule != "WARN-16" || r.Errors[0].Severity != report.SeverityError {
+		t.Errorf("expected WARN-16 raised to an error, got %v", r.Errors)
+	}
+	// Rules turned off are not reported, even as suppressed, and their
+	// suppressions are not stale.
+	if r.HasWarnings() || len(r.Suppressed) != 0 {
+		t.Errorf("expected WARN-20, WARN-22 and WARN-31 turned off, got %v %v", r.Warnings, r.Suppressed)
+	}
+}
+
+func TestCheckSeverityOverridesToInfo(t *testing.T) {
+	c, err := NewChecker()
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+	cfg := &config.Config{Severity: map[string]string{"WARN-16": "info", "WARN-20": "hint", "WARN-22": "info", "WARN-31": "info"}}
+	r := c.Check(refExample, CheckOptions{Config: cfg})
+	if r.HasWarnings() || r.Summary.InfoCount != 3 || r.Summary.HintCount != 1 {
+		t.Errorf("expected the reference warnings lowered to info and hints, got %+v", r.Summary)
+	}
+	if len(r.Hints) != 1 || r.Hints[0].Rule != "WARN-20" || r.Hints[0].Severity != report.SeverityHint {
+		t.Errorf("expected WARN-20 as a hint, got %v", r.Hints)
+	}
+}
+
+func TestCheckDiscoverConfig(t *testing.T) {
+	c, err := NewChecker()
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+	data, err := os.ReadFile(refExample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := writeWorkspace(t, map[string]string{
+		"specs/auth/auth.allium.json": string(data),
+		".alliumcheck.json":           `{"severity": {"WARN-16": "error"}}`,
+	})
+	path := filepath.Join(dir, "specs", "auth", "auth.allium.json")
+
+	if r := c.Check(path, CheckOptions{}); len(r.Errors) != 0 {
+		t.Errorf("expected no discovery by default, got %v", r.Errors)
+	}
+	r := c.Check(path, CheckOptions{DiscoverConfig: true})
+	if len(r.Errors) != 1 || r.Errors[0].Rule != "WARN-16" {
+		t.Errorf("expected WARN-16 raised by the discovered config, got %v", r.Errors)
+	}
+
+	// An explicit configuration takes precedence over discovery.
+	r = c.Check(path, CheckOptions{Config: &config.Config{}, DiscoverConfig: true})
+	if len(r.Errors) != 0 {
+		t.Errorf("expected the explicit config to be used, got %v", r.Errors)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, ".alliumcheck.json"), []byte(`{"severity": {"WARN-16": "fatal"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	r = c.Check(path, CheckOptions{DiscoverConfig: true})
+	if len(r.Errors) != 1 || r.Errors[0].Rule != "INPUT" {
+		t.Errorf("expected an INPUT error for the invalid config, got %v", r.Errors)
+	}
+}
+
+func TestCheckConfiguredTerminalStates(t *testing.T) {
+	c, err := NewChecker()
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+	dir := writeWorkspace(t, map[string]string{
+		"tasks.allium.json": `{"version": "1", "file": "tasks.allium",
+  "entities": [{"name": "Task", "fields": [{"name": "status", "type": {"kind": "inline_enum", "values": ["open", "blocked"]}}]}],
+  "rules": [
+    {"name": "CreateTask", "trigger": {"kind": "external_stimulus", "name": "TaskCreated", "parameters": []},
+     "ensures": [{"kind": "entity_creation", "entity": "Task", "fields": {"status": {"kind": "literal", "type": "enum_value", "value": "open"}}}]},
+    {"name": "BlockTask", "trigger": {"kind": "external_stimulus", "name": "TaskBlocked", "parameters": [{"name": "task"}]},
+     "ensures": [{"kind": "state_change", "target": {"kind": "field_access", "object": {"kind": "field_access", "object": null, "field": "task"}, "field": "status"}, "value": {"kind": "literal", "type": "enum_value", "value": "blocked"}}]}
+  ]}`,
+		".alliumcheck.json": `{"terminal_states": {"Task": {"status": ["blocked"]}}}`,
+	})
+	path := filepath.Join(dir, "tasks.allium.json")
+
+	r := c.Check(path, CheckOptions{})
+	if len(r.Errors) != 1 || r.Errors[0].Rule != "RULE-08" {
+		t.Fatalf("expected RULE-08 for dead-end 'blocked', got %v", r.Errors)
+	}
+
+	cfg, err := config.Load(filepath.Join(dir, ".alliumcheck.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r = c.Check(path, CheckOptions{Config: cfg})
+	if r.HasErrors() || r.HasWarnings() {
+		t.Errorf("expected no findings with 'blocked' configured terminal, got %v %v", r.Errors, r.Warnings)
+	}
+}
+
+func TestCheckConfiguredSensitiveFields(t *testing.T) {
+	c, err := NewChecker()
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+	path := writeSuppressedExample(t, []map[string]string{})
+	cfgPath := filepath.Join(filepath.Dir(path), ".alliumcheck.json")
+	if err := os.WriteFile(cfgPath, []byte(`{"sensitive_fields": {"User": {"password_hash": [], "email": ["AuthenticatedUser"]}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(cfgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := c.Check(path, CheckOptions{Config: cfg, RuleIDs: []string{"RULE-62"}})
+	want := []string{
+		"$.surfaces[1].exposes[0]: Surface 'PasswordReset' exposes sensitive field 'User.email' to 'Visitor' via visitor.email",
+		"$.surfaces[2].exposes[1]: Surface 'AccountManagement' exposes sensitive field 'User.password_hash' to 'AuthenticatedUser' via user.active_sessions.user.password_hash",
+	}
+	if got := ruleFindings(r, "RULE-62"); !slices.Equal(got, want) {
+		t.Errorf("RULE-62 findings = %q, want %q", got, want)
+	}
+}
+
+func TestCheckConfiguredNaming(t *testing.T) {
+	c, err := NewChecker()
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+	path := writeSuppressedExample(t, []map[string]string{})
+	cfgPath := filepath.Join(filepath.Dir(path), ".alliumcheck.json")
+	if err := os.WriteFile(cfgPath, []byte(`{"naming": {"fields": "snake_case", "triggers": "verb_noun"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(cfgPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r := c.Check(path, CheckOptions{RuleIDs: []string{"WARN-28"}}); r.HasWarnings() {
+		t.Errorf("expected no naming conventions without a config, got %v", r.Warnings)
+	}
+	r := c.Check(path, CheckOptions{Config: cfg, RuleIDs: []string{"WARN-28"}})
+	var names []string
+	for _, f := range r.Warnings {
+		names = append(names, f.Message)
+	}
+	// The example's triggers name their subject first, as in UserLogsIn.
+	if len(r.Warnings) == 0 || !strings.Contains(names[0], "Trigger 'UserRegisters' of rule") {
+		t.Errorf("expected subject-first triggers reported, got %v", names)
+	}
+}
@@ -0,0 +1,68 @@
+package checker
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/report"
+	"github.com/foundry-zero/allium/internal/template"
+)
+
+// templateRules lists the rule numbers covered by the comparison with a spec
+// template.
+var templateRules = []int{43}
+
+// templateFindings reports each way spec departs from the template t as a
+// RULE-43 error: a required section that is empty, a populated section the
+// template does not allow, a required name no declaration matches, and a
+// declared name without one of its section's prefixes.
+func templateFindings(t *template.Template, spec *ast.Spec) []report.Finding {
+	var findings []report.Finding
+	add := func(at, format string, args ...any) {
+		findings = append(findings, report.NewError("RULE-43", fmt.Sprintf(format, args...),
+			report.Location{File: spec.File, Path: at}))
+	}
+
+	for _, s := range template.Sections {
+		populated := s.Len(spec) > 0
+		switch {
+		case slices.Contains(t.RequiredSections, s.Key):
+			if !populated {
+				add("$", "Spec has no '%s' section, which the template requires", s.Key)
+			}
+		case populated && len(t.AllowedSections) > 0 && !slices.Contains(t.AllowedSections, s.Key):
+			add("$."+s.Key, "Section '%s' is not part of the template", s.Key)
+		}
+	}
+
+	for _, s := range template.Sections {
+		if !s.Named() {
+			continue
+		}
+		names := s.Names(spec)
+		for _, pattern := range t.Required[s.Key] {
+			if !slices.ContainsFunc(names, func(name string) bool {
+				ok, _ := path.Match(pattern, name)
+				return ok
+			}) {
+				at := "$." + s.Key
+				if len(names) == 0 {
+					at = "$"
+				}
+				add(at, "No declaration in '%s' matches '%s', which the template requires", s.Key, pattern)
+			}
+		}
+		if prefixes := t.Prefixes[s.Key]; len(prefixes) > 0 {
+			for i, name := range names {
+				if !slices.ContainsFunc(prefixes, func(p string) bool { return strings.HasPrefix(name, p) }) {
+					add(fmt.Sprintf("$.%s[%d]", s.Key, i), "Name '%s' in '%s' does not start with a template prefix (%s)",
+						name, s.Key, strings.Join(prefixes, ", "))
+				}
+			}
+		}
+	}
+	return findings
+}
@@ -0,0 +1,53 @@
+package checker
+
+import (
+	"testing"
+
+	"github.com/foundry-zero/allium/internal/template"
+)
+
+func TestCheckTemplate(t *testing.T) {
+	c, err := NewChecker()
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+
+	r := c.Check(refExample, CheckOptions{Template: &template.Template{
+		RequiredSections: []string{"entities", "rules"},
+		Required:         map[string][]string{"actors": {"Authenticated*"}, "rules": {"Login*"}},
+	}})
+	if len(r.Errors) != 0 {
+		t.Errorf("expected the reference example to follow the template, got %v", r.Errors)
+	}
+
+	tmpl := &template.Template{
+		RequiredSections: []string{"entities", "type_aliases"},
+		AllowedSections:  []string{"rules", "surfaces", "actors"},
+		Required:         map[string][]string{"actors": {"*Admin"}},
+		Prefixes:         map[string][]string{"surfaces": {"Account", "Authentication"}},
+	}
+	r = c.Check(refExample, CheckOptions{Template: tmpl, RuleIDs: []string{"RULE-43"}})
+	got := make(map[string]string)
+	for _, f := range r.Errors {
+		got[f.Location.Path] = f.Message
+	}
+	for path, want := range map[string]string{
+		"$":              "Spec has no 'type_aliases' section, which the template requires",
+		"$.defaults":     "Section 'defaults' is not part of the template",
+		"$.actors":       "No declaration in 'actors' matches '*Admin', which the template requires",
+		"$.surfaces[1]":  "Name 'PasswordReset' in 'surfaces' does not start with a template prefix (Account, Authentication)",
+		"$.enumerations": "Section 'enumerations' is not part of the template",
+	} {
+		if got[path] != want {
+			t.Errorf("at %s: got %q, want %q", path, got[path], want)
+		}
+	}
+	if _, ok := got["$.rules"]; ok {
+		t.Error("allowed section reported as extra")
+	}
+
+	r = c.Check(refExample, CheckOptions{Template: tmpl, RuleIDs: []string{"WARN-16"}})
+	if len(r.Errors) != 0 {
+		t.Errorf("template compared although RULE-43 was not selected: %v", r.Errors)
+	}
+}
@@ -0,0 +1,122 @@
+package checker
+
+import (
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/foundry-zero/allium/internal/report"
+	"github.com/foundry-zero/allium/internal/semantic"
+)
+
+// workspaceRules lists the rule numbers covered by the cross-spec pass,
+// which reports coordinates a bundle manifest does not list as RULE-59.
+var workspaceRules = []int{35, 59}
+
+// layeringRules lists the rule numbers covered by the layering check.
+var layeringRules = []int{39}
+
+// CheckWorkspace validates a set of spec files as one workspace. Each file is
+// checked individually as with Check; files that load successfully are then
+// indexed together so that use_declaration coordinates can be resolved
+// against the other members (RULE-35), or fetched with opts.Resolver when
+// they name no member. Reports are returned in input order.
+//
+// When the project configuration declares layering rules, imports between
+// layers it forbids are reported as RULE-39 errors. With DiscoverConfig, the
+// layering rules are those of the first input file that has a configuration.
+func (c *Checker) CheckWorkspace(paths []string, opts CheckOptions) []*report.Report {
+	reports, _ := c.CheckWorkspaceGraph(paths, opts)
+	return reports
+}
+
+// CheckWorkspaceGraph is CheckWorkspace that also returns the workspace's
+// import graph.
+func (c *Checker) CheckWorkspaceGraph(paths []string, opts CheckOptions) ([]*report.Report, *ImportGraph) {
+	checks := make([]*fileCheck, len(paths))
+	for i, path := range paths {
+		checks[i] = c.check(path, opts)
+	}
+	ws := semantic.NewWorkspace()
+	members := addMembers(ws, paths, checks)
+	fetchImports(ws, opts)
+	graph := crossCheck(ws, members, checks, opts)
+	reports := make([]*report.Report, len(paths))
+	for i, fc := range checks {
+		reports[i] = fc.finish()
+	}
+	return reports, graph
+}
+
+// addMembers adds the specs that loaded to ws, returning the member of each
+// check, or nil for those that did not load.
+func addMembers(ws *semantic.Workspace, paths []string, checks []*fileCheck) []*semantic.WorkspaceMember {
+	members := make([]*semantic.WorkspaceMember, len(checks))
+	for i, fc := range checks {
+		if fc.spec != nil {
+			members[i] = ws.Add(paths[i], fc.spec)
+		}
+	}
+	return members
+}
+
+// crossCheck runs the checks across the members of ws, the workspace (RULE-35)
+// and layering (RULE-39) checks, recording their findings on checks, and
+// returns the import graph.
+func crossCheck(ws *semantic.Workspace, members []*semantic.WorkspaceMember, checks []*fileCheck, opts CheckOptions) *ImportGraph {
+	if !opts.SchemaOnly && opts.selectsPass(workspaceRules) {
+		for i, m := range members {
+			if m == nil {
+				continue
+			}
+			start := time.Now()
+			findings := semantic.CheckWorkspaceReferences(ws, m)
+			checks[i].timed("workspace", start)
+			for _, f := range findings {
+				checks[i].add(f)
+			}
+		}
+	}
+
+	cfg := opts.Config
+	for _, fc := range checks {
+		if cfg == nil {
+			cfg = fc.opts.Config
+		}
+	}
+	graph := buildImportGraph(ws, cfg)
+	if !opts.SchemaOnly && opts.selectsPass(layeringRules) {
+		for i, m := range members {
+			if m == nil {
+				continue
+			}
+			start := time.Now()
+			findings := checkLayering(graph, m)
+			checks[i].timed("layering", start)
+			for _, f := range findings {
+				checks[i].add(f)
+			}
+		}
+	}
+	return graph
+}
+
+// DiscoverSpecs walks root and returns the paths of all .allium.json files
+// beneath it in lexical order.
+func DiscoverSpecs(root string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(path, ".allium.json") {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
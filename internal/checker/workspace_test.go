@@ -0,0 +1,115 @@
+package checker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const billingSpec = `{
+  "version": "1",
+  "file": "billing.allium",
+  "entities": [
+    {"name": "Invoice", "fields": [{"name": "total", "type": {"kind": "primitive", "value": "Integer"}}]}
+  ]
+}`
+
+const ordersSpec = `{
+  "version": "1",
+  "file": "orders.allium",
+  "use_declarations": [{"coordinate": "./billing.allium", "alias": "billing"}],
+  "external_entities": [{"name": "Invoice", "fields": []}]
+}`
+
+func writeWorkspace(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestCheckWorkspaceResolvesImports(t *testing.T) {
+	dir := writeWorkspace(t, map[string]string{
+		"billing.allium.json": billingSpec,
+		"orders.allium.json":  ordersSpec,
+	})
+	c, err := NewChecker()
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+
+	paths := []string{filepath.Join(dir, "orders.allium.json"), filepath.Join(dir, "billing.allium.json")}
+	reports := c.CheckWorkspace(paths, CheckOptions{})
+	if len(reports) != 2 {
+		t.Fatalf("expected 2 reports, got %d", len(reports))
+	}
+	if reports[0].File != paths[0] {
+		t.Errorf("reports not in input order: %q", reports[0].File)
+	}
+	for _, r := range reports {
+		for _, e := range r.Errors {
+			if e.Rule == "RULE-35" {
+				t.Errorf("unexpected RULE-35 in %s: %s", r.File, e.Message)
+			}
+		}
+	}
+}
+
+func TestCheckWorkspaceUnresolvedImport(t *testing.T) {
+	dir := writeWorkspace(t, map[string]string{
+		"orders.allium.json": ordersSpec,
+	})
+	c, err := NewChecker()
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+
+	reports := c.CheckWorkspace([]string{filepath.Join(dir, "orders.allium.json")}, CheckOptions{})
+	found := false
+	for _, e := range reports[0].Errors {
+		if e.Rule == "RULE-35" && e.Location.Path == "$.use_declarations[0].coordinate" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected RULE-35 for unresolved coordinate, got %v", reports[0].Errors)
+	}
+
+	// Rule filtering excludes the workspace pass.
+	reports = c.CheckWorkspace([]string{filepath.Join(dir, "orders.allium.json")}, CheckOptions{RuleFilter: []int{1}})
+	for _, e := range reports[0].Errors {
+		if e.Rule == "RULE-35" {
+			t.Errorf("RULE-35 should be filtered out: %s", e.Message)
+		}
+	}
+}
+
+func TestDiscoverSpecs(t *testing.T) {
+	dir := writeWorkspace(t, map[string]string{
+		"billing.allium.json":       billingSpec,
+		"nested/orders.allium.json": ordersSpec,
+		"notes.json":                `{}`,
+	})
+
+	paths, err := DiscoverSpecs(dir)
+	if err != nil {
+		t.Fatalf("DiscoverSpecs: %v", err)
+	}
+	want := []string{filepath.Join(dir, "billing.allium.json"), filepath.Join(dir, "nested", "orders.allium.json")}
+	if len(paths) != len(want) {
+		t.Fatalf("DiscoverSpecs = %v, want %v", paths, want)
+	}
+	for i := range want {
+		if paths[i] != want[i] {
+			t.Errorf("paths[%d] = %q, want %q", i, paths[i], want[i])
+		}
+	}
+}
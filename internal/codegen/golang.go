@@ -0,0 +1,353 @@
+// Package codegen generates code from an Allium specification for the
+// systems that implement it or exchange its data: types mirroring its
+// entities, value types, enumerations and trigger payloads.
+package codegen
+
+import (
+	"fmt"
+	"go/format"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/semantic"
+)
+
+// Go returns gofmt-formatted Go source, in package pkg, declaring:
+//
+//   - a string type with a constant per value for each enumeration, and for
+//     each inline enum field, named after its entity and field, e.g.
+//     SessionStatus;
+//   - a type alias for each type alias;
+//   - a struct for each value type, external entity, entity and variant,
+//     with a field per declared field, JSON tags carrying the spec's field
+//     names and optional fields omitted when empty; a variant embeds the
+//     struct of its base entity;
+//   - a payload struct for each external stimulus and chained trigger, with
+//     a field per parameter of the rules it fires, typed as
+//     semantic.TriggerParameterTypes infers.
+//
+// Entity references are pointers to the referenced struct, sets and lists
+// are slices, Timestamp is time.Time, Duration time.Duration, Integer int64
+// and Decimal float64. Relationships, projections and derived values are
+// computed, so they have no fields. A reference to a type the spec does not
+// declare, or a parameter whose type cannot be inferred, is typed any.
+func Go(spec *ast.Spec, st *semantic.SymbolTable, pkg string) ([]byte, error) {
+	g := &goGen{spec: spec, st: st, taken: make(map[string]bool)}
+	for _, name := range declaredTypes(spec) {
+		g.taken[goName(name)] = true
+	}
+
+	var decls []string
+	for _, a := range spec.TypeAliases {
+		decls = append(decls, fmt.Sprintf("// %s is the %s type alias.\ntype %s = %s\n",
+			goName(a.Name), a.Name, goName(a.Name), g.goType(a.Type, a.Name, "")))
+	}
+	for _, vt := range spec.ValueTypes {
+		decls = append(decls, g.goStruct(vt.Name, "is a value type.", "", vt.Fields))
+	}
+	for _, ee := range spec.ExternalEntities {
+		decls = append(decls, g.goStruct(ee.Name, "is an entity managed outside this specification.", "", ee.Fields))
+	}
+	for _, e := range spec.Entities {
+		decls = append(decls, g.goStruct(e.Name, "is an entity.", "", e.Fields))
+	}
+	for _, v := range spec.Variants {
+		embed := ""
+		if v.BaseEntity != v.Name && slices.Contains(declaredTypes(spec), v.BaseEntity) {
+			embed = goName(v.BaseEntity)
+		}
+		decls = append(decls, g.goStruct(v.Name, "is a variant of "+v.BaseEntity+".", embed, v.Fields))
+	}
+	for _, t := range triggerPayloads(spec, st) {
+		decls = append(decls, g.goPayload(t))
+	}
+
+	// Enumerations come first, inline ones in the order their fields were
+	// reached.
+	var enums []string
+	for _, e := range spec.Enumerations {
+		enums = append(enums, goEnum(goName(e.Name), "is the "+e.Name+" enumeration.", e.Values))
+	}
+	for _, e := range g.inlineEnums {
+		enums = append(enums, goEnum(e.name, "holds the values of "+e.owner+"."+e.field+".", e.values))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Code generated by allium-gen from %s. DO NOT EDIT.\n\n", spec.File)
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+	if g.usesTime {
+		b.WriteString("import \"time\"\n\n")
+	}
+	for _, d := range append(enums, decls...) {
+		b.WriteString(d)
+		b.WriteString("\n")
+	}
+	return format.Source([]byte(b.String()))
+}
+
+// goGen holds the state of one Go generation.
+type goGen struct {
+	spec        *ast.Spec
+	st          *semantic.SymbolTable
+	taken       map[string]bool // Go type names in use
+	inlineEnums []inlineEnum
+	usesTime    bool
+}
+
+// inlineEnum is the type generated for an inline enum field.
+type inlineEnum struct {
+	name, owner, field string
+	values             []string
+}
+
+// goStruct returns the declaration of the struct for an entity-like type,
+// embedding the struct named embed when it is not empty.
+func (g *goGen) goStruct(name, doc, embed string, fields []ast.Field) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s %s\ntype %s struct {\n", goName(name), doc, goName(name))
+	if embed != "" {
+		fmt.Fprintf(&b, "\t%s\n", embed)
+	}
+	for _, f := range fields {
+		fmt.Fprintf(&b, "\t%s %s %s\n", goName(f.Name), g.goType(f.Type, name, f.Name), jsonTag(f.Name, f.Type.Kind == "optional"))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// goPayload returns the declaration of the payload struct of a trigger.
+func (g *goGen) goPayload(t payload) string {
+	name := goName(t.trigger)
+	if g.taken[name] {
+		name += "Payload"
+	}
+	g.taken[name] = true
+
+	var b strings.Builder
+	rules := "rule "
+	if len(t.rules) > 1 {
+		rules = "rules "
+	}
+	fmt.Fprintf(&b, "// %s is the payload of the %s trigger, handled by %s%s.\ntype %s struct {\n",
+		name, t.trigger, rules, strings.Join(t.rules, ", "), name)
+	for _, p := range t.params {
+		typ := "any"
+		if p.typ != nil {
+			typ = g.goType(*p.typ, "", "")
+		}
+		if p.optional && !strings.HasPrefix(typ, "*") && !strings.HasPrefix(typ, "[]") && typ != "any" {
+			typ = "*" + typ
+		}
+		fmt.Fprintf(&b, "\t%s %s %s\n", goName(p.name), typ, jsonTag(p.name, p.optional))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// goType returns the Go type of a field type. An inline enum is given a type
+// named after owner and field; outside a type, where owner is "", it is a
+// string.
+func (g *goGen) goType(ft ast.FieldType, owner, field string) string {
+	switch ft.Kind {
+	case "primitive":
+		switch ft.Value {
+		case "String":
+			return "string"
+		case "Integer":
+			return "int64"
+		case "Decimal":
+			return "float64"
+		case "Boolean":
+			return "bool"
+		case "Timestamp":
+			g.usesTime = true
+			return "time.Time"
+		case "Duration":
+			g.usesTime = true
+			return "time.Duration"
+		}
+	case "entity_ref":
+		if !slices.Contains(declaredTypes(g.spec), ft.Entity) {
+			return "any"
+		}
+		if g.st.LookupValueType(ft.Entity) != nil {
+			return goName(ft.Entity)
+		}
+		return "*" + goName(ft.Entity)
+	case "named_enum", "alias":
+		return goName(ft.Name)
+	case "inline_enum":
+		if owner == "" {
+			return "string"
+		}
+		name := goName(owner + "_" + field)
+		if g.taken[name] {
+			name += "Value"
+		}
+		g.taken[name] = true
+		g.inlineEnums = append(g.inlineEnums, inlineEnum{name, owner, field, ft.Values})
+		return name
+	case "optional":
+		if ft.Inner == nil {
+			return "any"
+		}
+		inner := g.goType(*ft.Inner, owner, field)
+		if strings.HasPrefix(inner, "*") || strings.HasPrefix(inner, "[]") || inner == "any" {
+			return inner
+		}
+		return "*" + inner
+	case "set", "list":
+		if ft.Element == nil {
+			return "[]any"
+		}
+		return "[]" + g.goType(*ft.Element, owner, field)
+	}
+	return "any"
+}
+
+// goEnum returns the declaration of a string type and its constants.
+func goEnum(name, doc string, values []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// %s %s\ntype %s string\n\n", name, doc, name)
+	fmt.Fprintf(&b, "// Values of %s.\nconst (\n", name)
+	for _, v := range values {
+		fmt.Fprintf(&b, "\t%s %s = %q\n", name+goName(v), name, v)
+	}
+	b.WriteString(")\n")
+	return b.String()
+}
+
+// jsonTag returns the struct tag carrying a field's name in the spec.
+func jsonTag(name string, omitEmpty bool) string {
+	if omitEmpty {
+		return fmt.Sprintf("`json:\"%s,omitempty\"`", name)
+	}
+	return fmt.Sprintf("`json:\"%s\"`", name)
+}
+
+// declaredTypes returns the names of the types the spec declares.
+func declaredTypes(spec *ast.Spec) []string {
+	var names []string
+	for _, vt := range spec.ValueTypes {
+		names = append(names, vt.Name)
+	}
+	for _, ee := range spec.ExternalEntities {
+		names = append(names, ee.Name)
+	}
+	for _, e := range spec.Entities {
+		names = append(names, e.Name)
+	}
+	for _, v := range spec.Variants {
+		names = append(names, v.Name)
+	}
+	for _, e := range spec.Enumerations {
+		names = append(names, e.Name)
+	}
+	for _, a := range spec.TypeAliases {
+		names = append(names, a.Name)
+	}
+	return names
+}
+
+// payload describes the parameters of a trigger.
+type payload struct {
+	trigger string
+	rules   []string
+	params  []payloadParam
+}
+
+// payloadParam is a trigger parameter with its type, nil if unknown.
+type payloadParam struct {
+	name     string
+	typ      *ast.FieldType
+	optional bool
+}
+
+// triggerPayloads returns the external stimulus and chained triggers of a
+// spec, in the order of the first rule each fires. A parameter is listed
+// once across the rules sharing a trigger, typed by the first rule whose use
+// of it gives it a type, and optional if any rule declares it so.
+func triggerPayloads(spec *ast.Spec, st *semantic.SymbolTable) []payload {
+	var payloads []payload
+	index := make(map[string]int)
+	for _, r := range spec.Rules {
+		if r.Trigger.Kind != "external_stimulus" && r.Trigger.Kind != "chained" || r.Trigger.Name == "" {
+			continue
+		}
+		i, ok := index[r.Trigger.Name]
+		if !ok {
+			i = len(payloads)
+			index[r.Trigger.Name] = i
+			payloads = append(payloads, payload{trigger: r.Trigger.Name})
+		}
+		p := &payloads[i]
+		p.rules = append(p.rules, r.Name)
+		types := semantic.TriggerParameterTypes(r, spec, st)
+		for _, tp := range r.Trigger.Parameters {
+			j := slices.IndexFunc(p.params, func(pp payloadParam) bool { return pp.name == tp.Name })
+			if j < 0 {
+				p.params = append(p.params, payloadParam{name: tp.Name})
+				j = len(p.params) - 1
+			}
+			if p.params[j].typ == nil {
+				p.params[j].typ = types[tp.Name]
+			}
+			p.params[j].optional = p.params[j].optional || tp.Optional
+		}
+	}
+	return payloads
+}
+
+// initialisms are the words Go names spell in capitals.
+var initialisms = map[string]bool{
+	"api": true, "dns": true, "html": true, "http": true, "https": true, "id": true, "ip": true,
+	"json": true, "sql": true, "ssh": true, "tls": true, "ttl": true, "uri": true, "url": true,
+	"uuid": true, "xml": true,
+}
+
+// goName converts a spec name in snake_case, kebab-case, camelCase or
+// PascalCase to an exported Go identifier, e.g. "trusted_ip" to "TrustedIP"
+// and "APIKey" to "APIKey". A name starting with a digit, as an enum value
+// may, is prefixed with "X".
+func goName(name string) string {
+	var b strings.Builder
+	for _, w := range nameWords(name) {
+		if initialisms[strings.ToLower(w)] {
+			b.WriteString(strings.ToUpper(w))
+			continue
+		}
+		r := []rune(w)
+		b.WriteRune(unicode.ToUpper(r[0]))
+		b.WriteString(string(r[1:]))
+	}
+	s := b.String()
+	if s == "" || unicode.IsDigit([]rune(s)[0]) {
+		s = "X" + s
+	}
+	return s
+}
+
+// nameWords splits a name into words at underscores, hyphens, spaces and
+// case changes; a run of capitals is one word, as "API" in "APIKey".
+func nameWords(name string) []string {
+	var words []string
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool { return r == '_' || r == '-' || r == ' ' || r == '.' || r == '/' }) {
+		runes := []rune(part)
+		start := 0
+		for i := 1; i < len(runes); i++ {
+			if !unicode.IsUpper(runes[i]) {
+				continue
+			}
+			prevLower := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || unicode.IsUpper(runes[i-1]) && nextLower {
+				words = append(words, string(runes[start:i]))
+				start = i
+			}
+		}
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
@@ -0,0 +1,135 @@
+package codegen
+
+import (
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/semantic"
+)
+
+var refExample = filepath.Join("..", "..", "schemas", "v1", "examples", "password-auth.allium.json")
+
+// generate returns the Go source for spec, failing the test if it cannot be
+// generated or does not parse.
+func generate(t *testing.T, spec *ast.Spec) string {
+	t.Helper()
+	src, err := Go(spec, semantic.BuildSymbolTable(spec), "model")
+	if err != nil {
+		t.Fatalf("Go: %v", err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "model.go", src, parser.AllErrors); err != nil {
+		t.Fatalf("generated source does not parse: %v\n%s", err, src)
+	}
+	return string(src)
+}
+
+// assertContains fails the test for each line of want missing from src,
+// comparing with runs of spaces collapsed.
+func assertContains(t *testing.T, src string, want ...string) {
+	t.Helper()
+	norm := strings.Join(strings.Fields(src), " ")
+	for _, w := range want {
+		if !strings.Contains(norm, strings.Join(strings.Fields(w), " ")) {
+			t.Errorf("generated source lacks %q:\n%s", w, src)
+		}
+	}
+}
+
+func TestGoReferenceExample(t *testing.T) {
+	spec, err := ast.LoadSpec(refExample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := generate(t, spec)
+	assertContains(t, src,
+		"// Code generated by allium-gen from password-auth.allium. DO NOT EDIT.",
+		"package model",
+		`import "time"`,
+		"type AuthEventType string",
+		`AuthEventTypeLoginSuccess AuthEventType = "login_success"`,
+		"type SessionStatus string",
+		`SessionStatusRevoked SessionStatus = "revoked"`,
+		"type TokenData struct {",
+		"type User struct {",
+		"Status UserStatus `json:\"status\"`",
+		"FailedLoginAttempts int64 `json:\"failed_login_attempts\"`",
+		"LockedUntil *time.Time `json:\"locked_until,omitempty\"`",
+		"TrustedIps []string `json:\"trusted_ips\"`",
+		"User *User `json:\"user\"`",
+		"// UserLogsIn is the payload of the UserLogsIn trigger, handled by rules LoginSuccess, LoginFailure, LoginAttemptWhileLocked.",
+		"Token *PasswordResetToken `json:\"token\"`",
+		"IP string `json:\"ip\"`",
+		"Admin any `json:\"admin\"`",
+	)
+}
+
+func TestGoTypes(t *testing.T) {
+	str := ast.FieldType{Kind: "primitive", Value: "String"}
+	spec := &ast.Spec{
+		File:         "shop.allium",
+		TypeAliases:  []ast.TypeAlias{{Name: "Sku", Type: str}},
+		ValueTypes:   []ast.ValueType{{Name: "Money", Fields: []ast.Field{{Name: "amount", Type: ast.FieldType{Kind: "primitive", Value: "Decimal"}}}}},
+		Enumerations: []ast.Enumeration{{Name: "Channel", Values: []string{"web", "in-store", "3rd_party"}}},
+		Entities: []ast.Entity{
+			{Name: "Order", Fields: []ast.Field{
+				{Name: "sku", Type: ast.FieldType{Kind: "alias", Name: "Sku"}},
+				{Name: "total", Type: ast.FieldType{Kind: "entity_ref", Entity: "Money"}},
+				{Name: "channel", Type: ast.FieldType{Kind: "optional", Inner: &ast.FieldType{Kind: "named_enum", Name: "Channel"}}},
+				{Name: "lines", Type: ast.FieldType{Kind: "list", Element: &ast.FieldType{Kind: "entity_ref", Entity: "OrderLine"}}},
+				{Name: "warehouse", Type: ast.FieldType{Kind: "entity_ref", Entity: "inventory/Warehouse"}},
+				{Name: "api_key", Type: str},
+			}},
+			{Name: "OrderLine", Fields: []ast.Field{{Name: "kind", Type: ast.FieldType{Kind: "inline_enum", Values: []string{"item", "gift"}}}}},
+		},
+		Variants: []ast.Variant{{Name: "GiftLine", BaseEntity: "OrderLine", Fields: []ast.Field{{Name: "message", Type: str}}}},
+		Rules: []ast.Rule{
+			{Name: "PlaceOrder", Trigger: ast.Trigger{Kind: "external_stimulus", Name: "Order", Parameters: []ast.TriggerParam{{Name: "note", Optional: true}}},
+				Ensures: []ast.EnsuresClause{{Kind: "entity_creation", Entity: "GiftLine", Fields: map[string]ast.Expression{"message": {Kind: "field_access", Field: "note"}}}}},
+			{Name: "Restock", Trigger: ast.Trigger{Kind: "state_becomes", Binding: "o", Entity: "Order", Field: "channel", Value: "web"}},
+		},
+	}
+	src := generate(t, spec)
+	assertContains(t, src,
+		"type Sku = string",
+		`ChannelInStore Channel = "in-store"`,
+		`ChannelX3rdParty Channel = "3rd_party"`,
+		"type OrderLineKind string",
+		"Sku Sku `json:\"sku\"`",
+		"Total Money `json:\"total\"`",
+		"Channel *Channel `json:\"channel,omitempty\"`",
+		"Lines []*OrderLine `json:\"lines\"`",
+		"Warehouse any `json:\"warehouse\"`",
+		"APIKey string `json:\"api_key\"`",
+		"type GiftLine struct { OrderLine Message string `json:\"message\"` }",
+		// The trigger shares its name with the Order entity.
+		"type OrderPayload struct { Note *string `json:\"note,omitempty\"` }",
+	)
+	if strings.Contains(src, "import") {
+		t.Errorf("source without time types imports packages:\n%s", src)
+	}
+	if strings.Contains(src, "Restock") {
+		t.Errorf("state_becomes trigger has a payload:\n%s", src)
+	}
+}
+
+func TestGoName(t *testing.T) {
+	for _, tt := range []struct{ in, want string }{
+		{"email", "Email"},
+		{"password_hash", "PasswordHash"},
+		{"createdAt", "CreatedAt"},
+		{"trusted_ip", "TrustedIP"},
+		{"user_id", "UserID"},
+		{"APIKey", "APIKey"},
+		{"UserLogsIn", "UserLogsIn"},
+		{"in-store", "InStore"},
+		{"2fa", "X2fa"},
+	} {
+		if got := goName(tt.in); got != tt.want {
+			t.Errorf("goName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package codegen
+
+import (
+	"encoding/json"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/semantic"
+)
+
+// jsonSchemaDialect is the JSON Schema version generated.
+const jsonSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchemaSuffix ends the name of each file JSONSchemas generates.
+const JSONSchemaSuffix = ".schema.json"
+
+// jsonSchemaDoc is a standalone JSON Schema document.
+type jsonSchemaDoc struct {
+	Schema string `json:"$schema"`
+	ID     string `json:"$id"`
+	Title  string `json:"title"`
+	*schema
+}
+
+// JSONSchemas returns a JSON Schema document, as indented JSON, for each
+// type the spec declares, keyed by file name: the type's name followed by
+// JSONSchemaSuffix, e.g. "User.schema.json", which is also its $id.
+// Entities, external entities and value types are objects with a property
+// per field, required unless optional; sets are arrays of unique items and
+// lists arrays; inline enums are string enumerations. A reference to another
+// declared type, whether an entity, value type, enumeration or alias, is a
+// $ref to its file, so the documents are meant to be published together. A
+// variant's schema is its base entity's extended with its own fields.
+func JSONSchemas(spec *ast.Spec, st *semantic.SymbolTable) (map[string][]byte, error) {
+	sg := newSchemaGen(spec, "", JSONSchemaSuffix)
+	kinds := make(map[string]string)
+	for _, a := range spec.TypeAliases {
+		kinds[a.Name] = "the " + a.Name + " type alias"
+	}
+	for _, e := range spec.Enumerations {
+		kinds[e.Name] = "the " + e.Name + " enumeration"
+	}
+	for _, vt := range spec.ValueTypes {
+		kinds[vt.Name] = "the " + vt.Name + " value type"
+	}
+	for _, ee := range spec.ExternalEntities {
+		kinds[ee.Name] = "the " + ee.Name + " entity, managed outside the specification"
+	}
+	for _, e := range spec.Entities {
+		kinds[e.Name] = "the " + e.Name + " entity"
+	}
+	for _, v := range spec.Variants {
+		kinds[v.Name] = "the " + v.Name + " variant of " + v.BaseEntity
+	}
+
+	files := make(map[string][]byte)
+	for name, s := range sg.typeSchemas(st) {
+		s.Description = "Payload of " + kinds[name] + " in " + spec.File + "."
+		file := name + JSONSchemaSuffix
+		data, err := json.MarshalIndent(jsonSchemaDoc{Schema: jsonSchemaDialect, ID: file, Title: name, schema: s}, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		files[file] = append(data, '\n')
+	}
+	return files, nil
+}
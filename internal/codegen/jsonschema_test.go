@@ -0,0 +1,117 @@
+package codegen
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/semantic"
+)
+
+// compileSchemas generates the JSON Schemas of spec and compiles them
+// together, returning the compiled schema of each file.
+func compileSchemas(t *testing.T, spec *ast.Spec) map[string]*jsonschema.Schema {
+	t.Helper()
+	files, err := JSONSchemas(spec, semantic.BuildSymbolTable(spec))
+	if err != nil {
+		t.Fatalf("JSONSchemas: %v", err)
+	}
+	c := jsonschema.NewCompiler()
+	for name, data := range files {
+		var doc any
+		if err := json.Unmarshal(data, &doc); err != nil {
+			t.Fatalf("%s does not decode: %v", name, err)
+		}
+		if err := c.AddResource(name, doc); err != nil {
+			t.Fatalf("add %s: %v", name, err)
+		}
+	}
+	compiled := make(map[string]*jsonschema.Schema)
+	for name := range files {
+		s, err := c.Compile(name)
+		if err != nil {
+			t.Fatalf("compile %s: %v", name, err)
+		}
+		compiled[name] = s
+	}
+	return compiled
+}
+
+func TestJSONSchemasReferenceExample(t *testing.T) {
+	spec, err := ast.LoadSpec(refExample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	schemas := compileSchemas(t, spec)
+	var names []string
+	for name := range schemas {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	want := []string{"AuditLog.schema.json", "AuthEventType.schema.json", "Email.schema.json", "EmailService.schema.json",
+		"PasswordResetToken.schema.json", "Session.schema.json", "TokenData.schema.json", "User.schema.json"}
+	if !slices.Equal(names, want) {
+		t.Errorf("files = %v, want %v", names, want)
+	}
+
+	user := `{"email": "a@example.com", "password_hash": "x", "status": "active", "failed_login_attempts": 0, "trusted_ips": []}`
+	tests := []struct {
+		name    string
+		payload string
+		valid   bool
+	}{
+		{"valid", `{"user": ` + user + `, "created_at": "2026-01-01T00:00:00Z", "expires_at": "2026-01-02T00:00:00Z", "status": "active"}`, true},
+		{"unknown status", `{"user": ` + user + `, "created_at": "2026-01-01T00:00:00Z", "expires_at": "2026-01-02T00:00:00Z", "status": "paused"}`, false},
+		{"missing field", `{"user": ` + user + `, "created_at": "2026-01-01T00:00:00Z", "status": "active"}`, false},
+		{"invalid user", `{"user": {"email": 3}, "created_at": "2026-01-01T00:00:00Z", "expires_at": "2026-01-02T00:00:00Z", "status": "active"}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inst, err := jsonschema.UnmarshalJSON(strings.NewReader(tt.payload))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = schemas["Session.schema.json"].Validate(inst)
+			if (err == nil) != tt.valid {
+				t.Errorf("Validate = %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestJSONSchemasTypes(t *testing.T) {
+	str := ast.FieldType{Kind: "primitive", Value: "String"}
+	spec := &ast.Spec{
+		File:         "shop.allium",
+		Enumerations: []ast.Enumeration{{Name: "Channel", Values: []string{"web", "store"}}},
+		Entities: []ast.Entity{{Name: "Order", Fields: []ast.Field{
+			{Name: "channel", Type: ast.FieldType{Kind: "named_enum", Name: "Channel"}},
+			{Name: "note", Type: ast.FieldType{Kind: "optional", Inner: &str}},
+			{Name: "tags", Type: ast.FieldType{Kind: "set", Element: &str}},
+		}}},
+		Variants: []ast.Variant{{Name: "GiftOrder", BaseEntity: "Order", Fields: []ast.Field{{Name: "message", Type: str}}}},
+	}
+	schemas := compileSchemas(t, spec)
+	for _, tt := range []struct {
+		file, payload string
+		valid         bool
+	}{
+		{"Order.schema.json", `{"channel": "web", "tags": ["a", "b"]}`, true},
+		{"Order.schema.json", `{"channel": "web", "tags": ["a", "a"]}`, false},
+		{"Order.schema.json", `{"channel": "fax", "tags": []}`, false},
+		{"GiftOrder.schema.json", `{"channel": "web", "tags": [], "message": "hi"}`, true},
+		{"GiftOrder.schema.json", `{"channel": "web", "tags": []}`, false},
+	} {
+		inst, err := jsonschema.UnmarshalJSON(strings.NewReader(tt.payload))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := schemas[tt.file].Validate(inst); (err == nil) != tt.valid {
+			t.Errorf("%s: Validate(%s) = %v, want valid %v", tt.file, tt.payload, err, tt.valid)
+		}
+	}
+}
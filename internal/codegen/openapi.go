@@ -0,0 +1,343 @@
+package codegen
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/docgen"
+	"github.com/foundry-zero/allium/internal/semantic"
+)
+
+// openAPIVersion is the version of the OpenAPI specification generated.
+const openAPIVersion = "3.1.0"
+
+// openAPIDoc is an OpenAPI document, in the subset OpenAPI uses.
+type openAPIDoc struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       openAPIInfo                             `json:"info"`
+	Tags       []openAPITag                            `json:"tags,omitempty"`
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components openAPIComponents                       `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type openAPITag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+type openAPIOperation struct {
+	OperationID string                     `json:"operationId"`
+	Summary     string                     `json:"summary,omitempty"`
+	Description string                     `json:"description,omitempty"`
+	Tags        []string                   `json:"tags"`
+	Parameters  []openAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *openAPIBody               `json:"requestBody,omitempty"`
+	Responses   map[string]openAPIResponse `json:"responses"`
+}
+
+type openAPIParameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Required    bool    `json:"required"`
+	Description string  `json:"description,omitempty"`
+	Schema      *schema `json:"schema"`
+}
+
+type openAPIBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIMediaType struct {
+	Schema *schema `json:"schema"`
+}
+
+type openAPIComponents struct {
+	Schemas map[string]*schema `json:"schemas"`
+}
+
+// OpenAPI returns an OpenAPI 3.1 document, as indented JSON, describing the
+// surfaces of spec as path groups, each tagged with the surface's name:
+//
+//   - a surface is at /<surface-name>, or /<surface-name>/{binding} when it
+//     has a context;
+//   - a surface exposing data has a GET operation whose response is an
+//     object with a property per exposed item, typed from its expression and
+//     required unless it is optional or exposed only when a condition holds;
+//   - each action a surface provides is a POST operation at
+//     /<surface-name>/<trigger-name> whose request body carries the
+//     trigger's arguments the caller supplies, typed from the parameters of
+//     the rules the trigger fires.
+//
+// The when conditions of exposed items, actions and for each groups, the
+// facing party, guarantees and guidance are given as descriptions, in
+// Allium source syntax. Every type the spec declares is a component schema.
+// The document's version is the spec's metadata.spec_version, if it has one.
+func OpenAPI(spec *ast.Spec, st *semantic.SymbolTable) ([]byte, error) {
+	sg := newSchemaGen(spec, "#/components/schemas/", "")
+	title := spec.Metadata.Scope
+	if title == "" {
+		title = spec.File
+	}
+	version := spec.Metadata.SpecVersion
+	if version == "" {
+		version = spec.Version
+	}
+	doc := &openAPIDoc{
+		OpenAPI:    openAPIVersion,
+		Info:       openAPIInfo{Title: title, Description: spec.Metadata.Description, Version: version},
+		Paths:      make(map[string]map[string]*openAPIOperation),
+		Components: openAPIComponents{Schemas: sg.typeSchemas(st)},
+	}
+
+	payloads := make(map[string]payload)
+	for _, p := range triggerPayloads(spec, st) {
+		payloads[p.trigger] = p
+	}
+	for _, s := range spec.Surfaces {
+		doc.Tags = append(doc.Tags, openAPITag{Name: s.Name, Description: surfaceDescription(s)})
+		base := "/" + kebabCase(s.Name)
+		var params []openAPIParameter
+		if c := s.Context; c != nil && c.Binding != "" {
+			base += "/{" + c.Binding + "}"
+			params = append(params, openAPIParameter{
+				Name: c.Binding, In: "path", Required: true,
+				Description: withCondition("The "+c.Type+" the surface is scoped to", "where", c.Condition),
+				Schema:      &schema{Type: "string"},
+			})
+		}
+		group := make(map[string]*openAPIOperation)
+		doc.Paths[base] = group
+
+		if len(s.Exposes) > 0 {
+			group["get"] = &openAPIOperation{
+				OperationID: lowerFirst(goName(s.Name)),
+				Summary:     "What " + s.Name + " exposes",
+				Tags:        []string{s.Name},
+				Parameters:  params,
+				Responses: map[string]openAPIResponse{"200": {
+					Description: "The data " + s.Name + " exposes.",
+					Content:     jsonContent(exposesSchema(s, spec, st, sg)),
+				}},
+			}
+		}
+		for _, a := range surfaceActions(s.Provides, nil) {
+			path := base + "/" + kebabCase(a.item.Trigger)
+			if doc.Paths[path] != nil {
+				continue
+			}
+			op := &openAPIOperation{
+				OperationID: lowerFirst(goName(s.Name)) + goName(a.item.Trigger),
+				Summary:     a.item.Trigger,
+				Description: actionDescription(a, payloads[a.item.Trigger]),
+				Tags:        []string{s.Name},
+				Parameters:  params,
+				Responses:   map[string]openAPIResponse{"202": {Description: "The trigger was accepted."}},
+			}
+			if body := actionSchema(a, surfaceBindings(s), payloads[a.item.Trigger], sg); len(body.Properties) > 0 {
+				op.RequestBody = &openAPIBody{Required: true, Content: jsonContent(body)}
+			}
+			doc.Paths[path] = map[string]*openAPIOperation{"post": op}
+		}
+		if len(group) == 0 {
+			delete(doc.Paths, base)
+		}
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// surfaceDescription describes who a surface faces, what it guarantees and
+// the guidance it gives.
+func surfaceDescription(s ast.Surface) string {
+	lines := []string{fmt.Sprintf("Facing %s as %s.", s.Facing.Type, s.Facing.Binding)}
+	for _, g := range s.Guarantees {
+		line := "Guarantees " + g.Name
+		switch {
+		case g.Description != "":
+			line += ": " + g.Description
+		case g.Expression != nil:
+			line += ": " + docgen.ExprSource(g.Expression)
+		}
+		lines = append(lines, strings.TrimSuffix(line, ".")+".")
+	}
+	lines = append(lines, s.Guidance...)
+	return strings.Join(lines, "\n\n")
+}
+
+// exposesSchema returns the schema of the data a surface exposes. Each item
+// is a property named after the last field it reads, or after its source
+// when that name is taken or it reads no field.
+func exposesSchema(s ast.Surface, spec *ast.Spec, st *semantic.SymbolTable, sg *schemaGen) *schema {
+	obj := &schema{Type: "object", Properties: make(map[string]*schema)}
+	for _, item := range s.Exposes {
+		src := docgen.ExprSource(item.Expression)
+		name := src
+		if e := item.Expression; e != nil && e.Kind == "field_access" {
+			if _, taken := obj.Properties[e.Field]; !taken {
+				name = e.Field
+			}
+		}
+		ft := semantic.SurfaceExpressionType(s, spec, st, item.Expression)
+		prop := sg.fieldSchema(ft)
+		desc := withCondition(src, "when", item.When)
+		if prop.Description != "" {
+			desc += ". " + prop.Description
+		}
+		prop.Description = desc
+		obj.Properties[name] = prop
+		if item.When == nil && (ft == nil || ft.Kind != "optional") {
+			obj.Required = append(obj.Required, name)
+		}
+	}
+	return obj
+}
+
+// action is a provided action with the for each groups enclosing it,
+// outermost first.
+type action struct {
+	item    ast.ProvidesItem
+	forEach []ast.ProvidesItem
+}
+
+// surfaceActions flattens provides items into their actions.
+func surfaceActions(items []ast.ProvidesItem, enclosing []ast.ProvidesItem) []action {
+	var actions []action
+	for _, item := range items {
+		switch item.Kind {
+		case "action":
+			if item.Trigger != "" {
+				actions = append(actions, action{item, enclosing})
+			}
+		case "for_each":
+			actions = append(actions, surfaceActions(item.Items, append(enclosing[:len(enclosing):len(enclosing)], item))...)
+		}
+	}
+	return actions
+}
+
+// actionDescription describes the rules an action fires and the conditions
+// under which it is available.
+func actionDescription(a action, p payload) string {
+	var lines []string
+	if len(p.rules) > 0 {
+		rules := "rule "
+		if len(p.rules) > 1 {
+			rules = "rules "
+		}
+		lines = append(lines, "Fires "+a.item.Trigger+", handled by "+rules+strings.Join(p.rules, ", ")+".")
+	}
+	for _, fe := range a.forEach {
+		lines = append(lines, withCondition("Offered for each "+fe.Binding+" in "+docgen.ExprSource(fe.Collection), "when", fe.When)+".")
+	}
+	if a.item.When != nil {
+		lines = append(lines, "Available when "+docgen.ExprSource(a.item.When)+".")
+	}
+	return strings.Join(lines, "\n\n")
+}
+
+// surfaceBindings returns the names a surface binds: its facing, context
+// and let bindings.
+func surfaceBindings(s ast.Surface) map[string]bool {
+	bound := map[string]bool{s.Facing.Binding: true}
+	if s.Context != nil {
+		bound[s.Context.Binding] = true
+	}
+	for _, lb := range s.LetBindings {
+		bound[lb.Name] = true
+	}
+	return bound
+}
+
+// actionSchema returns the schema of the request body of an action: a
+// property for each argument the caller supplies, that is each argument the
+// surface neither computes nor takes from a binding of its own or of an
+// enclosing for each group, or each parameter of the trigger when the action
+// lists no arguments. A parameter is required unless the trigger's rules
+// declare it optional.
+func actionSchema(a action, bound map[string]bool, p payload, sg *schemaGen) *schema {
+	for _, fe := range a.forEach {
+		bound[fe.Binding] = true
+	}
+	var names []string
+	if len(a.item.Arguments) == 0 {
+		for _, pp := range p.params {
+			names = append(names, pp.name)
+		}
+	}
+	for _, arg := range a.item.Arguments {
+		if arg.Expression == nil && !bound[arg.Name] {
+			names = append(names, arg.Name)
+		}
+	}
+	obj := &schema{Type: "object", Properties: make(map[string]*schema)}
+	for _, name := range names {
+		var param payloadParam
+		for _, pp := range p.params {
+			if pp.name == name {
+				param = pp
+			}
+		}
+		if param.typ != nil && param.typ.Kind == "entity_ref" && sg.declared[param.typ.Entity] {
+			// The caller names the instance rather than sending it.
+			obj.Properties[name] = &schema{Type: "string", Description: "Identifies the " + param.typ.Entity + "."}
+		} else {
+			obj.Properties[name] = sg.fieldSchema(param.typ)
+		}
+		if !param.optional {
+			obj.Required = append(obj.Required, name)
+		}
+	}
+	return obj
+}
+
+// withCondition appends a condition in source syntax to text, e.g. "user.email
+// when user.verified".
+func withCondition(text, word string, cond *ast.Expression) string {
+	if cond == nil {
+		return text
+	}
+	return text + " " + word + " " + docgen.ExprSource(cond)
+}
+
+// jsonContent returns the content map of a JSON body with schema s.
+func jsonContent(s *schema) map[string]openAPIMediaType {
+	return map[string]openAPIMediaType{"application/json": {Schema: s}}
+}
+
+// kebabCase converts a name to kebab-case, e.g. "UserLogsIn" to
+// "user-logs-in".
+func kebabCase(name string) string {
+	words := nameWords(name)
+	for i, w := range words {
+		words[i] = strings.ToLower(w)
+	}
+	return strings.Join(words, "-")
+}
+
+// lowerFirst lower-cases the leading word of a Go name, e.g. "APIKeys" to
+// "apiKeys" and "Dashboard" to "dashboard".
+func lowerFirst(name string) string {
+	words := nameWords(name)
+	if len(words) == 0 {
+		return name
+	}
+	return strings.ToLower(words[0]) + strings.TrimPrefix(name, words[0])
+}
@@ -0,0 +1,150 @@
+package codegen
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/semantic"
+)
+
+// openAPI generates the OpenAPI document for spec and decodes it.
+func openAPI(t *testing.T, spec *ast.Spec) *openAPIDoc {
+	t.Helper()
+	data, err := OpenAPI(spec, semantic.BuildSymbolTable(spec))
+	if err != nil {
+		t.Fatalf("OpenAPI: %v", err)
+	}
+	var doc openAPIDoc
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("generated document does not decode: %v\n%s", err, data)
+	}
+	return &doc
+}
+
+// body returns the request body schema of an operation, or nil.
+func body(op *openAPIOperation) *schema {
+	if op == nil || op.RequestBody == nil {
+		return nil
+	}
+	return op.RequestBody.Content["application/json"].Schema
+}
+
+func TestOpenAPIReferenceExample(t *testing.T) {
+	spec, err := ast.LoadSpec(refExample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := openAPI(t, spec)
+	if doc.OpenAPI != "3.1.0" || doc.Info.Title != "authentication" || doc.Info.Version != "1" {
+		t.Errorf("openapi %q, title %q, version %q", doc.OpenAPI, doc.Info.Title, doc.Info.Version)
+	}
+	spec.Metadata.SpecVersion = "1.4.0"
+	if v := openAPI(t, spec).Info.Version; v != "1.4.0" {
+		t.Errorf("version = %q, want the spec version", v)
+	}
+	spec.Metadata.SpecVersion = ""
+
+	login := doc.Paths["/authentication/user-logs-in"]["post"]
+	if login == nil {
+		t.Fatalf("no POST /authentication/user-logs-in in %v", slices.Sorted(maps.Keys(doc.Paths)))
+	}
+	if b := body(login); b == nil || b.Properties["email"].Type != "string" || !slices.Equal(b.Required, []string{"email", "password"}) {
+		t.Errorf("unexpected login body %+v", b)
+	}
+	if !strings.Contains(login.Description, "Available when not visitor.is_locked.") {
+		t.Errorf("login description lacks its when condition: %q", login.Description)
+	}
+
+	// The token comes from the path, not the body.
+	reset := doc.Paths["/password-reset/{token}/user-resets-password"]["post"]
+	if b := body(reset); b == nil || len(b.Properties) != 1 || b.Properties["new_password"] == nil {
+		t.Errorf("unexpected reset body %+v", b)
+	}
+	if len(reset.Parameters) != 1 || reset.Parameters[0].Name != "token" || reset.Parameters[0].In != "path" {
+		t.Errorf("unexpected reset parameters %+v", reset.Parameters)
+	}
+
+	get := doc.Paths["/password-reset/{token}"]["get"]
+	if get == nil {
+		t.Fatal("no GET /password-reset/{token}")
+	}
+	exposed := get.Responses["200"].Content["application/json"].Schema
+	if p := exposed.Properties["expires_at"]; p == nil || p.Format != "date-time" {
+		t.Errorf("unexpected expires_at property %+v", p)
+	}
+
+	// Actions supplied entirely by the surface have no body.
+	logout := doc.Paths["/account-management/user-logs-out"]["post"]
+	if logout == nil || logout.RequestBody != nil || !strings.Contains(logout.Description, "Offered for each session in user.active_sessions.") {
+		t.Errorf("unexpected logout operation %+v", logout)
+	}
+
+	for _, name := range []string{"User", "Session", "AuthEventType", "TokenData"} {
+		if doc.Components.Schemas[name] == nil {
+			t.Errorf("no component schema %s", name)
+		}
+	}
+	if u := doc.Components.Schemas["User"]; !slices.Contains(u.Required, "email") || slices.Contains(u.Required, "locked_until") {
+		t.Errorf("unexpected User required list %v", u.Required)
+	}
+}
+
+func TestOpenAPIExposes(t *testing.T) {
+	str := ast.FieldType{Kind: "primitive", Value: "String"}
+	spec := &ast.Spec{
+		File: "shop.allium",
+		Entities: []ast.Entity{
+			{Name: "Customer", Fields: []ast.Field{
+				{Name: "name", Type: str},
+				{Name: "nickname", Type: ast.FieldType{Kind: "optional", Inner: &str}},
+				{Name: "vip", Type: ast.FieldType{Kind: "primitive", Value: "Boolean"}},
+				{Name: "manager", Type: ast.FieldType{Kind: "entity_ref", Entity: "Customer"}},
+			}},
+		},
+		Surfaces: []ast.Surface{{
+			Name:   "Profile",
+			Facing: ast.FacingClause{Binding: "customer", Type: "Customer"},
+			Exposes: []ast.ExposesItem{
+				{Expression: &ast.Expression{Kind: "field_access", Object: &ast.Expression{Kind: "field_access", Field: "customer"}, Field: "name"}},
+				{Expression: &ast.Expression{Kind: "field_access", Object: &ast.Expression{Kind: "field_access", Field: "customer"}, Field: "nickname"}},
+				{Expression: &ast.Expression{Kind: "field_access", Object: &ast.Expression{Kind: "field_access", Field: "customer"}, Field: "manager"},
+					When: &ast.Expression{Kind: "field_access", Object: &ast.Expression{Kind: "field_access", Field: "customer"}, Field: "vip"}},
+			},
+		}},
+	}
+	doc := openAPI(t, spec)
+	get := doc.Paths["/profile"]["get"]
+	if get == nil {
+		t.Fatal("no GET /profile")
+	}
+	s := get.Responses["200"].Content["application/json"].Schema
+	if !slices.Equal(s.Required, []string{"name"}) {
+		t.Errorf("required = %v, want [name]", s.Required)
+	}
+	m := s.Properties["manager"]
+	if m == nil || m.Ref != "#/components/schemas/Customer" || m.Description != "customer.manager when customer.vip" {
+		t.Errorf("unexpected manager property %+v", m)
+	}
+	if len(doc.Paths) != 1 {
+		t.Errorf("surface without actions has paths %v", slices.Sorted(maps.Keys(doc.Paths)))
+	}
+}
+
+func TestKebabCaseAndLowerFirst(t *testing.T) {
+	for _, tt := range []struct{ in, kebab, lower string }{
+		{"UserLogsIn", "user-logs-in", "userLogsIn"},
+		{"APIKeys", "api-keys", "apiKeys"},
+		{"Dashboard", "dashboard", "dashboard"},
+	} {
+		if got := kebabCase(tt.in); got != tt.kebab {
+			t.Errorf("kebabCase(%q) = %q, want %q", tt.in, got, tt.kebab)
+		}
+		if got := lowerFirst(tt.in); got != tt.lower {
+			t.Errorf("lowerFirst(%q) = %q, want %q", tt.in, got, tt.lower)
+		}
+	}
+}
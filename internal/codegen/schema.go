@@ -0,0 +1,130 @@
+package codegen
+
+import (
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/semantic"
+)
+
+// schema is a JSON Schema, in the subset OpenAPI 3.1 shares with it.
+type schema struct {
+	Ref         string             `json:"$ref,omitempty"`
+	Type        string             `json:"type,omitempty"`
+	Format      string             `json:"format,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Enum        []string           `json:"enum,omitempty"`
+	Items       *schema            `json:"items,omitempty"`
+	UniqueItems bool               `json:"uniqueItems,omitempty"`
+	AllOf       []*schema          `json:"allOf,omitempty"`
+	Properties  map[string]*schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+}
+
+// schemaGen maps field types to schemas, referring to the schema of a named
+// type by its name between refPrefix and refSuffix, e.g.
+// "#/components/schemas/User" or "User.schema.json".
+type schemaGen struct {
+	spec                 *ast.Spec
+	refPrefix, refSuffix string
+	declared             map[string]bool
+}
+
+func newSchemaGen(spec *ast.Spec, refPrefix, refSuffix string) *schemaGen {
+	g := &schemaGen{spec: spec, refPrefix: refPrefix, refSuffix: refSuffix, declared: make(map[string]bool)}
+	for _, name := range declaredTypes(spec) {
+		g.declared[name] = true
+	}
+	return g
+}
+
+// ref returns the reference to the schema of a named type.
+func (g *schemaGen) ref(name string) string {
+	return g.refPrefix + name + g.refSuffix
+}
+
+// fieldSchema returns the schema of a field type. Optional types have the
+// schema of the type they wrap; whether a value may be left out is recorded
+// by the enclosing object's required list.
+func (g *schemaGen) fieldSchema(ft *ast.FieldType) *schema {
+	if ft == nil {
+		return &schema{}
+	}
+	switch ft.Kind {
+	case "primitive":
+		switch ft.Value {
+		case "String":
+			return &schema{Type: "string"}
+		case "Integer":
+			return &schema{Type: "integer"}
+		case "Decimal":
+			return &schema{Type: "number"}
+		case "Boolean":
+			return &schema{Type: "boolean"}
+		case "Timestamp":
+			return &schema{Type: "string", Format: "date-time"}
+		case "Duration":
+			return &schema{Type: "string", Format: "duration"}
+		}
+	case "entity_ref":
+		if !g.declared[ft.Entity] {
+			return &schema{Description: ft.Entity + " is not declared in this specification."}
+		}
+		return &schema{Ref: g.ref(ft.Entity)}
+	case "named_enum", "alias":
+		if !g.declared[ft.Name] {
+			return &schema{Description: ft.Name + " is not declared in this specification."}
+		}
+		return &schema{Ref: g.ref(ft.Name)}
+	case "inline_enum":
+		return &schema{Type: "string", Enum: ft.Values}
+	case "optional":
+		return g.fieldSchema(ft.Inner)
+	case "set", "list":
+		return &schema{Type: "array", Items: g.fieldSchema(ft.Element), UniqueItems: ft.Kind == "set"}
+	}
+	return &schema{}
+}
+
+// objectSchema returns the schema of an object with a property per field,
+// each required unless optional.
+func (g *schemaGen) objectSchema(fields []ast.Field) *schema {
+	s := &schema{Type: "object", Properties: make(map[string]*schema, len(fields))}
+	for _, f := range fields {
+		s.Properties[f.Name] = g.fieldSchema(&f.Type)
+		if f.Type.Kind != "optional" {
+			s.Required = append(s.Required, f.Name)
+		}
+	}
+	return s
+}
+
+// typeSchemas returns the schema of each type the spec declares, by name:
+// an object for each value type, external entity and entity, the object of
+// its base entity extended with its own fields for each variant, a string
+// enumeration for each enumeration and the aliased type for each alias.
+func (g *schemaGen) typeSchemas(st *semantic.SymbolTable) map[string]*schema {
+	schemas := make(map[string]*schema)
+	for _, a := range g.spec.TypeAliases {
+		schemas[a.Name] = g.fieldSchema(&a.Type)
+	}
+	for _, e := range g.spec.Enumerations {
+		schemas[e.Name] = &schema{Type: "string", Enum: e.Values}
+	}
+	for _, vt := range g.spec.ValueTypes {
+		schemas[vt.Name] = g.objectSchema(vt.Fields)
+	}
+	for _, ee := range g.spec.ExternalEntities {
+		schemas[ee.Name] = g.objectSchema(ee.Fields)
+	}
+	for _, e := range g.spec.Entities {
+		schemas[e.Name] = g.objectSchema(e.Fields)
+	}
+	for _, v := range g.spec.Variants {
+		own := g.objectSchema(v.Fields)
+		if v.BaseEntity == v.Name || st.LookupEntity(v.BaseEntity) == nil {
+			schemas[v.Name] = own
+			continue
+		}
+		schemas[v.Name] = &schema{AllOf: []*schema{{Ref: g.ref(v.BaseEntity)}, own}}
+	}
+	return schemas
+}
@@ -0,0 +1,323 @@
+// Package config loads allium-check project configuration: settings shared by
+// every spec in a repository, kept in a JSON file (conventionally
+// .alliumcheck.json) at the repository root.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// FileName is the name under which Discover looks for a project
+// configuration.
+const FileName = ".alliumcheck.json"
+
+// Severity settings for a rule in Config.Severity.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+	SeverityHint    = "hint"
+	SeverityOff     = "off"
+)
+
+// Naming styles for the case settings of Naming.
+const (
+	CaseSnake  = "snake_case"
+	CaseCamel  = "camelCase"
+	CasePascal = "PascalCase"
+)
+
+// TriggersVerbNoun is the Naming.Triggers setting requiring trigger names to
+// start with a verb, such as SubmitOrder or submit_order.
+const TriggersVerbNoun = "verb_noun"
+
+// ruleID matches the identifiers of semantic rules and warnings.
+var ruleID = regexp.MustCompile(`^(RULE|WARN)-[0-9]+$`)
+
+// Config is a parsed project configuration.
+type Config struct {
+	// Critical lists glob patterns, relative to the config file's directory,
+	// for spec files held to a stricter standard: every warning in a matching
+	// file is reported as an error. "*" matches within one path segment and
+	// "**" matches any number of segments, e.g. "payments/**".
+	Critical []string `json:"critical,omitempty"`
+
+	// Layers assigns spec files to named architectural layers by glob
+	// pattern, using the same syntax as Critical. A file may belong to
+	// several layers.
+	Layers map[string][]string `json:"layers,omitempty"`
+
+	// Layering restricts which layers may import which through
+	// use_declarations. Violations are reported in workspace mode.
+	Layering []LayerRule `json:"layering,omitempty"`
+
+	// TerminalStates declares status values that are intentionally terminal,
+	// by entity and then status field, e.g. {"Order": {"status":
+	// ["delivered", "cancelled"]}}. RULE-08 does not report them as dead ends.
+	TerminalStates map[string]map[string][]string `json:"terminal_states,omitempty"`
+
+	// InitialStates declares the status values that instances created
+	// outside any rule, such as entities provided through given bindings,
+	// may start in, by entity and then status field. RULE-07 treats them as
+	// reachable.
+	InitialStates map[string]map[string][]string `json:"initial_states,omitempty"`
+
+	// SensitiveFields marks members holding sensitive data, by type and then
+	// member, with the actors allowed to see each, e.g. {"User":
+	// {"password_hash": []}}. RULE-62 reports surfaces exposing them to
+	// other actors. Specs may mark further members in their metadata.
+	SensitiveFields map[string]map[string][]string `json:"sensitive_fields,omitempty"`
+
+	// Severity overrides the severity of individual rules, e.g.
+	// {"RULE-08": "warning", "WARN-16": "error", "WARN-20": "off"}. A rule
+	// set to "off" is not reported at all. Critical files still report every
+	// remaining warning as an error.
+	Severity map[string]string `json:"severity,omitempty"`
+
+	// Naming selects the naming conventions the project's specs follow.
+	// Departures are reported as WARN-28.
+	Naming *Naming `json:"naming,omitempty"`
+
+	dir string // directory containing the config file; patterns are relative to it
+}
+
+// LayerRule forbids specs in layer From from importing specs in any of the
+// MustNotImport layers, e.g. core modules must not import feature modules.
+type LayerRule struct {
+	From          string   `json:"from"`
+	MustNotImport []string `json:"must_not_import"`
+}
+
+// Naming is a set of naming conventions. Each convention is checked only
+// when it is set, so teams enable the ones they follow.
+type Naming struct {
+	// Fields is the case style of the fields of entities, external
+	// entities, variants and value types: CaseSnake, CaseCamel or
+	// CasePascal.
+	Fields string `json:"fields,omitempty"`
+
+	// EnumValues is the case style of enumeration and inline enum values.
+	EnumValues string `json:"enum_values,omitempty"`
+
+	// Triggers is the form of external stimulus and chained trigger names.
+	// The only form is TriggersVerbNoun.
+	Triggers string `json:"triggers,omitempty"`
+
+	// SurfaceSuffix, if set, must end every surface name, e.g. "View".
+	SurfaceSuffix string `json:"surface_suffix,omitempty"`
+
+	// NoEntityShadowing forbids naming a binding after an entity, external
+	// entity, variant or value type.
+	NoEntityShadowing bool `json:"no_entity_shadowing,omitempty"`
+}
+
+// Load reads the config file at path. Unknown keys are rejected so that a
+// misspelt setting is not silently ignored.
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var c Config
+	if err := dec.Decode(&c); err != nil {
+		return nil, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	for _, p := range c.Critical {
+		if _, err := matchGlob(p, ""); err != nil {
+			return nil, fmt.Errorf("config %s: invalid critical pattern %q: %w", path, p, err)
+		}
+	}
+	for layer, patterns := range c.Layers {
+		for _, p := range patterns {
+			if _, err := matchGlob(p, ""); err != nil {
+				return nil, fmt.Errorf("config %s: invalid pattern %q for layer %q: %w", path, p, layer, err)
+			}
+		}
+	}
+	for i, r := range c.Layering {
+		for _, layer := range append([]string{r.From}, r.MustNotImport...) {
+			if _, ok := c.Layers[layer]; !ok {
+				return nil, fmt.Errorf("config %s: layering rule %d names undeclared layer %q", path, i, layer)
+			}
+		}
+	}
+	for rule, sev := range c.Severity {
+		if !ruleID.MatchString(rule) {
+			return nil, fmt.Errorf("config %s: invalid rule %q in severity (use e.g. RULE-08 or WARN-16)", path, rule)
+		}
+		if !slices.Contains([]string{SeverityError, SeverityWarning, SeverityInfo, SeverityHint, SeverityOff}, sev) {
+			return nil, fmt.Errorf("config %s: invalid severity %q for %s (use error, warning, info, hint or off)", path, sev, rule)
+		}
+	}
+	if n := c.Naming; n != nil {
+		styles := []string{CaseSnake, CaseCamel, CasePascal}
+		for _, setting := range [][2]string{{"fields", n.Fields}, {"enum_values", n.EnumValues}} {
+			if style := setting[1]; style != "" && !slices.Contains(styles, style) {
+				return nil, fmt.Errorf("config %s: invalid naming style %q for %s (use %s)", path, style, setting[0], strings.Join(styles, ", "))
+			}
+		}
+		if n.Triggers != "" && n.Triggers != TriggersVerbNoun {
+			return nil, fmt.Errorf("config %s: invalid naming style %q for triggers (use %s)", path, n.Triggers, TriggersVerbNoun)
+		}
+	}
+	abs, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+	c.dir = abs
+	return &c, nil
+}
+
+// Discover looks for a FileName config in the directory containing specPath
+// and then in each parent directory in turn, and returns the path of the
+// first one found, or "" if there is none.
+func Discover(specPath string) (string, error) {
+	dir, err := filepath.Abs(filepath.Dir(specPath))
+	if err != nil {
+		return "", fmt.Errorf("discover config: %w", err)
+	}
+	for {
+		candidate := filepath.Join(dir, FileName)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// IsCritical reports whether the spec at specPath matches one of the critical
+// patterns. Files outside the config's directory never match. It is safe to
+// call on a nil Config.
+func (c *Config) IsCritical(specPath string) bool {
+	if c == nil {
+		return false
+	}
+	return c.matchAny(c.Critical, specPath)
+}
+
+// SeverityOf returns the configured severity of rule, one of SeverityError,
+// SeverityWarning, SeverityInfo, SeverityHint and SeverityOff, or "" if it is
+// not overridden. It is safe
+// to call on a nil Config.
+func (c *Config) SeverityOf(rule string) string {
+	if c == nil {
+		return ""
+	}
+	return c.Severity[rule]
+}
+
+// LayersOf returns the sorted names of the layers the spec at specPath
+// belongs to. It is safe to call on a nil Config.
+func (c *Config) LayersOf(specPath string) []string {
+	if c == nil {
+		return nil
+	}
+	var layers []string
+	for layer, patterns := range c.Layers {
+		if c.matchAny(patterns, specPath) {
+			layers = append(layers, layer)
+		}
+	}
+	sort.Strings(layers)
+	return layers
+}
+
+// ForbiddenImports returns the layering rules violated by a spec in the
+// from layers importing a spec in the to layers, one description per
+// violated pair, e.g. "layer 'core' must not import layer 'feature'".
+func (c *Config) ForbiddenImports(from, to []string) []string {
+	if c == nil {
+		return nil
+	}
+	var violations []string
+	for _, r := range c.Layering {
+		if !slices.Contains(from, r.From) {
+			continue
+		}
+		for _, denied := range r.MustNotImport {
+			if slices.Contains(to, denied) {
+				v := fmt.Sprintf("layer '%s' must not import layer '%s'", r.From, denied)
+				if !slices.Contains(violations, v) {
+					violations = append(violations, v)
+				}
+			}
+		}
+	}
+	return violations
+}
+
+// matchAny reports whether specPath, relative to the config directory,
+// matches any of patterns. Files outside the directory never match.
+func (c *Config) matchAny(patterns []string, specPath string) bool {
+	if len(patterns) == 0 {
+		return false
+	}
+	abs, err := filepath.Abs(specPath)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(c.dir, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	for _, p := range patterns {
+		if ok, _ := matchGlob(p, rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches a slash-separated name against pattern, where "**" as a
+// whole segment matches zero or more segments and other segments use
+// path.Match syntax. The pattern is always checked for syntax errors.
+func matchGlob(pattern, name string) (bool, error) {
+	pat := strings.Split(strings.Trim(pattern, "/"), "/")
+	for _, seg := range pat {
+		if _, err := path.Match(seg, ""); err != nil {
+			return false, err
+		}
+	}
+	var segs []string
+	if name != "" {
+		segs = strings.Split(name, "/")
+	}
+	return matchSegments(pat, segs), nil
+}
+
+func matchSegments(pat, segs []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			for i := 0; i <= len(segs); i++ {
+				if matchSegments(pat[1:], segs[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segs) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], segs[0]); !ok {
+			return false
+		}
+		pat, segs = pat[1:], segs[1:]
+	}
+	return len(segs) == 0
+}
@@ -0,0 +1,201 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeConfig(t *testing.T, dir, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, ".alliumcheck.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"payments/**", "payments/refunds.allium.json", true},
+		{"payments/**", "payments/eu/refunds.allium.json", true},
+		{"payments/**", "orders/payments.allium.json", false},
+		{"**/ledger.allium.json", "ledger.allium.json", true},
+		{"**/ledger.allium.json", "core/money/ledger.allium.json", true},
+		{"payments/*.allium.json", "payments/eu/refunds.allium.json", false},
+		{"*.allium.json", "auth.allium.json", true},
+		{"core/**/accounts/*", "core/accounts/a.allium.json", true},
+	}
+	for _, tt := range tests {
+		got, err := matchGlob(tt.pattern, tt.name)
+		if err != nil {
+			t.Fatalf("matchGlob(%q): %v", tt.pattern, err)
+		}
+		if got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsCritical(t *testing.T) {
+	dir := t.TempDir()
+	c, err := Load(writeConfig(t, dir, `{"critical": ["payments/**"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !c.IsCritical(filepath.Join(dir, "payments", "refunds.allium.json")) {
+		t.Error("expected payments spec to be critical")
+	}
+	if c.IsCritical(filepath.Join(dir, "orders", "orders.allium.json")) {
+		t.Error("expected orders spec not to be critical")
+	}
+	// Patterns are relative to the config file, not the working directory.
+	if c.IsCritical(filepath.Join(filepath.Dir(dir), "payments", "refunds.allium.json")) {
+		t.Error("expected spec outside the config directory not to be critical")
+	}
+	var none *Config
+	if none.IsCritical(filepath.Join(dir, "payments", "refunds.allium.json")) {
+		t.Error("nil config should mark nothing critical")
+	}
+}
+
+func TestLayering(t *testing.T) {
+	dir := t.TempDir()
+	c, err := Load(writeConfig(t, dir, `{
+  "layers": {"core": ["core/**", "shared/*.allium.json"], "feature": ["features/**"], "shared": ["shared/**"]},
+  "layering": [{"from": "core", "must_not_import": ["feature"]}, {"from": "shared", "must_not_import": ["feature", "core"]}]
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	shared := c.LayersOf(filepath.Join(dir, "shared", "money.allium.json"))
+	if len(shared) != 2 || shared[0] != "core" || shared[1] != "shared" {
+		t.Errorf("LayersOf(shared spec) = %v, want [core shared]", shared)
+	}
+	feature := c.LayersOf(filepath.Join(dir, "features", "checkout.allium.json"))
+
+	got := c.ForbiddenImports(shared, feature)
+	want := []string{"layer 'core' must not import layer 'feature'", "layer 'shared' must not import layer 'feature'"}
+	if len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("ForbiddenImports(shared, feature) = %v, want %v", got, want)
+	}
+	if got := c.ForbiddenImports(feature, shared); len(got) != 0 {
+		t.Errorf("ForbiddenImports(feature, shared) = %v, want none", got)
+	}
+}
+
+func TestStates(t *testing.T) {
+	c, err := Load(writeConfig(t, t.TempDir(), `{
+  "terminal_states": {"Order": {"status": ["delivered", "cancelled"]}},
+  "initial_states": {"Workspace": {"status": ["active"]}}
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := c.TerminalStates["Order"]["status"]
+	if len(got) != 2 || got[0] != "delivered" || got[1] != "cancelled" {
+		t.Errorf("TerminalStates[Order][status] = %v, want [delivered cancelled]", got)
+	}
+	if got := c.InitialStates["Workspace"]["status"]; len(got) != 1 || got[0] != "active" {
+		t.Errorf("InitialStates[Workspace][status] = %v, want [active]", got)
+	}
+}
+
+func TestSensitiveFields(t *testing.T) {
+	c, err := Load(writeConfig(t, t.TempDir(), `{"sensitive_fields": {"User": {"password_hash": [], "email": ["AuthenticatedUser"]}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := c.SensitiveFields["User"]["password_hash"]; !ok || len(got) != 0 {
+		t.Errorf("SensitiveFields[User][password_hash] = %v, %v; want [], true", got, ok)
+	}
+	if got := c.SensitiveFields["User"]["email"]; len(got) != 1 || got[0] != "AuthenticatedUser" {
+		t.Errorf("SensitiveFields[User][email] = %v, want [AuthenticatedUser]", got)
+	}
+}
+
+func TestNaming(t *testing.T) {
+	c, err := Load(writeConfig(t, t.TempDir(), `{"naming": {"fields": "snake_case", "triggers": "verb_noun", "surface_suffix": "View"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := Naming{Fields: CaseSnake, Triggers: TriggersVerbNoun, SurfaceSuffix: "View"}
+	if c.Naming == nil || *c.Naming != want {
+		t.Errorf("Naming = %+v, want %+v", c.Naming, want)
+	}
+}
+
+func TestSeverityOf(t *testing.T) {
+	c, err := Load(writeConfig(t, t.TempDir(), `{"severity": {"RULE-08": "warning", "WARN-16": "error", "WARN-20": "off", "WARN-04": "info", "WARN-28": "hint"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rule, want := range map[string]string{
+		"RULE-08": SeverityWarning,
+		"WARN-16": SeverityError,
+		"WARN-20": SeverityOff,
+		"WARN-04": SeverityInfo,
+		"WARN-28": SeverityHint,
+		"RULE-01": "",
+	} {
+		if got := c.SeverityOf(rule); got != want {
+			t.Errorf("SeverityOf(%s) = %q, want %q", rule, got, want)
+		}
+	}
+	var none *Config
+	if got := none.SeverityOf("WARN-16"); got != "" {
+		t.Errorf("nil config SeverityOf = %q, want none", got)
+	}
+}
+
+func TestDiscover(t *testing.T) {
+	root := t.TempDir()
+	nested := filepath.Join(root, "specs", "auth")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	spec := filepath.Join(nested, "auth.allium.json")
+
+	if got, err := Discover(spec); err != nil || got != "" {
+		t.Errorf("Discover without a config = %q, %v, want none", got, err)
+	}
+	want := writeConfig(t, root, `{}`)
+	if got, err := Discover(spec); err != nil || got != want {
+		t.Errorf("Discover = %q, %v, want %q", got, err, want)
+	}
+	// The nearest config wins.
+	nearer := writeConfig(t, filepath.Join(root, "specs"), `{}`)
+	if got, err := Discover(spec); err != nil || got != nearer {
+		t.Errorf("Discover = %q, %v, want %q", got, err, nearer)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	for _, content := range []string{
+		`{"layers": {"core": ["core/["]}}`,
+		`{"layers": {"core": ["core/**"]}, "layering": [{"from": "core", "must_not_import": ["feature"]}]}`,
+		`{"layers": {"core": ["core/**"]}, "layering": [{"from": "app", "must_not_import": ["core"]}]}`,
+		`{"critical": ["payments/["]}`,
+		`{"critcal": ["payments/**"]}`,
+		`{"critical": "payments/**"}`,
+		`{"terminal_states": {"Order": ["delivered"]}}`,
+		`{"severity": {"WARN-16": "fatal"}}`,
+		`{"severity": {"unused-binding": "off"}}`,
+		`{"naming": {"fields": "kebab-case"}}`,
+		`{"naming": {"triggers": "noun_verb"}}`,
+		`{"naming": {"surface_suffix": true}}`,
+		`not json`,
+	} {
+		if _, err := Load(writeConfig(t, t.TempDir(), content)); err == nil {
+			t.Errorf("expected error loading %s", content)
+		}
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected error for missing config file")
+	}
+}
@@ -0,0 +1,228 @@
+// Package diagram renders the structure of an Allium spec as Graphviz DOT
+// and Mermaid diagrams: the graph of entities and the references between
+// them, and the state machine of each entity's status field.
+package diagram
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/semantic"
+)
+
+// Cardinality describes how many instances of the target an entity links to.
+type Cardinality string
+
+const (
+	One      Cardinality = "one"
+	Optional Cardinality = "optional"
+	Many     Cardinality = "many"
+)
+
+// Node is an entity-like declaration in the entity graph.
+type Node struct {
+	Name     string
+	External bool // declared as an external entity
+}
+
+// Link is a directed edge of the entity graph: a relationship, a field
+// referencing another entity, or a variant's link to its base entity.
+type Link struct {
+	From        string
+	To          string
+	Label       string // relationship or field name, or "variant of"
+	Cardinality Cardinality
+	Variant     bool
+}
+
+// EntityGraph is the graph of entities, variants and external entities, and
+// the references between them.
+type EntityGraph struct {
+	Nodes []Node
+	Links []Link
+}
+
+// Entities builds the entity graph of spec. Relationships, entity_ref fields
+// (also within optional, set and list types, with aliases expanded) and
+// variant declarations each contribute a link, in declaration order.
+func Entities(spec *ast.Spec, st *semantic.SymbolTable) *EntityGraph {
+	g := &EntityGraph{}
+	for _, e := range spec.Entities {
+		g.Nodes = append(g.Nodes, Node{Name: e.Name})
+	}
+	for _, v := range spec.Variants {
+		g.Nodes = append(g.Nodes, Node{Name: v.Name})
+	}
+	for _, e := range spec.ExternalEntities {
+		g.Nodes = append(g.Nodes, Node{Name: e.Name, External: true})
+	}
+
+	for _, e := range spec.Entities {
+		for _, r := range e.Relationships {
+			card := One
+			if r.Cardinality == "many" {
+				card = Many
+			}
+			g.Links = append(g.Links, Link{From: e.Name, To: r.TargetEntity, Label: r.Name, Cardinality: card})
+		}
+		g.addReferences(e.Name, st.ResolveFields(e.Fields))
+	}
+	for _, v := range spec.Variants {
+		g.Links = append(g.Links, Link{From: v.Name, To: v.BaseEntity, Label: "variant of", Cardinality: One, Variant: true})
+		g.addReferences(v.Name, st.ResolveFields(v.Fields))
+	}
+	for _, e := range spec.ExternalEntities {
+		g.addReferences(e.Name, st.ResolveFields(e.Fields))
+	}
+	return g
+}
+
+func (g *EntityGraph) addReferences(from string, fields []ast.Field) {
+	for _, f := range fields {
+		if target, card := referencedEntity(f.Type); target != "" {
+			g.Links = append(g.Links, Link{From: from, To: target, Label: f.Name, Cardinality: card})
+		}
+	}
+}
+
+// referencedEntity returns the entity a field type refers to and how many
+// instances it holds, or "" if the type is not a reference.
+func referencedEntity(ft ast.FieldType) (string, Cardinality) {
+	switch ft.Kind {
+	case "entity_ref":
+		return ft.Entity, One
+	case "optional":
+		if ft.Inner != nil && ft.Inner.Kind == "entity_ref" {
+			return ft.Inner.Entity, Optional
+		}
+	case "set", "list":
+		if ft.Element != nil && ft.Element.Kind == "entity_ref" {
+			return ft.Element.Entity, Many
+		}
+	}
+	return "", ""
+}
+
+// DOT renders the entity graph in Graphviz DOT format. External entities are
+// dashed, references to many instances end in a crow's foot, optional ones
+// are dashed, and variants point to their base entity with an empty arrow.
+func (g *EntityGraph) DOT() string {
+	var b strings.Builder
+	b.WriteString("digraph entities {\n")
+	b.WriteString("  node [shape=box];\n")
+	for _, n := range g.Nodes {
+		if n.External {
+			fmt.Fprintf(&b, "  %s [style=dashed];\n", QuoteDOT(n.Name))
+		} else {
+			fmt.Fprintf(&b, "  %s;\n", QuoteDOT(n.Name))
+		}
+	}
+	for _, l := range g.Links {
+		var attrs string
+		switch {
+		case l.Variant:
+			attrs = "arrowhead=empty, style=dashed"
+		case l.Cardinality == Many:
+			attrs = "label=" + QuoteDOT(l.Label) + ", arrowhead=crow"
+		case l.Cardinality == Optional:
+			attrs = "label=" + QuoteDOT(l.Label) + ", style=dashed"
+		default:
+			attrs = "label=" + QuoteDOT(l.Label)
+		}
+		fmt.Fprintf(&b, "  %s -> %s [%s];\n", QuoteDOT(l.From), QuoteDOT(l.To), attrs)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// Mermaid renders the entity graph as a Mermaid erDiagram.
+func (g *EntityGraph) Mermaid() string {
+	var b strings.Builder
+	b.WriteString("erDiagram\n")
+	for _, n := range g.Nodes {
+		fmt.Fprintf(&b, "  %s\n", mermaidID(n.Name))
+	}
+	for _, l := range g.Links {
+		rel := "||--||"
+		switch l.Cardinality {
+		case Optional:
+			rel = "||--o|"
+		case Many:
+			rel = "||--o{"
+		}
+		fmt.Fprintf(&b, "  %s %s %s : %s\n", mermaidID(l.From), rel, mermaidID(l.To), quoteMermaid(l.Label))
+	}
+	return b.String()
+}
+
+// StatesDOT renders state machines in Graphviz DOT format, one cluster per
+// entity. A point marks the start, with an edge to each initial state.
+func StatesDOT(machines []semantic.StateMachine) string {
+	var b strings.Builder
+	b.WriteString("digraph states {\n")
+	b.WriteString("  node [shape=ellipse];\n")
+	for i, sm := range machines {
+		id := func(state string) string { return QuoteDOT(sm.Entity + "." + state) }
+		fmt.Fprintf(&b, "  subgraph cluster_%d {\n", i)
+		fmt.Fprintf(&b, "    label=%s;\n", QuoteDOT(sm.Entity+"."+sm.Field))
+		fmt.Fprintf(&b, "    %s [shape=point];\n", QuoteDOT(sm.Entity+".[*]"))
+		for _, s := range sm.States {
+			fmt.Fprintf(&b, "    %s [label=%s];\n", id(s), QuoteDOT(s))
+		}
+		for _, s := range sm.Initial {
+			fmt.Fprintf(&b, "    %s -> %s;\n", QuoteDOT(sm.Entity+".[*]"), id(s))
+		}
+		for _, t := range sm.Transitions {
+			fmt.Fprintf(&b, "    %s -> %s;\n", id(t.From), id(t.To))
+		}
+		b.WriteString("  }\n")
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// StatesMermaid renders state machines as a Mermaid stateDiagram-v2, one
+// composite state per entity. State identifiers are prefixed with the entity
+// name, since Mermaid shares them across composite states.
+func StatesMermaid(machines []semantic.StateMachine) string {
+	var b strings.Builder
+	b.WriteString("stateDiagram-v2\n")
+	for _, sm := range machines {
+		id := func(state string) string { return mermaidID(sm.Entity + "_" + state) }
+		fmt.Fprintf(&b, "  state %s {\n", mermaidID(sm.Entity))
+		for _, s := range sm.States {
+			fmt.Fprintf(&b, "    state %s as %s\n", quoteMermaid(s), id(s))
+		}
+		for _, s := range sm.Initial {
+			fmt.Fprintf(&b, "    [*] --> %s\n", id(s))
+		}
+		for _, t := range sm.Transitions {
+			fmt.Fprintf(&b, "    %s --> %s\n", id(t.From), id(t.To))
+		}
+		b.WriteString("  }\n")
+	}
+	return b.String()
+}
+
+// QuoteDOT quotes s as a DOT identifier.
+func QuoteDOT(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// mermaidID replaces characters Mermaid does not accept in identifiers, such
+// as the "/" of a qualified name, with underscores.
+func mermaidID(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return '_'
+	}, s)
+}
+
+// quoteMermaid quotes a Mermaid label. Mermaid has no escape for double
+// quotes, so they are replaced with single ones.
+func quoteMermaid(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, "'") + `"`
+}
@@ -0,0 +1,185 @@
+package diagram
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/semantic"
+)
+
+func graphSpec() *ast.Spec {
+	return &ast.Spec{
+		File: "shop.allium",
+		ExternalEntities: []ast.ExternalEntity{
+			{Name: "Customer"},
+		},
+		Entities: []ast.Entity{
+			{
+				Name: "Order",
+				Fields: []ast.Field{
+					{Name: "customer", Type: ast.FieldType{Kind: "entity_ref", Entity: "Customer"}},
+					{Name: "coupon", Type: ast.FieldType{Kind: "optional", Inner: &ast.FieldType{Kind: "alias", Name: "CouponRef"}}},
+					{Name: "total", Type: ast.FieldType{Kind: "primitive", Value: "Integer"}},
+				},
+				Relationships: []ast.Relationship{
+					{Name: "items", TargetEntity: "LineItem", ForeignKey: "order", Cardinality: "many"},
+				},
+			},
+			{Name: "LineItem"},
+			{Name: "Coupon"},
+			{Name: "Payment"},
+		},
+		Variants: []ast.Variant{
+			{Name: "CardPayment", BaseEntity: "Payment"},
+		},
+		TypeAliases: []ast.TypeAlias{
+			{Name: "CouponRef", Type: ast.FieldType{Kind: "entity_ref", Entity: "Coupon"}},
+		},
+	}
+}
+
+func TestEntities(t *testing.T) {
+	spec := graphSpec()
+	g := Entities(spec, semantic.BuildSymbolTable(spec))
+
+	var names []string
+	for _, n := range g.Nodes {
+		names = append(names, n.Name)
+	}
+	if got := strings.Join(names, ","); got != "Order,LineItem,Coupon,Payment,CardPayment,Customer" {
+		t.Errorf("nodes = %s", got)
+	}
+	if !g.Nodes[5].External {
+		t.Error("expected Customer to be external")
+	}
+
+	want := []Link{
+		{From: "Order", To: "LineItem", Label: "items", Cardinality: Many},
+		{From: "Order", To: "Customer", Label: "customer", Cardinality: One},
+		{From: "Order", To: "Coupon", Label: "coupon", Cardinality: Optional},
+		{From: "CardPayment", To: "Payment", Label: "variant of", Cardinality: One, Variant: true},
+	}
+	if len(g.Links) != len(want) {
+		t.Fatalf("links = %+v, want %+v", g.Links, want)
+	}
+	for i := range want {
+		if g.Links[i] != want[i] {
+			t.Errorf("link %d = %+v, want %+v", i, g.Links[i], want[i])
+		}
+	}
+}
+
+func TestEntityGraphDOT(t *testing.T) {
+	spec := graphSpec()
+	dot := Entities(spec, semantic.BuildSymbolTable(spec)).DOT()
+
+	for _, want := range []string{
+		"digraph entities {",
+		`"Customer" [style=dashed];`,
+		`"Order" -> "LineItem" [label="items", arrowhead=crow];`,
+		`"Order" -> "Customer" [label="customer"];`,
+		`"Order" -> "Coupon" [label="coupon", style=dashed];`,
+		`"CardPayment" -> "Payment" [arrowhead=empty, style=dashed];`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT output missing %q:\n%s", want, dot)
+		}
+	}
+}
+
+func TestEntityGraphMermaid(t *testing.T) {
+	spec := graphSpec()
+	out := Entities(spec, semantic.BuildSymbolTable(spec)).Mermaid()
+
+	for _, want := range []string{
+		"erDiagram\n",
+		"  LineItem\n",
+		`  Order ||--o{ LineItem : "items"`,
+		`  Order ||--|| Customer : "customer"`,
+		`  Order ||--o| Coupon : "coupon"`,
+		`  CardPayment ||--|| Payment : "variant of"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Mermaid output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func testMachines() []semantic.StateMachine {
+	return []semantic.StateMachine{{
+		Entity:  "Order",
+		Field:   "status",
+		States:  []string{"pending", "paid"},
+		Initial: []string{"pending"},
+		Transitions: []semantic.Transition{
+			{From: "pending", To: "paid"},
+		},
+	}}
+}
+
+func TestStatesDOT(t *testing.T) {
+	dot := StatesDOT(testMachines())
+
+	for _, want := range []string{
+		"subgraph cluster_0 {",
+		`label="Order.status";`,
+		`"Order.[*]" [shape=point];`,
+		`"Order.pending" [label="pending"];`,
+		`"Order.[*]" -> "Order.pending";`,
+		`"Order.pending" -> "Order.paid";`,
+	} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT output missing %q:\n%s", want, dot)
+		}
+	}
+}
+
+func TestStatesMermaid(t *testing.T) {
+	out := StatesMermaid(testMachines())
+
+	for _, want := range []string{
+		"stateDiagram-v2\n",
+		"  state Order {\n",
+		`    state "pending" as Order_pending`,
+		"    [*] --> Order_pending\n",
+		"    Order_pending --> Order_paid\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Mermaid output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestReferenceExampleDiagrams(t *testing.T) {
+	spec, err := ast.LoadSpec(filepath.Join("..", "..", "schemas", "v1", "examples", "password-auth.allium.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := semantic.BuildSymbolTable(spec)
+
+	g := Entities(spec, st)
+	if len(g.Nodes) == 0 || len(g.Links) == 0 {
+		t.Errorf("expected entities and links in the reference example, got %+v", g)
+	}
+	machines := semantic.StateMachines(spec, st)
+	if len(machines) == 0 {
+		t.Fatal("expected state machines in the reference example")
+	}
+	if out := StatesMermaid(machines); strings.Count(out, "  state ") < len(machines) {
+		t.Errorf("expected one composite state per machine:\n%s", out)
+	}
+}
+
+func TestQuoteDOT(t *testing.T) {
+	if got := QuoteDOT(`say "hi" \ bye`); got != `"say \"hi\" \\ bye"` {
+		t.Errorf("QuoteDOT = %s", got)
+	}
+}
+
+func TestMermaidID(t *testing.T) {
+	if got := mermaidID("auth/User.v2"); got != "auth_User_v2" {
+		t.Errorf("mermaidID = %s", got)
+	}
+}
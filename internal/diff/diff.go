@@ -0,0 +1,391 @@
+// Package diff compares two versions of an Allium specification at the AST
+// level. It reports the entities, fields, enumeration values, rules,
+// triggers and surfaces that were added, removed or changed, and whether
+// each change breaks consumers written against the old version.
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/foundry-zero/allium/internal/ast"
+)
+
+// Change kinds.
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// Change is one difference between two versions of a spec. Path locates
+// the change in the new spec, or a removal in the old one; Within then
+// locates what the element was removed from in the new spec.
+type Change struct {
+	Kind     string `json:"kind"`             // Added, Removed or Changed
+	Element  string `json:"element"`          // e.g. "entity", "field", "enum_value", "trigger"
+	Name     string `json:"name"`             // qualified name, e.g. "User.email"
+	Path     string `json:"path"`             // JSONPath, e.g. "$.entities[0].fields[2]"
+	Within   string `json:"within,omitempty"` // removals only, e.g. "$.entities[0]" or "$"
+	Detail   string `json:"detail,omitempty"` // e.g. "String -> Integer"
+	Breaking bool   `json:"breaking"`
+}
+
+// Description renders the change without its location, e.g.
+// "removed field User.email".
+func (c Change) Description() string {
+	s := fmt.Sprintf("%s %s %s", c.Kind, strings.ReplaceAll(c.Element, "_", " "), c.Name)
+	if c.Detail != "" {
+		s += ": " + c.Detail
+	}
+	return s
+}
+
+// String renders the change on one line, e.g.
+// "removed field User.email at $.entities[0].fields[2]".
+func (c Change) String() string {
+	return c.Description() + " at " + c.Path
+}
+
+// Breaking returns the changes that break consumers of the old version.
+func Breaking(changes []Change) []Change {
+	var breaking []Change
+	for _, c := range changes {
+		if c.Breaking {
+			breaking = append(breaking, c)
+		}
+	}
+	return breaking
+}
+
+// Compare returns the changes from old to new, in declaration order: entities
+// and other field-bearing types, enumerations, rules, then surfaces.
+// Removals are a breaking change, as are changes that narrow what consumers
+// may rely on, such as a field type other than one made optional. Additions
+// are not, except for a required trigger parameter.
+func Compare(old, new *ast.Spec) []Change {
+	d := &differ{}
+	d.types(old, new)
+	d.enumerations(old.Enumerations, new.Enumerations)
+	d.rules(old.Rules, new.Rules)
+	d.surfaces(old.Surfaces, new.Surfaces)
+	return d.changes
+}
+
+// differ accumulates the changes found by Compare.
+type differ struct {
+	changes []Change
+}
+
+func (d *differ) add(kind, element, name, path, detail string, breaking bool) {
+	d.changes = append(d.changes, Change{Kind: kind, Element: element, Name: name, Path: path, Detail: detail, Breaking: breaking})
+}
+
+// remove records a removal, which always breaks consumers, of the element
+// at path in the old spec from the element at within in the new one.
+func (d *differ) remove(element, name, path, within string) {
+	d.changes = append(d.changes, Change{Kind: Removed, Element: element, Name: name, Path: path, Within: within, Breaking: true})
+}
+
+// match pairs the elements of old and new with the same key. It calls
+// removed for each old element without a partner, both for each pair, in
+// the order of new, and added for each new element without a partner.
+func match[T any](old, new []T, key func(T) string, removed func(i int), both func(i, j int), added func(j int)) {
+	oldIndex := make(map[string]int, len(old))
+	for i, o := range old {
+		oldIndex[key(o)] = i
+	}
+	newKeys := make(map[string]bool, len(new))
+	for _, n := range new {
+		newKeys[key(n)] = true
+	}
+	for i, o := range old {
+		if !newKeys[key(o)] {
+			removed(i)
+		}
+	}
+	for j, n := range new {
+		if i, ok := oldIndex[key(n)]; ok {
+			both(i, j)
+		} else {
+			added(j)
+		}
+	}
+}
+
+// fieldSet is a declaration that has fields: an entity, external entity,
+// value type or variant.
+type fieldSet struct {
+	element string
+	name    string
+	path    string
+	fields  []ast.Field
+}
+
+// fieldSets lists the field-bearing declarations of spec.
+func fieldSets(spec *ast.Spec) []fieldSet {
+	var sets []fieldSet
+	for i, e := range spec.Entities {
+		sets = append(sets, fieldSet{"entity", e.Name, fmt.Sprintf("$.entities[%d]", i), e.Fields})
+	}
+	for i, e := range spec.ExternalEntities {
+		sets = append(sets, fieldSet{"external_entity", e.Name, fmt.Sprintf("$.external_entities[%d]", i), e.Fields})
+	}
+	for i, v := range spec.ValueTypes {
+		sets = append(sets, fieldSet{"value_type", v.Name, fmt.Sprintf("$.value_types[%d]", i), v.Fields})
+	}
+	for i, v := range spec.Variants {
+		sets = append(sets, fieldSet{"variant", v.Name, fmt.Sprintf("$.variants[%d]", i), v.Fields})
+	}
+	return sets
+}
+
+// types compares the field-bearing declarations of the two specs. A
+// declaration that changes between, say, entity and value type is reported
+// as removed and added.
+func (d *differ) types(old, new *ast.Spec) {
+	oldSets, newSets := fieldSets(old), fieldSets(new)
+	key := func(s fieldSet) string { return s.element + " " + s.name }
+	match(oldSets, newSets, key,
+		func(i int) { d.remove(oldSets[i].element, oldSets[i].name, oldSets[i].path, "$") },
+		func(i, j int) { d.fields(oldSets[i], newSets[j]) },
+		func(j int) { d.add(Added, newSets[j].element, newSets[j].name, newSets[j].path, "", false) },
+	)
+}
+
+func (d *differ) fields(old, new fieldSet) {
+	name := func(f ast.Field) string { return f.Name }
+	match(old.fields, new.fields, name,
+		func(i int) {
+			d.remove("field", old.name+"."+old.fields[i].Name, fmt.Sprintf("%s.fields[%d]", old.path, i), new.path)
+		},
+		func(i, j int) {
+			d.fieldType(new.name+"."+new.fields[j].Name,
+				fmt.Sprintf("%s.fields[%d].type", old.path, i), fmt.Sprintf("%s.fields[%d].type", new.path, j),
+				&old.fields[i].Type, &new.fields[j].Type)
+		},
+		func(j int) {
+			d.add(Added, "field", new.name+"."+new.fields[j].Name, fmt.Sprintf("%s.fields[%d]", new.path, j), "", false)
+		},
+	)
+}
+
+// fieldType compares the type of a field. Values added to or removed from
+// an inline enum are reported individually; any other change is a type
+// change, which breaks consumers unless it only makes the field optional.
+func (d *differ) fieldType(name, oldPath, newPath string, old, new *ast.FieldType) {
+	if typeString(old) == typeString(new) {
+		return
+	}
+	oldEnum, oldOptional := unwrapOptional(old)
+	newEnum, newOptional := unwrapOptional(new)
+	if oldEnum.Kind == "inline_enum" && newEnum.Kind == "inline_enum" && oldOptional == newOptional {
+		d.values("enum_value", name, oldPath, newPath, oldEnum.Values, newEnum.Values)
+		return
+	}
+	widened := new.Kind == "optional" && typeString(new.Inner) == typeString(old)
+	d.add(Changed, "field", name, newPath, typeString(old)+" -> "+typeString(new), !widened)
+}
+
+func unwrapOptional(ft *ast.FieldType) (*ast.FieldType, bool) {
+	if ft.Kind == "optional" && ft.Inner != nil {
+		return ft.Inner, true
+	}
+	return ft, false
+}
+
+// values reports the values removed from and added to a list of names, such
+// as an enumeration's values, at the list's path in each version.
+func (d *differ) values(element, name, oldPath, newPath string, old, new []string) {
+	for _, v := range old {
+		if !slices.Contains(new, v) {
+			d.remove(element, name+"."+v, oldPath, newPath)
+		}
+	}
+	for _, v := range new {
+		if !slices.Contains(old, v) {
+			d.add(Added, element, name+"."+v, newPath, "", false)
+		}
+	}
+}
+
+func (d *differ) enumerations(old, new []ast.Enumeration) {
+	name := func(e ast.Enumeration) string { return e.Name }
+	match(old, new, name,
+		func(i int) { d.remove("enum", old[i].Name, fmt.Sprintf("$.enumerations[%d]", i), "$") },
+		func(i, j int) {
+			d.values("enum_value", new[j].Name, fmt.Sprintf("$.enumerations[%d].values", i), fmt.Sprintf("$.enumerations[%d].values", j), old[i].Values, new[j].Values)
+		},
+		func(j int) { d.add(Added, "enum", new[j].Name, fmt.Sprintf("$.enumerations[%d]", j), "", false) },
+	)
+}
+
+func (d *differ) rules(old, new []ast.Rule) {
+	name := func(r ast.Rule) string { return r.Name }
+	match(old, new, name,
+		func(i int) { d.remove("rule", old[i].Name, fmt.Sprintf("$.rules[%d]", i), "$") },
+		func(i, j int) {
+			path := fmt.Sprintf("$.rules[%d]", j)
+			d.trigger(new[j].Name, fmt.Sprintf("$.rules[%d].trigger", i), path+".trigger", old[i].Trigger, new[j].Trigger)
+			if !sameJSON(ruleBody(old[i]), ruleBody(new[j])) {
+				d.add(Changed, "rule", new[j].Name, path, "requires or ensures changed", false)
+			}
+		},
+		func(j int) { d.add(Added, "rule", new[j].Name, fmt.Sprintf("$.rules[%d]", j), "", false) },
+	)
+}
+
+// ruleBody returns the rule without its name and trigger.
+func ruleBody(r ast.Rule) ast.Rule {
+	r.Name, r.Trigger = "", ast.Trigger{}
+	return r
+}
+
+// trigger compares the trigger of a rule present in both versions. Any
+// change to what fires the rule breaks consumers, as do removing a
+// parameter, adding a required one, or making one required.
+func (d *differ) trigger(rule, oldPath, path string, old, new ast.Trigger) {
+	if describeTrigger(old) != describeTrigger(new) {
+		d.add(Changed, "trigger", rule, path, describeTrigger(old)+" -> "+describeTrigger(new), true)
+		return
+	}
+	name := func(p ast.TriggerParam) string { return p.Name }
+	qualified := func(p ast.TriggerParam) string { return rule + "." + p.Name }
+	match(old.Parameters, new.Parameters, name,
+		func(i int) {
+			d.remove("trigger_parameter", qualified(old.Parameters[i]), fmt.Sprintf("%s.parameters[%d]", oldPath, i), path)
+		},
+		func(i, j int) {
+			o, n := old.Parameters[i], new.Parameters[j]
+			switch {
+			case o.Optional && !n.Optional:
+				d.add(Changed, "trigger_parameter", qualified(n), fmt.Sprintf("%s.parameters[%d]", path, j), "optional -> required", true)
+			case !o.Optional && n.Optional:
+				d.add(Changed, "trigger_parameter", qualified(n), fmt.Sprintf("%s.parameters[%d]", path, j), "required -> optional", false)
+			}
+		},
+		func(j int) {
+			p := new.Parameters[j]
+			detail := "required"
+			if p.Optional {
+				detail = "optional"
+			}
+			d.add(Added, "trigger_parameter", qualified(p), fmt.Sprintf("%s.parameters[%d]", path, j), detail, !p.Optional)
+		},
+	)
+}
+
+// describeTrigger renders what fires a rule, without its parameters.
+func describeTrigger(t ast.Trigger) string {
+	switch t.Kind {
+	case "external_stimulus", "chained":
+		return t.Kind + " " + t.Name
+	case "state_transition":
+		return fmt.Sprintf("%s %s.%s to %s", t.Kind, t.Entity, t.Field, t.ToValue)
+	case "state_becomes":
+		return fmt.Sprintf("%s %s.%s = %s", t.Kind, t.Entity, t.Field, t.Value)
+	case "derived_condition":
+		return fmt.Sprintf("%s %s.%s", t.Kind, t.Entity, t.Field)
+	case "temporal":
+		return fmt.Sprintf("%s %s %s", t.Kind, t.Entity, compactJSON(t.Condition))
+	default:
+		return t.Kind + " " + t.Entity
+	}
+}
+
+func (d *differ) surfaces(old, new []ast.Surface) {
+	name := func(s ast.Surface) string { return s.Name }
+	match(old, new, name,
+		func(i int) { d.remove("surface", old[i].Name, fmt.Sprintf("$.surfaces[%d]", i), "$") },
+		func(i, j int) {
+			d.surface(old[i], new[j], fmt.Sprintf("$.surfaces[%d]", i), fmt.Sprintf("$.surfaces[%d]", j))
+		},
+		func(j int) { d.add(Added, "surface", new[j].Name, fmt.Sprintf("$.surfaces[%d]", j), "", false) },
+	)
+}
+
+// surface compares a surface present in both versions: the party it faces,
+// the data it exposes and the actions it provides.
+func (d *differ) surface(old, new ast.Surface, oldPath, path string) {
+	if old.Facing.Type != new.Facing.Type {
+		d.add(Changed, "surface", new.Name, path+".facing", old.Facing.Type+" -> "+new.Facing.Type, true)
+	}
+	exposed := func(e ast.ExposesItem) string { return exprName(e.Expression) }
+	match(old.Exposes, new.Exposes, exposed,
+		func(i int) {
+			d.remove("exposed_item", new.Name+"."+exposed(old.Exposes[i]), fmt.Sprintf("%s.exposes[%d]", oldPath, i), path)
+		},
+		func(i, j int) {},
+		func(j int) {
+			d.add(Added, "exposed_item", new.Name+"."+exposed(new.Exposes[j]), fmt.Sprintf("%s.exposes[%d]", path, j), "", false)
+		},
+	)
+	oldActions, newActions := providedTriggers(old.Provides), providedTriggers(new.Provides)
+	d.values("provided_action", new.Name, oldPath+".provides", path+".provides", oldActions, newActions)
+}
+
+// providedTriggers returns the triggers of a surface's actions, including
+// those nested in for_each items.
+func providedTriggers(items []ast.ProvidesItem) []string {
+	var triggers []string
+	for _, p := range items {
+		if p.Trigger != "" {
+			triggers = append(triggers, p.Trigger)
+		}
+		triggers = append(triggers, providedTriggers(p.Items)...)
+	}
+	return triggers
+}
+
+// exprName renders a field access chain as a dotted path, or any other
+// expression as compact JSON.
+func exprName(e *ast.Expression) string {
+	var parts []string
+	for x := e; x != nil; x = x.Object {
+		if x.Kind != "field_access" {
+			return compactJSON(e)
+		}
+		parts = append(parts, x.Field)
+	}
+	slices.Reverse(parts)
+	return strings.Join(parts, ".")
+}
+
+// typeString renders a field type in Allium source syntax.
+func typeString(ft *ast.FieldType) string {
+	if ft == nil {
+		return "?"
+	}
+	switch ft.Kind {
+	case "primitive":
+		return ft.Value
+	case "entity_ref":
+		return ft.Entity
+	case "named_enum", "alias":
+		return ft.Name
+	case "inline_enum":
+		return strings.Join(ft.Values, " | ")
+	case "optional":
+		return typeString(ft.Inner) + "?"
+	case "set":
+		return "Set<" + typeString(ft.Element) + ">"
+	case "list":
+		return "List<" + typeString(ft.Element) + ">"
+	default:
+		return ft.Kind
+	}
+}
+
+func compactJSON(v any) string {
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+func sameJSON(a, b any) bool {
+	x, _ := json.Marshal(a)
+	y, _ := json.Marshal(b)
+	return bytes.Equal(x, y)
+}
@@ -0,0 +1,148 @@
+package diff
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/foundry-zero/allium/internal/ast"
+)
+
+var refExample = filepath.Join("..", "..", "schemas", "v1", "examples", "password-auth.allium.json")
+
+func primitive(v string) ast.FieldType { return ast.FieldType{Kind: "primitive", Value: v} }
+
+func optional(ft ast.FieldType) ast.FieldType { return ast.FieldType{Kind: "optional", Inner: &ft} }
+
+// baseSpec returns the old version used by TestCompare.
+func baseSpec() *ast.Spec {
+	return &ast.Spec{
+		Entities: []ast.Entity{{Name: "Order", Fields: []ast.Field{
+			{Name: "status", Type: ast.FieldType{Kind: "inline_enum", Values: []string{"open", "paid"}}},
+			{Name: "total", Type: primitive("Integer")},
+			{Name: "note", Type: primitive("String")},
+			{Name: "placed_at", Type: primitive("Timestamp")},
+		}}},
+		ValueTypes:   []ast.ValueType{{Name: "Money", Fields: []ast.Field{{Name: "amount", Type: primitive("Decimal")}}}},
+		Enumerations: []ast.Enumeration{{Name: "Channel", Values: []string{"web", "phone"}}},
+		Rules: []ast.Rule{
+			{Name: "PlaceOrder", Trigger: ast.Trigger{Kind: "external_stimulus", Name: "OrderPlaced",
+				Parameters: []ast.TriggerParam{{Name: "total"}, {Name: "note", Optional: true}, {Name: "channel"}}}},
+			{Name: "PayOrder", Trigger: ast.Trigger{Kind: "state_transition", Binding: "order", Entity: "Order", Field: "status", ToValue: "paid"}},
+			{Name: "Cancel", Trigger: ast.Trigger{Kind: "external_stimulus", Name: "OrderCancelled"}},
+		},
+		Surfaces: []ast.Surface{
+			{Name: "Checkout", Facing: ast.FacingClause{Binding: "customer", Type: "Customer"},
+				Exposes: []ast.ExposesItem{
+					{Expression: &ast.Expression{Kind: "field_access", Object: &ast.Expression{Kind: "field_access", Field: "order"}, Field: "total"}},
+				},
+				Provides: []ast.ProvidesItem{{Kind: "action", Trigger: "OrderPlaced"}, {Kind: "action", Trigger: "OrderCancelled"}}},
+		},
+	}
+}
+
+func TestCompare(t *testing.T) {
+	old := baseSpec()
+	new := baseSpec()
+
+	order := &new.Entities[0]
+	order.Fields[0].Type.Values = []string{"open", "paid", "refunded"} // enum value added
+	order.Fields[1].Type = primitive("Decimal")                        // type changed
+	order.Fields[2].Type = optional(primitive("String"))               // made optional
+	order.Fields = append(order.Fields[:3], ast.Field{Name: "channel", Type: ast.FieldType{Kind: "named_enum", Name: "Channel"}})
+	new.ValueTypes = nil
+	new.Variants = []ast.Variant{{Name: "Gift", BaseEntity: "Order"}}
+	new.Enumerations[0].Values = []string{"web"}
+
+	place := &new.Rules[0].Trigger
+	place.Parameters = []ast.TriggerParam{{Name: "total", Optional: true}, {Name: "note"}, {Name: "coupon", Optional: true}, {Name: "currency"}}
+	new.Rules[1].Trigger.ToValue = "refunded"
+	new.Rules[2].Requires = []ast.Expression{{Kind: "literal", Type: "boolean"}}
+	new.Rules = append(new.Rules, ast.Rule{Name: "Refund", Trigger: ast.Trigger{Kind: "external_stimulus", Name: "Refunded"}})
+
+	new.Surfaces[0].Facing.Type = "Staff"
+	new.Surfaces[0].Exposes = nil
+	new.Surfaces[0].Provides = []ast.ProvidesItem{{Kind: "for_each", Items: []ast.ProvidesItem{{Kind: "action", Trigger: "Refunded"}}}}
+
+	want := []string{
+		"removed value type Money at $.value_types[0] (breaking)",
+		"removed field Order.placed_at at $.entities[0].fields[3] (breaking)",
+		"added enum value Order.status.refunded at $.entities[0].fields[0].type",
+		"changed field Order.total: Integer -> Decimal at $.entities[0].fields[1].type (breaking)",
+		"changed field Order.note: String -> String? at $.entities[0].fields[2].type",
+		"added field Order.channel at $.entities[0].fields[3]",
+		"added variant Gift at $.variants[0]",
+		"removed enum value Channel.phone at $.enumerations[0].values (breaking)",
+		"removed trigger parameter PlaceOrder.channel at $.rules[0].trigger.parameters[2] (breaking)",
+		"changed trigger parameter PlaceOrder.total: required -> optional at $.rules[0].trigger.parameters[0]",
+		"changed trigger parameter PlaceOrder.note: optional -> required at $.rules[0].trigger.parameters[1] (breaking)",
+		"added trigger parameter PlaceOrder.coupon: optional at $.rules[0].trigger.parameters[2]",
+		"added trigger parameter PlaceOrder.currency: required at $.rules[0].trigger.parameters[3] (breaking)",
+		"changed trigger PayOrder: state_transition Order.status to paid -> state_transition Order.status to refunded at $.rules[1].trigger (breaking)",
+		"changed rule Cancel: requires or ensures changed at $.rules[2]",
+		"added rule Refund at $.rules[3]",
+		"changed surface Checkout: Customer -> Staff at $.surfaces[0].facing (breaking)",
+		"removed exposed item Checkout.order.total at $.surfaces[0].exposes[0] (breaking)",
+		"removed provided action Checkout.OrderPlaced at $.surfaces[0].provides (breaking)",
+		"removed provided action Checkout.OrderCancelled at $.surfaces[0].provides (breaking)",
+		"added provided action Checkout.Refunded at $.surfaces[0].provides",
+	}
+
+	changes := Compare(old, new)
+	var got []string
+	for _, c := range changes {
+		s := c.String()
+		if c.Breaking {
+			s += " (breaking)"
+		}
+		got = append(got, s)
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Compare:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if n := len(Breaking(changes)); n != 12 {
+		t.Errorf("Breaking = %d changes, want 12", n)
+	}
+
+	// Removals also locate what they were removed from in the new spec.
+	for i, within := range map[int]string{0: "$", 1: "$.entities[0]", 7: "$.enumerations[0].values", 8: "$.rules[0].trigger", 17: "$.surfaces[0]"} {
+		if changes[i].Within != within {
+			t.Errorf("%v: Within = %q, want %q", changes[i], changes[i].Within, within)
+		}
+	}
+}
+
+func TestCompareIdentical(t *testing.T) {
+	spec, err := ast.LoadSpec(refExample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := ast.LoadSpec(refExample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes := Compare(spec, other); len(changes) != 0 {
+		t.Errorf("expected no changes comparing the reference example with itself, got %v", changes)
+	}
+}
+
+func TestCompareRemovedDeclarations(t *testing.T) {
+	old := baseSpec()
+	changes := Compare(old, &ast.Spec{})
+	if len(changes) != 7 {
+		t.Fatalf("expected 7 removals, got %v", changes)
+	}
+	for _, c := range changes {
+		if c.Kind != Removed || !c.Breaking {
+			t.Errorf("expected a breaking removal, got %v", c)
+		}
+	}
+	if c := changes[0]; c.Element != "entity" || c.Name != "Order" || c.Path != "$.entities[0]" || c.Within != "$" {
+		t.Errorf("first change = %+v", c)
+	}
+
+	// The reverse adds everything, which breaks nothing.
+	if breaking := Breaking(Compare(&ast.Spec{}, old)); len(breaking) != 0 {
+		t.Errorf("expected additions not to break consumers, got %v", breaking)
+	}
+}
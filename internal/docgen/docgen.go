@@ -0,0 +1,265 @@
+// Package docgen generates reference documentation for an Allium
+// specification: tables of the fields of each entity and other types,
+// summaries of each rule's trigger, requires and ensures clauses, the
+// contract of each surface, and a state diagram of each status field. A
+// spec is first summarized as a Doc, which renders as Markdown or HTML.
+package docgen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/semantic"
+)
+
+// Doc is the documentation of a spec. Expressions and types are rendered in
+// Allium source syntax.
+type Doc struct {
+	Title         string
+	Description   string
+	Types         []TypeDoc
+	Enumerations  []Row // enumeration name and its values
+	Config        []Row // parameter name and "Type = default"
+	Actors        []Row // actor name and how it is identified
+	Rules         []RuleDoc
+	Surfaces      []SurfaceDoc
+	StateMachines []semantic.StateMachine
+}
+
+// Row is a named entry and its rendered description.
+type Row struct {
+	Name  string
+	Value string
+}
+
+// TypeDoc documents an entity, external entity, value type or variant.
+type TypeDoc struct {
+	Name          string
+	Kind          string // e.g. "Entity", "Variant of Account"
+	Fields        []Row  // field name and type
+	Relationships []Row  // relationship name and target, e.g. "Set<Session> via user"
+	Projections   []Row  // projection name and "source where condition"
+	DerivedValues []Row  // derived value name and expression
+}
+
+// RuleDoc summarizes a rule.
+type RuleDoc struct {
+	Name     string
+	Trigger  string
+	For      string // "binding in collection", if the rule has a for clause
+	Lets     []Row
+	Requires []string
+	Ensures  []Clause
+}
+
+// Clause is an ensures clause. Conditionals and iterations nest their
+// clauses as children.
+type Clause struct {
+	Text     string
+	Children []Clause
+}
+
+// SurfaceDoc summarizes the contract of a surface.
+type SurfaceDoc struct {
+	Name       string
+	Facing     string
+	Context    string
+	Exposes    []string
+	Provides   []string
+	Guarantees []Row
+	Guidance   []string
+	Related    []string
+	Timeouts   []string
+}
+
+// Build summarizes spec. The symbol table supplies the state machines.
+func Build(spec *ast.Spec, st *semantic.SymbolTable) *Doc {
+	d := &Doc{
+		Title:         spec.File,
+		Description:   spec.Metadata.Description,
+		StateMachines: semantic.StateMachines(spec, st),
+	}
+	if d.Title == "" {
+		d.Title = "Specification"
+	}
+
+	for _, e := range spec.Entities {
+		t := TypeDoc{Name: e.Name, Kind: "Entity", Fields: fieldRows(e.Fields)}
+		for _, r := range e.Relationships {
+			target := r.TargetEntity
+			if r.Cardinality == "many" {
+				target = "Set<" + target + ">"
+			}
+			t.Relationships = append(t.Relationships, Row{r.Name, target + " via " + r.ForeignKey})
+		}
+		for _, p := range e.Projections {
+			value := p.Source
+			if p.Condition != nil {
+				value += " where " + ExprSource(p.Condition)
+			}
+			t.Projections = append(t.Projections, Row{p.Name, value})
+		}
+		t.DerivedValues = derivedRows(e.DerivedValues)
+		d.Types = append(d.Types, t)
+	}
+	for _, v := range spec.Variants {
+		d.Types = append(d.Types, TypeDoc{Name: v.Name, Kind: "Variant of " + v.BaseEntity, Fields: fieldRows(v.Fields)})
+	}
+	for _, e := range spec.ExternalEntities {
+		d.Types = append(d.Types, TypeDoc{Name: e.Name, Kind: "External entity", Fields: fieldRows(e.Fields)})
+	}
+	for _, v := range spec.ValueTypes {
+		d.Types = append(d.Types, TypeDoc{Name: v.Name, Kind: "Value type", Fields: fieldRows(v.Fields), DerivedValues: derivedRows(v.DerivedValues)})
+	}
+
+	for _, e := range spec.Enumerations {
+		d.Enumerations = append(d.Enumerations, Row{e.Name, strings.Join(e.Values, " | ")})
+	}
+	for _, c := range spec.Config {
+		value := typeSource(&c.Type)
+		if c.DefaultValue != nil {
+			value += " = " + ExprSource(c.DefaultValue)
+		}
+		d.Config = append(d.Config, Row{c.Name, value})
+	}
+	for _, a := range spec.Actors {
+		value := a.IdentifiedBy.Entity
+		if a.IdentifiedBy.Condition != nil {
+			value += " where " + ExprSource(a.IdentifiedBy.Condition)
+		}
+		if a.Within != "" {
+			value += " within " + a.Within
+		}
+		d.Actors = append(d.Actors, Row{a.Name, value})
+	}
+
+	for _, r := range spec.Rules {
+		d.Rules = append(d.Rules, ruleDoc(r))
+	}
+	for _, s := range spec.Surfaces {
+		d.Surfaces = append(d.Surfaces, surfaceDoc(s))
+	}
+	return d
+}
+
+func fieldRows(fields []ast.Field) []Row {
+	rows := make([]Row, len(fields))
+	for i, f := range fields {
+		rows[i] = Row{f.Name, typeSource(&f.Type)}
+	}
+	return rows
+}
+
+func derivedRows(dvs []ast.DerivedValue) []Row {
+	var rows []Row
+	for _, dv := range dvs {
+		name := dv.Name
+		if len(dv.Parameters) > 0 {
+			name += "(" + strings.Join(dv.Parameters, ", ") + ")"
+		}
+		rows = append(rows, Row{name, ExprSource(dv.Expression)})
+	}
+	return rows
+}
+
+func ruleDoc(r ast.Rule) RuleDoc {
+	doc := RuleDoc{Name: r.Name, Trigger: triggerSource(r.Trigger)}
+	if fc := r.ForClause; fc != nil {
+		doc.For = fc.Binding + " in " + ExprSource(fc.Collection)
+		if fc.Condition != nil {
+			doc.For += " where " + ExprSource(fc.Condition)
+		}
+	}
+	for _, lb := range r.LetBindings {
+		doc.Lets = append(doc.Lets, Row{lb.Name, ExprSource(lb.Expression)})
+	}
+	for i := range r.Requires {
+		doc.Requires = append(doc.Requires, ExprSource(&r.Requires[i]))
+	}
+	doc.Ensures = clauses(r.Ensures)
+	return doc
+}
+
+// clauses summarizes ensures clauses.
+func clauses(ecs []ast.EnsuresClause) []Clause {
+	var out []Clause
+	for _, ec := range ecs {
+		switch ec.Kind {
+		case "state_change":
+			out = append(out, Clause{Text: ExprSource(ec.Target) + " = " + valueSource(ec.Value)})
+		case "entity_creation":
+			out = append(out, Clause{Text: ec.Entity + ".created(" + namedSource(ec.Fields) + ")"})
+		case "trigger_emission":
+			out = append(out, Clause{Text: ec.Name + "(" + namedSource(ec.Arguments) + ")"})
+		case "entity_removal":
+			out = append(out, Clause{Text: "remove " + ExprSource(ec.Target)})
+		case "set_mutation":
+			out = append(out, Clause{Text: fmt.Sprintf("%s.%s(%s)", ExprSource(ec.Target), ec.Operation, valueSource(ec.Value))})
+		case "let_binding":
+			out = append(out, Clause{Text: "let " + ec.Name + " = " + valueSource(ec.Value)})
+		case "conditional":
+			out = append(out, Clause{Text: "if " + ExprSource(ec.Condition) + ":", Children: clauses(ec.Then)})
+			if len(ec.Else) > 0 {
+				out = append(out, Clause{Text: "else:", Children: clauses(ec.Else)})
+			}
+		case "iteration":
+			out = append(out, Clause{Text: "for " + ec.Binding + " in " + ExprSource(ec.Collection) + ":", Children: clauses(ec.Body)})
+		default:
+			out = append(out, Clause{Text: ec.Kind})
+		}
+	}
+	return out
+}
+
+func surfaceDoc(s ast.Surface) SurfaceDoc {
+	doc := SurfaceDoc{Name: s.Name, Facing: s.Facing.Binding + ": " + s.Facing.Type, Guidance: s.Guidance}
+	if c := s.Context; c != nil {
+		doc.Context = c.Binding + ": " + c.Type
+		if c.Condition != nil {
+			doc.Context += " where " + ExprSource(c.Condition)
+		}
+	}
+	for _, e := range s.Exposes {
+		doc.Exposes = append(doc.Exposes, withWhen(ExprSource(e.Expression), e.When))
+	}
+	doc.Provides = provides(s.Provides, "")
+	for _, g := range s.Guarantees {
+		doc.Guarantees = append(doc.Guarantees, Row{g.Name, g.Description})
+	}
+	for _, r := range s.Related {
+		doc.Related = append(doc.Related, withWhen(r.Surface+"("+ExprSource(r.ContextExpression)+")", r.When))
+	}
+	for _, t := range s.Timeout {
+		doc.Timeouts = append(doc.Timeouts, withWhen(t.Rule, t.When))
+	}
+	return doc
+}
+
+// provides summarizes provided actions, prefixing those inside for_each
+// items with their iteration.
+func provides(items []ast.ProvidesItem, prefix string) []string {
+	var out []string
+	for _, p := range items {
+		if p.Kind == "for_each" {
+			out = append(out, provides(p.Items, prefix+"for "+p.Binding+" in "+ExprSource(p.Collection)+": ")...)
+			continue
+		}
+		args := make([]string, len(p.Arguments))
+		for i, a := range p.Arguments {
+			args[i] = a.Name
+			if a.Expression != nil {
+				args[i] += ": " + ExprSource(a.Expression)
+			}
+		}
+		out = append(out, prefix+withWhen(p.Trigger+"("+strings.Join(args, ", ")+")", p.When))
+	}
+	return out
+}
+
+func withWhen(s string, when *ast.Expression) string {
+	if when == nil {
+		return s
+	}
+	return s + " when " + ExprSource(when)
+}
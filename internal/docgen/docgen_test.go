@@ -0,0 +1,157 @@
+package docgen
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/semantic"
+)
+
+var refExample = filepath.Join("..", "..", "schemas", "v1", "examples", "password-auth.allium.json")
+
+func field(names ...string) *ast.Expression {
+	var e *ast.Expression
+	for _, n := range names {
+		e = &ast.Expression{Kind: "field_access", Object: e, Field: n}
+	}
+	return e
+}
+
+func literal(typ string, v any) *ast.Expression {
+	raw, _ := json.Marshal(v)
+	return &ast.Expression{Kind: "literal", Type: typ, LitValue: raw}
+}
+
+func TestExprSource(t *testing.T) {
+	tests := []struct {
+		expr *ast.Expression
+		want string
+	}{
+		{field("user", "email"), "user.email"},
+		{literal("string", "a \"b\""), `"a \"b\""`},
+		{literal("duration", "15.minutes"), "15.minutes"},
+		{literal("integer", 3), "3"},
+		{literal("null", nil), "null"},
+		{&ast.Expression{Kind: "boolean_logic", Operator: "or",
+			Left:  &ast.Expression{Kind: "comparison", Operator: "=", Left: field("status"), Right: literal("enum_value", "open")},
+			Right: &ast.Expression{Kind: "not", Operand: field("closed")}},
+			"(status = open) or (not closed)"},
+		{&ast.Expression{Kind: "arithmetic", Operator: "+", Left: literal("timestamp", "now"), Right: field("config", "ttl")}, "now + config.ttl"},
+		{&ast.Expression{Kind: "function_call", FuncName: "length", FuncArguments: []ast.Expression{*field("password")}}, "length(password)"},
+		{&ast.Expression{Kind: "collection_op", Operation: "count", Collection: field("user", "sessions")}, "user.sessions.count"},
+		{&ast.Expression{Kind: "collection_op", Operation: "all", Collection: field("items"),
+			Lambda: &ast.Expression{Kind: "lambda", Parameter: "i", Body: field("i", "paid")}}, "items.all(i => i.paid)"},
+		{&ast.Expression{Kind: "membership", Element: field("status"),
+			Collection: &ast.Expression{Kind: "set_literal", Elements: []ast.Expression{*literal("enum_value", "a"), *literal("enum_value", "b")}}},
+			"status in {a, b}"},
+		{&ast.Expression{Kind: "join_lookup", Entity: "User", Fields: map[string]ast.Expression{"tenant": *field("t"), "email": *field("e")}},
+			"User{email: e, tenant: t}"},
+		{&ast.Expression{Kind: "null_coalesce", Left: field("a"), Right: literal("integer", 0)}, "a ?? 0"},
+	}
+	for _, tt := range tests {
+		if got := ExprSource(tt.expr); got != tt.want {
+			t.Errorf("ExprSource = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestTriggerSource(t *testing.T) {
+	tests := []struct {
+		trigger ast.Trigger
+		want    string
+	}{
+		{ast.Trigger{Kind: "external_stimulus", Name: "OrderPlaced", Parameters: []ast.TriggerParam{{Name: "order"}, {Name: "note", Optional: true}}}, "OrderPlaced(order, note?)"},
+		{ast.Trigger{Kind: "state_transition", Binding: "order", Entity: "Order", Field: "status", ToValue: "paid"}, "order: Order.status transitions_to paid"},
+		{ast.Trigger{Kind: "state_becomes", Binding: "order", Entity: "Order", Field: "status", Value: "open"}, "order: Order.status becomes open"},
+		{ast.Trigger{Kind: "derived_condition", Binding: "order", Entity: "Order", Field: "is_late"}, "order: Order.is_late"},
+		{ast.Trigger{Kind: "entity_creation", Binding: "order", Entity: "Order"}, "order: Order.created"},
+		{ast.Trigger{Kind: "temporal", Binding: "order", Entity: "Order",
+			Condition: &ast.Expression{Kind: "comparison", Operator: "<=", Left: field("order", "due_at"), Right: literal("timestamp", "now")}},
+			"order: Order where order.due_at <= now"},
+	}
+	for _, tt := range tests {
+		if got := triggerSource(tt.trigger); got != tt.want {
+			t.Errorf("triggerSource(%s) = %q, want %q", tt.trigger.Kind, got, tt.want)
+		}
+	}
+}
+
+func referenceDoc(t *testing.T) *Doc {
+	t.Helper()
+	spec, err := ast.LoadSpec(refExample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Build(spec, semantic.BuildSymbolTable(spec))
+}
+
+func TestBuild(t *testing.T) {
+	d := referenceDoc(t)
+	if d.Title != "password-auth.allium" || d.Description != "Password authentication with reset flow" {
+		t.Errorf("unexpected title %q and description %q", d.Title, d.Description)
+	}
+	if len(d.Types) != 7 || d.Types[0].Name != "User" || d.Types[3].Kind != "External entity" || d.Types[6].Kind != "Value type" {
+		t.Errorf("unexpected types %+v", d.Types)
+	}
+	if len(d.Rules) != 18 || len(d.Surfaces) != 3 || len(d.StateMachines) != 3 {
+		t.Errorf("got %d rules, %d surfaces, %d state machines", len(d.Rules), len(d.Surfaces), len(d.StateMachines))
+	}
+
+	var failure RuleDoc
+	for _, r := range d.Rules {
+		if r.Name == "LoginFailure" {
+			failure = r
+		}
+	}
+	if failure.Trigger != "UserLogsIn(email, password)" || len(failure.Requires) != 3 || failure.Lets[0] != (Row{"user", "User{email: email}"}) {
+		t.Errorf("unexpected LoginFailure summary %+v", failure)
+	}
+	if len(failure.Ensures) != 2 || failure.Ensures[1].Text != "if user.failed_login_attempts >= config.max_login_attempts:" || len(failure.Ensures[1].Children) != 4 {
+		t.Errorf("unexpected LoginFailure ensures %+v", failure.Ensures)
+	}
+
+	account := d.Surfaces[2]
+	if account.Facing != "user: AuthenticatedUser" || account.Provides[0] != "for session in user.active_sessions: UserLogsOut(session)" {
+		t.Errorf("unexpected AccountManagement summary %+v", account)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	md := referenceDoc(t).Markdown()
+	for _, want := range []string{
+		"# password-auth.allium\n\nPassword authentication with reset flow\n",
+		"| `status` | `active \\| locked \\| deactivated` |\n",
+		"### LoginFailure\n\n**When:** `UserLogsIn(email, password)`\n",
+		"- `if user.failed_login_attempts >= config.max_login_attempts:`\n  - `user.status = locked`\n",
+		"- `NoSessionRequired`: Accessible without an existing session.\n",
+		"### User.status\n\n```mermaid\nstateDiagram-v2\n",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown does not contain %q", want)
+		}
+	}
+}
+
+func TestHTML(t *testing.T) {
+	page := referenceDoc(t).HTML()
+	for _, want := range []string{
+		"<title>password-auth.allium</title>",
+		"<tr><td><code>status</code></td><td><code>active | locked | deactivated</code></td></tr>\n",
+		"<li><code>length(password) &gt;= config.min_password_length</code></li>\n",
+		"<li><code>if user.failed_login_attempts &gt;= config.max_login_attempts:</code>\n<ul>\n<li><code>user.status = locked</code></li>\n",
+		"<pre class=\"mermaid\">\nstateDiagram-v2\n",
+		"mermaid.initialize",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("HTML does not contain %q", want)
+		}
+	}
+
+	// Without state machines the page needs no script.
+	if page := (&Doc{Title: "Empty"}).HTML(); strings.Contains(page, "<script") {
+		t.Errorf("unexpected script in page without diagrams:\n%s", page)
+	}
+}
@@ -0,0 +1,317 @@
+package docgen
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/foundry-zero/allium/internal/diagram"
+	"github.com/foundry-zero/allium/internal/semantic"
+)
+
+// writer is an output format. Text passed as code is Allium source and is
+// set in a code font; other text is prose.
+type writer interface {
+	heading(level int, text string)
+	paragraph(text string)
+	label(name, code string)             // a labelled line, e.g. "When: UserLogsIn(email)"
+	table(headers [2]string, rows []Row) // names and values, both as code
+	list(name string, items []string)    // a labelled list of code items
+	rows(name string, rows []Row)        // a labelled list of "name: value" items, both as code
+	notes(name string, rows []Row)       // a labelled list of "name: value" items, the value as prose
+	prose(name string, items []string)   // a labelled list of prose items
+	clauses(name string, cs []Clause)    // a labelled nested list of code items
+	mermaid(src string)
+}
+
+// render writes the sections of d that have content.
+func (d *Doc) render(w writer) {
+	w.heading(1, d.Title)
+	if d.Description != "" {
+		w.paragraph(d.Description)
+	}
+
+	if len(d.Types) > 0 {
+		w.heading(2, "Types")
+		for _, t := range d.Types {
+			w.heading(3, t.Name)
+			w.paragraph(t.Kind)
+			if len(t.Fields) > 0 {
+				w.table([2]string{"Field", "Type"}, t.Fields)
+			}
+			w.rows("Relationships", t.Relationships)
+			w.rows("Projections", t.Projections)
+			w.rows("Derived values", t.DerivedValues)
+		}
+	}
+	if len(d.Enumerations) > 0 {
+		w.heading(2, "Enumerations")
+		w.table([2]string{"Enumeration", "Values"}, d.Enumerations)
+	}
+	if len(d.Config) > 0 {
+		w.heading(2, "Configuration")
+		w.table([2]string{"Parameter", "Type and default"}, d.Config)
+	}
+	if len(d.Actors) > 0 {
+		w.heading(2, "Actors")
+		w.table([2]string{"Actor", "Identified by"}, d.Actors)
+	}
+
+	if len(d.Rules) > 0 {
+		w.heading(2, "Rules")
+		for _, r := range d.Rules {
+			w.heading(3, r.Name)
+			w.label("When", r.Trigger)
+			if r.For != "" {
+				w.label("For", r.For)
+			}
+			w.rows("Let", r.Lets)
+			w.list("Requires", r.Requires)
+			w.clauses("Ensures", r.Ensures)
+		}
+	}
+
+	if len(d.Surfaces) > 0 {
+		w.heading(2, "Surfaces")
+		for _, s := range d.Surfaces {
+			w.heading(3, s.Name)
+			w.label("Facing", s.Facing)
+			if s.Context != "" {
+				w.label("Context", s.Context)
+			}
+			w.list("Exposes", s.Exposes)
+			w.list("Provides", s.Provides)
+			w.notes("Guarantees", s.Guarantees)
+			w.list("Related", s.Related)
+			w.list("Timeouts", s.Timeouts)
+			w.prose("Guidance", s.Guidance)
+		}
+	}
+
+	if len(d.StateMachines) > 0 {
+		w.heading(2, "State machines")
+		for _, sm := range d.StateMachines {
+			w.heading(3, sm.Entity+"."+sm.Field)
+			w.mermaid(diagram.StatesMermaid([]semantic.StateMachine{sm}))
+		}
+	}
+}
+
+// Markdown renders the documentation as GitHub-flavored Markdown, with state
+// diagrams in mermaid code blocks.
+func (d *Doc) Markdown() string {
+	var m markdown
+	d.render(&m)
+	return m.String()
+}
+
+// HTML renders the documentation as a standalone HTML page. State diagrams
+// are Mermaid sources that the page renders with Mermaid from a CDN.
+func (d *Doc) HTML() string {
+	h := &htmlWriter{}
+	d.render(h)
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&b, "<title>%s</title>\n", html.EscapeString(d.Title))
+	b.WriteString("<style>\nbody { font-family: sans-serif; max-width: 60em; margin: 2em auto; }\n" +
+		"table { border-collapse: collapse; }\nth, td { border: 1px solid #ccc; padding: 0.2em 0.6em; text-align: left; }\n</style>\n")
+	b.WriteString("</head>\n<body>\n")
+	b.WriteString(h.String())
+	if len(d.StateMachines) > 0 {
+		b.WriteString("<script type=\"module\">\nimport mermaid from \"https://cdn.jsdelivr.net/npm/mermaid@11/dist/mermaid.esm.min.mjs\";\nmermaid.initialize({ startOnLoad: true });\n</script>\n")
+	}
+	b.WriteString("</body>\n</html>\n")
+	return b.String()
+}
+
+// markdown writes Markdown, separating blocks with blank lines.
+type markdown struct {
+	strings.Builder
+}
+
+func (m *markdown) block(s string) {
+	if m.Len() > 0 {
+		m.WriteString("\n")
+	}
+	m.WriteString(s)
+}
+
+func mdCode(s string) string { return "`" + s + "`" }
+
+// mdCell sets s as inline code in a table cell, where pipes must be escaped.
+func mdCell(s string) string { return mdCode(strings.ReplaceAll(s, "|", `\|`)) }
+
+func (m *markdown) heading(level int, text string) {
+	m.block(strings.Repeat("#", level) + " " + text + "\n")
+}
+
+func (m *markdown) paragraph(text string) { m.block(text + "\n") }
+
+func (m *markdown) label(name, code string) { m.block(fmt.Sprintf("**%s:** %s\n", name, mdCode(code))) }
+
+func (m *markdown) table(headers [2]string, rows []Row) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "| %s | %s |\n|---|---|\n", headers[0], headers[1])
+	for _, r := range rows {
+		fmt.Fprintf(&b, "| %s | %s |\n", mdCell(r.Name), mdCell(r.Value))
+	}
+	m.block(b.String())
+}
+
+func (m *markdown) list(name string, items []string) {
+	code := make([]string, len(items))
+	for i, it := range items {
+		code[i] = mdCode(it)
+	}
+	m.items(name, code)
+}
+
+func (m *markdown) rows(name string, rows []Row) {
+	items := make([]string, len(rows))
+	for i, r := range rows {
+		items[i] = mdCode(r.Name) + ": " + mdCode(r.Value)
+	}
+	m.items(name, items)
+}
+
+func (m *markdown) notes(name string, rows []Row) {
+	items := make([]string, len(rows))
+	for i, r := range rows {
+		items[i] = mdCode(r.Name)
+		if r.Value != "" {
+			items[i] += ": " + r.Value
+		}
+	}
+	m.items(name, items)
+}
+
+func (m *markdown) prose(name string, items []string) { m.items(name, items) }
+
+// items writes a labelled list of items already in Markdown.
+func (m *markdown) items(name string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "**%s:**\n\n", name)
+	for _, it := range items {
+		fmt.Fprintf(&b, "- %s\n", it)
+	}
+	m.block(b.String())
+}
+
+func (m *markdown) clauses(name string, cs []Clause) {
+	if len(cs) == 0 {
+		return
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "**%s:**\n\n", name)
+	var walk func(cs []Clause, indent string)
+	walk = func(cs []Clause, indent string) {
+		for _, c := range cs {
+			fmt.Fprintf(&b, "%s- %s\n", indent, mdCode(c.Text))
+			walk(c.Children, indent+"  ")
+		}
+	}
+	walk(cs, "")
+	m.block(b.String())
+}
+
+func (m *markdown) mermaid(src string) { m.block("```mermaid\n" + src + "```\n") }
+
+// htmlWriter writes the body of an HTML page.
+type htmlWriter struct {
+	strings.Builder
+}
+
+func htmlCode(s string) string { return "<code>" + html.EscapeString(s) + "</code>" }
+
+func (h *htmlWriter) heading(level int, text string) {
+	fmt.Fprintf(h, "<h%d>%s</h%d>\n", level, html.EscapeString(text), level)
+}
+
+func (h *htmlWriter) paragraph(text string) { fmt.Fprintf(h, "<p>%s</p>\n", html.EscapeString(text)) }
+
+func (h *htmlWriter) label(name, code string) {
+	fmt.Fprintf(h, "<p><strong>%s:</strong> %s</p>\n", html.EscapeString(name), htmlCode(code))
+}
+
+func (h *htmlWriter) table(headers [2]string, rows []Row) {
+	fmt.Fprintf(h, "<table>\n<tr><th>%s</th><th>%s</th></tr>\n", html.EscapeString(headers[0]), html.EscapeString(headers[1]))
+	for _, r := range rows {
+		fmt.Fprintf(h, "<tr><td>%s</td><td>%s</td></tr>\n", htmlCode(r.Name), htmlCode(r.Value))
+	}
+	h.WriteString("</table>\n")
+}
+
+func (h *htmlWriter) list(name string, items []string) {
+	code := make([]string, len(items))
+	for i, it := range items {
+		code[i] = htmlCode(it)
+	}
+	h.items(name, code)
+}
+
+func (h *htmlWriter) rows(name string, rows []Row) {
+	items := make([]string, len(rows))
+	for i, r := range rows {
+		items[i] = htmlCode(r.Name) + ": " + htmlCode(r.Value)
+	}
+	h.items(name, items)
+}
+
+func (h *htmlWriter) notes(name string, rows []Row) {
+	items := make([]string, len(rows))
+	for i, r := range rows {
+		items[i] = htmlCode(r.Name)
+		if r.Value != "" {
+			items[i] += ": " + html.EscapeString(r.Value)
+		}
+	}
+	h.items(name, items)
+}
+
+func (h *htmlWriter) prose(name string, items []string) {
+	escaped := make([]string, len(items))
+	for i, it := range items {
+		escaped[i] = html.EscapeString(it)
+	}
+	h.items(name, escaped)
+}
+
+// items writes a labelled list of items already in HTML.
+func (h *htmlWriter) items(name string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Fprintf(h, "<p><strong>%s:</strong></p>\n<ul>\n", html.EscapeString(name))
+	for _, it := range items {
+		fmt.Fprintf(h, "<li>%s</li>\n", it)
+	}
+	h.WriteString("</ul>\n")
+}
+
+func (h *htmlWriter) clauses(name string, cs []Clause) {
+	if len(cs) == 0 {
+		return
+	}
+	fmt.Fprintf(h, "<p><strong>%s:</strong></p>\n", html.EscapeString(name))
+	var walk func(cs []Clause)
+	walk = func(cs []Clause) {
+		h.WriteString("<ul>\n")
+		for _, c := range cs {
+			fmt.Fprintf(h, "<li>%s", htmlCode(c.Text))
+			if len(c.Children) > 0 {
+				h.WriteString("\n")
+				walk(c.Children)
+			}
+			h.WriteString("</li>\n")
+		}
+		h.WriteString("</ul>\n")
+	}
+	walk(cs)
+}
+
+func (h *htmlWriter) mermaid(src string) {
+	fmt.Fprintf(h, "<pre class=\"mermaid\">\n%s</pre>\n", html.EscapeString(src))
+}
@@ -0,0 +1,177 @@
+package docgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/foundry-zero/allium/internal/ast"
+)
+
+// typeSource renders a field type in Allium source syntax.
+func typeSource(ft *ast.FieldType) string {
+	if ft == nil {
+		return "?"
+	}
+	switch ft.Kind {
+	case "primitive":
+		return ft.Value
+	case "entity_ref":
+		return ft.Entity
+	case "named_enum", "alias":
+		return ft.Name
+	case "inline_enum":
+		return strings.Join(ft.Values, " | ")
+	case "optional":
+		return typeSource(ft.Inner) + "?"
+	case "set":
+		return "Set<" + typeSource(ft.Element) + ">"
+	case "list":
+		return "List<" + typeSource(ft.Element) + ">"
+	default:
+		return ft.Kind
+	}
+}
+
+// ExprSource renders an expression in Allium source syntax. Operands that
+// are themselves operations are parenthesized.
+func ExprSource(e *ast.Expression) string {
+	if e == nil {
+		return ""
+	}
+	switch e.Kind {
+	case "field_access":
+		if e.Object == nil {
+			return e.Field
+		}
+		return ExprSource(e.Object) + "." + e.Field
+	case "literal":
+		return literalSource(e)
+	case "comparison", "arithmetic", "boolean_logic":
+		return operandSource(e.Left) + " " + e.Operator + " " + operandSource(e.Right)
+	case "null_coalesce":
+		return operandSource(e.Left) + " ?? " + operandSource(e.Right)
+	case "not":
+		return "not " + operandSource(e.Operand)
+	case "exists":
+		return "exists " + operandSource(e.Target)
+	case "function_call":
+		args := make([]string, len(e.FuncArguments))
+		for i := range e.FuncArguments {
+			args[i] = ExprSource(&e.FuncArguments[i])
+		}
+		return e.FuncName + "(" + strings.Join(args, ", ") + ")"
+	case "collection_op":
+		s := operandSource(e.Collection) + "." + e.Operation
+		switch {
+		case e.Lambda != nil:
+			s += "(" + ExprSource(e.Lambda) + ")"
+		case e.Condition != nil:
+			s += "(" + ExprSource(e.Condition) + ")"
+		}
+		return s
+	case "lambda":
+		return e.Parameter + " => " + ExprSource(e.Body)
+	case "set_literal":
+		elems := make([]string, len(e.Elements))
+		for i := range e.Elements {
+			elems[i] = ExprSource(&e.Elements[i])
+		}
+		return "{" + strings.Join(elems, ", ") + "}"
+	case "membership":
+		return operandSource(e.Element) + " in " + operandSource(e.Collection)
+	case "join_lookup":
+		return e.Entity + "{" + namedSource(e.Fields) + "}"
+	default:
+		return e.Kind
+	}
+}
+
+// operandSource renders an operand of an operation, parenthesizing it if it
+// is an operation too.
+func operandSource(e *ast.Expression) string {
+	if e == nil {
+		return ""
+	}
+	switch e.Kind {
+	case "comparison", "arithmetic", "boolean_logic", "null_coalesce", "membership", "not", "exists":
+		return "(" + ExprSource(e) + ")"
+	}
+	return ExprSource(e)
+}
+
+// literalSource renders a literal: strings are quoted, and other values,
+// such as enum values, timestamps and durations, appear as written.
+func literalSource(e *ast.Expression) string {
+	if e.Type == "null" || len(e.LitValue) == 0 {
+		return "null"
+	}
+	if e.Type == "string" {
+		return string(e.LitValue)
+	}
+	var s string
+	if json.Unmarshal(e.LitValue, &s) == nil {
+		return s
+	}
+	return string(e.LitValue)
+}
+
+// namedSource renders named arguments or fields as "name: value", sorted by
+// name.
+func namedSource(fields map[string]ast.Expression) string {
+	var parts []string
+	for _, name := range slices.Sorted(maps.Keys(fields)) {
+		v := fields[name]
+		parts = append(parts, name+": "+ExprSource(&v))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// paramsSource renders trigger parameters, marking optional ones with "?".
+func paramsSource(params []ast.TriggerParam) string {
+	names := make([]string, len(params))
+	for i, p := range params {
+		names[i] = p.Name
+		if p.Optional {
+			names[i] += "?"
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// triggerSource renders what fires a rule.
+func triggerSource(t ast.Trigger) string {
+	bound := t.Binding + ": " + t.Entity
+	switch t.Kind {
+	case "external_stimulus", "chained":
+		return t.Name + "(" + paramsSource(t.Parameters) + ")"
+	case "state_transition":
+		return fmt.Sprintf("%s.%s transitions_to %s", bound, t.Field, t.ToValue)
+	case "state_becomes":
+		return fmt.Sprintf("%s.%s becomes %s", bound, t.Field, t.Value)
+	case "derived_condition":
+		return bound + "." + t.Field
+	case "entity_creation":
+		return bound + ".created"
+	case "temporal":
+		return bound + " where " + ExprSource(t.Condition)
+	default:
+		return t.Kind
+	}
+}
+
+// valueSource renders the value of a state change or let binding, which is
+// either an expression or, for a let binding, an entity creation.
+func valueSource(raw json.RawMessage) string {
+	var ec ast.EnsuresClause
+	if json.Unmarshal(raw, &ec) == nil && ec.Kind == "entity_creation" {
+		return ec.Entity + ".created(" + namedSource(ec.Fields) + ")"
+	}
+	var e ast.Expression
+	if json.Unmarshal(raw, &e) != nil {
+		return string(raw)
+	}
+	return ExprSource(&e)
+}
@@ -0,0 +1,185 @@
+package fix
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// Diff returns a unified diff turning a into b, with the file names from and
+// to in its header, or "" if they are equal.
+func Diff(from, to string, a, b []byte) string {
+	if string(a) == string(b) {
+		return ""
+	}
+	ops := diffLines(splitLines(string(a)), splitLines(string(b)))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", from, to)
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		// A hunk runs from the context before this change to the context
+		// after the last change within twice the context of the previous.
+		start := max(0, i-diffContext)
+		end := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind == ' ' {
+				if j-end >= 2*diffContext {
+					break
+				}
+				continue
+			}
+			end = j + 1
+		}
+		end = min(len(ops), end+diffContext)
+		writeHunk(&out, ops[start:end])
+		i = end
+	}
+	return out.String()
+}
+
+// lineOp is a line of a diff: kept (' '), removed ('-') or added ('+'). aLine
+// and bLine count the lines of a and b before it.
+type lineOp struct {
+	kind         byte
+	text         string
+	aLine, bLine int
+}
+
+func writeHunk(out *strings.Builder, ops []lineOp) {
+	aStart, bStart := ops[0].aLine+1, ops[0].bLine+1
+	aCount, bCount := 0, 0
+	for _, op := range ops {
+		if op.kind != '+' {
+			aCount++
+		}
+		if op.kind != '-' {
+			bCount++
+		}
+	}
+	// An empty range is numbered by the line before it.
+	if aCount == 0 {
+		aStart--
+	}
+	if bCount == 0 {
+		bStart--
+	}
+	fmt.Fprintf(out, "@@ -%d,%d +%d,%d @@\n", aStart, aCount, bStart, bCount)
+	for _, op := range ops {
+		out.WriteByte(op.kind)
+		out.WriteString(op.text)
+		if !strings.HasSuffix(op.text, "\n") {
+			out.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// splitLines splits s into lines, each keeping its newline.
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns a shortest edit script turning a into b, using Myers'
+// algorithm on the lines between their common prefix and suffix.
+func diffLines(a, b []string) []lineOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []lineOp
+	for i := range prefix {
+		ops = append(ops, lineOp{' ', a[i], i, i})
+	}
+	for _, op := range myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]) {
+		op.aLine += prefix
+		op.bLine += prefix
+		ops = append(ops, op)
+	}
+	for i := range suffix {
+		ai, bi := len(a)-suffix+i, len(b)-suffix+i
+		ops = append(ops, lineOp{' ', a[ai], ai, bi})
+	}
+	return ops
+}
+
+// myers returns a shortest edit script turning a into b.
+func myers(a, b []string) []lineOp {
+	n, m := len(a), len(b)
+	offset := n + m
+	// v[offset+k] is the furthest x reached on diagonal k; trace keeps v as
+	// it was before each round d, for walking the path back.
+	v := make([]int, 2*(n+m)+2)
+	var trace [][]int
+	for d := 0; d <= n+m; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+				x = v[offset+k+1] // down: insert from b
+			} else {
+				x = v[offset+k-1] + 1 // right: delete from a
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(a, b, trace, d)
+			}
+		}
+	}
+	return nil
+}
+
+func backtrack(a, b []string, trace [][]int, d int) []lineOp {
+	offset := len(a) + len(b)
+	x, y := len(a), len(b)
+	var ops []lineOp
+	for ; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+		prevK := k - 1
+		if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+			prevK = k + 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, lineOp{' ', a[x], x, y})
+		}
+		if x == prevX {
+			y--
+			ops = append(ops, lineOp{'+', b[y], x, y})
+		} else {
+			x--
+			ops = append(ops, lineOp{'-', a[x], x, y})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		ops = append(ops, lineOp{' ', a[x], x, y})
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
@@ -0,0 +1,67 @@
+package fix
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func numbered(from, to int, changed map[int]string) string {
+	var b strings.Builder
+	for i := from; i <= to; i++ {
+		if s, ok := changed[i]; ok {
+			b.WriteString(s)
+			continue
+		}
+		fmt.Fprintf(&b, "line %d\n", i)
+	}
+	return b.String()
+}
+
+func TestDiff(t *testing.T) {
+	if d := Diff("a", "b", []byte("x\n"), []byte("x\n")); d != "" {
+		t.Errorf("equal inputs: %q", d)
+	}
+
+	a := numbered(1, 20, nil)
+	b := numbered(1, 20, map[int]string{2: "line two\n", 18: ""})
+	want := `--- a/spec.json
++++ b/spec.json
+@@ -1,5 +1,5 @@
+ line 1
+-line 2
++line two
+ line 3
+ line 4
+ line 5
+@@ -15,6 +15,5 @@
+ line 15
+ line 16
+ line 17
+-line 18
+ line 19
+ line 20
+`
+	if got := Diff("a/spec.json", "b/spec.json", []byte(a), []byte(b)); got != want {
+		t.Errorf("Diff =\n%s\nwant\n%s", got, want)
+	}
+
+	// Changes closer than twice the context share a hunk.
+	b = numbered(1, 20, map[int]string{5: "five\n", 11: "eleven\n"})
+	if got := Diff("a", "b", []byte(a), []byte(b)); strings.Count(got, "@@ -") != 1 || !strings.Contains(got, "@@ -2,13 +2,13 @@") {
+		t.Errorf("expected one hunk:\n%s", got)
+	}
+}
+
+func TestDiffInsertAndNoNewline(t *testing.T) {
+	got := Diff("a", "b", []byte("x"), []byte("w\nx\ny"))
+	want := "--- a\n+++ b\n@@ -1,1 +1,3 @@\n-x\n\\ No newline at end of file\n+w\n+x\n+y\n\\ No newline at end of file\n"
+	if got != want {
+		t.Errorf("Diff =\n%q\nwant\n%q", got, want)
+	}
+
+	got = Diff("a", "b", nil, []byte("new\n"))
+	if want := "--- a\n+++ b\n@@ -0,0 +1,1 @@\n+new\n"; got != want {
+		t.Errorf("Diff from empty =\n%q\nwant\n%q", got, want)
+	}
+}
@@ -0,0 +1,353 @@
+// Package fix applies the suggestions attached to findings to spec files.
+// Edits are made to the text of the document, so a fixed spec keeps its
+// layout and differs from the original only where an edit applies.
+package fix
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/foundry-zero/allium/internal/report"
+	"github.com/foundry-zero/allium/internal/srcmap"
+)
+
+// maxFixes bounds the fixes applied to one spec, in case a fix keeps
+// producing findings that suggest another.
+const maxFixes = 100
+
+// Fix applies suggested fixes to the spec in data until its findings suggest
+// no more. check returns the report for a version of the spec; the first
+// suggestion of the first finding that has one is applied, and the spec is
+// checked again, so each fix sees the result of the previous ones. Fix
+// returns the fixed spec and the suggestions applied, in order.
+func Fix(data []byte, check func([]byte) *report.Report) ([]byte, []report.Suggestion, error) {
+	var applied []report.Suggestion
+	for {
+		s, ok := nextSuggestion(check(data))
+		if !ok {
+			return data, applied, nil
+		}
+		if len(applied) == maxFixes {
+			return nil, nil, fmt.Errorf("fixes did not converge after %d edits", maxFixes)
+		}
+		fixed, err := Apply(data, s.Edits...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("apply %q: %w", s.Description, err)
+		}
+		if bytes.Equal(fixed, data) {
+			return nil, nil, fmt.Errorf("apply %q: the spec did not change", s.Description)
+		}
+		data = fixed
+		applied = append(applied, s)
+	}
+}
+
+func nextSuggestion(r *report.Report) (report.Suggestion, bool) {
+	for _, findings := range [][]report.Finding{r.Errors, r.Warnings} {
+		for _, f := range findings {
+			if len(f.Suggestions) > 0 {
+				return f.Suggestions[0], true
+			}
+		}
+	}
+	return report.Suggestion{}, false
+}
+
+// Apply makes edits to the JSON document in data, in order, and returns the
+// result. New values are laid out to match their surroundings: on one line
+// where the value they replace or join is, and indented below it otherwise.
+func Apply(data []byte, edits ...report.Edit) ([]byte, error) {
+	for _, e := range edits {
+		source, err := srcmap.Build(data)
+		if err != nil {
+			return nil, err
+		}
+		d := &document{data: data, source: source}
+		switch e.Op {
+		case "replace":
+			err = d.replace(e.Path, e.Value)
+		case "add":
+			err = d.add(e.Path, e.Value)
+		case "remove":
+			err = d.remove(e.Path)
+		default:
+			err = fmt.Errorf("unknown edit operation %q", e.Op)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", e.Op, e.Path, err)
+		}
+		if !json.Valid(d.data) {
+			return nil, fmt.Errorf("%s %s: the result is not valid JSON", e.Op, e.Path)
+		}
+		data = d.data
+	}
+	return data, nil
+}
+
+// document is a JSON document being edited, with the source spans of its values
+// before the edit.
+type document struct {
+	data   []byte
+	source srcmap.Map
+}
+
+func (d *document) lookup(path string) (srcmap.Span, error) {
+	pos, ok := d.source[path]
+	if !ok {
+		return srcmap.Span{}, fmt.Errorf("no value at %s", path)
+	}
+	return pos, nil
+}
+
+// splice replaces the bytes from start to end with text.
+func (d *document) splice(start, end int, text string) {
+	d.data = append(d.data[:start:start], append([]byte(text), d.data[end:]...)...)
+}
+
+func (d *document) replace(path string, value json.RawMessage) error {
+	pos, err := d.lookup(path)
+	if err != nil {
+		return err
+	}
+	var text string
+	if bytes.IndexByte(d.data[pos.Start.Offset:pos.End.Offset], '\n') < 0 {
+		text, err = inline(value)
+	} else {
+		text, err = indented(value, d.indent(pos.Start.Offset), indentUnit)
+	}
+	if err != nil {
+		return err
+	}
+	d.splice(pos.Start.Offset, pos.End.Offset, text)
+	return nil
+}
+
+func (d *document) add(path string, value json.RawMessage) error {
+	open := strings.LastIndexByte(path, '[')
+	if open < 0 || !strings.HasSuffix(path, "]") {
+		return fmt.Errorf("path does not end with an array index")
+	}
+	index, err := strconv.Atoi(path[open+1 : len(path)-1])
+	if err != nil || index < 0 {
+		return fmt.Errorf("invalid array index in %s", path)
+	}
+	arrayPath := path[:open]
+	arr, ok := d.source[arrayPath]
+	if !ok {
+		if index != 0 {
+			return fmt.Errorf("no array at %s", arrayPath)
+		}
+		return d.addMember(arrayPath, value)
+	}
+	raw := arr.Text(d.data)
+	if string(raw) == "null" || raw[0] == '[' && len(bytes.TrimSpace(raw[1:len(raw)-1])) == 0 {
+		if index != 0 {
+			return fmt.Errorf("index %d is out of range", index)
+		}
+		indent := d.indent(arr.Start.Offset)
+		text, err := indented(value, indent+indentUnit, indentUnit)
+		if err != nil {
+			return err
+		}
+		d.splice(arr.Start.Offset, arr.End.Offset, "[\n"+indent+indentUnit+text+"\n"+indent+"]")
+		return nil
+	}
+	if d.data[arr.Start.Offset] != '[' {
+		return fmt.Errorf("%s is not an array", arrayPath)
+	}
+
+	n := 0
+	for ; ; n++ {
+		if _, ok := d.source[fmt.Sprintf("%s[%d]", arrayPath, n)]; !ok {
+			break
+		}
+	}
+	if index > n {
+		return fmt.Errorf("index %d is out of range", index)
+	}
+	// Lay the value out like the element it is inserted before, or the last.
+	neighbour := d.source[fmt.Sprintf("%s[%d]", arrayPath, min(index, n-1))]
+	ownLine := d.startsLine(neighbour.Start.Offset)
+	indent := d.indent(neighbour.Start.Offset)
+	var text string
+	if ownLine {
+		text, err = indented(value, indent, d.unit(arr.Start.Offset, neighbour.Start.Offset))
+	} else {
+		text, err = inline(value)
+	}
+	if err != nil {
+		return err
+	}
+	sep := ", "
+	if ownLine {
+		sep = ",\n" + indent
+	}
+	if index < n {
+		d.splice(neighbour.Start.Offset, neighbour.Start.Offset, text+sep)
+	} else {
+		d.splice(neighbour.End.Offset, neighbour.End.Offset, sep+text)
+	}
+	return nil
+}
+
+// addMember adds the member at path, which must be missing, to its object as
+// an array holding value.
+func (d *document) addMember(path string, value json.RawMessage) error {
+	dot := strings.LastIndexByte(path, '.')
+	if dot < 0 {
+		return fmt.Errorf("no array at %s", path)
+	}
+	objPath, key := path[:dot], path[dot+1:]
+	obj, err := d.lookup(objPath)
+	if err != nil {
+		return err
+	}
+	if d.data[obj.Start.Offset] != '{' {
+		return fmt.Errorf("%s is not an object", objPath)
+	}
+	closing := obj.End.Offset - 1
+	last := bytes.TrimRight(d.data[obj.Start.Offset+1:closing], " \t\r\n")
+	indent := d.indent(obj.Start.Offset) + indentUnit
+	text, err := indented(value, indent+indentUnit, indentUnit)
+	if err != nil {
+		return err
+	}
+	member := strconv.Quote(key) + ": [\n" + indent + indentUnit + text + "\n" + indent + "]"
+	if len(last) == 0 {
+		d.splice(obj.Start.Offset+1, closing, "\n"+indent+member+"\n"+d.indent(obj.Start.Offset))
+		return nil
+	}
+	end := obj.Start.Offset + 1 + len(last)
+	d.splice(end, end, ",\n"+indent+member)
+	return nil
+}
+
+func (d *document) remove(path string) error {
+	if path == "$" {
+		return fmt.Errorf("cannot remove the document")
+	}
+	pos, err := d.lookup(path)
+	if err != nil {
+		return err
+	}
+	start := pos.Start.Offset
+	if !strings.HasSuffix(path, "]") {
+		// An object member starts at its key.
+		if start, err = d.keyStart(pos.Start.Offset); err != nil {
+			return err
+		}
+	}
+
+	before := d.skipSpaceBack(start)
+	after := d.skipSpace(pos.End.Offset)
+	switch {
+	case d.data[before-1] == ',':
+		// Remove the separator before the value along with it.
+		d.splice(before-1, pos.End.Offset, "")
+	case d.data[after] == ',':
+		d.splice(start, d.skipSpace(after+1), "")
+	default:
+		// The only value in its container.
+		d.splice(before, after, "")
+	}
+	return nil
+}
+
+// keyStart returns the offset of the key of the object member whose value
+// starts at offset.
+func (d *document) keyStart(offset int) (int, error) {
+	i := d.skipSpaceBack(offset)
+	if i == 0 || d.data[i-1] != ':' {
+		return 0, fmt.Errorf("value at offset %d is not an object member", offset)
+	}
+	i = d.skipSpaceBack(i - 1)
+	if i == 0 || d.data[i-1] != '"' {
+		return 0, fmt.Errorf("value at offset %d has no key", offset)
+	}
+	for i -= 2; i >= 0; i-- {
+		if d.data[i] != '"' {
+			continue
+		}
+		escapes := 0
+		for j := i - 1; j >= 0 && d.data[j] == '\\'; j-- {
+			escapes++
+		}
+		if escapes%2 == 0 {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("value at offset %d has no key", offset)
+}
+
+// skipSpaceBack returns the offset just past the last non-space byte before
+// offset.
+func (d *document) skipSpaceBack(offset int) int {
+	for offset > 0 && isSpace(d.data[offset-1]) {
+		offset--
+	}
+	return offset
+}
+
+// skipSpace returns the offset of the first non-space byte from offset.
+func (d *document) skipSpace(offset int) int {
+	for offset < len(d.data) && isSpace(d.data[offset]) {
+		offset++
+	}
+	return offset
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+// indentUnit is the indentation of a nesting level where the document does
+// not show its own.
+const indentUnit = "  "
+
+// indent returns the leading whitespace of the line containing offset.
+func (d *document) indent(offset int) string {
+	start := bytes.LastIndexByte(d.data[:offset], '\n') + 1
+	end := start
+	for end < len(d.data) && (d.data[end] == ' ' || d.data[end] == '\t') {
+		end++
+	}
+	return string(d.data[start:end])
+}
+
+// startsLine reports whether only whitespace precedes offset on its line.
+func (d *document) startsLine(offset int) bool {
+	i := d.skipSpaceBack(offset)
+	return i == 0 || bytes.IndexByte(d.data[i:offset], '\n') >= 0
+}
+
+// unit returns the indentation the document adds for a nesting level, taken
+// from a container and one of its values on its own line.
+func (d *document) unit(container, value int) string {
+	outer, inner := d.indent(container), d.indent(value)
+	if len(inner) > len(outer) && strings.HasPrefix(inner, outer) {
+		return inner[len(outer):]
+	}
+	return indentUnit
+}
+
+// inline formats value on one line, as { "kind": "named_enum", "name": "X" }.
+func inline(value json.RawMessage) (string, error) {
+	var b bytes.Buffer
+	if err := json.Indent(&b, value, "", ""); err != nil {
+		return "", err
+	}
+	// Newlines only separate tokens, as strings cannot contain them.
+	return strings.ReplaceAll(b.String(), "\n", " "), nil
+}
+
+// indented formats value over several lines, for a line indented by prefix.
+func indented(value json.RawMessage, prefix, unit string) (string, error) {
+	var b bytes.Buffer
+	if err := json.Indent(&b, value, prefix, unit); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
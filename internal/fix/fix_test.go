@@ -0,0 +1,122 @@
+package fix
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/foundry-zero/allium/internal/report"
+)
+
+const doc = `{
+  "version": "1",
+  "use_declarations": [
+    { "coordinate": "", "alias": "a" },
+    { "coordinate": "x", "alias": "b" }
+  ],
+  "entities": [
+    {
+      "name": "Order",
+      "fields": [
+        { "name": "status", "type": { "kind": "inline_enum", "values": ["open", "closed"] } }
+      ]
+    }
+  ],
+  "enumerations": [],
+  "rules": null
+}
+`
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name string
+		edit report.Edit
+		want string // a line of the result, or the removed text prefixed by "-"
+	}{
+		{"replace inline", report.ReplaceEdit("$.entities[0].fields[0].type", map[string]string{"kind": "named_enum", "name": "Status"}),
+			`        { "name": "status", "type": { "kind": "named_enum", "name": "Status" } }`},
+		{"remove first element", report.RemoveEdit("$.use_declarations[0]"),
+			"  \"use_declarations\": [\n    { \"coordinate\": \"x\", \"alias\": \"b\" }\n  ],"},
+		{"remove last element", report.RemoveEdit("$.use_declarations[1]"),
+			"  \"use_declarations\": [\n    { \"coordinate\": \"\", \"alias\": \"a\" }\n  ],"},
+		{"remove member", report.RemoveEdit("$.use_declarations[1].alias"), `    { "coordinate": "x" }`},
+		{"remove first member", report.RemoveEdit("$.use_declarations[1].coordinate"), `    { "alias": "b" }`},
+		{"remove only element", report.RemoveEdit("$.entities[0].fields[0]"), `      "fields": []`},
+		{"add to empty array", report.AddEdit("$.enumerations[0]", map[string]any{"name": "Status"}),
+			"  \"enumerations\": [\n    {\n      \"name\": \"Status\"\n    }\n  ],"},
+		{"add to null", report.AddEdit("$.rules[0]", "r"), "  \"rules\": [\n    \"r\"\n  ]"},
+		{"add to missing array", report.AddEdit("$.variants[0]", "v"), "  \"rules\": null,\n  \"variants\": [\n    \"v\"\n  ]\n}"},
+		{"append element", report.AddEdit("$.use_declarations[2]", map[string]string{"alias": "c"}),
+			"    { \"coordinate\": \"x\", \"alias\": \"b\" },\n    {\n      \"alias\": \"c\"\n    }\n  ],"},
+		{"insert element", report.AddEdit("$.use_declarations[1]", "c"), "    \"c\",\n    { \"coordinate\": \"x\""},
+		{"append inline", report.AddEdit("$.entities[0].fields[0].type.values[2]", "held"), `["open", "closed", "held"]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply([]byte(doc), tt.edit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(got), tt.want) {
+				t.Errorf("result does not contain\n%s\n---\n%s", tt.want, got)
+			}
+		})
+	}
+
+	for _, e := range []report.Edit{
+		report.RemoveEdit("$.missing"),
+		report.AddEdit("$.enumerations[1]", "x"),
+		report.AddEdit("$.use_declarations[5]", "x"),
+		report.AddEdit("$.version", "x"),
+		report.AddEdit("$.version[0]", "x"),
+		{Op: "move", Path: "$.version"},
+		{Op: "replace", Path: "$.version", Value: json.RawMessage(`{`)},
+	} {
+		if _, err := Apply([]byte(doc), e); err == nil {
+			t.Errorf("Apply(%s %s) succeeded", e.Op, e.Path)
+		}
+	}
+}
+
+func TestFix(t *testing.T) {
+	// Each check suggests removing the first use declaration with an empty
+	// coordinate, as RULE-35 does.
+	check := func(data []byte) *report.Report {
+		var spec struct {
+			UseDeclarations []struct{ Coordinate string } `json:"use_declarations"`
+		}
+		if err := json.Unmarshal(data, &spec); err != nil {
+			t.Fatal(err)
+		}
+		r := report.NewReport("spec.json")
+		for i, u := range spec.UseDeclarations {
+			if u.Coordinate == "" {
+				path := "$.use_declarations[" + string(rune('0'+i)) + "]"
+				r.AddFinding(report.NewError("RULE-35", "empty", report.Location{Path: path}).
+					WithSuggestion("Remove "+path, report.RemoveEdit(path)))
+			}
+		}
+		return r
+	}
+	data := strings.Replace(doc, `"coordinate": "x"`, `"coordinate": ""`, 1)
+	fixed, applied, err := Fix([]byte(data), check)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 2 || applied[1].Description != "Remove $.use_declarations[0]" {
+		t.Errorf("applied = %+v", applied)
+	}
+	if !strings.Contains(string(fixed), `"use_declarations": [],`) {
+		t.Errorf("unexpected result:\n%s", fixed)
+	}
+
+	loop := func([]byte) *report.Report {
+		r := report.NewReport("spec.json")
+		r.AddFinding(report.NewWarning("WARN-19", "w", report.Location{}).
+			WithSuggestion("Grow", report.AddEdit("$.enumerations[0]", "e")))
+		return r
+	}
+	if _, _, err := Fix([]byte(doc), loop); err == nil {
+		t.Error("expected a fix that never converges to fail")
+	}
+}
@@ -0,0 +1,168 @@
+package lsp
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/report"
+	"github.com/foundry-zero/allium/internal/semantic"
+)
+
+// document is an open editor buffer. Spec and Symbols are nil while the
+// buffer is not valid JSON.
+type document struct {
+	URI        string
+	Path       string
+	Version    int
+	Text       []byte
+	lineStarts []int
+
+	Spec    *ast.Spec
+	Symbols *semantic.SymbolTable
+}
+
+func newDocument(uri string, version int, text string) *document {
+	d := &document{URI: uri, Path: uriToPath(uri), Version: version, Text: []byte(text)}
+	d.lineStarts = []int{0}
+	for i, b := range d.Text {
+		if b == '\n' {
+			d.lineStarts = append(d.lineStarts, i+1)
+		}
+	}
+	if spec, err := ast.ParseSpec(d.Text); err == nil {
+		d.Spec = spec
+		d.Symbols = semantic.BuildSymbolTable(spec)
+	}
+	return d
+}
+
+// uriToPath converts a file:// URI to a filesystem path. Other URIs are
+// returned unchanged so that reports still carry a recognisable name.
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+// lineEnd returns the offset of the end of the given zero-based line,
+// excluding the line terminator.
+func (d *document) lineEnd(line int) int {
+	end := len(d.Text)
+	if line+1 < len(d.lineStarts) {
+		end = d.lineStarts[line+1] - 1
+	}
+	if end > d.lineStarts[line] && d.Text[end-1] == '\r' {
+		end--
+	}
+	return end
+}
+
+// offsetAt converts an LSP position (UTF-16 character offset) to a byte offset,
+// clamping to the end of the line or document.
+func (d *document) offsetAt(pos Position) int {
+	if pos.Line < 0 {
+		return 0
+	}
+	if pos.Line >= len(d.lineStarts) {
+		return len(d.Text)
+	}
+	off, end := d.lineStarts[pos.Line], d.lineEnd(pos.Line)
+	for units := 0; off < end && units < pos.Character; {
+		r, size := utf8.DecodeRune(d.Text[off:])
+		units += utf16.RuneLen(r)
+		off += size
+	}
+	return off
+}
+
+// positionAt converts a byte offset to an LSP position.
+func (d *document) positionAt(offset int) Position {
+	offset = min(max(offset, 0), len(d.Text))
+	line := 0
+	for line+1 < len(d.lineStarts) && d.lineStarts[line+1] <= offset {
+		line++
+	}
+	units := 0
+	for off := d.lineStarts[line]; off < offset; {
+		r, size := utf8.DecodeRune(d.Text[off:])
+		units += utf16.RuneLen(r)
+		off += size
+	}
+	return Position{Line: line, Character: units}
+}
+
+// valueRange returns the range of the JSON token starting at offset: a whole
+// string or scalar, or just the opening bracket of an object or array.
+func (d *document) valueRange(offset int) Range {
+	return Range{Start: d.positionAt(offset), End: d.positionAt(tokenEnd(d.Text, offset))}
+}
+
+// findingRange converts a finding's 1-based line and character column to a
+// range. Findings without a line are placed at the start of the document.
+func (d *document) findingRange(loc report.Location) Range {
+	if loc.Line <= 0 || loc.Line > len(d.lineStarts) {
+		return Range{}
+	}
+	off, end := d.lineStarts[loc.Line-1], d.lineEnd(loc.Line-1)
+	for col := 1; col < loc.Column && off < end; col++ {
+		_, size := utf8.DecodeRune(d.Text[off:])
+		off += size
+	}
+	return d.valueRange(off)
+}
+
+// stringAt returns the decoded JSON string literal containing offset, along
+// with the offset of its opening quote. Strings cannot span lines, so the
+// scan starts at the beginning of the line.
+func (d *document) stringAt(offset int) (string, int, bool) {
+	line := d.positionAt(offset).Line
+	start, inString := -1, false
+	for off := d.lineStarts[line]; off < d.lineEnd(line); off++ {
+		switch c := d.Text[off]; {
+		case inString && c == '\\':
+			off++
+		case c == '"':
+			if !inString {
+				start, inString = off, true
+				continue
+			}
+			inString = false
+			if start <= offset && offset <= off {
+				return unquote(d.Text[start : off+1]), start, true
+			}
+		}
+	}
+	return "", 0, false
+}
+
+// tokenEnd returns the end offset of the JSON token starting at offset.
+func tokenEnd(data []byte, offset int) int {
+	if offset >= len(data) {
+		return offset
+	}
+	switch data[offset] {
+	case '{', '[':
+		return offset + 1
+	case '"':
+		for off := offset + 1; off < len(data); off++ {
+			switch data[off] {
+			case '\\':
+				off++
+			case '"', '\n':
+				return off + 1
+			}
+		}
+		return len(data)
+	}
+	off := offset
+	for off < len(data) && !strings.ContainsRune(",]} \t\r\n", rune(data[off])) {
+		off++
+	}
+	return off
+}
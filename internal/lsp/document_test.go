@@ -0,0 +1,88 @@
+package lsp
+
+import (
+	"testing"
+
+	"github.com/foundry-zero/allium/internal/report"
+)
+
+func TestDocumentPositions(t *testing.T) {
+	// "é" is two bytes and one UTF-16 unit; "𝄞" is four bytes and two units.
+	d := newDocument(testURI, 1, "{\r\n  \"a\": \"é𝄞x\"\n}")
+
+	tests := []struct {
+		offset int
+		pos    Position
+	}{
+		{0, Position{0, 0}},
+		{5, Position{1, 2}},
+		{10, Position{1, 7}},
+		{11, Position{1, 8}},
+		{13, Position{1, 9}},
+		{17, Position{1, 11}},
+		{20, Position{2, 0}},
+	}
+	for _, tt := range tests {
+		if got := d.positionAt(tt.offset); got != tt.pos {
+			t.Errorf("positionAt(%d) = %+v, want %+v", tt.offset, got, tt.pos)
+		}
+		if got := d.offsetAt(tt.pos); got != tt.offset {
+			t.Errorf("offsetAt(%+v) = %d, want %d", tt.pos, got, tt.offset)
+		}
+	}
+
+	// Positions past the end of a line clamp to the line end, before "\r\n".
+	if got := d.offsetAt(Position{0, 40}); got != 1 {
+		t.Errorf("offsetAt past line end = %d, want 1", got)
+	}
+}
+
+func TestDocumentStringAt(t *testing.T) {
+	d := newDocument(testURI, 1, `{"kind": "entity_ref", "entity": "Us\"er"}`)
+
+	tests := []struct {
+		offset int
+		want   string
+		ok     bool
+	}{
+		{3, "kind", true},
+		{9, "entity_ref", true},
+		{20, "entity_ref", true},
+		{22, "", false},
+		{37, `Us"er`, true},
+	}
+	for _, tt := range tests {
+		got, _, ok := d.stringAt(tt.offset)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("stringAt(%d) = %q, %v; want %q, %v", tt.offset, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestDocumentFindingRange(t *testing.T) {
+	d := newDocument(testURI, 1, "{\n  \"rules\": [\n    {\"name\": \"é\"}, 42\n  ]\n}")
+
+	tests := []struct {
+		loc  report.Location
+		want Range
+	}{
+		{report.Location{}, Range{}},
+		{report.Location{Line: 3, Column: 5}, Range{Position{2, 4}, Position{2, 5}}},
+		{report.Location{Line: 3, Column: 14}, Range{Position{2, 13}, Position{2, 16}}},
+		{report.Location{Line: 3, Column: 20}, Range{Position{2, 19}, Position{2, 21}}},
+	}
+	for _, tt := range tests {
+		if got := d.findingRange(tt.loc); got != tt.want {
+			t.Errorf("findingRange(%d:%d) = %+v, want %+v", tt.loc.Line, tt.loc.Column, got, tt.want)
+		}
+	}
+}
+
+func TestURIToPath(t *testing.T) {
+	if got := uriToPath("file:///specs/my%20spec.allium.json"); got != "/specs/my spec.allium.json" {
+		t.Errorf("uriToPath = %q", got)
+	}
+	if got := uriToPath("untitled:Untitled-1"); got != "untitled:Untitled-1" {
+		t.Errorf("uriToPath(non-file) = %q", got)
+	}
+}
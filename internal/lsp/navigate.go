@@ -0,0 +1,175 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/foundry-zero/allium/internal/ast"
+)
+
+// unquote decodes a JSON string literal, returning "" if it is malformed.
+func unquote(lit []byte) string {
+	var s string
+	if err := json.Unmarshal(lit, &s); err != nil {
+		return ""
+	}
+	return s
+}
+
+// hoverMarkdown describes the declaration named name, or returns "" when the
+// name is not declared in the spec.
+func hoverMarkdown(d *document, name string) string {
+	st := d.Symbols
+	if st == nil || name == "" {
+		return ""
+	}
+
+	var b strings.Builder
+	switch {
+	case st.LookupEntity(name) != nil:
+		e := st.LookupEntity(name)
+		fmt.Fprintf(&b, "**entity** `%s`\n", name)
+		writeFields(&b, e.Fields)
+		for _, rel := range e.Relationships {
+			fmt.Fprintf(&b, "- `%s`: %s (%s)\n", rel.Name, rel.TargetEntity, rel.Cardinality)
+		}
+	case st.LookupExternalEntity(name) != nil:
+		fmt.Fprintf(&b, "**external entity** `%s`\n", name)
+		writeFields(&b, st.LookupExternalEntity(name).Fields)
+	case st.LookupValueType(name) != nil:
+		fmt.Fprintf(&b, "**value type** `%s`\n", name)
+		writeFields(&b, st.LookupValueType(name).Fields)
+	case st.LookupVariant(name) != nil:
+		v := st.LookupVariant(name)
+		fmt.Fprintf(&b, "**variant** `%s` of `%s`\n", name, v.BaseEntity)
+		writeFields(&b, v.Fields)
+	case st.LookupEnumeration(name) != nil:
+		fmt.Fprintf(&b, "**enum** `%s`\n\n%s\n", name, strings.Join(st.LookupEnumeration(name).Values, " | "))
+	case st.LookupTypeAlias(name) != nil:
+		fmt.Fprintf(&b, "**type** `%s` = %s\n", name, formatFieldType(&st.LookupTypeAlias(name).Type))
+	case st.LookupRule(name) != nil:
+		fmt.Fprintf(&b, "**rule** `%s`\n\nTrigger: %s\n", name, describeTrigger(st.LookupRule(name).Trigger))
+	case len(st.LookupTrigger(name)) > 0:
+		rules := st.LookupTrigger(name)
+		var params []string
+		for _, p := range rules[0].Trigger.Parameters {
+			if p.Optional {
+				params = append(params, p.Name+"?")
+			} else {
+				params = append(params, p.Name)
+			}
+		}
+		fmt.Fprintf(&b, "**%s trigger** `%s(%s)`\n\nHandled by:\n", strings.ReplaceAll(rules[0].Trigger.Kind, "_", " "), name, strings.Join(params, ", "))
+		for _, r := range rules {
+			fmt.Fprintf(&b, "- `%s`\n", r.Name)
+		}
+	case st.LookupActor(name) != nil:
+		fmt.Fprintf(&b, "**actor** `%s`\n\nIdentified by `%s`\n", name, st.LookupActor(name).IdentifiedBy.Entity)
+	case st.LookupSurface(name) != nil:
+		fmt.Fprintf(&b, "**surface** `%s`\n", name)
+	case st.LookupConfig(name) != nil:
+		fmt.Fprintf(&b, "**config** `%s`: %s\n", name, formatFieldType(&st.LookupConfig(name).Type))
+	}
+	return b.String()
+}
+
+func writeFields(b *strings.Builder, fields []ast.Field) {
+	if len(fields) > 0 {
+		b.WriteString("\n")
+	}
+	for _, f := range fields {
+		fmt.Fprintf(b, "- `%s`: %s\n", f.Name, formatFieldType(&f.Type))
+	}
+}
+
+// formatFieldType renders a field type in Allium source syntax.
+func formatFieldType(ft *ast.FieldType) string {
+	if ft == nil {
+		return "?"
+	}
+	switch ft.Kind {
+	case "primitive":
+		return ft.Value
+	case "entity_ref":
+		return ft.Entity
+	case "named_enum", "alias":
+		return ft.Name
+	case "inline_enum":
+		return strings.Join(ft.Values, " | ")
+	case "optional":
+		return formatFieldType(ft.Inner) + "?"
+	case "set":
+		return "Set<" + formatFieldType(ft.Element) + ">"
+	case "list":
+		return "List<" + formatFieldType(ft.Element) + ">"
+	default:
+		return ft.Kind
+	}
+}
+
+// describeTrigger summarises a rule trigger for hover text.
+func describeTrigger(t ast.Trigger) string {
+	switch t.Kind {
+	case "external_stimulus", "chained":
+		return fmt.Sprintf("%s `%s`", strings.ReplaceAll(t.Kind, "_", " "), t.Name)
+	case "state_transition":
+		return fmt.Sprintf("`%s.%s` transitions to `%s`", t.Entity, t.Field, t.ToValue)
+	case "state_becomes":
+		return fmt.Sprintf("`%s.%s` becomes `%s`", t.Entity, t.Field, t.Value)
+	case "entity_creation":
+		return fmt.Sprintf("`%s` created", t.Entity)
+	default:
+		return fmt.Sprintf("%s on `%s`", strings.ReplaceAll(t.Kind, "_", " "), t.Entity)
+	}
+}
+
+// definitionPaths returns the JSON paths declaring name: the name of an
+// entity, external entity, value type, variant, enumeration or type alias,
+// or the trigger of every rule that handles a trigger of that name.
+func definitionPaths(spec *ast.Spec, name string) []string {
+	if spec == nil || name == "" {
+		return nil
+	}
+
+	var paths []string
+	for i, e := range spec.Entities {
+		if e.Name == name {
+			paths = append(paths, fmt.Sprintf("$.entities[%d].name", i))
+		}
+	}
+	for i, e := range spec.ExternalEntities {
+		if e.Name == name {
+			paths = append(paths, fmt.Sprintf("$.external_entities[%d].name", i))
+		}
+	}
+	for i, v := range spec.ValueTypes {
+		if v.Name == name {
+			paths = append(paths, fmt.Sprintf("$.value_types[%d].name", i))
+		}
+	}
+	for i, v := range spec.Variants {
+		if v.Name == name {
+			paths = append(paths, fmt.Sprintf("$.variants[%d].name", i))
+		}
+	}
+	for i, e := range spec.Enumerations {
+		if e.Name == name {
+			paths = append(paths, fmt.Sprintf("$.enumerations[%d].name", i))
+		}
+	}
+	for i, a := range spec.TypeAliases {
+		if a.Name == name {
+			paths = append(paths, fmt.Sprintf("$.type_aliases[%d].name", i))
+		}
+	}
+	for i, r := range spec.Rules {
+		switch r.Trigger.Kind {
+		case "external_stimulus", "chained":
+			if r.Trigger.Name == name {
+				paths = append(paths, fmt.Sprintf("$.rules[%d].trigger.name", i))
+			}
+		}
+	}
+	return paths
+}
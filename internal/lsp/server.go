@@ -0,0 +1,266 @@
+// Package lsp implements a Language Server Protocol server for Allium
+// specification files. It publishes checker findings as diagnostics and
+// offers hover and go-to-definition using the spec's symbol table.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/foundry-zero/allium/internal/annotate"
+	"github.com/foundry-zero/allium/internal/checker"
+	"github.com/foundry-zero/allium/internal/report"
+)
+
+// ErrExitWithoutShutdown is returned by Serve when the client sends exit
+// without a preceding shutdown request.
+var ErrExitWithoutShutdown = errors.New("exit received before shutdown")
+
+// Server is a single-client LSP server communicating over a byte stream.
+type Server struct {
+	session  *checker.Session
+	version  string
+	out      io.Writer
+	docs     map[string]*document
+	shutdown bool
+	writeErr error // first failure writing a notification
+}
+
+// NewServer creates a server that validates documents with c using opts.
+// The version is reported to clients in the initialize response.
+func NewServer(c *checker.Checker, opts checker.CheckOptions, version string) *Server {
+	return &Server{
+		session: c.NewSession(opts),
+		version: version,
+		docs:    make(map[string]*document),
+	}
+}
+
+// Serve reads requests from in and writes responses and notifications to out
+// until the client sends exit or the input is closed.
+func (s *Server) Serve(in io.Reader, out io.Writer) error {
+	s.out = out
+	r := bufio.NewReader(in)
+	for {
+		msg, err := readMessage(r)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("read message: %w", err)
+		}
+		if msg.Method == "exit" {
+			if !s.shutdown {
+				return ErrExitWithoutShutdown
+			}
+			return nil
+		}
+		if err := s.handle(msg); err != nil {
+			return fmt.Errorf("write message: %w", err)
+		}
+	}
+}
+
+// handle dispatches one incoming message. Only write failures are returned;
+// protocol errors are reported to the client.
+func (s *Server) handle(msg *message) error {
+	if msg.Error != nil {
+		return s.reply(nil, nil, msg.Error)
+	}
+	if s.shutdown && msg.ID != nil {
+		return s.reply(msg.ID, nil, &responseError{Code: codeInvalidRequest, Message: "server is shut down"})
+	}
+
+	result, rerr := s.dispatch(msg)
+	if s.writeErr != nil {
+		return s.writeErr
+	}
+	if msg.ID == nil {
+		// Notifications get no response, even on error.
+		return nil
+	}
+	return s.reply(msg.ID, result, rerr)
+}
+
+func (s *Server) dispatch(msg *message) (any, *responseError) {
+	switch msg.Method {
+	case "initialize":
+		return initializeResult{
+			Capabilities: serverCapabilities{
+				TextDocumentSync:   textDocumentSyncFull,
+				HoverProvider:      true,
+				DefinitionProvider: true,
+			},
+			ServerInfo: serverInfo{Name: "allium-lsp", Version: s.version},
+		}, nil
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+
+	case "textDocument/didOpen":
+		var p didOpenParams
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			return nil, invalidParams(err)
+		}
+		s.open(newDocument(p.TextDocument.URI, p.TextDocument.Version, p.TextDocument.Text))
+		return nil, nil
+	case "textDocument/didChange":
+		var p didChangeParams
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			return nil, invalidParams(err)
+		}
+		if len(p.ContentChanges) == 0 {
+			return nil, nil
+		}
+		// Full sync: the last change holds the complete document.
+		text := p.ContentChanges[len(p.ContentChanges)-1].Text
+		s.open(newDocument(p.TextDocument.URI, p.TextDocument.Version, text))
+		return nil, nil
+	case "textDocument/didClose":
+		var p didCloseParams
+		if err := json.Unmarshal(msg.Params, &p); err != nil {
+			return nil, invalidParams(err)
+		}
+		if d := s.docs[p.TextDocument.URI]; d != nil {
+			s.session.Forget(d.Path)
+		}
+		delete(s.docs, p.TextDocument.URI)
+		s.publish(p.TextDocument.URI, nil, []Diagnostic{})
+		return nil, nil
+
+	case "textDocument/hover":
+		d, name, start, rerr := s.symbolAt(msg.Params)
+		if rerr != nil || d == nil {
+			return nil, rerr
+		}
+		text := hoverMarkdown(d, name)
+		if text == "" {
+			return nil, nil
+		}
+		rng := d.valueRange(start)
+		return hover{Contents: markupContent{Kind: "markdown", Value: text}, Range: &rng}, nil
+	case "textDocument/definition":
+		d, name, _, rerr := s.symbolAt(msg.Params)
+		if rerr != nil || d == nil {
+			return nil, rerr
+		}
+		locations := []Location{}
+		for _, path := range definitionPaths(d.Spec, name) {
+			if span, ok := d.Spec.SourceMap[path]; ok {
+				locations = append(locations, Location{URI: d.URI, Range: d.valueRange(span.Start.Offset)})
+			}
+		}
+		return locations, nil
+	}
+
+	return nil, &responseError{Code: codeMethodNotFound, Message: fmt.Sprintf("method not found: %s", msg.Method)}
+}
+
+func invalidParams(err error) *responseError {
+	return &responseError{Code: codeInvalidParams, Message: err.Error()}
+}
+
+// open stores a document and publishes its diagnostics.
+func (s *Server) open(d *document) {
+	s.docs[d.URI] = d
+	version := d.Version
+	s.publish(d.URI, &version, s.diagnostics(d))
+}
+
+// diagnostics runs the checker over the document's current text, re-running
+// only the passes its last edit could affect. Findings
+// that reviewers have accepted or deferred in the spec's annotation sidecar
+// carry that status in their message.
+func (s *Server) diagnostics(d *document) []Diagnostic {
+	r := s.session.Check(d.Path, d.Text)
+	var review *annotate.File
+	if d.Path != "" {
+		// An unreadable sidecar only loses the review status, not the diagnostics.
+		review, _ = annotate.Load(annotate.SidecarPath(d.Path))
+	}
+	diags := []Diagnostic{}
+	add := func(findings []report.Finding, severity int) {
+		for _, f := range findings {
+			msg := f.Message
+			if a := review.Lookup(f.Fingerprint()); a != nil && a.Status != annotate.StatusOpen {
+				msg += " [" + a.Status
+				if a.Note != "" {
+					msg += ": " + a.Note
+				}
+				msg += "]"
+			}
+			diag := Diagnostic{
+				Range:    d.findingRange(f.Location),
+				Severity: severity,
+				Code:     f.Rule,
+				Source:   "allium",
+				Message:  msg,
+			}
+			for _, rel := range f.Related {
+				diag.RelatedInformation = append(diag.RelatedInformation, DiagnosticRelatedInformation{
+					Location: Location{URI: d.URI, Range: d.findingRange(rel.Location)},
+					Message:  rel.Message,
+				})
+			}
+			diags = append(diags, diag)
+		}
+	}
+	add(r.Errors, severityError)
+	add(r.Warnings, severityWarning)
+	add(r.Info, severityInformation)
+	add(r.Hints, severityHint)
+	return diags
+}
+
+// symbolAt resolves the string literal under the cursor of a position request.
+// It returns a nil document when the request does not point at a name.
+func (s *Server) symbolAt(params json.RawMessage) (*document, string, int, *responseError) {
+	var p textDocumentPositionParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, "", 0, invalidParams(err)
+	}
+	d := s.docs[p.TextDocument.URI]
+	if d == nil || d.Spec == nil {
+		return nil, "", 0, nil
+	}
+	name, start, ok := d.stringAt(d.offsetAt(p.Position))
+	if !ok {
+		return nil, "", 0, nil
+	}
+	return d, name, start, nil
+}
+
+// publish sends a publishDiagnostics notification, recording any write
+// failure in writeErr.
+func (s *Server) publish(uri string, version *int, diags []Diagnostic) {
+	if s.writeErr != nil {
+		return
+	}
+	params, err := json.Marshal(publishDiagnosticsParams{URI: uri, Version: version, Diagnostics: diags})
+	if err == nil {
+		err = writeMessage(s.out, &message{Method: "textDocument/publishDiagnostics", Params: params})
+	}
+	s.writeErr = err
+}
+
+// reply sends a response. A nil result is encoded as JSON null, as the
+// protocol requires every successful response to carry a result.
+func (s *Server) reply(id *json.RawMessage, result any, rerr *responseError) error {
+	msg := &message{ID: id, Error: rerr}
+	if id == nil {
+		null := json.RawMessage("null")
+		msg.ID = &null
+	}
+	if rerr == nil {
+		if result == nil {
+			result = json.RawMessage("null")
+		}
+		msg.Result = result
+	}
+	return writeMessage(s.out, msg)
+}
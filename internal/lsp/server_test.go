@@ -0,0 +1,369 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/foundry-zero/allium/internal/annotate"
+	"github.com/foundry-zero/allium/internal/checker"
+	"github.com/foundry-zero/allium/internal/report"
+)
+
+var refExample = filepath.Join("..", "..", "schemas", "v1", "examples", "password-auth.allium.json")
+
+const testURI = "file:///specs/test.allium.json"
+
+// session frames client messages and runs them through a server, returning
+// every message the server wrote.
+type session struct {
+	t   *testing.T
+	in  bytes.Buffer
+	ids int
+}
+
+func (s *session) send(method string, params any) {
+	s.t.Helper()
+	s.write(&message{Method: method, Params: mustMarshal(s.t, params)})
+}
+
+func (s *session) request(method string, params any) {
+	s.t.Helper()
+	s.ids++
+	id := json.RawMessage(mustMarshal(s.t, s.ids))
+	s.write(&message{ID: &id, Method: method, Params: mustMarshal(s.t, params)})
+}
+
+func (s *session) write(msg *message) {
+	s.t.Helper()
+	if err := writeMessage(&s.in, msg); err != nil {
+		s.t.Fatal(err)
+	}
+}
+
+// run serves the queued messages and returns the server's output messages.
+func (s *session) run() ([]rawMessage, error) {
+	s.t.Helper()
+	c, err := checker.NewChecker()
+	if err != nil {
+		s.t.Fatalf("NewChecker: %v", err)
+	}
+	var out bytes.Buffer
+	serveErr := NewServer(c, checker.CheckOptions{}, "test").Serve(&s.in, &out)
+
+	var msgs []rawMessage
+	r := bufio.NewReader(&out)
+	for {
+		msg, err := readMessage(r)
+		if err != nil {
+			break
+		}
+		raw := rawMessage{Method: msg.Method, Params: msg.Params, Error: msg.Error}
+		if msg.ID != nil {
+			raw.ID = string(*msg.ID)
+		}
+		// Results decode as generic values; re-marshal for typed decoding.
+		raw.Result = mustMarshal(s.t, msg.Result)
+		msgs = append(msgs, raw)
+	}
+	return msgs, serveErr
+}
+
+type rawMessage struct {
+	ID     string
+	Method string
+	Params json.RawMessage
+	Result json.RawMessage
+	Error  *responseError
+}
+
+func mustMarshal(t *testing.T, v any) json.RawMessage {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func response(t *testing.T, msgs []rawMessage, id string) rawMessage {
+	t.Helper()
+	for _, m := range msgs {
+		if m.ID == id && m.Method == "" {
+			return m
+		}
+	}
+	t.Fatalf("no response with id %s in %d messages", id, len(msgs))
+	return rawMessage{}
+}
+
+func diagnosticsFor(t *testing.T, msgs []rawMessage) []publishDiagnosticsParams {
+	t.Helper()
+	var all []publishDiagnosticsParams
+	for _, m := range msgs {
+		if m.Method != "textDocument/publishDiagnostics" {
+			continue
+		}
+		var p publishDiagnosticsParams
+		if err := json.Unmarshal(m.Params, &p); err != nil {
+			t.Fatal(err)
+		}
+		all = append(all, p)
+	}
+	return all
+}
+
+func openParams(text string) didOpenParams {
+	return didOpenParams{TextDocument: textDocumentItem{URI: testURI, Version: 1, Text: text}}
+}
+
+func readExample(t *testing.T) string {
+	t.Helper()
+	data, err := os.ReadFile(refExample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// positionOf returns the LSP position of the first occurrence of needle,
+// offset by delta bytes.
+func positionOf(t *testing.T, text, needle string, delta int) Position {
+	t.Helper()
+	i := strings.Index(text, needle)
+	if i < 0 {
+		t.Fatalf("%q not found", needle)
+	}
+	return newDocument(testURI, 0, text).positionAt(i + delta)
+}
+
+func TestServerInitializeAndShutdown(t *testing.T) {
+	s := &session{t: t}
+	s.request("initialize", map[string]any{})
+	s.send("initialized", map[string]any{})
+	s.request("shutdown", nil)
+	s.send("exit", nil)
+
+	msgs, err := s.run()
+	if err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+
+	var init initializeResult
+	if err := json.Unmarshal(response(t, msgs, "1").Result, &init); err != nil {
+		t.Fatal(err)
+	}
+	caps := init.Capabilities
+	if caps.TextDocumentSync != textDocumentSyncFull || !caps.HoverProvider || !caps.DefinitionProvider {
+		t.Errorf("unexpected capabilities: %+v", caps)
+	}
+	if shutdown := response(t, msgs, "2"); shutdown.Error != nil || string(shutdown.Result) != "null" {
+		t.Errorf("shutdown response = %s, %v", shutdown.Result, shutdown.Error)
+	}
+}
+
+func TestServerExitWithoutShutdown(t *testing.T) {
+	s := &session{t: t}
+	s.send("exit", nil)
+	if _, err := s.run(); !errors.Is(err, ErrExitWithoutShutdown) {
+		t.Errorf("Serve = %v, want ErrExitWithoutShutdown", err)
+	}
+}
+
+func TestServerUnknownMethod(t *testing.T) {
+	s := &session{t: t}
+	s.request("workspace/symbol", map[string]any{})
+	s.send("$/cancelRequest", map[string]any{"id": 1})
+
+	msgs, _ := s.run()
+	if len(msgs) != 1 {
+		t.Fatalf("expected only the method-not-found response, got %d messages", len(msgs))
+	}
+	if r := response(t, msgs, "1"); r.Error == nil || r.Error.Code != codeMethodNotFound {
+		t.Errorf("expected method not found, got %+v", r.Error)
+	}
+}
+
+func TestServerPublishesDiagnostics(t *testing.T) {
+	text := "{\n  \"version\": \"1\",\n  \"file\": \"test.allium\",\n  \"entities\": [\n" +
+		"    {\"name\": \"Order\", \"fields\": [{\"name\": \"buyer\", \"type\": {\"kind\": \"entity_ref\", \"entity\": \"Buyer\"}}]}\n  ]\n}\n"
+
+	s := &session{t: t}
+	s.send("textDocument/didOpen", openParams(text))
+	s.send("textDocument/didChange", didChangeParams{
+		TextDocument:   versionedTextDocumentIdentifier{URI: testURI, Version: 2},
+		ContentChanges: []contentChange{{Text: `{"version": "1", "file": "test.allium"}`}},
+	})
+	s.send("textDocument/didClose", didCloseParams{TextDocument: textDocumentIdentifier{URI: testURI}})
+
+	msgs, _ := s.run()
+	published := diagnosticsFor(t, msgs)
+	if len(published) != 3 {
+		t.Fatalf("expected 3 publishDiagnostics notifications, got %d", len(published))
+	}
+
+	opened := published[0]
+	if opened.Version == nil || *opened.Version != 1 {
+		t.Errorf("expected version 1, got %v", opened.Version)
+	}
+	var found *Diagnostic
+	for i, d := range opened.Diagnostics {
+		if d.Code == "RULE-01" {
+			found = &opened.Diagnostics[i]
+		}
+	}
+	if found == nil {
+		t.Fatalf("expected a RULE-01 diagnostic for the unknown entity, got %+v", opened.Diagnostics)
+	}
+	if found.Severity != severityError || found.Source != "allium" {
+		t.Errorf("unexpected diagnostic: %+v", found)
+	}
+	if found.Range.Start.Line != 4 {
+		t.Errorf("expected diagnostic on line 4, got %+v", found.Range)
+	}
+
+	if len(published[1].Diagnostics) != 0 {
+		t.Errorf("expected no diagnostics after fixing the document, got %+v", published[1].Diagnostics)
+	}
+	if len(published[2].Diagnostics) != 0 || published[2].Version != nil {
+		t.Errorf("expected an empty unversioned publish on close, got %+v", published[2])
+	}
+}
+
+func TestServerDiagnosticReviewStatus(t *testing.T) {
+	text := `{"version": "1", "file": "test.allium", "entities": [` +
+		`{"name": "Order", "fields": [{"name": "buyer", "type": {"kind": "entity_ref", "entity": "Buyer"}}]}]}`
+	spec := filepath.Join(t.TempDir(), "orders.allium.json")
+	finding := report.NewError("RULE-01", "Entity 'Buyer' referenced but not declared",
+		report.Location{Path: "$.entities[0].fields[0].type"})
+	err := annotate.Save(annotate.SidecarPath(spec), &annotate.File{
+		Version: annotate.Version,
+		Annotations: []annotate.Annotation{
+			{Fingerprint: finding.Fingerprint(), Rule: "RULE-01", Status: annotate.StatusDeferred, Note: "buyer lands next release"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &session{t: t}
+	s.send("textDocument/didOpen", didOpenParams{TextDocument: textDocumentItem{URI: "file://" + spec, Version: 1, Text: text}})
+	msgs, _ := s.run()
+	published := diagnosticsFor(t, msgs)
+	if len(published) != 1 || len(published[0].Diagnostics) == 0 {
+		t.Fatalf("expected diagnostics, got %+v", published)
+	}
+	want := finding.Message + " [deferred: buyer lands next release]"
+	if got := published[0].Diagnostics[0].Message; got != want {
+		t.Errorf("message = %q, want %q", got, want)
+	}
+}
+
+func TestServerInvalidJSONDiagnostic(t *testing.T) {
+	s := &session{t: t}
+	s.send("textDocument/didOpen", openParams(`{"version": `))
+	s.request("textDocument/hover", textDocumentPositionParams{
+		TextDocument: textDocumentIdentifier{URI: testURI},
+	})
+
+	msgs, _ := s.run()
+	published := diagnosticsFor(t, msgs)
+	if len(published) != 1 || len(published[0].Diagnostics) != 1 || published[0].Diagnostics[0].Code != "INPUT" {
+		t.Fatalf("expected one INPUT diagnostic, got %+v", published)
+	}
+	if r := response(t, msgs, "1"); string(r.Result) != "null" {
+		t.Errorf("hover on unparseable document = %s, want null", r.Result)
+	}
+}
+
+func TestServerHover(t *testing.T) {
+	text := readExample(t)
+
+	s := &session{t: t}
+	s.send("textDocument/didOpen", openParams(text))
+	s.request("textDocument/hover", textDocumentPositionParams{
+		TextDocument: textDocumentIdentifier{URI: testURI},
+		Position:     positionOf(t, text, `"entity": "User"`, len(`"entity": "U`)),
+	})
+	s.request("textDocument/hover", textDocumentPositionParams{
+		TextDocument: textDocumentIdentifier{URI: testURI},
+		Position:     positionOf(t, text, `"kind": "entity_ref"`, 2),
+	})
+
+	msgs, _ := s.run()
+	var h hover
+	if err := json.Unmarshal(response(t, msgs, "1").Result, &h); err != nil {
+		t.Fatal(err)
+	}
+	if h.Contents.Kind != "markdown" || !strings.Contains(h.Contents.Value, "**entity** `User`") {
+		t.Errorf("unexpected hover: %+v", h.Contents)
+	}
+	if !strings.Contains(h.Contents.Value, "- `email`: ") {
+		t.Errorf("hover should list fields:\n%s", h.Contents.Value)
+	}
+
+	if r := response(t, msgs, "2"); string(r.Result) != "null" {
+		t.Errorf("hover on a property key = %s, want null", r.Result)
+	}
+}
+
+func TestServerDefinition(t *testing.T) {
+	text := readExample(t)
+
+	s := &session{t: t}
+	s.send("textDocument/didOpen", openParams(text))
+	s.request("textDocument/definition", textDocumentPositionParams{
+		TextDocument: textDocumentIdentifier{URI: testURI},
+		Position:     positionOf(t, text, `"entity": "User"`, len(`"entity": "U`)),
+	})
+	s.request("textDocument/definition", textDocumentPositionParams{
+		TextDocument: textDocumentIdentifier{URI: testURI},
+		Position:     positionOf(t, text, `"name": "AccountLockTriggered"`, len(`"name": "A`)),
+	})
+
+	msgs, _ := s.run()
+
+	var entityLocs []Location
+	if err := json.Unmarshal(response(t, msgs, "1").Result, &entityLocs); err != nil {
+		t.Fatal(err)
+	}
+	want := positionOf(t, text, `"name": "User"`, len(`"name": `))
+	if len(entityLocs) != 1 || entityLocs[0].URI != testURI || entityLocs[0].Range.Start != want {
+		t.Errorf("entity definition = %+v, want start %+v", entityLocs, want)
+	}
+
+	var triggerLocs []Location
+	if err := json.Unmarshal(response(t, msgs, "2").Result, &triggerLocs); err != nil {
+		t.Fatal(err)
+	}
+	d := newDocument(testURI, 0, text)
+	if len(triggerLocs) != 1 {
+		t.Fatalf("expected one trigger definition, got %+v", triggerLocs)
+	}
+	start := d.offsetAt(triggerLocs[0].Range.Start)
+	if !strings.HasPrefix(text[start:], `"AccountLockTriggered"`) {
+		t.Errorf("trigger definition does not point at the trigger name: %q", text[start:start+30])
+	}
+	if line := triggerLocs[0].Range.Start.Line; line <= positionOf(t, text, `"kind": "trigger_emission"`, 0).Line {
+		t.Errorf("expected the chained rule's trigger after the emission, got line %d", line)
+	}
+}
+
+func TestServerInfoDiagnostic(t *testing.T) {
+	s := &session{t: t}
+	s.send("textDocument/didOpen", openParams(`{"version": "1", "file": "test.allium", "open_questions": ["Who approves refunds?"]}`))
+
+	msgs, _ := s.run()
+	published := diagnosticsFor(t, msgs)
+	if len(published) != 1 || len(published[0].Diagnostics) != 1 {
+		t.Fatalf("expected a single diagnostic, got %+v", published)
+	}
+	if d := published[0].Diagnostics[0]; d.Code != "WARN-02" || d.Severity != severityInformation {
+		t.Errorf("expected WARN-02 published as information, got %+v", d)
+	}
+}
@@ -0,0 +1,209 @@
+package migrate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// Object is a JSON object that keeps its keys in document order, so that a
+// migrated spec differs from the original only where a migration changed it.
+// Values are *Object, []any, string, json.Number, bool or nil.
+type Object struct {
+	keys   []string
+	values map[string]any
+}
+
+// NewObject returns an empty Object.
+func NewObject() *Object {
+	return &Object{values: make(map[string]any)}
+}
+
+// Keys returns the object's keys in order.
+func (o *Object) Keys() []string {
+	return slices.Clone(o.keys)
+}
+
+// Get returns the value of key and whether it is present.
+func (o *Object) Get(key string) (any, bool) {
+	v, ok := o.values[key]
+	return v, ok
+}
+
+// Set sets the value of key, appending it if it is not already present.
+func (o *Object) Set(key string, v any) {
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = v
+}
+
+// Delete removes key.
+func (o *Object) Delete(key string) {
+	if _, ok := o.values[key]; !ok {
+		return
+	}
+	delete(o.values, key)
+	o.keys = slices.DeleteFunc(o.keys, func(k string) bool { return k == key })
+}
+
+// RenameKey renames key from to key to in place, keeping its position. It
+// does nothing if from is absent, and reports an error if to is already
+// present.
+func (o *Object) RenameKey(from, to string) error {
+	v, ok := o.values[from]
+	if !ok {
+		return nil
+	}
+	if _, clash := o.values[to]; clash {
+		return fmt.Errorf("cannot rename %q to %q: %q is already present", from, to, to)
+	}
+	delete(o.values, from)
+	o.values[to] = v
+	o.keys[slices.Index(o.keys, from)] = to
+	return nil
+}
+
+// String returns the value of key if it is a string.
+func (o *Object) String(key string) (string, bool) {
+	s, ok := o.values[key].(string)
+	return s, ok
+}
+
+// Walk calls fn for every object within v, parents before their children.
+func Walk(v any, fn func(*Object) error) error {
+	switch v := v.(type) {
+	case *Object:
+		if err := fn(v); err != nil {
+			return err
+		}
+		for _, k := range v.keys {
+			if err := Walk(v.values[k], fn); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, e := range v {
+			if err := Walk(e, fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Decode parses a JSON document whose top level is an object.
+func Decode(data []byte) (*Object, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	v, err := decodeValue(dec)
+	if err != nil {
+		return nil, fmt.Errorf("parse document: %w", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("parse document: unexpected data after the top-level value")
+	}
+	obj, ok := v.(*Object)
+	if !ok {
+		return nil, fmt.Errorf("parse document: top level is not an object")
+	}
+	return obj, nil
+}
+
+func decodeValue(dec *json.Decoder) (any, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch tok {
+	case json.Delim('{'):
+		obj := NewObject()
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj.Set(keyTok.(string), v)
+		}
+		_, err := dec.Token() // closing brace
+		return obj, err
+	case json.Delim('['):
+		arr := []any{}
+		for dec.More() {
+			v, err := decodeValue(dec)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		_, err := dec.Token() // closing bracket
+		return arr, err
+	}
+	return tok, nil
+}
+
+// Encode formats the document as indented JSON with a trailing newline.
+func Encode(doc *Object) ([]byte, error) {
+	var compact bytes.Buffer
+	if err := encodeValue(&compact, doc); err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, compact.Bytes(), "", "  "); err != nil {
+		return nil, err
+	}
+	out.WriteByte('\n')
+	return out.Bytes(), nil
+}
+
+func encodeValue(b *bytes.Buffer, v any) error {
+	switch v := v.(type) {
+	case *Object:
+		b.WriteByte('{')
+		for i, k := range v.keys {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if err := encodeScalar(b, k); err != nil {
+				return err
+			}
+			b.WriteByte(':')
+			if err := encodeValue(b, v.values[k]); err != nil {
+				return err
+			}
+		}
+		b.WriteByte('}')
+	case []any:
+		b.WriteByte('[')
+		for i, e := range v {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if err := encodeValue(b, e); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(']')
+	default:
+		return encodeScalar(b, v)
+	}
+	return nil
+}
+
+// encodeScalar writes a JSON scalar without escaping HTML characters, which
+// are common in expressions such as "a < b".
+func encodeScalar(b *bytes.Buffer, v any) error {
+	enc := json.NewEncoder(b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	b.Truncate(b.Len() - 1) // Encode appends a newline
+	return nil
+}
@@ -0,0 +1,135 @@
+// Package migrate rewrites Allium spec documents written against an older
+// schema version so that they conform to a newer one. A Pipeline holds
+// migration steps between adjacent versions and chains them to reach the
+// requested version.
+package migrate
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Latest is the schema version the embedded schemas describe.
+const Latest = "1"
+
+// Migration rewrites a document from one schema version to the next.
+type Migration struct {
+	From        string
+	To          string
+	Description string
+
+	// Apply rewrites the document in place. The pipeline updates the version
+	// marker afterwards, so a step that changes nothing else leaves Apply nil.
+	Apply func(doc *Object) error
+}
+
+// Pipeline chains registered migrations between schema versions.
+type Pipeline struct {
+	steps []Migration
+}
+
+// NewPipeline creates a Pipeline with the built-in migrations registered.
+func NewPipeline() *Pipeline {
+	p := &Pipeline{}
+	p.Register(Migration{
+		From:        "0.4",
+		To:          "1",
+		Description: "update the version marker to the first stable schema",
+	})
+	return p
+}
+
+// Register adds a migration step. Versions are normalized as by
+// NormalizeVersion.
+func (p *Pipeline) Register(m Migration) {
+	m.From, m.To = NormalizeVersion(m.From), NormalizeVersion(m.To)
+	p.steps = append(p.steps, m)
+}
+
+// Migrations returns the registered steps in registration order.
+func (p *Pipeline) Migrations() []Migration {
+	return slices.Clone(p.steps)
+}
+
+// Plan returns the shortest sequence of steps leading from one version to
+// another, which is empty when they are the same version.
+func (p *Pipeline) Plan(from, to string) ([]Migration, error) {
+	from, to = NormalizeVersion(from), NormalizeVersion(to)
+	// Breadth-first search over versions, recording the step reaching each.
+	via := map[string]int{from: -1}
+	queue := []string{from}
+	for len(queue) > 0 && !containsKey(via, to) {
+		v := queue[0]
+		queue = queue[1:]
+		for i, m := range p.steps {
+			if m.From == v && !containsKey(via, m.To) {
+				via[m.To] = i
+				queue = append(queue, m.To)
+			}
+		}
+	}
+	if !containsKey(via, to) {
+		return nil, fmt.Errorf("no migration path from version %s to %s", from, to)
+	}
+	var plan []Migration
+	for v := to; via[v] >= 0; v = p.steps[via[v]].From {
+		plan = append(plan, p.steps[via[v]])
+	}
+	slices.Reverse(plan)
+	return plan, nil
+}
+
+func containsKey(m map[string]int, k string) bool {
+	_, ok := m[k]
+	return ok
+}
+
+// Migrate rewrites the spec document in data to version to and returns the
+// result with the steps applied. If from is empty, the document's own version
+// marker is used; otherwise it must agree with the marker, when present.
+// The result is not validated against the schema.
+func (p *Pipeline) Migrate(data []byte, from, to string) ([]byte, []Migration, error) {
+	doc, err := Decode(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	declared, hasVersion := doc.String("version")
+	switch {
+	case from == "" && !hasVersion:
+		return nil, nil, fmt.Errorf("document has no version marker; specify the version to migrate from")
+	case from == "":
+		from = declared
+	case hasVersion && NormalizeVersion(declared) != NormalizeVersion(from):
+		return nil, nil, fmt.Errorf("document declares version %s, not %s", declared, from)
+	}
+
+	plan, err := p.Plan(from, to)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, m := range plan {
+		if m.Apply != nil {
+			if err := m.Apply(doc); err != nil {
+				return nil, nil, fmt.Errorf("migrate %s to %s: %w", m.From, m.To, err)
+			}
+		}
+		doc.Set("version", m.To)
+	}
+	out, err := Encode(doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, plan, nil
+}
+
+// NormalizeVersion returns a version without a leading "v" or trailing ".0"
+// components, so that "0.4.0" and "0.4" name the same version, as do "v1"
+// and "1.0".
+func NormalizeVersion(v string) string {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	for strings.HasSuffix(v, ".0") {
+		v = strings.TrimSuffix(v, ".0")
+	}
+	return v
+}
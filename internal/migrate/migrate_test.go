@@ -0,0 +1,176 @@
+package migrate
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/foundry-zero/allium/internal/schema"
+)
+
+var refExample = filepath.Join("..", "..", "schemas", "v1", "examples", "password-auth.allium.json")
+
+func TestDecodeEncodePreservesOrder(t *testing.T) {
+	in := `{"version": "1", "file": "a.allium", "entities": [{"name": "A", "fields": []}], "n": 1.50, "html": "a < b && c"}`
+	doc, err := Decode([]byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(doc.Keys(), ","); got != "version,file,entities,n,html" {
+		t.Errorf("keys = %s", got)
+	}
+	out, err := Encode(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"n": 1.50`, `"html": "a < b && c"`, "\n  \"entities\": [\n"} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("encoded document missing %q:\n%s", want, out)
+		}
+	}
+
+	for _, bad := range []string{`[1]`, `{"a": 1} {}`, `{"a": }`} {
+		if _, err := Decode([]byte(bad)); err == nil {
+			t.Errorf("expected error decoding %s", bad)
+		}
+	}
+}
+
+func TestObjectEdits(t *testing.T) {
+	doc, err := Decode([]byte(`{"a": 1, "b": {"c": 2}, "d": [{"c": 3}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.RenameKey("a", "z"); err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.RenameKey("z", "b"); err == nil {
+		t.Error("expected error renaming onto an existing key")
+	}
+	doc.Delete("missing")
+	doc.Set("e", true)
+
+	var renamed int
+	err = Walk(doc, func(o *Object) error {
+		if _, ok := o.Get("c"); ok {
+			renamed++
+			return o.RenameKey("c", "y")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, _ := Encode(doc)
+	want := "{\n  \"z\": 1,\n  \"b\": {\n    \"y\": 2\n  },\n  \"d\": [\n    {\n      \"y\": 3\n    }\n  ],\n  \"e\": true\n}\n"
+	if renamed != 2 || string(out) != want {
+		t.Errorf("edited document (%d renames):\n%s\nwant:\n%s", renamed, out, want)
+	}
+}
+
+func TestNormalizeVersion(t *testing.T) {
+	for in, want := range map[string]string{"0.4.0": "0.4", "0.4": "0.4", "v1": "1", "1.0": "1", "0.10": "0.10"} {
+		if got := NormalizeVersion(in); got != want {
+			t.Errorf("NormalizeVersion(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestPlan(t *testing.T) {
+	p := &Pipeline{}
+	p.Register(Migration{From: "0.3", To: "0.4"})
+	p.Register(Migration{From: "0.4", To: "1"})
+	p.Register(Migration{From: "0.3", To: "0.3.1"})
+
+	plan, err := p.Plan("0.3.0", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan) != 2 || plan[0].To != "0.4" || plan[1].To != "1" {
+		t.Errorf("Plan(0.3, 1) = %+v", plan)
+	}
+	if plan, err := p.Plan("1", "1.0"); err != nil || len(plan) != 0 {
+		t.Errorf("Plan(1, 1) = %+v, %v, want empty", plan, err)
+	}
+	if _, err := p.Plan("1", "0.4"); err == nil {
+		t.Error("expected no path backwards")
+	}
+}
+
+func TestMigrateReferenceExample(t *testing.T) {
+	data, err := os.ReadFile(refExample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := bytes.Replace(data, []byte(`"version": "1"`), []byte(`"version": "0.4.0"`), 1)
+
+	p := NewPipeline()
+	out, applied, err := p.Migrate(old, "", Latest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 1 || applied[0].From != "0.4" {
+		t.Errorf("applied = %+v", applied)
+	}
+	sv, err := schema.NewSchemaValidator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := sv.ValidateBytes(out); len(errs) != 0 {
+		t.Errorf("migrated example fails schema validation: %v", errs)
+	}
+	// Key order is kept, so the version marker stays first.
+	if !bytes.HasPrefix(out, []byte("{\n  \"version\": \"1\",\n  \"file\"")) {
+		t.Errorf("unexpected migrated document start:\n%.80s", out)
+	}
+}
+
+func TestMigrateCustomStep(t *testing.T) {
+	p := NewPipeline()
+	p.Register(Migration{
+		From: "1",
+		To:   "2",
+		Apply: func(doc *Object) error {
+			return Walk(doc, func(o *Object) error {
+				if kind, _ := o.String("kind"); kind == "external_stimulus" {
+					return o.RenameKey("parameters", "params")
+				}
+				return nil
+			})
+		},
+	})
+	out, applied, err := p.Migrate([]byte(`{"version": "0.4", "rules": [{"trigger": {"kind": "external_stimulus", "parameters": []}}]}`), "0.4", "2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(applied) != 2 || !strings.Contains(string(out), `"version": "2"`) || !strings.Contains(string(out), `"params": []`) {
+		t.Errorf("applied %d steps:\n%s", len(applied), out)
+	}
+
+	p.Register(Migration{From: "2", To: "3", Apply: func(*Object) error { return os.ErrInvalid }})
+	if _, _, err := p.Migrate([]byte(`{"version": "2"}`), "", "3"); err == nil || !strings.Contains(err.Error(), "migrate 2 to 3") {
+		t.Errorf("expected step error, got %v", err)
+	}
+}
+
+func TestMigrateErrors(t *testing.T) {
+	p := NewPipeline()
+	for _, tt := range []struct {
+		doc, from, to string
+	}{
+		{`{"file": "a.allium"}`, "", "1"},
+		{`{"version": "1"}`, "0.4", "1"},
+		{`{"version": "0.3"}`, "", "1"},
+		{`not json`, "0.4", "1"},
+	} {
+		if _, _, err := p.Migrate([]byte(tt.doc), tt.from, tt.to); err == nil {
+			t.Errorf("Migrate(%s, %q, %q): expected error", tt.doc, tt.from, tt.to)
+		}
+	}
+	// A document without a marker can be migrated from a stated version.
+	if _, _, err := p.Migrate([]byte(`{"file": "a.allium"}`), "0.4", "1"); err != nil {
+		t.Errorf("Migrate with --from and no marker: %v", err)
+	}
+}
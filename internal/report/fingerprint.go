@@ -0,0 +1,20 @@
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Fingerprint returns a stable identifier for the finding, derived from its
+// rule, JSON path and message. It ignores the file name and the line and
+// column, so the fingerprint survives moving the spec and edits elsewhere in
+// the document that do not change the finding itself.
+func (f Finding) Fingerprint() string {
+	h := sha256.New()
+	h.Write([]byte(f.Rule))
+	h.Write([]byte{0})
+	h.Write([]byte(f.Location.Path))
+	h.Write([]byte{0})
+	h.Write([]byte(f.Message))
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
@@ -0,0 +1,67 @@
+package report
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// FormatGitHub returns the findings of the reports as GitHub Actions workflow
+// commands, one per line, such as
+//
+//	::error file=specs/orders.allium.json,line=40,col=9,title=RULE-12::type mismatch at $.rules[3]
+//
+// so that a workflow step running the checker annotates the lines of a pull
+// request. Errors and warnings keep their level; info findings and hints are
+// notices. Suppressed findings are left out.
+func FormatGitHub(reports []*Report) string {
+	var b strings.Builder
+	for _, r := range reports {
+		file := filepath.ToSlash(r.File)
+		for _, f := range r.Findings() {
+			props := []string{"file=" + escapeGitHubProperty(file)}
+			if f.Location.Line > 0 {
+				props = append(props, fmt.Sprintf("line=%d", f.Location.Line))
+				if f.Location.Column > 0 {
+					props = append(props, fmt.Sprintf("col=%d", f.Location.Column))
+				}
+				if f.Location.EndLine > 0 {
+					props = append(props, fmt.Sprintf("endLine=%d", f.Location.EndLine))
+					if f.Location.EndColumn > 0 {
+						props = append(props, fmt.Sprintf("endColumn=%d", f.Location.EndColumn))
+					}
+				}
+			}
+			props = append(props, "title="+escapeGitHubProperty(f.Rule))
+			msg := f.Message
+			if f.Location.Path != "" {
+				msg += " at " + f.Location.Path
+			}
+			fmt.Fprintf(&b, "::%s %s::%s\n", githubLevel(f.Severity), strings.Join(props, ","), escapeGitHubData(msg))
+		}
+	}
+	return b.String()
+}
+
+// githubLevel maps a finding severity to a workflow command.
+func githubLevel(s Severity) string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "notice"
+	}
+}
+
+// escapeGitHubData escapes the message of a workflow command.
+func escapeGitHubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeGitHubProperty escapes a property value of a workflow command, which
+// additionally may not contain the separators of properties.
+func escapeGitHubProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
@@ -0,0 +1,32 @@
+package report
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatGitHub(t *testing.T) {
+	r1 := NewReport("specs/orders.allium.json")
+	r1.AddFinding(NewError("RULE-12", "type mismatch", Location{Path: "$.rules[3].requires[1]", Line: 40, Column: 9, EndLine: 42, EndColumn: 10}))
+	r1.AddFinding(NewInfo("WARN-02", "Open questions present: 2 unresolved", Location{Path: "$.open_questions"}))
+	r1.AddSuppressed(NewWarning("WARN-20", "unconsumed", Location{Path: "$.rules[0]"}))
+	r2 := NewReport("specs/a,b.allium.json")
+	r2.AddFinding(NewWarning("WARN-16", "100% of\nthe time", Location{Path: "$.rules[1]", Line: 3}))
+
+	got := FormatGitHub([]*Report{r1, r2})
+	want := "::error file=specs/orders.allium.json,line=40,col=9,endLine=42,endColumn=10,title=RULE-12::type mismatch at $.rules[3].requires[1]\n" +
+		"::notice file=specs/orders.allium.json,title=WARN-02::Open questions present: 2 unresolved at $.open_questions\n" +
+		"::warning file=specs/a%2Cb.allium.json,line=3,title=WARN-16::100%25 of%0Athe time at $.rules[1]\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+	if strings.Contains(got, "WARN-20") {
+		t.Error("suppressed findings should not be annotated")
+	}
+}
+
+func TestFormatGitHubEmpty(t *testing.T) {
+	if got := FormatGitHub([]*Report{NewReport("a.allium.json")}); got != "" {
+		t.Errorf("expected no output for a clean report, got %q", got)
+	}
+}
@@ -0,0 +1,75 @@
+package report
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"path/filepath"
+)
+
+// gitlabIssue is an issue of a GitLab Code Quality report.
+type gitlabIssue struct {
+	Description string         `json:"description"`
+	CheckName   string         `json:"check_name"`
+	Fingerprint string         `json:"fingerprint"`
+	Severity    string         `json:"severity"`
+	Location    gitlabLocation `json:"location"`
+}
+
+type gitlabLocation struct {
+	Path  string      `json:"path"`
+	Lines gitlabLines `json:"lines"`
+}
+
+type gitlabLines struct {
+	Begin int `json:"begin"`
+}
+
+// FormatGitLab returns the findings of the reports as a GitLab Code Quality
+// report, a JSON array of issues that a CI job publishes as a
+// codequality artifact so merge requests show them inline. Errors are major
+// issues, warnings minor and info findings and hints info. A finding without
+// a known line is placed on line 1. Suppressed findings are left out.
+func FormatGitLab(reports []*Report) ([]byte, error) {
+	issues := []gitlabIssue{}
+	for _, r := range reports {
+		file := filepath.ToSlash(r.File)
+		for _, f := range r.Findings() {
+			desc := f.Message
+			if f.Location.Path != "" {
+				desc += " at " + f.Location.Path
+			}
+			issues = append(issues, gitlabIssue{
+				Description: desc,
+				CheckName:   f.Rule,
+				Fingerprint: gitlabFingerprint(file, f),
+				Severity:    gitlabSeverity(f.Severity),
+				Location:    gitlabLocation{Path: file, Lines: gitlabLines{Begin: max(f.Location.Line, 1)}},
+			})
+		}
+	}
+	return json.MarshalIndent(issues, "", "  ")
+}
+
+// gitlabFingerprint identifies a finding across pipelines. GitLab compares
+// issues of every file by fingerprint, so unlike Finding.Fingerprint it
+// includes the file.
+func gitlabFingerprint(file string, f Finding) string {
+	h := sha256.New()
+	h.Write([]byte(file))
+	h.Write([]byte{0})
+	h.Write([]byte(f.Fingerprint()))
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// gitlabSeverity maps a finding severity to a Code Quality severity.
+func gitlabSeverity(s Severity) string {
+	switch s {
+	case SeverityError:
+		return "major"
+	case SeverityWarning:
+		return "minor"
+	default:
+		return "info"
+	}
+}
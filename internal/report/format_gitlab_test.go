@@ -0,0 +1,61 @@
+package report
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestFormatGitLab(t *testing.T) {
+	r1 := NewReport("specs/orders.allium.json")
+	r1.AddFinding(NewError("RULE-12", "type mismatch", Location{Path: "$.rules[3].requires[1]", Line: 40, Column: 9}))
+	r1.AddFinding(NewWarning("WARN-16", "optional field", Location{Path: "$.rules[1]", Line: 12}))
+	r1.AddFinding(NewInfo("WARN-02", "open questions", Location{Path: "$.open_questions"}))
+	r1.AddSuppressed(NewWarning("WARN-20", "unconsumed", Location{Path: "$.rules[0]"}))
+	// The same finding in another file is a different issue.
+	r2 := NewReport("specs/billing.allium.json")
+	r2.AddFinding(NewError("RULE-12", "type mismatch", Location{Path: "$.rules[3].requires[1]", Line: 40, Column: 9}))
+
+	data, err := FormatGitLab([]*Report{r1, r2})
+	if err != nil {
+		t.Fatalf("FormatGitLab: %v", err)
+	}
+	var issues []gitlabIssue
+	if err := json.Unmarshal(data, &issues); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(issues) != 4 {
+		t.Fatalf("expected 4 issues, got %s", data)
+	}
+
+	first := issues[0]
+	if first.CheckName != "RULE-12" || first.Severity != "major" || first.Description != "type mismatch at $.rules[3].requires[1]" {
+		t.Errorf("unexpected issue %+v", first)
+	}
+	if first.Location.Path != "specs/orders.allium.json" || first.Location.Lines.Begin != 40 {
+		t.Errorf("unexpected location %+v", first.Location)
+	}
+	if issues[1].Severity != "minor" || issues[2].Severity != "info" {
+		t.Errorf("severities = %s, %s, want minor, info", issues[1].Severity, issues[2].Severity)
+	}
+	if issues[2].Location.Lines.Begin != 1 {
+		t.Errorf("expected a finding without a line on line 1, got %d", issues[2].Location.Lines.Begin)
+	}
+
+	seen := make(map[string]bool)
+	for _, issue := range issues {
+		if issue.Fingerprint == "" || seen[issue.Fingerprint] {
+			t.Errorf("fingerprint %q is empty or repeated", issue.Fingerprint)
+		}
+		seen[issue.Fingerprint] = true
+	}
+}
+
+func TestFormatGitLabEmpty(t *testing.T) {
+	data, err := FormatGitLab([]*Report{NewReport("a.allium.json")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "[]" {
+		t.Errorf("expected an empty array, got %s", data)
+	}
+}
@@ -0,0 +1,227 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+)
+
+// HTMLOptions configures FormatHTML.
+type HTMLOptions struct {
+	// ToolVersion is shown in the page footer.
+	ToolVersion string
+	// RuleTitles maps rule IDs to the titles shown beside them.
+	RuleTitles map[string]string
+	// Source returns the contents of a report's file, from which the lines
+	// around each located finding are shown. Without it, or when it fails,
+	// findings are shown without snippets.
+	Source func(file string) ([]byte, error)
+}
+
+// snippetContext is the number of lines shown before and after the line of a
+// finding.
+const snippetContext = 2
+
+const htmlStyle = `body { font-family: sans-serif; max-width: 70em; margin: 2em auto; color: #222; }
+nav ul { list-style: none; padding-left: 0; }
+summary { cursor: pointer; }
+details.file { border: 1px solid #ccc; border-radius: 4px; margin: 1em 0; padding: 0.5em 1em; }
+details.file > summary { font-size: 1.2em; font-weight: bold; }
+details.rule { margin: 0.5em 0 0.5em 1em; }
+.finding { margin: 0.5em 0 0.5em 1.5em; }
+.badge { display: inline-block; border-radius: 3px; padding: 0 0.4em; font-size: 0.85em; color: #fff; }
+.badge.error { background: #c62828; }
+.badge.warning { background: #ef8f00; }
+.badge.info { background: #1565c0; }
+.badge.hint { background: #00838f; }
+.badge.suppressed { background: #757575; }
+.badge.ok { background: #2e7d32; }
+pre { background: #f6f8fa; padding: 0.5em; overflow-x: auto; }
+pre .hit { background: #fff3b0; display: block; }
+footer { color: #777; font-size: 0.85em; margin-top: 2em; }
+`
+
+// FormatHTML returns the reports as a standalone HTML page: a summary with a
+// link to each file, then a collapsible section per file with its findings
+// grouped by rule. Files with errors are expanded; suppressed findings are
+// listed in a collapsed section of their own.
+func FormatHTML(reports []*Report, opts HTMLOptions) []byte {
+	var b strings.Builder
+	var total Summary
+	for _, r := range reports {
+		total.ErrorCount += r.Summary.ErrorCount
+		total.WarningCount += r.Summary.WarningCount
+		total.InfoCount += r.Summary.InfoCount
+		total.HintCount += r.Summary.HintCount
+	}
+
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>Allium check report</title>\n")
+	b.WriteString("<style>\n" + htmlStyle + "</style>\n</head>\n<body>\n")
+	b.WriteString("<h1>Allium check report</h1>\n")
+	counts := []string{countBadge(total.ErrorCount, "error", "errors"), countBadge(total.WarningCount, "warning", "warnings")}
+	if total.InfoCount > 0 {
+		counts = append(counts, countBadge(total.InfoCount, "info", "info"))
+	}
+	if total.HintCount > 0 {
+		counts = append(counts, countBadge(total.HintCount, "hint", "hints"))
+	}
+	fmt.Fprintf(&b, "<p>%d files, %s</p>\n", len(reports), strings.Join(counts, ", "))
+
+	b.WriteString("<nav>\n<ul>\n")
+	for i, r := range reports {
+		fmt.Fprintf(&b, "<li>%s <a href=\"#file-%d\">%s</a></li>\n", statusBadge(r), i, html.EscapeString(r.File))
+	}
+	b.WriteString("</ul>\n</nav>\n")
+
+	for i, r := range reports {
+		writeHTMLFile(&b, r, fmt.Sprintf("file-%d", i), opts)
+	}
+
+	b.WriteString("<footer>Generated by allium-check")
+	if opts.ToolVersion != "" {
+		b.WriteString(" " + html.EscapeString(opts.ToolVersion))
+	}
+	b.WriteString("</footer>\n</body>\n</html>\n")
+	return []byte(b.String())
+}
+
+func writeHTMLFile(b *strings.Builder, r *Report, id string, opts HTMLOptions) {
+	var lines []string
+	if opts.Source != nil {
+		if data, err := opts.Source(r.File); err == nil {
+			lines = strings.Split(string(data), "\n")
+		}
+	}
+
+	open := ""
+	if r.HasErrors() {
+		open = " open"
+	}
+	fmt.Fprintf(b, "<details class=\"file\" id=\"%s\"%s>\n<summary>%s %s</summary>\n", id, open, html.EscapeString(r.File), statusBadge(r))
+	if !r.SchemaValid && r.HasErrors() {
+		b.WriteString("<p>The file does not conform to the JSON Schema.</p>\n")
+	}
+
+	findings := r.Findings()
+	if len(findings) == 0 {
+		b.WriteString("<p>No findings.</p>\n")
+	}
+	for _, group := range groupByRule(findings) {
+		writeHTMLRule(b, group, id, opts, lines, "")
+	}
+	if len(r.Suppressed) > 0 {
+		fmt.Fprintf(b, "<details class=\"rule\">\n<summary>Suppressed %s</summary>\n", countBadge(len(r.Suppressed), "suppressed", "suppressed"))
+		for _, group := range groupByRule(r.Suppressed) {
+			writeHTMLRule(b, group, id+"-suppressed", opts, lines, "suppressed")
+		}
+		b.WriteString("</details>\n")
+	}
+	b.WriteString("</details>\n")
+}
+
+// writeHTMLRule writes a collapsible section for the findings of one rule.
+// A non-empty badge replaces each finding's severity badge.
+func writeHTMLRule(b *strings.Builder, findings []Finding, id string, opts HTMLOptions, lines []string, badge string) {
+	rule := findings[0].Rule
+	title := rule
+	if t := opts.RuleTitles[rule]; t != "" {
+		title += ": " + t
+	}
+	fmt.Fprintf(b, "<details class=\"rule\" id=\"%s-%s\" open>\n<summary>%s (%d)</summary>\n",
+		id, html.EscapeString(rule), html.EscapeString(title), len(findings))
+	for _, f := range findings {
+		class := badge
+		if class == "" {
+			class = f.Severity.String()
+		}
+		fmt.Fprintf(b, "<div class=\"finding\">\n<p><span class=\"badge %s\">%s</span> %s</p>\n<p>at <code>%s</code>",
+			class, class, html.EscapeString(f.Message), html.EscapeString(f.Location.Path))
+		if f.Location.Line > 0 {
+			fmt.Fprintf(b, ", line %d", f.Location.Line)
+		}
+		b.WriteString("</p>\n")
+		writeSnippet(b, lines, f.Location.Line)
+		b.WriteString("</div>\n")
+	}
+	b.WriteString("</details>\n")
+}
+
+// writeSnippet writes the source lines around line, numbered and with line
+// itself highlighted. Nothing is written when the line is unknown or out of
+// range.
+func writeSnippet(b *strings.Builder, lines []string, line int) {
+	if line <= 0 || line > len(lines) {
+		return
+	}
+	first := max(line-snippetContext, 1)
+	last := min(line+snippetContext, len(lines))
+	width := len(fmt.Sprint(last))
+	b.WriteString("<pre>")
+	for n := first; n <= last; n++ {
+		text := fmt.Sprintf("%*d  %s", width, n, html.EscapeString(strings.TrimRight(lines[n-1], "\r")))
+		if n == line {
+			fmt.Fprintf(b, "<span class=\"hit\">%s</span>", text)
+		} else {
+			b.WriteString(text + "\n")
+		}
+	}
+	b.WriteString("</pre>\n")
+}
+
+// groupByRule groups findings by rule, keeping their order within a rule.
+// Rules with errors come first, then rules are ordered by ID.
+func groupByRule(findings []Finding) [][]Finding {
+	index := make(map[string]int)
+	var groups [][]Finding
+	for _, f := range findings {
+		i, ok := index[f.Rule]
+		if !ok {
+			i = len(groups)
+			index[f.Rule] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], f)
+	}
+	hasError := func(g []Finding) bool {
+		for _, f := range g {
+			if f.Severity == SeverityError {
+				return true
+			}
+		}
+		return false
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if ei, ej := hasError(groups[i]), hasError(groups[j]); ei != ej {
+			return ei
+		}
+		return groups[i][0].Rule < groups[j][0].Rule
+	})
+	return groups
+}
+
+// statusBadge summarizes a report's counts as badges, or a single "valid"
+// badge when it has no findings.
+func statusBadge(r *Report) string {
+	if len(r.Findings()) == 0 {
+		return `<span class="badge ok">valid</span>`
+	}
+	var badges []string
+	if r.Summary.ErrorCount > 0 {
+		badges = append(badges, countBadge(r.Summary.ErrorCount, "error", "errors"))
+	}
+	if r.Summary.WarningCount > 0 {
+		badges = append(badges, countBadge(r.Summary.WarningCount, "warning", "warnings"))
+	}
+	if r.Summary.InfoCount > 0 {
+		badges = append(badges, countBadge(r.Summary.InfoCount, "info", "info"))
+	}
+	if r.Summary.HintCount > 0 {
+		badges = append(badges, countBadge(r.Summary.HintCount, "hint", "hints"))
+	}
+	return strings.Join(badges, " ")
+}
+
+func countBadge(n int, class, noun string) string {
+	return fmt.Sprintf("<span class=\"badge %s\">%d %s</span>", class, n, noun)
+}
@@ -0,0 +1,56 @@
+package report
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFormatHTML(t *testing.T) {
+	r1 := NewReport("specs/orders.allium.json")
+	r1.SchemaValid = true
+	r1.AddFinding(NewWarning("WARN-02", "open questions", Location{Path: "$.open_questions"}))
+	r1.AddFinding(NewError("RULE-12", "type <mismatch>", Location{Path: "$.rules[0].requires[0]", Line: 3}))
+	r1.AddFinding(NewError("RULE-12", "another mismatch", Location{Path: "$.rules[0].requires[1]", Line: 4}))
+	r1.AddSuppressed(NewError("RULE-08", "terminal state", Location{Path: "$.entities[0]"}))
+	r2 := NewReport("specs/billing.allium.json")
+	r2.SchemaValid = true
+
+	source := func(file string) ([]byte, error) {
+		if file != "specs/orders.allium.json" {
+			return nil, errors.New("not found")
+		}
+		return []byte("{\n  \"rules\": [\n    \"a < b\",\n    \"c\"\n  ]\n}\n"), nil
+	}
+	out := string(FormatHTML([]*Report{r1, r2}, HTMLOptions{
+		ToolVersion: "1.2.3",
+		RuleTitles:  map[string]string{"RULE-12": "Type mismatch"},
+		Source:      source,
+	}))
+
+	for _, want := range []string{
+		`<a href="#file-0">specs/orders.allium.json</a>`,
+		`<span class="badge ok">valid</span> <a href="#file-1">specs/billing.allium.json</a>`,
+		`<details class="file" id="file-0" open>`,
+		`<details class="file" id="file-1">`,
+		`<summary>RULE-12: Type mismatch (2)</summary>`,
+		`<summary>WARN-02 (1)</summary>`,
+		`<span class="badge error">error</span> type &lt;mismatch&gt;`,
+		`<span class="badge suppressed">suppressed</span> terminal state`,
+		"<pre>1  {\n2    &#34;rules&#34;: [\n<span class=\"hit\">3      &#34;a &lt; b&#34;,</span>4      &#34;c&#34;\n5    ]\n</pre>",
+		"Generated by allium-check 1.2.3",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+
+	// Rules with errors come before rules with only warnings.
+	if strings.Index(out, "RULE-12: Type mismatch") > strings.Index(out, "WARN-02 (1)") {
+		t.Error("RULE-12 should be listed before WARN-02")
+	}
+	// The billing report has no source, and no findings to show it for.
+	if !strings.Contains(out, "<p>No findings.</p>") {
+		t.Error("expected an empty report to say it has no findings")
+	}
+}
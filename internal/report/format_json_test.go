@@ -2,7 +2,9 @@ package report
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestFormatJSONEmpty(t *testing.T) {
@@ -157,3 +159,32 @@ func TestFormatJSONRequiredKeys(t *testing.T) {
 		}
 	}
 }
+
+func TestFormatJSONTimings(t *testing.T) {
+	r := NewReport("timed.allium.json")
+	if data, _ := FormatJSON(r); strings.Contains(string(data), "timings") {
+		t.Errorf("timings should be omitted when not recorded:\n%s", data)
+	}
+
+	r.AddTiming("schema", 3*time.Millisecond)
+	r.AddTiming("references", 500*time.Microsecond)
+	if r.Elapsed() != 3500*time.Microsecond {
+		t.Errorf("Elapsed = %s", r.Elapsed())
+	}
+	data, err := FormatJSON(r)
+	if err != nil {
+		t.Fatalf("FormatJSON: %v", err)
+	}
+	var m struct {
+		Timings []map[string]any `json:"timings"`
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(m.Timings) != 2 || m.Timings[0]["phase"] != "schema" || m.Timings[0]["duration_ns"] != float64(3e6) {
+		t.Errorf("timings = %v", m.Timings)
+	}
+	if grouped, _ := FormatJSONGrouped(r, 0); !strings.Contains(string(grouped), `"duration_ns": 500000`) {
+		t.Errorf("grouped JSON should keep the timings:\n%s", grouped)
+	}
+}
@@ -0,0 +1,182 @@
+package report
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+)
+
+// SARIF log structure (subset of SARIF 2.1.0 used by code scanning consumers).
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name    string      `json:"name"`
+	Version string      `json:"version,omitempty"`
+	Rules   []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID           string             `json:"ruleId"`
+	RuleIndex        int                `json:"ruleIndex"`
+	Level            string             `json:"level"`
+	Message          sarifMessage       `json:"message"`
+	Locations        []sarifLocation    `json:"locations"`
+	RelatedLocations []sarifLocation    `json:"relatedLocations,omitempty"`
+	Suppressions     []sarifSuppression `json:"suppressions,omitempty"`
+}
+
+type sarifSuppression struct {
+	Kind string `json:"kind"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+	Message          *sarifMessage          `json:"message,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// FormatSARIF returns the reports as a single SARIF 2.1.0 log with one run.
+// Each finding becomes a result located in its report's file; the finding's
+// JSON path is recorded as a logical location and its line, when known, as
+// the region start line.
+func FormatSARIF(reports []*Report, toolVersion string) ([]byte, error) {
+	seen := make(map[string]bool)
+	var ruleIDs []string
+	for _, r := range reports {
+		for _, findings := range [][]Finding{r.Findings(), r.Suppressed} {
+			for _, f := range findings {
+				if !seen[f.Rule] {
+					seen[f.Rule] = true
+					ruleIDs = append(ruleIDs, f.Rule)
+				}
+			}
+		}
+	}
+	sort.Strings(ruleIDs)
+	ruleIndex := make(map[string]int, len(ruleIDs))
+	rules := make([]sarifRule, len(ruleIDs))
+	for i, id := range ruleIDs {
+		ruleIndex[id] = i
+		rules[i] = sarifRule{ID: id}
+	}
+
+	results := []sarifResult{}
+	for _, r := range reports {
+		uri := filepath.ToSlash(r.File)
+		for _, f := range r.Findings() {
+			results = append(results, sarifResultFor(f, uri, ruleIndex[f.Rule]))
+		}
+		for _, f := range r.Suppressed {
+			// Suppressed in the spec itself; consumers hide these by default.
+			res := sarifResultFor(f, uri, ruleIndex[f.Rule])
+			res.Suppressions = []sarifSuppression{{Kind: "inSource"}}
+			results = append(results, res)
+		}
+	}
+
+	log := sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:    "allium-check",
+				Version: toolVersion,
+				Rules:   rules,
+			}},
+			Results: results,
+		}},
+	}
+	return json.MarshalIndent(log, "", "  ")
+}
+
+func sarifResultFor(f Finding, uri string, ruleIdx int) sarifResult {
+	result := sarifResult{
+		RuleID:    f.Rule,
+		RuleIndex: ruleIdx,
+		Level:     sarifLevel(f.Severity),
+		Message:   sarifMessage{Text: f.Message},
+		Locations: []sarifLocation{sarifLocationFor(f.Location, uri)},
+	}
+	for _, r := range f.Related {
+		loc := sarifLocationFor(r.Location, uri)
+		loc.Message = &sarifMessage{Text: r.Message}
+		result.RelatedLocations = append(result.RelatedLocations, loc)
+	}
+	return result
+}
+
+// sarifLocationFor returns the SARIF location of loc in the file at uri.
+func sarifLocationFor(l Location, uri string) sarifLocation {
+	loc := sarifLocation{
+		PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: uri}},
+	}
+	if l.Line > 0 {
+		loc.PhysicalLocation.Region = &sarifRegion{StartLine: l.Line, StartColumn: l.Column,
+			EndLine: l.EndLine, EndColumn: l.EndColumn}
+	}
+	if l.Path != "" {
+		loc.LogicalLocations = []sarifLogicalLocation{{FullyQualifiedName: l.Path, Kind: "element"}}
+	}
+	return loc
+}
+
+// sarifLevel maps a finding severity to a SARIF result level. SARIF has no
+// level below "note", so info findings and hints share it.
+func sarifLevel(s Severity) string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "note"
+	}
+}
@@ -0,0 +1,139 @@
+package report
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestFormatSARIF(t *testing.T) {
+	r1 := NewReport("specs/orders.allium.json")
+	r1.AddFinding(NewError("RULE-12", "type mismatch", Location{File: "orders.allium", Path: "$.rules[3].requires[1]", Line: 40, Column: 9, EndLine: 42, EndColumn: 10}))
+	r1.AddFinding(NewWarning("WARN-02", "open questions", Location{File: "orders.allium", Path: "$.open_questions"}))
+	r2 := NewReport("specs/billing.allium.json")
+	r2.AddFinding(NewError("RULE-01", "undeclared entity", Location{File: "billing.allium", Path: "$.entities[0]"}))
+
+	data, err := FormatSARIF([]*Report{r1, r2}, "1.2.3")
+	if err != nil {
+		t.Fatalf("FormatSARIF: %v", err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if log.Version != "2.1.0" {
+		t.Errorf("version = %q", log.Version)
+	}
+	if len(log.Runs) != 1 {
+		t.Fatalf("expected 1 run, got %d", len(log.Runs))
+	}
+	run := log.Runs[0]
+	if run.Tool.Driver.Name != "allium-check" || run.Tool.Driver.Version != "1.2.3" {
+		t.Errorf("driver = %+v", run.Tool.Driver)
+	}
+
+	wantRules := []string{"RULE-01", "RULE-12", "WARN-02"}
+	if len(run.Tool.Driver.Rules) != len(wantRules) {
+		t.Fatalf("rules = %+v", run.Tool.Driver.Rules)
+	}
+	for i, id := range wantRules {
+		if run.Tool.Driver.Rules[i].ID != id {
+			t.Errorf("rules[%d] = %q, want %q", i, run.Tool.Driver.Rules[i].ID, id)
+		}
+	}
+
+	if len(run.Results) != 3 {
+		t.Fatalf("expected 3 results, got %d", len(run.Results))
+	}
+	first := run.Results[0]
+	if first.RuleID != "RULE-12" || first.RuleIndex != 1 || first.Level != "error" {
+		t.Errorf("first result = %+v", first)
+	}
+	loc := first.Locations[0]
+	if loc.PhysicalLocation.ArtifactLocation.URI != "specs/orders.allium.json" {
+		t.Errorf("uri = %q", loc.PhysicalLocation.ArtifactLocation.URI)
+	}
+	if region := loc.PhysicalLocation.Region; region == nil || *region != (sarifRegion{StartLine: 40, StartColumn: 9, EndLine: 42, EndColumn: 10}) {
+		t.Errorf("region = %+v", loc.PhysicalLocation.Region)
+	}
+	if len(loc.LogicalLocations) != 1 || loc.LogicalLocations[0].FullyQualifiedName != "$.rules[3].requires[1]" {
+		t.Errorf("logical locations = %+v", loc.LogicalLocations)
+	}
+
+	warn := run.Results[1]
+	if warn.Level != "warning" {
+		t.Errorf("warning level = %q", warn.Level)
+	}
+	if warn.Locations[0].PhysicalLocation.Region != nil {
+		t.Error("region should be omitted when line is unknown")
+	}
+}
+
+func TestFormatSARIFEmpty(t *testing.T) {
+	data, err := FormatSARIF([]*Report{NewReport("clean.allium.json")}, "")
+	if err != nil {
+		t.Fatalf("FormatSARIF: %v", err)
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	run := m["runs"].([]any)[0].(map[string]any)
+	if results, ok := run["results"].([]any); !ok || len(results) != 0 {
+		t.Errorf("results should be an empty array, got %v", run["results"])
+	}
+}
+
+func TestFormatSARIFSuppressed(t *testing.T) {
+	r := NewReport("specs/orders.allium.json")
+	r.AddFinding(NewError("RULE-12", "type mismatch", Location{Path: "$.rules[3]"}))
+	r.AddSuppressed(NewWarning("WARN-20", "unconsumed", Location{Path: "$.rules[0]"}))
+
+	data, err := FormatSARIF([]*Report{r}, "")
+	if err != nil {
+		t.Fatalf("FormatSARIF: %v", err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+
+	results := log.Runs[0].Results
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if len(results[0].Suppressions) != 0 {
+		t.Errorf("active result should not be suppressed: %+v", results[0])
+	}
+	if s := results[1].Suppressions; len(s) != 1 || s[0].Kind != "inSource" {
+		t.Errorf("suppressed result = %+v", results[1])
+	}
+	if rules := log.Runs[0].Tool.Driver.Rules; len(rules) != 2 || rules[1].ID != "WARN-20" {
+		t.Errorf("suppressed rule missing from driver rules: %+v", rules)
+	}
+}
+
+func TestFormatSARIFRelated(t *testing.T) {
+	r := NewReport("specs/orders.allium.json")
+	r.AddFinding(NewError("RULE-08", "dead end", Location{Path: "$.entities[0]"}).
+		WithRelated("rule 'Block' sets it", Location{Path: "$.rules[2]", Line: 40, Column: 5}))
+
+	data, err := FormatSARIF([]*Report{r}, "")
+	if err != nil {
+		t.Fatalf("FormatSARIF: %v", err)
+	}
+	var log sarifLog
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	related := log.Runs[0].Results[0].RelatedLocations
+	if len(related) != 1 {
+		t.Fatalf("expected 1 related location, got %+v", related)
+	}
+	if related[0].Message == nil || related[0].Message.Text != "rule 'Block' sets it" {
+		t.Errorf("related message = %+v", related[0].Message)
+	}
+	if region := related[0].PhysicalLocation.Region; region == nil || region.StartLine != 40 {
+		t.Errorf("related region = %+v", region)
+	}
+}
@@ -3,31 +3,201 @@ package report
 import (
 	"fmt"
 	"strings"
+	"unicode/utf8"
 )
 
+// TextOptions configures FormatTextWith and FormatTextGroupedWith. The zero
+// value gives the plain format of FormatText, suited to logs and files.
+type TextOptions struct {
+	// Color marks each finding with a severity icon and colors severities,
+	// rule IDs, locations and snippets with ANSI escape codes.
+	Color bool
+	// Source returns the contents of a report's file, as for HTMLOptions.
+	// Each located finding is then followed by its source line, with the
+	// value it points at underlined. Without it, or when it fails, findings
+	// are shown without snippets. Grouped findings never have snippets.
+	Source func(file string) ([]byte, error)
+	// Explain returns an explanation of a rule, written indented under
+	// each of its findings, or "" for none. Grouped findings are explained
+	// once per group.
+	Explain func(rule string) string
+}
+
+// ANSI escape codes used when TextOptions.Color is set.
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiDim    = "\x1b[2m"
+	ansiRed    = "\x1b[31m"
+	ansiYellow = "\x1b[33m"
+	ansiBlue   = "\x1b[34m"
+	ansiCyan   = "\x1b[36m"
+)
+
+// severityStyles gives the color and icon of each severity.
+var severityStyles = map[Severity]struct{ color, icon string }{
+	SeverityError:   {ansiRed, "✖"},
+	SeverityWarning: {ansiYellow, "⚠"},
+	SeverityInfo:    {ansiBlue, "ℹ"},
+	SeverityHint:    {ansiCyan, "•"},
+}
+
 // FormatText returns a human-readable string representation of the report.
-// Each finding is on its own line with rule ID, severity, message, and location.
-// A summary line is appended at the end.
+// Each finding is on its own line with rule ID, severity, message, and location,
+// followed by the edits of any suggested fixes.
+// A summary line is appended at the end, counting info findings and hints
+// only when there are any.
 func FormatText(r *Report) string {
+	return FormatTextWith(r, TextOptions{})
+}
+
+// FormatTextWith is FormatText with the colors, source snippets and
+// explanations opts asks for.
+func FormatTextWith(r *Report, opts TextOptions) string {
 	var b strings.Builder
+	style := textStyle{color: opts.Color}
 
-	fmt.Fprintf(&b, "File: %s\n", r.File)
+	fmt.Fprintf(&b, "File: %s\n", style.paint(ansiBold, r.File))
 
-	for _, f := range r.Errors {
-		writeFinding(&b, f)
+	var lines []string
+	if opts.Source != nil {
+		if data, err := opts.Source(r.File); err == nil {
+			lines = strings.Split(string(data), "\n")
+		}
 	}
-	for _, f := range r.Warnings {
-		writeFinding(&b, f)
+	for _, f := range r.Findings() {
+		writeFinding(&b, f, style, lines)
+		writeExplanation(&b, f.Rule, opts.Explain)
 	}
 
-	fmt.Fprintf(&b, "\n%d errors, %d warnings\n", r.Summary.ErrorCount, r.Summary.WarningCount)
+	writeSummary(&b, r, style)
 	return b.String()
 }
 
-func writeFinding(b *strings.Builder, f Finding) {
-	loc := f.Location.Path
-	if f.Location.Line > 0 {
-		loc = fmt.Sprintf("%s (line %d)", loc, f.Location.Line)
+// textStyle applies the colors of TextOptions, or nothing when color is off.
+type textStyle struct {
+	color bool
+}
+
+// paint wraps text in the given escape code when coloring.
+func (s textStyle) paint(code, text string) string {
+	if !s.color || text == "" {
+		return text
+	}
+	return code + text + ansiReset
+}
+
+// header returns the start of a finding's line: its rule and severity, as in
+// "[RULE-12] error", preceded by a colored icon when coloring.
+func (s textStyle) header(rule string, sev Severity) string {
+	if !s.color {
+		return fmt.Sprintf("[%s] %s", rule, sev)
+	}
+	st := severityStyles[sev]
+	return fmt.Sprintf("%s %s %s", s.paint(st.color, st.icon), s.paint(ansiBold, "["+rule+"]"), s.paint(ansiBold+st.color, sev.String()))
+}
+
+// writeSummary writes the blank line and summary line that end a text
+// report.
+func writeSummary(b *strings.Builder, r *Report, style textStyle) {
+	count := func(n int, sev Severity, noun string) string {
+		text := fmt.Sprintf("%d %s", n, noun)
+		if n == 0 {
+			return text
+		}
+		return style.paint(severityStyles[sev].color, text)
+	}
+	fmt.Fprintf(b, "\n%s, %s", count(r.Summary.ErrorCount, SeverityError, "errors"), count(r.Summary.WarningCount, SeverityWarning, "warnings"))
+	if r.Summary.InfoCount > 0 {
+		fmt.Fprintf(b, ", %s", count(r.Summary.InfoCount, SeverityInfo, "info"))
+	}
+	if r.Summary.HintCount > 0 {
+		fmt.Fprintf(b, ", %s", count(r.Summary.HintCount, SeverityHint, "hints"))
+	}
+	if r.Summary.SuppressedCount > 0 {
+		fmt.Fprintf(b, " (%d suppressed)", r.Summary.SuppressedCount)
+	}
+	b.WriteString("\n")
+}
+
+// formatLocation returns the JSON path of loc with its line and column,
+// when known.
+func formatLocation(loc Location) string {
+	if loc.Line > 0 && loc.Column > 0 {
+		return fmt.Sprintf("%s (line %d, column %d)", loc.Path, loc.Line, loc.Column)
+	} else if loc.Line > 0 {
+		return fmt.Sprintf("%s (line %d)", loc.Path, loc.Line)
+	}
+	return loc.Path
+}
+
+// writeFinding writes a finding, followed by its source line when lines
+// holds the file's source, its related locations and its suggested fixes.
+func writeFinding(b *strings.Builder, f Finding, style textStyle, lines []string) {
+	fmt.Fprintf(b, "  %s: %s at %s\n", style.header(f.Rule, f.Severity), f.Message, style.paint(ansiDim, formatLocation(f.Location)))
+	writeTextSnippet(b, lines, f.Location, style, severityStyles[f.Severity].color)
+	for _, r := range f.Related {
+		fmt.Fprintf(b, "    related: %s at %s\n", r.Message, style.paint(ansiDim, formatLocation(r.Location)))
+	}
+	for _, s := range f.Suggestions {
+		fmt.Fprintf(b, "    suggestion: %s\n", s.Description)
+		for _, e := range s.Edits {
+			if e.Value == nil {
+				fmt.Fprintf(b, "      %s %s\n", e.Op, e.Path)
+			} else {
+				fmt.Fprintf(b, "      %s %s = %s\n", e.Op, e.Path, e.Value)
+			}
+		}
+	}
+}
+
+// writeExplanation writes explain's explanation of rule, indented under
+// its finding, when there is one.
+func writeExplanation(b *strings.Builder, rule string, explain func(string) string) {
+	if explain == nil {
+		return
+	}
+	text := strings.TrimRight(explain(rule), "\n")
+	if text == "" {
+		return
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if line == "" {
+			b.WriteString("\n")
+		} else {
+			fmt.Fprintf(b, "    %s\n", line)
+		}
+	}
+}
+
+// writeTextSnippet writes the source line of loc, numbered, and a line
+// underlining the value at loc: to its end when it ends on the same line, or
+// to the end of the line otherwise. Nothing is written when the line or
+// column is unknown or out of range.
+func writeTextSnippet(b *strings.Builder, lines []string, loc Location, style textStyle, color string) {
+	if loc.Line <= 0 || loc.Line > len(lines) || loc.Column <= 0 {
+		return
+	}
+	text := strings.TrimRight(lines[loc.Line-1], "\r")
+	length := utf8.RuneCountInString(text)
+	if loc.Column > length {
+		return
+	}
+	end := length + 1
+	if loc.EndLine == loc.Line && loc.EndColumn > loc.Column {
+		end = min(loc.EndColumn, end)
+	}
+
+	// The underline keeps the line's tabs so that it stays aligned.
+	var pad strings.Builder
+	for _, r := range []rune(text)[:loc.Column-1] {
+		if r == '\t' {
+			pad.WriteRune('\t')
+		} else {
+			pad.WriteByte(' ')
+		}
 	}
-	fmt.Fprintf(b, "  [%s] %s: %s at %s\n", f.Rule, f.Severity, f.Message, loc)
+	number := fmt.Sprint(loc.Line)
+	fmt.Fprintf(b, "    %s %s\n", style.paint(ansiBlue, number+" |"), text)
+	fmt.Fprintf(b, "    %s %s%s\n", style.paint(ansiBlue, strings.Repeat(" ", len(number))+" |"), pad.String(), style.paint(color, strings.Repeat("^", end-loc.Column)))
 }
@@ -1,6 +1,7 @@
 package report
 
 import (
+	"os"
 	"strings"
 	"testing"
 )
@@ -73,3 +74,154 @@ func TestFormatTextNoLineNumber(t *testing.T) {
 		t.Errorf("should show path:\n%s", out)
 	}
 }
+
+func TestFormatTextLineAndColumn(t *testing.T) {
+	r := NewReport("test.json")
+	r.AddFinding(NewError("RULE-06", "dup trigger", Location{
+		File:   "test.json",
+		Path:   "$.rules[0]",
+		Line:   12,
+		Column: 5,
+	}))
+
+	out := FormatText(r)
+	if !strings.Contains(out, "at $.rules[0] (line 12, column 5)") {
+		t.Errorf("line and column missing:\n%s", out)
+	}
+}
+
+func TestFormatTextSuppressedCount(t *testing.T) {
+	r := NewReport("test.json")
+	r.AddSuppressed(NewWarning("WARN-20", "unconsumed", Location{Path: "$.rules[0]"}))
+
+	out := FormatText(r)
+	if !strings.Contains(out, "0 errors, 0 warnings (1 suppressed)") {
+		t.Errorf("suppressed count missing:\n%s", out)
+	}
+	if strings.Contains(out, "WARN-20") {
+		t.Errorf("suppressed findings should not be listed:\n%s", out)
+	}
+}
+
+func TestFormatTextInfoAndHints(t *testing.T) {
+	r := NewReport("test.json")
+	r.AddFinding(NewInfo("WARN-02", "Open questions present: 1 unresolved", Location{Path: "$.open_questions"}))
+	r.AddFinding(NewHint("WARN-28", "Field 'createdAt' is not snake_case", Location{Path: "$.entities[0].fields[1].name"}))
+
+	out := FormatText(r)
+	for _, want := range []string{
+		"[WARN-02] info: Open questions present",
+		"[WARN-28] hint: Field 'createdAt'",
+		"0 errors, 0 warnings, 1 info, 1 hints",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestFormatTextSuggestions(t *testing.T) {
+	r := NewReport("test.json")
+	r.AddFinding(NewError("RULE-35", "empty coordinate", Location{Path: "$.use_declarations[0].coordinate"}).
+		WithSuggestion("Remove use declaration 'Bad'", RemoveEdit("$.use_declarations[0]")))
+	r.AddFinding(NewWarning("WARN-19", "identical enums", Location{Path: "$.entities[0]"}).
+		WithSuggestion("Extract enumeration", AddEdit("$.enumerations[0]", map[string]any{"name": "Status"})))
+
+	out := FormatText(r)
+	for _, want := range []string{
+		"    suggestion: Remove use declaration 'Bad'\n      remove $.use_declarations[0]\n",
+		"    suggestion: Extract enumeration\n      add $.enumerations[0] = {\"name\":\"Status\"}\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestFormatTextRelated(t *testing.T) {
+	r := NewReport("test.json")
+	r.AddFinding(NewError("RULE-08", "dead end", Location{Path: "$.entities[0]"}).
+		WithRelated("rule 'Block' sets it", Location{Path: "$.rules[2]", Line: 40, Column: 5}))
+
+	want := "    related: rule 'Block' sets it at $.rules[2] (line 40, column 5)\n"
+	if out := FormatText(r); !strings.Contains(out, want) {
+		t.Errorf("missing %q in:\n%s", want, out)
+	}
+}
+
+func TestFormatTextWithSnippets(t *testing.T) {
+	source := "{\n\t\"rules\": [\n\t  {\"name\": \"Bad\"}\n  ]\n}\n"
+	r := NewReport("test.json")
+	r.AddFinding(NewError("RULE-27", "bad name", Location{Path: "$.rules[0].name", Line: 3, Column: 13, EndLine: 3, EndColumn: 18}))
+	r.AddFinding(NewWarning("WARN-04", "unused", Location{Path: "$.rules", Line: 2, Column: 11, EndLine: 4, EndColumn: 4}))
+	r.AddFinding(NewWarning("WARN-09", "no position", Location{Path: "$.actors"}))
+
+	out := FormatTextWith(r, TextOptions{Source: func(string) ([]byte, error) { return []byte(source), nil }})
+	for _, want := range []string{
+		"at $.rules[0].name (line 3, column 13)\n    3 | \t  {\"name\": \"Bad\"}\n      | \t           ^^^^^\n",
+		// A value spanning lines is underlined to the end of its first line.
+		"at $.rules (line 2, column 11)\n    2 | \t\"rules\": [\n      | \t         ^\n",
+		"at $.actors\n\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "\x1b[") {
+		t.Errorf("expected no colors without Color:\n%s", out)
+	}
+
+	failing := FormatTextWith(r, TextOptions{Source: func(string) ([]byte, error) { return nil, os.ErrNotExist }})
+	if failing != FormatText(r) {
+		t.Errorf("an unreadable source should give the plain format, got:\n%s", failing)
+	}
+}
+
+func TestFormatTextWithExplain(t *testing.T) {
+	r := NewReport("test.json")
+	r.AddFinding(NewError("RULE-27", "bad name", Location{Path: "$.rules[0].name"}))
+	r.AddFinding(NewWarning("WARN-04", "unused", Location{Path: "$.rules"}))
+	explain := func(rule string) string {
+		if rule == "WARN-04" {
+			return ""
+		}
+		return "why: it matters\n\nvalid:\n  a = b\n"
+	}
+
+	out := FormatTextWith(r, TextOptions{Explain: explain})
+	want := "  [RULE-27] error: bad name at $.rules[0].name\n    why: it matters\n\n    valid:\n      a = b\n  [WARN-04] warning: unused at $.rules\n\n"
+	if !strings.Contains(out, want) {
+		t.Errorf("missing %q in:\n%s", want, out)
+	}
+
+	r.AddFinding(NewError("RULE-27", "bad name", Location{Path: "$.rules[1].name"}))
+	grouped := FormatTextGroupedWith(r, 0, TextOptions{Explain: explain})
+	if want := "    at $.rules[1].name\n    why: it matters\n"; !strings.Contains(grouped, want) || strings.Count(grouped, "why:") != 1 {
+		t.Errorf("expected one explanation after the group's locations, got:\n%s", grouped)
+	}
+}
+
+func TestFormatTextWithColor(t *testing.T) {
+	r := NewReport("test.json")
+	r.AddFinding(NewError("RULE-27", "bad name", Location{Path: "$.rules[0]"}))
+	r.AddFinding(NewWarning("WARN-04", "unused", Location{Path: "$.entities[0]"}))
+
+	out := FormatTextWith(r, TextOptions{Color: true})
+	for _, want := range []string{
+		"\x1b[31m✖\x1b[0m \x1b[1m[RULE-27]\x1b[0m \x1b[1m\x1b[31merror\x1b[0m: bad name at \x1b[2m$.rules[0]\x1b[0m\n",
+		"\x1b[33m⚠\x1b[0m \x1b[1m[WARN-04]\x1b[0m",
+		"\x1b[31m1 errors\x1b[0m, \x1b[33m1 warnings\x1b[0m",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%q", want, out)
+		}
+	}
+
+	grouped := FormatTextGroupedWith(r, 0, TextOptions{Color: true})
+	if !strings.Contains(grouped, "\x1b[31m✖\x1b[0m \x1b[1m[RULE-27]\x1b[0m") {
+		t.Errorf("expected grouped output colored too:\n%q", grouped)
+	}
+	if FormatTextGroupedWith(r, 0, TextOptions{}) != FormatTextGrouped(r, 0) {
+		t.Error("zero options should give the plain grouped format")
+	}
+}
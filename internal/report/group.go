@@ -0,0 +1,114 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Group is the findings of one rule with the same severity and message,
+// such as every use of one undeclared entity, reported once with all of
+// their locations.
+type Group struct {
+	Rule      string     `json:"rule"`
+	Severity  Severity   `json:"severity"`
+	Message   string     `json:"message"`
+	Count     int        `json:"count"`
+	Locations []Location `json:"locations"`
+
+	// Omitted counts the locations left out of Locations by a limit.
+	Omitted int `json:"omitted,omitempty"`
+}
+
+// GroupFindings groups findings by rule, severity and message, in the order
+// of each group's first finding. With a positive limit, a group lists at
+// most limit locations and counts the rest in Omitted. Suggestions and
+// related locations are not kept, since they differ between the findings of
+// a group.
+func GroupFindings(findings []Finding, limit int) []Group {
+	type key struct {
+		rule     string
+		severity Severity
+		message  string
+	}
+	index := make(map[key]int)
+	groups := []Group{}
+	for _, f := range findings {
+		k := key{f.Rule, f.Severity, f.Message}
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, Group{Rule: f.Rule, Severity: f.Severity, Message: f.Message, Locations: []Location{}})
+		}
+		g := &groups[i]
+		g.Count++
+		if limit > 0 && len(g.Locations) == limit {
+			g.Omitted++
+			continue
+		}
+		g.Locations = append(g.Locations, f.Location)
+	}
+	return groups
+}
+
+// GroupedReport is a Report with its findings grouped by GroupFindings.
+// Suppressed findings are only counted in its summary.
+type GroupedReport struct {
+	File        string   `json:"file"`
+	SchemaValid bool     `json:"schema_valid"`
+	Groups      []Group  `json:"groups"`
+	Summary     Summary  `json:"summary"`
+	Timings     []Timing `json:"timings,omitempty"`
+}
+
+// Grouped returns r with its findings grouped, listing at most limit
+// locations per group when limit is positive.
+func (r *Report) Grouped(limit int) *GroupedReport {
+	return &GroupedReport{
+		File:        r.File,
+		SchemaValid: r.SchemaValid,
+		Groups:      GroupFindings(r.Findings(), limit),
+		Summary:     r.Summary,
+		Timings:     r.Timings,
+	}
+}
+
+// FormatJSONGrouped returns the report with its findings grouped as
+// indented JSON bytes.
+func FormatJSONGrouped(r *Report, limit int) ([]byte, error) {
+	return json.MarshalIndent(r.Grouped(limit), "", "  ")
+}
+
+// FormatTextGrouped is FormatText with findings grouped: a finding reported
+// at several locations is written once, followed by its locations, at most
+// limit of them when limit is positive and then a count of the rest.
+func FormatTextGrouped(r *Report, limit int) string {
+	return FormatTextGroupedWith(r, limit, TextOptions{})
+}
+
+// FormatTextGroupedWith is FormatTextGrouped with the colors and
+// explanations opts asks for.
+func FormatTextGroupedWith(r *Report, limit int, opts TextOptions) string {
+	var b strings.Builder
+	style := textStyle{color: opts.Color}
+
+	fmt.Fprintf(&b, "File: %s\n", style.paint(ansiBold, r.File))
+	for _, g := range GroupFindings(r.Findings(), limit) {
+		if g.Count == 1 {
+			fmt.Fprintf(&b, "  %s: %s at %s\n", style.header(g.Rule, g.Severity), g.Message, style.paint(ansiDim, formatLocation(g.Locations[0])))
+			writeExplanation(&b, g.Rule, opts.Explain)
+			continue
+		}
+		fmt.Fprintf(&b, "  %s: %s (%d locations)\n", style.header(g.Rule, g.Severity), g.Message, g.Count)
+		for _, loc := range g.Locations {
+			fmt.Fprintf(&b, "    at %s\n", style.paint(ansiDim, formatLocation(loc)))
+		}
+		if g.Omitted > 0 {
+			fmt.Fprintf(&b, "    ... and %d more\n", g.Omitted)
+		}
+		writeExplanation(&b, g.Rule, opts.Explain)
+	}
+	writeSummary(&b, r, style)
+	return b.String()
+}
@@ -0,0 +1,88 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// undeclaredBuyer returns a report with a RULE-01 error at n locations and
+// one other warning.
+func undeclaredBuyer(n int) *Report {
+	r := NewReport("test.allium.json")
+	for i := range n {
+		r.AddFinding(NewError("RULE-01", "Entity 'Buyer' referenced but not declared",
+			Location{Path: fmt.Sprintf("$.rules[%d].requires[0]", i), Line: 10 + i, Column: 5}))
+	}
+	r.AddFinding(NewWarning("WARN-04", "Entity 'Order' is never used", Location{Path: "$.entities[0]"}))
+	return r
+}
+
+func TestGroupFindings(t *testing.T) {
+	r := undeclaredBuyer(4)
+	r.AddFinding(NewError("RULE-01", "Entity 'Seller' referenced but not declared", Location{Path: "$.rules[9]"}))
+	groups := GroupFindings(r.Findings(), 0)
+	if len(groups) != 3 {
+		t.Fatalf("expected 3 groups, got %+v", groups)
+	}
+	if g := groups[0]; g.Rule != "RULE-01" || g.Count != 4 || len(g.Locations) != 4 || g.Omitted != 0 || !strings.Contains(g.Message, "Buyer") {
+		t.Errorf("unexpected first group %+v", g)
+	}
+	if g := groups[1]; !strings.Contains(g.Message, "Seller") || g.Count != 1 {
+		t.Errorf("expected a different message to form its own group, got %+v", g)
+	}
+	if g := groups[2]; g.Rule != "WARN-04" || g.Severity != SeverityWarning {
+		t.Errorf("expected the warning last, got %+v", g)
+	}
+}
+
+func TestGroupFindingsLimit(t *testing.T) {
+	groups := GroupFindings(undeclaredBuyer(7).Findings(), 3)
+	g := groups[0]
+	if g.Count != 7 || len(g.Locations) != 3 || g.Omitted != 4 {
+		t.Errorf("expected 3 of 7 locations listed, got %+v", g)
+	}
+	if g.Locations[2].Path != "$.rules[2].requires[0]" {
+		t.Errorf("expected the first locations to be kept, got %+v", g.Locations)
+	}
+}
+
+func TestFormatTextGrouped(t *testing.T) {
+	out := FormatTextGrouped(undeclaredBuyer(5), 2)
+	for _, want := range []string{
+		"  [RULE-01] error: Entity 'Buyer' referenced but not declared (5 locations)\n",
+		"    at $.rules[0].requires[0] (line 10, column 5)\n",
+		"    at $.rules[1].requires[0] (line 11, column 5)\n",
+		"    ... and 3 more\n",
+		"  [WARN-04] warning: Entity 'Order' is never used at $.entities[0]\n",
+		"\n5 errors, 1 warnings\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "$.rules[2]") {
+		t.Errorf("expected locations past the limit to be left out:\n%s", out)
+	}
+}
+
+func TestFormatJSONGrouped(t *testing.T) {
+	data, err := FormatJSONGrouped(undeclaredBuyer(3), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got GroupedReport
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.File != "test.allium.json" || len(got.Groups) != 2 || got.Groups[0].Count != 3 || len(got.Groups[0].Locations) != 3 {
+		t.Errorf("unexpected grouped report %s", data)
+	}
+	if got.Summary.ErrorCount != 3 || got.Summary.WarningCount != 1 {
+		t.Errorf("expected the summary to count findings, got %+v", got.Summary)
+	}
+	if strings.Contains(string(data), "omitted") {
+		t.Errorf("expected omitted to be left out without a limit:\n%s", data)
+	}
+}
@@ -1,24 +1,39 @@
-// Package report defines types for validation findings (errors and warnings)
-// and the report structure used to collect and present validation results.
+// Package report defines types for validation findings (errors, warnings,
+// info and hints) and the report structure used to collect and present
+// validation results.
 package report
 
-import "fmt"
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+)
 
-// Severity indicates whether a finding is an error or a warning.
+// Severity indicates whether a finding is an error, a warning, or an
+// informational note. Info and hint findings are advisory and never fail a
+// check; hints are the lesser of the two, such as suggestions an editor
+// shows unobtrusively.
 type Severity int
 
 const (
 	SeverityError   Severity = iota
 	SeverityWarning
+	SeverityInfo
+	SeverityHint
 )
 
-// String returns "error" or "warning".
+// String returns "error", "warning", "info" or "hint".
 func (s Severity) String() string {
 	switch s {
 	case SeverityError:
 		return "error"
 	case SeverityWarning:
 		return "warning"
+	case SeverityInfo:
+		return "info"
+	case SeverityHint:
+		return "hint"
 	default:
 		return fmt.Sprintf("severity(%d)", int(s))
 	}
@@ -36,6 +51,10 @@ func (s *Severity) UnmarshalText(text []byte) error {
 		*s = SeverityError
 	case "warning":
 		*s = SeverityWarning
+	case "info":
+		*s = SeverityInfo
+	case "hint":
+		*s = SeverityHint
 	default:
 		return fmt.Errorf("unknown severity %q", text)
 	}
@@ -45,16 +64,92 @@ func (s *Severity) UnmarshalText(text []byte) error {
 // Location identifies where in a source file a finding occurred.
 type Location struct {
 	File string `json:"file"`
-	Path string `json:"path"` // JSON path like "$.entities[0].fields[1]"
-	Line int    `json:"line,omitempty"`
+	Path   string `json:"path"` // JSON path like "$.entities[0].fields[1]"
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
+
+	// EndLine and EndColumn are the point just past the value at Path,
+	// when its source is known.
+	EndLine   int `json:"end_line,omitempty"`
+	EndColumn int `json:"end_column,omitempty"`
 }
 
-// Finding represents a single validation error or warning.
+// Finding represents a single validation finding.
 type Finding struct {
 	Rule     string   `json:"rule"`
 	Severity Severity `json:"severity"`
 	Message  string   `json:"message"`
 	Location Location `json:"location"`
+
+	// Suggestions lists mechanical fixes for the finding, if any.
+	Suggestions []Suggestion `json:"suggestions,omitempty"`
+
+	// Related lists other places that explain the finding, such as the
+	// rules that lead to a dead-end state.
+	Related []RelatedLocation `json:"related,omitempty"`
+}
+
+// WithSuggestion returns a copy of f offering a fix made of the given edits.
+func (f Finding) WithSuggestion(description string, edits ...Edit) Finding {
+	f.Suggestions = append(f.Suggestions[:len(f.Suggestions):len(f.Suggestions)],
+		Suggestion{Description: description, Edits: edits})
+	return f
+}
+
+// WithRelated returns a copy of f pointing at loc as a secondary location.
+func (f Finding) WithRelated(message string, loc Location) Finding {
+	f.Related = append(f.Related[:len(f.Related):len(f.Related)],
+		RelatedLocation{Message: message, Location: loc})
+	return f
+}
+
+// RelatedLocation is a secondary location of a finding.
+type RelatedLocation struct {
+	Message  string   `json:"message"`
+	Location Location `json:"location"`
+}
+
+// Suggestion is a fix for a finding that tools can apply without judgement,
+// such as an editor quick-fix. Its edits are applied in order.
+type Suggestion struct {
+	Description string `json:"description"`
+	Edits       []Edit `json:"edits"`
+}
+
+// Edit changes the spec document at Path, a JSONPath such as
+// "$.use_declarations[2]". Op is "replace" to set the value at Path, "add"
+// to insert Value into an array at the index Path ends with (the array's
+// length appends, and index 0 of a missing array creates it), or "remove"
+// to delete the value at Path.
+type Edit struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// AddEdit returns an Edit inserting value into an array at path. value must
+// marshal to JSON.
+func AddEdit(path string, value any) Edit {
+	return Edit{Op: "add", Path: path, Value: mustMarshal(value)}
+}
+
+// ReplaceEdit returns an Edit setting the value at path. value must marshal
+// to JSON.
+func ReplaceEdit(path string, value any) Edit {
+	return Edit{Op: "replace", Path: path, Value: mustMarshal(value)}
+}
+
+// RemoveEdit returns an Edit deleting the value at path.
+func RemoveEdit(path string) Edit {
+	return Edit{Op: "remove", Path: path}
+}
+
+func mustMarshal(value any) json.RawMessage {
+	data, err := json.Marshal(value)
+	if err != nil {
+		panic(fmt.Sprintf("report: edit value: %v", err))
+	}
+	return data
 }
 
 // NewFinding creates a Finding with the given parameters.
@@ -77,10 +172,23 @@ func NewWarning(rule string, message string, loc Location) Finding {
 	return NewFinding(rule, SeverityWarning, message, loc)
 }
 
+// NewInfo creates an info-severity Finding.
+func NewInfo(rule string, message string, loc Location) Finding {
+	return NewFinding(rule, SeverityInfo, message, loc)
+}
+
+// NewHint creates a hint-severity Finding.
+func NewHint(rule string, message string, loc Location) Finding {
+	return NewFinding(rule, SeverityHint, message, loc)
+}
+
 // Summary holds aggregate counts for a report.
 type Summary struct {
-	ErrorCount   int `json:"error_count"`
-	WarningCount int `json:"warning_count"`
+	ErrorCount      int `json:"error_count"`
+	WarningCount    int `json:"warning_count"`
+	InfoCount       int `json:"info_count,omitempty"`
+	HintCount       int `json:"hint_count,omitempty"`
+	SuppressedCount int `json:"suppressed_count,omitempty"`
 }
 
 // Report collects all validation findings for a single file.
@@ -89,7 +197,22 @@ type Report struct {
 	SchemaValid bool      `json:"schema_valid"`
 	Errors      []Finding `json:"errors"`
 	Warnings    []Finding `json:"warnings"`
+	Info        []Finding `json:"info,omitempty"`
+	Hints       []Finding `json:"hints,omitempty"`
+	Suppressed  []Finding `json:"suppressed,omitempty"`
 	Summary     Summary   `json:"summary"`
+
+	// Timings lists how long each phase of the check took, in the order
+	// they ran, when the checker was asked to record them.
+	Timings []Timing `json:"timings,omitempty"`
+}
+
+// Timing is how long one phase of checking a file took: "decode" of the
+// JSON, "schema" validation, "parse" into the AST, building the "symbols"
+// table, or a semantic pass, named as it is registered.
+type Timing struct {
+	Phase    string        `json:"phase"`
+	Duration time.Duration `json:"duration_ns"`
 }
 
 // NewReport creates a Report for the given file with empty finding slices.
@@ -101,8 +224,8 @@ func NewReport(file string) *Report {
 	}
 }
 
-// AddFinding appends a finding to the appropriate slice (Errors or Warnings)
-// and updates the summary counts.
+// AddFinding appends a finding to the slice for its severity (Errors,
+// Warnings, Info or Hints) and updates the summary counts.
 func (r *Report) AddFinding(f Finding) {
 	switch f.Severity {
 	case SeverityError:
@@ -111,7 +234,40 @@ func (r *Report) AddFinding(f Finding) {
 	case SeverityWarning:
 		r.Warnings = append(r.Warnings, f)
 		r.Summary.WarningCount++
+	case SeverityInfo:
+		r.Info = append(r.Info, f)
+		r.Summary.InfoCount++
+	case SeverityHint:
+		r.Hints = append(r.Hints, f)
+		r.Summary.HintCount++
+	}
+}
+
+// AddTiming records that phase took d.
+func (r *Report) AddTiming(phase string, d time.Duration) {
+	r.Timings = append(r.Timings, Timing{Phase: phase, Duration: d})
+}
+
+// Elapsed returns the total time of the report's recorded phases.
+func (r *Report) Elapsed() time.Duration {
+	var total time.Duration
+	for _, t := range r.Timings {
+		total += t.Duration
 	}
+	return total
+}
+
+// Findings returns the report's unsuppressed findings, most severe first:
+// its errors, warnings, info findings and hints.
+func (r *Report) Findings() []Finding {
+	return slices.Concat(r.Errors, r.Warnings, r.Info, r.Hints)
+}
+
+// AddSuppressed records a finding silenced by a spec suppression. It is kept
+// apart from the other findings and does not affect the exit status.
+func (r *Report) AddSuppressed(f Finding) {
+	r.Suppressed = append(r.Suppressed, f)
+	r.Summary.SuppressedCount++
 }
 
 // HasErrors returns true if the report contains any error-severity findings.
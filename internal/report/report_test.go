@@ -2,6 +2,7 @@ package report
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 )
 
@@ -12,6 +13,8 @@ func TestSeverityString(t *testing.T) {
 	}{
 		{SeverityError, "error"},
 		{SeverityWarning, "warning"},
+		{SeverityInfo, "info"},
+		{SeverityHint, "hint"},
 		{Severity(99), "severity(99)"},
 	}
 	for _, tt := range tests {
@@ -102,6 +105,37 @@ func TestReportAddFinding(t *testing.T) {
 	}
 }
 
+func TestReportAddInfoAndHints(t *testing.T) {
+	r := NewReport("test.allium.json")
+	loc := Location{File: "test.allium.json", Path: "$"}
+	r.AddFinding(NewHint("WARN-28", "naming", loc))
+	r.AddFinding(NewInfo("WARN-02", "open questions", loc))
+	r.AddFinding(NewWarning("WARN-01", "unused", loc))
+
+	if r.Summary.InfoCount != 1 || r.Summary.HintCount != 1 || r.Summary.WarningCount != 1 {
+		t.Errorf("Summary = %+v, want one each of warning, info and hint", r.Summary)
+	}
+	var rules []string
+	for _, f := range r.Findings() {
+		rules = append(rules, f.Rule)
+	}
+	if got := strings.Join(rules, " "); got != "WARN-01 WARN-02 WARN-28" {
+		t.Errorf("Findings() = %s, want warnings, then info, then hints", got)
+	}
+
+	var back Report
+	data, err := FormatJSON(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	if len(back.Info) != 1 || back.Info[0].Severity != SeverityInfo || len(back.Hints) != 1 || back.Hints[0].Severity != SeverityHint {
+		t.Errorf("round trip lost info or hints: %s", data)
+	}
+}
+
 func TestNewReportEmptySlices(t *testing.T) {
 	r := NewReport("x.json")
 	data, err := json.Marshal(r)
@@ -144,3 +178,97 @@ func TestLocationOmitsZeroLine(t *testing.T) {
 		t.Error("line=0 should be omitted from JSON")
 	}
 }
+
+func TestFindingWithSuggestion(t *testing.T) {
+	base := NewError("RULE-35", "empty coordinate", Location{Path: "$.use_declarations[0].coordinate"})
+	f := base.WithSuggestion("Remove it", RemoveEdit("$.use_declarations[0]"))
+	g := f.WithSuggestion("Replace it", ReplaceEdit("$.use_declarations[0].coordinate", "org.example:auth"))
+	if len(base.Suggestions) != 0 || len(f.Suggestions) != 1 || len(g.Suggestions) != 2 {
+		t.Fatalf("WithSuggestion should copy: %d, %d, %d suggestions", len(base.Suggestions), len(f.Suggestions), len(g.Suggestions))
+	}
+
+	data, err := json.Marshal(g)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	want := `"suggestions":[{"description":"Remove it","edits":[{"op":"remove","path":"$.use_declarations[0]"}]},` +
+		`{"description":"Replace it","edits":[{"op":"replace","path":"$.use_declarations[0].coordinate","value":"org.example:auth"}]}]`
+	if !strings.Contains(string(data), want) {
+		t.Errorf("unexpected JSON:\n%s", data)
+	}
+	if data, _ := json.Marshal(base); strings.Contains(string(data), "suggestions") {
+		t.Errorf("findings without suggestions should omit them: %s", data)
+	}
+}
+
+func TestFindingWithRelated(t *testing.T) {
+	base := NewError("RULE-08", "dead end", Location{Path: "$.entities[0]"})
+	f := base.WithRelated("rule 'Block' sets it", Location{Path: "$.rules[2]", Line: 40})
+	if len(base.Related) != 0 || len(f.Related) != 1 {
+		t.Fatalf("WithRelated should copy: %d, %d related", len(base.Related), len(f.Related))
+	}
+
+	data, err := json.Marshal(f)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	want := `"related":[{"message":"rule 'Block' sets it","location":{"file":"","path":"$.rules[2]","line":40}}]`
+	if !strings.Contains(string(data), want) {
+		t.Errorf("unexpected JSON:\n%s", data)
+	}
+	if data, _ := json.Marshal(base); strings.Contains(string(data), "related") {
+		t.Errorf("findings without related locations should omit them: %s", data)
+	}
+}
+
+func TestReportAddSuppressed(t *testing.T) {
+	r := NewReport("x.json")
+	r.AddSuppressed(NewWarning("WARN-20", "unconsumed", Location{Path: "$.rules[0]"}))
+
+	if r.HasWarnings() || r.HasErrors() {
+		t.Error("suppressed findings should not count as errors or warnings")
+	}
+	if r.Summary.SuppressedCount != 1 || len(r.Suppressed) != 1 {
+		t.Errorf("suppressed = %d (%v), want 1", r.Summary.SuppressedCount, r.Suppressed)
+	}
+
+	data, err := json.Marshal(NewReport("clean.json"))
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+	if _, ok := m["suppressed"]; ok {
+		t.Error("suppressed should be omitted when empty")
+	}
+	if _, ok := m["summary"].(map[string]any)["suppressed_count"]; ok {
+		t.Error("suppressed_count should be omitted when zero")
+	}
+}
+
+func TestFindingFingerprint(t *testing.T) {
+	f := NewError("RULE-01", "Entity 'Buyer' referenced but not declared",
+		Location{File: "a.allium.json", Path: "$.entities[0].fields[0].type", Line: 5})
+
+	moved := f
+	moved.Location.File = "other/a.allium.json"
+	moved.Location.Line = 42
+	if f.Fingerprint() != moved.Fingerprint() {
+		t.Error("fingerprint should not depend on file name or line")
+	}
+	if len(f.Fingerprint()) != 16 {
+		t.Errorf("fingerprint = %q, want 16 hex characters", f.Fingerprint())
+	}
+
+	for _, changed := range []Finding{
+		NewError("RULE-03", f.Message, f.Location),
+		NewError(f.Rule, "Entity 'Seller' referenced but not declared", f.Location),
+		NewError(f.Rule, f.Message, Location{Path: "$.entities[1].fields[0].type"}),
+	} {
+		if changed.Fingerprint() == f.Fingerprint() {
+			t.Errorf("fingerprint collision for %+v", changed)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// Package scaffold generates the skeleton of a new Allium specification: a
+// small but complete spec with metadata, an enumeration, an entity with a
+// status lifecycle, the rules that drive it, an actor and a surface, which
+// passes schema and semantic validation and is meant to be edited into the
+// real domain.
+package scaffold
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+//go:embed skeleton.allium.json.tmpl
+var skeleton string
+
+var skeletonTemplate = template.Must(template.New("skeleton").Funcs(template.FuncMap{
+	"json": func(s string) (string, error) {
+		data, err := json.Marshal(s)
+		return string(data), err
+	},
+}).Parse(skeleton))
+
+// Options names the parts of a generated spec. Empty fields take the
+// defaults of Defaults.
+type Options struct {
+	File        string // the spec's "file" marker, e.g. "tasks.allium"
+	Scope       string // metadata scope
+	Description string // metadata description
+	Entity      string // the sample entity, e.g. "Task"
+	Actor       string // the actor working on it, e.g. "Member"
+	ActorEntity string // the entity identifying the actor, e.g. "User"
+}
+
+// Defaults returns the options generating a to-do list of tasks that
+// members create, complete and reopen.
+func Defaults() Options {
+	return Options{
+		Description: "Describe what this specification covers.",
+		Entity:      "Task",
+		Actor:       "Member",
+		ActorEntity: "User",
+	}
+}
+
+// reserved are the names the skeleton declares whatever the options: its
+// enumeration, and the fields and trigger parameters whose names the entity
+// and actor bindings must not take.
+var reserved = []string{"Priority", "priority", "title", "status", "owner", "email"}
+
+// typeName matches the names of entities and actors.
+var typeName = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+
+// Generate returns the skeleton spec for opts as indented JSON.
+func Generate(opts Options) ([]byte, error) {
+	def := Defaults()
+	for _, f := range []struct {
+		v   *string
+		def string
+	}{
+		{&opts.Description, def.Description},
+		{&opts.Entity, def.Entity},
+		{&opts.Actor, def.Actor},
+		{&opts.ActorEntity, def.ActorEntity},
+	} {
+		if *f.v == "" {
+			*f.v = f.def
+		}
+	}
+	for _, n := range []struct{ role, name, example string }{
+		{"entity", opts.Entity, def.Entity},
+		{"actor", opts.Actor, def.Actor},
+		{"actor entity", opts.ActorEntity, def.ActorEntity},
+	} {
+		if !typeName.MatchString(n.name) {
+			return nil, fmt.Errorf("invalid %s name %q (use a PascalCase name such as %s)", n.role, n.name, n.example)
+		}
+	}
+	if opts.Entity == opts.Actor || opts.Entity == opts.ActorEntity || opts.Actor == opts.ActorEntity {
+		return nil, fmt.Errorf("the entity, actor and actor entity need different names, got %s, %s and %s", opts.Entity, opts.Actor, opts.ActorEntity)
+	}
+
+	data := struct {
+		Options
+		EntityBinding string
+		ActorBinding  string
+		Collection    string
+	}{
+		Options:       opts,
+		EntityBinding: snakeCase(opts.Entity),
+		ActorBinding:  snakeCase(opts.Actor),
+		Collection:    plural(snakeCase(opts.Entity)),
+	}
+	if data.Scope == "" {
+		data.Scope = data.Collection
+	}
+	if data.File == "" {
+		data.File = strings.ReplaceAll(data.Scope, " ", "-") + ".allium"
+	}
+	for _, n := range []string{opts.Entity, opts.Actor, opts.ActorEntity, data.EntityBinding, data.ActorBinding, data.Collection} {
+		if slices.Contains(reserved, n) {
+			return nil, fmt.Errorf("the name %s is taken by the skeleton; choose another", n)
+		}
+	}
+
+	var b bytes.Buffer
+	if err := skeletonTemplate.Execute(&b, data); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// snakeCase converts a PascalCase name to snake_case, e.g. "PurchaseOrder"
+// to "purchase_order" and "APIKey" to "api_key".
+func snakeCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		upper := r >= 'A' && r <= 'Z'
+		if upper && i > 0 {
+			prevLower := runes[i-1] >= 'a' && runes[i-1] <= 'z' || runes[i-1] >= '0' && runes[i-1] <= '9'
+			nextLower := i+1 < len(runes) && runes[i+1] >= 'a' && runes[i+1] <= 'z'
+			if prevLower || nextLower {
+				b.WriteByte('_')
+			}
+		}
+		if upper {
+			r += 'a' - 'A'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// plural returns the English plural of a snake_case name, as a collection
+// of its values is named: "task" becomes "tasks", "status_entry"
+// "status_entries" and "box" "boxes".
+func plural(name string) string {
+	switch {
+	case strings.HasSuffix(name, "y") && len(name) > 1 && !strings.ContainsRune("aeiou", rune(name[len(name)-2])):
+		return name[:len(name)-1] + "ies"
+	case strings.HasSuffix(name, "s"), strings.HasSuffix(name, "x"), strings.HasSuffix(name, "ch"), strings.HasSuffix(name, "sh"):
+		return name + "es"
+	}
+	return name + "s"
+}
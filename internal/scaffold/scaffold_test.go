@@ -0,0 +1,103 @@
+package scaffold
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/foundry-zero/allium/internal/checker"
+)
+
+func TestGenerateValidates(t *testing.T) {
+	c, err := checker.NewChecker()
+	if err != nil {
+		t.Fatalf("NewChecker: %v", err)
+	}
+	for name, opts := range map[string]Options{
+		"defaults":    {},
+		"orders":      {Scope: "purchasing", Description: `Orders "placed" by buyers`, Entity: "PurchaseOrder", Actor: "Buyer", ActorEntity: "Customer"},
+		"irregular":   {Entity: "Inbox", Actor: "APIClient", ActorEntity: "Account"},
+		"plural in y": {Entity: "Delivery", Actor: "Courier", ActorEntity: "Person"},
+		"file marker": {File: "bugs.allium", Entity: "Bug", Actor: "Reporter", ActorEntity: "User"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			data, err := Generate(opts)
+			if err != nil {
+				t.Fatalf("Generate: %v", err)
+			}
+			r := c.CheckSource("skeleton.allium.json", data, checker.CheckOptions{})
+			if findings := r.Findings(); len(findings) != 0 {
+				t.Errorf("expected a clean spec, got %v\n%s", findings, data)
+			}
+		})
+	}
+}
+
+func TestGenerateNames(t *testing.T) {
+	data, err := Generate(Options{Description: `Orders "placed" by buyers`, Entity: "PurchaseOrder", Actor: "Buyer", ActorEntity: "Customer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var spec struct {
+		File     string            `json:"file"`
+		Metadata map[string]string `json:"metadata"`
+		Rules    []struct {
+			Name    string `json:"name"`
+			Trigger struct {
+				Name string `json:"name"`
+			} `json:"trigger"`
+		} `json:"rules"`
+		Surfaces []struct {
+			Name string `json:"name"`
+		} `json:"surfaces"`
+	}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if spec.File != "purchase_orders.allium" || spec.Metadata["scope"] != "purchase_orders" || spec.Metadata["description"] != `Orders "placed" by buyers` {
+		t.Errorf("unexpected file %q and metadata %v", spec.File, spec.Metadata)
+	}
+	if spec.Rules[0].Name != "CreatePurchaseOrder" || spec.Rules[0].Trigger.Name != "BuyerCreatesPurchaseOrder" {
+		t.Errorf("unexpected first rule %+v", spec.Rules[0])
+	}
+	if spec.Surfaces[0].Name != "PurchaseOrderBoard" {
+		t.Errorf("unexpected surface %q", spec.Surfaces[0].Name)
+	}
+	for _, binding := range []string{`"purchase_order"`, `"buyer"`, `"purchase_orders"`} {
+		if !strings.Contains(string(data), binding) {
+			t.Errorf("expected the binding %s in the spec", binding)
+		}
+	}
+}
+
+func TestGenerateInvalid(t *testing.T) {
+	for _, opts := range []Options{
+		{Entity: "task"},
+		{Actor: "Member Admin"},
+		{Entity: "User"},
+		{Entity: "Priority"},
+		{Entity: "Title"},
+	} {
+		if _, err := Generate(opts); err == nil {
+			t.Errorf("Generate(%+v): expected an error", opts)
+		}
+	}
+}
+
+func TestSnakeCaseAndPlural(t *testing.T) {
+	for name, want := range map[string]string{
+		"Task":          "tasks",
+		"PurchaseOrder": "purchase_orders",
+		"APIKey":        "api_keys",
+		"Delivery":      "deliveries",
+		"Day":           "days",
+		"Inbox":         "inboxes",
+		"Address":       "addresses",
+		"Batch":         "batches",
+		"V2Item":        "v2_items",
+	} {
+		if got := plural(snakeCase(name)); got != want {
+			t.Errorf("plural(snakeCase(%q)) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -2,14 +2,23 @@
 package schema
 
 import (
+	"cmp"
 	"embed"
 	"encoding/json"
 	"fmt"
 	"io/fs"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+
+	"github.com/foundry-zero/allium/internal/suggest"
 )
 
 //go:embed all:schemas
@@ -17,9 +26,13 @@ var schemaFS embed.FS
 
 // SchemaError represents a single schema validation error.
 type SchemaError struct {
-	Path       string `json:"path"`
-	Message    string `json:"message"`
-	ParseError bool   `json:"-"` // true when the error is a JSON parse or read failure
+	Path       string   `json:"path"`
+	Message    string   `json:"message"`
+	Keyword    string   `json:"keyword,omitempty"`    // failing keyword, for enum and pattern failures; "version" for an unsupported version
+	Allowed    []string `json:"allowed,omitempty"`    // values the enum permits, or the supported versions
+	Pattern    string   `json:"pattern,omitempty"`    // pattern the value must match
+	Suggestion string   `json:"suggestion,omitempty"` // closest acceptable value, if one is plausible
+	ParseError bool     `json:"-"`                    // true when the error is a JSON parse or read failure
 }
 
 func (e SchemaError) String() string {
@@ -29,21 +42,123 @@ func (e SchemaError) String() string {
 	return e.Message
 }
 
-// SchemaValidator validates Allium JSON documents against the embedded JSON schemas.
+// SchemaValidator validates Allium JSON documents against the embedded JSON
+// schema for the version each document declares, or against a schema loaded
+// by NewSchemaValidatorFromFile.
 type SchemaValidator struct {
-	schema *jsonschema.Schema
+	schemas map[string]*jsonschema.Schema // by version
+	latest  string
+
+	// override, when set, validates every document in place of the
+	// embedded schemas.
+	override *jsonschema.Schema
+}
+
+// SupportedVersions returns the schema versions that have an embedded schema,
+// oldest first. A version is the value of a spec's "version" field, e.g. "1";
+// its schema is embedded under schemas/v<version>/.
+func SupportedVersions() []string {
+	entries, err := schemaFS.ReadDir("schemas")
+	if err != nil {
+		return nil
+	}
+	var versions []string
+	for _, e := range entries {
+		if v, ok := strings.CutPrefix(e.Name(), "v"); ok && e.IsDir() {
+			versions = append(versions, v)
+		}
+	}
+	slices.SortFunc(versions, compareVersions)
+	return versions
+}
+
+// compareVersions orders versions by their dot-separated numeric components,
+// falling back to string order for components that are not numbers.
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aerr := strconv.Atoi(as[i])
+		bn, berr := strconv.Atoi(bs[i])
+		if aerr != nil || berr != nil {
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+			continue
+		}
+		if c := cmp.Compare(an, bn); c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(as), len(bs))
+}
+
+// canonicalVersion returns the supported version that declared spells in
+// another format, as "1.0", "v1" and "1.0.0" do "1", or "" if there is none.
+func canonicalVersion(declared string, supported []string) string {
+	declared = strings.TrimPrefix(strings.TrimSpace(declared), "v")
+	for strings.HasSuffix(declared, ".0") {
+		declared = strings.TrimSuffix(declared, ".0")
+	}
+	for _, s := range supported {
+		if s == declared {
+			return s
+		}
+	}
+	return ""
 }
 
-// NewSchemaValidator creates a new validator with the embedded schemas loaded.
+// NewSchemaValidator creates a new validator with the schema of every
+// supported version compiled.
 func NewSchemaValidator() (*SchemaValidator, error) {
+	versions := SupportedVersions()
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("load embedded schemas: no schema versions found")
+	}
+	v := &SchemaValidator{schemas: make(map[string]*jsonschema.Schema), latest: versions[len(versions)-1]}
+	for _, version := range versions {
+		schema, err := compileVersion(version)
+		if err != nil {
+			return nil, err
+		}
+		v.schemas[version] = schema
+	}
+	return v, nil
+}
+
+// NewSchemaValidatorFromFile creates a validator that checks every document
+// against the JSON schema in the file at path instead of the embedded ones,
+// such as a pre-release schema or a team's extension of the current one.
+// References to other files ("$ref": "definitions/common.json") resolve
+// relative to path, and only local files are loaded. The schema is used
+// whatever version a document declares, so it decides which versions are
+// valid.
+func NewSchemaValidatorFromFile(path string) (*SchemaValidator, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("load schema %s: %w", path, err)
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return nil, fmt.Errorf("load schema: %w", err)
+	}
 	c := jsonschema.NewCompiler()
+	c.UseLoader(jsonschema.FileLoader{})
+	schema, err := c.Compile(abs)
+	if err != nil {
+		return nil, fmt.Errorf("compile schema %s: %w", path, err)
+	}
+	return &SchemaValidator{override: schema}, nil
+}
 
-	// Walk all embedded schema files and add them to the compiler.
-	// Use relative paths from schemas/v1/ as resource URLs so that
-	// $ref resolution in the root schema (e.g. "definitions/common.json")
+// compileVersion compiles the embedded schema of one version.
+func compileVersion(version string) (*jsonschema.Schema, error) {
+	c := jsonschema.NewCompiler()
+
+	// Walk the version's embedded schema files and add them to the compiler.
+	// Use relative paths from the version directory as resource URLs so
+	// that $ref resolution in the root schema (e.g. "definitions/common.json")
 	// finds the correct resources.
-	const schemaRoot = "schemas/v1/"
-	err := fs.WalkDir(schemaFS, "schemas", func(path string, d fs.DirEntry, err error) error {
+	schemaRoot := "schemas/v" + version + "/"
+	err := fs.WalkDir(schemaFS, strings.TrimSuffix(schemaRoot, "/"), func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
@@ -72,15 +187,14 @@ func NewSchemaValidator() (*SchemaValidator, error) {
 		return nil
 	})
 	if err != nil {
-		return nil, fmt.Errorf("load embedded schemas: %w", err)
+		return nil, fmt.Errorf("load embedded schemas for version %s: %w", version, err)
 	}
 
 	schema, err := c.Compile("allium-spec.json")
 	if err != nil {
-		return nil, fmt.Errorf("compile root schema: %w", err)
+		return nil, fmt.Errorf("compile root schema for version %s: %w", version, err)
 	}
-
-	return &SchemaValidator{schema: schema}, nil
+	return schema, nil
 }
 
 // Validate validates an Allium JSON document at the given path against the schema.
@@ -89,7 +203,11 @@ func (v *SchemaValidator) Validate(docPath string) []SchemaError {
 	if err != nil {
 		return []SchemaError{{Message: fmt.Sprintf("failed to read file: %v", err), ParseError: true}}
 	}
+	return v.ValidateBytes(data)
+}
 
+// ValidateBytes validates an Allium JSON document held in memory against the schema.
+func (v *SchemaValidator) ValidateBytes(data []byte) []SchemaError {
 	var doc any
 	if err := json.Unmarshal(data, &doc); err != nil {
 		return []SchemaError{{Message: fmt.Sprintf("failed to parse JSON: %v", err), ParseError: true}}
@@ -98,9 +216,57 @@ func (v *SchemaValidator) Validate(docPath string) []SchemaError {
 	return v.ValidateDocument(doc)
 }
 
-// ValidateDocument validates an already-parsed JSON document against the schema.
+// ValidateDocument validates an already-parsed JSON document against the
+// schema of the version it declares. A document without a string version is
+// validated against the latest schema, which reports the missing version; a
+// version without an embedded schema is reported as a single error. A
+// validator from NewSchemaValidatorFromFile uses its schema for every
+// document.
 func (v *SchemaValidator) ValidateDocument(doc any) []SchemaError {
-	err := v.schema.Validate(doc)
+	if v.override != nil {
+		return validateAgainst(v.override, doc)
+	}
+	version := v.latest
+	if obj, ok := doc.(map[string]any); ok {
+		switch declared := obj["version"].(type) {
+		case string:
+			version = declared
+		case json.Number, float64:
+			// A number such as 1 for "1" would only be reported as the
+			// wrong type; say which string to write instead.
+			supported := SupportedVersions()
+			if canonical := canonicalVersion(fmt.Sprint(declared), supported); canonical != "" {
+				return []SchemaError{{
+					Path:       "/version",
+					Message:    fmt.Sprintf("schema version must be a string (did you mean '%s'?)", canonical),
+					Keyword:    "version",
+					Allowed:    supported,
+					Suggestion: canonical,
+				}}
+			}
+		}
+	}
+	schema, ok := v.schemas[version]
+	if !ok {
+		supported := SupportedVersions()
+		se := SchemaError{
+			Path:    "/version",
+			Message: fmt.Sprintf("unsupported schema version %q (supported: %s)", version, strings.Join(supported, ", ")),
+			Keyword: "version",
+			Allowed: supported,
+		}
+		if se.Suggestion = canonicalVersion(version, supported); se.Suggestion != "" {
+			se.Message += fmt.Sprintf(" (did you mean '%s'?)", se.Suggestion)
+		}
+		return []SchemaError{se}
+	}
+
+	return validateAgainst(schema, doc)
+}
+
+// validateAgainst validates doc against schema, returning its leaf errors.
+func validateAgainst(schema *jsonschema.Schema, doc any) []SchemaError {
+	err := schema.Validate(doc)
 	if err == nil {
 		return nil
 	}
@@ -125,10 +291,12 @@ func collectErrors(ve *jsonschema.ValidationError) []SchemaError {
 	if len(ve.Causes) == 0 {
 		msg := ve.Error()
 		if msg != "" {
-			errors = append(errors, SchemaError{
+			se := SchemaError{
 				Path:    instancePath,
 				Message: msg,
-			})
+			}
+			describeKeyword(&se, ve.ErrorKind)
+			errors = append(errors, se)
 		}
 	} else {
 		for _, cause := range ve.Causes {
@@ -138,3 +306,99 @@ func collectErrors(ve *jsonschema.ValidationError) []SchemaError {
 
 	return errors
 }
+
+// describeKeyword records the allowed values or expected pattern of an enum or
+// pattern failure on se, and appends a suggestion to its message when the
+// rejected value is close to an acceptable one.
+func describeKeyword(se *SchemaError, k jsonschema.ErrorKind) {
+	switch k := k.(type) {
+	case *kind.Enum:
+		se.Keyword = "enum"
+		for _, want := range k.Want {
+			se.Allowed = append(se.Allowed, displayValue(want))
+		}
+		if got, ok := k.Got.(string); ok {
+			se.Suggestion = suggest.Closest(got, se.Allowed)
+		}
+	case *kind.Pattern:
+		se.Keyword = "pattern"
+		se.Pattern = k.Want
+		se.Suggestion = conformingValue(k.Got, k.Want)
+	}
+	if se.Suggestion != "" {
+		se.Message += fmt.Sprintf(" (did you mean '%s'?)", se.Suggestion)
+	}
+}
+
+// displayValue renders an enum value: strings as they are, anything else as
+// JSON.
+func displayValue(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// conformingValue rewrites got in PascalCase or snake_case and returns the
+// first rewrite that matches pattern, or "" if neither does. Names are the
+// usual reason a string fails a pattern, so this covers the common mistakes
+// of using one naming convention where the schema expects the other.
+func conformingValue(got, pattern string) string {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return ""
+	}
+	words := splitWords(got)
+	if len(words) == 0 {
+		return ""
+	}
+	var pascal, snake strings.Builder
+	for i, w := range words {
+		w = strings.ToLower(w)
+		pascal.WriteString(strings.ToUpper(w[:1]) + w[1:])
+		if i > 0 {
+			snake.WriteByte('_')
+		}
+		snake.WriteString(w)
+	}
+	for _, candidate := range []string{pascal.String(), snake.String()} {
+		if candidate != got && re.MatchString(candidate) {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// splitWords splits a name into words at non-alphanumeric characters and at
+// lower-to-upper case boundaries, so "user_id", "user-id" and "userId" all
+// give [user id]. Only ASCII names are split; anything else gives no words.
+func splitWords(name string) []string {
+	var words []string
+	start := -1
+	for i, r := range name {
+		if r > unicode.MaxASCII {
+			return nil
+		}
+		alnum := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case !alnum:
+			if start >= 0 {
+				words = append(words, name[start:i])
+				start = -1
+			}
+		case start < 0:
+			start = i
+		case unicode.IsUpper(r) && unicode.IsLower(rune(name[i-1])):
+			words = append(words, name[start:i])
+			start = i
+		}
+	}
+	if start >= 0 {
+		words = append(words, name[start:])
+	}
+	return words
+}
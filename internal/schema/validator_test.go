@@ -278,3 +278,422 @@ func TestSchemaError_JSON(t *testing.T) {
 		t.Errorf("round-trip failed: got %+v, want %+v", decoded, se)
 	}
 }
+
+func TestValidate_EntityRetention(t *testing.T) {
+	v := newValidator(t)
+
+	entity := func(retention map[string]any) map[string]any {
+		return map[string]any{
+			"version": "1",
+			"file":    "test.allium",
+			"entities": []any{
+				map[string]any{
+					"name": "Session",
+					"fields": []any{
+						map[string]any{"name": "created_at", "type": map[string]any{"kind": "primitive", "value": "Timestamp"}},
+					},
+					"retention": retention,
+				},
+			},
+		}
+	}
+
+	if errors := v.ValidateDocument(entity(map[string]any{"duration": "30.days", "from": "created_at"})); len(errors) > 0 {
+		t.Errorf("expected valid retention, got %v", errors)
+	}
+	if errors := v.ValidateDocument(entity(map[string]any{"duration": "thirty days"})); len(errors) == 0 {
+		t.Error("expected error for malformed retention duration")
+	}
+	if errors := v.ValidateDocument(entity(map[string]any{"from": "created_at"})); len(errors) == 0 {
+		t.Error("expected error for retention without duration")
+	}
+}
+
+func TestValidate_Suppressions(t *testing.T) {
+	v := newValidator(t)
+
+	spec := func(suppression map[string]any) map[string]any {
+		return map[string]any{
+			"version":      "1",
+			"file":         "test.allium",
+			"suppressions": []any{suppression},
+		}
+	}
+
+	valid := []map[string]any{
+		{"rule": "RULE-08"},
+		{"rule": "WARN-20", "path": "$.rules[3]", "reason": "consumed by another service"},
+	}
+	for _, s := range valid {
+		if errors := v.ValidateDocument(spec(s)); len(errors) > 0 {
+			t.Errorf("expected valid suppression %v, got %v", s, errors)
+		}
+	}
+
+	invalid := []map[string]any{
+		{"path": "$.rules[3]"},
+		{"rule": "RULE-8"},
+		{"rule": "SCHEMA"},
+		{"rule": "RULE-08", "path": "rules[3]"},
+		{"rule": "RULE-08", "reason": ""},
+		{"rule": "RULE-08", "until": "2027-01-01"},
+	}
+	for _, s := range invalid {
+		if errors := v.ValidateDocument(spec(s)); len(errors) == 0 {
+			t.Errorf("expected error for suppression %v", s)
+		}
+	}
+}
+
+func TestValidate_TypeAliases(t *testing.T) {
+	v := newValidator(t)
+
+	spec := func(alias map[string]any, fieldType map[string]any) map[string]any {
+		return map[string]any{
+			"version":      "1",
+			"file":         "test.allium",
+			"type_aliases": []any{alias},
+			"value_types": []any{
+				map[string]any{
+					"name":   "Contact",
+					"fields": []any{map[string]any{"name": "email", "type": fieldType}},
+				},
+			},
+		}
+	}
+	optionalEmail := map[string]any{"kind": "optional", "inner": map[string]any{"kind": "primitive", "value": "String"}}
+	emailRef := map[string]any{"kind": "alias", "name": "Email"}
+
+	if errors := v.ValidateDocument(spec(map[string]any{"name": "Email", "type": optionalEmail}, emailRef)); len(errors) > 0 {
+		t.Errorf("expected valid type alias, got %v", errors)
+	}
+
+	invalid := []struct {
+		alias     map[string]any
+		fieldType map[string]any
+	}{
+		{map[string]any{"name": "email", "type": optionalEmail}, emailRef},
+		{map[string]any{"name": "Email"}, emailRef},
+		{map[string]any{"name": "Email", "type": optionalEmail}, map[string]any{"kind": "alias"}},
+		{map[string]any{"name": "Email", "type": optionalEmail}, map[string]any{"kind": "alias", "name": "email"}},
+	}
+	for _, tc := range invalid {
+		if errors := v.ValidateDocument(spec(tc.alias, tc.fieldType)); len(errors) == 0 {
+			t.Errorf("expected error for alias %v used as %v", tc.alias, tc.fieldType)
+		}
+	}
+}
+
+func TestValidate_EntityUnique(t *testing.T) {
+	v := newValidator(t)
+
+	entity := func(unique any) map[string]any {
+		return map[string]any{
+			"version": "1",
+			"file":    "test.allium",
+			"entities": []any{
+				map[string]any{
+					"name":   "User",
+					"fields": []any{map[string]any{"name": "email", "type": map[string]any{"kind": "primitive", "value": "String"}}},
+					"unique": unique,
+				},
+			},
+		}
+	}
+
+	if errors := v.ValidateDocument(entity([]any{map[string]any{"fields": []any{"email"}}})); len(errors) > 0 {
+		t.Errorf("expected valid unique constraint, got %v", errors)
+	}
+
+	invalid := []any{
+		[]any{map[string]any{"fields": []any{}}},
+		[]any{map[string]any{"fields": []any{"email", "email"}}},
+		[]any{map[string]any{"fields": []any{"Email"}}},
+		[]any{map[string]any{}},
+		[]any{[]any{"email"}},
+	}
+	for _, u := range invalid {
+		if errors := v.ValidateDocument(entity(u)); len(errors) == 0 {
+			t.Errorf("expected error for unique %v", u)
+		}
+	}
+}
+
+func TestValidate_EnumSuggestion(t *testing.T) {
+	v := newValidator(t)
+
+	doc := map[string]any{
+		"version": "1",
+		"file":    "test.allium",
+		"entities": []any{
+			map[string]any{
+				"name": "User",
+				"fields": []any{
+					map[string]any{
+						"name": "email",
+						"type": map[string]any{"kind": "primitive", "value": "Strng"},
+					},
+				},
+			},
+		},
+	}
+	var enumErr *SchemaError
+	for _, e := range v.ValidateDocument(doc) {
+		if e.Keyword == "enum" {
+			enumErr = &e
+			break
+		}
+	}
+	if enumErr == nil {
+		t.Fatal("expected an enum error for unknown primitive type")
+	}
+	if enumErr.Path != "/entities/0/fields/0/type/value" {
+		t.Errorf("path = %q", enumErr.Path)
+	}
+	if len(enumErr.Allowed) == 0 || enumErr.Allowed[0] != "String" {
+		t.Errorf("allowed = %v, want primitive type names", enumErr.Allowed)
+	}
+	if enumErr.Suggestion != "String" {
+		t.Errorf("suggestion = %q, want String", enumErr.Suggestion)
+	}
+	if !strings.HasSuffix(enumErr.Message, "(did you mean 'String'?)") {
+		t.Errorf("message = %q, want suggestion appended", enumErr.Message)
+	}
+}
+
+func TestValidate_PatternSuggestion(t *testing.T) {
+	v := newValidator(t)
+
+	doc := map[string]any{
+		"version": "1",
+		"file":    "test.allium",
+		"entities": []any{
+			map[string]any{
+				"name": "user_account",
+				"fields": []any{
+					map[string]any{
+						"name": "Email",
+						"type": map[string]any{"kind": "primitive", "value": "String"},
+					},
+				},
+			},
+		},
+	}
+	got := make(map[string]SchemaError)
+	for _, e := range v.ValidateDocument(doc) {
+		if e.Keyword == "pattern" {
+			got[e.Path] = e
+		}
+	}
+	name, ok := got["/entities/0/name"]
+	if !ok {
+		t.Fatalf("expected a pattern error for the entity name, got %v", got)
+	}
+	if name.Pattern != "^[A-Z][a-zA-Z0-9]*$" || name.Suggestion != "UserAccount" {
+		t.Errorf("entity name error = %+v", name)
+	}
+	field, ok := got["/entities/0/fields/0/name"]
+	if !ok {
+		t.Fatalf("expected a pattern error for the field name, got %v", got)
+	}
+	if field.Suggestion != "email" {
+		t.Errorf("field name suggestion = %q, want email", field.Suggestion)
+	}
+}
+
+func TestConformingValue(t *testing.T) {
+	const pascal = "^[A-Z][a-zA-Z0-9]*$"
+	const snake = "^[a-z][a-z0-9_]*$"
+	tests := []struct {
+		got, pattern, want string
+	}{
+		{"user_account", pascal, "UserAccount"},
+		{"user-account", pascal, "UserAccount"},
+		{"UserAccount", snake, "user_account"},
+		{"userAccount", snake, "user_account"},
+		{"2fa", pascal, ""},
+		{"---", pascal, ""},
+		{"café", pascal, ""},
+		{"anything", "(", ""},
+	}
+	for _, tt := range tests {
+		if got := conformingValue(tt.got, tt.pattern); got != tt.want {
+			t.Errorf("conformingValue(%q, %q) = %q, want %q", tt.got, tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestSchemaError_JSONDetails(t *testing.T) {
+	se := SchemaError{
+		Path:       "/entities/0/fields/0/type/value",
+		Message:    "value must be one of 'String', 'Integer'",
+		Keyword:    "enum",
+		Allowed:    []string{"String", "Integer"},
+		Suggestion: "String",
+	}
+	data, err := json.Marshal(se)
+	if err != nil {
+		t.Fatalf("failed to marshal SchemaError: %v", err)
+	}
+	for _, want := range []string{`"keyword":"enum"`, `"allowed":["String","Integer"]`, `"suggestion":"String"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("JSON %s missing %s", data, want)
+		}
+	}
+	if strings.Contains(string(data), `"pattern"`) {
+		t.Errorf("JSON %s should omit empty pattern", data)
+	}
+}
+
+func TestSupportedVersions(t *testing.T) {
+	versions := SupportedVersions()
+	if len(versions) == 0 || versions[0] != "1" {
+		t.Errorf("SupportedVersions() = %v, want it to start with 1", versions)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	for _, tt := range []struct {
+		a, b string
+		want int
+	}{
+		{"1", "2", -1},
+		{"10", "2", 1},
+		{"1.1", "1", 1},
+		{"1.2", "1.10", -1},
+		{"2", "2", 0},
+		{"1-beta", "1-alpha", 1},
+	} {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestValidate_UnsupportedVersion(t *testing.T) {
+	v := newValidator(t)
+
+	errors := v.ValidateDocument(map[string]any{"version": "99", "file": "test.allium"})
+	if len(errors) != 1 {
+		t.Fatalf("expected a single error, got %v", errors)
+	}
+	e := errors[0]
+	if e.Path != "/version" || e.Keyword != "version" || e.Message != `unsupported schema version "99" (supported: 1)` {
+		t.Errorf("unexpected error %+v", e)
+	}
+	if len(e.Allowed) != 1 || e.Allowed[0] != "1" {
+		t.Errorf("Allowed = %v, want [1]", e.Allowed)
+	}
+}
+
+func TestValidate_VersionFormat(t *testing.T) {
+	v := newValidator(t)
+
+	tests := []struct {
+		version any
+		message string
+	}{
+		{"1.0", `unsupported schema version "1.0" (supported: 1) (did you mean '1'?)`},
+		{"v1", `unsupported schema version "v1" (supported: 1) (did you mean '1'?)`},
+		{"1.0.0", `unsupported schema version "1.0.0" (supported: 1) (did you mean '1'?)`},
+		{json.Number("1"), `schema version must be a string (did you mean '1'?)`},
+		{1.0, `schema version must be a string (did you mean '1'?)`},
+	}
+	for _, tt := range tests {
+		errors := v.ValidateDocument(map[string]any{"version": tt.version, "file": "test.allium"})
+		if len(errors) != 1 || errors[0].Message != tt.message || errors[0].Suggestion != "1" || errors[0].Path != "/version" {
+			t.Errorf("version %#v: got %+v, want %q", tt.version, errors, tt.message)
+		}
+	}
+
+	// A number that is no supported version is reported as the wrong type.
+	errors := v.ValidateDocument(map[string]any{"version": json.Number("7"), "file": "test.allium"})
+	if len(errors) != 1 || !strings.Contains(errors[0].Message, "want string") {
+		t.Errorf("version 7: got %+v", errors)
+	}
+}
+
+// pilotSchema copies the published v1 schema to a temporary directory with
+// its version constant changed to "2-beta", and returns the root file.
+func pilotSchema(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	src := filepath.Join("..", "..", "schemas", "v1")
+	if err := os.CopyFS(dir, os.DirFS(src)); err != nil {
+		t.Fatal(err)
+	}
+	root := filepath.Join(dir, "allium-spec.json")
+	data, err := os.ReadFile(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pilot := strings.Replace(string(data), `"const": "1"`, `"const": "2-beta"`, 1)
+	if pilot == string(data) {
+		t.Fatal("version constant not found in the root schema")
+	}
+	if err := os.WriteFile(root, []byte(pilot), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestNewSchemaValidatorFromFile(t *testing.T) {
+	v, err := NewSchemaValidatorFromFile(pilotSchema(t))
+	if err != nil {
+		t.Fatalf("NewSchemaValidatorFromFile: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join("..", "..", "schemas", "v1", "examples", "password-auth.allium.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	// The pilot schema's version is accepted and the embedded one is not.
+	doc["version"] = "2-beta"
+	if errors := v.ValidateDocument(doc); len(errors) > 0 {
+		t.Errorf("expected the pilot version to be valid, got %v", errors)
+	}
+	doc["version"] = "1"
+	errors := v.ValidateDocument(doc)
+	if len(errors) != 1 || errors[0].Path != "/version" {
+		t.Errorf("expected a single /version error for version 1, got %v", errors)
+	}
+
+	// Definitions referenced from the root are loaded from beside it.
+	doc["version"] = "2-beta"
+	doc["entities"] = []any{map[string]any{"name": "User", "fields": []any{map[string]any{"name": "email"}}}}
+	if errors := v.ValidateDocument(doc); len(errors) == 0 {
+		t.Error("expected an error for a field without a type")
+	}
+}
+
+func TestNewSchemaValidatorFromFile_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	broken := filepath.Join(dir, "broken.schema.json")
+	if err := os.WriteFile(broken, []byte(`{"type": 3}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for name, path := range map[string]string{
+		"missing":           filepath.Join(dir, "missing.schema.json"),
+		"invalid schema":    broken,
+		"missing reference": writeSchema(t, dir, `{"$ref": "definitions/none.json"}`),
+	} {
+		if _, err := NewSchemaValidatorFromFile(path); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+// writeSchema writes a root schema to dir and returns its path.
+func writeSchema(t *testing.T, dir, schema string) string {
+	t.Helper()
+	path := filepath.Join(dir, "root.schema.json")
+	if err := os.WriteFile(path, []byte(schema), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,111 @@
+package semantic
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/report"
+)
+
+// CheckActors validates actor declarations and the surfaces facing them.
+//
+//   - RULE-51: An actor's within must name a declared entity, its
+//     identified_by condition may only read members of the identified entity,
+//     this, within (when declared), given bindings and config, and a surface
+//     facing an actor with a within scope must declare a context of that type
+func CheckActors(spec *ast.Spec, st *SymbolTable) []report.Finding {
+	var findings []report.Finding
+
+	for i, a := range spec.Actors {
+		path := fmt.Sprintf("$.actors[%d]", i)
+		if a.Within != "" && !st.LookupAnyEntity(a.Within) {
+			findings = append(findings, report.NewError(
+				"RULE-51",
+				fmt.Sprintf("Actor '%s' is within '%s', which is not a declared entity%s", a.Name, a.Within, didYouMean(a.Within, entityLikeNames(spec))),
+				report.Location{File: spec.File, Path: path + ".within"},
+			))
+		}
+		findings = checkActorCondition(findings, spec, st, a, path+".identified_by.condition")
+	}
+
+	for i, s := range spec.Surfaces {
+		findings = checkSurfaceWithin(findings, spec, st, s, fmt.Sprintf("$.surfaces[%d]", i))
+	}
+
+	return findings
+}
+
+// checkActorCondition checks the names an actor's identified_by condition
+// reads. Bare names are members of the identified entity, whose members are
+// only checked when it is declared in this spec.
+func checkActorCondition(findings []report.Finding, spec *ast.Spec, st *SymbolTable, a ast.Actor, path string) []report.Finding {
+	if a.IdentifiedBy.Condition == nil {
+		return findings
+	}
+	entity := a.IdentifiedBy.Entity
+	members, known := triggerEntityMembers(st, entity)
+	if st.LookupUseDeclaration(entity) != nil {
+		known = false
+	}
+
+	scope := map[string]bool{"this": true, "config": true}
+	types := map[string]*ast.FieldType{"this": {Kind: "entity_ref", Entity: entity}}
+	for _, name := range append(members.names(true, true), members.related...) {
+		scope[name] = true
+		if ft := memberType(st, entity, name); ft != nil {
+			types[name] = ft
+		}
+	}
+	for _, g := range spec.Given {
+		scope[g.Name] = true
+		ft := st.ResolveType(g.Type)
+		types[g.Name] = &ft
+	}
+	if a.Within != "" {
+		scope["within"] = true
+		types["within"] = &ast.FieldType{Kind: "entity_ref", Entity: a.Within}
+	}
+
+	subject := fmt.Sprintf("Actor '%s'", a.Name)
+	unbound := func(name string) string {
+		switch {
+		case name == "within":
+			return fmt.Sprintf("%s reads 'within', but declares no within scope", subject)
+		case !known:
+			return "" // may be a member of an entity not known here
+		}
+		return fmt.Sprintf("%s reads '%s', but '%s' has no member '%s'%s", subject, name, entity, name,
+			didYouMean(name, slices.Sorted(maps.Keys(scope))))
+	}
+	return checkReadNames(findings, spec, st, "RULE-51", subject, a.IdentifiedBy.Condition, scope, types, unbound, ast.NewPath(path))
+}
+
+// checkSurfaceWithin checks that a surface facing an actor with a within
+// scope declares a context of that entity or one of its variants.
+func checkSurfaceWithin(findings []report.Finding, spec *ast.Spec, st *SymbolTable, s ast.Surface, path string) []report.Finding {
+	a := st.LookupActor(s.Facing.Type)
+	if a == nil || a.Within == "" {
+		return findings
+	}
+	if s.Context == nil {
+		return append(findings, report.NewError(
+			"RULE-51",
+			fmt.Sprintf("Surface '%s' faces actor '%s', which is within '%s', but declares no context", s.Name, a.Name, a.Within),
+			report.Location{File: spec.File, Path: path + ".facing.type"},
+		))
+	}
+	ctx := s.Context.Type
+	if v := st.LookupVariant(ctx); v != nil && v.BaseEntity == a.Within {
+		return findings
+	}
+	if ctx != a.Within {
+		findings = append(findings, report.NewError(
+			"RULE-51",
+			fmt.Sprintf("Surface '%s' has context '%s', but its actor '%s' is within '%s'", s.Name, ctx, a.Name, a.Within),
+			report.Location{File: spec.File, Path: path + ".context.type"},
+		))
+	}
+	return findings
+}
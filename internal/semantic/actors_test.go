@@ -0,0 +1,141 @@
+package semantic
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/foundry-zero/allium/internal/ast"
+)
+
+// actorSpec returns a spec with users, workspaces and their memberships, an
+// actor WorkspaceAdmin within Workspace identified by condition, and a
+// surface facing it with the given context.
+func actorSpec(condition *ast.Expression, context *ast.ContextClause) *ast.Spec {
+	str := ast.FieldType{Kind: "primitive", Value: "String"}
+	return &ast.Spec{
+		File: "test.allium.json",
+		Entities: []ast.Entity{
+			{Name: "User", Fields: []ast.Field{{Name: "email", Type: str}}},
+			{Name: "Workspace", Fields: []ast.Field{
+				{Name: "name", Type: str},
+				{Name: "kind", Type: ast.FieldType{Kind: "inline_enum", Values: []string{"team"}}},
+			}},
+			{Name: "Membership", Fields: []ast.Field{
+				{Name: "user", Type: ast.FieldType{Kind: "entity_ref", Entity: "User"}},
+				{Name: "workspace", Type: ast.FieldType{Kind: "entity_ref", Entity: "Workspace"}},
+				{Name: "can_admin", Type: ast.FieldType{Kind: "primitive", Value: "Boolean"}},
+			}},
+		},
+		Variants: []ast.Variant{{Name: "Team", BaseEntity: "Workspace"}},
+		Actors: []ast.Actor{{Name: "WorkspaceAdmin", Within: "Workspace",
+			IdentifiedBy: ast.IdentifiedBy{Entity: "User", Condition: condition}}},
+		Surfaces: []ast.Surface{{Name: "Settings", Facing: ast.FacingClause{Binding: "admin", Type: "WorkspaceAdmin"}, Context: context}},
+	}
+}
+
+// adminLookup returns a join lookup of the membership between this user and
+// the within workspace.
+func adminLookup() *ast.Expression {
+	return &ast.Expression{Kind: "field_access", Field: "can_admin", Object: &ast.Expression{Kind: "join_lookup", Entity: "Membership",
+		Fields: map[string]ast.Expression{"user": *fieldAccess("this"), "workspace": *fieldAccess("within")}}}
+}
+
+func TestCheckActors_Valid(t *testing.T) {
+	workspace := &ast.ContextClause{Binding: "workspace", Type: "Workspace"}
+	for name, spec := range map[string]*ast.Spec{
+		"join lookup":     actorSpec(adminLookup(), workspace),
+		"within member":   actorSpec(comparisonExpr("!=", chain("within", "name"), strLitExpr("")), workspace),
+		"entity member":   actorSpec(comparisonExpr("!=", fieldAccess("email"), strLitExpr("")), workspace),
+		"variant context": actorSpec(adminLookup(), &ast.ContextClause{Binding: "team", Type: "Team"}),
+	} {
+		if findings := CheckActors(spec, BuildSymbolTable(spec)); len(findings) != 0 {
+			t.Errorf("%s: expected no findings, got %v", name, findings)
+		}
+	}
+}
+
+func TestCheckActors_RULE51(t *testing.T) {
+	workspace := &ast.ContextClause{Binding: "workspace", Type: "Workspace"}
+	tests := []struct {
+		name    string
+		spec    func() *ast.Spec
+		path    string
+		message string
+	}{
+		{
+			name: "undeclared within",
+			spec: func() *ast.Spec {
+				spec := actorSpec(nil, workspace)
+				spec.Actors[0].Within = "Worksapce"
+				spec.Surfaces = nil
+				return spec
+			},
+			path:    "$.actors[0].within",
+			message: "Actor 'WorkspaceAdmin' is within 'Worksapce', which is not a declared entity (did you mean 'Workspace'?)",
+		},
+		{
+			name: "undeclared member",
+			spec: func() *ast.Spec {
+				return actorSpec(comparisonExpr("!=", fieldAccess("emial"), strLitExpr("")), workspace)
+			},
+			path:    "$.actors[0].identified_by.condition.left",
+			message: "Actor 'WorkspaceAdmin' reads 'emial', but 'User' has no member 'emial' (did you mean 'email'?)",
+		},
+		{
+			name: "undeclared within member",
+			spec: func() *ast.Spec {
+				return actorSpec(comparisonExpr("!=", chain("within", "nmae"), strLitExpr("")), workspace)
+			},
+			path:    "$.actors[0].identified_by.condition.left",
+			message: "reads 'within.nmae', but 'Workspace' has no member 'nmae' (did you mean 'name'?)",
+		},
+		{
+			name: "within without scope",
+			spec: func() *ast.Spec {
+				spec := actorSpec(adminLookup(), nil)
+				spec.Actors[0].Within = ""
+				return spec
+			},
+			path:    "$.actors[0].identified_by.condition.object.fields.workspace",
+			message: "Actor 'WorkspaceAdmin' reads 'within', but declares no within scope",
+		},
+		{
+			name:    "surface without context",
+			spec:    func() *ast.Spec { return actorSpec(adminLookup(), nil) },
+			path:    "$.surfaces[0].facing.type",
+			message: "Surface 'Settings' faces actor 'WorkspaceAdmin', which is within 'Workspace', but declares no context",
+		},
+		{
+			name:    "surface with other context",
+			spec:    func() *ast.Spec { return actorSpec(adminLookup(), &ast.ContextClause{Binding: "user", Type: "User"}) },
+			path:    "$.surfaces[0].context.type",
+			message: "Surface 'Settings' has context 'User', but its actor 'WorkspaceAdmin' is within 'Workspace'",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := tt.spec()
+			findings := findingsWithRule(CheckActors(spec, BuildSymbolTable(spec)), "RULE-51")
+			if len(findings) != 1 {
+				t.Fatalf("expected 1 RULE-51, got %v", findings)
+			}
+			if findings[0].Location.Path != tt.path {
+				t.Errorf("path = %q, want %q", findings[0].Location.Path, tt.path)
+			}
+			if !strings.Contains(findings[0].Message, tt.message) {
+				t.Errorf("message %q does not contain %q", findings[0].Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestCheckActors_UseDeclaredEntity(t *testing.T) {
+	spec := actorSpec(comparisonExpr("!=", fieldAccess("handle"), strLitExpr("")), nil)
+	spec.Entities = spec.Entities[1:]
+	spec.Actors[0].Within = ""
+	spec.UseDeclarations = []ast.UseDeclaration{{Coordinate: "users/v1", Alias: "User"}}
+	if findings := CheckActors(spec, BuildSymbolTable(spec)); len(findings) != 0 {
+		t.Errorf("expected members of an imported entity to go unchecked, got %v", findings)
+	}
+}
@@ -0,0 +1,116 @@
+package semantic
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/report"
+)
+
+// CheckTypeAliases validates type alias declarations.
+//
+//   - RULE-37: Type alias names must be unique, must not reuse the name of an
+//     entity, value type, enumeration or variant, and aliases must not be
+//     defined in terms of themselves
+func CheckTypeAliases(spec *ast.Spec, st *SymbolTable) []report.Finding {
+	var findings []report.Finding
+
+	seen := make(map[string]int, len(spec.TypeAliases))
+	for i, a := range spec.TypeAliases {
+		path := fmt.Sprintf("$.type_aliases[%d]", i)
+		if first, ok := seen[a.Name]; ok {
+			findings = append(findings, report.NewError(
+				"RULE-37",
+				fmt.Sprintf("Duplicate type alias '%s' (first declared at index %d)", a.Name, first),
+				report.Location{File: spec.File, Path: path + ".name"},
+			))
+			continue
+		}
+		seen[a.Name] = i
+
+		if kind := typeDeclarationKind(st, a.Name); kind != "" {
+			findings = append(findings, report.NewError(
+				"RULE-37",
+				fmt.Sprintf("Type alias '%s' has the same name as %s", a.Name, kind),
+				report.Location{File: spec.File, Path: path + ".name"},
+			))
+		}
+	}
+
+	return checkTypeAliasCycles(findings, spec)
+}
+
+// typeDeclarationKind describes the non-alias declaration named name, or
+// returns "" if there is none.
+func typeDeclarationKind(st *SymbolTable, name string) string {
+	switch {
+	case st.LookupEntity(name) != nil:
+		return "an entity"
+	case st.LookupExternalEntity(name) != nil:
+		return "an external entity"
+	case st.LookupVariant(name) != nil:
+		return "a variant"
+	case st.LookupValueType(name) != nil:
+		return "a value type"
+	case st.LookupEnumeration(name) != nil:
+		return "an enumeration"
+	case st.LookupUseDeclaration(name) != nil:
+		return "a use declaration"
+	}
+	return ""
+}
+
+// checkTypeAliasCycles reports aliases that refer back to themselves, directly
+// or through other aliases. Such an alias never expands to a concrete type.
+func checkTypeAliasCycles(findings []report.Finding, spec *ast.Spec) []report.Finding {
+	nameIdx := make(map[string]int, len(spec.TypeAliases))
+	for i, a := range spec.TypeAliases {
+		if _, ok := nameIdx[a.Name]; !ok {
+			nameIdx[a.Name] = i
+		}
+	}
+
+	adj := make([][]int, len(spec.TypeAliases))
+	for i, a := range spec.TypeAliases {
+		adj[i] = collectAliasRefs(a.Type, nameIdx, adj[i])
+	}
+
+	for _, scc := range tarjanSCC(adj) {
+		if len(scc) == 1 && !slices.Contains(adj[scc[0]], scc[0]) {
+			continue
+		}
+		slices.Sort(scc)
+		names := make([]string, 0, len(scc)+1)
+		for _, idx := range scc {
+			names = append(names, spec.TypeAliases[idx].Name)
+		}
+		names = append(names, names[0])
+		findings = append(findings, report.NewError(
+			"RULE-37",
+			fmt.Sprintf("Circular type alias: %s", joinArrow(names)),
+			report.Location{File: spec.File, Path: fmt.Sprintf("$.type_aliases[%d].type", scc[0])},
+		))
+	}
+
+	return findings
+}
+
+// collectAliasRefs appends the indices of aliases referenced anywhere in ft.
+func collectAliasRefs(ft ast.FieldType, nameIdx map[string]int, refs []int) []int {
+	switch ft.Kind {
+	case "alias":
+		if idx, ok := nameIdx[ft.Name]; ok && !slices.Contains(refs, idx) {
+			refs = append(refs, idx)
+		}
+	case "optional":
+		if ft.Inner != nil {
+			refs = collectAliasRefs(*ft.Inner, nameIdx, refs)
+		}
+	case "set", "list":
+		if ft.Element != nil {
+			refs = collectAliasRefs(*ft.Element, nameIdx, refs)
+		}
+	}
+	return refs
+}
@@ -0,0 +1,391 @@
+package semantic
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+
+	"github.com/foundry-zero/allium/internal/ast"
+)
+
+// ActorCapabilities is what an actor can do through a spec's surfaces: the
+// surfaces it can reach, the triggers they provide to it, and the entities
+// and fields that the rules those triggers set off read and write.
+type ActorCapabilities struct {
+	Actor    string              `json:"actor"`
+	Entity   string              `json:"entity,omitempty"` // the entity identifying the actor
+	Surfaces []SurfaceAccess     `json:"surfaces"`
+	Triggers []TriggerCapability `json:"triggers"`
+
+	// Reads, Writes, Creates and Removes are those of all of Triggers.
+	Reads   []string `json:"reads"`
+	Writes  []string `json:"writes"`
+	Creates []string `json:"creates"`
+	Removes []string `json:"removes"`
+}
+
+// SurfaceAccess is a surface an actor can reach.
+type SurfaceAccess struct {
+	Surface string `json:"surface"`
+	// Via is the reachable surface that lists this one as related, or empty
+	// for a surface facing the actor.
+	Via string `json:"via,omitempty"`
+}
+
+// TriggerCapability is a trigger an actor can invoke and its effects.
+type TriggerCapability struct {
+	Trigger  string   `json:"trigger"`
+	Surfaces []string `json:"surfaces"` // reachable surfaces providing it
+	// Rules are the rules the trigger fires and those they set off in turn,
+	// in declaration order.
+	Rules []string `json:"rules"`
+	// Reads and Writes are the fields the rules read and change, as
+	// "Entity.field"; Creates and Removes the entities they create and
+	// remove.
+	Reads   []string `json:"reads"`
+	Writes  []string `json:"writes"`
+	Creates []string `json:"creates"`
+	Removes []string `json:"removes"`
+}
+
+// ActorCapabilityMatrix returns the capabilities of each actor of spec, in
+// declaration order.
+//
+// An actor reaches the surfaces facing it by name and, transitively, the
+// surfaces those list as related. It can invoke the triggers of the actions
+// those surfaces provide, including within for_each groups. A trigger fires
+// the rules triggered by its name, and a rule sets off the rules of the
+// chained triggers it emits, the state_transition and state_becomes rules
+// watching a field it changes (to a matching value, when the value is a
+// literal) and the entity_creation rules of entities it creates. Temporal
+// and derived_condition rules are not followed, since they fire on time and
+// state rather than on an invocation.
+//
+// Reads and writes are found by typing field accesses as RULE-46 does, so
+// those whose object cannot be typed, such as fields of trigger parameters,
+// are not listed.
+func ActorCapabilityMatrix(spec *ast.Spec, st *SymbolTable) []ActorCapabilities {
+	cm := &capabilityMatrix{spec: spec, st: st, effects: make(map[int]*ruleEffects)}
+	matrix := make([]ActorCapabilities, 0, len(spec.Actors))
+	for _, a := range spec.Actors {
+		matrix = append(matrix, cm.actor(a))
+	}
+	return matrix
+}
+
+// capabilityMatrix computes actor capabilities, caching each rule's effects.
+type capabilityMatrix struct {
+	spec    *ast.Spec
+	st      *SymbolTable
+	effects map[int]*ruleEffects // by rule index
+}
+
+// ruleEffects is what one rule reads, writes and sets off.
+type ruleEffects struct {
+	reads, writes, creates, removes map[string]bool
+	emits                           []string     // chained triggers
+	changes                         []stateWrite // state changes, to follow state triggers
+}
+
+// stateWrite is a state_change of an entity's field, to value when it is a
+// literal.
+type stateWrite struct {
+	entity, field, value string
+}
+
+func (cm *capabilityMatrix) actor(a ast.Actor) ActorCapabilities {
+	ac := ActorCapabilities{Actor: a.Name, Entity: a.IdentifiedBy.Entity, Surfaces: []SurfaceAccess{}, Triggers: []TriggerCapability{}}
+
+	reached := make(map[string]bool)
+	for _, s := range cm.spec.Surfaces {
+		if s.Facing.Type == a.Name && !reached[s.Name] {
+			reached[s.Name] = true
+			ac.Surfaces = append(ac.Surfaces, SurfaceAccess{Surface: s.Name})
+		}
+	}
+	for i := 0; i < len(ac.Surfaces); i++ {
+		s := cm.st.LookupSurface(ac.Surfaces[i].Surface)
+		if s == nil {
+			continue
+		}
+		for _, r := range s.Related {
+			if !reached[r.Surface] && cm.st.LookupSurface(r.Surface) != nil {
+				reached[r.Surface] = true
+				ac.Surfaces = append(ac.Surfaces, SurfaceAccess{Surface: r.Surface, Via: s.Name})
+			}
+		}
+	}
+
+	// Triggers in the order their surfaces are reached.
+	byTrigger := make(map[string]int)
+	for _, access := range ac.Surfaces {
+		s := cm.st.LookupSurface(access.Surface)
+		for _, trigger := range providedActions(s.Provides) {
+			i, ok := byTrigger[trigger]
+			if !ok {
+				i = len(ac.Triggers)
+				byTrigger[trigger] = i
+				ac.Triggers = append(ac.Triggers, cm.trigger(trigger))
+			}
+			if !slices.Contains(ac.Triggers[i].Surfaces, s.Name) {
+				ac.Triggers[i].Surfaces = append(ac.Triggers[i].Surfaces, s.Name)
+			}
+		}
+	}
+
+	var reads, writes, creates, removes []string
+	for _, t := range ac.Triggers {
+		reads = append(reads, t.Reads...)
+		writes = append(writes, t.Writes...)
+		creates = append(creates, t.Creates...)
+		removes = append(removes, t.Removes...)
+	}
+	ac.Reads, ac.Writes, ac.Creates, ac.Removes = sortedUnique(reads), sortedUnique(writes), sortedUnique(creates), sortedUnique(removes)
+	return ac
+}
+
+// providedActions returns the triggers of the actions in items, including
+// those in for_each groups, in order.
+func providedActions(items []ast.ProvidesItem) []string {
+	var triggers []string
+	for _, p := range items {
+		switch p.Kind {
+		case "action":
+			if p.Trigger != "" {
+				triggers = append(triggers, p.Trigger)
+			}
+		case "for_each":
+			triggers = append(triggers, providedActions(p.Items)...)
+		}
+	}
+	return triggers
+}
+
+// trigger returns the rules that invoking trigger sets off and their
+// effects.
+func (cm *capabilityMatrix) trigger(trigger string) TriggerCapability {
+	fired := make(map[int]bool)
+	var queue []int
+	fire := func(match func(ast.Trigger) bool) {
+		for i, r := range cm.spec.Rules {
+			if !fired[i] && match(r.Trigger) {
+				fired[i] = true
+				queue = append(queue, i)
+			}
+		}
+	}
+	fire(func(t ast.Trigger) bool {
+		return (t.Kind == "external_stimulus" || t.Kind == "chained") && t.Name == trigger
+	})
+	for len(queue) > 0 {
+		fx := cm.ruleEffects(queue[0])
+		queue = queue[1:]
+		for _, name := range fx.emits {
+			fire(func(t ast.Trigger) bool { return t.Kind == "chained" && t.Name == name })
+		}
+		for _, w := range fx.changes {
+			fire(func(t ast.Trigger) bool {
+				if t.Entity != w.entity || t.Field != w.field {
+					return false
+				}
+				switch t.Kind {
+				case "state_transition":
+					return w.value == "" || t.ToValue == w.value
+				case "state_becomes":
+					return w.value == "" || t.Value == w.value
+				}
+				return false
+			})
+		}
+		for entity := range fx.creates {
+			fire(func(t ast.Trigger) bool { return t.Kind == "entity_creation" && t.Entity == entity })
+		}
+	}
+
+	tc := TriggerCapability{Trigger: trigger, Surfaces: []string{}, Rules: []string{}}
+	reads, writes, creates, removes := make(map[string]bool), make(map[string]bool), make(map[string]bool), make(map[string]bool)
+	for _, i := range slices.Sorted(maps.Keys(fired)) {
+		tc.Rules = append(tc.Rules, cm.spec.Rules[i].Name)
+		fx := cm.ruleEffects(i)
+		maps.Copy(reads, fx.reads)
+		maps.Copy(writes, fx.writes)
+		maps.Copy(creates, fx.creates)
+		maps.Copy(removes, fx.removes)
+	}
+	tc.Reads, tc.Writes = sortedKeys(reads), sortedKeys(writes)
+	tc.Creates, tc.Removes = sortedKeys(creates), sortedKeys(removes)
+	return tc
+}
+
+// ruleEffects returns the effects of the rule at index i.
+func (cm *capabilityMatrix) ruleEffects(i int) *ruleEffects {
+	if fx, ok := cm.effects[i]; ok {
+		return fx
+	}
+	rule := cm.spec.Rules[i]
+	fx := &ruleEffects{reads: make(map[string]bool), writes: make(map[string]bool), creates: make(map[string]bool), removes: make(map[string]bool)}
+	cm.effects[i] = fx
+
+	types := ruleFieldTypes(rule, cm.spec, cm.st)
+	// Bare names read fields of the trigger entity, unless bound otherwise.
+	var entity *ast.Entity
+	if rule.Trigger.Entity != "" {
+		entity = cm.st.LookupEntity(rule.Trigger.Entity)
+	}
+	bound := make(map[string]bool)
+	for _, g := range cm.spec.Given {
+		bound[g.Name] = true
+	}
+	bound[rule.Trigger.Binding] = true
+	if rule.ForClause != nil {
+		bound[rule.ForClause.Binding] = true
+	}
+	for _, lb := range rule.LetBindings {
+		bound[lb.Name] = true
+	}
+	rc := &ruleCollector{cm: cm, fx: fx, entity: entity, bound: bound}
+
+	rc.read(rule.Trigger.Condition, types)
+	if fc := rule.ForClause; fc != nil {
+		rc.read(fc.Collection, types)
+		rc.read(fc.Condition, types)
+	}
+	for _, lb := range rule.LetBindings {
+		rc.read(lb.Expression, types)
+	}
+	for j := range rule.Requires {
+		rc.read(&rule.Requires[j], types)
+	}
+	for _, ec := range rule.Ensures {
+		rc.ensures(ec, types)
+	}
+	return fx
+}
+
+// ruleCollector records the effects of one rule.
+type ruleCollector struct {
+	cm     *capabilityMatrix
+	fx     *ruleEffects
+	entity *ast.Entity     // the trigger entity, whose fields bare names read
+	bound  map[string]bool // names bound in the rule, which hide those fields
+}
+
+// member returns the entity and field that a field access names, or "" if
+// its object cannot be typed as an entity.
+func (rc *ruleCollector) member(e *ast.Expression, types map[string]*ast.FieldType) (string, string) {
+	if e == nil || e.Kind != "field_access" {
+		return "", ""
+	}
+	if e.Object == nil {
+		if rc.entity != nil && !rc.bound[e.Field] && types[e.Field] != nil && hasField(rc.cm.st, rc.entity, e.Field) {
+			return rc.entity.Name, e.Field
+		}
+		return "", ""
+	}
+	objType := resolveFieldAccessType(e.Object, types, rc.cm.st)
+	for objType != nil && objType.Kind == "optional" {
+		objType = objType.Inner
+	}
+	if objType == nil || objType.Kind != "entity_ref" {
+		return "", ""
+	}
+	return objType.Entity, e.Field
+}
+
+// hasField reports whether entity declares a field named name.
+func hasField(st *SymbolTable, entity *ast.Entity, name string) bool {
+	for _, f := range st.ResolveFields(entity.Fields) {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// read records the entity fields read within expr.
+func (rc *ruleCollector) read(expr *ast.Expression, types map[string]*ast.FieldType) {
+	ast.Walk(expr, func(e *ast.Expression) bool {
+		if entity, field := rc.member(e, types); entity != "" {
+			rc.fx.reads[entity+"."+field] = true
+		}
+		return true
+	})
+}
+
+// ensures records the effects of an ensures clause and the clauses nested in
+// it. A written target's object is read, but the target itself is not.
+func (rc *ruleCollector) ensures(ec ast.EnsuresClause, types map[string]*ast.FieldType) {
+	switch ec.Kind {
+	case "state_change", "set_mutation":
+		if entity, field := rc.member(ec.Target, types); entity != "" {
+			rc.fx.writes[entity+"."+field] = true
+			if ec.Kind == "state_change" {
+				rc.fx.changes = append(rc.fx.changes, stateWrite{entity, field, enumLiteral(ec.Value)})
+			}
+		}
+		if ec.Target != nil {
+			rc.read(ec.Target.Object, types)
+		}
+	case "entity_removal":
+		if t := resolveFieldAccessType(ec.Target, types, rc.cm.st); t != nil && t.Kind == "entity_ref" {
+			rc.fx.removes[t.Entity] = true
+		}
+	case "entity_creation":
+		rc.fx.creates[ec.Entity] = true
+	case "trigger_emission":
+		rc.fx.emits = append(rc.fx.emits, ec.Name)
+	case "iteration":
+		var element *ast.FieldType
+		if ct := resolveFieldAccessType(ec.Collection, types, rc.cm.st); ct != nil && (ct.Kind == "set" || ct.Kind == "list") {
+			element = ct.Element
+		}
+		rc.read(ec.Collection, types)
+		types = withBinding(types, ec.Binding, element)
+	case "let_binding":
+		var creation ast.EnsuresClause
+		if json.Unmarshal(ec.Value, &creation) == nil && creation.Kind == "entity_creation" {
+			rc.ensures(creation, types)
+		}
+		types = withBinding(types, ec.Name, letValueType(ec.Value, types, rc.cm.st))
+	}
+	if ec.Kind != "iteration" {
+		for _, e := range ec.Expressions("") {
+			if e.Expr != ec.Target {
+				rc.read(e.Expr, types)
+			}
+		}
+	}
+	for _, then := range ec.Then {
+		rc.ensures(then, types)
+	}
+	for _, el := range ec.Else {
+		rc.ensures(el, types)
+	}
+	for _, body := range ec.Body {
+		rc.ensures(body, types)
+	}
+}
+
+// enumLiteral returns the value of an enum value literal, or "".
+func enumLiteral(value json.RawMessage) string {
+	var lit ast.Expression
+	var s string
+	if json.Unmarshal(value, &lit) != nil || lit.Kind != "literal" || json.Unmarshal(lit.LitValue, &s) != nil {
+		return ""
+	}
+	return s
+}
+
+// sortedKeys returns the keys of m in order, as an empty slice rather than
+// nil so that they encode as a JSON array.
+func sortedKeys(m map[string]bool) []string {
+	return append([]string{}, slices.Sorted(maps.Keys(m))...)
+}
+
+// sortedUnique returns the distinct values of s in order, as sortedKeys
+// does.
+func sortedUnique(s []string) []string {
+	s = append([]string{}, s...)
+	slices.Sort(s)
+	return slices.Compact(s)
+}
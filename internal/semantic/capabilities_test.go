@@ -0,0 +1,133 @@
+package semantic
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/foundry-zero/allium/internal/ast"
+)
+
+func TestActorCapabilityMatrix_Example(t *testing.T) {
+	spec, err := ast.LoadSpec(filepath.Join("..", "..", "schemas", "v1", "examples", "password-auth.allium.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	matrix := ActorCapabilityMatrix(spec, BuildSymbolTable(spec))
+	if len(matrix) != 2 || matrix[0].Actor != "AuthenticatedUser" || matrix[1].Actor != "Visitor" {
+		t.Fatalf("unexpected actors %+v", matrix)
+	}
+
+	user := matrix[0]
+	var triggers []string
+	for _, tc := range user.Triggers {
+		triggers = append(triggers, tc.Trigger)
+	}
+	// UserLogsOut is provided within a for_each group.
+	if !slices.Equal(triggers, []string{"UserLogsOut", "UserRequestsPasswordReset"}) {
+		t.Errorf("AuthenticatedUser triggers = %q", triggers)
+	}
+
+	visitor := matrix[1]
+	if len(visitor.Surfaces) != 2 || visitor.Surfaces[0].Surface != "Authentication" || visitor.Surfaces[1].Surface != "PasswordReset" {
+		t.Errorf("Visitor surfaces = %+v", visitor.Surfaces)
+	}
+	login := visitor.Triggers[0]
+	if login.Trigger != "UserLogsIn" || !slices.Equal(login.Surfaces, []string{"Authentication"}) {
+		t.Fatalf("first Visitor trigger = %+v", login)
+	}
+	// The lockout sets off NotifyAccountLocked by a state transition, a new
+	// session AuditNewSession, and the emitted AccountLockTriggered
+	// NotifySecurityTeam.
+	wantRules := []string{"LoginSuccess", "LoginFailure", "LoginAttemptWhileLocked", "NotifyAccountLocked", "AuditNewSession", "NotifySecurityTeam"}
+	if !slices.Equal(login.Rules, wantRules) {
+		t.Errorf("UserLogsIn rules = %q, want %q", login.Rules, wantRules)
+	}
+	if !slices.Contains(login.Writes, "User.status") || !slices.Contains(login.Reads, "User.password_hash") || !slices.Contains(login.Creates, "Session") {
+		t.Errorf("UserLogsIn effects = %+v", login)
+	}
+	if !slices.Contains(visitor.Creates, "User") || !slices.Contains(visitor.Writes, "PasswordResetToken.status") {
+		t.Errorf("Visitor effects = %+v", visitor)
+	}
+}
+
+func TestActorCapabilityMatrix_Chains(t *testing.T) {
+	status, _ := json.Marshal(enumLitExpr("shipped"))
+	orderRef := ast.FieldType{Kind: "entity_ref", Entity: "Order"}
+	spec := &ast.Spec{
+		File: "test.allium.json",
+		Entities: []ast.Entity{
+			{Name: "Order", Fields: []ast.Field{
+				{Name: "status", Type: ast.FieldType{Kind: "inline_enum", Values: []string{"open", "shipped", "cancelled"}}},
+				{Name: "total", Type: ast.FieldType{Kind: "primitive", Value: "Integer"}},
+			}},
+			{Name: "Shipment", Fields: []ast.Field{{Name: "order", Type: orderRef}}},
+		},
+		Actors: []ast.Actor{
+			{Name: "Clerk", IdentifiedBy: ast.IdentifiedBy{Entity: "Staff"}},
+			{Name: "Auditor", IdentifiedBy: ast.IdentifiedBy{Entity: "Staff"}},
+		},
+		Surfaces: []ast.Surface{
+			{Name: "Desk", Facing: ast.FacingClause{Binding: "clerk", Type: "Clerk"},
+				Related: []ast.RelatedItem{{Surface: "BackOffice"}, {Surface: "Missing"}}},
+			{Name: "BackOffice", Facing: ast.FacingClause{Binding: "staff", Type: "Staff"},
+				Related:  []ast.RelatedItem{{Surface: "Desk"}},
+				Provides: []ast.ProvidesItem{{Kind: "action", Trigger: "ShipOrder"}}},
+			{Name: "Reports", Facing: ast.FacingClause{Binding: "auditor", Type: "Auditor"},
+				Provides: []ast.ProvidesItem{{Kind: "action", Trigger: "CancelOrder"}}},
+		},
+		Rules: []ast.Rule{
+			{Name: "Ship", Trigger: ast.Trigger{Kind: "external_stimulus", Name: "ShipOrder"},
+				Ensures: []ast.EnsuresClause{creation("Shipment")}},
+			{Name: "Dispatch", Trigger: ast.Trigger{Kind: "entity_creation", Binding: "shipment", Entity: "Shipment"},
+				Ensures: []ast.EnsuresClause{{
+					Kind:   "state_change",
+					Target: &ast.Expression{Kind: "field_access", Object: &ast.Expression{Kind: "field_access", Object: fieldAccess("shipment"), Field: "order"}, Field: "status"},
+					Value:  status,
+				}}},
+			{Name: "OnShipped", Trigger: ast.Trigger{Kind: "state_transition", Binding: "order", Entity: "Order", Field: "status", ToValue: "shipped"},
+				Requires: []ast.Expression{*comparisonExpr(">", fieldAccess("total"), &ast.Expression{Kind: "literal", Type: "integer", LitValue: json.RawMessage("0")})},
+				Ensures:  []ast.EnsuresClause{{Kind: "trigger_emission", Name: "OrderShipped"}}},
+			{Name: "OnCancelled", Trigger: ast.Trigger{Kind: "state_transition", Binding: "order", Entity: "Order", Field: "status", ToValue: "cancelled"},
+				Ensures: []ast.EnsuresClause{{Kind: "entity_removal", Target: fieldAccess("order")}}},
+			{Name: "Archive", Trigger: ast.Trigger{Kind: "chained", Name: "OrderShipped", Binding: "order", Entity: "Order"},
+				Ensures: []ast.EnsuresClause{{Kind: "entity_removal", Target: fieldAccess("order")}}},
+		},
+	}
+	matrix := ActorCapabilityMatrix(spec, BuildSymbolTable(spec))
+
+	clerk := matrix[0]
+	if len(clerk.Surfaces) != 2 || clerk.Surfaces[1] != (SurfaceAccess{Surface: "BackOffice", Via: "Desk"}) {
+		t.Errorf("Clerk surfaces = %+v", clerk.Surfaces)
+	}
+	if len(clerk.Triggers) != 1 {
+		t.Fatalf("Clerk triggers = %+v", clerk.Triggers)
+	}
+	ship := clerk.Triggers[0]
+	if !slices.Equal(ship.Surfaces, []string{"BackOffice"}) {
+		t.Errorf("ShipOrder surfaces = %q", ship.Surfaces)
+	}
+	// Only the transition to the value written is followed.
+	if want := []string{"Ship", "Dispatch", "OnShipped", "Archive"}; !slices.Equal(ship.Rules, want) {
+		t.Errorf("ShipOrder rules = %q, want %q", ship.Rules, want)
+	}
+	if !slices.Equal(ship.Reads, []string{"Order.total", "Shipment.order"}) {
+		t.Errorf("ShipOrder reads = %q", ship.Reads)
+	}
+	if !slices.Equal(ship.Writes, []string{"Order.status"}) {
+		t.Errorf("ShipOrder writes = %q", ship.Writes)
+	}
+	if !slices.Equal(ship.Creates, []string{"Shipment"}) || !slices.Equal(ship.Removes, []string{"Order"}) {
+		t.Errorf("ShipOrder creates %q, removes %q", ship.Creates, ship.Removes)
+	}
+	if !slices.Equal(clerk.Writes, ship.Writes) || !slices.Equal(clerk.Removes, ship.Removes) {
+		t.Errorf("Clerk effects = %+v", clerk)
+	}
+
+	// CancelOrder fires no rule, so it has no effects.
+	auditor := matrix[1]
+	if len(auditor.Triggers) != 1 || len(auditor.Triggers[0].Rules) != 0 || auditor.Writes == nil {
+		t.Errorf("Auditor capabilities = %+v", auditor)
+	}
+}
@@ -0,0 +1,407 @@
+package semantic
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/foundry-zero/allium/internal/ast"
+)
+
+// enumLookup returns the qualified name (e.g. "Order.status") and values of
+// the enum a field path is typed as, or "" and nil when the type is unknown or
+// not an enum.
+type enumLookup func(path *ast.Expression) (string, []string)
+
+// conditionContradiction reports why cond can never hold, or returns "" when
+// no contradiction is found. It evaluates boolean literals and boolean field
+// paths, and/or/not, equality and inequality of field paths against
+// literals, ordering of field paths against integer literals, membership in
+// set literals, and comparisons of enum-typed paths with values outside the
+// enum. It is sound but incomplete: "" does not mean the condition is
+// satisfiable.
+func conditionContradiction(cond *ast.Expression, enums enumLookup) string {
+	return newCondFacts(enums).falsify(cond)
+}
+
+// condFacts accumulates the constraints a conjunction places on each field
+// path.
+type condFacts struct {
+	enums    enumLookup
+	allowed  map[string][]string // path -> values it may still take; absent means unconstrained
+	excluded map[string][]string // path -> values it may not take
+	bounds   map[string][2]int64 // path -> inclusive integer range it may take; absent means unbounded
+	reasons  map[string][]string // path -> constraints seen, for messages
+}
+
+func newCondFacts(enums enumLookup) *condFacts {
+	return &condFacts{
+		enums:    enums,
+		allowed:  make(map[string][]string),
+		excluded: make(map[string][]string),
+		bounds:   make(map[string][2]int64),
+		reasons:  make(map[string][]string),
+	}
+}
+
+func (c *condFacts) clone() *condFacts {
+	cp := newCondFacts(c.enums)
+	for k, v := range c.allowed {
+		cp.allowed[k] = slices.Clone(v)
+	}
+	for k, v := range c.excluded {
+		cp.excluded[k] = slices.Clone(v)
+	}
+	for k, v := range c.bounds {
+		cp.bounds[k] = v
+	}
+	for k, v := range c.reasons {
+		cp.reasons[k] = slices.Clone(v)
+	}
+	return cp
+}
+
+// falsify adds the constraints of e to c and returns the first contradiction
+// found, or "".
+func (c *condFacts) falsify(e *ast.Expression) string {
+	if e == nil {
+		return ""
+	}
+	switch e.Kind {
+	case "literal":
+		if e.Type == "boolean" && bytes.Equal(bytes.TrimSpace(e.LitValue), []byte("false")) {
+			return "the condition is the literal false"
+		}
+	case "not":
+		if op := e.Operand; op != nil && op.Kind == "literal" && op.Type == "boolean" &&
+			bytes.Equal(bytes.TrimSpace(op.LitValue), []byte("true")) {
+			return "the condition is 'not true'"
+		}
+		return c.falsifyNegation(e.Operand)
+	case "field_access":
+		return c.constrain(e, "=", "true")
+	case "boolean_logic":
+		switch e.Operator {
+		case "and":
+			if reason := c.falsify(e.Left); reason != "" {
+				return reason
+			}
+			return c.falsify(e.Right)
+		case "or":
+			left := c.clone().falsify(e.Left)
+			right := c.clone().falsify(e.Right)
+			if left != "" && right != "" {
+				return fmt.Sprintf("both alternatives are impossible (%s; %s)", left, right)
+			}
+		}
+	case "comparison":
+		return c.compare(e)
+	case "membership":
+		path := exprPath(e.Element)
+		values, ok := literalSet(e.Collection)
+		if path == "" || !ok {
+			return ""
+		}
+		return c.restrict(e.Element, path, values, fmt.Sprintf("%s in {%s}", path, strings.Join(values, ", ")))
+	}
+	return ""
+}
+
+// negatedOperators maps each comparison operator to the one that holds
+// exactly when it does not.
+var negatedOperators = map[string]string{"=": "!=", "!=": "=", "<": ">=", "<=": ">", ">": "<=", ">=": "<"}
+
+// flippedOperators maps each comparison operator to the one that holds with
+// its operands swapped.
+var flippedOperators = map[string]string{"=": "=", "!=": "!=", "<": ">", "<=": ">=", ">": "<", ">=": "<="}
+
+// falsifyNegation adds the constraints of e being false and returns the
+// first contradiction found, or "". Negations are pushed inward, so
+// not (a and b) holds when either operand is false and not (a or b) when
+// both are.
+func (c *condFacts) falsifyNegation(e *ast.Expression) string {
+	if e == nil {
+		return ""
+	}
+	switch e.Kind {
+	case "literal":
+		if e.Type == "boolean" && bytes.Equal(bytes.TrimSpace(e.LitValue), []byte("true")) {
+			return "the condition is the literal true"
+		}
+	case "not":
+		return c.falsify(e.Operand)
+	case "field_access":
+		return c.constrain(e, "=", "false")
+	case "boolean_logic":
+		switch e.Operator {
+		case "and":
+			left := c.clone().falsifyNegation(e.Left)
+			right := c.clone().falsifyNegation(e.Right)
+			if left != "" && right != "" {
+				return fmt.Sprintf("both alternatives are impossible (%s; %s)", left, right)
+			}
+		case "or":
+			if reason := c.falsifyNegation(e.Left); reason != "" {
+				return reason
+			}
+			return c.falsifyNegation(e.Right)
+		}
+	case "comparison":
+		if op, ok := negatedOperators[e.Operator]; ok {
+			negated := *e
+			negated.Operator = op
+			return c.compare(&negated)
+		}
+	case "membership":
+		path := exprPath(e.Element)
+		values, ok := literalSet(e.Collection)
+		if path == "" || !ok {
+			return ""
+		}
+		c.reasons[path] = append(c.reasons[path], fmt.Sprintf("%s not in {%s}", path, strings.Join(values, ", ")))
+		for _, v := range values {
+			c.exclude(e.Element, path, v)
+		}
+		return c.check(path)
+	}
+	return ""
+}
+
+// mayHold reports whether path may take the literal string value under the
+// constraints in c.
+func (c *condFacts) mayHold(path, value string) bool {
+	is := func(key string) bool { return unquoteLiteral(key) == value }
+	if allowed, ok := c.allowed[path]; ok && !slices.ContainsFunc(allowed, is) {
+		return false
+	}
+	return c.inBounds(path, value) && !slices.ContainsFunc(c.excluded[path], is)
+}
+
+func (c *condFacts) compare(e *ast.Expression) string {
+	if _, ok := flippedOperators[e.Operator]; !ok {
+		return ""
+	}
+	left, leftLit := literalKey(e.Left)
+	right, rightLit := literalKey(e.Right)
+	switch {
+	case leftLit && rightLit:
+		if holds, known := compareLiterals(e.Operator, left, right); known && !holds {
+			return fmt.Sprintf("%s %s %s is always false", left, e.Operator, right)
+		}
+		return ""
+	case rightLit:
+		return c.constrain(e.Left, e.Operator, right)
+	case leftLit:
+		return c.constrain(e.Right, flippedOperators[e.Operator], left)
+	}
+	return ""
+}
+
+// compareLiterals evaluates a comparison of two literal keys. Only equality
+// and inequality of any literals, and ordering of integers, are known.
+func compareLiterals(op, left, right string) (holds, known bool) {
+	switch op {
+	case "=":
+		return left == right, true
+	case "!=":
+		return left != right, true
+	}
+	l, lerr := strconv.ParseInt(left, 10, 64)
+	r, rerr := strconv.ParseInt(right, 10, 64)
+	if lerr != nil || rerr != nil {
+		return false, false
+	}
+	switch op {
+	case "<":
+		return l < r, true
+	case "<=":
+		return l <= r, true
+	case ">":
+		return l > r, true
+	case ">=":
+		return l >= r, true
+	}
+	return false, false
+}
+
+func (c *condFacts) constrain(pathExpr *ast.Expression, op, value string) string {
+	path := exprPath(pathExpr)
+	if path == "" {
+		return ""
+	}
+	desc := fmt.Sprintf("%s %s %s", path, op, value)
+	switch op {
+	case "=":
+		return c.restrict(pathExpr, path, []string{value}, desc)
+	case "!=":
+		c.reasons[path] = append(c.reasons[path], desc)
+		c.exclude(pathExpr, path, value)
+		return c.check(path)
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return ""
+	}
+	// Make strict bounds inclusive; x < MinInt64 and x > MaxInt64 are left
+	// alone rather than overflow.
+	switch {
+	case op == "<" && n > math.MinInt64:
+		op, n = "<=", n-1
+	case op == ">" && n < math.MaxInt64:
+		op, n = ">=", n+1
+	case op == "<" || op == ">":
+		return ""
+	}
+	r, ok := c.bounds[path]
+	if !ok {
+		r = [2]int64{math.MinInt64, math.MaxInt64}
+	}
+	if op == "<=" {
+		r[1] = min(r[1], n)
+	} else {
+		r[0] = max(r[0], n)
+	}
+	c.bounds[path] = r
+	c.reasons[path] = append(c.reasons[path], desc)
+	return c.check(path)
+}
+
+// exclude rules value out for path. Ruling a value out of an enum-typed path
+// leaves it the enum's other values.
+func (c *condFacts) exclude(pathExpr *ast.Expression, path, value string) {
+	if _, ok := c.allowed[path]; !ok {
+		if name, enumValues := c.enums(pathExpr); name != "" {
+			keys := make([]string, len(enumValues))
+			for i, v := range enumValues {
+				key, _ := json.Marshal(v)
+				keys[i] = string(key)
+			}
+			c.allowed[path] = keys
+		}
+	}
+	if !slices.Contains(c.excluded[path], value) {
+		c.excluded[path] = append(c.excluded[path], value)
+	}
+}
+
+// restrict narrows the values path may take to values.
+func (c *condFacts) restrict(pathExpr *ast.Expression, path string, values []string, desc string) string {
+	if name, enumValues := c.enums(pathExpr); name != "" {
+		for _, v := range values {
+			if !slices.Contains(enumValues, unquoteLiteral(v)) {
+				return fmt.Sprintf("%s is not a value of %s (%s)", v, name, strings.Join(enumValues, " | "))
+			}
+		}
+	}
+	c.reasons[path] = append(c.reasons[path], desc)
+	if prev, ok := c.allowed[path]; ok {
+		values = slices.DeleteFunc(slices.Clone(values), func(v string) bool { return !slices.Contains(prev, v) })
+	}
+	c.allowed[path] = values
+	return c.check(path)
+}
+
+// check reports a contradiction if no value remains for path.
+func (c *condFacts) check(path string) string {
+	if c.satisfiable(path) {
+		return ""
+	}
+	return "contradictory constraints " + strings.Join(c.reasons[path], " and ")
+}
+
+// satisfiable reports whether some value meets every constraint on path.
+func (c *condFacts) satisfiable(path string) bool {
+	excluded := c.excluded[path]
+	if allowed, ok := c.allowed[path]; ok {
+		return slices.ContainsFunc(allowed, func(v string) bool {
+			return c.inBounds(path, v) && !slices.Contains(excluded, v)
+		})
+	}
+	r, ok := c.bounds[path]
+	switch {
+	case !ok:
+		return true
+	case r[0] > r[1]:
+		return false
+	case uint64(r[1]-r[0]) >= uint64(len(excluded)):
+		return true // more integers in range than values excluded
+	}
+	for n := r[0]; ; n++ {
+		if !slices.Contains(excluded, strconv.FormatInt(n, 10)) {
+			return true
+		}
+		if n == r[1] {
+			return false
+		}
+	}
+}
+
+// inBounds reports whether an integer literal lies within the range bounding
+// path. Other literals are not bounded.
+func (c *condFacts) inBounds(path, key string) bool {
+	r, ok := c.bounds[path]
+	n, err := strconv.ParseInt(key, 10, 64)
+	return !ok || err != nil || r[0] <= n && n <= r[1]
+}
+
+// exprPath renders a field access chain as a dotted path, or returns "" for
+// any other expression.
+func exprPath(e *ast.Expression) string {
+	if e == nil || e.Kind != "field_access" {
+		return ""
+	}
+	if e.Object == nil {
+		return e.Field
+	}
+	base := exprPath(e.Object)
+	if base == "" {
+		return ""
+	}
+	return base + "." + e.Field
+}
+
+// literalKey returns the compact JSON form of a literal's value, which both
+// identifies it for comparison and displays it in messages.
+func literalKey(e *ast.Expression) (string, bool) {
+	if e == nil || e.Kind != "literal" || len(e.LitValue) == 0 {
+		return "", false
+	}
+	var b bytes.Buffer
+	if err := json.Compact(&b, e.LitValue); err != nil {
+		return "", false
+	}
+	return b.String(), true
+}
+
+// literalSet returns the literal keys of a set literal whose elements are all
+// literals.
+func literalSet(e *ast.Expression) ([]string, bool) {
+	if e == nil || e.Kind != "set_literal" {
+		return nil, false
+	}
+	values := make([]string, 0, len(e.Elements))
+	for i := range e.Elements {
+		key, ok := literalKey(&e.Elements[i])
+		if !ok {
+			return nil, false
+		}
+		if !slices.Contains(values, key) {
+			values = append(values, key)
+		}
+	}
+	return values, true
+}
+
+// unquoteLiteral returns the string a literal key encodes, or the key itself
+// for non-string literals.
+func unquoteLiteral(key string) string {
+	var s string
+	if err := json.Unmarshal([]byte(key), &s); err == nil {
+		return s
+	}
+	return key
+}
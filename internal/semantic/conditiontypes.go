@@ -0,0 +1,177 @@
+package semantic
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/report"
+)
+
+// checkConditionTypes reports RULE-58: conditions whose type is known must
+// be Boolean. Conditions are rule requires, for clause conditions and the
+// conditions of conditional ensures; surface context conditions and the
+// when conditions of exposes, provides, related surfaces and timeouts; and
+// actor identified_by conditions. The operands of and, or and not within
+// them are conditions too. Optional Booleans are accepted.
+func checkConditionTypes(findings []report.Finding, spec *ast.Spec, st *SymbolTable) []report.Finding {
+	for i, rule := range spec.Rules {
+		basePath := fmt.Sprintf("$.rules[%d]", i)
+		types := ruleFieldTypes(rule, spec, st)
+		for j := range rule.Requires {
+			findings = checkBooleanCondition(findings, &rule.Requires[j], types, st, ast.NewPath(ast.IndexPath(basePath, "requires", j)), spec.File)
+		}
+		if fc := rule.ForClause; fc != nil {
+			findings = checkBooleanCondition(findings, fc.Condition, types, st, ast.NewPath(basePath+".for_clause.condition"), spec.File)
+		}
+		for j, ec := range rule.Ensures {
+			findings = checkEnsuresConditionTypes(findings, ec, types, st, ast.IndexPath(basePath, "ensures", j), spec.File)
+		}
+	}
+
+	for i, s := range spec.Surfaces {
+		path := fmt.Sprintf("$.surfaces[%d]", i)
+		types := surfaceFieldTypes(s, spec, st)
+		if s.Context != nil {
+			findings = checkBooleanCondition(findings, s.Context.Condition, types, st, ast.NewPath(path+".context.condition"), spec.File)
+		}
+		for j, ex := range s.Exposes {
+			findings = checkBooleanCondition(findings, ex.When, types, st, ast.NewPath(ast.IndexPath(path, "exposes", j)+".when"), spec.File)
+		}
+		for j, p := range s.Provides {
+			findings = checkProvidesConditionTypes(findings, p, types, st, ast.IndexPath(path, "provides", j), spec.File)
+		}
+		for j, rel := range s.Related {
+			findings = checkBooleanCondition(findings, rel.When, types, st, ast.NewPath(ast.IndexPath(path, "related", j)+".when"), spec.File)
+		}
+		for j, to := range s.Timeout {
+			findings = checkBooleanCondition(findings, to.When, types, st, ast.NewPath(ast.IndexPath(path, "timeout", j)+".when"), spec.File)
+		}
+	}
+
+	for i, a := range spec.Actors {
+		entity := a.IdentifiedBy.Entity
+		types := derivedFieldTypes(entity, ast.DerivedValue{}, spec, st)
+		types["this"] = &ast.FieldType{Kind: "entity_ref", Entity: entity}
+		if a.Within != "" {
+			types["within"] = &ast.FieldType{Kind: "entity_ref", Entity: a.Within}
+		}
+		findings = checkBooleanCondition(findings, a.IdentifiedBy.Condition, types, st,
+			ast.NewPath(fmt.Sprintf("$.actors[%d].identified_by.condition", i)), spec.File)
+	}
+
+	return findings
+}
+
+// checkEnsuresConditionTypes checks the conditions of the conditionals in an
+// ensures tree, typing iteration and let bindings for their body as
+// checkStateChangeTargets does.
+func checkEnsuresConditionTypes(findings []report.Finding, ec ast.EnsuresClause, types map[string]*ast.FieldType, st *SymbolTable, path string, file string) []report.Finding {
+	switch ec.Kind {
+	case "conditional":
+		findings = checkBooleanCondition(findings, ec.Condition, types, st, ast.NewPath(path+".condition"), file)
+	case "iteration":
+		var element *ast.FieldType
+		if ct := resolveFieldAccessType(ec.Collection, types, st); ct != nil && (ct.Kind == "set" || ct.Kind == "list") {
+			element = ct.Element
+		}
+		types = withBinding(types, ec.Binding, element)
+	case "let_binding":
+		types = withBinding(types, ec.Name, letValueType(ec.Value, types, st))
+	}
+	for j, then := range ec.Then {
+		findings = checkEnsuresConditionTypes(findings, then, types, st, ast.IndexPath(path, "then", j), file)
+	}
+	for j, el := range ec.Else {
+		findings = checkEnsuresConditionTypes(findings, el, types, st, ast.IndexPath(path, "else", j), file)
+	}
+	for j, body := range ec.Body {
+		findings = checkEnsuresConditionTypes(findings, body, types, st, ast.IndexPath(path, "body", j), file)
+	}
+	return findings
+}
+
+// checkProvidesConditionTypes checks the when condition of a provides item
+// and of the items nested in a for_each, with the iteration binding typed as
+// an element of the collection.
+func checkProvidesConditionTypes(findings []report.Finding, p ast.ProvidesItem, types map[string]*ast.FieldType, st *SymbolTable, path string, file string) []report.Finding {
+	findings = checkBooleanCondition(findings, p.When, types, st, ast.NewPath(path+".when"), file)
+	if len(p.Items) == 0 {
+		return findings
+	}
+	inner := types
+	if p.Binding != "" {
+		inner = maps.Clone(types)
+		delete(inner, p.Binding)
+		if ct := resolveFieldAccessType(p.Collection, types, st); ct != nil && (ct.Kind == "set" || ct.Kind == "list") {
+			inner[p.Binding] = ct.Element
+		}
+	}
+	for k, item := range p.Items {
+		findings = checkProvidesConditionTypes(findings, item, inner, st, ast.IndexPath(path, "items", k), file)
+	}
+	return findings
+}
+
+// checkBooleanCondition reports cond if its type is known and not Boolean,
+// checking the operands of and, or and not in its place.
+func checkBooleanCondition(findings []report.Finding, cond *ast.Expression, types map[string]*ast.FieldType, st *SymbolTable, path *ast.Path, file string) []report.Finding {
+	if cond == nil {
+		return findings
+	}
+	switch cond.Kind {
+	case "boolean_logic":
+		findings = checkBooleanCondition(findings, cond.Left, types, st, path.Field("left"), file)
+		return checkBooleanCondition(findings, cond.Right, types, st, path.Field("right"), file)
+	case "not":
+		return checkBooleanCondition(findings, cond.Operand, types, st, path.Field("operand"), file)
+	}
+
+	ft := inferExprType(cond, types, st)
+	optional := ft != nil && ft.Kind == "optional"
+	if optional {
+		ft = ft.Inner
+	}
+	if ft == nil || ft.Kind == "primitive" && ft.Value == "Boolean" {
+		return findings
+	}
+	name := conditionTypeName(ft)
+	if name == "" {
+		return findings
+	}
+	subject, hint := "Condition", ""
+	if p := exprPath(cond); p != "" {
+		subject += " '" + p + "'"
+	}
+	switch {
+	case optional && exprPath(cond) != "":
+		hint = fmt.Sprintf("; test whether it is present with 'exists %s'", exprPath(cond))
+	case ft.Kind == "set" || ft.Kind == "list":
+		hint = "; test its elements with any or all, or its size with count"
+	case ft.Kind == "named_enum" || ft.Kind == "inline_enum":
+		hint = "; compare it with one of its values"
+	}
+	return append(findings, report.NewError(
+		"RULE-58",
+		fmt.Sprintf("%s is %s, not Boolean%s", subject, name, hint),
+		report.Location{File: file, Path: path.String()},
+	))
+}
+
+// conditionTypeName names a non-Boolean type in RULE-58 messages, or
+// returns "" for a type it cannot name.
+func conditionTypeName(ft *ast.FieldType) string {
+	switch ft.Kind {
+	case "primitive":
+		return "of type " + ft.Value
+	case "entity_ref":
+		return "an instance of '" + ft.Entity + "'"
+	case "named_enum":
+		return "of enum type " + ft.Name
+	case "inline_enum":
+		return "an enum"
+	case "set", "list":
+		return "a collection (" + collectionTypeName(ft) + ")"
+	}
+	return ""
+}
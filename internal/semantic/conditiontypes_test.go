@@ -0,0 +1,115 @@
+package semantic
+
+import (
+	"testing"
+
+	"github.com/foundry-zero/allium/internal/ast"
+)
+
+// conditionSpec returns the nullability test spec, whose Order also has an
+// inline status enum, a set of tags and a Boolean, with the given requires.
+func conditionSpec(requires ...ast.Expression) *ast.Spec {
+	spec := nullSpec(requires)
+	spec.Entities[0].Fields = append(spec.Entities[0].Fields,
+		ast.Field{Name: "status", Type: ast.FieldType{Kind: "inline_enum", Values: []string{"open", "paid"}}},
+		ast.Field{Name: "tags", Type: ast.FieldType{Kind: "set", Element: &ast.FieldType{Kind: "primitive", Value: "String"}}},
+		ast.Field{Name: "paid", Type: ast.FieldType{Kind: "primitive", Value: "Boolean"}},
+	)
+	return spec
+}
+
+func TestCheckConditionTypes_RULE58(t *testing.T) {
+	tests := []struct {
+		name string
+		cond *ast.Expression
+		path string
+		want string
+	}{
+		{"enum", chain("order", "status"), "$.rules[0].requires[0]",
+			"Condition 'order.status' is an enum, not Boolean; compare it with one of its values"},
+		{"integer", chain("order", "total"), "$.rules[0].requires[0]",
+			"Condition 'order.total' is of type Integer, not Boolean"},
+		{"optional", chain("order", "coupon"), "$.rules[0].requires[0]",
+			"Condition 'order.coupon' is an instance of 'Coupon', not Boolean; test whether it is present with 'exists order.coupon'"},
+		{"collection", chain("order", "tags"), "$.rules[0].requires[0]",
+			"Condition 'order.tags' is a collection (Set<String>), not Boolean; test its elements with any or all, or its size with count"},
+		{"arithmetic", arithmeticExpr("+", chain("order", "total"), intLitExpr(1)), "$.rules[0].requires[0]",
+			"Condition is of type Integer, not Boolean"},
+		{"operand of and", andExpr("and", chain("order", "paid"), chain("order", "status")), "$.rules[0].requires[0].right", ""},
+		{"operand of not", &ast.Expression{Kind: "not", Operand: chain("order", "total")}, "$.rules[0].requires[0].operand", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := conditionSpec(*tt.cond)
+			r58 := findingsWithRule(CheckExpressions(spec, BuildSymbolTable(spec)), "RULE-58")
+			if len(r58) != 1 {
+				t.Fatalf("expected 1 RULE-58 finding, got %v", r58)
+			}
+			if r58[0].Location.Path != tt.path {
+				t.Errorf("path = %q, want %q", r58[0].Location.Path, tt.path)
+			}
+			if tt.want != "" && r58[0].Message != tt.want {
+				t.Errorf("message = %q, want %q", r58[0].Message, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckConditionTypes_Boolean(t *testing.T) {
+	for name, cond := range map[string]*ast.Expression{
+		"Boolean field": chain("order", "paid"),
+		"comparison":    comparisonExpr("=", chain("order", "status"), strLitExpr("open")),
+		"exists":        existsExpr(chain("order", "coupon")),
+		"and":           andExpr("or", chain("order", "paid"), existsExpr(chain("order", "note"))),
+		"literal":       boolLitExpr(true),
+		"untyped":       chain("request", "flag"),
+		"derived value": chain("order", "is_late"),
+	} {
+		spec := conditionSpec(*cond)
+		if r58 := findingsWithRule(CheckExpressions(spec, BuildSymbolTable(spec)), "RULE-58"); len(r58) != 0 {
+			t.Errorf("%s: expected no RULE-58, got %v", name, r58)
+		}
+	}
+}
+
+func TestCheckConditionTypes_Positions(t *testing.T) {
+	status := chain("order", "status")
+	spec := conditionSpec()
+	spec.Rules[0].ForClause = &ast.ForClause{Binding: "c", Collection: chain("order", "tags"), Condition: status}
+	spec.Rules[0].Ensures = []ast.EnsuresClause{{Kind: "iteration", Binding: "o", Collection: chain("order", "orders"),
+		Body: []ast.EnsuresClause{{Kind: "conditional", Condition: status}}}}
+	spec.Surfaces = []ast.Surface{{
+		Name:    "OrderView",
+		Facing:  ast.FacingClause{Binding: "viewer", Type: "Coupon"},
+		Context: &ast.ContextClause{Binding: "order", Type: "Order", Condition: status},
+		Exposes: []ast.ExposesItem{{Expression: chain("order", "total"), When: status}},
+		Provides: []ast.ProvidesItem{{Kind: "for_each", Binding: "t", Collection: chain("order", "tags"),
+			Items: []ast.ProvidesItem{{Kind: "action", Trigger: "Tag", When: status}}}},
+		Related: []ast.RelatedItem{{Surface: "Other", When: status}},
+		Timeout: []ast.TimeoutItem{{Rule: "OnOrder", When: status}},
+	}}
+	spec.Actors = []ast.Actor{{Name: "Buyer", IdentifiedBy: ast.IdentifiedBy{Entity: "Order", Condition: fieldAccess("status")}}}
+
+	var paths []string
+	for _, f := range findingsWithRule(CheckExpressions(spec, BuildSymbolTable(spec)), "RULE-58") {
+		paths = append(paths, f.Location.Path)
+	}
+	want := []string{
+		"$.rules[0].for_clause.condition",
+		"$.rules[0].ensures[0].body[0].condition",
+		"$.surfaces[0].context.condition",
+		"$.surfaces[0].exposes[0].when",
+		"$.surfaces[0].provides[0].items[0].when",
+		"$.surfaces[0].related[0].when",
+		"$.surfaces[0].timeout[0].when",
+		"$.actors[0].identified_by.condition",
+	}
+	if len(paths) != len(want) {
+		t.Fatalf("RULE-58 paths = %v, want %v", paths, want)
+	}
+	for i := range want {
+		if paths[i] != want[i] {
+			t.Errorf("path %d = %q, want %q", i, paths[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package semantic
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/report"
+)
+
+// CheckCreations validates the fields supplied when an entity is created,
+// by a rule or as a default instance.
+//
+//   - RULE-45: An entity_creation ensures clause, including one bound by a
+//     let_binding, must create a declared entity, set only fields it
+//     declares, and set every field that is neither optional nor a collection
+//   - RULE-47: A default instance must be of a declared entity and set its
+//     fields as RULE-45 requires, each to a value of the field's type
+func CheckCreations(spec *ast.Spec, st *SymbolTable) []report.Finding {
+	var findings []report.Finding
+
+	for i, d := range spec.Defaults {
+		findings = checkDefaultFields(findings, spec, st, d, fmt.Sprintf("$.defaults[%d]", i))
+	}
+
+	for i, rule := range spec.Rules {
+		for j, ec := range rule.Ensures {
+			findings = walkCreations(findings, ec, fmt.Sprintf("$.rules[%d].ensures[%d]", i, j),
+				func(findings []report.Finding, ec ast.EnsuresClause, path string) []report.Finding {
+					return checkCreationFields(findings, spec, st, ec, path)
+				})
+		}
+	}
+
+	return findings
+}
+
+// walkCreations calls fn for every entity_creation clause in an ensures tree,
+// including those nested in conditional, iteration and let_binding bodies and
+// those bound as a let_binding value.
+func walkCreations(findings []report.Finding, ec ast.EnsuresClause, path string,
+	fn func([]report.Finding, ast.EnsuresClause, string) []report.Finding) []report.Finding {
+	switch ec.Kind {
+	case "entity_creation":
+		findings = fn(findings, ec, path)
+	case "let_binding":
+		var inner ast.EnsuresClause
+		if len(ec.Value) > 0 && json.Unmarshal(ec.Value, &inner) == nil && inner.Kind == "entity_creation" {
+			findings = fn(findings, inner, path+".value")
+		}
+	}
+	for j, then := range ec.Then {
+		findings = walkCreations(findings, then, ast.IndexPath(path, "then", j), fn)
+	}
+	for j, el := range ec.Else {
+		findings = walkCreations(findings, el, ast.IndexPath(path, "else", j), fn)
+	}
+	for j, body := range ec.Body {
+		findings = walkCreations(findings, body, ast.IndexPath(path, "body", j), fn)
+	}
+	return findings
+}
+
+// checkCreationFields checks the fields of an entity_creation against those
+// its entity, variant or external entity declares. The fields of an entity
+// imported through a use declaration are not known here.
+func checkCreationFields(findings []report.Finding, spec *ast.Spec, st *SymbolTable, ec ast.EnsuresClause, path string) []report.Finding {
+	if st.LookupUseDeclaration(ec.Entity) != nil {
+		return findings
+	}
+	members, ok := triggerEntityMembers(st, ec.Entity)
+	if !ok {
+		return append(findings, report.NewError(
+			"RULE-45",
+			fmt.Sprintf("Created entity '%s' is not declared%s", ec.Entity, didYouMean(ec.Entity, entityLikeNames(spec))),
+			report.Location{File: spec.File, Path: path + ".entity"},
+		))
+	}
+	return checkSuppliedFields(findings, spec, st, "RULE-45", fmt.Sprintf("Creation of '%s'", ec.Entity), ec.Entity, members, ec.Fields, path)
+}
+
+// checkSuppliedFields checks the fields set on a new instance of entity, with
+// the given members: each must be declared, and every field that is neither
+// optional nor a collection must be set. A discriminator is set by creating a
+// variant (RULE-19), so it need not be. subject names the instance in
+// messages, as in "Creation of 'Order'".
+func checkSuppliedFields(findings []report.Finding, spec *ast.Spec, st *SymbolTable, rule, subject, entity string,
+	members triggerMembers, fields map[string]ast.Expression, path string) []report.Finding {
+	declared := members.names(true, false)
+	supplied := slices.Sorted(maps.Keys(fields))
+	for _, name := range supplied {
+		if !slices.Contains(declared, name) {
+			findings = append(findings, report.NewError(
+				rule,
+				fmt.Sprintf("%s sets '%s', which is not a field of '%s'%s", subject, name, entity, didYouMean(name, declared)),
+				report.Location{File: spec.File, Path: path + ".fields." + name},
+			))
+		}
+	}
+
+	for _, f := range members.fields {
+		if slices.Contains(supplied, f.Name) || !requiredOnCreation(f.Type) || isDiscriminator(st, f.Type) {
+			continue
+		}
+		findings = append(findings, report.NewError(
+			rule,
+			fmt.Sprintf("%s does not set required field '%s'", subject, f.Name),
+			report.Location{File: spec.File, Path: path + ".fields"},
+		))
+	}
+	return findings
+}
+
+// requiredOnCreation reports whether a field of the given type must be set
+// when its entity is created. Optional fields start absent and collections
+// start empty.
+func requiredOnCreation(ft ast.FieldType) bool {
+	switch ft.Kind {
+	case "optional", "set", "list":
+		return false
+	}
+	return true
+}
+
+// isDiscriminator reports whether a field of the given type names variants.
+func isDiscriminator(st *SymbolTable, ft ast.FieldType) bool {
+	if ft.Kind != "inline_enum" {
+		return false
+	}
+	return slices.ContainsFunc(ft.Values, func(v string) bool {
+		return lookupVariantByEnumValue(st, v) != nil
+	})
+}
+
+// checkDefaultFields checks a default instance against its entity. Values
+// are checked where their type is known: literals, config parameters and
+// given bindings, compared with primitive, enum and optional fields.
+func checkDefaultFields(findings []report.Finding, spec *ast.Spec, st *SymbolTable, d ast.Default, path string) []report.Finding {
+	if st.LookupUseDeclaration(d.Entity) != nil {
+		return findings
+	}
+	members, ok := triggerEntityMembers(st, d.Entity)
+	if !ok {
+		return append(findings, report.NewError(
+			"RULE-47",
+			fmt.Sprintf("Default '%s' is of undeclared entity '%s'%s", d.Name, d.Entity, didYouMean(d.Entity, entityLikeNames(spec))),
+			report.Location{File: spec.File, Path: path + ".entity"},
+		))
+	}
+	subject := fmt.Sprintf("Default '%s'", d.Name)
+	findings = checkSuppliedFields(findings, spec, st, "RULE-47", subject, d.Entity, members, d.Fields, path)
+
+	types := make(map[string]*ast.FieldType)
+	for _, g := range spec.Given {
+		ft := st.ResolveType(g.Type)
+		types[g.Name] = &ft
+	}
+	for _, name := range slices.Sorted(maps.Keys(d.Fields)) {
+		f := members.field(name)
+		if f == nil {
+			continue
+		}
+		value := d.Fields[name]
+		if reason := valueMismatch(st, f.Type, &value, types); reason != "" {
+			findings = append(findings, report.NewError(
+				"RULE-47",
+				fmt.Sprintf("%s sets '%s' to %s", subject, name, reason),
+				report.Location{File: spec.File, Path: path + ".fields." + name},
+			))
+		}
+	}
+	return findings
+}
+
+// valueMismatch returns why value cannot be assigned to a field of type ft,
+// as in "a value of type Integer, but the field is String", or "" if it can or either
+// type is unknown.
+func valueMismatch(st *SymbolTable, ft ast.FieldType, value *ast.Expression, types map[string]*ast.FieldType) string {
+	optional := ft.Kind == "optional" && ft.Inner != nil
+	if optional {
+		ft = *ft.Inner
+	}
+	if value.Kind == "literal" {
+		switch value.Type {
+		case "null":
+			if !optional {
+				return "null, but the field is not optional"
+			}
+			return ""
+		case "enum_value", "string":
+			if values, ok := enumValuesOf(st, ft); ok {
+				if v := extractLiteralValue(value); !slices.Contains(values, v) {
+					return fmt.Sprintf("'%s', which is not a value of the field (%s)%s", v, strings.Join(values, " | "), didYouMean(v, values))
+				}
+				return ""
+			}
+		}
+	}
+
+	got, want := resolveExprType(value, types, st), fieldTypeToDescriptor(&ft)
+	if got == "" || want == "" || got == want || got == "Integer" && want == "Decimal" {
+		return ""
+	}
+	if _, ok := enumValuesOf(st, ft); ok {
+		want = "an enum"
+	}
+	if got == "EnumValue" {
+		return fmt.Sprintf("an enum value, but the field is %s", want)
+	}
+	return fmt.Sprintf("a value of type %s, but the field is %s", got, want)
+}
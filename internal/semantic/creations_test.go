@@ -0,0 +1,205 @@
+package semantic
+
+import (
+	"encoding/json"
+	"maps"
+	"strings"
+	"testing"
+
+	"github.com/foundry-zero/allium/internal/ast"
+)
+
+// creationSpec returns the trigger test spec with one rule whose ensures are
+// the given clauses.
+func creationSpec(ensures ...ast.EnsuresClause) *ast.Spec {
+	spec := triggerSpec(ast.Trigger{Kind: "external_stimulus", Name: "open_account"})
+	spec.Rules[0].Ensures = ensures
+	return spec
+}
+
+// creation returns an entity_creation of entity setting the given fields.
+func creation(entity string, fields ...string) ast.EnsuresClause {
+	ec := ast.EnsuresClause{Kind: "entity_creation", Entity: entity, Fields: map[string]ast.Expression{}}
+	for _, f := range fields {
+		ec.Fields[f] = *fieldAccess("value")
+	}
+	return ec
+}
+
+func TestCheckCreations_Valid(t *testing.T) {
+	account := []string{"status", "verified", "created_at", "name"}
+	for _, ec := range []ast.EnsuresClause{
+		creation("Account", account...),
+		creation("Business", append(account, "tier")...),
+		creation("Payment"), // state is optional
+		{Kind: "conditional", Condition: fieldAccess("value"), Then: []ast.EnsuresClause{creation("Account", account...)}},
+	} {
+		spec := creationSpec(ec)
+		if findings := CheckCreations(spec, BuildSymbolTable(spec)); len(findings) != 0 {
+			t.Errorf("creation of %s: expected no findings, got %v", ec.Entity, findings)
+		}
+	}
+}
+
+func TestCheckCreations_RULE45(t *testing.T) {
+	value, err := json.Marshal(creation("Account", "status", "verified", "created_at", "nmae"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		ensures  ast.EnsuresClause
+		paths    []string
+		messages []string
+	}{
+		{
+			name:     "undeclared entity",
+			ensures:  creation("Acount", "name"),
+			paths:    []string{"$.rules[0].ensures[0].entity"},
+			messages: []string{"did you mean 'Account'?"},
+		},
+		{
+			name:     "missing field",
+			ensures:  creation("Account", "status", "verified", "name"),
+			paths:    []string{"$.rules[0].ensures[0].fields"},
+			messages: []string{"does not set required field 'created_at'"},
+		},
+		{
+			name:     "missing base entity field of variant",
+			ensures:  creation("Business", "status", "verified", "created_at", "tier"),
+			paths:    []string{"$.rules[0].ensures[0].fields"},
+			messages: []string{"Creation of 'Business' does not set required field 'name'"},
+		},
+		{
+			name:     "unknown field in let binding",
+			ensures:  ast.EnsuresClause{Kind: "let_binding", Name: "account", Value: value},
+			paths:    []string{"$.rules[0].ensures[0].value.fields.nmae", "$.rules[0].ensures[0].value.fields"},
+			messages: []string{"sets 'nmae', which is not a field of 'Account' (did you mean 'name'?)", "required field 'name'"},
+		},
+		{
+			name: "nested in iteration",
+			ensures: ast.EnsuresClause{Kind: "iteration", Binding: "x", Collection: fieldAccess("xs"),
+				Body: []ast.EnsuresClause{creation("Payment", "status")}},
+			paths:    []string{"$.rules[0].ensures[0].body[0].fields.status"},
+			messages: []string{"did you mean 'state'?"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := creationSpec(tt.ensures)
+			findings := findingsWithRule(CheckCreations(spec, BuildSymbolTable(spec)), "RULE-45")
+			if len(findings) != len(tt.paths) {
+				t.Fatalf("expected %d RULE-45 findings, got %v", len(tt.paths), findings)
+			}
+			for i, f := range findings {
+				if f.Location.Path != tt.paths[i] {
+					t.Errorf("path = %q, want %q", f.Location.Path, tt.paths[i])
+				}
+				if !strings.Contains(f.Message, tt.messages[i]) {
+					t.Errorf("message %q does not contain %q", f.Message, tt.messages[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCheckCreations_Discriminator(t *testing.T) {
+	spec := creationSpec(creation("Card", "number"))
+	spec.Entities = append(spec.Entities, ast.Entity{Name: "Method", Fields: []ast.Field{
+		{Name: "kind", Type: ast.FieldType{Kind: "inline_enum", Values: []string{"card"}}},
+	}})
+	spec.Variants = append(spec.Variants, ast.Variant{Name: "Card", BaseEntity: "Method", Fields: []ast.Field{
+		{Name: "number", Type: ast.FieldType{Kind: "primitive", Value: "String"}},
+	}})
+	if findings := CheckCreations(spec, BuildSymbolTable(spec)); len(findings) != 0 {
+		t.Errorf("expected the discriminator to be set by the variant, got %v", findings)
+	}
+}
+
+func TestCheckCreations_RULE47(t *testing.T) {
+	lit := func(typ, value string) ast.Expression {
+		return ast.Expression{Kind: "literal", Type: typ, LitValue: json.RawMessage(value)}
+	}
+	valid := map[string]ast.Expression{
+		"status":     lit("enum_value", `"active"`),
+		"verified":   lit("boolean", `true`),
+		"created_at": lit("timestamp", `"2024-01-01T00:00:00Z"`),
+		"name":       lit("string", `"System"`),
+	}
+	with := func(name string, value ast.Expression) map[string]ast.Expression {
+		fields := maps.Clone(valid)
+		fields[name] = value
+		return fields
+	}
+
+	tests := []struct {
+		name    string
+		def     ast.Default
+		path    string
+		message string
+	}{
+		{name: "valid", def: ast.Default{Entity: "Account", Name: "system", Fields: valid}},
+		{name: "optional null", def: ast.Default{Entity: "Payment", Name: "none", Fields: map[string]ast.Expression{"state": lit("null", `null`)}}},
+		{
+			name:    "undeclared entity",
+			def:     ast.Default{Entity: "Acount", Name: "system", Fields: valid},
+			path:    "$.defaults[0].entity",
+			message: "Default 'system' is of undeclared entity 'Acount' (did you mean 'Account'?)",
+		},
+		{
+			name:    "unknown field",
+			def:     ast.Default{Entity: "Account", Name: "system", Fields: with("nmae", lit("string", `"x"`))},
+			path:    "$.defaults[0].fields.nmae",
+			message: "Default 'system' sets 'nmae', which is not a field of 'Account' (did you mean 'name'?)",
+		},
+		{
+			name: "missing field",
+			def: ast.Default{Entity: "Account", Name: "system", Fields: map[string]ast.Expression{
+				"status": valid["status"], "verified": valid["verified"], "created_at": valid["created_at"]}},
+			path:    "$.defaults[0].fields",
+			message: "Default 'system' does not set required field 'name'",
+		},
+		{
+			name:    "wrong primitive type",
+			def:     ast.Default{Entity: "Account", Name: "system", Fields: with("verified", lit("string", `"yes"`))},
+			path:    "$.defaults[0].fields.verified",
+			message: "sets 'verified' to a value of type String, but the field is Boolean",
+		},
+		{
+			name:    "enum value not declared",
+			def:     ast.Default{Entity: "Account", Name: "system", Fields: with("status", lit("enum_value", `"actve"`))},
+			path:    "$.defaults[0].fields.status",
+			message: "sets 'status' to 'actve', which is not a value of the field (active | closed) (did you mean 'active'?)",
+		},
+		{
+			name:    "null for required field",
+			def:     ast.Default{Entity: "Account", Name: "system", Fields: with("name", lit("null", `null`))},
+			path:    "$.defaults[0].fields.name",
+			message: "sets 'name' to null, but the field is not optional",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := creationSpec()
+			spec.Defaults = []ast.Default{tt.def}
+			findings := findingsWithRule(CheckCreations(spec, BuildSymbolTable(spec)), "RULE-47")
+			if tt.message == "" {
+				if len(findings) != 0 {
+					t.Errorf("expected no RULE-47, got %v", findings)
+				}
+				return
+			}
+			if len(findings) != 1 {
+				t.Fatalf("expected 1 RULE-47, got %v", findings)
+			}
+			if findings[0].Location.Path != tt.path {
+				t.Errorf("path = %q, want %q", findings[0].Location.Path, tt.path)
+			}
+			if !strings.Contains(findings[0].Message, tt.message) {
+				t.Errorf("message %q does not contain %q", findings[0].Message, tt.message)
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package semantic
+
+import (
+	"encoding/json"
+	goast "go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// ensuresKindsIgnored lists, for each function that dispatches on an ensures
+// clause's kind, the kinds it deliberately leaves unhandled and why. Every
+// kind in the schema must be handled by each dispatcher, listed here, or
+// covered by a default case; TestEnsuresKindCoverage fails otherwise, so a
+// kind added to the schema cannot be skipped silently.
+var ensuresKindsIgnored = map[string]map[string]string{
+	"collectEnsuresStateInfo": {
+		"trigger_emission": "emits an event without assigning state",
+		"entity_removal":   "removes an instance without assigning state",
+		"set_mutation":     "state fields are enums, never sets",
+	},
+	"ensuresExpires": {
+		"entity_creation":  "creates an instance rather than expiring one",
+		"trigger_emission": "emits an event without changing the instance",
+		"set_mutation":     "state fields are enums, never sets",
+		"conditional":      "nested clauses are walked after the switch",
+		"iteration":        "nested clauses are walked after the switch",
+		"let_binding":      "nested clauses are walked after the switch",
+	},
+	"checkCreationVariantUse": {
+		"state_change":     "creates nothing",
+		"trigger_emission": "creates nothing",
+		"entity_removal":   "creates nothing",
+		"set_mutation":     "creates nothing",
+	},
+	"walkCreations": {
+		"state_change":     "creates nothing",
+		"trigger_emission": "creates nothing",
+		"entity_removal":   "creates nothing",
+		"set_mutation":     "creates nothing",
+		"conditional":      "nested clauses are walked after the switch",
+		"iteration":        "nested clauses are walked after the switch",
+	},
+	"checkStateChangeTargets": {
+		"entity_creation":  "assigns no existing field",
+		"trigger_emission": "assigns no field",
+		"conditional":      "nested clauses are walked after the switch",
+	},
+	"checkEnsuresNarrowing": {
+		"trigger_emission": "assigns no field",
+		"entity_removal":   "assigns no field",
+		"set_mutation":     "adds or removes elements rather than assigning",
+		"conditional":      "nested clauses are walked after the switch",
+	},
+	"checkEnsuresConditionTypes": {
+		"state_change":     "contains no condition",
+		"entity_creation":  "contains no condition",
+		"trigger_emission": "contains no condition",
+		"entity_removal":   "contains no condition",
+		"set_mutation":     "contains no condition",
+	},
+	"checkEnumConditionals": {
+		"state_change":     "contains no conditional",
+		"entity_creation":  "contains no conditional",
+		"trigger_emission": "contains no conditional",
+		"entity_removal":   "contains no conditional",
+		"set_mutation":     "contains no conditional",
+	},
+	"variantAccess.ensures": {
+		"state_change":     "expressions are walked before the switch",
+		"entity_creation":  "expressions are walked before the switch",
+		"trigger_emission": "expressions are walked before the switch",
+		"entity_removal":   "expressions are walked before the switch",
+		"set_mutation":     "expressions are walked before the switch",
+	},
+	"nullChecker.passesParam": {
+		"entity_removal": "passes no value on",
+		"conditional":    "passes no value on; its condition is a read",
+		"iteration":      "passes no value on; its collection is a read",
+		"let_binding":    "binds the value to a name rather than to a field or parameter",
+		"set_mutation":   "adds to a set, which holds no absent elements",
+	},
+	"ruleCollector.ensures": {
+		"conditional": "its condition is read and its nested clauses walked after the switch",
+	},
+	"checkWarn29ShadowedBinding": {
+		"state_change":     "binds nothing; expressions are walked before the switch",
+		"entity_creation":  "binds nothing; expressions are walked before the switch",
+		"trigger_emission": "binds nothing; expressions are walked before the switch",
+		"entity_removal":   "binds nothing; expressions are walked before the switch",
+		"set_mutation":     "binds nothing; expressions are walked before the switch",
+		"conditional":      "binds nothing; nested clauses are walked before the switch",
+	},
+	"checkUnguardedCreation": {
+		"state_change":     "creates nothing",
+		"trigger_emission": "creates nothing",
+		"entity_removal":   "creates nothing",
+		"set_mutation":     "creates nothing",
+	},
+	"clauseParamTypes": {
+		"trigger_emission": "arguments are typed by the rules of the emitted trigger",
+		"entity_removal":   "its target is a reference, not a value",
+		"conditional":      "nested clauses are visited by WalkClauses",
+		"iteration":        "nested clauses are visited by WalkClauses",
+		"let_binding":      "nested clauses are visited by WalkClauses",
+	},
+	"nullChecker.ensures": {
+		"state_change":     "binds nothing; its expressions are checked before the switch",
+		"entity_creation":  "binds nothing; its expressions are checked before the switch",
+		"trigger_emission": "binds nothing; its expressions are checked before the switch",
+		"entity_removal":   "binds nothing; its expressions are checked before the switch",
+		"set_mutation":     "binds nothing; its expressions are checked before the switch",
+	},
+}
+
+// schemaKinds returns the kind constants of the alternatives of the named
+// oneOf definition in a schema definitions file.
+func schemaKinds(t *testing.T, file, def string) []string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("..", "..", "schemas", "v1", "definitions", file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Defs map[string]struct {
+			OneOf []struct {
+				Ref string `json:"$ref"`
+			} `json:"oneOf"`
+			Properties struct {
+				Kind struct {
+					Const string `json:"const"`
+				} `json:"kind"`
+			} `json:"properties"`
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	var kinds []string
+	for _, alt := range doc.Defs[def].OneOf {
+		k := doc.Defs[strings.TrimPrefix(alt.Ref, "#/$defs/")].Properties.Kind.Const
+		if k == "" {
+			t.Fatalf("%s: %s alternative %s has no kind constant", file, def, alt.Ref)
+		}
+		kinds = append(kinds, k)
+	}
+	if len(kinds) == 0 {
+		t.Fatalf("%s: no alternatives found for %s", file, def)
+	}
+	return kinds
+}
+
+// kindSwitch is a switch on a .Kind field found in the validator's source.
+type kindSwitch struct {
+	fn         string // enclosing function, e.g. "checkUnguardedCreation" or "ensuresEffects.check"
+	pos        token.Position
+	cases      []string
+	hasDefault bool
+}
+
+// findKindSwitches parses the non-test Go files under root and returns every
+// switch statement whose tag is a .Kind selector.
+func findKindSwitches(t *testing.T, root string) []kindSwitch {
+	t.Helper()
+	fset := token.NewFileSet()
+	var switches []kindSwitch
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return err
+		}
+		f, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+		for _, decl := range f.Decls {
+			fd, ok := decl.(*goast.FuncDecl)
+			if !ok || fd.Body == nil {
+				continue
+			}
+			name := fd.Name.Name
+			if fd.Recv != nil && len(fd.Recv.List) == 1 {
+				recv := fd.Recv.List[0].Type
+				if star, ok := recv.(*goast.StarExpr); ok {
+					recv = star.X
+				}
+				if id, ok := recv.(*goast.Ident); ok {
+					name = id.Name + "." + name
+				}
+			}
+			goast.Inspect(fd.Body, func(n goast.Node) bool {
+				sw, ok := n.(*goast.SwitchStmt)
+				if !ok {
+					return true
+				}
+				if sel, ok := sw.Tag.(*goast.SelectorExpr); !ok || sel.Sel.Name != "Kind" {
+					return true
+				}
+				ks := kindSwitch{fn: name, pos: fset.Position(sw.Pos())}
+				for _, stmt := range sw.Body.List {
+					cc := stmt.(*goast.CaseClause)
+					if cc.List == nil {
+						ks.hasDefault = true
+					}
+					for _, e := range cc.List {
+						if lit, ok := e.(*goast.BasicLit); ok && lit.Kind == token.STRING {
+							if v, err := strconv.Unquote(lit.Value); err == nil {
+								ks.cases = append(ks.cases, v)
+							}
+						}
+					}
+				}
+				switches = append(switches, ks)
+				return true
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return switches
+}
+
+// TestEnsuresKindCoverage audits every switch over ensures clause kinds in
+// the validator against the schema's list of kinds. A switch dispatches on
+// ensures kinds when it has a case for a kind that is not also a trigger or
+// expression kind.
+func TestEnsuresKindCoverage(t *testing.T) {
+	ensuresKinds := schemaKinds(t, "rules.json", "EnsuresClause")
+	var otherKinds []string
+	otherKinds = append(otherKinds, schemaKinds(t, "rules.json", "Trigger")...)
+	otherKinds = append(otherKinds, schemaKinds(t, "expressions.json", "Expression")...)
+
+	dispatchers := make(map[string]bool)
+	for _, sw := range findKindSwitches(t, "..") {
+		isEnsures := slices.ContainsFunc(sw.cases, func(k string) bool {
+			return slices.Contains(ensuresKinds, k) && !slices.Contains(otherKinds, k)
+		})
+		if !isEnsures {
+			continue
+		}
+		dispatchers[sw.fn] = true
+		ignored := ensuresKindsIgnored[sw.fn]
+		for k := range ignored {
+			if slices.Contains(sw.cases, k) {
+				t.Errorf("%s (%s): ensures kind %q is handled but listed as ignored", sw.fn, sw.pos, k)
+			}
+		}
+		if sw.hasDefault {
+			continue
+		}
+		for _, k := range ensuresKinds {
+			if _, ok := ignored[k]; !ok && !slices.Contains(sw.cases, k) {
+				t.Errorf("%s (%s) silently ignores ensures kind %q: handle it or add it to ensuresKindsIgnored with a reason", sw.fn, sw.pos, k)
+			}
+		}
+	}
+
+	for fn := range ensuresKindsIgnored {
+		if !dispatchers[fn] {
+			t.Errorf("ensuresKindsIgnored lists %s, which no longer dispatches on ensures kinds", fn)
+		}
+	}
+}
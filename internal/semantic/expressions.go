@@ -1,9 +1,10 @@
 package semantic
 
 import (
-	"encoding/json"
 	"fmt"
 	"maps"
+	"slices"
+	"strings"
 
 	"github.com/foundry-zero/allium/internal/ast"
 	"github.com/foundry-zero/allium/internal/report"
@@ -13,9 +14,17 @@ import (
 //
 //   - RULE-10: Derived value dependency cycles (Tarjan SCC)
 //   - RULE-11: All field_access roots must be in scope
-//   - RULE-12: Type compatibility in comparisons and arithmetic
+//   - RULE-12: Type compatibility in comparisons and arithmetic, in rules,
+//     derived values and surfaces
 //   - RULE-13: any/all expressions must have explicit lambda parameters
 //   - RULE-14: Inline enum comparisons are forbidden; named enum comparisons must be same type
+//   - RULE-40: Calls to registered functions must match their signatures
+//   - RULE-49: Collection operations apply to collections, and comparisons
+//     and arithmetic to single values
+//   - RULE-54: Enum value literals compared with, or tested for membership
+//     against, a field of enum type must be values of that enum
+//   - RULE-58: Requires, when and other conditions must be Boolean when
+//     their type is known
 func CheckExpressions(spec *ast.Spec, st *SymbolTable) []report.Finding {
 	var findings []report.Finding
 
@@ -25,7 +34,9 @@ func CheckExpressions(spec *ast.Spec, st *SymbolTable) []report.Finding {
 	// RULE-11: Out-of-scope field access in rules
 	findings = checkRuleScopes(findings, spec, st)
 
-	// RULE-12: Type mismatches in comparisons and arithmetic
+	// RULE-12, RULE-40, RULE-49, RULE-54: Type mismatches in comparisons,
+	// arithmetic, function calls and collection operations, and enum values
+	// outside their field's enum
 	findings = checkTypeMismatches(findings, spec, st)
 
 	// RULE-13: any/all lambda parameter check
@@ -34,6 +45,9 @@ func CheckExpressions(spec *ast.Spec, st *SymbolTable) []report.Finding {
 	// RULE-14: Enum comparison check
 	findings = checkEnumComparisons(findings, spec, st)
 
+	// RULE-58: Conditions that are not Boolean
+	findings = checkConditionTypes(findings, spec, st)
+
 	return findings
 }
 
@@ -64,19 +78,8 @@ func checkDerivedValueCycles(findings []report.Finding, spec *ast.Spec) []report
 // detectDerivedCycles runs Tarjan's SCC on the derived value dependency graph
 // and reports any multi-node strongly connected components (cycles).
 func detectDerivedCycles(findings []report.Finding, dvs []ast.DerivedValue, path string, file string) []report.Finding {
-	// Build name -> index and adjacency list
-	nameIdx := make(map[string]int, len(dvs))
-	for j, dv := range dvs {
-		nameIdx[dv.Name] = j
-	}
-
-	adj := make([][]int, len(dvs))
-	for j, dv := range dvs {
-		adj[j] = collectDerivedRefs(dv.Expression, nameIdx)
-	}
-
 	// Run Tarjan's SCC
-	sccs := tarjanSCC(adj)
+	sccs := tarjanSCC(derivedGraph(dvs))
 	for _, scc := range sccs {
 		if len(scc) > 1 {
 			names := make([]string, len(scc))
@@ -96,6 +99,77 @@ func detectDerivedCycles(findings []report.Finding, dvs []ast.DerivedValue, path
 	return findings
 }
 
+// DerivedValueOrder returns the names of dvs in an order in which each can
+// be evaluated after the derived values it references. Values that do not
+// depend on one another keep their declaration order. It returns an error
+// naming the cycle if the values depend on each other cyclically (RULE-10).
+func DerivedValueOrder(dvs []ast.DerivedValue) ([]string, error) {
+	// Tarjan's algorithm completes each component after every component it
+	// references, so the components come out in evaluation order.
+	order := make([]string, 0, len(dvs))
+	for _, scc := range tarjanSCC(derivedGraph(dvs)) {
+		if len(scc) > 1 {
+			names := make([]string, len(scc))
+			for k, idx := range scc {
+				names[k] = dvs[idx].Name
+			}
+			names = append(names, names[0])
+			return nil, fmt.Errorf("cycle detected in derived values: %s", joinArrow(names))
+		}
+		order = append(order, dvs[scc[0]].Name)
+	}
+	return order, nil
+}
+
+// DerivedOrder is the evaluation order of the derived values of one entity
+// or value type.
+type DerivedOrder struct {
+	Name  string   `json:"name"`
+	Kind  string   `json:"kind"` // "entity" or "value_type"
+	Order []string `json:"order,omitempty"`
+	Error string   `json:"error,omitempty"` // set instead of Order when the values form a cycle
+}
+
+// DerivedValueOrders returns the derived value evaluation order of every
+// entity and value type in spec that declares derived values.
+func DerivedValueOrders(spec *ast.Spec) []DerivedOrder {
+	var orders []DerivedOrder
+	add := func(name, kind string, dvs []ast.DerivedValue) {
+		if len(dvs) == 0 {
+			return
+		}
+		o := DerivedOrder{Name: name, Kind: kind}
+		order, err := DerivedValueOrder(dvs)
+		if err != nil {
+			o.Error = err.Error()
+		} else {
+			o.Order = order
+		}
+		orders = append(orders, o)
+	}
+	for _, e := range spec.Entities {
+		add(e.Name, "entity", e.DerivedValues)
+	}
+	for _, vt := range spec.ValueTypes {
+		add(vt.Name, "value_type", vt.DerivedValues)
+	}
+	return orders
+}
+
+// derivedGraph returns the dependency graph of dvs as adjacency lists: the
+// indices of the derived values each one references.
+func derivedGraph(dvs []ast.DerivedValue) [][]int {
+	nameIdx := make(map[string]int, len(dvs))
+	for j, dv := range dvs {
+		nameIdx[dv.Name] = j
+	}
+	adj := make([][]int, len(dvs))
+	for j, dv := range dvs {
+		adj[j] = collectDerivedRefs(dv.Expression, nameIdx)
+	}
+	return adj
+}
+
 // collectDerivedRefs finds which derived values an expression references.
 func collectDerivedRefs(expr *ast.Expression, nameIdx map[string]int) []int {
 	if expr == nil {
@@ -104,39 +178,23 @@ func collectDerivedRefs(expr *ast.Expression, nameIdx map[string]int) []int {
 	var refs []int
 	seen := make(map[int]bool)
 
-	var walk func(e *ast.Expression)
-	walk = func(e *ast.Expression) {
-		if e == nil {
-			return
-		}
+	ast.Walk(expr, func(e *ast.Expression) bool {
 		if e.Kind == "field_access" && e.Object == nil {
 			if idx, ok := nameIdx[e.Field]; ok && !seen[idx] {
 				refs = append(refs, idx)
 				seen[idx] = true
 			}
 		}
-		walk(e.Object)
-		walk(e.Left)
-		walk(e.Right)
-		walk(e.Target)
-		walk(e.Operand)
-		walk(e.Collection)
-		walk(e.Lambda)
-		walk(e.Condition)
-		walk(e.Body)
-		walk(e.Element)
-		for j := range e.FuncArguments {
-			walk(&e.FuncArguments[j])
-		}
-		for j := range e.Elements {
-			walk(&e.Elements[j])
-		}
-	}
-	walk(expr)
+		return true
+	})
 	return refs
 }
 
 // tarjanSCC returns strongly connected components using Tarjan's algorithm.
+// Components are completed, and returned, after every component reachable
+// from them. The depth-first search keeps an explicit stack of frames, each
+// a node and the position of the next edge to follow, so long dependency
+// chains cannot exhaust the goroutine stack.
 func tarjanSCC(adj [][]int) [][]int {
 	n := len(adj)
 	index := make([]int, n)
@@ -147,42 +205,57 @@ func tarjanSCC(adj [][]int) [][]int {
 	var sccs [][]int
 	counter := 0
 
-	var strongConnect func(v int)
-	strongConnect = func(v int) {
+	type frame struct{ v, edge int }
+	var calls []frame
+	visit := func(v int) {
 		index[v] = counter
 		lowlink[v] = counter
 		counter++
 		defined[v] = true
 		stack = append(stack, v)
 		onStack[v] = true
+		calls = append(calls, frame{v: v})
+	}
 
-		for _, w := range adj[v] {
-			if !defined[w] {
-				strongConnect(w)
-				lowlink[v] = min(lowlink[v], lowlink[w])
-			} else if onStack[w] {
-				lowlink[v] = min(lowlink[v], index[w])
-			}
+	for root := range n {
+		if defined[root] {
+			continue
 		}
-
-		if lowlink[v] == index[v] {
-			var scc []int
-			for {
-				w := stack[len(stack)-1]
-				stack = stack[:len(stack)-1]
-				onStack[w] = false
-				scc = append(scc, w)
-				if w == v {
-					break
+		visit(root)
+		for len(calls) > 0 {
+			f := &calls[len(calls)-1]
+			v := f.v
+			if f.edge < len(adj[v]) {
+				w := adj[v][f.edge]
+				f.edge++
+				if !defined[w] {
+					visit(w)
+				} else if onStack[w] {
+					lowlink[v] = min(lowlink[v], index[w])
 				}
+				continue
 			}
-			sccs = append(sccs, scc)
-		}
-	}
 
-	for v := range n {
-		if !defined[v] {
-			strongConnect(v)
+			// Every edge of v has been followed: complete v, then return
+			// to its caller.
+			calls = calls[:len(calls)-1]
+			if lowlink[v] == index[v] {
+				var scc []int
+				for {
+					w := stack[len(stack)-1]
+					stack = stack[:len(stack)-1]
+					onStack[w] = false
+					scc = append(scc, w)
+					if w == v {
+						break
+					}
+				}
+				sccs = append(sccs, scc)
+			}
+			if len(calls) > 0 {
+				caller := calls[len(calls)-1].v
+				lowlink[caller] = min(lowlink[caller], lowlink[v])
+			}
 		}
 	}
 
@@ -190,11 +263,7 @@ func tarjanSCC(adj [][]int) [][]int {
 }
 
 func joinArrow(parts []string) string {
-	result := parts[0]
-	for _, p := range parts[1:] {
-		result += " -> " + p
-	}
-	return result
+	return strings.Join(parts, " -> ")
 }
 
 // --- RULE-11: Out-of-scope field access ---
@@ -224,7 +293,7 @@ func checkRuleScopes(findings []report.Finding, spec *ast.Spec, _ *SymbolTable)
 		letScope := copyScope(scope)
 		for j, lb := range rule.LetBindings {
 			findings = walkForScopeViolations(findings, lb.Expression, letScope,
-				fmt.Sprintf("%s.let_bindings[%d].expression", basePath, j), spec.File)
+				ast.NewPath(ast.IndexPath(basePath, "let_bindings", j)+".expression"), spec.File)
 			// Add the let binding name to scope for subsequent bindings
 			letScope[lb.Name] = true
 		}
@@ -238,18 +307,18 @@ func checkRuleScopes(findings []report.Finding, spec *ast.Spec, _ *SymbolTable)
 		// Check requires (let bindings are in scope for requires)
 		for j, req := range rule.Requires {
 			findings = walkForScopeViolations(findings, &req, fullScope,
-				fmt.Sprintf("%s.requires[%d]", basePath, j), spec.File)
+				ast.NewPath(ast.IndexPath(basePath, "requires", j)), spec.File)
 		}
 
 		// Check for_clause
 		if rule.ForClause != nil {
 			findings = walkForScopeViolations(findings, rule.ForClause.Collection, fullScope,
-				fmt.Sprintf("%s.for_clause.collection", basePath), spec.File)
+				ast.NewPath(basePath+".for_clause.collection"), spec.File)
 			if rule.ForClause.Condition != nil {
 				forScope := copyScope(fullScope)
 				forScope[rule.ForClause.Binding] = true
 				findings = walkForScopeViolations(findings, rule.ForClause.Condition, forScope,
-					fmt.Sprintf("%s.for_clause.condition", basePath), spec.File)
+					ast.NewPath(basePath+".for_clause.condition"), spec.File)
 			}
 			// Add for-clause binding to scope for ensures
 			fullScope[rule.ForClause.Binding] = true
@@ -258,7 +327,7 @@ func checkRuleScopes(findings []report.Finding, spec *ast.Spec, _ *SymbolTable)
 		// Check ensures clauses
 		for j, ec := range rule.Ensures {
 			findings = walkEnsuresForScopeViolations(findings, ec, fullScope,
-				fmt.Sprintf("%s.ensures[%d]", basePath, j), spec.File)
+				ast.IndexPath(basePath, "ensures", j), spec.File)
 		}
 	}
 
@@ -290,133 +359,48 @@ func copyScope(src map[string]bool) map[string]bool {
 }
 
 // walkForScopeViolations walks an expression tree and reports root field_access
-// identifiers that are not in the given scope.
-func walkForScopeViolations(findings []report.Finding, expr *ast.Expression, scope map[string]bool, path string, file string) []report.Finding {
-	if expr == nil {
-		return findings
-	}
-
-	if expr.Kind == "field_access" && expr.Object == nil {
-		if !scope[expr.Field] {
-			findings = append(findings, report.NewError(
-				"RULE-11",
-				fmt.Sprintf("Identifier '%s' is not in scope", expr.Field),
-				report.Location{File: file, Path: path},
-			))
-		}
-		return findings // no need to recurse into a root field_access
-	}
-
-	// For lambda expressions, add parameter to scope for the body
-	if expr.Kind == "lambda" && expr.Parameter != "" {
-		lambdaScope := copyScope(scope)
-		lambdaScope[expr.Parameter] = true
-		findings = walkForScopeViolations(findings, expr.Body, lambdaScope, path+".body", file)
-		return findings
-	}
-
-	// For join_lookup, the fields map values are expressions, walk them
-	if expr.Kind == "join_lookup" {
-		for name, fieldExpr := range expr.Fields {
-			fe := fieldExpr // avoid aliasing
-			findings = walkForScopeViolations(findings, &fe, scope,
-				fmt.Sprintf("%s.fields.%s", path, name), file)
+// identifiers that are not in the given scope. A lambda adds its parameter to
+// the scope of its body.
+func walkForScopeViolations(findings []report.Finding, expr *ast.Expression, scope map[string]bool, path *ast.Path, file string) []report.Finding {
+	ast.WalkExpression(expr, path, func(e *ast.Expression, path *ast.Path) bool {
+		switch {
+		case e.Kind == "field_access" && e.Object == nil:
+			if !scope[e.Field] {
+				findings = append(findings, report.NewError(
+					"RULE-11",
+					fmt.Sprintf("Identifier '%s' is not in scope", e.Field),
+					report.Location{File: file, Path: path.String()},
+				))
+			}
+		case e.Kind == "lambda" && e.Parameter != "":
+			lambdaScope := copyScope(scope)
+			lambdaScope[e.Parameter] = true
+			findings = walkForScopeViolations(findings, e.Body, lambdaScope, path.Field("body"), file)
+			return false
 		}
-		return findings
-	}
-
-	// Recurse into sub-expressions
-	findings = walkForScopeViolations(findings, expr.Object, scope, path+".object", file)
-	findings = walkForScopeViolations(findings, expr.Left, scope, path+".left", file)
-	findings = walkForScopeViolations(findings, expr.Right, scope, path+".right", file)
-	findings = walkForScopeViolations(findings, expr.Target, scope, path+".target", file)
-	findings = walkForScopeViolations(findings, expr.Operand, scope, path+".operand", file)
-	findings = walkForScopeViolations(findings, expr.Collection, scope, path+".collection", file)
-	findings = walkForScopeViolations(findings, expr.Lambda, scope, path+".lambda", file)
-	findings = walkForScopeViolations(findings, expr.Condition, scope, path+".condition", file)
-	findings = walkForScopeViolations(findings, expr.Body, scope, path+".body", file)
-	findings = walkForScopeViolations(findings, expr.Element, scope, path+".element", file)
-
-	for j := range expr.FuncArguments {
-		findings = walkForScopeViolations(findings, &expr.FuncArguments[j], scope,
-			fmt.Sprintf("%s.arguments[%d]", path, j), file)
-	}
-	for j := range expr.Elements {
-		findings = walkForScopeViolations(findings, &expr.Elements[j], scope,
-			fmt.Sprintf("%s.elements[%d]", path, j), file)
-	}
-
+		return true
+	})
 	return findings
 }
 
-// walkEnsuresForScopeViolations walks an ensures clause tree for scope violations.
+// walkEnsuresForScopeViolations walks an ensures clause tree for scope
+// violations. An iteration or let_binding adds its binding to the scope of
+// its body.
 func walkEnsuresForScopeViolations(findings []report.Finding, ec ast.EnsuresClause, scope map[string]bool, path string, file string) []report.Finding {
-	findings = walkForScopeViolations(findings, ec.Target, scope, path+".target", file)
-	findings = walkForScopeViolations(findings, ec.Condition, scope, path+".condition", file)
-	findings = walkForScopeViolations(findings, ec.Collection, scope, path+".collection", file)
-
-	// Walk value if present (it's a json.RawMessage that could contain an Expression)
-	if ec.Value != nil {
-		var valExpr ast.Expression
-		if err := json.Unmarshal(ec.Value, &valExpr); err == nil && valExpr.Kind != "" {
-			findings = walkForScopeViolations(findings, &valExpr, scope, path+".value", file)
-		}
-	}
-
-	// Walk fields map (entity_creation, trigger_emission)
-	for name, fieldExpr := range ec.Fields {
-		fe := fieldExpr
-		findings = walkForScopeViolations(findings, &fe, scope,
-			fmt.Sprintf("%s.fields.%s", path, name), file)
-	}
-
-	// Walk arguments map (trigger_emission)
-	for name, argExpr := range ec.Arguments {
-		ae := argExpr
-		findings = walkForScopeViolations(findings, &ae, scope,
-			fmt.Sprintf("%s.arguments.%s", path, name), file)
-	}
-
-	for j, then := range ec.Then {
-		findings = walkEnsuresForScopeViolations(findings, then, scope,
-			fmt.Sprintf("%s.then[%d]", path, j), file)
-	}
-	for j, el := range ec.Else {
-		findings = walkEnsuresForScopeViolations(findings, el, scope,
-			fmt.Sprintf("%s.else[%d]", path, j), file)
-	}
-
-	// iteration: add binding to scope for body
-	if ec.Kind == "iteration" && ec.Binding != "" {
-		iterScope := copyScope(scope)
-		iterScope[ec.Binding] = true
-		for j, body := range ec.Body {
-			findings = walkEnsuresForScopeViolations(findings, body, iterScope,
-				fmt.Sprintf("%s.body[%d]", path, j), file)
+	ast.WalkClauses(&ec, path, func(c *ast.EnsuresClause, path string) bool {
+		for _, e := range c.Expressions(path) {
+			findings = walkForScopeViolations(findings, e.Expr, scope, ast.NewPath(e.Path), file)
 		}
-	} else if ec.Kind == "let_binding" && ec.Binding != "" {
-		// let_binding in ensures: add binding to scope for body
-		// Walk the value with current scope
-		if ec.Value != nil {
-			var valExpr ast.Expression
-			if err := json.Unmarshal(ec.Value, &valExpr); err == nil && valExpr.Kind != "" {
-				// Already walked above, but let_binding value could be entity_creation
-				// which is handled differently. The unmarshal above covers expression values.
+		if (c.Kind == "iteration" || c.Kind == "let_binding") && c.Binding != "" {
+			bodyScope := copyScope(scope)
+			bodyScope[c.Binding] = true
+			for j := range c.Body {
+				findings = walkEnsuresForScopeViolations(findings, c.Body[j], bodyScope, ast.IndexPath(path, "body", j), file)
 			}
+			return false
 		}
-		letScope := copyScope(scope)
-		letScope[ec.Binding] = true
-		for j, body := range ec.Body {
-			findings = walkEnsuresForScopeViolations(findings, body, letScope,
-				fmt.Sprintf("%s.body[%d]", path, j), file)
-		}
-	} else {
-		for j, body := range ec.Body {
-			findings = walkEnsuresForScopeViolations(findings, body, scope,
-				fmt.Sprintf("%s.body[%d]", path, j), file)
-		}
-	}
-
+		return true
+	})
 	return findings
 }
 
@@ -433,25 +417,19 @@ func resolveExprType(expr *ast.Expression, fieldTypes map[string]*ast.FieldType,
 	case "literal":
 		return literalTypeToDescriptor(expr.Type)
 	case "field_access":
-		if expr.Object == nil {
-			// Root field access: look up in entity fields
-			if ft, ok := fieldTypes[expr.Field]; ok {
-				return fieldTypeToDescriptor(ft)
-			}
-			return ""
-		}
-		// Chained field access: cannot resolve without full type tracking
-		return ""
+		return fieldTypeToDescriptor(resolveFieldAccessType(expr, fieldTypes, st))
 	case "arithmetic":
-		// The result type of arithmetic is the common numeric/temporal type
+		// The result type of arithmetic is the common numeric/temporal type.
+		// An Integer operand is promoted when the other is Decimal.
 		leftType := resolveExprType(expr.Left, fieldTypes, st)
-		if leftType != "" {
-			return leftType
+		rightType := resolveExprType(expr.Right, fieldTypes, st)
+		if leftType == "" || leftType == "Integer" && rightType == "Decimal" {
+			return rightType
 		}
-		return resolveExprType(expr.Right, fieldTypes, st)
+		return leftType
 	case "function_call":
-		// Cannot determine return type of arbitrary functions
-		return ""
+		// Only registered functions have a known return type
+		return st.Functions.Lookup(expr.FuncName).returnType()
 	case "collection_op":
 		if expr.Operation == "count" {
 			return "Integer"
@@ -462,11 +440,281 @@ func resolveExprType(expr *ast.Expression, fieldTypes map[string]*ast.FieldType,
 	}
 }
 
+// resolveFieldAccessType returns the declared type of a field access chain,
+// or nil if any step cannot be resolved. Root names are looked up in
+// fieldTypes, except for config, whose members are config parameters. Each
+// further step looks the field up on the entity, variant, external entity or
+// value type the previous step refers to, following relationships; optional
+// types are looked through.
+func resolveFieldAccessType(expr *ast.Expression, fieldTypes map[string]*ast.FieldType, st *SymbolTable) *ast.FieldType {
+	if expr == nil || expr.Kind != "field_access" {
+		return nil
+	}
+	if expr.Object == nil {
+		return fieldTypes[expr.Field]
+	}
+	if expr.Object.Kind == "field_access" && expr.Object.Object == nil && expr.Object.Field == "config" {
+		if _, shadowed := fieldTypes["config"]; !shadowed {
+			if c := st.LookupConfig(expr.Field); c != nil {
+				ft := st.ResolveType(c.Type)
+				return &ft
+			}
+			return nil
+		}
+	}
+	objType := resolveFieldAccessType(expr.Object, fieldTypes, st)
+	for objType != nil && objType.Kind == "optional" {
+		objType = objType.Inner
+	}
+	if objType == nil || objType.Kind != "entity_ref" {
+		return nil
+	}
+	return memberType(st, objType.Entity, expr.Field)
+}
+
+// memberType returns the type of the named field, relationship or projection
+// of an entity-like declaration, or nil. A relationship has type entity_ref
+// for cardinality one and a set of entity_ref for many. Variants also expose
+// the members of their base entity.
+func memberType(st *SymbolTable, typeName, name string) *ast.FieldType {
+	fieldNamed := func(fields []ast.Field) *ast.FieldType {
+		for _, f := range st.ResolveFields(fields) {
+			if f.Name == name {
+				return &f.Type
+			}
+		}
+		return nil
+	}
+	if e := st.LookupEntity(typeName); e != nil {
+		if ft := fieldNamed(e.Fields); ft != nil {
+			return ft
+		}
+		for _, rel := range e.Relationships {
+			if rel.Name != name {
+				continue
+			}
+			ref := &ast.FieldType{Kind: "entity_ref", Entity: rel.TargetEntity}
+			if rel.Cardinality == "many" {
+				return &ast.FieldType{Kind: "set", Element: ref}
+			}
+			return ref
+		}
+		for _, p := range e.Projections {
+			if p.Name == name {
+				return projectionType(st, e, p)
+			}
+		}
+		return nil
+	}
+	if v := st.LookupVariant(typeName); v != nil {
+		if ft := fieldNamed(v.Fields); ft != nil {
+			return ft
+		}
+		if v.BaseEntity != typeName {
+			return memberType(st, v.BaseEntity, name)
+		}
+		return nil
+	}
+	if ee := st.LookupExternalEntity(typeName); ee != nil {
+		return fieldNamed(ee.Fields)
+	}
+	if vt := st.LookupValueType(typeName); vt != nil {
+		return fieldNamed(vt.Fields)
+	}
+	return nil
+}
+
+// projectionType returns the type of a projection of entity e: a set of the
+// target of the relationship it filters or, with a mapping, of the mapped
+// field of that target. It is nil when the source is not a relationship of e
+// or the mapped field cannot be resolved.
+func projectionType(st *SymbolTable, e *ast.Entity, p ast.Projection) *ast.FieldType {
+	for _, rel := range e.Relationships {
+		if rel.Name != p.Source {
+			continue
+		}
+		if p.Mapping == "" {
+			return &ast.FieldType{Kind: "set", Element: &ast.FieldType{Kind: "entity_ref", Entity: rel.TargetEntity}}
+		}
+		if ft := memberType(st, rel.TargetEntity, p.Mapping); ft != nil {
+			return &ast.FieldType{Kind: "set", Element: ft}
+		}
+	}
+	return nil
+}
+
+// inferExprType returns the type of a let binding's expression, or nil if it
+// cannot be inferred. Field accesses take their declared type, join lookups
+// refer to the entity they look up, comparisons and other predicates are
+// Boolean, and literals, arithmetic, registered function calls and counts
+// take the primitive type resolveExprType reports for them. A where filter
+// of a collection has the collection's type, and first and last its
+// element's.
+func inferExprType(expr *ast.Expression, fieldTypes map[string]*ast.FieldType, st *SymbolTable) *ast.FieldType {
+	if expr == nil {
+		return nil
+	}
+	switch expr.Kind {
+	case "field_access":
+		return resolveFieldAccessType(expr, fieldTypes, st)
+	case "join_lookup":
+		if expr.Entity == "" {
+			return nil
+		}
+		return &ast.FieldType{Kind: "entity_ref", Entity: expr.Entity}
+	case "comparison", "boolean_logic", "not", "exists", "membership":
+		return &ast.FieldType{Kind: "primitive", Value: "Boolean"}
+	case "collection_op":
+		ct := inferExprType(expr.Collection, fieldTypes, st)
+		if ct == nil || ct.Kind != "set" && ct.Kind != "list" {
+			break
+		}
+		switch expr.Operation {
+		case "where":
+			return ct
+		case "first", "last":
+			return ct.Element
+		case "any", "all":
+			return &ast.FieldType{Kind: "primitive", Value: "Boolean"}
+		}
+	}
+	switch t := resolveExprType(expr, fieldTypes, st); t {
+	case "String", "Integer", "Decimal", "Boolean", "Timestamp", "Duration":
+		return &ast.FieldType{Kind: "primitive", Value: t}
+	}
+	return nil
+}
+
+// resolveNullableType returns the type of expr as inferExprType does, wrapped
+// in an optional type when the value may be null although its last step is
+// not declared optional: a field access chain that reads through an optional
+// value, such as user.manager.email with manager optional.
+func resolveNullableType(expr *ast.Expression, fieldTypes map[string]*ast.FieldType, st *SymbolTable) *ast.FieldType {
+	ft := inferExprType(expr, fieldTypes, st)
+	if ft == nil || ft.Kind == "optional" || !readsThroughOptional(expr, fieldTypes, st) {
+		return ft
+	}
+	return &ast.FieldType{Kind: "optional", Inner: ft}
+}
+
+// readsThroughOptional reports whether a field access chain reads a member
+// of a value declared optional at any of its steps.
+func readsThroughOptional(expr *ast.Expression, fieldTypes map[string]*ast.FieldType, st *SymbolTable) bool {
+	for e := expr; e != nil && e.Kind == "field_access" && e.Object != nil; e = e.Object {
+		if ft := resolveFieldAccessType(e.Object, fieldTypes, st); ft != nil && ft.Kind == "optional" {
+			return true
+		}
+	}
+	return false
+}
+
+// ruleFieldTypes builds the type environment for a rule's expressions: the
+// fields of its trigger entity, given bindings, the trigger binding, the for
+// clause binding and let bindings whose type can be inferred. Bindings are
+// typed in order, so a let binding may use the ones before it.
+func ruleFieldTypes(rule ast.Rule, spec *ast.Spec, st *SymbolTable) map[string]*ast.FieldType {
+	fieldTypes := make(map[string]*ast.FieldType)
+	if rule.Trigger.Entity != "" {
+		if ent := st.LookupEntity(rule.Trigger.Entity); ent != nil {
+			fieldTypes = buildFieldTypeMap(st.ResolveFields(ent.Fields))
+		}
+	}
+	for _, g := range spec.Given {
+		ft := st.ResolveType(g.Type)
+		fieldTypes[g.Name] = &ft
+	}
+	if rule.Trigger.Binding != "" && rule.Trigger.Entity != "" {
+		fieldTypes[rule.Trigger.Binding] = &ast.FieldType{Kind: "entity_ref", Entity: rule.Trigger.Entity}
+	}
+	if fc := rule.ForClause; fc != nil && fc.Binding != "" {
+		if ct := resolveFieldAccessType(fc.Collection, fieldTypes, st); ct != nil && (ct.Kind == "set" || ct.Kind == "list") {
+			fieldTypes[fc.Binding] = ct.Element
+		}
+	}
+	for _, lb := range rule.LetBindings {
+		if ft := inferExprType(lb.Expression, fieldTypes, st); ft != nil {
+			fieldTypes[lb.Name] = ft
+		}
+	}
+	return fieldTypes
+}
+
+// derivedFieldTypes builds the type environment for a derived value of the
+// named entity or value type: given bindings and the owner's fields, and an
+// entity's relationships and projections, through which expressions reach
+// the fields of other entities. The derived value's parameters declare no
+// type, so hide any member of the same name.
+func derivedFieldTypes(owner string, dv ast.DerivedValue, spec *ast.Spec, st *SymbolTable) map[string]*ast.FieldType {
+	fieldTypes := make(map[string]*ast.FieldType)
+	for _, g := range spec.Given {
+		ft := st.ResolveType(g.Type)
+		fieldTypes[g.Name] = &ft
+	}
+	if e := st.LookupEntity(owner); e != nil {
+		maps.Copy(fieldTypes, buildFieldTypeMap(st.ResolveFields(e.Fields)))
+		for _, rel := range e.Relationships {
+			if _, ok := fieldTypes[rel.Name]; !ok {
+				fieldTypes[rel.Name] = memberType(st, owner, rel.Name)
+			}
+		}
+		for _, p := range e.Projections {
+			if _, ok := fieldTypes[p.Name]; !ok {
+				if ft := projectionType(st, e, p); ft != nil {
+					fieldTypes[p.Name] = ft
+				}
+			}
+		}
+	} else if vt := st.LookupValueType(owner); vt != nil {
+		maps.Copy(fieldTypes, buildFieldTypeMap(st.ResolveFields(vt.Fields)))
+	}
+	for _, p := range dv.Parameters {
+		delete(fieldTypes, p)
+	}
+	return fieldTypes
+}
+
+// surfaceFieldTypes builds the type environment for a surface's expressions:
+// given bindings, the facing binding, typed as the entity identifying the
+// actor it names (or as the named entity itself), the context binding and
+// let bindings whose type can be inferred.
+func surfaceFieldTypes(s ast.Surface, spec *ast.Spec, st *SymbolTable) map[string]*ast.FieldType {
+	fieldTypes := make(map[string]*ast.FieldType)
+	for _, g := range spec.Given {
+		ft := st.ResolveType(g.Type)
+		fieldTypes[g.Name] = &ft
+	}
+	if s.Facing.Binding != "" && s.Facing.Type != "" {
+		entity := s.Facing.Type
+		if a := st.LookupActor(entity); a != nil {
+			entity = a.IdentifiedBy.Entity
+		}
+		fieldTypes[s.Facing.Binding] = &ast.FieldType{Kind: "entity_ref", Entity: entity}
+	}
+	if c := s.Context; c != nil && c.Binding != "" && c.Type != "" {
+		fieldTypes[c.Binding] = &ast.FieldType{Kind: "entity_ref", Entity: c.Type}
+	}
+	for _, lb := range s.LetBindings {
+		if ft := inferExprType(lb.Expression, fieldTypes, st); ft != nil {
+			fieldTypes[lb.Name] = ft
+		}
+	}
+	return fieldTypes
+}
+
+// SurfaceExpressionType returns the type of an expression of surface s, such
+// as an exposed item, in the environment of the surface's bindings, or nil
+// if it cannot be inferred.
+func SurfaceExpressionType(s ast.Surface, spec *ast.Spec, st *SymbolTable, expr *ast.Expression) *ast.FieldType {
+	return inferExprType(expr, surfaceFieldTypes(s, spec, st), st)
+}
+
 // literalTypeToDescriptor maps literal type strings to canonical type descriptors.
 func literalTypeToDescriptor(litType string) string {
 	switch litType {
 	case "integer":
 		return "Integer"
+	case "decimal":
+		return "Decimal"
 	case "string":
 		return "String"
 	case "boolean":
@@ -508,7 +756,7 @@ func fieldTypeToDescriptor(ft *ast.FieldType) string {
 
 // isNumericType returns true for types that can participate in arithmetic.
 func isNumericType(t string) bool {
-	return t == "Integer"
+	return t == "Integer" || t == "Decimal"
 }
 
 // isTemporalType returns true for Timestamp or Duration.
@@ -533,13 +781,14 @@ func isComparable(left, right string) bool {
 	if isTemporalType(left) && isTemporalType(right) {
 		return true
 	}
-	return false
+	// Integers are compared with Decimals by value
+	return isNumericType(left) && isNumericType(right)
 }
 
 // isValidArithmetic checks if an arithmetic expression has valid operand types.
 // Returns (valid, leftType, rightType).
 func isValidArithmetic(op string, leftType, rightType string) bool {
-	// Integer arithmetic
+	// Numeric arithmetic, with Integer promoted to Decimal when mixed
 	if isNumericType(leftType) && isNumericType(rightType) {
 		return true
 	}
@@ -562,46 +811,148 @@ func isValidArithmetic(op string, leftType, rightType string) bool {
 // checkTypeMismatches validates type compatibility in comparisons and arithmetic.
 func checkTypeMismatches(findings []report.Finding, spec *ast.Spec, st *SymbolTable) []report.Finding {
 	for i, entity := range spec.Entities {
-		fieldTypes := buildFieldTypeMap(entity.Fields)
 		for j, dv := range entity.DerivedValues {
-			findings = walkForTypeMismatches(findings, dv.Expression, fieldTypes, st,
-				fmt.Sprintf("$.entities[%d].derived_values[%d].expression", i, j), spec.File)
+			findings = walkForTypeMismatches(findings, dv.Expression, derivedFieldTypes(entity.Name, dv, spec, st), st,
+				ast.NewPath(fmt.Sprintf("$.entities[%d].derived_values[%d].expression", i, j)), spec.File)
+		}
+		// A projection's condition reads the members of its relationship's
+		// target.
+		for j, p := range entity.Projections {
+			if ft := projectionType(st, &entity, ast.Projection{Source: p.Source}); ft != nil {
+				findings = walkForTypeMismatches(findings, p.Condition, derivedFieldTypes(ft.Element.Entity, ast.DerivedValue{}, spec, st), st,
+					ast.NewPath(fmt.Sprintf("$.entities[%d].projections[%d].condition", i, j)), spec.File)
+			}
+		}
+	}
+	for i, vt := range spec.ValueTypes {
+		for j, dv := range vt.DerivedValues {
+			findings = walkForTypeMismatches(findings, dv.Expression, derivedFieldTypes(vt.Name, dv, spec, st), st,
+				ast.NewPath(fmt.Sprintf("$.value_types[%d].derived_values[%d].expression", i, j)), spec.File)
 		}
 	}
 
 	for i, rule := range spec.Rules {
 		basePath := fmt.Sprintf("$.rules[%d]", i)
-		fieldTypes := make(map[string]*ast.FieldType)
-		if rule.Trigger.Entity != "" {
-			if ent := st.LookupEntity(rule.Trigger.Entity); ent != nil {
-				fieldTypes = buildFieldTypeMap(ent.Fields)
-			}
-		}
+		fieldTypes := ruleFieldTypes(rule, spec, st)
 
 		for j, req := range rule.Requires {
 			findings = walkForTypeMismatches(findings, &req, fieldTypes, st,
-				fmt.Sprintf("%s.requires[%d]", basePath, j), spec.File)
+				ast.NewPath(ast.IndexPath(basePath, "requires", j)), spec.File)
 		}
 
 		for j, lb := range rule.LetBindings {
 			findings = walkForTypeMismatches(findings, lb.Expression, fieldTypes, st,
-				fmt.Sprintf("%s.let_bindings[%d].expression", basePath, j), spec.File)
+				ast.NewPath(ast.IndexPath(basePath, "let_bindings", j)+".expression"), spec.File)
 		}
 
 		for j, ec := range rule.Ensures {
 			findings = walkEnsuresForTypeMismatches(findings, ec, fieldTypes, st,
-				fmt.Sprintf("%s.ensures[%d]", basePath, j), spec.File)
+				ast.IndexPath(basePath, "ensures", j), spec.File)
 		}
 	}
 
+	for i, surface := range spec.Surfaces {
+		findings = checkSurfaceTypeMismatches(findings, surface, spec, st,
+			fmt.Sprintf("$.surfaces[%d]", i))
+	}
+
 	return findings
 }
 
-func walkForTypeMismatches(findings []report.Finding, expr *ast.Expression, fieldTypes map[string]*ast.FieldType, st *SymbolTable, path string, file string) []report.Finding {
-	if expr == nil {
+// checkSurfaceTypeMismatches runs the RULE-12 and RULE-40 checks over the
+// expressions of a surface: its context condition, let bindings, exposes,
+// provides, related surfaces, timeouts and guarantees.
+func checkSurfaceTypeMismatches(findings []report.Finding, s ast.Surface, spec *ast.Spec, st *SymbolTable, path string) []report.Finding {
+	fieldTypes := surfaceFieldTypes(s, spec, st)
+	walk := func(expr *ast.Expression, exprPath string) {
+		findings = walkForTypeMismatches(findings, expr, fieldTypes, st, ast.NewPath(exprPath), spec.File)
+	}
+
+	if s.Context != nil {
+		walk(s.Context.Condition, path+".context.condition")
+	}
+	for j, lb := range s.LetBindings {
+		walk(lb.Expression, ast.IndexPath(path, "let_bindings", j)+".expression")
+	}
+	for j, ex := range s.Exposes {
+		walk(ex.Expression, ast.IndexPath(path, "exposes", j)+".expression")
+		walk(ex.When, ast.IndexPath(path, "exposes", j)+".when")
+	}
+	for j, p := range s.Provides {
+		findings = walkProvidesForTypeMismatches(findings, p, fieldTypes, st, ast.IndexPath(path, "provides", j), spec.File)
+	}
+	for j, rel := range s.Related {
+		walk(rel.ContextExpression, ast.IndexPath(path, "related", j)+".context_expression")
+		walk(rel.When, ast.IndexPath(path, "related", j)+".when")
+	}
+	for j, to := range s.Timeout {
+		walk(to.When, ast.IndexPath(path, "timeout", j)+".when")
+	}
+	for j, g := range s.Guarantees {
+		walk(g.Expression, ast.IndexPath(path, "guarantees", j)+".expression")
+	}
+	return findings
+}
+
+// walkProvidesForTypeMismatches checks a provides item and, for a for_each
+// item, its nested items with the iteration binding typed as an element of
+// the collection.
+func walkProvidesForTypeMismatches(findings []report.Finding, p ast.ProvidesItem, fieldTypes map[string]*ast.FieldType, st *SymbolTable, path string, file string) []report.Finding {
+	findings = walkForTypeMismatches(findings, p.When, fieldTypes, st, ast.NewPath(path+".when"), file)
+	for k, arg := range p.Arguments {
+		findings = walkForTypeMismatches(findings, arg.Expression, fieldTypes, st,
+			ast.NewPath(ast.IndexPath(path, "arguments", k)+".expression"), file)
+	}
+	findings = walkForTypeMismatches(findings, p.Collection, fieldTypes, st, ast.NewPath(path+".collection"), file)
+
+	if len(p.Items) == 0 {
 		return findings
 	}
+	inner := fieldTypes
+	if p.Binding != "" {
+		inner = maps.Clone(fieldTypes)
+		delete(inner, p.Binding)
+		if ct := resolveFieldAccessType(p.Collection, fieldTypes, st); ct != nil && (ct.Kind == "set" || ct.Kind == "list") {
+			inner[p.Binding] = ct.Element
+		}
+	}
+	for k, item := range p.Items {
+		findings = walkProvidesForTypeMismatches(findings, item, inner, st, ast.IndexPath(path, "items", k), file)
+	}
+	return findings
+}
 
+// walkForTypeMismatches checks RULE-12, RULE-40, RULE-49 and RULE-54 for
+// expr and every expression below it.
+func walkForTypeMismatches(findings []report.Finding, expr *ast.Expression, fieldTypes map[string]*ast.FieldType, st *SymbolTable, path *ast.Path, file string) []report.Finding {
+	ast.WalkExpression(expr, path, func(e *ast.Expression, path *ast.Path) bool {
+		findings = checkTypeMismatch(findings, e, fieldTypes, st, path, file)
+		if e.Kind == "collection_op" && e.Lambda != nil && e.Lambda.Parameter != "" {
+			findings = walkForTypeMismatches(findings, e.Collection, fieldTypes, st, path.Field("collection"), file)
+			findings = walkForTypeMismatches(findings, e.Lambda.Body, lambdaFieldTypes(e, fieldTypes, st), st, path.Field("lambda").Field("body"), file)
+			findings = walkForTypeMismatches(findings, e.Condition, fieldTypes, st, path.Field("condition"), file)
+			return false
+		}
+		return true
+	})
+	return findings
+}
+
+// lambdaFieldTypes returns fieldTypes with the lambda parameter of a
+// collection operation typed as an element of its collection, such as an
+// Order for orders.any(o => o.total > 100), or hiding any outer binding of
+// the same name when the collection's type is unknown.
+func lambdaFieldTypes(op *ast.Expression, fieldTypes map[string]*ast.FieldType, st *SymbolTable) map[string]*ast.FieldType {
+	var element *ast.FieldType
+	if ct, _ := resolveCollectionType(op.Collection, fieldTypes, st); ct != nil {
+		element = ct.Element
+	}
+	return withBinding(fieldTypes, op.Lambda.Parameter, element)
+}
+
+// checkTypeMismatch checks the operands of a single comparison, arithmetic
+// expression, function call, collection operation or membership test.
+func checkTypeMismatch(findings []report.Finding, expr *ast.Expression, fieldTypes map[string]*ast.FieldType, st *SymbolTable, path *ast.Path, file string) []report.Finding {
 	if expr.Kind == "comparison" {
 		leftType := resolveExprType(expr.Left, fieldTypes, st)
 		rightType := resolveExprType(expr.Right, fieldTypes, st)
@@ -610,7 +961,7 @@ func walkForTypeMismatches(findings []report.Finding, expr *ast.Expression, fiel
 			findings = append(findings, report.NewError(
 				"RULE-12",
 				fmt.Sprintf("Type mismatch in comparison: %s vs %s", leftType, rightType),
-				report.Location{File: file, Path: path},
+				report.Location{File: file, Path: path.String()},
 			))
 		}
 	}
@@ -621,84 +972,249 @@ func walkForTypeMismatches(findings []report.Finding, expr *ast.Expression, fiel
 
 		if leftType != "" && rightType != "" && !isValidArithmetic(expr.Operator, leftType, rightType) {
 			// Determine which side is the non-numeric/non-temporal one
-			if !isNumericType(leftType) && !isTemporalType(leftType) {
+			if leftType == "String" && rightType == "String" && expr.Operator == "+" {
+				findings = append(findings, concatFinding(expr, path, file))
+			} else if !isNumericType(leftType) && !isTemporalType(leftType) {
 				findings = append(findings, report.NewError(
 					"RULE-12",
 					fmt.Sprintf("Non-numeric type %s in arithmetic", leftType),
-					report.Location{File: file, Path: path},
+					report.Location{File: file, Path: path.String()},
 				))
 			} else if !isNumericType(rightType) && !isTemporalType(rightType) {
 				findings = append(findings, report.NewError(
 					"RULE-12",
 					fmt.Sprintf("Non-numeric type %s in arithmetic", rightType),
-					report.Location{File: file, Path: path},
+					report.Location{File: file, Path: path.String()},
 				))
 			} else {
 				// Both are numeric/temporal but the combination is invalid
 				findings = append(findings, report.NewError(
 					"RULE-12",
 					fmt.Sprintf("Type mismatch in arithmetic: %s %s %s", leftType, expr.Operator, rightType),
-					report.Location{File: file, Path: path},
+					report.Location{File: file, Path: path.String()},
 				))
 			}
 		}
 	}
 
-	// Recurse
-	findings = walkForTypeMismatches(findings, expr.Object, fieldTypes, st, path+".object", file)
-	findings = walkForTypeMismatches(findings, expr.Left, fieldTypes, st, path+".left", file)
-	findings = walkForTypeMismatches(findings, expr.Right, fieldTypes, st, path+".right", file)
-	findings = walkForTypeMismatches(findings, expr.Target, fieldTypes, st, path+".target", file)
-	findings = walkForTypeMismatches(findings, expr.Operand, fieldTypes, st, path+".operand", file)
-	findings = walkForTypeMismatches(findings, expr.Collection, fieldTypes, st, path+".collection", file)
-	findings = walkForTypeMismatches(findings, expr.Lambda, fieldTypes, st, path+".lambda", file)
-	findings = walkForTypeMismatches(findings, expr.Condition, fieldTypes, st, path+".condition", file)
-	findings = walkForTypeMismatches(findings, expr.Body, fieldTypes, st, path+".body", file)
-	findings = walkForTypeMismatches(findings, expr.Element, fieldTypes, st, path+".element", file)
+	if expr.Kind == "function_call" {
+		findings = checkFunctionCall(findings, expr, fieldTypes, st, path, file)
+	}
+
+	findings = checkEnumLiterals(findings, expr, fieldTypes, st, path, file)
+
+	return checkCollectionOperands(findings, expr, fieldTypes, st, path, file)
+}
+
+// concatFinding reports String + String. The language has no concatenation
+// operator, so strings are joined with the built-in concat function, which
+// the finding offers to call in place of the arithmetic.
+func concatFinding(expr *ast.Expression, path *ast.Path, file string) report.Finding {
+	at := path.String()
+	call := ast.Expression{Kind: "function_call", FuncName: "concat", FuncArguments: []ast.Expression{*expr.Left, *expr.Right}}
+	return report.NewError(
+		"RULE-12",
+		"Strings cannot be joined with '+'; use concat(a, b)",
+		report.Location{File: file, Path: at},
+	).WithSuggestion("Replace '+' with a call to concat", report.ReplaceEdit(at, call))
+}
 
-	for j := range expr.FuncArguments {
-		findings = walkForTypeMismatches(findings, &expr.FuncArguments[j], fieldTypes, st,
-			fmt.Sprintf("%s.arguments[%d]", path, j), file)
+// checkFunctionCall checks RULE-40: a call to a registered function must pass
+// as many arguments as it declares parameters, and each argument of known type
+// must be accepted by its parameter.
+func checkFunctionCall(findings []report.Finding, expr *ast.Expression, fieldTypes map[string]*ast.FieldType, st *SymbolTable, path *ast.Path, file string) []report.Finding {
+	sig := st.Functions.Lookup(expr.FuncName)
+	if sig == nil {
+		return findings
 	}
-	for j := range expr.Elements {
-		findings = walkForTypeMismatches(findings, &expr.Elements[j], fieldTypes, st,
-			fmt.Sprintf("%s.elements[%d]", path, j), file)
+	if len(expr.FuncArguments) != len(sig.Parameters) {
+		plural := "s"
+		if len(sig.Parameters) == 1 {
+			plural = ""
+		}
+		return append(findings, report.NewError(
+			"RULE-40",
+			fmt.Sprintf("Function '%s' expects %d argument%s, got %d", expr.FuncName, len(sig.Parameters), plural, len(expr.FuncArguments)),
+			report.Location{File: file, Path: path.String()},
+		))
+	}
+	for j, param := range sig.Parameters {
+		actual := resolveExprType(&expr.FuncArguments[j], fieldTypes, st)
+		if actual != "" && !acceptsArgument(param, actual) {
+			findings = append(findings, report.NewError(
+				"RULE-40",
+				fmt.Sprintf("Argument %d of '%s' has type %s, expected %s", j+1, expr.FuncName, actual, param),
+				report.Location{File: file, Path: path.Index("arguments", j).String()},
+			))
+		}
 	}
-
 	return findings
 }
 
-func walkEnsuresForTypeMismatches(findings []report.Finding, ec ast.EnsuresClause, fieldTypes map[string]*ast.FieldType, st *SymbolTable, path string, file string) []report.Finding {
-	findings = walkForTypeMismatches(findings, ec.Target, fieldTypes, st, path+".target", file)
-	findings = walkForTypeMismatches(findings, ec.Condition, fieldTypes, st, path+".condition", file)
-	findings = walkForTypeMismatches(findings, ec.Collection, fieldTypes, st, path+".collection", file)
+// --- RULE-49: Collections and scalars ---
+
+// checkCollectionOperands checks RULE-49: a collection operation must be
+// applied to a collection, and comparisons and arithmetic must not be given
+// one. Two collections may be compared with = or !=.
+func checkCollectionOperands(findings []report.Finding, expr *ast.Expression, fieldTypes map[string]*ast.FieldType, st *SymbolTable, path *ast.Path, file string) []report.Finding {
+	switch expr.Kind {
+	case "collection_op":
+		if ct, known := resolveCollectionType(expr.Collection, fieldTypes, st); known && ct == nil {
+			findings = append(findings, report.NewError(
+				"RULE-49",
+				fmt.Sprintf("Collection operation '%s' is applied to %s, which is not a collection", expr.Operation, operandName(expr.Collection)),
+				report.Location{File: file, Path: path.Field("collection").String()},
+			))
+		}
+	case "comparison", "arithmetic":
+		left, leftKnown := resolveCollectionType(expr.Left, fieldTypes, st)
+		right, rightKnown := resolveCollectionType(expr.Right, fieldTypes, st)
+		if expr.Kind == "comparison" && (expr.Operator == "=" || expr.Operator == "!=") {
+			// Either side may be a collection if the other could be one too.
+			if left != nil && (!rightKnown || right != nil) || right != nil && !leftKnown {
+				return findings
+			}
+		}
+		context := "Comparison with"
+		if expr.Kind == "arithmetic" {
+			context = "Arithmetic on"
+		}
+		for _, side := range []struct {
+			key  string
+			expr *ast.Expression
+			ct   *ast.FieldType
+		}{{"left", expr.Left, left}, {"right", expr.Right, right}} {
+			if side.ct != nil {
+				findings = append(findings, report.NewError(
+					"RULE-49",
+					fmt.Sprintf("%s %s, which is a collection (%s) rather than a single value", context, operandName(side.expr), collectionTypeName(side.ct)),
+					report.Location{File: file, Path: path.Field(side.key).String()},
+				))
+			}
+		}
+	}
+	return findings
+}
 
-	if ec.Value != nil {
-		var valExpr ast.Expression
-		if err := json.Unmarshal(ec.Value, &valExpr); err == nil && valExpr.Kind != "" {
-			findings = walkForTypeMismatches(findings, &valExpr, fieldTypes, st, path+".value", file)
+// resolveCollectionType returns the set or list type of expr if it is a
+// collection, with known true. For an expression known to be a single
+// value it returns nil and true, and for one of unknown type nil and false.
+// Set and list fields, relationships of cardinality many, set literals and
+// where filters of a collection are collections; count, any and all reduce
+// one to a single value, and first and last take one of its elements.
+func resolveCollectionType(expr *ast.Expression, fieldTypes map[string]*ast.FieldType, st *SymbolTable) (*ast.FieldType, bool) {
+	if expr == nil {
+		return nil, false
+	}
+	var ft *ast.FieldType
+	switch expr.Kind {
+	case "collection_op":
+		if expr.Operation == "count" || expr.Operation == "any" || expr.Operation == "all" {
+			return nil, true
 		}
+		ft = inferExprType(expr, fieldTypes, st)
+	case "field_access":
+		ft = inferExprType(expr, fieldTypes, st)
+	case "set_literal":
+		return &ast.FieldType{Kind: "set"}, true
+	case "literal", "arithmetic", "comparison", "boolean_logic", "not", "exists", "membership", "join_lookup":
+		return nil, true
+	case "function_call":
+		return nil, st.Functions.Lookup(expr.FuncName).returnType() != ""
+	}
+	for ft != nil && ft.Kind == "optional" {
+		ft = ft.Inner
+	}
+	if ft == nil {
+		return nil, false
 	}
+	if ft.Kind == "set" || ft.Kind == "list" {
+		return ft, true
+	}
+	return nil, true
+}
 
-	for name, fieldExpr := range ec.Fields {
-		fe := fieldExpr
-		findings = walkForTypeMismatches(findings, &fe, fieldTypes, st,
-			fmt.Sprintf("%s.fields.%s", path, name), file)
+// collectionTypeName renders a set or list type as "Set<Session>", or as
+// "Set" when its element type is not a named type.
+func collectionTypeName(ft *ast.FieldType) string {
+	name := "Set"
+	if ft.Kind == "list" {
+		name = "List"
+	}
+	if el := ft.Element; el != nil {
+		switch el.Kind {
+		case "entity_ref":
+			return name + "<" + el.Entity + ">"
+		case "primitive":
+			return name + "<" + el.Value + ">"
+		case "named_enum":
+			return name + "<" + el.Name + ">"
+		}
 	}
+	return name
+}
 
-	for j, then := range ec.Then {
-		findings = walkEnsuresForTypeMismatches(findings, then, fieldTypes, st,
-			fmt.Sprintf("%s.then[%d]", path, j), file)
+// operandName names an operand in messages: its path, quoted, for a field
+// access, or the kind of expression it is.
+func operandName(expr *ast.Expression) string {
+	if p := exprPath(expr); p != "" {
+		return "'" + p + "'"
 	}
-	for j, el := range ec.Else {
-		findings = walkEnsuresForTypeMismatches(findings, el, fieldTypes, st,
-			fmt.Sprintf("%s.else[%d]", path, j), file)
+	if expr.Kind == "collection_op" {
+		return "a '" + expr.Operation + "' result"
 	}
-	for j, body := range ec.Body {
-		findings = walkEnsuresForTypeMismatches(findings, body, fieldTypes, st,
-			fmt.Sprintf("%s.body[%d]", path, j), file)
+	return "a " + strings.ReplaceAll(expr.Kind, "_", " ")
+}
+
+// walkEnsuresForTypeMismatches checks every expression in an ensures clause
+// tree as walkForTypeMismatches does.
+func walkEnsuresForTypeMismatches(findings []report.Finding, ec ast.EnsuresClause, fieldTypes map[string]*ast.FieldType, st *SymbolTable, path string, file string) []report.Finding {
+	ast.WalkEnsures(&ec, path, func(e *ast.Expression, path *ast.Path) bool {
+		findings = checkTypeMismatch(findings, e, fieldTypes, st, path, file)
+		return true
+	})
+	return findings
+}
+
+// --- RULE-54: Enum value literals outside their field's enum ---
+
+// checkEnumLiterals checks that the enum value literals of a comparison or
+// membership test are values of the enum field they are tested against:
+// `order.status = "shiped"`, `order.status in {"shipped", "lost"}` or
+// `"admin" in user.roles`, where roles is a set of an enum. Fields whose type
+// cannot be resolved are not checked.
+func checkEnumLiterals(findings []report.Finding, expr *ast.Expression, fieldTypes map[string]*ast.FieldType, st *SymbolTable, path *ast.Path, file string) []report.Finding {
+	check := func(field, lit *ast.Expression, ft *ast.FieldType, litPath *ast.Path) {
+		if lit == nil || lit.Kind != "literal" || lit.Type != "enum_value" || ft == nil {
+			return
+		}
+		values, ok := enumValuesOf(st, *ft)
+		if !ok {
+			return
+		}
+		if v := extractLiteralValue(lit); !slices.Contains(values, v) {
+			findings = append(findings, report.NewError(
+				"RULE-54",
+				fmt.Sprintf("Enum value '%s' is not a value of %s (%s)%s", v, operandName(field), strings.Join(values, " | "), didYouMean(v, values)),
+				report.Location{File: file, Path: litPath.String()},
+			))
+		}
 	}
 
+	switch expr.Kind {
+	case "comparison":
+		check(expr.Left, expr.Right, resolveFieldAccessType(expr.Left, fieldTypes, st), path.Field("right"))
+		check(expr.Right, expr.Left, resolveFieldAccessType(expr.Right, fieldTypes, st), path.Field("left"))
+	case "membership":
+		if expr.Collection != nil && expr.Collection.Kind == "set_literal" {
+			ft := resolveFieldAccessType(expr.Element, fieldTypes, st)
+			for k := range expr.Collection.Elements {
+				check(expr.Element, &expr.Collection.Elements[k], ft, path.Field("collection").Index("elements", k))
+			}
+		} else if ct := resolveFieldAccessType(expr.Collection, fieldTypes, st); ct != nil && (ct.Kind == "set" || ct.Kind == "list") {
+			check(expr.Collection, expr.Element, ct.Element, path.Field("element"))
+		}
+	}
 	return findings
 }
 
@@ -708,82 +1224,52 @@ func checkCollectionOps(findings []report.Finding, spec *ast.Spec) []report.Find
 	for i, entity := range spec.Entities {
 		for j, dv := range entity.DerivedValues {
 			findings = walkForCollectionOps(findings, dv.Expression,
-				fmt.Sprintf("$.entities[%d].derived_values[%d].expression", i, j), spec.File)
+				ast.NewPath(fmt.Sprintf("$.entities[%d].derived_values[%d].expression", i, j)), spec.File)
 		}
 	}
 	for i, rule := range spec.Rules {
 		basePath := fmt.Sprintf("$.rules[%d]", i)
 		for j, req := range rule.Requires {
 			findings = walkForCollectionOps(findings, &req,
-				fmt.Sprintf("%s.requires[%d]", basePath, j), spec.File)
+				ast.NewPath(ast.IndexPath(basePath, "requires", j)), spec.File)
 		}
 		for j, ec := range rule.Ensures {
 			findings = walkEnsuresForCollectionOps(findings, ec,
-				fmt.Sprintf("%s.ensures[%d]", basePath, j), spec.File)
+				ast.IndexPath(basePath, "ensures", j), spec.File)
 		}
 	}
 	return findings
 }
 
-func walkForCollectionOps(findings []report.Finding, expr *ast.Expression, path string, file string) []report.Finding {
-	if expr == nil {
-		return findings
-	}
-
-	if expr.Kind == "collection_op" {
-		op := expr.Operation
-		if op == "any" || op == "all" {
-			if expr.Lambda == nil || expr.Lambda.Kind != "lambda" || expr.Lambda.Parameter == "" {
-				findings = append(findings, report.NewError(
-					"RULE-13",
-					fmt.Sprintf("Collection operation '%s' requires explicit lambda parameter", op),
-					report.Location{File: file, Path: path},
-				))
-			}
-		}
-	}
-
-	// Recurse
-	findings = walkForCollectionOps(findings, expr.Object, path+".object", file)
-	findings = walkForCollectionOps(findings, expr.Left, path+".left", file)
-	findings = walkForCollectionOps(findings, expr.Right, path+".right", file)
-	findings = walkForCollectionOps(findings, expr.Target, path+".target", file)
-	findings = walkForCollectionOps(findings, expr.Operand, path+".operand", file)
-	findings = walkForCollectionOps(findings, expr.Collection, path+".collection", file)
-	findings = walkForCollectionOps(findings, expr.Lambda, path+".lambda", file)
-	findings = walkForCollectionOps(findings, expr.Condition, path+".condition", file)
-	findings = walkForCollectionOps(findings, expr.Body, path+".body", file)
-	findings = walkForCollectionOps(findings, expr.Element, path+".element", file)
-	for j := range expr.FuncArguments {
-		findings = walkForCollectionOps(findings, &expr.FuncArguments[j],
-			fmt.Sprintf("%s.arguments[%d]", path, j), file)
-	}
-	for j := range expr.Elements {
-		findings = walkForCollectionOps(findings, &expr.Elements[j],
-			fmt.Sprintf("%s.elements[%d]", path, j), file)
-	}
-
+func walkForCollectionOps(findings []report.Finding, expr *ast.Expression, path *ast.Path, file string) []report.Finding {
+	ast.WalkExpression(expr, path, func(e *ast.Expression, path *ast.Path) bool {
+		findings = checkCollectionOpLambda(findings, e, path, file)
+		return true
+	})
 	return findings
 }
 
 func walkEnsuresForCollectionOps(findings []report.Finding, ec ast.EnsuresClause, path string, file string) []report.Finding {
-	findings = walkForCollectionOps(findings, ec.Target, path+".target", file)
-	findings = walkForCollectionOps(findings, ec.Condition, path+".condition", file)
-	findings = walkForCollectionOps(findings, ec.Collection, path+".collection", file)
+	ast.WalkEnsures(&ec, path, func(e *ast.Expression, path *ast.Path) bool {
+		findings = checkCollectionOpLambda(findings, e, path, file)
+		return true
+	})
+	return findings
+}
 
-	for j, then := range ec.Then {
-		findings = walkEnsuresForCollectionOps(findings, then,
-			fmt.Sprintf("%s.then[%d]", path, j), file)
-	}
-	for j, el := range ec.Else {
-		findings = walkEnsuresForCollectionOps(findings, el,
-			fmt.Sprintf("%s.else[%d]", path, j), file)
+// checkCollectionOpLambda checks that an any or all operation names its
+// lambda parameter.
+func checkCollectionOpLambda(findings []report.Finding, expr *ast.Expression, path *ast.Path, file string) []report.Finding {
+	if expr.Kind != "collection_op" || expr.Operation != "any" && expr.Operation != "all" {
+		return findings
 	}
-	for j, body := range ec.Body {
-		findings = walkEnsuresForCollectionOps(findings, body,
-			fmt.Sprintf("%s.body[%d]", path, j), file)
+	if expr.Lambda == nil || expr.Lambda.Kind != "lambda" || expr.Lambda.Parameter == "" {
+		findings = append(findings, report.NewError(
+			"RULE-13",
+			fmt.Sprintf("Collection operation '%s' requires explicit lambda parameter", expr.Operation),
+			report.Location{File: file, Path: path.String()},
+		))
 	}
-
 	return findings
 }
 
@@ -791,29 +1277,19 @@ func walkEnsuresForCollectionOps(findings []report.Finding, ec ast.EnsuresClause
 
 func checkEnumComparisons(findings []report.Finding, spec *ast.Spec, st *SymbolTable) []report.Finding {
 	for i, entity := range spec.Entities {
-		// Build a map of field name -> type for this entity
-		fieldTypes := buildFieldTypeMap(entity.Fields)
-
 		for j, dv := range entity.DerivedValues {
-			findings = walkForEnumComparisons(findings, dv.Expression, fieldTypes, st,
-				fmt.Sprintf("$.entities[%d].derived_values[%d].expression", i, j), spec.File)
+			findings = walkForEnumComparisons(findings, dv.Expression, derivedFieldTypes(entity.Name, dv, spec, st), st,
+				ast.NewPath(fmt.Sprintf("$.entities[%d].derived_values[%d].expression", i, j)), spec.File)
 		}
 	}
 
 	for i, rule := range spec.Rules {
 		basePath := fmt.Sprintf("$.rules[%d]", i)
-
-		// Build scope field types from trigger entity
-		fieldTypes := make(map[string]*ast.FieldType)
-		if rule.Trigger.Entity != "" {
-			if ent := st.LookupEntity(rule.Trigger.Entity); ent != nil {
-				fieldTypes = buildFieldTypeMap(ent.Fields)
-			}
-		}
+		fieldTypes := ruleFieldTypes(rule, spec, st)
 
 		for j, req := range rule.Requires {
 			findings = walkForEnumComparisons(findings, &req, fieldTypes, st,
-				fmt.Sprintf("%s.requires[%d]", basePath, j), spec.File)
+				ast.NewPath(ast.IndexPath(basePath, "requires", j)), spec.File)
 		}
 	}
 
@@ -828,48 +1304,33 @@ func buildFieldTypeMap(fields []ast.Field) map[string]*ast.FieldType {
 	return m
 }
 
-func walkForEnumComparisons(findings []report.Finding, expr *ast.Expression, fieldTypes map[string]*ast.FieldType, st *SymbolTable, path string, file string) []report.Finding {
-	if expr == nil {
-		return findings
-	}
-
-	if expr.Kind == "comparison" {
-		leftType := resolveExprEnumType(expr.Left, fieldTypes, st)
-		rightType := resolveExprEnumType(expr.Right, fieldTypes, st)
-
-		if leftType != nil && rightType != nil {
-			// Both sides are enum-typed
-			if leftType.Kind == "inline_enum" || rightType.Kind == "inline_enum" {
-				// Any inline enum comparison across different fields is invalid
-				findings = append(findings, report.NewError(
-					"RULE-14",
-					"Cannot compare inline enums from different fields",
-					report.Location{File: file, Path: path},
-				))
-			} else if leftType.Kind == "named_enum" && rightType.Kind == "named_enum" {
-				if leftType.Name != rightType.Name {
-					findings = append(findings, report.NewError(
-						"RULE-14",
-						fmt.Sprintf("Cannot compare named enums of different types: '%s' vs '%s'", leftType.Name, rightType.Name),
-						report.Location{File: file, Path: path},
-					))
-				}
-			}
+func walkForEnumComparisons(findings []report.Finding, expr *ast.Expression, fieldTypes map[string]*ast.FieldType, st *SymbolTable, path *ast.Path, file string) []report.Finding {
+	ast.WalkExpression(expr, path, func(e *ast.Expression, path *ast.Path) bool {
+		if e.Kind != "comparison" {
+			return true
 		}
-	}
-
-	// Recurse
-	findings = walkForEnumComparisons(findings, expr.Object, fieldTypes, st, path+".object", file)
-	findings = walkForEnumComparisons(findings, expr.Left, fieldTypes, st, path+".left", file)
-	findings = walkForEnumComparisons(findings, expr.Right, fieldTypes, st, path+".right", file)
-	findings = walkForEnumComparisons(findings, expr.Target, fieldTypes, st, path+".target", file)
-	findings = walkForEnumComparisons(findings, expr.Operand, fieldTypes, st, path+".operand", file)
-	findings = walkForEnumComparisons(findings, expr.Collection, fieldTypes, st, path+".collection", file)
-	findings = walkForEnumComparisons(findings, expr.Lambda, fieldTypes, st, path+".lambda", file)
-	findings = walkForEnumComparisons(findings, expr.Condition, fieldTypes, st, path+".condition", file)
-	findings = walkForEnumComparisons(findings, expr.Body, fieldTypes, st, path+".body", file)
-	findings = walkForEnumComparisons(findings, expr.Element, fieldTypes, st, path+".element", file)
-
+		leftType := resolveExprEnumType(e.Left, fieldTypes, st)
+		rightType := resolveExprEnumType(e.Right, fieldTypes, st)
+		if leftType == nil || rightType == nil {
+			return true
+		}
+		// Both sides are enum-typed
+		if leftType.Kind == "inline_enum" || rightType.Kind == "inline_enum" {
+			// Any inline enum comparison across different fields is invalid
+			findings = append(findings, report.NewError(
+				"RULE-14",
+				"Cannot compare inline enums from different fields",
+				report.Location{File: file, Path: path.String()},
+			))
+		} else if leftType.Kind == "named_enum" && rightType.Kind == "named_enum" && leftType.Name != rightType.Name {
+			findings = append(findings, report.NewError(
+				"RULE-14",
+				fmt.Sprintf("Cannot compare named enums of different types: '%s' vs '%s'", leftType.Name, rightType.Name),
+				report.Location{File: file, Path: path.String()},
+			))
+		}
+		return true
+	})
 	return findings
 }
 
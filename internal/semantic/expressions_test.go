@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"testing"
 
@@ -18,6 +19,11 @@ func intLitExpr(val int) *ast.Expression {
 	return &ast.Expression{Kind: "literal", Type: "integer", LitValue: raw}
 }
 
+func decLitExpr(val float64) *ast.Expression {
+	raw, _ := json.Marshal(val)
+	return &ast.Expression{Kind: "literal", Type: "decimal", LitValue: raw}
+}
+
 func strLitExpr(val string) *ast.Expression {
 	raw, _ := json.Marshal(val)
 	return &ast.Expression{Kind: "literal", Type: "string", LitValue: raw}
@@ -225,6 +231,62 @@ func TestCheckExpressions_RULE10_ValueTypeCycle(t *testing.T) {
 	}
 }
 
+func TestDerivedValueOrder(t *testing.T) {
+	ref := func(names ...string) *ast.Expression {
+		e := &ast.Expression{Kind: "field_access", Field: names[0]}
+		for _, n := range names[1:] {
+			e = &ast.Expression{Kind: "arithmetic", Operator: "+", Left: e, Right: &ast.Expression{Kind: "field_access", Field: n}}
+		}
+		return e
+	}
+	dvs := []ast.DerivedValue{
+		{Name: "total", Expression: ref("subtotal", "tax")},
+		{Name: "tax", Expression: ref("subtotal", "rate")},
+		{Name: "label", Expression: &ast.Expression{Kind: "literal", Type: "string"}},
+		{Name: "subtotal", Expression: ref("amount")},
+	}
+	order, err := DerivedValueOrder(dvs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(order, ","); got != "subtotal,tax,total,label" {
+		t.Errorf("order = %s, want subtotal,tax,total,label", got)
+	}
+
+	dvs[3].Expression = ref("total")
+	if _, err := DerivedValueOrder(dvs); err == nil || !strings.Contains(err.Error(), "cycle detected") {
+		t.Errorf("expected cycle error, got %v", err)
+	}
+}
+
+func TestDerivedValueOrders(t *testing.T) {
+	spec := &ast.Spec{
+		Entities: []ast.Entity{
+			{Name: "Plain"},
+			{Name: "Order", DerivedValues: []ast.DerivedValue{
+				{Name: "total", Expression: &ast.Expression{Kind: "field_access", Field: "tax"}},
+				{Name: "tax", Expression: &ast.Expression{Kind: "literal", Type: "integer"}},
+			}},
+		},
+		ValueTypes: []ast.ValueType{
+			{Name: "Money", DerivedValues: []ast.DerivedValue{
+				{Name: "x", Expression: &ast.Expression{Kind: "field_access", Field: "y"}},
+				{Name: "y", Expression: &ast.Expression{Kind: "field_access", Field: "x"}},
+			}},
+		},
+	}
+	orders := DerivedValueOrders(spec)
+	if len(orders) != 2 {
+		t.Fatalf("expected orders for Order and Money only, got %+v", orders)
+	}
+	if o := orders[0]; o.Name != "Order" || o.Kind != "entity" || strings.Join(o.Order, ",") != "tax,total" {
+		t.Errorf("unexpected order for Order: %+v", o)
+	}
+	if o := orders[1]; o.Name != "Money" || o.Kind != "value_type" || o.Order != nil || !strings.Contains(o.Error, "x -> y -> x") && !strings.Contains(o.Error, "y -> x -> y") {
+		t.Errorf("unexpected order for Money: %+v", o)
+	}
+}
+
 // --- RULE-11: Out-of-scope field access ---
 
 func TestCheckExpressions_RULE11_OutOfScope(t *testing.T) {
@@ -656,6 +718,43 @@ func TestCheckExpressions_RULE12_IntegerTimesInteger_Valid(t *testing.T) {
 	}
 }
 
+func TestCheckExpressions_RULE12_Decimal(t *testing.T) {
+	tests := []struct {
+		name string
+		expr *ast.Expression
+		want string
+	}{
+		{"Integer times Decimal", arithmeticExpr("*", intLitExpr(2), decLitExpr(1.5)), ""},
+		{"Decimal minus Integer", arithmeticExpr("-", decLitExpr(2.5), intLitExpr(1)), ""},
+		{"Decimal compared with Integer", comparisonExpr(">", decLitExpr(0.5), intLitExpr(0)), ""},
+		{"promoted result", comparisonExpr("=", arithmeticExpr("+", intLitExpr(1), decLitExpr(0.5)), strLitExpr("x")),
+			"Type mismatch in comparison: Decimal vs String"},
+		{"Decimal plus Timestamp", arithmeticExpr("+", decLitExpr(1.5), tsLitExpr("now")),
+			"Type mismatch in arithmetic: Decimal + Timestamp"},
+		{"Decimal plus String", arithmeticExpr("+", decLitExpr(1.5), strLitExpr("a")),
+			"Non-numeric type String in arithmetic"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := &ast.Spec{
+				File: "test.allium.json",
+				Rules: []ast.Rule{{
+					Name:     "R1",
+					Trigger:  ast.Trigger{Kind: "external_stimulus", Name: "test"},
+					Requires: []ast.Expression{*tt.expr},
+				}},
+			}
+			r12 := findingsWithRule(CheckExpressions(spec, BuildSymbolTable(spec)), "RULE-12")
+			switch {
+			case tt.want == "" && len(r12) != 0:
+				t.Errorf("expected no RULE-12, got %v", r12)
+			case tt.want != "" && (len(r12) != 1 || r12[0].Message != tt.want):
+				t.Errorf("expected RULE-12 %q, got %v", tt.want, r12)
+			}
+		})
+	}
+}
+
 func TestCheckExpressions_RULE12_StringPlusString_Invalid(t *testing.T) {
 	spec := &ast.Spec{
 		File: "test.allium.json",
@@ -673,8 +772,26 @@ func TestCheckExpressions_RULE12_StringPlusString_Invalid(t *testing.T) {
 	findings := CheckExpressions(spec, st)
 
 	r12 := findingsWithRule(findings, "RULE-12")
-	if len(r12) == 0 {
-		t.Fatal("expected RULE-12 for String + String arithmetic")
+	if len(r12) != 1 {
+		t.Fatalf("expected 1 RULE-12 for String + String arithmetic, got %v", r12)
+	}
+	if r12[0].Message != "Strings cannot be joined with '+'; use concat(a, b)" {
+		t.Errorf("message = %q", r12[0].Message)
+	}
+	if len(r12[0].Suggestions) != 1 {
+		t.Fatalf("expected a concat suggestion, got %v", r12[0].Suggestions)
+	}
+	edit := r12[0].Suggestions[0].Edits[0]
+	want := `{"kind":"function_call","name":"concat","arguments":[{"kind":"literal","type":"string","value":"a"},{"kind":"literal","type":"string","value":"b"}]}`
+	if edit.Op != "replace" || edit.Path != "$.rules[0].requires[0]" || string(edit.Value) != want {
+		t.Errorf("edit = %s %s %s", edit.Op, edit.Path, edit.Value)
+	}
+
+	// concat is a registered function, so its result is a String.
+	spec.Rules[0].Requires = []ast.Expression{*comparisonExpr("=", callExpr("concat", strLitExpr("a"), strLitExpr("b")), intLitExpr(1))}
+	r12 = findingsWithRule(CheckExpressions(spec, BuildSymbolTable(spec)), "RULE-12")
+	if len(r12) != 1 || r12[0].Message != "Type mismatch in comparison: String vs Integer" {
+		t.Errorf("expected concat to return String, got %v", r12)
 	}
 }
 
@@ -825,6 +942,269 @@ func TestCheckExpressions_RULE12_FieldTypeArithmetic(t *testing.T) {
 	}
 }
 
+func TestCheckExpressions_RULE12_DerivedCrossEntity(t *testing.T) {
+	str := ast.FieldType{Kind: "primitive", Value: "String"}
+	integer := ast.FieldType{Kind: "primitive", Value: "Integer"}
+	greaterThan3 := func(left *ast.Expression) *ast.Expression { return comparisonExpr(">", left, intLitExpr(3)) }
+	spec := &ast.Spec{
+		File: "test.allium.json",
+		Entities: []ast.Entity{
+			{
+				Name:   "Customer",
+				Fields: []ast.Field{{Name: "name", Type: str}},
+				Relationships: []ast.Relationship{
+					{Name: "orders", TargetEntity: "Order", ForeignKey: "customer", Cardinality: "many"},
+				},
+				Projections: []ast.Projection{
+					{Name: "large_orders", Source: "orders", Condition: greaterThan3(fieldAccess("reference"))},
+					{Name: "references", Source: "orders", Mapping: "reference"},
+				},
+				DerivedValues: []ast.DerivedValue{
+					{Name: "has_named_order", Expression: &ast.Expression{Kind: "collection_op", Operation: "any", Collection: fieldAccess("orders"),
+						Lambda: &ast.Expression{Kind: "lambda", Parameter: "o", Body: greaterThan3(chain("o", "reference"))}}},
+					{Name: "any_reference", Expression: &ast.Expression{Kind: "collection_op", Operation: "any", Collection: fieldAccess("references"),
+						Lambda: &ast.Expression{Kind: "lambda", Parameter: "r", Body: greaterThan3(fieldAccess("r"))}}},
+					// Parameters hide members of the same name.
+					{Name: "named", Parameters: []string{"name"}, Expression: greaterThan3(fieldAccess("name"))},
+				},
+			},
+			{
+				Name:          "Order",
+				Fields:        []ast.Field{{Name: "reference", Type: str}, {Name: "total", Type: integer}},
+				Relationships: []ast.Relationship{{Name: "customer", TargetEntity: "Customer", ForeignKey: "customer", Cardinality: "one"}},
+				DerivedValues: []ast.DerivedValue{{Name: "vip", Expression: greaterThan3(chain("customer", "name"))}},
+			},
+		},
+		ValueTypes: []ast.ValueType{{Name: "Money", Fields: []ast.Field{{Name: "currency", Type: str}},
+			DerivedValues: []ast.DerivedValue{{Name: "big", Expression: greaterThan3(fieldAccess("currency"))}}}},
+	}
+	var paths []string
+	for _, f := range findingsWithRule(CheckExpressions(spec, BuildSymbolTable(spec)), "RULE-12") {
+		paths = append(paths, f.Location.Path)
+	}
+	want := []string{
+		"$.entities[0].derived_values[0].expression.lambda.body",
+		"$.entities[0].derived_values[1].expression.lambda.body",
+		"$.entities[0].projections[0].condition",
+		"$.entities[1].derived_values[0].expression",
+		"$.value_types[0].derived_values[0].expression",
+	}
+	slices.Sort(paths)
+	if !slices.Equal(paths, want) {
+		t.Errorf("RULE-12 paths = %v, want %v", paths, want)
+	}
+}
+
+// chainedTypeSpec declares Account, owned by User through a one-to-one
+// relationship, a given binding and a config parameter, for tests of chained
+// field access typing.
+func chainedTypeSpec(requires ...ast.Expression) *ast.Spec {
+	return &ast.Spec{
+		File: "test.allium.json",
+		Entities: []ast.Entity{
+			{
+				Name: "User",
+				Fields: []ast.Field{
+					{Name: "email", Type: ast.FieldType{Kind: "primitive", Value: "String"}},
+					{Name: "manager", Type: ast.FieldType{Kind: "optional", Inner: &ast.FieldType{Kind: "entity_ref", Entity: "User"}}},
+				},
+				Relationships: []ast.Relationship{
+					{Name: "account", TargetEntity: "Account", ForeignKey: "user_id", Cardinality: "one"},
+					{Name: "orders", TargetEntity: "Account", ForeignKey: "user_id", Cardinality: "many"},
+				},
+			},
+			{
+				Name: "Account",
+				Fields: []ast.Field{
+					{Name: "balance", Type: ast.FieldType{Kind: "primitive", Value: "Integer"}},
+				},
+			},
+		},
+		Given: []ast.GivenBinding{
+			{Name: "admin", Type: ast.FieldType{Kind: "entity_ref", Entity: "User"}},
+		},
+		Config: []ast.ConfigParam{
+			{Name: "limit", Type: ast.FieldType{Kind: "primitive", Value: "Duration"}},
+		},
+		Rules: []ast.Rule{
+			{
+				Name:     "R1",
+				Trigger:  ast.Trigger{Kind: "state_becomes", Binding: "user", Entity: "User", Field: "email"},
+				Requires: requires,
+			},
+		},
+	}
+}
+
+func chain(fields ...string) *ast.Expression {
+	e := fieldAccess(fields[0])
+	for _, f := range fields[1:] {
+		e = &ast.Expression{Kind: "field_access", Object: e, Field: f}
+	}
+	return e
+}
+
+func TestCheckExpressions_RULE12_ChainedFieldAccess(t *testing.T) {
+	tests := []struct {
+		name string
+		expr *ast.Expression
+		want string
+	}{
+		{"trigger binding through relationship", comparisonExpr("=", chain("user", "account", "balance"), strLitExpr("x")),
+			"Type mismatch in comparison: Integer vs String"},
+		{"given binding through optional entity_ref", comparisonExpr(">", chain("admin", "manager", "account", "balance"), boolLitExpr(true)),
+			"Type mismatch in comparison: Integer vs Boolean"},
+		{"config parameter", arithmeticExpr("+", chain("user", "email"), chain("config", "limit")),
+			"Non-numeric type String in arithmetic"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := chainedTypeSpec(*tt.expr)
+			r12 := findingsWithRule(CheckExpressions(spec, BuildSymbolTable(spec)), "RULE-12")
+			if len(r12) != 1 || r12[0].Message != tt.want {
+				t.Fatalf("expected RULE-12 %q, got %v", tt.want, r12)
+			}
+		})
+	}
+}
+
+func TestCheckExpressions_RULE12_ChainedFieldAccessUnresolved(t *testing.T) {
+	spec := chainedTypeSpec(
+		// Valid comparison through a relationship.
+		*comparisonExpr(">", chain("user", "account", "balance"), intLitExpr(0)),
+		// A to-many relationship is a collection: its members are not typed.
+		*comparisonExpr("=", chain("user", "orders", "balance"), strLitExpr("x")),
+		// Unknown fields and bindings resolve to no type.
+		*comparisonExpr("=", chain("user", "account", "owner"), strLitExpr("x")),
+		*comparisonExpr("=", chain("other", "balance"), strLitExpr("x")),
+	)
+	spec.Rules[0].ForClause = &ast.ForClause{Binding: "acct", Collection: chain("user", "orders")}
+	spec.Rules[0].LetBindings = []ast.LetBinding{{Name: "acc", Expression: chain("user", "account")}}
+	spec.Rules[0].Requires = append(spec.Rules[0].Requires,
+		*comparisonExpr("=", chain("acct", "balance"), intLitExpr(1)),
+		*comparisonExpr("=", chain("acc", "balance"), intLitExpr(1)),
+	)
+	if r12 := findingsWithRule(CheckExpressions(spec, BuildSymbolTable(spec)), "RULE-12"); len(r12) != 0 {
+		t.Errorf("expected no RULE-12, got %v", r12)
+	}
+
+	// for and let bindings carry the element and expression types.
+	spec.Rules[0].Requires = []ast.Expression{
+		*comparisonExpr("=", chain("acct", "balance"), strLitExpr("x")),
+		*comparisonExpr("=", chain("acc", "balance"), strLitExpr("x")),
+	}
+	if r12 := findingsWithRule(CheckExpressions(spec, BuildSymbolTable(spec)), "RULE-12"); len(r12) != 2 {
+		t.Errorf("expected RULE-12 through for and let bindings, got %v", r12)
+	}
+}
+
+func TestCheckExpressions_RULE12_InferredLetBindings(t *testing.T) {
+	lookup := &ast.Expression{Kind: "join_lookup", Entity: "Account", Fields: map[string]ast.Expression{"user": *fieldAccess("user")}}
+	tests := []struct {
+		name string
+		lets []ast.LetBinding
+		expr *ast.Expression
+		want string
+	}{
+		{"join lookup", []ast.LetBinding{{Name: "acct", Expression: lookup}},
+			comparisonExpr("=", chain("acct", "balance"), strLitExpr("x")), "Type mismatch in comparison: Integer vs String"},
+		{"earlier let binding", []ast.LetBinding{{Name: "acct", Expression: lookup}, {Name: "balance", Expression: chain("acct", "balance")}},
+			comparisonExpr("=", fieldAccess("balance"), strLitExpr("x")), "Type mismatch in comparison: Integer vs String"},
+		{"literal", []ast.LetBinding{{Name: "limit", Expression: intLitExpr(3)}},
+			comparisonExpr("<", fieldAccess("limit"), tsLitExpr("now")), "Type mismatch in comparison: Integer vs Timestamp"},
+		{"arithmetic", []ast.LetBinding{{Name: "next", Expression: arithmeticExpr("+", chain("user", "account", "balance"), intLitExpr(1))}},
+			comparisonExpr("=", fieldAccess("next"), boolLitExpr(true)), "Type mismatch in comparison: Integer vs Boolean"},
+		{"count", []ast.LetBinding{{Name: "n", Expression: &ast.Expression{Kind: "collection_op", Operation: "count", Collection: chain("user", "orders")}}},
+			comparisonExpr("=", fieldAccess("n"), strLitExpr("x")), "Type mismatch in comparison: Integer vs String"},
+		{"comparison", []ast.LetBinding{{Name: "known", Expression: comparisonExpr("=", chain("user", "email"), strLitExpr("x"))}},
+			arithmeticExpr("+", fieldAccess("known"), intLitExpr(1)), "Non-numeric type Boolean in arithmetic"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := chainedTypeSpec(*tt.expr)
+			spec.Rules[0].LetBindings = tt.lets
+			r12 := findingsWithRule(CheckExpressions(spec, BuildSymbolTable(spec)), "RULE-12")
+			if len(r12) != 1 || r12[0].Message != tt.want {
+				t.Fatalf("expected RULE-12 %q, got %v", tt.want, r12)
+			}
+		})
+	}
+
+	// An untyped expression leaves the binding untyped.
+	spec := chainedTypeSpec(*comparisonExpr("=", fieldAccess("risk"), strLitExpr("x")))
+	spec.Rules[0].LetBindings = []ast.LetBinding{{Name: "risk", Expression: callExpr("risk_score", chain("user", "email"))}}
+	if r12 := findingsWithRule(CheckExpressions(spec, BuildSymbolTable(spec)), "RULE-12"); len(r12) != 0 {
+		t.Errorf("expected no RULE-12 for an untyped let binding, got %v", r12)
+	}
+}
+
+// --- RULE-40: Function call signatures ---
+
+func callExpr(name string, args ...*ast.Expression) *ast.Expression {
+	e := &ast.Expression{Kind: "function_call", FuncName: name}
+	for _, a := range args {
+		e.FuncArguments = append(e.FuncArguments, *a)
+	}
+	return e
+}
+
+func TestCheckExpressions_RULE40_FunctionSignatures(t *testing.T) {
+	spec := chainedTypeSpec(
+		*comparisonExpr(">=", callExpr("length", chain("user", "email"), intLitExpr(1)), intLitExpr(8)),
+		*callExpr("verify", chain("user", "account", "balance"), strLitExpr("x")),
+		// Unregistered black box functions are not checked.
+		*callExpr("parse_mentions", intLitExpr(1), intLitExpr(2)),
+		// Arguments of unknown type are not checked.
+		*callExpr("hash", fieldAccess("password")),
+	)
+	r40 := findingsWithRule(CheckExpressions(spec, BuildSymbolTable(spec)), "RULE-40")
+	if len(r40) != 2 {
+		t.Fatalf("expected 2 RULE-40, got %v", r40)
+	}
+	if r40[0].Message != "Function 'length' expects 1 argument, got 2" || r40[0].Location.Path != "$.rules[0].requires[0].left" {
+		t.Errorf("unexpected finding: %s at %s", r40[0].Message, r40[0].Location.Path)
+	}
+	if r40[1].Message != "Argument 1 of 'verify' has type Integer, expected String" || r40[1].Location.Path != "$.rules[0].requires[1].arguments[0]" {
+		t.Errorf("unexpected finding: %s at %s", r40[1].Message, r40[1].Location.Path)
+	}
+}
+
+func TestCheckExpressions_RULE40_DecimalParameter(t *testing.T) {
+	spec := chainedTypeSpec(
+		*callExpr("round", intLitExpr(2)),
+		*callExpr("round", decLitExpr(2.5)),
+		*callExpr("round", strLitExpr("2.5")),
+	)
+	st := BuildSymbolTable(spec)
+	st.Functions = st.Functions.Extend([]FunctionSignature{{Name: "round", Parameters: []string{"Decimal"}, Returns: "Integer"}})
+	r40 := findingsWithRule(CheckExpressions(spec, st), "RULE-40")
+	if len(r40) != 1 || r40[0].Message != "Argument 1 of 'round' has type String, expected Decimal" {
+		t.Errorf("expected only the String argument reported, got %v", r40)
+	}
+}
+
+func TestCheckExpressions_RULE12_FunctionReturnType(t *testing.T) {
+	spec := chainedTypeSpec(
+		*comparisonExpr(">=", callExpr("length", chain("user", "email")), strLitExpr("8")),
+		*comparisonExpr("<", callExpr("now"), tsLitExpr("now")),
+	)
+	r12 := findingsWithRule(CheckExpressions(spec, BuildSymbolTable(spec)), "RULE-12")
+	if len(r12) != 1 || r12[0].Message != "Type mismatch in comparison: Integer vs String" {
+		t.Errorf("expected one RULE-12 from length's return type, got %v", r12)
+	}
+
+	// A manifest can declare the return type of a domain function.
+	spec = chainedTypeSpec(*comparisonExpr("=", callExpr("risk_score", chain("user", "email")), strLitExpr("high")))
+	st := BuildSymbolTable(spec)
+	if r12 := findingsWithRule(CheckExpressions(spec, st), "RULE-12"); len(r12) != 0 {
+		t.Errorf("unregistered function should be untyped, got %v", r12)
+	}
+	st.Functions = st.Functions.Extend([]FunctionSignature{{Name: "risk_score", Parameters: []string{"String"}, Returns: "Integer"}})
+	if r12 := findingsWithRule(CheckExpressions(spec, st), "RULE-12"); len(r12) != 1 {
+		t.Errorf("expected RULE-12 from manifest return type, got %v", r12)
+	}
+}
+
 // --- RULE-13: any/all lambda checks ---
 
 func TestCheckExpressions_RULE13_MissingLambda(t *testing.T) {
@@ -962,6 +1342,25 @@ func TestCheckExpressions_RULE13_InEnsures(t *testing.T) {
 	}
 }
 
+func TestCheckExpressions_RULE13_InEnsuresValue(t *testing.T) {
+	spec := &ast.Spec{
+		File: "test.allium.json",
+		Rules: []ast.Rule{{
+			Name:    "R1",
+			Trigger: ast.Trigger{Kind: "external_stimulus", Name: "test"},
+			Ensures: []ast.EnsuresClause{{
+				Kind:   "state_change",
+				Target: fieldAccess("verified"),
+				Value:  json.RawMessage(`{"kind": "collection_op", "operation": "any", "collection": {"kind": "field_access", "field": "items"}}`),
+			}},
+		}},
+	}
+	findings := findingsWithRule(CheckExpressions(spec, BuildSymbolTable(spec)), "RULE-13")
+	if len(findings) != 1 || findings[0].Location.Path != "$.rules[0].ensures[0].value" {
+		t.Fatalf("expected RULE-13 at the ensures value, got %v", findings)
+	}
+}
+
 // --- RULE-14: Enum comparison checks ---
 
 func TestCheckExpressions_RULE14_InlineEnumComparison(t *testing.T) {
@@ -1076,6 +1475,155 @@ func TestCheckExpressions_RULE14_SameNamedEnum(t *testing.T) {
 	}
 }
 
+func TestCheckExpressions_RULE14_LetBinding(t *testing.T) {
+	spec := &ast.Spec{
+		File: "test.allium.json",
+		Enumerations: []ast.Enumeration{
+			{Name: "Priority", Values: []string{"low", "high"}},
+			{Name: "Status", Values: []string{"active", "inactive"}},
+		},
+		Entities: []ast.Entity{
+			{
+				Name: "Task",
+				Fields: []ast.Field{
+					{Name: "priority", Type: ast.FieldType{Kind: "named_enum", Name: "Priority"}},
+					{Name: "status", Type: ast.FieldType{Kind: "named_enum", Name: "Status"}},
+				},
+			},
+		},
+		Rules: []ast.Rule{
+			{
+				Name:        "R1",
+				Trigger:     ast.Trigger{Kind: "state_transition", Entity: "Task", Field: "status", Binding: "task"},
+				LetBindings: []ast.LetBinding{{Name: "current", Expression: chain("task", "priority")}},
+				Requires: []ast.Expression{
+					*comparisonExpr("=", fieldAccess("current"), fieldAccess("status")),
+				},
+			},
+		},
+	}
+	r14 := findingsWithRule(CheckExpressions(spec, BuildSymbolTable(spec)), "RULE-14")
+	if len(r14) != 1 || r14[0].Message != "Cannot compare named enums of different types: 'Priority' vs 'Status'" {
+		t.Errorf("expected RULE-14 through the let binding, got %v", r14)
+	}
+}
+
+// --- RULE-49: Collections and single values ---
+
+func collectionOp(op string, collection *ast.Expression) *ast.Expression {
+	return &ast.Expression{Kind: "collection_op", Operation: op, Collection: collection}
+}
+
+func TestCheckExpressions_RULE49_CollectionOperands(t *testing.T) {
+	active := collectionOp("where", chain("user", "orders"))
+	tests := []struct {
+		name string
+		expr *ast.Expression
+		path string
+		want string
+	}{
+		{"count of a single value", comparisonExpr(">", collectionOp("count", chain("user", "email")), intLitExpr(0)),
+			"$.rules[0].requires[0].left.collection", "Collection operation 'count' is applied to 'user.email', which is not a collection"},
+		{"any of a one relationship", collectionOp("any", chain("user", "account")),
+			"$.rules[0].requires[0].collection", "Collection operation 'any' is applied to 'user.account', which is not a collection"},
+		{"ordering a collection", comparisonExpr(">", chain("user", "orders"), intLitExpr(3)),
+			"$.rules[0].requires[0].left", "Comparison with 'user.orders', which is a collection (Set<Account>) rather than a single value"},
+		{"equating a collection with a single value", comparisonExpr("=", chain("admin", "account"), chain("user", "orders")),
+			"$.rules[0].requires[0].right", "Comparison with 'user.orders', which is a collection (Set<Account>) rather than a single value"},
+		{"arithmetic on a filtered collection", comparisonExpr("=", arithmeticExpr("+", active, intLitExpr(1)), intLitExpr(2)),
+			"$.rules[0].requires[0].left.left", "Arithmetic on a 'where' result, which is a collection (Set<Account>) rather than a single value"},
+		{"let binding of a filter", comparisonExpr("<", fieldAccess("recent"), intLitExpr(2)),
+			"$.rules[0].requires[0].left", "Comparison with 'recent', which is a collection (Set<Account>) rather than a single value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := chainedTypeSpec(*tt.expr)
+			spec.Rules[0].LetBindings = []ast.LetBinding{{Name: "recent", Expression: active}}
+			r49 := findingsWithRule(CheckExpressions(spec, BuildSymbolTable(spec)), "RULE-49")
+			if len(r49) != 1 || r49[0].Message != tt.want {
+				t.Fatalf("expected RULE-49 %q, got %v", tt.want, r49)
+			}
+			if r49[0].Location.Path != tt.path {
+				t.Errorf("path = %q, want %q", r49[0].Location.Path, tt.path)
+			}
+		})
+	}
+}
+
+func TestCheckExpressions_RULE49_Valid(t *testing.T) {
+	spec := chainedTypeSpec(
+		*comparisonExpr(">", collectionOp("count", chain("user", "orders")), intLitExpr(3)),
+		*comparisonExpr("=", collectionOp("count", collectionOp("where", chain("user", "orders"))), intLitExpr(0)),
+		*comparisonExpr("=", chain("user", "orders"), chain("admin", "orders")),
+		*comparisonExpr("=", chain("user", "orders"), &ast.Expression{Kind: "set_literal"}),
+		*comparisonExpr(">", chain("user", "account", "balance"), intLitExpr(0)),
+		// Operands of unknown type are not checked.
+		*collectionOp("any", chain("user", "sessions")),
+		*comparisonExpr("=", chain("user", "orders"), fieldAccess("others")),
+	)
+	if r49 := findingsWithRule(CheckExpressions(spec, BuildSymbolTable(spec)), "RULE-49"); len(r49) != 0 {
+		t.Errorf("expected no RULE-49, got %v", r49)
+	}
+}
+
+func TestCheckExpressions_RULE54_EnumLiterals(t *testing.T) {
+	statuses := &ast.Expression{Kind: "set_literal", Elements: []ast.Expression{*enumLitExpr("active"), *enumLitExpr("frozen")}}
+	tests := []struct {
+		name string
+		expr *ast.Expression
+		path string
+		want string
+	}{
+		{"comparison", comparisonExpr("=", accountField("status"), enumLitExpr("closd")),
+			"$.rules[0].requires[0].right", "Enum value 'closd' is not a value of 'account.status' (active | closed) (did you mean 'closed'?)"},
+		{"literal on the left", comparisonExpr("!=", enumLitExpr("gone"), accountField("status")),
+			"$.rules[0].requires[0].left", "Enum value 'gone' is not a value of 'account.status' (active | closed)"},
+		{"set literal", &ast.Expression{Kind: "membership", Element: accountField("status"), Collection: statuses},
+			"$.rules[0].requires[0].collection.elements[1]", "Enum value 'frozen' is not a value of 'account.status' (active | closed)"},
+		{"enum collection", &ast.Expression{Kind: "membership", Element: enumLitExpr("archived"), Collection: accountField("history")},
+			"$.rules[0].requires[0].element", "Enum value 'archived' is not a value of 'account.history' (active | closed)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := enumLiteralSpec(*tt.expr)
+			r54 := findingsWithRule(CheckExpressions(spec, BuildSymbolTable(spec)), "RULE-54")
+			if len(r54) != 1 || r54[0].Message != tt.want {
+				t.Fatalf("expected RULE-54 %q, got %v", tt.want, r54)
+			}
+			if r54[0].Location.Path != tt.path {
+				t.Errorf("path = %q, want %q", r54[0].Location.Path, tt.path)
+			}
+		})
+	}
+}
+
+func TestCheckExpressions_RULE54_Valid(t *testing.T) {
+	spec := enumLiteralSpec(
+		*comparisonExpr("=", accountField("status"), enumLitExpr("closed")),
+		*comparisonExpr("=", &ast.Expression{Kind: "field_access", Object: fieldAccess("business"), Field: "tier"}, enumLitExpr("plus")),
+		*comparisonExpr("=", enumLitExpr("active"), accountField("status")),
+		ast.Expression{Kind: "membership", Element: enumLitExpr("closed"), Collection: accountField("history")},
+		// Fields that are not enums, or of unknown type, are not checked.
+		*comparisonExpr("=", accountField("name"), enumLitExpr("anything")),
+		*comparisonExpr("=", chain("request", "status"), enumLitExpr("anything")),
+	)
+	if r54 := findingsWithRule(CheckExpressions(spec, BuildSymbolTable(spec)), "RULE-54"); len(r54) != 0 {
+		t.Errorf("expected no RULE-54, got %v", r54)
+	}
+}
+
+// enumLiteralSpec returns the trigger test spec with a rule on Account that
+// requires each of exprs, given a Business. Account also has a history, a
+// list of its statuses.
+func enumLiteralSpec(exprs ...ast.Expression) *ast.Spec {
+	spec := triggerSpec(ast.Trigger{Kind: "entity_creation", Binding: "account", Entity: "Account"})
+	spec.Entities[0].Fields = append(spec.Entities[0].Fields, ast.Field{Name: "history",
+		Type: ast.FieldType{Kind: "list", Element: &ast.FieldType{Kind: "named_enum", Name: "AccountStatus"}}})
+	spec.Given = []ast.GivenBinding{{Name: "business", Type: ast.FieldType{Kind: "entity_ref", Entity: "Business"}}}
+	spec.Rules[0].Requires = exprs
+	return spec
+}
+
 // --- Tarjan SCC unit tests ---
 
 func TestTarjanSCC_NoCycle(t *testing.T) {
@@ -1128,3 +1676,70 @@ func TestTarjanSCC_Empty(t *testing.T) {
 		t.Errorf("expected empty, got %v", sccs)
 	}
 }
+
+func TestTarjanSCC_ReverseTopologicalOrder(t *testing.T) {
+	// 0 -> {1, 2}, 1 <-> 2, 2 -> 3
+	adj := [][]int{{1, 2}, {2}, {1, 3}, {}}
+	sccs := tarjanSCC(adj)
+	if len(sccs) != 3 || len(sccs[0]) != 1 || sccs[0][0] != 3 || len(sccs[1]) != 2 || len(sccs[2]) != 1 || sccs[2][0] != 0 {
+		t.Errorf("expected [[3] [1 2] [0]] in some member order, got %v", sccs)
+	}
+}
+
+func TestTarjanSCC_LongChain(t *testing.T) {
+	// 0 -> 1 -> ... -> n-1, deeper than a recursive search could go, and
+	// closed into one cycle by n-1 -> 0.
+	const n = 1_000_000
+	adj := make([][]int, n)
+	for v := range n - 1 {
+		adj[v] = []int{v + 1}
+	}
+	if sccs := tarjanSCC(adj); len(sccs) != n || sccs[0][0] != n-1 {
+		t.Fatalf("expected %d single-node components starting with the last, got %d", n, len(sccs))
+	}
+	adj[n-1] = []int{0}
+	if sccs := tarjanSCC(adj); len(sccs) != 1 || len(sccs[0]) != n {
+		t.Errorf("expected one component of %d nodes, got %d components", n, len(sccs))
+	}
+}
+
+func TestCheckExpressions_RULE12_Surfaces(t *testing.T) {
+	spec := chainedTypeSpec()
+	spec.Actors = []ast.Actor{{Name: "Customer", IdentifiedBy: ast.IdentifiedBy{Entity: "User"}}}
+	spec.Surfaces = []ast.Surface{{
+		Name:    "AccountView",
+		Facing:  ast.FacingClause{Binding: "viewer", Type: "Customer"},
+		Context: &ast.ContextClause{Binding: "account", Type: "Account"},
+		Exposes: []ast.ExposesItem{
+			{Expression: chain("account", "balance"), When: comparisonExpr("=", chain("viewer", "email"), intLitExpr(3))},
+		},
+		Provides: []ast.ProvidesItem{
+			{
+				Kind:       "for_each",
+				Binding:    "order",
+				Collection: chain("viewer", "orders"),
+				Items: []ast.ProvidesItem{
+					{Kind: "action", Trigger: "Refund", When: comparisonExpr(">", chain("order", "balance"), strLitExpr("0"))},
+				},
+			},
+		},
+		Related: []ast.RelatedItem{
+			{Surface: "Other", ContextExpression: chain("viewer", "account"), When: comparisonExpr("=", chain("account", "balance"), intLitExpr(0))},
+		},
+	}}
+	st := BuildSymbolTable(spec)
+	r12 := findingsWithRule(CheckExpressions(spec, st), "RULE-12")
+
+	want := map[string]string{
+		"$.surfaces[0].exposes[0].when":           "Type mismatch in comparison: String vs Integer",
+		"$.surfaces[0].provides[0].items[0].when": "Type mismatch in comparison: Integer vs String",
+	}
+	if len(r12) != len(want) {
+		t.Fatalf("expected %d RULE-12 findings, got %d: %v", len(want), len(r12), r12)
+	}
+	for _, f := range r12 {
+		if msg, ok := want[f.Location.Path]; !ok || f.Message != msg {
+			t.Errorf("unexpected finding %q at %s", f.Message, f.Location.Path)
+		}
+	}
+}
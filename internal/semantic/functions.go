@@ -0,0 +1,137 @@
+package semantic
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+)
+
+// anyType is the signature type that accepts, or stands for, a value of any type.
+const anyType = "Any"
+
+// signatureTypes lists the type names a function signature may use. They are
+// the descriptors produced by resolveExprType for primitive values.
+var signatureTypes = []string{"String", "Integer", "Decimal", "Boolean", "Timestamp", "Duration", anyType}
+
+var functionNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// FunctionSignature declares the parameter and return types of a black box
+// function. A Returns of "" or "Any" leaves the result type unknown.
+type FunctionSignature struct {
+	Name       string   `json:"name"`
+	Parameters []string `json:"parameters"`
+	Returns    string   `json:"returns,omitempty"`
+}
+
+// FunctionRegistry maps function names to their signatures. Calls to
+// functions outside the registry are not checked.
+type FunctionRegistry struct {
+	funcs map[string]FunctionSignature
+}
+
+// builtinFunctions are the black box functions every spec may call.
+var builtinFunctions = []FunctionSignature{
+	{Name: "now", Parameters: []string{}, Returns: "Timestamp"},
+	{Name: "length", Parameters: []string{"String"}, Returns: "Integer"},
+	{Name: "lower", Parameters: []string{"String"}, Returns: "String"},
+	{Name: "upper", Parameters: []string{"String"}, Returns: "String"},
+	{Name: "trim", Parameters: []string{"String"}, Returns: "String"},
+	{Name: "concat", Parameters: []string{"String", "String"}, Returns: "String"},
+	{Name: "hash", Parameters: []string{"String"}, Returns: "String"},
+	{Name: "verify", Parameters: []string{"String", "String"}, Returns: "Boolean"},
+	{Name: "abs", Parameters: []string{"Integer"}, Returns: "Integer"},
+}
+
+// BuiltinFunctions returns a registry holding the built-in functions.
+func BuiltinFunctions() *FunctionRegistry {
+	r := &FunctionRegistry{funcs: make(map[string]FunctionSignature, len(builtinFunctions))}
+	for _, sig := range builtinFunctions {
+		r.funcs[sig.Name] = sig
+	}
+	return r
+}
+
+// Lookup returns the signature of the named function, or nil. It is safe to
+// call on a nil registry, which holds the built-in functions.
+func (r *FunctionRegistry) Lookup(name string) *FunctionSignature {
+	if r == nil {
+		r = BuiltinFunctions()
+	}
+	sig, ok := r.funcs[name]
+	if !ok {
+		return nil
+	}
+	return &sig
+}
+
+// Extend returns a copy of r with sigs added. A signature replaces any
+// existing one of the same name, including a built-in.
+func (r *FunctionRegistry) Extend(sigs []FunctionSignature) *FunctionRegistry {
+	if r == nil {
+		r = BuiltinFunctions()
+	}
+	ext := &FunctionRegistry{funcs: maps.Clone(r.funcs)}
+	for _, sig := range sigs {
+		ext.funcs[sig.Name] = sig
+	}
+	return ext
+}
+
+// functionManifest is the content of a function manifest file.
+type functionManifest struct {
+	Functions []FunctionSignature `json:"functions"`
+}
+
+// LoadFunctionManifest reads a JSON manifest of domain-specific function
+// signatures, of the form {"functions": [{"name": "parse_mentions",
+// "parameters": ["String"], "returns": "Any"}]}, and returns the built-in
+// registry extended with them.
+func LoadFunctionManifest(path string) (*FunctionRegistry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read function manifest: %w", err)
+	}
+	var m functionManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("parse function manifest %s: %w", path, err)
+	}
+	seen := make(map[string]bool)
+	for i, sig := range m.Functions {
+		if !functionNamePattern.MatchString(sig.Name) {
+			return nil, fmt.Errorf("function manifest %s: function %d has invalid name %q", path, i, sig.Name)
+		}
+		if seen[sig.Name] {
+			return nil, fmt.Errorf("function manifest %s: function %q declared twice", path, sig.Name)
+		}
+		seen[sig.Name] = true
+		for _, p := range sig.Parameters {
+			if !slices.Contains(signatureTypes, p) {
+				return nil, fmt.Errorf("function manifest %s: function %q has unknown parameter type %q", path, sig.Name, p)
+			}
+		}
+		if sig.Returns != "" && !slices.Contains(signatureTypes, sig.Returns) {
+			return nil, fmt.Errorf("function manifest %s: function %q has unknown return type %q", path, sig.Name, sig.Returns)
+		}
+	}
+	return BuiltinFunctions().Extend(m.Functions), nil
+}
+
+// returnType returns the type descriptor of the function's result, or "" if
+// it is unknown.
+func (sig *FunctionSignature) returnType() string {
+	if sig == nil || sig.Returns == anyType {
+		return ""
+	}
+	return sig.Returns
+}
+
+// acceptsArgument reports whether a value of the known type actual may be
+// passed for a parameter of type param. Null is accepted for any parameter,
+// since optional values may be passed through, and an Integer for a Decimal
+// parameter, since it widens without loss.
+func acceptsArgument(param, actual string) bool {
+	return param == anyType || param == actual || actual == "Null" || param == "Decimal" && actual == "Integer"
+}
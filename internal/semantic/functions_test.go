@@ -0,0 +1,61 @@
+package semantic
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuiltinFunctions(t *testing.T) {
+	r := BuiltinFunctions()
+	if sig := r.Lookup("length"); sig == nil || len(sig.Parameters) != 1 || sig.Returns != "Integer" {
+		t.Errorf("unexpected length signature: %+v", sig)
+	}
+	if sig := r.Lookup("parse_mentions"); sig != nil {
+		t.Errorf("expected no signature for unregistered function, got %+v", sig)
+	}
+	var nilRegistry *FunctionRegistry
+	if nilRegistry.Lookup("now") == nil {
+		t.Error("nil registry should hold the built-ins")
+	}
+}
+
+func TestLoadFunctionManifest(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) string {
+		path := filepath.Join(dir, "functions.json")
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	r, err := LoadFunctionManifest(write(`{"functions": [
+  {"name": "parse_mentions", "parameters": ["String"], "returns": "Any"},
+  {"name": "hash", "parameters": ["String", "String"], "returns": "String"}
+]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Lookup("parse_mentions") == nil || r.Lookup("now") == nil {
+		t.Error("expected manifest functions alongside built-ins")
+	}
+	if sig := r.Lookup("hash"); len(sig.Parameters) != 2 {
+		t.Errorf("manifest should override built-in hash, got %+v", sig)
+	}
+	if sig := BuiltinFunctions().Lookup("hash"); len(sig.Parameters) != 1 {
+		t.Errorf("extending must not change the built-ins, got %+v", sig)
+	}
+
+	for _, content := range []string{
+		`{"functions": [{"name": "ParseMentions", "parameters": []}]}`,
+		`{"functions": [{"name": "f", "parameters": ["Text"]}]}`,
+		`{"functions": [{"name": "f", "parameters": [], "returns": "Set"}]}`,
+		`{"functions": [{"name": "f", "parameters": []}, {"name": "f", "parameters": ["String"]}]}`,
+		`{"functions": {}}`,
+	} {
+		if _, err := LoadFunctionManifest(write(content)); err == nil {
+			t.Errorf("expected error for manifest %s", content)
+		}
+	}
+}
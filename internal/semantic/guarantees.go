@@ -0,0 +1,84 @@
+package semantic
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/report"
+)
+
+// checkGuarantee checks RULE-50 for one guarantee of surface s: every rule
+// it names must be declared, and its expression may only read the surface's
+// facing, context and let bindings, given bindings and config, and members
+// their entities declare.
+func checkGuarantee(findings []report.Finding, spec *ast.Spec, st *SymbolTable, s ast.Surface, g ast.Guarantee, path string) []report.Finding {
+	for k, name := range g.Rules {
+		if st.LookupRule(name) == nil {
+			findings = append(findings, report.NewError(
+				"RULE-50",
+				fmt.Sprintf("Guarantee '%s' of surface '%s' names rule '%s', which is not declared%s", g.Name, s.Name, name, didYouMean(name, ruleNames(spec))),
+				report.Location{File: spec.File, Path: ast.IndexPath(path, "rules", k)},
+			))
+		}
+	}
+	if g.Expression == nil {
+		return findings
+	}
+
+	scope := collectSurfaceBindings(s)
+	for _, gb := range spec.Given {
+		scope[gb.Name] = true
+	}
+	scope["config"] = true
+	subject := fmt.Sprintf("Guarantee '%s' of surface '%s'", g.Name, s.Name)
+	unbound := func(name string) string {
+		return fmt.Sprintf("%s reads '%s', which is not bound in the surface%s", subject, name, didYouMean(name, slices.Sorted(maps.Keys(scope))))
+	}
+	return checkReadNames(findings, spec, st, "RULE-50", subject, g.Expression, scope, surfaceFieldTypes(s, spec, st), unbound, ast.NewPath(path+".expression"))
+}
+
+// ruleNames returns the names of the spec's rules.
+func ruleNames(spec *ast.Spec) []string {
+	names := make([]string, 0, len(spec.Rules))
+	for _, r := range spec.Rules {
+		names = append(names, r.Name)
+	}
+	return names
+}
+
+// GuaranteeCoverage describes whether the rules of a spec model one of its
+// surface guarantees.
+type GuaranteeCoverage struct {
+	Surface string `json:"surface"`
+	Name    string `json:"name"`
+
+	// Rules are the declared rules the guarantee names as keeping it. A
+	// guarantee without any is a promise the spec does not model.
+	Rules   []string `json:"rules"`
+	Covered bool     `json:"covered"`
+}
+
+// GuaranteeCoverages returns the coverage of every surface guarantee in
+// spec, in declaration order.
+func GuaranteeCoverages(spec *ast.Spec) []GuaranteeCoverage {
+	declared := make(map[string]bool, len(spec.Rules))
+	for _, r := range spec.Rules {
+		declared[r.Name] = true
+	}
+	var coverage []GuaranteeCoverage
+	for _, s := range spec.Surfaces {
+		for _, g := range s.Guarantees {
+			c := GuaranteeCoverage{Surface: s.Name, Name: g.Name, Rules: []string{}}
+			for _, name := range g.Rules {
+				if declared[name] && !slices.Contains(c.Rules, name) {
+					c.Rules = append(c.Rules, name)
+				}
+			}
+			c.Covered = len(c.Rules) > 0
+			coverage = append(coverage, c)
+		}
+	}
+	return coverage
+}
@@ -0,0 +1,92 @@
+package semantic
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/foundry-zero/allium/internal/ast"
+)
+
+func TestCheckSurfaces_RULE50_Guarantees(t *testing.T) {
+	order := func(fields ...string) *ast.Expression { return chain(append([]string{"order"}, fields...)...) }
+	allItems := &ast.Expression{Kind: "collection_op", Operation: "all", Collection: order("items"),
+		Lambda: &ast.Expression{Kind: "lambda", Parameter: "item", Body: comparisonExpr("!=", fieldAccess("item"), strLitExpr(""))}}
+
+	tests := []struct {
+		name    string
+		g       ast.Guarantee
+		path    string
+		message string
+	}{
+		{name: "descriptive", g: ast.Guarantee{Name: "Prompt", Description: "Orders are confirmed promptly"}},
+		{name: "declared rule and bound fields", g: ast.Guarantee{Name: "Known", Rules: []string{"SubmitOrder"},
+			Expression: comparisonExpr("!=", order("status"), strLitExpr(""))}},
+		{name: "lambda parameter", g: ast.Guarantee{Name: "Items", Expression: allItems}},
+		{
+			name:    "undeclared rule",
+			g:       ast.Guarantee{Name: "Known", Rules: []string{"SubmitOrder", "SubmitOrdr"}},
+			path:    "$.surfaces[0].guarantees[0].rules[1]",
+			message: "Guarantee 'Known' of surface 'OrderView' names rule 'SubmitOrdr', which is not declared (did you mean 'SubmitOrder'?)",
+		},
+		{
+			name:    "unbound name",
+			g:       ast.Guarantee{Name: "Known", Expression: chain("ordr", "status")},
+			path:    "$.surfaces[0].guarantees[0].expression.object",
+			message: "reads 'ordr', which is not bound in the surface (did you mean 'order'?)",
+		},
+		{
+			name:    "undeclared member",
+			g:       ast.Guarantee{Name: "Known", Expression: comparisonExpr("=", order("state"), strLitExpr("open"))},
+			path:    "$.surfaces[0].guarantees[0].expression.left",
+			message: "reads 'order.state', but 'Order' has no member 'state' (did you mean 'status'?)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := surfaceSpec()
+			spec.Surfaces[0].Guarantees = []ast.Guarantee{tt.g}
+			findings := findingsWithRule(CheckSurfaces(spec, BuildSymbolTable(spec)), "RULE-50")
+			if tt.message == "" {
+				if len(findings) != 0 {
+					t.Errorf("expected no RULE-50, got %v", findings)
+				}
+				return
+			}
+			if len(findings) != 1 {
+				t.Fatalf("expected 1 RULE-50, got %v", findings)
+			}
+			if findings[0].Location.Path != tt.path {
+				t.Errorf("path = %q, want %q", findings[0].Location.Path, tt.path)
+			}
+			if !strings.Contains(findings[0].Message, tt.message) {
+				t.Errorf("message %q does not contain %q", findings[0].Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestGuaranteeCoverages(t *testing.T) {
+	spec := surfaceSpec()
+	spec.Surfaces[0].Guarantees = []ast.Guarantee{
+		{Name: "Submitted", Rules: []string{"SubmitOrder", "SubmitOrder"}},
+		{Name: "Prompt", Description: "Orders are confirmed promptly"},
+		{Name: "Refunded", Rules: []string{"RefundOrder"}},
+	}
+	got := GuaranteeCoverages(spec)
+	want := []GuaranteeCoverage{
+		{Surface: "OrderView", Name: "Submitted", Rules: []string{"SubmitOrder"}, Covered: true},
+		{Surface: "OrderView", Name: "Prompt", Rules: []string{}},
+		// An undeclared rule (RULE-50) does not keep the guarantee.
+		{Surface: "OrderView", Name: "Refunded", Rules: []string{}},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v", got)
+	}
+	for i := range want {
+		if got[i].Surface != want[i].Surface || got[i].Name != want[i].Name || !slices.Equal(got[i].Rules, want[i].Rules) || got[i].Covered != want[i].Covered {
+			t.Errorf("coverage[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package semantic
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/report"
+	"github.com/foundry-zero/allium/internal/semver"
+)
+
+// CheckMetadata validates the spec's own metadata.
+//
+//   - RULE-60: metadata.spec_version must be a semantic version, authors must
+//     be non-empty and listed once, reviewed_at must be a date or RFC 3339
+//     timestamp, and sensitive_fields must mark declared members and allow
+//     declared actors
+func CheckMetadata(spec *ast.Spec, st *SymbolTable) []report.Finding {
+	var findings []report.Finding
+	md := spec.Metadata
+
+	if md.SpecVersion != "" {
+		if _, err := semver.Parse(md.SpecVersion); err != nil {
+			f := report.NewError(
+				"RULE-60",
+				fmt.Sprintf("Spec version '%s' is not a semantic version (MAJOR.MINOR.PATCH, e.g. 1.4.0)", md.SpecVersion),
+				report.Location{File: spec.File, Path: "$.metadata.spec_version"},
+			)
+			if v := semver.Normalize(md.SpecVersion); v != "" {
+				f = f.WithSuggestion(fmt.Sprintf("Write the version as '%s'", v), report.ReplaceEdit("$.metadata.spec_version", v))
+			}
+			findings = append(findings, f)
+		}
+	}
+
+	seen := make(map[string]bool, len(md.Authors))
+	for i, author := range md.Authors {
+		path := fmt.Sprintf("$.metadata.authors[%d]", i)
+		switch {
+		case author == "":
+			findings = append(findings, report.NewError(
+				"RULE-60",
+				"Author is empty",
+				report.Location{File: spec.File, Path: path},
+			).WithSuggestion("Remove the empty author", report.RemoveEdit(path)))
+		case seen[author]:
+			findings = append(findings, report.NewError(
+				"RULE-60",
+				fmt.Sprintf("Author '%s' is listed more than once", author),
+				report.Location{File: spec.File, Path: path},
+			))
+		}
+		seen[author] = true
+	}
+
+	if md.ReviewedAt != "" && !isReviewDate(md.ReviewedAt) {
+		findings = append(findings, report.NewError(
+			"RULE-60",
+			fmt.Sprintf("Review date '%s' is not a date (YYYY-MM-DD) or RFC 3339 timestamp", md.ReviewedAt),
+			report.Location{File: spec.File, Path: "$.metadata.reviewed_at"},
+		))
+	}
+
+	for _, typeName := range slices.Sorted(maps.Keys(md.SensitiveFields)) {
+		members := md.SensitiveFields[typeName]
+		for _, name := range slices.Sorted(maps.Keys(members)) {
+			path := "$.metadata.sensitive_fields." + typeName + "." + name
+			if !slices.ContainsFunc(typeMembers(st, typeName), func(m typeMember) bool { return m.name == name }) {
+				findings = append(findings, report.NewError(
+					"RULE-60",
+					fmt.Sprintf("Sensitive field '%s.%s' is not a declared field, relationship or derived value", typeName, name),
+					report.Location{File: spec.File, Path: path},
+				))
+				continue
+			}
+			for i, actor := range members[name] {
+				if st.LookupActor(actor) == nil && !st.LookupAnyEntity(actor) {
+					findings = append(findings, report.NewError(
+						"RULE-60",
+						fmt.Sprintf("Sensitive field '%s.%s' allows undeclared actor '%s'", typeName, name, actor),
+						report.Location{File: spec.File, Path: fmt.Sprintf("%s[%d]", path, i)},
+					))
+				}
+			}
+		}
+	}
+
+	return findings
+}
+
+// isReviewDate reports whether s is a calendar date or an RFC 3339
+// timestamp.
+func isReviewDate(s string) bool {
+	if _, err := time.Parse(time.DateOnly, s); err == nil {
+		return true
+	}
+	_, err := time.Parse(time.RFC3339, s)
+	return err == nil
+}
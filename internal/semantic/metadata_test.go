@@ -0,0 +1,70 @@
+package semantic
+
+import (
+	"testing"
+
+	"github.com/foundry-zero/allium/internal/ast"
+)
+
+func TestCheckMetadata_Valid(t *testing.T) {
+	for _, md := range []ast.Metadata{
+		{},
+		{SpecVersion: "1.4.0", Authors: []string{"ana", "ben"}, ReviewedAt: "2026-03-01"},
+		{SpecVersion: "2.0.0-rc.1+build.5", ReviewedAt: "2026-03-01T09:30:00Z"},
+	} {
+		spec := &ast.Spec{File: "test.allium", Metadata: md}
+		if findings := CheckMetadata(spec, BuildSymbolTable(spec)); len(findings) != 0 {
+			t.Errorf("%+v: expected no findings, got %v", md, findings)
+		}
+	}
+}
+
+func TestCheckMetadata_Invalid(t *testing.T) {
+	spec := &ast.Spec{File: "test.allium", Metadata: ast.Metadata{
+		SpecVersion: "v1.4",
+		Authors:     []string{"ana", "", "ana"},
+		ReviewedAt:  "01/03/2026",
+	}}
+	findings := CheckMetadata(spec, BuildSymbolTable(spec))
+	want := []struct{ path, message string }{
+		{"$.metadata.spec_version", "Spec version 'v1.4' is not a semantic version (MAJOR.MINOR.PATCH, e.g. 1.4.0)"},
+		{"$.metadata.authors[1]", "Author is empty"},
+		{"$.metadata.authors[2]", "Author 'ana' is listed more than once"},
+		{"$.metadata.reviewed_at", "Review date '01/03/2026' is not a date (YYYY-MM-DD) or RFC 3339 timestamp"},
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("expected %d findings, got %v", len(want), findings)
+	}
+	for i, w := range want {
+		if f := findings[i]; f.Rule != "RULE-60" || f.Location.Path != w.path || f.Message != w.message {
+			t.Errorf("finding %d = %s %s %q, want RULE-60 %s %q", i, f.Rule, f.Location.Path, f.Message, w.path, w.message)
+		}
+	}
+	if s := findings[0].Suggestions; len(s) != 1 || string(s[0].Edits[0].Value) != `"1.4.0"` {
+		t.Errorf("expected a suggestion to write 1.4.0, got %+v", s)
+	}
+	if s := findings[1].Suggestions; len(s) != 1 || s[0].Edits[0].Op != "remove" {
+		t.Errorf("expected a suggestion to remove the empty author, got %+v", s)
+	}
+}
+
+func TestCheckMetadata_SensitiveFields(t *testing.T) {
+	spec := sensitiveSpec()
+	spec.Metadata.SensitiveFields = map[string]map[string][]string{
+		"User":    {"password_hash": {"Admin", "Admn"}, "password_strength": {}, "pasword": {}},
+		"Session": {"owner": {"User"}},
+	}
+	findings := CheckMetadata(spec, BuildSymbolTable(spec))
+	want := []struct{ path, message string }{
+		{"$.metadata.sensitive_fields.User.password_hash[1]", "Sensitive field 'User.password_hash' allows undeclared actor 'Admn'"},
+		{"$.metadata.sensitive_fields.User.pasword", "Sensitive field 'User.pasword' is not a declared field, relationship or derived value"},
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("expected %d findings, got %v", len(want), findings)
+	}
+	for i, w := range want {
+		if f := findings[i]; f.Rule != "RULE-60" || f.Location.Path != w.path || f.Message != w.message {
+			t.Errorf("finding %d = %s %s %q, want RULE-60 %s %q", i, f.Rule, f.Location.Path, f.Message, w.path, w.message)
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package semantic
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/report"
+)
+
+// NamingConventions are the naming conventions a project follows, as set in
+// its configuration. Each is checked only when set.
+type NamingConventions struct {
+	Fields            string // case style of entity, external entity, variant and value type fields
+	EnumValues        string // case style of enumeration and inline enum values
+	Triggers          string // "verb_noun", or "" for no convention
+	SurfaceSuffix     string // suffix every surface name ends with
+	NoEntityShadowing bool   // bindings must not be named after a declared type
+}
+
+// casePatterns matches names in each case style of NamingConventions.
+var casePatterns = map[string]*regexp.Regexp{
+	"snake_case": regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`),
+	"camelCase":  regexp.MustCompile(`^[a-z][a-zA-Z0-9]*$`),
+	"PascalCase": regexp.MustCompile(`^[A-Z][a-zA-Z0-9]*$`),
+}
+
+// WARN-28: A name departs from the project's naming conventions.
+func checkWarn28NamingConventions(findings []report.Finding, spec *ast.Spec, st *SymbolTable) []report.Finding {
+	n := st.Naming
+	if n == nil {
+		return findings
+	}
+	warn := func(message, path string) {
+		findings = append(findings, report.NewWarning("WARN-28", message, report.Location{File: spec.File, Path: path}))
+	}
+
+	// fieldsOf calls fn for the fields of every entity, external entity,
+	// variant and value type, with the owner's description and path.
+	fieldsOf := func(fn func(owner string, fields []ast.Field, path string)) {
+		for i, e := range spec.Entities {
+			fn(fmt.Sprintf("entity '%s'", e.Name), e.Fields, fmt.Sprintf("$.entities[%d]", i))
+		}
+		for i, e := range spec.ExternalEntities {
+			fn(fmt.Sprintf("external entity '%s'", e.Name), e.Fields, fmt.Sprintf("$.external_entities[%d]", i))
+		}
+		for i, v := range spec.Variants {
+			fn(fmt.Sprintf("variant '%s'", v.Name), v.Fields, fmt.Sprintf("$.variants[%d]", i))
+		}
+		for i, v := range spec.ValueTypes {
+			fn(fmt.Sprintf("value type '%s'", v.Name), v.Fields, fmt.Sprintf("$.value_types[%d]", i))
+		}
+	}
+
+	if pattern := casePatterns[n.Fields]; pattern != nil {
+		fieldsOf(func(owner string, fields []ast.Field, path string) {
+			for j, f := range fields {
+				if !pattern.MatchString(f.Name) {
+					warn(fmt.Sprintf("Field '%s' of %s is not %s", f.Name, owner, n.Fields), fmt.Sprintf("%s.fields[%d].name", path, j))
+				}
+			}
+		})
+	}
+
+	if pattern := casePatterns[n.EnumValues]; pattern != nil {
+		for i, e := range spec.Enumerations {
+			for j, v := range e.Values {
+				if !pattern.MatchString(v) {
+					warn(fmt.Sprintf("Value '%s' of enumeration '%s' is not %s", v, e.Name, n.EnumValues), fmt.Sprintf("$.enumerations[%d].values[%d]", i, j))
+				}
+			}
+		}
+		fieldsOf(func(owner string, fields []ast.Field, path string) {
+			for j, f := range fields {
+				ft, typePath := f.Type, fmt.Sprintf("%s.fields[%d].type", path, j)
+				if ft.Kind == "optional" && ft.Inner != nil {
+					ft, typePath = *ft.Inner, typePath+".inner"
+				}
+				if ft.Kind != "inline_enum" {
+					continue
+				}
+				for k, v := range ft.Values {
+					if !pattern.MatchString(v) {
+						warn(fmt.Sprintf("Value '%s' of field '%s' of %s is not %s", v, f.Name, owner, n.EnumValues), fmt.Sprintf("%s.values[%d]", typePath, k))
+					}
+				}
+			}
+		})
+	}
+
+	if n.Triggers == "verb_noun" {
+		seen := make(map[string]bool)
+		for i, r := range spec.Rules {
+			t := r.Trigger
+			if t.Kind != "external_stimulus" && t.Kind != "chained" || t.Name == "" || seen[t.Name] {
+				continue
+			}
+			seen[t.Name] = true
+			if reason := verbNounViolation(st, t.Name); reason != "" {
+				warn(fmt.Sprintf("Trigger '%s' of rule '%s' is not verb_noun: %s", t.Name, r.Name, reason), fmt.Sprintf("$.rules[%d].trigger.name", i))
+			}
+		}
+	}
+
+	if suffix := n.SurfaceSuffix; suffix != "" {
+		for i, s := range spec.Surfaces {
+			if !strings.HasSuffix(s.Name, suffix) || s.Name == suffix {
+				warn(fmt.Sprintf("Surface '%s' does not end in '%s'", s.Name, suffix), fmt.Sprintf("$.surfaces[%d].name", i))
+			}
+		}
+	}
+
+	if n.NoEntityShadowing {
+		for _, b := range declaredBindings(spec) {
+			if kind := typeDeclarationKind(st, b.name); kind != "" {
+				warn(fmt.Sprintf("%s '%s' has the name of %s", b.kind, b.name, kind), b.path)
+			}
+		}
+	}
+	return findings
+}
+
+// verbNounViolation returns why a trigger name does not start with a verb,
+// or "" if it may. A name of a single word has no noun, and a name starting
+// with the name of a declared type, as in UserLogsIn, starts with its
+// subject. Other first words are taken to be verbs.
+func verbNounViolation(st *SymbolTable, name string) string {
+	words, sep := nameWords(name), ""
+	if strings.Contains(name, "_") {
+		sep = "_"
+	}
+	if len(words) < 2 {
+		return "it is a single word"
+	}
+	for k := len(words) - 1; k > 0; k-- {
+		subject := pascalCase(strings.Join(words[:k], "_"))
+		kind := typeDeclarationKind(st, subject)
+		if kind == "" && st.LookupActor(subject) != nil {
+			kind = "an actor"
+		}
+		if kind != "" {
+			return fmt.Sprintf("it starts with '%s', which names %s", strings.Join(words[:k], sep), kind)
+		}
+	}
+	return ""
+}
+
+// nameWords splits a snake_case, camelCase or PascalCase name into words.
+func nameWords(name string) []string {
+	if strings.Contains(name, "_") {
+		return strings.FieldsFunc(name, func(r rune) bool { return r == '_' })
+	}
+	var words []string
+	start := 0
+	runes := []rune(name)
+	for i := 1; i < len(runes); i++ {
+		if unicode.IsUpper(runes[i]) && !unicode.IsUpper(runes[i-1]) {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
+
+// declaredBinding is a name a spec binds, with its kind for messages, as in
+// "Given binding", and the path of the name.
+type declaredBinding struct {
+	name, kind, path string
+}
+
+// declaredBindings returns the bindings declared by given, rules and
+// surfaces, in document order.
+func declaredBindings(spec *ast.Spec) []declaredBinding {
+	var bindings []declaredBinding
+	add := func(name, kind, path string) {
+		if name != "" {
+			bindings = append(bindings, declaredBinding{name, kind, path})
+		}
+	}
+	for i, g := range spec.Given {
+		add(g.Name, "Given binding", fmt.Sprintf("$.given[%d].name", i))
+	}
+	for i, r := range spec.Rules {
+		path := fmt.Sprintf("$.rules[%d]", i)
+		add(r.Trigger.Binding, "Trigger binding", path+".trigger.binding")
+		for j, p := range r.Trigger.Parameters {
+			add(p.Name, "Trigger parameter", fmt.Sprintf("%s.trigger.parameters[%d].name", path, j))
+		}
+		if r.ForClause != nil {
+			add(r.ForClause.Binding, "For binding", path+".for_clause.binding")
+		}
+		for j, lb := range r.LetBindings {
+			add(lb.Name, "Let binding", fmt.Sprintf("%s.let_bindings[%d].name", path, j))
+		}
+	}
+	for i, s := range spec.Surfaces {
+		path := fmt.Sprintf("$.surfaces[%d]", i)
+		add(s.Facing.Binding, "Facing binding", path+".facing.binding")
+		if s.Context != nil {
+			add(s.Context.Binding, "Context binding", path+".context.binding")
+		}
+		for j, lb := range s.LetBindings {
+			add(lb.Name, "Let binding", fmt.Sprintf("%s.let_bindings[%d].name", path, j))
+		}
+	}
+	return bindings
+}
@@ -0,0 +1,128 @@
+package semantic
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/foundry-zero/allium/internal/ast"
+)
+
+// namingSpec returns a spec with a name in every position WARN-28 checks.
+func namingSpec() *ast.Spec {
+	str := ast.FieldType{Kind: "primitive", Value: "String"}
+	return &ast.Spec{
+		File: "test.allium.json",
+		Entities: []ast.Entity{{Name: "User", Fields: []ast.Field{
+			{Name: "email", Type: str},
+			{Name: "createdAt", Type: str},
+			{Name: "status", Type: ast.FieldType{Kind: "optional", Inner: &ast.FieldType{Kind: "inline_enum", Values: []string{"active", "OnHold"}}}},
+		}}},
+		Enumerations: []ast.Enumeration{{Name: "Plan", Values: []string{"free", "pro-plus"}}},
+		Actors:       []ast.Actor{{Name: "Admin", IdentifiedBy: ast.IdentifiedBy{Entity: "User"}}},
+		Given:        []ast.GivenBinding{{Name: "User", Type: ast.FieldType{Kind: "entity_ref", Entity: "User"}}},
+		Rules: []ast.Rule{
+			{Name: "Register", Trigger: ast.Trigger{Kind: "external_stimulus", Name: "RegisterUser", Parameters: []ast.TriggerParam{{Name: "email"}}}},
+			{Name: "LogIn", Trigger: ast.Trigger{Kind: "external_stimulus", Name: "UserLogsIn"}},
+			{Name: "LogInAgain", Trigger: ast.Trigger{Kind: "external_stimulus", Name: "UserLogsIn"}},
+			{Name: "Ban", Trigger: ast.Trigger{Kind: "chained", Name: "admin_bans_user"}},
+			{Name: "Ping", Trigger: ast.Trigger{Kind: "external_stimulus", Name: "Ping"}},
+			{Name: "Expire", Trigger: ast.Trigger{Kind: "temporal", Binding: "user", Entity: "User"}},
+		},
+		Surfaces: []ast.Surface{
+			{Name: "ProfileView", Facing: ast.FacingClause{Binding: "viewer", Type: "Admin"}},
+			{Name: "Dashboard", Facing: ast.FacingClause{Binding: "viewer", Type: "Admin"}, Context: &ast.ContextClause{Binding: "User", Type: "User"}},
+		},
+	}
+}
+
+func TestCheckWarnings_WARN28_NamingConventions(t *testing.T) {
+	tests := []struct {
+		name     string
+		naming   NamingConventions
+		paths    []string
+		messages []string
+	}{
+		{
+			name:     "field case",
+			naming:   NamingConventions{Fields: "snake_case"},
+			paths:    []string{"$.entities[0].fields[1].name"},
+			messages: []string{"Field 'createdAt' of entity 'User' is not snake_case"},
+		},
+		{
+			name:     "camel case fields",
+			naming:   NamingConventions{Fields: "camelCase"},
+			paths:    nil,
+			messages: nil,
+		},
+		{
+			name:   "enum value case",
+			naming: NamingConventions{EnumValues: "snake_case"},
+			paths:  []string{"$.enumerations[0].values[1]", "$.entities[0].fields[2].type.inner.values[1]"},
+			messages: []string{"Value 'pro-plus' of enumeration 'Plan' is not snake_case",
+				"Value 'OnHold' of field 'status' of entity 'User' is not snake_case"},
+		},
+		{
+			name:   "verb_noun triggers",
+			naming: NamingConventions{Triggers: "verb_noun"},
+			paths:  []string{"$.rules[1].trigger.name", "$.rules[3].trigger.name", "$.rules[4].trigger.name"},
+			messages: []string{"Trigger 'UserLogsIn' of rule 'LogIn' is not verb_noun: it starts with 'User', which names an entity",
+				"it starts with 'admin', which names an actor",
+				"Trigger 'Ping' of rule 'Ping' is not verb_noun: it is a single word"},
+		},
+		{
+			name:     "surface suffix",
+			naming:   NamingConventions{SurfaceSuffix: "View"},
+			paths:    []string{"$.surfaces[1].name"},
+			messages: []string{"Surface 'Dashboard' does not end in 'View'"},
+		},
+		{
+			name:     "entity shadowing",
+			naming:   NamingConventions{NoEntityShadowing: true},
+			paths:    []string{"$.given[0].name", "$.surfaces[1].context.binding"},
+			messages: []string{"Given binding 'User' has the name of an entity", "Context binding 'User' has the name of an entity"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := namingSpec()
+			st := BuildSymbolTable(spec)
+			st.Naming = &tt.naming
+			findings := warnFindings(CheckWarnings(spec, st), "WARN-28")
+			if len(findings) != len(tt.paths) {
+				t.Fatalf("expected %d WARN-28 findings, got %v", len(tt.paths), findings)
+			}
+			for i, f := range findings {
+				if f.Location.Path != tt.paths[i] {
+					t.Errorf("path = %q, want %q", f.Location.Path, tt.paths[i])
+				}
+				if !strings.Contains(f.Message, tt.messages[i]) {
+					t.Errorf("message %q does not contain %q", f.Message, tt.messages[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCheckWarnings_WARN28_Unconfigured(t *testing.T) {
+	spec := namingSpec()
+	if findings := warnFindings(CheckWarnings(spec, BuildSymbolTable(spec)), "WARN-28"); len(findings) != 0 {
+		t.Errorf("expected no naming conventions checked by default, got %v", findings)
+	}
+}
+
+func TestNameWords(t *testing.T) {
+	for name, want := range map[string][]string{
+		"UserLogsIn":      {"User", "Logs", "In"},
+		"submitOrder":     {"submit", "Order"},
+		"submit_order":    {"submit", "order"},
+		"AddTrustedIP":    {"Add", "Trusted", "IP"},
+		"Ping":            {"Ping"},
+		"__private_name_": {"private", "name"},
+	} {
+		if got := nameWords(name); !slices.Equal(got, want) {
+			t.Errorf("nameWords(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
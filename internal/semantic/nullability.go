@@ -0,0 +1,452 @@
+package semantic
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/report"
+)
+
+// CheckNullability tracks optional values through the expressions of derived
+// values, rules and surfaces.
+//
+//   - RULE-55: A member of an optional value, or an optional trigger
+//     parameter, may only be read where the value is known not to be null:
+//     after a null check (`exists x`, `x != null`) in an enclosing and, or,
+//     requires clause, for clause, conditional or when condition, or within
+//     a null test or the left of a null_coalesce. An optional parameter may
+//     also be passed as it is to an optional field or chained trigger
+//     parameter
+//   - RULE-56: A value that is not optional, nor read through an optional
+//     value, must not be compared with null; the comparison never changes
+func CheckNullability(spec *ast.Spec, st *SymbolTable) []report.Finding {
+	c := &nullChecker{spec: spec, st: st}
+
+	for i, entity := range spec.Entities {
+		c.related = make(map[string]bool)
+		for _, rel := range entity.Relationships {
+			c.related[rel.Name] = true
+		}
+		for j, dv := range entity.DerivedValues {
+			c.expr(dv.Expression, ast.NewPath(fmt.Sprintf("$.entities[%d].derived_values[%d].expression", i, j)), derivedFieldTypes(entity.Name, dv, spec, st), nil, nil)
+		}
+	}
+	c.related = nil
+
+	for i, rule := range spec.Rules {
+		c.rule(rule, fmt.Sprintf("$.rules[%d]", i))
+	}
+
+	for i, s := range spec.Surfaces {
+		c.surface(s, fmt.Sprintf("$.surfaces[%d]", i))
+	}
+
+	return c.findings
+}
+
+// nonNull is the set of field paths, such as "user.manager", known not to be
+// null at a point of an expression.
+type nonNull map[string]bool
+
+// assuming returns the paths known not to be null once cond has the given
+// truth value, in addition to those of n: a path checked with exists or
+// compared with null, or equated with a literal, and, when it is, every
+// path it reads through. The conjuncts of a true and and of a false or are
+// all assumed.
+func (n nonNull) assuming(cond *ast.Expression, truth bool) nonNull {
+	out := maps.Clone(n)
+	if out == nil {
+		out = make(nonNull)
+	}
+	var add func(e *ast.Expression, truth bool)
+	add = func(e *ast.Expression, truth bool) {
+		if e == nil {
+			return
+		}
+		switch e.Kind {
+		case "not":
+			add(e.Operand, !truth)
+		case "boolean_logic":
+			if e.Operator == "and" && truth || e.Operator == "or" && !truth {
+				add(e.Left, truth)
+				add(e.Right, truth)
+			}
+		case "exists":
+			if truth {
+				out.addPath(e.Target)
+			}
+		case "comparison":
+			side, other := e.Left, e.Right
+			if isNullLiteral(side) {
+				side, other = other, side
+			}
+			switch {
+			case isNullLiteral(other):
+				if truth == (e.Operator == "!=") {
+					out.addPath(side)
+				}
+			case truth && e.Operator == "=" && other != nil && other.Kind == "literal":
+				out.addPath(side)
+			}
+		}
+	}
+	add(cond, truth)
+	return out
+}
+
+// addPath records a field access chain and every chain it reads through as
+// not null.
+func (n nonNull) addPath(e *ast.Expression) {
+	for ; e != nil && e.Kind == "field_access"; e = e.Object {
+		if p := exprPath(e); p != "" {
+			n[p] = true
+		}
+	}
+}
+
+// without returns n less the paths rooted at name, for a binding that hides
+// an outer one.
+func (n nonNull) without(name string) nonNull {
+	out := maps.Clone(n)
+	maps.DeleteFunc(out, func(p string, _ bool) bool {
+		return p == name || strings.HasPrefix(p, name+".")
+	})
+	return out
+}
+
+// isNullLiteral reports whether e is the null literal.
+func isNullLiteral(e *ast.Expression) bool {
+	return e != nil && e.Kind == "literal" && e.Type == "null"
+}
+
+// nullChecker accumulates RULE-55 and RULE-56 findings for a spec.
+type nullChecker struct {
+	spec     *ast.Spec
+	st       *SymbolTable
+	related  map[string]bool // relationships read by bare name, in derived values
+	trigger  string          // the trigger of the rule being checked
+	findings []report.Finding
+}
+
+// rule checks the expressions of a rule. Let bindings are typed as
+// resolveNullableType infers them, so one bound to a chain through an
+// optional value is itself optional. The for clause condition and each
+// requires clause hold for the clauses after them and for the ensures.
+func (c *nullChecker) rule(rule ast.Rule, path string) {
+	c.trigger = rule.Trigger.Name
+	params := optionalParams(rule)
+	types := ruleFieldTypes(rule, c.spec, c.st)
+	for _, lb := range rule.LetBindings {
+		if ft := resolveNullableType(lb.Expression, types, c.st); ft != nil {
+			types[lb.Name] = ft
+		}
+	}
+
+	var safe nonNull
+	if fc := rule.ForClause; fc != nil {
+		c.expr(fc.Collection, ast.NewPath(path+".for_clause.collection"), types, safe, params)
+		c.expr(fc.Condition, ast.NewPath(path+".for_clause.condition"), types, safe, params)
+		safe = safe.assuming(fc.Condition, true)
+	}
+	for j, lb := range rule.LetBindings {
+		c.expr(lb.Expression, ast.NewPath(ast.IndexPath(path, "let_bindings", j)+".expression"), types, safe, params)
+	}
+	for j := range rule.Requires {
+		c.expr(&rule.Requires[j], ast.NewPath(ast.IndexPath(path, "requires", j)), types, safe, params)
+		safe = safe.assuming(&rule.Requires[j], true)
+	}
+	for j, ec := range rule.Ensures {
+		c.ensures(ec, ast.IndexPath(path, "ensures", j), types, safe, params)
+	}
+}
+
+// optionalParams returns the optional parameters of a rule's trigger that
+// its for clause and let bindings do not rebind.
+func optionalParams(rule ast.Rule) map[string]bool {
+	if rule.Trigger.Kind != "external_stimulus" && rule.Trigger.Kind != "chained" {
+		return nil
+	}
+	params := make(map[string]bool)
+	for _, p := range rule.Trigger.Parameters {
+		if p.Optional {
+			params[p.Name] = true
+		}
+	}
+	if rule.ForClause != nil {
+		delete(params, rule.ForClause.Binding)
+	}
+	for _, lb := range rule.LetBindings {
+		delete(params, lb.Name)
+	}
+	return params
+}
+
+// ensures checks an ensures clause and the clauses nested in it. A
+// conditional's condition holds in its then clauses and fails in its else
+// clauses; iteration and let bindings are typed for their body, and hide an
+// optional parameter of the same name there. params holds the optional
+// trigger parameters in scope.
+func (c *nullChecker) ensures(ec ast.EnsuresClause, path string, types map[string]*ast.FieldType, safe nonNull, params map[string]bool) {
+	for _, e := range ec.Expressions(path) {
+		if !c.passesParam(ec, e, path, types, params) {
+			c.expr(e.Expr, ast.NewPath(e.Path), types, safe, params)
+		}
+	}
+	switch ec.Kind {
+	case "conditional":
+		for j, then := range ec.Then {
+			c.ensures(then, ast.IndexPath(path, "then", j), types, safe.assuming(ec.Condition, true), params)
+		}
+		for j, el := range ec.Else {
+			c.ensures(el, ast.IndexPath(path, "else", j), types, safe.assuming(ec.Condition, false), params)
+		}
+		return
+	case "iteration":
+		var element *ast.FieldType
+		if ct := resolveFieldAccessType(ec.Collection, types, c.st); ct != nil && (ct.Kind == "set" || ct.Kind == "list") {
+			element = ct.Element
+		}
+		types, safe, params = withBinding(types, ec.Binding, element), safe.without(ec.Binding), withoutParam(params, ec.Binding)
+	case "let_binding":
+		var ft *ast.FieldType
+		var value ast.Expression
+		if json.Unmarshal(ec.Value, &value) == nil && value.Kind != "" && value.Kind != "entity_creation" {
+			ft = resolveNullableType(&value, types, c.st)
+		} else {
+			ft = letValueType(ec.Value, types, c.st)
+		}
+		types, safe, params = withBinding(types, ec.Name, ft), safe.without(ec.Name), withoutParam(params, ec.Name)
+	}
+	for j, body := range ec.Body {
+		c.ensures(body, ast.IndexPath(path, "body", j), types, safe, params)
+	}
+}
+
+// withoutParam returns params less name, as a copy when name is one of them,
+// so the set of an enclosing clause is left as it was.
+func withoutParam(params map[string]bool, name string) map[string]bool {
+	if !params[name] {
+		return params
+	}
+	out := maps.Clone(params)
+	delete(out, name)
+	return out
+}
+
+// passesParam reports whether e, an expression of ec, is an optional
+// parameter passed as it is to an optional field or to an optional
+// parameter of the chained trigger ec emits, where absence is allowed.
+func (c *nullChecker) passesParam(ec ast.EnsuresClause, e ast.ClauseExpression, path string, types map[string]*ast.FieldType, params map[string]bool) bool {
+	if e.Expr.Kind != "field_access" || e.Expr.Object != nil || !params[e.Expr.Field] {
+		return false
+	}
+	switch ec.Kind {
+	case "state_change":
+		if e.Path == path+".value" {
+			ft := resolveNullableType(ec.Target, types, c.st)
+			return ft != nil && ft.Kind == "optional"
+		}
+	case "entity_creation":
+		if field, ok := strings.CutPrefix(e.Path, path+".fields."); ok {
+			ft := memberType(c.st, ec.Entity, field)
+			return ft != nil && ft.Kind == "optional"
+		}
+	case "trigger_emission":
+		if arg, ok := strings.CutPrefix(e.Path, path+".arguments."); ok {
+			for _, r := range c.st.Triggers[ec.Name] {
+				if r.Trigger.Kind == "chained" {
+					return slices.ContainsFunc(r.Trigger.Parameters, func(p ast.TriggerParam) bool { return p.Name == arg && p.Optional })
+				}
+			}
+		}
+	}
+	return false
+}
+
+// surface checks the expressions of a surface. An exposed item, provided
+// action or related surface is checked assuming its when condition holds.
+func (c *nullChecker) surface(s ast.Surface, path string) {
+	types := surfaceFieldTypes(s, c.spec, c.st)
+	for _, lb := range s.LetBindings {
+		if ft := resolveNullableType(lb.Expression, types, c.st); ft != nil {
+			types[lb.Name] = ft
+		}
+	}
+
+	var safe nonNull
+	if s.Context != nil {
+		c.expr(s.Context.Condition, ast.NewPath(path+".context.condition"), types, nil, nil)
+		safe = safe.assuming(s.Context.Condition, true)
+	}
+	for j, lb := range s.LetBindings {
+		c.expr(lb.Expression, ast.NewPath(ast.IndexPath(path, "let_bindings", j)+".expression"), types, safe, nil)
+	}
+	for j, ex := range s.Exposes {
+		c.expr(ex.When, ast.NewPath(ast.IndexPath(path, "exposes", j)+".when"), types, safe, nil)
+		c.expr(ex.Expression, ast.NewPath(ast.IndexPath(path, "exposes", j)+".expression"), types, safe.assuming(ex.When, true), nil)
+	}
+	for j, p := range s.Provides {
+		c.provides(p, ast.IndexPath(path, "provides", j), types, safe)
+	}
+	for j, rel := range s.Related {
+		c.expr(rel.When, ast.NewPath(ast.IndexPath(path, "related", j)+".when"), types, safe, nil)
+		c.expr(rel.ContextExpression, ast.NewPath(ast.IndexPath(path, "related", j)+".context_expression"), types, safe.assuming(rel.When, true), nil)
+	}
+	for j, to := range s.Timeout {
+		c.expr(to.When, ast.NewPath(ast.IndexPath(path, "timeout", j)+".when"), types, safe, nil)
+	}
+	for j, g := range s.Guarantees {
+		c.expr(g.Expression, ast.NewPath(ast.IndexPath(path, "guarantees", j)+".expression"), types, safe, nil)
+	}
+}
+
+// provides checks a provided action, or a for_each item and the items nested
+// in it with the iteration binding typed as an element of the collection.
+func (c *nullChecker) provides(p ast.ProvidesItem, path string, types map[string]*ast.FieldType, safe nonNull) {
+	c.expr(p.When, ast.NewPath(path+".when"), types, safe, nil)
+	safe = safe.assuming(p.When, true)
+	for k, arg := range p.Arguments {
+		c.expr(arg.Expression, ast.NewPath(ast.IndexPath(path, "arguments", k)+".expression"), types, safe, nil)
+	}
+	c.expr(p.Collection, ast.NewPath(path+".collection"), types, safe, nil)
+	if len(p.Items) == 0 {
+		return
+	}
+	if p.Binding != "" {
+		var element *ast.FieldType
+		if ct := resolveFieldAccessType(p.Collection, types, c.st); ct != nil && (ct.Kind == "set" || ct.Kind == "list") {
+			element = ct.Element
+		}
+		types, safe = withBinding(types, p.Binding, element), safe.without(p.Binding)
+	}
+	for k, item := range p.Items {
+		c.provides(item, ast.IndexPath(path, "items", k), types, safe)
+	}
+}
+
+// expr checks an expression and every expression below it. The right of an
+// and is checked assuming its left holds, and the right of an or assuming
+// its left fails. Field access chains under a null test, the target of
+// exists, a side compared with null or the left of a null_coalesce, are
+// not reported by RULE-55, since they test whether the value is there.
+func (c *nullChecker) expr(expr *ast.Expression, path *ast.Path, types map[string]*ast.FieldType, safe nonNull, params map[string]bool) {
+	ast.WalkExpression(expr, path, func(e *ast.Expression, path *ast.Path) bool {
+		switch e.Kind {
+		case "field_access":
+			if c.absentParam(e, path, safe, params) {
+				return false
+			}
+			c.deref(e, path, types, safe)
+		case "boolean_logic":
+			c.expr(e.Left, path.Field("left"), types, safe, params)
+			c.expr(e.Right, path.Field("right"), types, safe.assuming(e.Left, e.Operator == "and"), params)
+			return false
+		case "exists":
+			c.tested(e.Target, path.Field("target"), types, safe, params)
+			return false
+		case "null_coalesce":
+			c.tested(e.Left, path.Field("left"), types, safe, params)
+			c.expr(e.Right, path.Field("right"), types, safe, params)
+			return false
+		case "comparison":
+			switch {
+			case isNullLiteral(e.Right):
+				c.nullComparison(e, e.Left, path, types)
+				c.tested(e.Left, path.Field("left"), types, safe, params)
+				return false
+			case isNullLiteral(e.Left):
+				c.nullComparison(e, e.Right, path, types)
+				c.tested(e.Right, path.Field("right"), types, safe, params)
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// tested checks an expression whose null test is the point: a field access
+// chain is not reported, and anything else is checked as usual.
+func (c *nullChecker) tested(e *ast.Expression, path *ast.Path, types map[string]*ast.FieldType, safe nonNull, params map[string]bool) {
+	if exprPath(e) != "" {
+		return
+	}
+	c.expr(e, path, types, safe, params)
+}
+
+// absentParam reports a field access chain rooted at an optional trigger
+// parameter not known to be present, and reports whether it did.
+func (c *nullChecker) absentParam(e *ast.Expression, path *ast.Path, safe nonNull, params map[string]bool) bool {
+	chain := exprPath(e)
+	param, _, _ := strings.Cut(chain, ".")
+	if chain == "" || !params[param] || safe[param] {
+		return false
+	}
+	c.findings = append(c.findings, report.NewError(
+		"RULE-55",
+		fmt.Sprintf("'%s' reads optional parameter '%s' of trigger '%s' without checking that it is present; guard it with 'exists %s' or use ??", chain, param, c.trigger, param),
+		report.Location{File: c.spec.File, Path: path.String()},
+	))
+	return true
+}
+
+// deref reports a field access reading a member of a value declared
+// optional that is not known to be present.
+func (c *nullChecker) deref(e *ast.Expression, path *ast.Path, types map[string]*ast.FieldType, safe nonNull) {
+	if e.Object == nil {
+		return
+	}
+	obj := exprPath(e.Object)
+	if obj == "" || safe[obj] {
+		return
+	}
+	if ft := resolveFieldAccessType(e.Object, types, c.st); ft == nil || ft.Kind != "optional" {
+		return
+	}
+	c.findings = append(c.findings, report.NewError(
+		"RULE-55",
+		fmt.Sprintf("'%s' reads '%s', which is optional, without checking that it is present; guard it with 'exists %s' or use ??", exprPath(e), obj, obj),
+		report.Location{File: c.spec.File, Path: path.String()},
+	))
+}
+
+// nullComparison reports a comparison of side with null when side is a
+// declared field that is neither optional nor read through an optional
+// value. Relationships, whose targets may not exist, and values of unknown
+// type are not reported.
+func (c *nullChecker) nullComparison(cmp, side *ast.Expression, path *ast.Path, types map[string]*ast.FieldType) {
+	name := exprPath(side)
+	if name == "" || cmp.Operator != "=" && cmp.Operator != "!=" {
+		return
+	}
+	ft := resolveNullableType(side, types, c.st)
+	if ft == nil || ft.Kind == "optional" {
+		return
+	}
+	if side.Object == nil && c.related[name] {
+		return
+	}
+	if side.Object != nil {
+		objType := resolveFieldAccessType(side.Object, types, c.st)
+		for objType != nil && objType.Kind == "optional" {
+			objType = objType.Inner
+		}
+		if objType != nil && objType.Kind == "entity_ref" {
+			if m, ok := triggerEntityMembers(c.st, objType.Entity); ok && slices.Contains(m.related, side.Field) {
+				return
+			}
+		}
+	}
+	result := "false"
+	if cmp.Operator == "!=" {
+		result = "true"
+	}
+	c.findings = append(c.findings, report.NewError(
+		"RULE-56",
+		fmt.Sprintf("'%s' is not optional, so comparing it with null is always %s", name, result),
+		report.Location{File: c.spec.File, Path: path.String()},
+	))
+}
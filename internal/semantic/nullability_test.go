@@ -0,0 +1,243 @@
+package semantic
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/foundry-zero/allium/internal/ast"
+)
+
+// nullSpec returns a spec of an Order with an optional Coupon and a rule on
+// order creation with the given requires and ensures.
+func nullSpec(requires []ast.Expression, ensures ...ast.EnsuresClause) *ast.Spec {
+	str := ast.FieldType{Kind: "primitive", Value: "String"}
+	return &ast.Spec{
+		File: "test.allium.json",
+		Entities: []ast.Entity{
+			{Name: "Order", Fields: []ast.Field{
+				{Name: "total", Type: ast.FieldType{Kind: "primitive", Value: "Integer"}},
+				{Name: "note", Type: ast.FieldType{Kind: "optional", Inner: &str}},
+				{Name: "coupon", Type: ast.FieldType{Kind: "optional", Inner: &ast.FieldType{Kind: "entity_ref", Entity: "Coupon"}}},
+			}},
+			{Name: "Coupon", Fields: []ast.Field{
+				{Name: "code", Type: str},
+				{Name: "discount", Type: ast.FieldType{Kind: "primitive", Value: "Integer"}},
+			}},
+		},
+		Rules: []ast.Rule{{
+			Name:     "OnOrder",
+			Trigger:  ast.Trigger{Kind: "entity_creation", Binding: "order", Entity: "Order"},
+			Requires: requires,
+			Ensures:  ensures,
+		}},
+	}
+}
+
+func nullLitExpr() *ast.Expression {
+	return &ast.Expression{Kind: "literal", Type: "null", LitValue: json.RawMessage("null")}
+}
+
+func existsExpr(target *ast.Expression) *ast.Expression {
+	return &ast.Expression{Kind: "exists", Target: target}
+}
+
+func andExpr(op string, left, right *ast.Expression) *ast.Expression {
+	return &ast.Expression{Kind: "boolean_logic", Operator: op, Left: left, Right: right}
+}
+
+func TestCheckNullability_RULE55(t *testing.T) {
+	discount := comparisonExpr(">", chain("order", "coupon", "discount"), intLitExpr(0))
+	value, _ := json.Marshal(chain("order", "coupon", "code"))
+
+	tests := []struct {
+		name string
+		spec *ast.Spec
+		path string
+		want string
+	}{
+		{"unguarded requires", nullSpec([]ast.Expression{*discount}),
+			"$.rules[0].requires[0].left",
+			"'order.coupon.discount' reads 'order.coupon', which is optional, without checking that it is present; guard it with 'exists order.coupon' or use ??"},
+		{"guard after the read", nullSpec([]ast.Expression{*andExpr("and", discount, existsExpr(chain("order", "coupon")))}),
+			"$.rules[0].requires[0].left.left", ""},
+		{"unguarded ensures", nullSpec(nil, ast.EnsuresClause{Kind: "state_change", Target: chain("order", "note"), Value: value}),
+			"$.rules[0].ensures[0].value", ""},
+		{"else of a presence check", nullSpec(nil, ast.EnsuresClause{Kind: "conditional", Condition: existsExpr(chain("order", "coupon")),
+			Else: []ast.EnsuresClause{{Kind: "state_change", Target: chain("order", "note"), Value: value}}}),
+			"$.rules[0].ensures[0].else[0].value", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r55 := findingsWithRule(CheckNullability(tt.spec, BuildSymbolTable(tt.spec)), "RULE-55")
+			if len(r55) != 1 {
+				t.Fatalf("expected 1 RULE-55 finding, got %v", r55)
+			}
+			if r55[0].Location.Path != tt.path {
+				t.Errorf("path = %q, want %q", r55[0].Location.Path, tt.path)
+			}
+			if tt.want != "" && r55[0].Message != tt.want {
+				t.Errorf("message = %q, want %q", r55[0].Message, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckNullability_RULE55_LetBinding(t *testing.T) {
+	spec := nullSpec([]ast.Expression{*comparisonExpr("=", chain("c", "code"), strLitExpr("x"))})
+	spec.Rules[0].LetBindings = []ast.LetBinding{{Name: "c", Expression: chain("order", "coupon")}}
+	r55 := findingsWithRule(CheckNullability(spec, BuildSymbolTable(spec)), "RULE-55")
+	if len(r55) != 1 || r55[0].Location.Path != "$.rules[0].requires[0].left" {
+		t.Errorf("expected RULE-55 on the let binding's read, got %v", r55)
+	}
+}
+
+func TestCheckNullability_Guarded(t *testing.T) {
+	coupon := chain("order", "coupon")
+	discount := comparisonExpr(">", chain("order", "coupon", "discount"), intLitExpr(0))
+	value, _ := json.Marshal(chain("order", "coupon", "code"))
+	assign := ast.EnsuresClause{Kind: "state_change", Target: chain("order", "note"), Value: value}
+
+	for name, spec := range map[string]*ast.Spec{
+		"and":              nullSpec([]ast.Expression{*andExpr("and", existsExpr(coupon), discount)}),
+		"or":               nullSpec([]ast.Expression{*andExpr("or", comparisonExpr("=", coupon, nullLitExpr()), discount)}),
+		"not exists or":    nullSpec([]ast.Expression{*andExpr("or", &ast.Expression{Kind: "not", Operand: existsExpr(coupon)}, discount)}),
+		"earlier requires": nullSpec([]ast.Expression{*comparisonExpr("!=", coupon, nullLitExpr()), *discount}),
+		"nested check":     nullSpec([]ast.Expression{*andExpr("and", existsExpr(chain("order", "coupon", "code")), discount)}),
+		"null coalesce": nullSpec([]ast.Expression{*comparisonExpr(">",
+			&ast.Expression{Kind: "null_coalesce", Left: chain("order", "coupon", "discount"), Right: intLitExpr(0)}, intLitExpr(1))}),
+		"null test":        nullSpec([]ast.Expression{*existsExpr(chain("order", "coupon", "code"))}),
+		"requires ensures": nullSpec([]ast.Expression{*existsExpr(coupon)}, assign),
+		"conditional": nullSpec(nil, ast.EnsuresClause{Kind: "conditional", Condition: existsExpr(coupon),
+			Then: []ast.EnsuresClause{assign}}),
+		"conditional else": nullSpec(nil, ast.EnsuresClause{Kind: "conditional", Condition: comparisonExpr("=", coupon, nullLitExpr()),
+			Else: []ast.EnsuresClause{assign}}),
+	} {
+		if r55 := findingsWithRule(CheckNullability(spec, BuildSymbolTable(spec)), "RULE-55"); len(r55) != 0 {
+			t.Errorf("%s: expected no RULE-55, got %v", name, r55)
+		}
+	}
+}
+
+// paramSpec returns the nullability test spec with its rule triggered by
+// PlaceOrder, whose promo parameter is optional, and a rule consuming an
+// emitted OrderPlaced, whose code parameter is optional.
+func paramSpec(requires []ast.Expression, ensures ...ast.EnsuresClause) *ast.Spec {
+	spec := nullSpec(requires, ensures...)
+	spec.Rules[0].Trigger = ast.Trigger{Kind: "external_stimulus", Name: "PlaceOrder",
+		Parameters: []ast.TriggerParam{{Name: "total"}, {Name: "promo", Optional: true}}}
+	spec.Rules = append(spec.Rules, ast.Rule{Name: "Notify", Trigger: ast.Trigger{Kind: "chained", Name: "OrderPlaced",
+		Parameters: []ast.TriggerParam{{Name: "total"}, {Name: "code", Optional: true}}}})
+	return spec
+}
+
+func TestCheckNullability_OptionalParams(t *testing.T) {
+	promo := chain("promo")
+	isX := comparisonExpr("=", promo, strLitExpr("x"))
+	order := func(field string) ast.EnsuresClause {
+		return ast.EnsuresClause{Kind: "entity_creation", Entity: "Order", Fields: map[string]ast.Expression{field: *promo}}
+	}
+	letP, _ := json.Marshal(isX)
+	total, _ := json.Marshal(chain("total"))
+	emit := func(arg string) ast.EnsuresClause {
+		return ast.EnsuresClause{Kind: "trigger_emission", Name: "OrderPlaced", Arguments: map[string]ast.Expression{arg: *promo}}
+	}
+
+	tests := []struct {
+		name string
+		spec *ast.Spec
+		path string
+	}{
+		{"unguarded requires", paramSpec([]ast.Expression{*isX}), "$.rules[0].requires[0].left"},
+		{"chain through the parameter", paramSpec([]ast.Expression{*comparisonExpr("=", chain("promo", "code"), strLitExpr("x"))}), "$.rules[0].requires[0].left"},
+		{"required field", paramSpec(nil, order("total")), "$.rules[0].ensures[0].fields.total"},
+		{"required chained parameter", paramSpec(nil, emit("total")), "$.rules[0].ensures[0].arguments.total"},
+		{"let value", paramSpec(nil, ast.EnsuresClause{Kind: "let_binding", Name: "p", Value: letP}), "$.rules[0].ensures[0].value.left"},
+		{"after a rebinding", paramSpec(nil, ast.EnsuresClause{Kind: "iteration", Binding: "promo", Collection: chain("total"),
+			Body: []ast.EnsuresClause{order("total")}}, order("total")), "$.rules[0].ensures[1].fields.total"},
+		{"beside a nested rebinding", paramSpec(nil, ast.EnsuresClause{Kind: "conditional", Condition: comparisonExpr("=", chain("total"), strLitExpr("x")),
+			Then: []ast.EnsuresClause{{Kind: "let_binding", Name: "promo", Value: total, Body: []ast.EnsuresClause{order("total")}}},
+			Else: []ast.EnsuresClause{order("total")}}), "$.rules[0].ensures[0].else[0].fields.total"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r55 := findingsWithRule(CheckNullability(tt.spec, BuildSymbolTable(tt.spec)), "RULE-55")
+			if len(r55) != 1 || r55[0].Location.Path != tt.path {
+				t.Fatalf("expected 1 RULE-55 at %s, got %v", tt.path, r55)
+			}
+		})
+	}
+
+	spec := paramSpec([]ast.Expression{*isX})
+	want := "'promo' reads optional parameter 'promo' of trigger 'PlaceOrder' without checking that it is present; guard it with 'exists promo' or use ??"
+	if r55 := findingsWithRule(CheckNullability(spec, BuildSymbolTable(spec)), "RULE-55"); len(r55) != 1 || r55[0].Message != want {
+		t.Errorf("expected %q, got %v", want, r55)
+	}
+
+	shadowed := paramSpec(nil)
+	shadowed.Rules[0].LetBindings = []ast.LetBinding{{Name: "promo", Expression: chain("total")}}
+	shadowed.Rules[0].Requires = []ast.Expression{*isX}
+	for name, spec := range map[string]*ast.Spec{
+		"required parameter":      paramSpec([]ast.Expression{*comparisonExpr("=", chain("total"), strLitExpr("x"))}),
+		"exists of a member":      paramSpec([]ast.Expression{*existsExpr(chain("promo", "code"))}, order("total")),
+		"exists":                  paramSpec([]ast.Expression{*andExpr("and", existsExpr(promo), isX)}),
+		"earlier requires":        paramSpec([]ast.Expression{*comparisonExpr("!=", promo, nullLitExpr()), *isX}),
+		"null coalesce":           paramSpec([]ast.Expression{*comparisonExpr("=", &ast.Expression{Kind: "null_coalesce", Left: promo, Right: strLitExpr("none")}, strLitExpr("x"))}),
+		"optional field":          paramSpec(nil, order("note")),
+		"optional chained param":  paramSpec(nil, emit("code")),
+		"rebound by a let":        shadowed,
+		"rebound by an iteration": paramSpec(nil, ast.EnsuresClause{Kind: "iteration", Binding: "promo", Collection: chain("total"), Body: []ast.EnsuresClause{order("total")}}),
+		"rebound by a let clause": paramSpec(nil, ast.EnsuresClause{Kind: "let_binding", Name: "promo", Value: total, Body: []ast.EnsuresClause{order("total")}}),
+		"conditional": paramSpec(nil, ast.EnsuresClause{Kind: "conditional", Condition: existsExpr(promo),
+			Then: []ast.EnsuresClause{order("total")}}),
+	} {
+		if r55 := findingsWithRule(CheckNullability(spec, BuildSymbolTable(spec)), "RULE-55"); len(r55) != 0 {
+			t.Errorf("%s: expected no RULE-55, got %v", name, r55)
+		}
+	}
+}
+
+func TestCheckNullability_DerivedValuesAndSurfaces(t *testing.T) {
+	spec := nullSpec(nil)
+	spec.Entities[0].DerivedValues = []ast.DerivedValue{
+		{Name: "discounted", Expression: comparisonExpr(">", chain("coupon", "discount"), intLitExpr(0))},
+	}
+	spec.Surfaces = []ast.Surface{{
+		Name:   "OrderView",
+		Facing: ast.FacingClause{Binding: "order", Type: "Order"},
+		Exposes: []ast.ExposesItem{
+			{Expression: chain("order", "coupon", "code"), When: existsExpr(chain("order", "coupon"))},
+			{Expression: chain("order", "coupon", "discount")},
+		},
+	}}
+	r55 := findingsWithRule(CheckNullability(spec, BuildSymbolTable(spec)), "RULE-55")
+	var paths []string
+	for _, f := range r55 {
+		paths = append(paths, f.Location.Path)
+	}
+	want := []string{"$.entities[0].derived_values[0].expression.left", "$.surfaces[0].exposes[1].expression"}
+	if len(paths) != len(want) || paths[0] != want[0] || paths[1] != want[1] {
+		t.Errorf("RULE-55 paths = %v, want %v", paths, want)
+	}
+}
+
+func TestCheckNullability_RULE56(t *testing.T) {
+	spec := nullSpec([]ast.Expression{
+		*comparisonExpr("=", chain("order", "total"), nullLitExpr()),
+		*comparisonExpr("!=", nullLitExpr(), chain("order", "total")),
+		// Optional fields, chains through them and untyped values may be null.
+		*comparisonExpr("=", chain("order", "note"), nullLitExpr()),
+		*comparisonExpr("=", chain("order", "coupon", "code"), nullLitExpr()),
+		*comparisonExpr("=", chain("request", "total"), nullLitExpr()),
+	})
+	r56 := findingsWithRule(CheckNullability(spec, BuildSymbolTable(spec)), "RULE-56")
+	if len(r56) != 2 {
+		t.Fatalf("expected 2 RULE-56 findings, got %v", r56)
+	}
+	if r56[0].Message != "'order.total' is not optional, so comparing it with null is always false" ||
+		r56[1].Message != "'order.total' is not optional, so comparing it with null is always true" {
+		t.Errorf("unexpected messages %q, %q", r56[0].Message, r56[1].Message)
+	}
+	if r56[1].Location.Path != "$.rules[0].requires[1]" {
+		t.Errorf("path = %q", r56[1].Location.Path)
+	}
+}
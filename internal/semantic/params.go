@@ -0,0 +1,295 @@
+package semantic
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/foundry-zero/allium/internal/ast"
+)
+
+// TriggerParameterTypes infers the types of a rule's trigger parameters,
+// which are declared by name only, from how the rule uses them. A parameter
+// takes the type of the entity field it is looked up by or created with, of
+// the other side of a comparison, of the target of a state change it is
+// assigned to, of the elements of a collection it is tested for membership in
+// or added to or removed from, or of the registered function parameter it is
+// passed for. Types found this way may type further parameters, through let
+// bindings for example. A parameter still untyped whose members the rule
+// reads, as in session.status, refers to the entity that declares all of
+// them; when several do, the one named after the parameter is chosen, as
+// Session for session or PasswordResetToken for token. Parameters whose type
+// cannot be inferred are left out of the result.
+func TriggerParameterTypes(rule ast.Rule, spec *ast.Spec, st *SymbolTable) map[string]*ast.FieldType {
+	params := make(map[string]bool, len(rule.Trigger.Parameters))
+	for _, p := range rule.Trigger.Parameters {
+		params[p.Name] = true
+	}
+	types := make(map[string]*ast.FieldType)
+	if len(params) == 0 {
+		return types
+	}
+
+	infer := func(name string, ft *ast.FieldType) bool {
+		if !params[name] || types[name] != nil || ft == nil {
+			return false
+		}
+		types[name] = ft
+		return true
+	}
+	for changed := true; changed; {
+		changed = false
+		env := parameterEnv(rule, spec, st, types)
+		eachRuleExpression(rule, func(e *ast.Expression) {
+			for name, ft := range expressionParamTypes(e, env, st) {
+				changed = infer(name, ft) || changed
+			}
+		}, func(ec *ast.EnsuresClause) {
+			for name, ft := range clauseParamTypes(ec, env, st) {
+				changed = infer(name, ft) || changed
+			}
+		})
+		if changed {
+			continue
+		}
+
+		// Only once nothing else types a parameter, guess from the
+		// members read on it.
+		members := make(map[string][]string)
+		eachRuleExpression(rule, func(e *ast.Expression) {
+			if e.Kind == "field_access" && e.Object != nil {
+				if name := rootName(e.Object); params[name] && types[name] == nil && !slices.Contains(members[name], e.Field) {
+					members[name] = append(members[name], e.Field)
+				}
+			}
+		}, nil)
+		for _, p := range rule.Trigger.Parameters {
+			if entity := entityDeclaring(spec, st, p.Name, members[p.Name]); entity != "" {
+				changed = infer(p.Name, &ast.FieldType{Kind: "entity_ref", Entity: entity}) || changed
+			}
+		}
+	}
+	return types
+}
+
+// parameterEnv returns the type environment of a rule's expressions with the
+// parameter types inferred so far, and its let bindings typed again, since
+// they may depend on the parameters.
+func parameterEnv(rule ast.Rule, spec *ast.Spec, st *SymbolTable, types map[string]*ast.FieldType) map[string]*ast.FieldType {
+	env := ruleFieldTypes(rule, spec, st)
+	for _, p := range rule.Trigger.Parameters {
+		delete(env, p.Name)
+	}
+	for name, ft := range types {
+		env[name] = ft
+	}
+	for _, lb := range rule.LetBindings {
+		if ft := inferExprType(lb.Expression, env, st); ft != nil {
+			env[lb.Name] = ft
+		}
+	}
+	return env
+}
+
+// eachRuleExpression calls exprFn for every expression of a rule: its let
+// bindings, for clause, requires and ensures, and clauseFn, when not nil,
+// for every ensures clause.
+func eachRuleExpression(rule ast.Rule, exprFn func(*ast.Expression), clauseFn func(*ast.EnsuresClause)) {
+	walk := func(e *ast.Expression) {
+		ast.Walk(e, func(e *ast.Expression) bool {
+			exprFn(e)
+			return true
+		})
+	}
+	for _, lb := range rule.LetBindings {
+		walk(lb.Expression)
+	}
+	if fc := rule.ForClause; fc != nil {
+		walk(fc.Collection)
+		walk(fc.Condition)
+	}
+	for i := range rule.Requires {
+		walk(&rule.Requires[i])
+	}
+	for i := range rule.Ensures {
+		ast.WalkClauses(&rule.Ensures[i], "", func(ec *ast.EnsuresClause, path string) bool {
+			if clauseFn != nil {
+				clauseFn(ec)
+			}
+			for _, ce := range ec.Expressions(path) {
+				walk(ce.Expr)
+			}
+			return true
+		})
+	}
+}
+
+// expressionParamTypes returns the types e gives the bare names it uses
+// directly: join lookup fields, comparison operands, membership elements and
+// function arguments. Names whose type is unknown map to nil.
+func expressionParamTypes(e *ast.Expression, env map[string]*ast.FieldType, st *SymbolTable) map[string]*ast.FieldType {
+	types := make(map[string]*ast.FieldType)
+	switch e.Kind {
+	case "join_lookup":
+		for _, field := range slices.Sorted(maps.Keys(e.Fields)) {
+			v := e.Fields[field]
+			if name := rootName(&v); name != "" && types[name] == nil {
+				types[name] = memberType(st, e.Entity, field)
+			}
+		}
+	case "comparison":
+		if name := rootName(e.Left); name != "" {
+			types[name] = inferExprType(e.Right, env, st)
+		}
+		if name := rootName(e.Right); name != "" && types[name] == nil {
+			types[name] = inferExprType(e.Left, env, st)
+		}
+	case "membership":
+		if name := rootName(e.Element); name != "" {
+			types[name] = elementType(inferExprType(e.Collection, env, st))
+		}
+	case "function_call":
+		sig := st.Functions.Lookup(e.FuncName)
+		if sig == nil {
+			break
+		}
+		for i := range e.FuncArguments {
+			name := rootName(&e.FuncArguments[i])
+			if name != "" && i < len(sig.Parameters) && sig.Parameters[i] != anyType {
+				types[name] = &ast.FieldType{Kind: "primitive", Value: sig.Parameters[i]}
+			}
+		}
+	}
+	return types
+}
+
+// clauseParamTypes returns the types an ensures clause gives the bare names
+// it uses as values: the fields of a created entity, the target of a state
+// change and the elements of a mutated set.
+func clauseParamTypes(ec *ast.EnsuresClause, env map[string]*ast.FieldType, st *SymbolTable) map[string]*ast.FieldType {
+	types := make(map[string]*ast.FieldType)
+	switch ec.Kind {
+	case "entity_creation":
+		for _, field := range slices.Sorted(maps.Keys(ec.Fields)) {
+			v := ec.Fields[field]
+			if name := rootName(&v); name != "" && types[name] == nil {
+				types[name] = memberType(st, ec.Entity, field)
+			}
+		}
+	case "state_change", "set_mutation":
+		var value ast.Expression
+		if err := json.Unmarshal(ec.Value, &value); err != nil {
+			break
+		}
+		name := rootName(&value)
+		if name == "" {
+			break
+		}
+		target := inferExprType(ec.Target, env, st)
+		if ec.Kind == "set_mutation" {
+			target = elementType(target)
+		}
+		types[name] = target
+	}
+	return types
+}
+
+// rootName returns the name e reads when it is a bare identifier, or "".
+func rootName(e *ast.Expression) string {
+	if e == nil || e.Kind != "field_access" || e.Object != nil {
+		return ""
+	}
+	return e.Field
+}
+
+// elementType returns the element type of a set or list type, or nil.
+func elementType(ft *ast.FieldType) *ast.FieldType {
+	if ft == nil || ft.Kind != "set" && ft.Kind != "list" {
+		return nil
+	}
+	return ft.Element
+}
+
+// entityDeclaring returns the entity, variant or external entity declaring
+// every one of members, as a field, relationship, projection or derived
+// value, choosing among several the one named after binding, or "" when
+// there is none or the choice is ambiguous.
+func entityDeclaring(spec *ast.Spec, st *SymbolTable, binding string, members []string) string {
+	if len(members) == 0 {
+		return ""
+	}
+	var candidates []string
+	consider := func(name string) {
+		for _, m := range members {
+			if !declaresMember(st, name, m) {
+				return
+			}
+		}
+		candidates = append(candidates, name)
+	}
+	for _, e := range spec.Entities {
+		consider(e.Name)
+	}
+	for _, v := range spec.Variants {
+		consider(v.Name)
+	}
+	for _, ee := range spec.ExternalEntities {
+		consider(ee.Name)
+	}
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+
+	// Prefer an entity named exactly as the binding, then one whose name
+	// ends with its words.
+	words := lowerWords(binding)
+	var exact, suffix []string
+	for _, c := range candidates {
+		cw := lowerWords(c)
+		switch {
+		case slices.Equal(cw, words):
+			exact = append(exact, c)
+		case len(cw) > len(words) && slices.Equal(cw[len(cw)-len(words):], words):
+			suffix = append(suffix, c)
+		}
+	}
+	switch {
+	case len(exact) == 1:
+		return exact[0]
+	case len(exact) == 0 && len(suffix) == 1:
+		return suffix[0]
+	}
+	return ""
+}
+
+// declaresMember reports whether the entity-like declaration typeName has a
+// member called name.
+func declaresMember(st *SymbolTable, typeName, name string) bool {
+	if memberType(st, typeName, name) != nil {
+		return true
+	}
+	hasDerived := func(dvs []ast.DerivedValue) bool {
+		return slices.ContainsFunc(dvs, func(dv ast.DerivedValue) bool { return dv.Name == name })
+	}
+	if e := st.LookupEntity(typeName); e != nil {
+		return hasDerived(e.DerivedValues) ||
+			slices.ContainsFunc(e.Projections, func(p ast.Projection) bool { return p.Name == name })
+	}
+	if v := st.LookupVariant(typeName); v != nil && v.BaseEntity != typeName {
+		return declaresMember(st, v.BaseEntity, name)
+	}
+	if vt := st.LookupValueType(typeName); vt != nil {
+		return hasDerived(vt.DerivedValues)
+	}
+	return false
+}
+
+// lowerWords returns the words of a name in lower case.
+func lowerWords(name string) []string {
+	words := nameWords(name)
+	for i, w := range words {
+		words[i] = strings.ToLower(w)
+	}
+	return words
+}
@@ -0,0 +1,132 @@
+package semantic
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/foundry-zero/allium/internal/ast"
+)
+
+// describeType renders a field type for comparison in tests.
+func describeType(ft *ast.FieldType) string {
+	if ft == nil {
+		return "<nil>"
+	}
+	switch ft.Kind {
+	case "primitive":
+		return ft.Value
+	case "entity_ref":
+		return ft.Entity
+	case "set", "list", "optional":
+		inner := ft.Element
+		if ft.Kind == "optional" {
+			inner = ft.Inner
+		}
+		return ft.Kind + "(" + describeType(inner) + ")"
+	}
+	return ft.Kind
+}
+
+func TestTriggerParameterTypes_ReferenceExample(t *testing.T) {
+	spec, err := ast.LoadSpec(filepath.Join(projectRoot(), "schemas", "v1", "examples", "password-auth.allium.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := BuildSymbolTable(spec)
+	want := map[string]map[string]string{
+		"Register":              {"email": "String", "password": "String"},
+		"LoginSuccess":          {"email": "String", "password": "String"},
+		"Logout":                {"session": "Session"},
+		"CompletePasswordReset": {"token": "PasswordResetToken", "new_password": "String"},
+		"NotifySecurityTeam":    {"user": "User"},
+		"AdminRevokesSession":   {"session": "Session"},
+		"DeactivateAccount":     {"user": "User"},
+		"AddTrustedIP":          {"user": "User", "ip": "String"},
+	}
+	for name, params := range want {
+		got := TriggerParameterTypes(*st.LookupRule(name), spec, st)
+		for param, typ := range params {
+			if d := describeType(got[param]); d != typ {
+				t.Errorf("%s: parameter %s has type %s, want %s", name, param, d, typ)
+			}
+		}
+	}
+	// Nothing in DeactivateAccount says what the admin is.
+	if ft := TriggerParameterTypes(*st.LookupRule("DeactivateAccount"), spec, st)["admin"]; ft != nil {
+		t.Errorf("DeactivateAccount: admin has type %s, want none", describeType(ft))
+	}
+}
+
+func TestTriggerParameterTypes_Evidence(t *testing.T) {
+	str := ast.FieldType{Kind: "primitive", Value: "String"}
+	spec := &ast.Spec{
+		File: "test.allium.json",
+		Entities: []ast.Entity{
+			{Name: "Order", Fields: []ast.Field{
+				{Name: "total", Type: ast.FieldType{Kind: "primitive", Value: "Decimal"}},
+				{Name: "note", Type: str},
+				{Name: "tags", Type: ast.FieldType{Kind: "set", Element: &str}},
+				{Name: "status", Type: ast.FieldType{Kind: "inline_enum", Values: []string{"open", "closed"}}},
+			}},
+			{Name: "Invoice", Fields: []ast.Field{{Name: "status", Type: str}}},
+		},
+	}
+	tests := []struct {
+		name string
+		rule ast.Rule
+		want map[string]string
+	}{
+		{
+			name: "creation field",
+			rule: ast.Rule{Ensures: []ast.EnsuresClause{{Kind: "entity_creation", Entity: "Order", Fields: map[string]ast.Expression{"note": *fieldAccess("text")}}}},
+			want: map[string]string{"text": "String"},
+		},
+		{
+			name: "comparison",
+			rule: ast.Rule{
+				LetBindings: []ast.LetBinding{{Name: "o", Expression: &ast.Expression{Kind: "join_lookup", Entity: "Order", Fields: map[string]ast.Expression{"note": *fieldAccess("text")}}}},
+				Requires:    []ast.Expression{*comparisonExpr(">", chain("o", "total"), fieldAccess("limit"))},
+			},
+			want: map[string]string{"text": "String", "limit": "Decimal"},
+		},
+		{
+			name: "set mutation",
+			rule: ast.Rule{Ensures: []ast.EnsuresClause{{
+				Kind: "set_mutation", Operation: "add", Target: chain("order", "tags"),
+				Value: []byte(`{"kind": "field_access", "object": null, "field": "tag"}`),
+			}}},
+			want: map[string]string{"order": "Order", "tag": "String"},
+		},
+		{
+			name: "function argument",
+			rule: ast.Rule{Requires: []ast.Expression{*comparisonExpr(">", callExpr("length", fieldAccess("text")), intLitExpr(0))}},
+			want: map[string]string{"text": "String"},
+		},
+		{
+			name: "ambiguous members",
+			rule: ast.Rule{Requires: []ast.Expression{*comparisonExpr("=", chain("thing", "status"), enumLitExpr("open"))}},
+			want: map[string]string{"thing": "<nil>"},
+		},
+		{
+			name: "members named after the parameter",
+			rule: ast.Rule{Requires: []ast.Expression{*comparisonExpr("=", chain("invoice", "status"), strLitExpr("paid"))}},
+			want: map[string]string{"invoice": "Invoice"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := BuildSymbolTable(spec)
+			tt.rule.Name = "R"
+			tt.rule.Trigger = ast.Trigger{Kind: "external_stimulus", Name: "Go"}
+			for param := range tt.want {
+				tt.rule.Trigger.Parameters = append(tt.rule.Trigger.Parameters, ast.TriggerParam{Name: param})
+			}
+			got := TriggerParameterTypes(tt.rule, spec, st)
+			for param, typ := range tt.want {
+				if d := describeType(got[param]); d != typ {
+					t.Errorf("parameter %s has type %s, want %s", param, d, typ)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package semantic
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/report"
+)
+
+// BenchmarkPasses runs every semantic pass over the reference example with
+// its rules repeated to approximate a large spec.
+func BenchmarkPasses(b *testing.B) {
+	spec, err := ast.LoadSpec(filepath.Join("..", "..", "schemas", "v1", "examples", "password-auth.allium.json"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	rules := spec.Rules
+	for range 50 {
+		spec.Rules = append(spec.Rules, rules...)
+	}
+	st := BuildSymbolTable(spec)
+	passes := []func(*ast.Spec, *SymbolTable) []report.Finding{
+		CheckReferences, CheckUniqueness, CheckStateMachines, CheckExpressions,
+		CheckSumTypes, CheckSurfaces, CheckRetention, CheckWarnings,
+	}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		for _, pass := range passes {
+			pass(spec, st)
+		}
+	}
+}
+
+// BenchmarkPassesDeepExpression runs every semantic pass over a spec whose
+// rule requires a 10k-node expression nested as deeply as it can be, as
+// generated specs may: a chain of nots, of additions and of ands. It shows
+// that passes get through such depth without exhausting the stack; the time
+// they take still grows faster than the depth, as type inference and
+// narrowing revisit the operands of each node.
+func BenchmarkPassesDeepExpression(b *testing.B) {
+	chains := []struct {
+		name string
+		wrap func(e *ast.Expression) *ast.Expression
+	}{
+		{"not", func(e *ast.Expression) *ast.Expression {
+			return &ast.Expression{Kind: "not", Operand: e}
+		}},
+		{"arithmetic", func(e *ast.Expression) *ast.Expression {
+			return &ast.Expression{Kind: "arithmetic", Operator: "+", Left: e, Right: intLitExpr(1)}
+		}},
+		{"boolean_logic", func(e *ast.Expression) *ast.Expression {
+			return &ast.Expression{Kind: "boolean_logic", Operator: "and", Left: e, Right: boolLitExpr(true)}
+		}},
+	}
+	passes := []func(*ast.Spec, *SymbolTable) []report.Finding{
+		CheckReferences, CheckUniqueness, CheckStateMachines, CheckExpressions,
+		CheckSumTypes, CheckSurfaces, CheckRetention, CheckTypeAliases, CheckTriggers,
+		CheckCreations, CheckStateChanges, CheckRelationships, CheckActors,
+		CheckTemporalConditions, CheckNullability, CheckMetadata, CheckSensitiveExposure, CheckWarnings,
+	}
+	for _, chain := range chains {
+		b.Run(chain.name, func(b *testing.B) {
+			spec := chainedTypeSpec()
+			expr := fieldAccess("account")
+			for range 10_000 {
+				expr = chain.wrap(expr)
+			}
+			spec.Rules[0].Requires = []ast.Expression{*expr}
+			st := BuildSymbolTable(spec)
+
+			b.ReportAllocs()
+			for b.Loop() {
+				for _, pass := range passes {
+					pass(spec, st)
+				}
+			}
+		})
+	}
+}
@@ -10,7 +10,8 @@ import (
 // CheckReferences verifies that all name references in the specification
 // resolve to declared symbols. It checks rules:
 //
-//   - RULE-01: entity_ref types resolve to a declared entity/external/variant/import
+//   - RULE-01: entity_ref types resolve to a declared entity/external/variant/import,
+//     and named_enum and alias types to a declared enumeration or type alias
 //   - RULE-03: relationship target_entity resolves
 //   - RULE-22: given binding type references resolve
 //   - RULE-27: config parameter references in expressions resolve
@@ -54,6 +55,10 @@ func CheckReferences(spec *ast.Spec, st *SymbolTable) []report.Finding {
 		findings = checkFieldTypeRefs(findings, spec, st, c.Type,
 			fmt.Sprintf("$.config[%d].type", i))
 	}
+	for i, a := range spec.TypeAliases {
+		findings = checkFieldTypeRefs(findings, spec, st, a.Type,
+			fmt.Sprintf("$.type_aliases[%d].type", i))
+	}
 
 	// RULE-03: Check relationship target_entity references
 	for i, e := range spec.Entities {
@@ -132,14 +137,15 @@ func CheckReferences(spec *ast.Spec, st *SymbolTable) []report.Finding {
 				"RULE-35",
 				fmt.Sprintf("Use declaration '%s' has empty coordinate", u.Alias),
 				report.Location{File: spec.File, Path: fmt.Sprintf("$.use_declarations[%d].coordinate", i)},
-			))
+			).WithSuggestion(fmt.Sprintf("Remove use declaration '%s'", u.Alias),
+				report.RemoveEdit(fmt.Sprintf("$.use_declarations[%d]", i))))
 		}
 	}
 
 	return findings
 }
 
-// checkFieldTypeRefs recursively checks entity_ref, named_enum, optional, set, list types.
+// checkFieldTypeRefs recursively checks entity_ref, named_enum, alias, optional, set, list types.
 func checkFieldTypeRefs(findings []report.Finding, spec *ast.Spec, st *SymbolTable, ft ast.FieldType, path string) []report.Finding {
 	switch ft.Kind {
 	case "entity_ref":
@@ -158,6 +164,14 @@ func checkFieldTypeRefs(findings []report.Finding, spec *ast.Spec, st *SymbolTab
 				report.Location{File: spec.File, Path: path},
 			))
 		}
+	case "alias":
+		if st.LookupTypeAlias(ft.Name) == nil {
+			findings = append(findings, report.NewError(
+				"RULE-01",
+				fmt.Sprintf("Type alias '%s' referenced but not declared", ft.Name),
+				report.Location{File: spec.File, Path: path},
+			))
+		}
 	case "optional":
 		if ft.Inner != nil {
 			findings = checkFieldTypeRefs(findings, spec, st, *ft.Inner, path+".inner")
@@ -189,6 +203,14 @@ func checkGivenTypeRef(findings []report.Finding, spec *ast.Spec, st *SymbolTabl
 				report.Location{File: spec.File, Path: path},
 			))
 		}
+	case "alias":
+		if st.LookupTypeAlias(g.Type.Name) == nil {
+			findings = append(findings, report.NewError(
+				"RULE-22",
+				fmt.Sprintf("Given binding '%s' references undeclared type alias '%s'", g.Name, g.Type.Name),
+				report.Location{File: spec.File, Path: path},
+			))
+		}
 	default:
 		// primitive and other types don't need reference checks
 	}
@@ -202,19 +224,19 @@ func checkRuleConfigRefs(findings []report.Finding, spec *ast.Spec, st *SymbolTa
 	// Check requires expressions
 	for j, expr := range r.Requires {
 		findings = checkExpressionConfigRefs(findings, st, &expr,
-			fmt.Sprintf("%s.requires[%d]", basePath, j), spec.File)
+			ast.IndexPath(basePath, "requires", j), spec.File)
 	}
 
 	// Check ensures clauses
 	for j, ec := range r.Ensures {
 		findings = checkEnsuresConfigRefs(findings, st, ec,
-			fmt.Sprintf("%s.ensures[%d]", basePath, j), spec.File)
+			ast.IndexPath(basePath, "ensures", j), spec.File)
 	}
 
 	// Check let bindings
 	for j, lb := range r.LetBindings {
 		findings = checkExpressionConfigRefs(findings, st, lb.Expression,
-			fmt.Sprintf("%s.let_bindings[%d].expression", basePath, j), spec.File)
+			ast.IndexPath(basePath, "let_bindings", j)+".expression", spec.File)
 	}
 
 	return findings
@@ -222,67 +244,37 @@ func checkRuleConfigRefs(findings []report.Finding, spec *ast.Spec, st *SymbolTa
 
 // checkExpressionConfigRefs walks an expression tree looking for config references (RULE-27).
 func checkExpressionConfigRefs(findings []report.Finding, st *SymbolTable, expr *ast.Expression, path string, file string) []report.Finding {
-	if expr == nil {
-		return findings
-	}
+	ast.WalkExpression(expr, ast.NewPath(path), func(e *ast.Expression, path *ast.Path) bool {
+		findings = checkConfigRef(findings, st, e, path, file)
+		return true
+	})
+	return findings
+}
 
-	// A config reference is config.param_name: a field_access where the object is
-	// a root field_access with field "config", and the outer field is the param name.
+// checkEnsuresConfigRefs walks an ensures clause tree for config references.
+func checkEnsuresConfigRefs(findings []report.Finding, st *SymbolTable, ec ast.EnsuresClause, path string, file string) []report.Finding {
+	ast.WalkEnsures(&ec, path, func(e *ast.Expression, path *ast.Path) bool {
+		findings = checkConfigRef(findings, st, e, path, file)
+		return true
+	})
+	return findings
+}
+
+// checkConfigRef reports expr if it reads a config parameter that is not
+// declared. A config reference is config.param_name: a field_access where the
+// object is a root field_access with field "config", and the outer field is
+// the param name.
+func checkConfigRef(findings []report.Finding, st *SymbolTable, expr *ast.Expression, path *ast.Path, file string) []report.Finding {
 	if expr.Kind == "field_access" && expr.Object != nil &&
 		expr.Object.Kind == "field_access" && expr.Object.Object == nil && expr.Object.Field == "config" {
-		paramName := expr.Field
-		if st.LookupConfig(paramName) == nil {
+		if st.LookupConfig(expr.Field) == nil {
 			findings = append(findings, report.NewError(
 				"RULE-27",
-				fmt.Sprintf("Config parameter '%s' referenced but not declared", paramName),
-				report.Location{File: file, Path: path},
+				fmt.Sprintf("Config parameter '%s' referenced but not declared", expr.Field),
+				report.Location{File: file, Path: path.String()},
 			))
 		}
 	}
-
-	// Recurse into sub-expressions
-	findings = checkExpressionConfigRefs(findings, st, expr.Object, path+".object", file)
-	findings = checkExpressionConfigRefs(findings, st, expr.Left, path+".left", file)
-	findings = checkExpressionConfigRefs(findings, st, expr.Right, path+".right", file)
-	findings = checkExpressionConfigRefs(findings, st, expr.Target, path+".target", file)
-	findings = checkExpressionConfigRefs(findings, st, expr.Operand, path+".operand", file)
-	findings = checkExpressionConfigRefs(findings, st, expr.Collection, path+".collection", file)
-	findings = checkExpressionConfigRefs(findings, st, expr.Lambda, path+".lambda", file)
-	findings = checkExpressionConfigRefs(findings, st, expr.Condition, path+".condition", file)
-	findings = checkExpressionConfigRefs(findings, st, expr.Body, path+".body", file)
-	findings = checkExpressionConfigRefs(findings, st, expr.Element, path+".element", file)
-
-	for j := range expr.FuncArguments {
-		findings = checkExpressionConfigRefs(findings, st, &expr.FuncArguments[j],
-			fmt.Sprintf("%s.arguments[%d]", path, j), file)
-	}
-	for j := range expr.Elements {
-		findings = checkExpressionConfigRefs(findings, st, &expr.Elements[j],
-			fmt.Sprintf("%s.elements[%d]", path, j), file)
-	}
-
-	return findings
-}
-
-// checkEnsuresConfigRefs walks an ensures clause tree for config references.
-func checkEnsuresConfigRefs(findings []report.Finding, st *SymbolTable, ec ast.EnsuresClause, path string, file string) []report.Finding {
-	findings = checkExpressionConfigRefs(findings, st, ec.Target, path+".target", file)
-	findings = checkExpressionConfigRefs(findings, st, ec.Condition, path+".condition", file)
-	findings = checkExpressionConfigRefs(findings, st, ec.Collection, path+".collection", file)
-
-	for j, then := range ec.Then {
-		findings = checkEnsuresConfigRefs(findings, st, then,
-			fmt.Sprintf("%s.then[%d]", path, j), file)
-	}
-	for j, el := range ec.Else {
-		findings = checkEnsuresConfigRefs(findings, st, el,
-			fmt.Sprintf("%s.else[%d]", path, j), file)
-	}
-	for j, body := range ec.Body {
-		findings = checkEnsuresConfigRefs(findings, st, body,
-			fmt.Sprintf("%s.body[%d]", path, j), file)
-	}
-
 	return findings
 }
 
@@ -304,7 +296,7 @@ func checkProvidesItemTrigger(findings []report.Finding, spec *ast.Spec, st *Sym
 	case "for_each":
 		for j, item := range p.Items {
 			findings = checkProvidesItemTrigger(findings, spec, st, item, surfaceName,
-				fmt.Sprintf("%s.items[%d]", path, j))
+				ast.IndexPath(path, "items", j))
 		}
 	}
 	return findings
@@ -1,6 +1,7 @@
 package semantic
 
 import (
+	"slices"
 	"testing"
 
 	"github.com/foundry-zero/allium/internal/ast"
@@ -155,6 +156,42 @@ func TestCheckReferences_RULE01_SetElement(t *testing.T) {
 	}
 }
 
+func TestCheckReferences_RULE01_UndeclaredAlias(t *testing.T) {
+	spec := cleanSpec()
+	spec.Entities[0].Fields[0].Type = ast.FieldType{
+		Kind:  "optional",
+		Inner: &ast.FieldType{Kind: "alias", Name: "Email"},
+	}
+	st := BuildSymbolTable(spec)
+	findings := CheckReferences(spec, st)
+
+	f := findingWithRule(findings, "RULE-01")
+	if f == nil {
+		t.Fatal("expected RULE-01 for undeclared type alias")
+	}
+	if f.Location.Path != "$.entities[0].fields[0].type.inner" {
+		t.Errorf("path = %q", f.Location.Path)
+	}
+}
+
+func TestCheckReferences_RULE01_AliasBody(t *testing.T) {
+	spec := cleanSpec()
+	spec.TypeAliases = []ast.TypeAlias{
+		{Name: "Owners", Type: ast.FieldType{Kind: "set", Element: &ast.FieldType{Kind: "entity_ref", Entity: "Nobody"}}},
+	}
+	spec.Entities[0].Fields[0].Type = ast.FieldType{Kind: "alias", Name: "Owners"}
+	st := BuildSymbolTable(spec)
+	findings := CheckReferences(spec, st)
+
+	r01 := findingsWithRule(findings, "RULE-01")
+	if len(r01) != 1 {
+		t.Fatalf("expected 1 RULE-01 finding, got %d: %v", len(r01), r01)
+	}
+	if r01[0].Location.Path != "$.type_aliases[0].type.element" {
+		t.Errorf("path = %q", r01[0].Location.Path)
+	}
+}
+
 func TestCheckReferences_RULE01_ExternalEntityFields(t *testing.T) {
 	spec := cleanSpec()
 	spec.ExternalEntities = []ast.ExternalEntity{
@@ -282,6 +319,23 @@ func TestCheckReferences_RULE22_NamedEnum(t *testing.T) {
 	}
 }
 
+func TestCheckReferences_RULE22_Alias(t *testing.T) {
+	spec := cleanSpec()
+	spec.Given[0].Type = ast.FieldType{Kind: "alias", Name: "Missing"}
+	st := BuildSymbolTable(spec)
+	findings := CheckReferences(spec, st)
+
+	if findingWithRule(findings, "RULE-22") == nil {
+		t.Fatal("expected RULE-22 for undeclared given type alias")
+	}
+
+	spec.TypeAliases = []ast.TypeAlias{{Name: "Missing", Type: ast.FieldType{Kind: "entity_ref", Entity: "User"}}}
+	st = BuildSymbolTable(spec)
+	if f := findingWithRule(CheckReferences(spec, st), "RULE-22"); f != nil {
+		t.Errorf("unexpected RULE-22 for declared alias: %v", f)
+	}
+}
+
 func TestCheckReferences_RULE22_Primitive_NoError(t *testing.T) {
 	spec := cleanSpec()
 	spec.Given[0].Type = ast.FieldType{Kind: "primitive", Value: "String"}
@@ -405,6 +459,10 @@ func TestCheckReferences_RULE35_EmptyCoordinate(t *testing.T) {
 	if f == nil {
 		t.Fatal("expected RULE-35 for empty use declaration coordinate")
 	}
+	if len(f.Suggestions) != 1 || len(f.Suggestions[0].Edits) != 1 ||
+		f.Suggestions[0].Edits[0].Op != "remove" || f.Suggestions[0].Edits[0].Path != "$.use_declarations[0]" {
+		t.Errorf("expected a suggestion removing the declaration, got %+v", f.Suggestions)
+	}
 }
 
 func TestCheckReferences_RULE27_UndeclaredConfigRef(t *testing.T) {
@@ -456,6 +514,25 @@ func TestCheckReferences_RULE27_ValidConfigRef(t *testing.T) {
 	}
 }
 
+func TestCheckReferences_RULE27_InNestedPositions(t *testing.T) {
+	configRef := ast.Expression{Kind: "field_access", Field: "missing_config", Object: &ast.Expression{Kind: "field_access", Field: "config"}}
+	spec := cleanSpec()
+	spec.Rules[0].Requires = []ast.Expression{{Kind: "join_lookup", Entity: "Membership",
+		Fields: map[string]ast.Expression{"limit": configRef}}}
+	spec.Rules[0].Ensures = []ast.EnsuresClause{{Kind: "trigger_emission", Name: "LimitReached",
+		Arguments: map[string]ast.Expression{"limit": configRef}}}
+	st := BuildSymbolTable(spec)
+
+	var paths []string
+	for _, f := range findingsWithRule(CheckReferences(spec, st), "RULE-27") {
+		paths = append(paths, f.Location.Path)
+	}
+	want := []string{"$.rules[0].requires[0].fields.limit", "$.rules[0].ensures[0].arguments.limit"}
+	if !slices.Equal(paths, want) {
+		t.Errorf("RULE-27 paths = %v, want %v", paths, want)
+	}
+}
+
 func TestCheckReferences_RULE27_InDerivedValue(t *testing.T) {
 	spec := cleanSpec()
 	// Add derived value with undeclared config ref
@@ -0,0 +1,262 @@
+package semantic
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/report"
+)
+
+// RelationshipMetrics describes where an entity or external entity sits in
+// the relationship graph, whose edges run from each entity to the entities
+// its fields reference. A relationship is the inverse of the foreign key
+// field of its target, so it contributes an edge from the target to the
+// entity declaring it.
+type RelationshipMetrics struct {
+	Name   string `json:"name"`
+	Kind   string `json:"kind"`    // "entity" or "external_entity"
+	FanOut int    `json:"fan_out"` // distinct entities it references
+	FanIn  int    `json:"fan_in"`  // distinct entities referencing it
+
+	// Depth is the number of references in the longest chain that starts at
+	// the entity. Entities in a cycle reach each other, so they share a
+	// depth, and the references between them are not counted.
+	Depth   int  `json:"depth"`
+	InCycle bool `json:"in_cycle,omitempty"`
+}
+
+// RelationshipGraphMetrics returns the metrics of every entity and external
+// entity in spec, in declaration order, entities first.
+func RelationshipGraphMetrics(spec *ast.Spec) []RelationshipMetrics {
+	var metrics []RelationshipMetrics
+	nodes := make(map[string]int)
+	for _, e := range spec.Entities {
+		if _, ok := nodes[e.Name]; !ok {
+			nodes[e.Name] = len(metrics)
+			metrics = append(metrics, RelationshipMetrics{Name: e.Name, Kind: "entity"})
+		}
+	}
+	for _, e := range spec.ExternalEntities {
+		if _, ok := nodes[e.Name]; !ok {
+			nodes[e.Name] = len(metrics)
+			metrics = append(metrics, RelationshipMetrics{Name: e.Name, Kind: "external_entity"})
+		}
+	}
+
+	adj := make([][]int, len(metrics))
+	link := func(source, target string) {
+		from, ok := nodes[source]
+		to, ok2 := nodes[target]
+		if ok && ok2 && !slices.Contains(adj[from], to) {
+			adj[from] = append(adj[from], to)
+		}
+	}
+	for _, e := range spec.Entities {
+		for _, r := range e.Relationships {
+			link(r.TargetEntity, e.Name)
+		}
+		for _, f := range e.Fields {
+			for _, target := range referencedEntities(f.Type, nil) {
+				link(e.Name, target)
+			}
+		}
+	}
+	for _, e := range spec.ExternalEntities {
+		for _, f := range e.Fields {
+			for _, target := range referencedEntities(f.Type, nil) {
+				link(e.Name, target)
+			}
+		}
+	}
+
+	for v, targets := range adj {
+		metrics[v].FanOut = len(targets)
+		for _, w := range targets {
+			metrics[w].FanIn++
+		}
+	}
+
+	// Tarjan's algorithm yields components in reverse topological order, so
+	// every component a component references has its depth by then.
+	for _, scc := range tarjanSCC(adj) {
+		depth := 0
+		for _, v := range scc {
+			for _, w := range adj[v] {
+				if !slices.Contains(scc, w) {
+					depth = max(depth, metrics[w].Depth+1)
+				}
+			}
+		}
+		for _, v := range scc {
+			metrics[v].Depth = depth
+			metrics[v].InCycle = len(scc) > 1 || slices.Contains(adj[v], v)
+		}
+	}
+	return metrics
+}
+
+// referencedEntities appends the entities ft refers to, directly or as the
+// element of an optional, set or list.
+func referencedEntities(ft ast.FieldType, names []string) []string {
+	switch ft.Kind {
+	case "entity_ref":
+		names = append(names, ft.Entity)
+	case "optional":
+		if ft.Inner != nil {
+			names = referencedEntities(*ft.Inner, names)
+		}
+	case "set", "list":
+		if ft.Element != nil {
+			names = referencedEntities(*ft.Element, names)
+		}
+	}
+	return names
+}
+
+// requiredReference is a field that must reference an existing entity when
+// an instance is created: a non-optional reference to a single entity.
+type requiredReference struct {
+	entity, field int // indices into spec.Entities and its fields
+	target        int // index into spec.Entities
+}
+
+// requiredReferenceCycles returns the cycles of required references between
+// entities, each as the references that lead from its first entity in
+// declaration order back to that entity. None of the entities in such a
+// cycle can be created before the others.
+func requiredReferenceCycles(spec *ast.Spec) [][]requiredReference {
+	nodes := make(map[string]int)
+	for i, e := range spec.Entities {
+		if _, ok := nodes[e.Name]; !ok {
+			nodes[e.Name] = i
+		}
+	}
+	edges := make([][]requiredReference, len(spec.Entities))
+	adj := make([][]int, len(spec.Entities))
+	for i, e := range spec.Entities {
+		for j, f := range e.Fields {
+			if f.Type.Kind != "entity_ref" {
+				continue
+			}
+			if target, ok := nodes[f.Type.Entity]; ok {
+				edges[i] = append(edges[i], requiredReference{i, j, target})
+				adj[i] = append(adj[i], target)
+			}
+		}
+	}
+
+	var cycles [][]requiredReference
+	for _, scc := range tarjanSCC(adj) {
+		if len(scc) == 1 && !slices.Contains(adj[scc[0]], scc[0]) {
+			continue
+		}
+		cycles = append(cycles, shortestCycle(slices.Min(scc), scc, edges))
+	}
+	slices.SortFunc(cycles, func(a, b []requiredReference) int { return a[0].entity - b[0].entity })
+	return cycles
+}
+
+// shortestCycle returns the shortest cycle of references from start back to
+// it through the entities of its strongly connected component.
+func shortestCycle(start int, scc []int, edges [][]requiredReference) []requiredReference {
+	via := make(map[int]requiredReference) // the reference each entity is first reached by
+	queue := []int{start}
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+		for _, ref := range edges[v] {
+			if ref.target == start {
+				cycle := []requiredReference{ref}
+				for w := v; w != start; w = via[w].entity {
+					cycle = append(cycle, via[w])
+				}
+				slices.Reverse(cycle)
+				return cycle
+			}
+			if _, seen := via[ref.target]; !seen && slices.Contains(scc, ref.target) {
+				via[ref.target] = ref
+				queue = append(queue, ref.target)
+			}
+		}
+	}
+	return nil
+}
+
+// describeReferenceCycle renders a cycle as "A.b -> B.a -> A".
+func describeReferenceCycle(spec *ast.Spec, cycle []requiredReference) string {
+	parts := make([]string, 0, len(cycle)+1)
+	for _, ref := range cycle {
+		e := spec.Entities[ref.entity]
+		parts = append(parts, fmt.Sprintf("%s.%s", e.Name, e.Fields[ref.field].Name))
+	}
+	parts = append(parts, spec.Entities[cycle[0].entity].Name)
+	return joinArrow(parts)
+}
+
+// CheckRelationships validates the foreign keys of relationships.
+//
+//   - RULE-48: A relationship's foreign_key must name a field of its target
+//     that refers back to the declaring entity. A relationship of cardinality
+//     one may instead name a field of the declaring entity that refers to
+//     the target
+func CheckRelationships(spec *ast.Spec, st *SymbolTable) []report.Finding {
+	var findings []report.Finding
+
+	for i, e := range spec.Entities {
+		for j, rel := range e.Relationships {
+			if _, problem := relationshipForeignKey(st, e.Name, rel); problem != "" {
+				findings = append(findings, report.NewError(
+					"RULE-48",
+					fmt.Sprintf("Relationship '%s' of '%s' has foreign key '%s', but %s", rel.Name, e.Name, rel.ForeignKey, problem),
+					report.Location{File: spec.File, Path: fmt.Sprintf("$.entities[%d].relationships[%d].foreign_key", i, j)},
+				))
+			}
+		}
+	}
+
+	return findings
+}
+
+// foreignKey is the field a relationship is read through.
+type foreignKey struct {
+	entity, field string // the entity declaring the field, and its name
+}
+
+// relationshipForeignKey returns the field the relationship rel of source
+// is read through, or a description of why its foreign key names no such
+// field, as in "'Session' has no field 'usr'". Relationships whose target
+// is not declared (RULE-03) or is imported through a use declaration are
+// not resolved, and yield neither.
+func relationshipForeignKey(st *SymbolTable, source string, rel ast.Relationship) (*foreignKey, string) {
+	target, ok := triggerEntityMembers(st, rel.TargetEntity)
+	if !ok || st.LookupUseDeclaration(rel.TargetEntity) != nil {
+		return nil, ""
+	}
+	if f := target.field(rel.ForeignKey); f != nil {
+		if !refersTo(f.Type, source) {
+			return nil, fmt.Sprintf("'%s.%s' does not refer to '%s'", rel.TargetEntity, f.Name, source)
+		}
+		return &foreignKey{rel.TargetEntity, f.Name}, ""
+	}
+
+	own, _ := triggerEntityMembers(st, source)
+	if f := own.field(rel.ForeignKey); f != nil && refersTo(f.Type, rel.TargetEntity) {
+		if rel.Cardinality != "one" {
+			return nil, fmt.Sprintf("'%s' has no field '%s'; '%s.%s' refers to a single '%s', so only a relationship of cardinality one can use it",
+				rel.TargetEntity, rel.ForeignKey, source, f.Name, rel.TargetEntity)
+		}
+		return &foreignKey{source, f.Name}, ""
+	}
+	return nil, fmt.Sprintf("'%s' has no field '%s'%s", rel.TargetEntity, rel.ForeignKey,
+		didYouMean(rel.ForeignKey, target.names(true, false)))
+}
+
+// refersTo reports whether a field of type ft holds a reference to a single
+// instance of entity, possibly absent.
+func refersTo(ft ast.FieldType, entity string) bool {
+	if ft.Kind == "optional" && ft.Inner != nil {
+		ft = *ft.Inner
+	}
+	return ft.Kind == "entity_ref" && ft.Entity == entity
+}
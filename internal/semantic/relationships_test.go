@@ -0,0 +1,105 @@
+package semantic
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/foundry-zero/allium/internal/ast"
+)
+
+func TestRelationshipGraphMetrics(t *testing.T) {
+	ref := func(entity string) ast.FieldType { return ast.FieldType{Kind: "entity_ref", Entity: entity} }
+	spec := &ast.Spec{
+		Entities: []ast.Entity{
+			{Name: "Account", Relationships: []ast.Relationship{{Name: "orders", TargetEntity: "Order", ForeignKey: "account", Cardinality: "many"}}},
+			{Name: "Order", Fields: []ast.Field{
+				{Name: "account", Type: ref("Account")},
+				{Name: "items", Type: ast.FieldType{Kind: "list", Element: &ast.FieldType{Kind: "entity_ref", Entity: "Item"}}},
+			}},
+			{Name: "Item", Fields: []ast.Field{{Name: "order", Type: ref("Order")}, {Name: "product", Type: ref("Product")}}},
+		},
+		ExternalEntities: []ast.ExternalEntity{{Name: "Product"}},
+	}
+
+	got := RelationshipGraphMetrics(spec)
+	want := []RelationshipMetrics{
+		// The relationship is the inverse of Order.account, not another edge.
+		{Name: "Account", Kind: "entity", FanOut: 0, FanIn: 1, Depth: 0},
+		// Order and Item reference each other, so they share a depth.
+		{Name: "Order", Kind: "entity", FanOut: 2, FanIn: 1, Depth: 1, InCycle: true},
+		{Name: "Item", Kind: "entity", FanOut: 2, FanIn: 1, Depth: 1, InCycle: true},
+		{Name: "Product", Kind: "external_entity", FanOut: 0, FanIn: 1, Depth: 0},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %+v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("metrics[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+// relationshipSpec returns a spec in which Account declares the given
+// relationships. An Order refers to its Account and a Profile may refer to
+// one, and the Account refers to its primary Order.
+func relationshipSpec(rels ...ast.Relationship) *ast.Spec {
+	ref := func(entity string) ast.FieldType { return ast.FieldType{Kind: "entity_ref", Entity: entity} }
+	return &ast.Spec{
+		Entities: []ast.Entity{
+			{Name: "Account", Fields: []ast.Field{{Name: "primary_order", Type: ref("Order")}}, Relationships: rels},
+			{Name: "Order", Fields: []ast.Field{{Name: "account", Type: ref("Account")}, {Name: "total", Type: ast.FieldType{Kind: "primitive", Value: "Decimal"}}}},
+			{Name: "Profile", Fields: []ast.Field{{Name: "account", Type: ast.FieldType{Kind: "optional", Inner: &ast.FieldType{Kind: "entity_ref", Entity: "Account"}}}}},
+		},
+	}
+}
+
+func TestCheckRelationships_RULE48(t *testing.T) {
+	tests := []struct {
+		name    string
+		rel     ast.Relationship
+		message string
+	}{
+		{name: "inverse of target field", rel: ast.Relationship{Name: "orders", TargetEntity: "Order", ForeignKey: "account", Cardinality: "many"}},
+		{name: "optional target field", rel: ast.Relationship{Name: "profile", TargetEntity: "Profile", ForeignKey: "account", Cardinality: "one"}},
+		{name: "field of the declaring entity", rel: ast.Relationship{Name: "primary", TargetEntity: "Order", ForeignKey: "primary_order", Cardinality: "one"}},
+		{name: "undeclared target", rel: ast.Relationship{Name: "items", TargetEntity: "Item", ForeignKey: "account", Cardinality: "many"}},
+		{
+			name:    "misspelt field",
+			rel:     ast.Relationship{Name: "orders", TargetEntity: "Order", ForeignKey: "acount", Cardinality: "many"},
+			message: "Relationship 'orders' of 'Account' has foreign key 'acount', but 'Order' has no field 'acount' (did you mean 'account'?)",
+		},
+		{
+			name:    "field refers elsewhere",
+			rel:     ast.Relationship{Name: "orders", TargetEntity: "Order", ForeignKey: "total", Cardinality: "many"},
+			message: "'Order.total' does not refer to 'Account'",
+		},
+		{
+			name:    "field of the declaring entity for many",
+			rel:     ast.Relationship{Name: "primaries", TargetEntity: "Order", ForeignKey: "primary_order", Cardinality: "many"},
+			message: "only a relationship of cardinality one can use it",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := relationshipSpec(tt.rel)
+			findings := CheckRelationships(spec, BuildSymbolTable(spec))
+			if tt.message == "" {
+				if len(findings) != 0 {
+					t.Errorf("expected no findings, got %v", findings)
+				}
+				return
+			}
+			if len(findings) != 1 || findings[0].Rule != "RULE-48" {
+				t.Fatalf("expected 1 RULE-48 finding, got %v", findings)
+			}
+			if findings[0].Location.Path != "$.entities[0].relationships[0].foreign_key" {
+				t.Errorf("path = %q", findings[0].Location.Path)
+			}
+			if !strings.Contains(findings[0].Message, tt.message) {
+				t.Errorf("message %q does not contain %q", findings[0].Message, tt.message)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package semantic
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/report"
+)
+
+// CheckRetention validates entity retention policies.
+//
+//   - RULE-36: A retention policy must reference a Timestamp field and a declared
+//     status value, and must be realized by a temporal rule on the entity that
+//     removes (or archives) expired instances
+func CheckRetention(spec *ast.Spec, st *SymbolTable) []report.Finding {
+	var findings []report.Finding
+
+	for i, entity := range spec.Entities {
+		policy := entity.Retention
+		if policy == nil {
+			continue
+		}
+		path := fmt.Sprintf("$.entities[%d].retention", i)
+
+		if policy.From != "" && !isTimestampField(st.ResolveFields(entity.Fields), policy.From) {
+			findings = append(findings, report.NewError(
+				"RULE-36",
+				fmt.Sprintf("Retention on '%s' is measured from '%s', which is not a Timestamp field", entity.Name, policy.From),
+				report.Location{File: spec.File, Path: path + ".from"},
+			))
+		}
+
+		statusField := ""
+		if policy.ArchivalState != "" {
+			var values []string
+			statusField, values = findStatusEnum(entity, st)
+			if !slices.Contains(values, policy.ArchivalState) {
+				findings = append(findings, report.NewError(
+					"RULE-36",
+					fmt.Sprintf("Retention archival state '%s' is not a status value of '%s'", policy.ArchivalState, entity.Name),
+					report.Location{File: spec.File, Path: path + ".archival_state"},
+				))
+				continue
+			}
+		}
+
+		if !isRetentionRealized(spec, entity.Name, policy, statusField) {
+			action := "removes"
+			if policy.ArchivalState != "" {
+				action = fmt.Sprintf("removes or archives (to '%s')", policy.ArchivalState)
+			}
+			findings = append(findings, report.NewError(
+				"RULE-36",
+				fmt.Sprintf("Retention of %s on '%s' is not realized: no temporal rule %s expired instances", policy.Duration, entity.Name, action),
+				report.Location{File: spec.File, Path: path},
+			))
+		}
+	}
+
+	return findings
+}
+
+// isTimestampField returns true if the named field is a (possibly optional) Timestamp.
+func isTimestampField(fields []ast.Field, name string) bool {
+	for _, f := range fields {
+		if f.Name != name {
+			continue
+		}
+		ft := f.Type
+		if ft.Kind == "optional" && ft.Inner != nil {
+			ft = *ft.Inner
+		}
+		return ft.Kind == "primitive" && ft.Value == "Timestamp"
+	}
+	return false
+}
+
+// isRetentionRealized reports whether some temporal rule on the entity expires
+// instances according to the policy. When the policy names a From field the
+// rule's condition must reference it.
+func isRetentionRealized(spec *ast.Spec, entityName string, policy *ast.Retention, statusField string) bool {
+	for _, rule := range spec.Rules {
+		trig := rule.Trigger
+		if trig.Kind != "temporal" || trig.Entity != entityName || trig.Binding == "" {
+			continue
+		}
+		if policy.From != "" && !slices.Contains(extractFieldNames(trig.Condition, trig.Binding), policy.From) {
+			continue
+		}
+		for _, ec := range rule.Ensures {
+			if ensuresExpires(ec, trig.Binding, statusField, policy.ArchivalState) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ensuresExpires reports whether an ensures clause (or any nested clause)
+// removes the bound instance or moves its status field to the archival state.
+func ensuresExpires(ec ast.EnsuresClause, binding, statusField, archivalState string) bool {
+	switch ec.Kind {
+	case "entity_removal":
+		if isRootAccess(ec.Target, binding) {
+			return true
+		}
+	case "state_change":
+		if archivalState != "" && ec.Target != nil && ec.Target.Kind == "field_access" &&
+			ec.Target.Field == statusField && isRootAccess(ec.Target.Object, binding) &&
+			extractRawValue(ec.Value) == archivalState {
+			return true
+		}
+	}
+
+	for _, nested := range [][]ast.EnsuresClause{ec.Then, ec.Else, ec.Body} {
+		for _, inner := range nested {
+			if ensuresExpires(inner, binding, statusField, archivalState) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isRootAccess reports whether expr is a bare reference to the named binding.
+func isRootAccess(expr *ast.Expression, name string) bool {
+	return expr != nil && expr.Kind == "field_access" && expr.Object == nil && expr.Field == name
+}
@@ -0,0 +1,124 @@
+package semantic
+
+import (
+	"testing"
+
+	"github.com/foundry-zero/allium/internal/ast"
+)
+
+func retentionSpec() *ast.Spec {
+	return &ast.Spec{
+		File: "test.allium.json",
+		Entities: []ast.Entity{
+			{
+				Name: "Session",
+				Fields: []ast.Field{
+					{Name: "status", Type: ast.FieldType{Kind: "inline_enum", Values: []string{"active", "archived"}}},
+					{Name: "created_at", Type: ast.FieldType{Kind: "primitive", Value: "Timestamp"}},
+					{Name: "token", Type: ast.FieldType{Kind: "primitive", Value: "String"}},
+				},
+				Retention: &ast.Retention{Duration: "30.days", From: "created_at"},
+			},
+		},
+		Rules: []ast.Rule{
+			{
+				Name: "ExpireSession",
+				Trigger: ast.Trigger{
+					Kind:    "temporal",
+					Binding: "session",
+					Entity:  "Session",
+					Condition: comparisonExpr("<=",
+						&ast.Expression{Kind: "field_access", Object: fieldAccess("session"), Field: "created_at"},
+						tsLitExpr("now")),
+				},
+				Ensures: []ast.EnsuresClause{
+					{Kind: "entity_removal", Target: fieldAccess("session")},
+				},
+			},
+		},
+	}
+}
+
+func TestCheckRetention_Realized(t *testing.T) {
+	spec := retentionSpec()
+	findings := CheckRetention(spec, BuildSymbolTable(spec))
+	if len(findings) != 0 {
+		t.Errorf("expected 0 findings, got %v", findings)
+	}
+}
+
+func TestCheckRetention_NoRule(t *testing.T) {
+	spec := retentionSpec()
+	spec.Rules = nil
+	findings := CheckRetention(spec, BuildSymbolTable(spec))
+	f := findingWithRule(findings, "RULE-36")
+	if f == nil {
+		t.Fatal("expected RULE-36 when no rule realizes retention")
+	}
+	if f.Location.Path != "$.entities[0].retention" {
+		t.Errorf("path = %q", f.Location.Path)
+	}
+}
+
+func TestCheckRetention_RuleIgnoresFromField(t *testing.T) {
+	spec := retentionSpec()
+	spec.Entities[0].Retention.From = "token"
+	spec.Entities[0].Fields = append(spec.Entities[0].Fields,
+		ast.Field{Name: "last_seen", Type: ast.FieldType{Kind: "optional", Inner: &ast.FieldType{Kind: "primitive", Value: "Timestamp"}}})
+
+	findings := CheckRetention(spec, BuildSymbolTable(spec))
+	if n := len(findingsWithRule(findings, "RULE-36")); n != 2 {
+		t.Fatalf("expected 2 RULE-36 findings (non-timestamp from, unrealized), got %d: %v", n, findings)
+	}
+
+	// An optional Timestamp is a valid anchor, but the rule still measures from created_at.
+	spec.Entities[0].Retention.From = "last_seen"
+	findings = CheckRetention(spec, BuildSymbolTable(spec))
+	if n := len(findingsWithRule(findings, "RULE-36")); n != 1 {
+		t.Fatalf("expected 1 RULE-36 finding, got %d: %v", n, findings)
+	}
+}
+
+func TestCheckRetention_Archival(t *testing.T) {
+	spec := retentionSpec()
+	spec.Entities[0].Retention.ArchivalState = "archived"
+	spec.Rules[0].Ensures = []ast.EnsuresClause{
+		{
+			Kind:      "conditional",
+			Condition: boolLitExpr(true),
+			Then: []ast.EnsuresClause{
+				{
+					Kind:   "state_change",
+					Target: &ast.Expression{Kind: "field_access", Object: fieldAccess("session"), Field: "status"},
+					Value:  rawExpr("archived"),
+				},
+			},
+		},
+	}
+
+	findings := CheckRetention(spec, BuildSymbolTable(spec))
+	if len(findings) != 0 {
+		t.Errorf("expected archival transition to realize retention, got %v", findings)
+	}
+}
+
+func TestCheckRetention_UndeclaredArchivalState(t *testing.T) {
+	spec := retentionSpec()
+	spec.Entities[0].Retention.ArchivalState = "frozen"
+
+	findings := CheckRetention(spec, BuildSymbolTable(spec))
+	f := findingWithRule(findings, "RULE-36")
+	if f == nil || f.Location.Path != "$.entities[0].retention.archival_state" {
+		t.Fatalf("expected RULE-36 on archival_state, got %v", findings)
+	}
+}
+
+func TestCheckRetention_WrongEntity(t *testing.T) {
+	spec := retentionSpec()
+	spec.Rules[0].Trigger.Entity = "Other"
+
+	findings := CheckRetention(spec, BuildSymbolTable(spec))
+	if findingWithRule(findings, "RULE-36") == nil {
+		t.Error("temporal rule on another entity should not realize retention")
+	}
+}
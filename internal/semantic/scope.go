@@ -0,0 +1,68 @@
+package semantic
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/report"
+)
+
+// checkReadNames reports, under rule, the names expr reads that are not in
+// scope, with the message unbound returns for the name (or not at all if it
+// returns ""), and the members it
+// reads that the entity reached so far does not declare. subject names the
+// declaration expr belongs to in messages. A lambda binds its parameter for
+// its body, typed as an element of the collection it ranges over.
+func checkReadNames(findings []report.Finding, spec *ast.Spec, st *SymbolTable, rule, subject string, expr *ast.Expression,
+	scope map[string]bool, types map[string]*ast.FieldType, unbound func(string) string, path *ast.Path) []report.Finding {
+	ast.WalkExpression(expr, path, func(e *ast.Expression, path *ast.Path) bool {
+		switch e.Kind {
+		case "field_access":
+			if e.Object == nil {
+				if !scope[e.Field] {
+					if msg := unbound(e.Field); msg != "" {
+						findings = append(findings, report.NewError(rule, msg, report.Location{File: spec.File, Path: path.String()}))
+					}
+				}
+				return false
+			}
+			objType := resolveFieldAccessType(e.Object, types, st)
+			for objType != nil && objType.Kind == "optional" {
+				objType = objType.Inner
+			}
+			if objType == nil || objType.Kind != "entity_ref" {
+				return true
+			}
+			members, ok := triggerEntityMembers(st, objType.Entity)
+			if !ok || members.field(e.Field) != nil || slices.Contains(members.derived, e.Field) || slices.Contains(members.related, e.Field) {
+				return true
+			}
+			findings = append(findings, report.NewError(
+				rule,
+				fmt.Sprintf("%s reads '%s', but '%s' has no member '%s'%s", subject, exprPath(e), objType.Entity, e.Field,
+					didYouMean(e.Field, members.names(true, true))),
+				report.Location{File: spec.File, Path: path.String()},
+			))
+		case "collection_op":
+			l := e.Lambda
+			if l == nil || l.Parameter == "" {
+				return true
+			}
+			var element *ast.FieldType
+			if ct := inferExprType(e.Collection, types, st); ct != nil && (ct.Kind == "set" || ct.Kind == "list") {
+				element = ct.Element
+			}
+			inner := maps.Clone(scope)
+			inner[l.Parameter] = true
+			findings = checkReadNames(findings, spec, st, rule, subject, e.Collection, scope, types, unbound, path.Field("collection"))
+			findings = checkReadNames(findings, spec, st, rule, subject, e.Condition, scope, types, unbound, path.Field("condition"))
+			findings = checkReadNames(findings, spec, st, rule, subject, l.Body, inner, withBinding(types, l.Parameter, element),
+				unbound, path.Field("lambda").Field("body"))
+			return false
+		}
+		return true
+	})
+	return findings
+}
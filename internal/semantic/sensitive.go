@@ -0,0 +1,254 @@
+package semantic
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/report"
+)
+
+// CheckSensitiveExposure reports surfaces exposing sensitive data.
+//
+//   - RULE-62: a surface must not expose a member marked sensitive, in the
+//     spec's metadata.sensitive_fields or the project configuration, to an
+//     actor the marks do not allow: directly, through a derived value
+//     computed from it, or through an exposed entity whose fields and
+//     relationships lead to it
+func CheckSensitiveExposure(spec *ast.Spec, st *SymbolTable) []report.Finding {
+	marks := sensitiveMarks{spec.Metadata.SensitiveFields, st.SensitiveFields}
+	if len(marks[0]) == 0 && len(marks[1]) == 0 {
+		return nil
+	}
+	var findings []report.Finding
+	for i, s := range spec.Surfaces {
+		types := surfaceFieldTypes(s, spec, st)
+		for j, exp := range s.Exposes {
+			if exp.Expression == nil {
+				continue
+			}
+			x := &exposure{spec: spec, st: st, marks: marks, audience: s.Facing.Type, seen: make(map[string]bool)}
+			x.reads(exp.Expression, "", types, nil, make(map[string]bool))
+			x.entity(exp.Expression, types)
+
+			path := ast.IndexPath(fmt.Sprintf("$.surfaces[%d]", i), "exposes", j)
+			for _, l := range x.leaks {
+				f := report.NewError(
+					"RULE-62",
+					fmt.Sprintf("Surface '%s' exposes sensitive field '%s' to '%s' via %s", s.Name, l.member, s.Facing.Type, l.via),
+					report.Location{File: spec.File, Path: path},
+				)
+				if l.via == exprPath(exp.Expression) {
+					f = f.WithSuggestion(fmt.Sprintf("Stop exposing '%s'", l.via), report.RemoveEdit(path))
+				}
+				findings = append(findings, f)
+			}
+		}
+	}
+	return findings
+}
+
+// sensitiveMarks are the sensitive members marked by each source, the
+// spec's metadata and the project configuration, by type and then member,
+// with the actors allowed to see each.
+type sensitiveMarks []map[string]map[string][]string
+
+// hidden reports whether the member of typeName, or of the entity a variant
+// extends, is marked sensitive and some mark does not allow audience to see
+// it. It returns the member as "Type.member", naming the type marking it.
+func (m sensitiveMarks) hidden(st *SymbolTable, typeName, member, audience string) (string, bool) {
+	owners := []string{typeName}
+	if v := st.LookupVariant(typeName); v != nil && v.BaseEntity != typeName {
+		owners = append(owners, v.BaseEntity)
+	}
+	for _, owner := range owners {
+		marked, allowed := false, true
+		for _, source := range m {
+			if actors, ok := source[owner][member]; ok {
+				marked = true
+				allowed = allowed && slices.Contains(actors, audience)
+			}
+		}
+		if marked {
+			return owner + "." + member, !allowed
+		}
+	}
+	return "", false
+}
+
+// exposure collects the sensitive members one exposed expression reveals.
+type exposure struct {
+	spec     *ast.Spec
+	st       *SymbolTable
+	marks    sensitiveMarks
+	audience string // the surface's facing type
+	leaks    []leak
+	seen     map[string]bool // members already leaked
+}
+
+// leak is a sensitive member an exposed expression reveals, and the
+// traversal revealing it.
+type leak struct {
+	member, via string
+}
+
+func (x *exposure) leak(member, via string) {
+	if !x.seen[member] {
+		x.seen[member] = true
+		x.leaks = append(x.leaks, leak{member, via})
+	}
+}
+
+// reads records the sensitive members that the field accesses within expr
+// read, and those read by the derived values they access, in turn. Bare
+// names are members of owner, the type whose derived value expr is, or
+// bindings when owner is empty. via holds the accesses leading to expr, and
+// visited the derived values already followed.
+func (x *exposure) reads(expr *ast.Expression, owner string, types map[string]*ast.FieldType, via []string, visited map[string]bool) {
+	ast.Walk(expr, func(e *ast.Expression) bool {
+		if e.Kind != "field_access" {
+			return true
+		}
+		typeName := owner
+		if e.Object != nil {
+			objType := resolveFieldAccessType(e.Object, types, x.st)
+			for objType != nil && objType.Kind == "optional" {
+				objType = objType.Inner
+			}
+			if objType == nil || objType.Kind != "entity_ref" {
+				return true
+			}
+			typeName = objType.Entity
+		}
+		if typeName == "" {
+			return true
+		}
+		step := exprPath(e)
+		if step == "" {
+			step = typeName + "." + e.Field
+		}
+		if member, hidden := x.marks.hidden(x.st, typeName, e.Field, x.audience); hidden {
+			x.leak(member, strings.Join(append(via, step), " -> "))
+			return true
+		}
+		dvOwner, dv := derivedValue(x.st, typeName, e.Field)
+		if dv != nil && !visited[dvOwner+"."+dv.Name] {
+			visited[dvOwner+"."+dv.Name] = true
+			x.reads(dv.Expression, dvOwner, derivedFieldTypes(dvOwner, *dv, x.spec, x.st), append(slices.Clip(via), step), visited)
+		}
+		return true
+	})
+}
+
+// entity records the sensitive members reachable from the entity expr
+// evaluates to, if it does: those of the entity itself and of the entities
+// its fields and relationships lead to, each by its shortest path.
+func (x *exposure) entity(expr *ast.Expression, types map[string]*ast.FieldType) {
+	start := referencedType(inferExprType(expr, types, x.st))
+	if start == "" {
+		return
+	}
+	root := exprPath(expr)
+	if root == "" {
+		root = start
+	}
+	type step struct{ typeName, path string }
+	visited := map[string]bool{start: true}
+	queue := []step{{start, root}}
+	for len(queue) > 0 {
+		s := queue[0]
+		queue = queue[1:]
+		for _, m := range typeMembers(x.st, s.typeName) {
+			path := s.path + "." + m.name
+			if member, hidden := x.marks.hidden(x.st, s.typeName, m.name, x.audience); hidden {
+				x.leak(member, path)
+				continue
+			}
+			if next := referencedType(m.typ); next != "" && !visited[next] {
+				visited[next] = true
+				queue = append(queue, step{next, path})
+			}
+		}
+	}
+}
+
+// referencedType returns the type an entity_ref refers to, looking through
+// optional types and collections, or "".
+func referencedType(ft *ast.FieldType) string {
+	for ft != nil {
+		switch ft.Kind {
+		case "optional":
+			ft = ft.Inner
+		case "set", "list":
+			ft = ft.Element
+		case "entity_ref":
+			return ft.Entity
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+// typeMember is a named member of a type and its type, nil for derived values.
+type typeMember struct {
+	name string
+	typ  *ast.FieldType
+}
+
+// typeMembers returns the fields, relationships and derived values of an
+// entity, variant (with those of its base entity), external entity or value
+// type, in declaration order.
+func typeMembers(st *SymbolTable, typeName string) []typeMember {
+	var members []typeMember
+	fields := func(fs []ast.Field) {
+		for _, f := range st.ResolveFields(fs) {
+			members = append(members, typeMember{f.Name, &f.Type})
+		}
+	}
+	derived := func(dvs []ast.DerivedValue) {
+		for _, dv := range dvs {
+			members = append(members, typeMember{name: dv.Name})
+		}
+	}
+	if v := st.LookupVariant(typeName); v != nil {
+		fields(v.Fields)
+		if v.BaseEntity != typeName {
+			typeName = v.BaseEntity
+		}
+	}
+	if e := st.LookupEntity(typeName); e != nil {
+		fields(e.Fields)
+		for _, rel := range e.Relationships {
+			members = append(members, typeMember{rel.Name, memberType(st, typeName, rel.Name)})
+		}
+		derived(e.DerivedValues)
+	} else if ee := st.LookupExternalEntity(typeName); ee != nil {
+		fields(ee.Fields)
+	} else if vt := st.LookupValueType(typeName); vt != nil {
+		fields(vt.Fields)
+		derived(vt.DerivedValues)
+	}
+	return members
+}
+
+// derivedValue returns the derived value named name of an entity or value
+// type, or of the entity a variant extends, and the type declaring it.
+func derivedValue(st *SymbolTable, typeName, name string) (string, *ast.DerivedValue) {
+	if v := st.LookupVariant(typeName); v != nil && v.BaseEntity != typeName {
+		typeName = v.BaseEntity
+	}
+	var dvs []ast.DerivedValue
+	if e := st.LookupEntity(typeName); e != nil {
+		dvs = e.DerivedValues
+	} else if vt := st.LookupValueType(typeName); vt != nil {
+		dvs = vt.DerivedValues
+	}
+	for i := range dvs {
+		if dvs[i].Name == name {
+			return typeName, &dvs[i]
+		}
+	}
+	return "", nil
+}
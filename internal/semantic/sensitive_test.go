@@ -0,0 +1,91 @@
+package semantic
+
+import (
+	"testing"
+
+	"github.com/foundry-zero/allium/internal/ast"
+)
+
+// sensitiveSpec returns a spec whose User has a password hash, marked
+// sensitive and visible to Admin, and a derived value computed from it. A
+// Visitor profile exposes the hash, the email and the derived value, and an
+// Admin view exposes a Session, whose owner is a User.
+func sensitiveSpec() *ast.Spec {
+	str := ast.FieldType{Kind: "primitive", Value: "String"}
+	visitorField := func(field string) *ast.Expression {
+		return &ast.Expression{Kind: "field_access", Object: fieldAccess("visitor"), Field: field}
+	}
+	return &ast.Spec{
+		File: "test.allium.json",
+		Metadata: ast.Metadata{SensitiveFields: map[string]map[string][]string{
+			"User": {"password_hash": {"Admin"}},
+		}},
+		Entities: []ast.Entity{
+			{Name: "User",
+				Fields: []ast.Field{{Name: "email", Type: str}, {Name: "password_hash", Type: str}},
+				DerivedValues: []ast.DerivedValue{{Name: "password_strength", Expression: &ast.Expression{
+					Kind: "function_call", FuncName: "length", FuncArguments: []ast.Expression{*fieldAccess("password_hash")},
+				}}}},
+			{Name: "Session", Fields: []ast.Field{{Name: "owner", Type: ast.FieldType{Kind: "entity_ref", Entity: "User"}}}},
+		},
+		Actors: []ast.Actor{
+			{Name: "Visitor", IdentifiedBy: ast.IdentifiedBy{Entity: "User"}},
+			{Name: "Admin", IdentifiedBy: ast.IdentifiedBy{Entity: "User"}},
+		},
+		Surfaces: []ast.Surface{
+			{Name: "Profile", Facing: ast.FacingClause{Binding: "visitor", Type: "Visitor"},
+				Exposes: []ast.ExposesItem{
+					{Expression: visitorField("password_hash")},
+					{Expression: visitorField("email")},
+					{Expression: visitorField("password_strength")},
+				}},
+			{Name: "SessionView", Facing: ast.FacingClause{Binding: "admin", Type: "Admin"},
+				Context: &ast.ContextClause{Binding: "session", Type: "Session"},
+				Exposes: []ast.ExposesItem{{Expression: fieldAccess("session")}}},
+		},
+	}
+}
+
+func TestCheckSensitiveExposure(t *testing.T) {
+	spec := sensitiveSpec()
+	findings := CheckSensitiveExposure(spec, BuildSymbolTable(spec))
+	want := []struct{ path, message string }{
+		{"$.surfaces[0].exposes[0]", "Surface 'Profile' exposes sensitive field 'User.password_hash' to 'Visitor' via visitor.password_hash"},
+		{"$.surfaces[0].exposes[2]", "Surface 'Profile' exposes sensitive field 'User.password_hash' to 'Visitor' via visitor.password_strength -> password_hash"},
+	}
+	if len(findings) != len(want) {
+		t.Fatalf("expected %d findings, got %v", len(want), findings)
+	}
+	for i, w := range want {
+		if f := findings[i]; f.Rule != "RULE-62" || f.Location.Path != w.path || f.Message != w.message {
+			t.Errorf("finding %d = %s %s %q, want RULE-62 %s %q", i, f.Rule, f.Location.Path, f.Message, w.path, w.message)
+		}
+	}
+	if s := findings[0].Suggestions; len(s) != 1 || s[0].Edits[0].Op != "remove" || s[0].Edits[0].Path != "$.surfaces[0].exposes[0]" {
+		t.Errorf("expected a suggestion to remove the exposes entry, got %+v", s)
+	}
+	if s := findings[1].Suggestions; len(s) != 0 {
+		t.Errorf("expected no suggestion through a derived value, got %+v", s)
+	}
+}
+
+func TestCheckSensitiveExposure_Configured(t *testing.T) {
+	spec := sensitiveSpec()
+	st := BuildSymbolTable(spec)
+	// The configuration allows no actor, so Admin is no longer allowed.
+	st.SensitiveFields = map[string]map[string][]string{"User": {"password_hash": {}}}
+	findings := CheckSensitiveExposure(spec, st)
+	if len(findings) != 3 {
+		t.Fatalf("expected 3 findings, got %v", findings)
+	}
+	f := findings[2]
+	if want := "Surface 'SessionView' exposes sensitive field 'User.password_hash' to 'Admin' via session.owner.password_hash"; f.Location.Path != "$.surfaces[1].exposes[0]" || f.Message != want {
+		t.Errorf("finding = %s %q, want %q", f.Location.Path, f.Message, want)
+	}
+
+	// Without marks there is nothing to report.
+	spec.Metadata.SensitiveFields = nil
+	if findings := CheckSensitiveExposure(spec, BuildSymbolTable(spec)); len(findings) != 0 {
+		t.Errorf("expected no findings without marks, got %v", findings)
+	}
+}
@@ -0,0 +1,219 @@
+package semantic
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/report"
+)
+
+// CheckStateChanges validates the targets of state_change, set_mutation and
+// entity_removal ensures clauses.
+//
+//   - RULE-46: A state_change whose target is a field of a binding with a
+//     known entity (the trigger binding, a typed given or for clause binding,
+//     or a let binding, in the rule or its ensures) must name a field that
+//     entity declares
+//   - RULE-54: An enum value literal it assigns to an enum field must be a
+//     value of that field's enum. Status fields are left to RULE-09
+//   - RULE-57: A state_change or set_mutation must not target a field of an
+//     external entity, and an entity_removal must not remove one. External
+//     entities are managed by another spec, which a rule asks for changes
+//     with a trigger_emission. Creating one is how a rule hands work to that
+//     spec, so is allowed
+func CheckStateChanges(spec *ast.Spec, st *SymbolTable) []report.Finding {
+	var findings []report.Finding
+
+	for i, rule := range spec.Rules {
+		types := ruleFieldTypes(rule, spec, st)
+		for j, ec := range rule.Ensures {
+			findings = checkStateChangeTargets(findings, spec, st, ec, types, fmt.Sprintf("$.rules[%d].ensures[%d]", i, j))
+		}
+	}
+
+	return findings
+}
+
+// checkStateChangeTargets checks the state_change clauses in an ensures tree.
+// Iteration and let bindings are typed for the clauses in their body, and
+// hide an outer binding of the same name even when their own type is unknown.
+func checkStateChangeTargets(findings []report.Finding, spec *ast.Spec, st *SymbolTable, ec ast.EnsuresClause,
+	types map[string]*ast.FieldType, path string) []report.Finding {
+	switch ec.Kind {
+	case "state_change":
+		findings = checkStateChangeTarget(findings, spec, st, ec.Target, types, path+".target")
+		findings = checkStateChangeValue(findings, spec, st, ec, types, path+".value")
+		findings = checkExternalMutation(findings, spec, st, ec, types, path+".target")
+	case "set_mutation", "entity_removal":
+		findings = checkExternalMutation(findings, spec, st, ec, types, path+".target")
+	case "iteration":
+		var element *ast.FieldType
+		if ct := resolveFieldAccessType(ec.Collection, types, st); ct != nil && (ct.Kind == "set" || ct.Kind == "list") {
+			element = ct.Element
+		}
+		types = withBinding(types, ec.Binding, element)
+	case "let_binding":
+		types = withBinding(types, ec.Name, letValueType(ec.Value, types, st))
+	}
+	for j, then := range ec.Then {
+		findings = checkStateChangeTargets(findings, spec, st, then, types, ast.IndexPath(path, "then", j))
+	}
+	for j, el := range ec.Else {
+		findings = checkStateChangeTargets(findings, spec, st, el, types, ast.IndexPath(path, "else", j))
+	}
+	for j, body := range ec.Body {
+		findings = checkStateChangeTargets(findings, spec, st, body, types, ast.IndexPath(path, "body", j))
+	}
+	return findings
+}
+
+// withBinding returns a copy of types with name bound to ft, or unbound if ft
+// is nil.
+func withBinding(types map[string]*ast.FieldType, name string, ft *ast.FieldType) map[string]*ast.FieldType {
+	if name == "" {
+		return types
+	}
+	types = maps.Clone(types)
+	if ft == nil {
+		delete(types, name)
+	} else {
+		types[name] = ft
+	}
+	return types
+}
+
+// letValueType returns the type of an ensures let binding's value: the
+// entity it creates, or the inferred type of its expression.
+func letValueType(value json.RawMessage, types map[string]*ast.FieldType, st *SymbolTable) *ast.FieldType {
+	var creation ast.EnsuresClause
+	if json.Unmarshal(value, &creation) == nil && creation.Kind == "entity_creation" {
+		return &ast.FieldType{Kind: "entity_ref", Entity: creation.Entity}
+	}
+	var expr ast.Expression
+	if json.Unmarshal(value, &expr) == nil && expr.Kind != "" {
+		return inferExprType(&expr, types, st)
+	}
+	return nil
+}
+
+// checkStateChangeTarget checks that a target such as user.status names a
+// field of the entity its object refers to. Targets whose object cannot be
+// typed, such as a field of an untyped trigger parameter, are not checked;
+// neither are entities imported through a use declaration, whose fields are
+// not known here. A relationship is a declared member, so is accepted.
+func checkStateChangeTarget(findings []report.Finding, spec *ast.Spec, st *SymbolTable, target *ast.Expression,
+	types map[string]*ast.FieldType, path string) []report.Finding {
+	if target == nil || target.Kind != "field_access" || target.Object == nil {
+		return findings
+	}
+	objType := resolveFieldAccessType(target.Object, types, st)
+	for objType != nil && objType.Kind == "optional" {
+		objType = objType.Inner
+	}
+	if objType == nil || objType.Kind != "entity_ref" || st.LookupUseDeclaration(objType.Entity) != nil {
+		return findings
+	}
+	members, ok := triggerEntityMembers(st, objType.Entity)
+	if !ok || members.field(target.Field) != nil || slices.Contains(members.related, target.Field) {
+		return findings
+	}
+
+	name := exprPath(target)
+	if name == "" {
+		name = target.Field
+	}
+	if slices.Contains(members.derived, target.Field) {
+		return append(findings, report.NewError(
+			"RULE-46",
+			fmt.Sprintf("State change targets '%s', but '%s' is a derived value of '%s' and cannot be assigned", name, target.Field, objType.Entity),
+			report.Location{File: spec.File, Path: path},
+		))
+	}
+	return append(findings, report.NewError(
+		"RULE-46",
+		fmt.Sprintf("State change targets '%s', but '%s' has no field '%s'%s", name, objType.Entity, target.Field, didYouMean(target.Field, members.names(true, false))),
+		report.Location{File: spec.File, Path: path},
+	))
+}
+
+// checkStateChangeValue checks that an enum value literal assigned to an enum
+// field is one of its values. The status field of an entity, the first of
+// enum type, is checked by RULE-09 with the rest of its state machine.
+func checkStateChangeValue(findings []report.Finding, spec *ast.Spec, st *SymbolTable, ec ast.EnsuresClause,
+	types map[string]*ast.FieldType, path string) []report.Finding {
+	var value ast.Expression
+	if json.Unmarshal(ec.Value, &value) != nil || value.Kind != "literal" || value.Type != "enum_value" {
+		return findings
+	}
+	ft := resolveFieldAccessType(ec.Target, types, st)
+	if ft == nil {
+		return findings
+	}
+	values, ok := enumValuesOf(st, *ft)
+	if !ok {
+		return findings
+	}
+	if objType := resolveFieldAccessType(ec.Target.Object, types, st); objType != nil && objType.Kind == "entity_ref" {
+		if e := st.LookupEntity(objType.Entity); e != nil {
+			if status, _ := findStatusEnum(*e, st); status == ec.Target.Field {
+				return findings
+			}
+		}
+	}
+	if v := extractLiteralValue(&value); !slices.Contains(values, v) {
+		findings = append(findings, report.NewError(
+			"RULE-54",
+			fmt.Sprintf("Enum value '%s' is not a value of %s (%s)%s", v, operandName(ec.Target), strings.Join(values, " | "), didYouMean(v, values)),
+			report.Location{File: spec.File, Path: path},
+		))
+	}
+	return findings
+}
+
+// checkExternalMutation reports a state_change or set_mutation of a field of
+// an external entity, or the entity_removal of one. Bare field targets are
+// not checked, as for RULE-46.
+func checkExternalMutation(findings []report.Finding, spec *ast.Spec, st *SymbolTable, ec ast.EnsuresClause,
+	types map[string]*ast.FieldType, path string) []report.Finding {
+	target := ec.Target
+	if target == nil || target.Kind != "field_access" {
+		return findings
+	}
+	owner := target
+	if ec.Kind != "entity_removal" {
+		if target.Object == nil {
+			return findings
+		}
+		owner = target.Object
+	}
+	ft := resolveFieldAccessType(owner, types, st)
+	for ft != nil && ft.Kind == "optional" {
+		ft = ft.Inner
+	}
+	if ft == nil || ft.Kind != "entity_ref" || st.LookupExternalEntity(ft.Entity) == nil {
+		return findings
+	}
+
+	name := exprPath(target)
+	if name == "" {
+		name = target.Field
+	}
+	var msg string
+	switch ec.Kind {
+	case "entity_removal":
+		msg = fmt.Sprintf("Removes '%s', an instance of external entity '%s', which is managed outside this spec", name, ft.Entity)
+	case "set_mutation":
+		msg = fmt.Sprintf("Set mutation of '%s' changes external entity '%s', which is managed outside this spec", name, ft.Entity)
+	default:
+		msg = fmt.Sprintf("State change of '%s' changes external entity '%s', which is managed outside this spec", name, ft.Entity)
+	}
+	return append(findings, report.NewError(
+		"RULE-57",
+		msg+"; emit a trigger for its owning spec to handle instead",
+		report.Location{File: spec.File, Path: path},
+	))
+}
@@ -0,0 +1,212 @@
+package semantic
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/report"
+)
+
+// stateChange returns a state_change assigning binding.field.
+func stateChange(binding, field string) ast.EnsuresClause {
+	value, _ := json.Marshal(fieldAccess("value"))
+	return ast.EnsuresClause{
+		Kind:   "state_change",
+		Target: &ast.Expression{Kind: "field_access", Object: fieldAccess(binding), Field: field},
+		Value:  value,
+	}
+}
+
+// stateChangeSpec returns the trigger test spec with a rule triggered by an
+// Account state transition and the given ensures. Account also has a set of
+// payments.
+func stateChangeSpec(ensures ...ast.EnsuresClause) *ast.Spec {
+	spec := triggerSpec(ast.Trigger{Kind: "state_transition", Binding: "account", Entity: "Account", Field: "status", ToValue: "closed"})
+	spec.Entities[0].Fields = append(spec.Entities[0].Fields, ast.Field{Name: "payments",
+		Type: ast.FieldType{Kind: "set", Element: &ast.FieldType{Kind: "entity_ref", Entity: "Payment"}}})
+	spec.Rules[0].Ensures = ensures
+	return spec
+}
+
+func TestCheckStateChanges_Valid(t *testing.T) {
+	creation, err := json.Marshal(creation("Business", "tier"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, ec := range map[string]ast.EnsuresClause{
+		"trigger binding": stateChange("account", "status"),
+		"iteration": {Kind: "iteration", Binding: "p", Collection: accountField("payments"),
+			Body: []ast.EnsuresClause{stateChange("p", "state")}},
+		"base field through variant": {Kind: "let_binding", Name: "b", Value: creation,
+			Body: []ast.EnsuresClause{stateChange("b", "name")}},
+		"untyped binding": stateChange("request", "anything"),
+	} {
+		spec := stateChangeSpec(ec)
+		if findings := withoutExternalMutations(CheckStateChanges(spec, BuildSymbolTable(spec))); len(findings) != 0 {
+			t.Errorf("%s: expected no findings, got %v", name, findings)
+		}
+	}
+}
+
+func TestCheckStateChanges_RULE46(t *testing.T) {
+	creation, err := json.Marshal(creation("Business", "tier"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		ensures ast.EnsuresClause
+		path    string
+		message string
+	}{
+		{
+			name:    "misspelt field",
+			ensures: stateChange("account", "stauts"),
+			path:    "$.rules[0].ensures[0].target",
+			message: "'Account' has no field 'stauts' (did you mean 'status'?)",
+		},
+		{
+			name:    "derived value",
+			ensures: stateChange("account", "is_dormant"),
+			path:    "$.rules[0].ensures[0].target",
+			message: "'is_dormant' is a derived value of 'Account'",
+		},
+		{
+			name: "conditional in iteration",
+			ensures: ast.EnsuresClause{Kind: "iteration", Binding: "p", Collection: accountField("payments"),
+				Body: []ast.EnsuresClause{{Kind: "conditional", Condition: fieldAccess("p"),
+					Then: []ast.EnsuresClause{stateChange("p", "status")}}}},
+			path:    "$.rules[0].ensures[0].body[0].then[0].target",
+			message: "State change targets 'p.status', but 'Payment' has no field 'status'",
+		},
+		{
+			name: "let binding",
+			ensures: ast.EnsuresClause{Kind: "let_binding", Name: "b", Value: creation,
+				Body: []ast.EnsuresClause{stateChange("b", "teir")}},
+			path:    "$.rules[0].ensures[0].body[0].target",
+			message: "did you mean 'tier'?",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := stateChangeSpec(tt.ensures)
+			findings := findingsWithRule(CheckStateChanges(spec, BuildSymbolTable(spec)), "RULE-46")
+			if len(findings) != 1 {
+				t.Fatalf("expected 1 RULE-46 finding, got %v", findings)
+			}
+			if findings[0].Location.Path != tt.path {
+				t.Errorf("path = %q, want %q", findings[0].Location.Path, tt.path)
+			}
+			if !strings.Contains(findings[0].Message, tt.message) {
+				t.Errorf("message %q does not contain %q", findings[0].Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestCheckStateChanges_Shadowing(t *testing.T) {
+	// An iteration binding of unknown type hides the trigger binding.
+	spec := stateChangeSpec(ast.EnsuresClause{Kind: "iteration", Binding: "account", Collection: fieldAccess("others"),
+		Body: []ast.EnsuresClause{stateChange("account", "anything")}})
+	if findings := CheckStateChanges(spec, BuildSymbolTable(spec)); len(findings) != 0 {
+		t.Errorf("expected the shadowed binding to be unchecked, got %v", findings)
+	}
+}
+
+func TestCheckStateChanges_RULE54(t *testing.T) {
+	creation, err := json.Marshal(creation("Business", "tier"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assign := func(binding, field, value string) ast.EnsuresClause {
+		ec := stateChange(binding, field)
+		ec.Value, _ = json.Marshal(enumLitExpr(value))
+		return ec
+	}
+	tests := []struct {
+		name    string
+		ensures ast.EnsuresClause
+		path    string
+		want    string
+	}{
+		{"iteration binding", ast.EnsuresClause{Kind: "iteration", Binding: "p", Collection: accountField("payments"),
+			Body: []ast.EnsuresClause{assign("p", "state", "setled")}},
+			"$.rules[0].ensures[0].body[0].value", "Enum value 'setled' is not a value of 'p.state' (pending | settled) (did you mean 'settled'?)"},
+		{"let binding", ast.EnsuresClause{Kind: "let_binding", Name: "b", Value: creation,
+			Body: []ast.EnsuresClause{assign("b", "tier", "gold")}},
+			"$.rules[0].ensures[0].body[0].value", "Enum value 'gold' is not a value of 'b.tier' (basic | plus)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := stateChangeSpec(tt.ensures)
+			findings := findingsWithRule(CheckStateChanges(spec, BuildSymbolTable(spec)), "RULE-54")
+			if len(findings) != 1 || findings[0].Message != tt.want {
+				t.Fatalf("expected RULE-54 %q, got %v", tt.want, findings)
+			}
+			if findings[0].Location.Path != tt.path {
+				t.Errorf("path = %q, want %q", findings[0].Location.Path, tt.path)
+			}
+		})
+	}
+
+	// Status fields are left to RULE-09, and declared values pass.
+	spec := stateChangeSpec(assign("account", "status", "frozen"), ast.EnsuresClause{Kind: "iteration", Binding: "p",
+		Collection: accountField("payments"), Body: []ast.EnsuresClause{assign("p", "state", "settled")}})
+	if findings := withoutExternalMutations(CheckStateChanges(spec, BuildSymbolTable(spec))); len(findings) != 0 {
+		t.Errorf("expected no findings, got %v", findings)
+	}
+}
+
+// withoutExternalMutations drops the RULE-57 findings for changes to
+// Payment, an external entity, from findings.
+func withoutExternalMutations(findings []report.Finding) []report.Finding {
+	return slices.DeleteFunc(findings, func(f report.Finding) bool { return f.Rule == "RULE-57" })
+}
+
+func TestCheckStateChanges_RULE57(t *testing.T) {
+	payment := &ast.Expression{Kind: "field_access", Object: fieldAccess("p"), Field: "state"}
+	overPayments := func(body ast.EnsuresClause) ast.EnsuresClause {
+		return ast.EnsuresClause{Kind: "iteration", Binding: "p", Collection: accountField("payments"), Body: []ast.EnsuresClause{body}}
+	}
+	tests := []struct {
+		name    string
+		ensures ast.EnsuresClause
+		want    string
+	}{
+		{"state change", overPayments(stateChange("p", "state")),
+			"State change of 'p.state' changes external entity 'Payment', which is managed outside this spec; emit a trigger for its owning spec to handle instead"},
+		{"set mutation", overPayments(ast.EnsuresClause{Kind: "set_mutation", Target: payment, Operation: "add", Value: rawExpr("x")}),
+			"Set mutation of 'p.state' changes external entity 'Payment', which is managed outside this spec; emit a trigger for its owning spec to handle instead"},
+		{"removal", overPayments(ast.EnsuresClause{Kind: "entity_removal", Target: fieldAccess("p")}),
+			"Removes 'p', an instance of external entity 'Payment', which is managed outside this spec; emit a trigger for its owning spec to handle instead"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := stateChangeSpec(tt.ensures)
+			findings := findingsWithRule(CheckStateChanges(spec, BuildSymbolTable(spec)), "RULE-57")
+			if len(findings) != 1 || findings[0].Message != tt.want {
+				t.Fatalf("expected RULE-57 %q, got %v", tt.want, findings)
+			}
+			if findings[0].Location.Path != "$.rules[0].ensures[0].body[0].target" {
+				t.Errorf("path = %q", findings[0].Location.Path)
+			}
+		})
+	}
+
+	// Entities declared by the spec may be changed and removed, and
+	// external entities created.
+	creation, err := json.Marshal(creation("Payment"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := stateChangeSpec(stateChange("account", "status"),
+		ast.EnsuresClause{Kind: "entity_removal", Target: fieldAccess("account")},
+		ast.EnsuresClause{Kind: "let_binding", Name: "p", Value: creation})
+	if findings := findingsWithRule(CheckStateChanges(spec, BuildSymbolTable(spec)), "RULE-57"); len(findings) != 0 {
+		t.Errorf("expected no RULE-57, got %v", findings)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"slices"
+	"strings"
 
 	"github.com/foundry-zero/allium/internal/ast"
 	"github.com/foundry-zero/allium/internal/report"
@@ -11,8 +12,15 @@ import (
 
 // CheckStateMachines analyzes entity lifecycle state machines.
 //
-//   - RULE-07: All status enum values must be reachable from creation points via BFS
-//   - RULE-08: Non-terminal status values must have at least one outgoing transition
+//   - RULE-07: All status enum values must be reachable via BFS from creation
+//     points: entity_creation ensures clauses, default instances and the
+//     values declared in st.InitialStates
+//   - RULE-08: Non-terminal status values must have at least one outgoing transition;
+//     values declared terminal in st.TerminalStates are exempt
+//
+// RULE-07 and RULE-08 findings name the rules that assign the value, or
+// for a value nothing assigns the rules that write the field, and point at
+// each of them as a related location.
 //   - RULE-09: Ensures clauses must only assign values declared in the enum
 func CheckStateMachines(spec *ast.Spec, st *SymbolTable) []report.Finding {
 	var findings []report.Finding
@@ -29,7 +37,8 @@ func CheckStateMachines(spec *ast.Spec, st *SymbolTable) []report.Finding {
 		}
 
 		// Collect creation values and transitions from rules
-		creationValues, transitions, undeclared := collectStateInfo(spec, st, entity.Name, enumField, valueSet)
+		creationValues, transitions, undeclared, sources := collectStateInfo(spec, st, entity.Name, enumField, valueSet)
+		entityLoc := report.Location{File: spec.File, Path: fmt.Sprintf("$.entities[%d]", i)}
 
 		// RULE-09: Report assignments to undeclared enum values
 		for _, u := range undeclared {
@@ -43,13 +52,26 @@ func CheckStateMachines(spec *ast.Spec, st *SymbolTable) []report.Finding {
 		// RULE-07: BFS reachability from creation values
 		reachable := bfsReachable(creationValues, transitions)
 		for _, v := range enumValues {
-			if !reachable[v] {
-				findings = append(findings, report.NewError(
-					"RULE-07",
-					fmt.Sprintf("Unreachable status value '%s' on '%s'", v, entity.Name),
-					report.Location{File: spec.File, Path: fmt.Sprintf("$.entities[%d]", i)},
-				))
+			if reachable[v] {
+				continue
+			}
+			msg := fmt.Sprintf("Unreachable status value '%s' on '%s'", v, entity.Name)
+			writers := stateWriters(spec, sources, func(e stateEdge) bool { return e.to == v })
+			if len(writers) > 0 {
+				msg += fmt.Sprintf(": assigned only from unreachable states, by rules: %s", writerNames(spec, writers))
+			} else {
+				msg += ": no rule creates or assigns it"
+				writers = stateWriters(spec, sources, func(stateEdge) bool { return true })
+				if len(writers) > 0 {
+					msg += fmt.Sprintf("; nearest writers of '%s': %s", enumField, writerNames(spec, writers))
+				}
 			}
+			f := report.NewError("RULE-07", msg, entityLoc)
+			for _, j := range writers {
+				f = f.WithRelated(fmt.Sprintf("rule '%s' writes '%s.%s'", spec.Rules[j].Name, entity.Name, enumField),
+					report.Location{File: spec.File, Path: fmt.Sprintf("$.rules[%d]", j)})
+			}
+			findings = append(findings, f)
 		}
 
 		// RULE-08: Non-terminal values must have outgoing transitions
@@ -59,16 +81,24 @@ func CheckStateMachines(spec *ast.Spec, st *SymbolTable) []report.Finding {
 		for from := range transitions {
 			outgoing[from] = true
 		}
+		terminal := st.TerminalStates[entity.Name][enumField]
 		for _, v := range enumValues {
-			if reachable[v] && !outgoing[v] && !isInCreationValues(v, creationValues) {
+			if reachable[v] && !outgoing[v] && !isInCreationValues(v, creationValues) && !slices.Contains(terminal, v) {
 				// Value is reachable but has no way out — could be terminal or dead-end
 				// We report it as RULE-08 (dead-end) since truly terminal states
 				// are intentional and rare; the spec author can suppress if intended
-				findings = append(findings, report.NewError(
+				writers := stateWriters(spec, sources, func(e stateEdge) bool { return e.to == v })
+				f := report.NewError(
 					"RULE-08",
-					fmt.Sprintf("Dead-end state '%s' on '%s' has no outgoing transition", v, entity.Name),
-					report.Location{File: spec.File, Path: fmt.Sprintf("$.entities[%d]", i)},
-				))
+					fmt.Sprintf("Dead-end state '%s' on '%s' has no outgoing transition: no rule transitions out of '%s'; reachable via rules: %s",
+						v, entity.Name, v, writerNames(spec, writers)),
+					entityLoc,
+				)
+				for _, j := range writers {
+					f = f.WithRelated(fmt.Sprintf("rule '%s' sets '%s.%s' to '%s'", spec.Rules[j].Name, entity.Name, enumField, v),
+						report.Location{File: spec.File, Path: fmt.Sprintf("$.rules[%d]", j)})
+				}
+				findings = append(findings, f)
 			}
 		}
 	}
@@ -76,10 +106,90 @@ func CheckStateMachines(spec *ast.Spec, st *SymbolTable) []report.Finding {
 	return findings
 }
 
+// StateMachine is the lifecycle of an entity's status field, as inferred from
+// the rules that create and update the entity.
+type StateMachine struct {
+	Entity      string       `json:"entity"`
+	Field       string       `json:"field"`
+	States      []string     `json:"states"`      // declared values, in declaration order
+	Initial     []string     `json:"initial"`     // values the entity is created or declared with
+	Transitions []Transition `json:"transitions"` // between declared values, sorted, without duplicates
+	Terminal    []string     `json:"terminal"`    // values with no transition out, or declared terminal, in declaration order
+}
+
+// Transition is a change of status from one value to another.
+type Transition struct {
+	From  string   `json:"from"`
+	To    string   `json:"to"`
+	Rules []string `json:"rules"` // rules whose ensures make the change, in declaration order
+}
+
+// StateMachines returns the state machine of every entity with an
+// enum-typed field, in declaration order. Transitions are those RULE-07 and
+// RULE-08 are checked against: the prior state of an assignment is
+// inferred from the rule's trigger, requires and enclosing conditionals,
+// and an assignment whose prior state is unknown is taken to be possible
+// from every other value. Transitions to values the
+// enum does not declare are left out; RULE-09 reports them. Terminal values
+// include those the project configuration declares terminal in
+// st.TerminalStates.
+func StateMachines(spec *ast.Spec, st *SymbolTable) []StateMachine {
+	var machines []StateMachine
+	for _, entity := range spec.Entities {
+		enumField, enumValues := findStatusEnum(entity, st)
+		if enumField == "" {
+			continue
+		}
+		valueSet := make(map[string]bool, len(enumValues))
+		for _, v := range enumValues {
+			valueSet[v] = true
+		}
+		creationValues, transitions, _, sources := collectStateInfo(spec, st, entity.Name, enumField, valueSet)
+
+		sm := StateMachine{
+			Entity:      entity.Name,
+			Field:       enumField,
+			States:      slices.Clone(enumValues),
+			Initial:     []string{},
+			Transitions: []Transition{},
+			Terminal:    []string{},
+		}
+		for _, v := range creationValues {
+			if !slices.Contains(sm.Initial, v) {
+				sm.Initial = append(sm.Initial, v)
+			}
+		}
+		seen := make(map[stateEdge]bool)
+		for from, targets := range transitions {
+			for _, to := range targets {
+				edge := stateEdge{from, to}
+				if valueSet[from] && valueSet[to] && !seen[edge] {
+					seen[edge] = true
+					sm.Transitions = append(sm.Transitions, Transition{From: from, To: to, Rules: sources[edge]})
+				}
+			}
+		}
+		slices.SortFunc(sm.Transitions, func(a, b Transition) int {
+			if c := strings.Compare(a.From, b.From); c != 0 {
+				return c
+			}
+			return strings.Compare(a.To, b.To)
+		})
+		terminal := st.TerminalStates[entity.Name][enumField]
+		for _, v := range enumValues {
+			if len(transitions[v]) == 0 || slices.Contains(terminal, v) {
+				sm.Terminal = append(sm.Terminal, v)
+			}
+		}
+		machines = append(machines, sm)
+	}
+	return machines
+}
+
 // findStatusEnum finds the first enum-typed field on an entity (typically named "status").
 // Returns the field name and enum values, or empty if none found.
 func findStatusEnum(entity ast.Entity, st *SymbolTable) (string, []string) {
-	for _, f := range entity.Fields {
+	for _, f := range st.ResolveFields(entity.Fields) {
 		switch f.Type.Kind {
 		case "named_enum":
 			if enum := st.LookupEnumeration(f.Type.Name); enum != nil {
@@ -92,19 +202,59 @@ func findStatusEnum(entity ast.Entity, st *SymbolTable) (string, []string) {
 	return "", nil
 }
 
+// stateEdge is a change of a status field from one value to another or,
+// with an empty from, the creation of an entity with a value.
+type stateEdge struct {
+	from, to string
+}
+
 type undeclaredAssignment struct {
 	value string
 	path  string
 }
 
 // collectStateInfo scans all rules for creation values and transitions
-// for the given entity's enum field.
-func collectStateInfo(spec *ast.Spec, _ *SymbolTable, entityName string, enumField string, validValues map[string]bool) (
+// for the given entity's enum field. Default instances and the initial
+// states declared in st.InitialStates also seed the creation values.
+// sources lists, for each creation value and transition, the rules that
+// make it.
+func collectStateInfo(spec *ast.Spec, st *SymbolTable, entityName string, enumField string, validValues map[string]bool) (
 	creationValues []string,
 	transitions map[string][]string,
 	undeclared []undeclaredAssignment,
+	sources map[stateEdge][]string,
 ) {
 	transitions = make(map[string][]string)
+	sources = make(map[stateEdge][]string)
+
+	// Default instances exist from the start, so their status values are
+	// reachable without any creation rule.
+	for i, d := range spec.Defaults {
+		if d.Entity != entityName {
+			continue
+		}
+		fieldExpr, ok := d.Fields[enumField]
+		if !ok {
+			continue
+		}
+		if val := extractLiteralValue(&fieldExpr); val != "" {
+			creationValues = append(creationValues, val)
+			if !validValues[val] {
+				undeclared = append(undeclared, undeclaredAssignment{
+					value: val,
+					path:  fmt.Sprintf("$.defaults[%d].fields.%s", i, enumField),
+				})
+			}
+		}
+	}
+
+	// Instances created outside the spec, such as entities provided through
+	// given bindings, start in the states the project configuration declares.
+	for _, val := range st.InitialStates[entityName][enumField] {
+		if validValues[val] {
+			creationValues = append(creationValues, val)
+		}
+	}
 
 	for i, rule := range spec.Rules {
 		basePath := fmt.Sprintf("$.rules[%d]", i)
@@ -124,18 +274,65 @@ func collectStateInfo(spec *ast.Spec, _ *SymbolTable, entityName string, enumFie
 			}
 		}
 
+		// Values and transitions are only appended, so those past the
+		// counts taken here come from this rule.
+		created := len(creationValues)
+		counts := make(map[string]int, len(transitions))
+		for from, targets := range transitions {
+			counts[from] = len(targets)
+		}
+		facts := priorStateFacts(rule, spec, st)
 		for j, ec := range rule.Ensures {
-			ecPath := fmt.Sprintf("%s.ensures[%d]", basePath, j)
+			ecPath := ast.IndexPath(basePath, "ensures", j)
 			creationValues, transitions, undeclared = collectEnsuresStateInfo(
-				ec, ecPath, entityName, enumField, triggerEntity, entityBindings, validValues,
+				ec, ecPath, entityName, enumField, triggerEntity, entityBindings, validValues, facts,
 				creationValues, transitions, undeclared,
 			)
 		}
+		for _, v := range creationValues[created:] {
+			addStateSource(sources, stateEdge{"", v}, rule.Name)
+		}
+		for from, targets := range transitions {
+			for _, to := range targets[counts[from]:] {
+				addStateSource(sources, stateEdge{from, to}, rule.Name)
+			}
+		}
 	}
 
 	return
 }
 
+// stateWriters returns the indices, in declaration order, of the rules that
+// make an edge accepted by match.
+func stateWriters(spec *ast.Spec, sources map[stateEdge][]string, match func(stateEdge) bool) []int {
+	var writers []int
+	for i, rule := range spec.Rules {
+		for edge, rules := range sources {
+			if match(edge) && slices.Contains(rules, rule.Name) {
+				writers = append(writers, i)
+				break
+			}
+		}
+	}
+	return writers
+}
+
+// writerNames lists the names of the rules at the given indices.
+func writerNames(spec *ast.Spec, indices []int) string {
+	names := make([]string, len(indices))
+	for k, i := range indices {
+		names[k] = spec.Rules[i].Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// addStateSource records that rule makes edge, once.
+func addStateSource(sources map[stateEdge][]string, edge stateEdge, rule string) {
+	if !slices.Contains(sources[edge], rule) {
+		sources[edge] = append(sources[edge], rule)
+	}
+}
+
 // collectEnsuresStateInfo recursively processes ensures clauses.
 func collectEnsuresStateInfo(
 	ec ast.EnsuresClause,
@@ -145,6 +342,7 @@ func collectEnsuresStateInfo(
 	triggerEntity string,
 	entityBindings map[string]bool,
 	validValues map[string]bool,
+	facts *condFacts,
 	creationValues []string,
 	transitions map[string][]string,
 	undeclared []undeclaredAssignment,
@@ -162,7 +360,7 @@ func collectEnsuresStateInfo(
 						if !validValues[val] {
 							undeclared = append(undeclared, undeclaredAssignment{
 								value: val,
-								path:  fmt.Sprintf("%s.fields.%s", path, enumField),
+								path:  path + ".fields." + enumField,
 							})
 						}
 					}
@@ -188,9 +386,12 @@ func collectEnsuresStateInfo(
 				}
 
 				// Track transitions for RULE-07/08 regardless of strict/loose
-				fromVal := extractFromState(ec)
-				if fromVal != "" {
-					transitions[fromVal] = append(transitions[fromVal], newVal)
+				if from, ok := extractFromStates(ec.Target, facts, validValues); ok {
+					for _, v := range from {
+						if v != newVal {
+							transitions[v] = append(transitions[v], newVal)
+						}
+					}
 				} else {
 					for v := range validValues {
 						if v != newVal {
@@ -202,17 +403,18 @@ func collectEnsuresStateInfo(
 		}
 
 	case "conditional":
+		thenFacts, elseFacts := branchFacts(facts, ec.Condition)
 		for i, then := range ec.Then {
 			creationValues, transitions, undeclared = collectEnsuresStateInfo(
-				then, fmt.Sprintf("%s.then[%d]", path, i),
-				entityName, enumField, triggerEntity, entityBindings, validValues,
+				then, ast.IndexPath(path, "then", i),
+				entityName, enumField, triggerEntity, entityBindings, validValues, thenFacts,
 				creationValues, transitions, undeclared,
 			)
 		}
 		for i, el := range ec.Else {
 			creationValues, transitions, undeclared = collectEnsuresStateInfo(
-				el, fmt.Sprintf("%s.else[%d]", path, i),
-				entityName, enumField, triggerEntity, entityBindings, validValues,
+				el, ast.IndexPath(path, "else", i),
+				entityName, enumField, triggerEntity, entityBindings, validValues, elseFacts,
 				creationValues, transitions, undeclared,
 			)
 		}
@@ -220,8 +422,8 @@ func collectEnsuresStateInfo(
 	case "iteration":
 		for i, body := range ec.Body {
 			creationValues, transitions, undeclared = collectEnsuresStateInfo(
-				body, fmt.Sprintf("%s.body[%d]", path, i),
-				entityName, enumField, triggerEntity, entityBindings, validValues,
+				body, ast.IndexPath(path, "body", i),
+				entityName, enumField, triggerEntity, entityBindings, validValues, facts,
 				creationValues, transitions, undeclared,
 			)
 		}
@@ -234,15 +436,15 @@ func collectEnsuresStateInfo(
 			if err := json.Unmarshal(ec.Value, &innerEC); err == nil && innerEC.Kind != "" {
 				creationValues, transitions, undeclared = collectEnsuresStateInfo(
 					innerEC, path+".value",
-					entityName, enumField, triggerEntity, entityBindings, validValues,
+					entityName, enumField, triggerEntity, entityBindings, validValues, facts,
 					creationValues, transitions, undeclared,
 				)
 			}
 		}
 		for i, body := range ec.Body {
 			creationValues, transitions, undeclared = collectEnsuresStateInfo(
-				body, fmt.Sprintf("%s.body[%d]", path, i),
-				entityName, enumField, triggerEntity, entityBindings, validValues,
+				body, ast.IndexPath(path, "body", i),
+				entityName, enumField, triggerEntity, entityBindings, validValues, facts,
 				creationValues, transitions, undeclared,
 			)
 		}
@@ -322,13 +524,75 @@ func extractRawValue(raw json.RawMessage) string {
 	return ""
 }
 
-// extractFromState tries to determine the "from" state from a state_change ensures.
-// This is heuristic — in practice, the trigger often constrains the from-state.
-func extractFromState(_ ast.EnsuresClause) string {
-	// State transitions are typically guarded by trigger conditions,
-	// not explicitly encoded in the ensures clause.
-	// Return empty to use the conservative all-to-all approach.
-	return ""
+// priorStateFacts returns what a rule knows of its fields when it fires:
+// the value a state_transition or state_becomes trigger fires on, and the
+// constraints of its requires. It returns nil when they contradict, leaving
+// the rule's state changes unconstrained rather than dropping them.
+func priorStateFacts(rule ast.Rule, spec *ast.Spec, st *SymbolTable) *condFacts {
+	facts, reason := ruleFacts(rule, spec, st)
+	if reason != "" {
+		return nil
+	}
+	t := rule.Trigger
+	value := t.ToValue
+	if t.Kind == "state_becomes" {
+		value = t.Value
+	} else if t.Kind != "state_transition" {
+		return facts
+	}
+	if t.Binding == "" || t.Field == "" || value == "" {
+		return facts
+	}
+	key, _ := json.Marshal(value)
+	// The field may be written through the binding or, being a field of the
+	// trigger entity, bare.
+	bare := &ast.Expression{Kind: "field_access", Field: t.Field}
+	bound := &ast.Expression{Kind: "field_access", Object: &ast.Expression{Kind: "field_access", Field: t.Binding}, Field: t.Field}
+	for _, target := range []*ast.Expression{bound, bare} {
+		if facts.constrain(target, "=", string(key)) != "" {
+			return nil
+		}
+	}
+	return facts
+}
+
+// branchFacts returns the facts that hold in the then and else branches of
+// a conditional. A branch whose condition contradicts the facts keeps them
+// unnarrowed; WARN-30 reports it.
+func branchFacts(facts *condFacts, cond *ast.Expression) (then, els *condFacts) {
+	if facts == nil {
+		return nil, nil
+	}
+	then, els = facts.clone(), facts.clone()
+	if then.falsify(cond) != "" {
+		then = facts
+	}
+	if els.falsifyNegation(cond) != "" {
+		els = facts
+	}
+	return then, els
+}
+
+// extractFromStates determines the values a status field may hold before a
+// state_change to it, from the facts about its path when the rule fires.
+// It reports false when nothing constrains the field, leaving the change
+// possible from every value.
+func extractFromStates(target *ast.Expression, facts *condFacts, validValues map[string]bool) ([]string, bool) {
+	path := exprPath(target)
+	if facts == nil || path == "" {
+		return nil, false
+	}
+	_, allowed := facts.allowed[path]
+	if !allowed && len(facts.excluded[path]) == 0 {
+		return nil, false
+	}
+	var from []string
+	for v := range validValues {
+		if facts.mayHold(path, v) {
+			from = append(from, v)
+		}
+	}
+	return from, true
 }
 
 // bfsReachable performs BFS from creation values through transitions.
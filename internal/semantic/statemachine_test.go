@@ -2,9 +2,13 @@ package semantic
 
 import (
 	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/report"
 )
 
 func litExpr(val string) ast.Expression {
@@ -120,21 +124,116 @@ func TestCheckStateMachines_RULE07_Unreachable(t *testing.T) {
 	if len(r07) == 0 {
 		t.Fatal("expected RULE-07 for unreachable 'archived'")
 	}
-	found := false
-	for _, f := range r07 {
-		if f.Message == "Unreachable status value 'archived' on 'Order'" {
-			found = true
-			break
+	want := "Unreachable status value 'archived' on 'Order': no rule creates or assigns it; " +
+		"nearest writers of 'status': CreateOrder, ActivateOrder, CompleteOrder"
+	if len(r07) != 1 || r07[0].Message != want {
+		t.Fatalf("expected message about 'archived', got: %v", r07)
+	}
+	if paths := relatedPaths(r07[0]); !slices.Equal(paths, []string{"$.rules[0]", "$.rules[1]", "$.rules[2]"}) {
+		t.Errorf("related paths = %v", paths)
+	}
+}
+
+func relatedPaths(f report.Finding) []string {
+	var paths []string
+	for _, r := range f.Related {
+		paths = append(paths, r.Location.Path)
+	}
+	return paths
+}
+
+func TestCheckStateMachines_RULE07_AssignedFromUnreachable(t *testing.T) {
+	spec := uncreatedSpec()
+	r07 := findingsWithRule(CheckStateMachines(spec, BuildSymbolTable(spec)), "RULE-07")
+	if len(r07) != 2 {
+		t.Fatalf("expected RULE-07 for both values, got %v", r07)
+	}
+	for k, want := range []string{
+		"Unreachable status value 'active' on 'Workspace': no rule creates or assigns it; nearest writers of 'status': ArchiveWorkspace",
+		"Unreachable status value 'archived' on 'Workspace': assigned only from unreachable states, by rules: ArchiveWorkspace",
+	} {
+		if r07[k].Message != want {
+			t.Errorf("message = %q, want %q", r07[k].Message, want)
+		}
+		if paths := relatedPaths(r07[k]); !slices.Equal(paths, []string{"$.rules[0]"}) {
+			t.Errorf("related paths = %v", paths)
 		}
 	}
-	if !found {
-		t.Errorf("expected message about 'archived', got: %v", r07)
+}
+
+// uncreatedSpec declares Workspace, which no rule creates, with a rule
+// archiving it.
+func uncreatedSpec() *ast.Spec {
+	return &ast.Spec{
+		File: "test.allium.json",
+		Entities: []ast.Entity{
+			{Name: "Workspace", Fields: []ast.Field{
+				{Name: "status", Type: ast.FieldType{Kind: "inline_enum", Values: []string{"active", "archived"}}},
+			}},
+		},
+		Rules: []ast.Rule{
+			{
+				Name:    "ArchiveWorkspace",
+				Trigger: ast.Trigger{Kind: "state_transition", Entity: "Workspace", Field: "status", Binding: "workspace"},
+				Ensures: []ast.EnsuresClause{
+					{
+						Kind:   "state_change",
+						Target: fieldAccess("status"),
+						Value:  rawExpr("archived"),
+					},
+				},
+			},
+		},
 	}
 }
 
-func TestCheckStateMachines_RULE08_DeadEnd(t *testing.T) {
-	// Create: open -> blocked (no transitions out of blocked)
-	spec := &ast.Spec{
+func TestCheckStateMachines_RULE07_DefaultSeeds(t *testing.T) {
+	spec := uncreatedSpec()
+	st := BuildSymbolTable(spec)
+	if r07 := findingsWithRule(CheckStateMachines(spec, st), "RULE-07"); len(r07) != 2 {
+		t.Fatalf("expected RULE-07 for both values without creation points, got %v", r07)
+	}
+
+	spec.Defaults = []ast.Default{
+		{Entity: "Workspace", Name: "main", Fields: map[string]ast.Expression{"status": litExpr("active")}},
+	}
+	st = BuildSymbolTable(spec)
+	findings := CheckStateMachines(spec, st)
+	if r07 := findingsWithRule(findings, "RULE-07"); len(r07) != 0 {
+		t.Errorf("expected the default instance to seed reachability, got %v", r07)
+	}
+	if sm := StateMachines(spec, st); len(sm) != 1 || !slices.Equal(sm[0].Initial, []string{"active"}) {
+		t.Errorf("expected initial state 'active', got %+v", sm)
+	}
+
+	spec.Defaults[0].Fields["status"] = litExpr("deleted")
+	r09 := findingsWithRule(CheckStateMachines(spec, BuildSymbolTable(spec)), "RULE-09")
+	if len(r09) != 1 || r09[0].Location.Path != "$.defaults[0].fields.status" {
+		t.Errorf("expected RULE-09 for the default's undeclared status, got %v", r09)
+	}
+}
+
+func TestCheckStateMachines_RULE07_InitialStates(t *testing.T) {
+	spec := uncreatedSpec()
+	spec.Given = []ast.GivenBinding{
+		{Name: "workspace", Type: ast.FieldType{Kind: "entity_ref", Entity: "Workspace"}},
+	}
+	st := BuildSymbolTable(spec)
+	st.InitialStates = map[string]map[string][]string{"Workspace": {"status": {"active", "bogus"}}}
+
+	if r07 := findingsWithRule(CheckStateMachines(spec, st), "RULE-07"); len(r07) != 0 {
+		t.Errorf("expected configured initial states to seed reachability, got %v", r07)
+	}
+	// Values the enum does not declare are ignored.
+	if sm := StateMachines(spec, st); len(sm) != 1 || !slices.Equal(sm[0].Initial, []string{"active"}) {
+		t.Errorf("expected initial state 'active', got %+v", sm)
+	}
+}
+
+// deadEndSpec creates Task with open -> blocked and no transitions out of
+// blocked.
+func deadEndSpec() *ast.Spec {
+	return &ast.Spec{
 		File: "test.allium.json",
 		Entities: []ast.Entity{
 			{Name: "Task", Fields: []ast.Field{
@@ -167,12 +266,49 @@ func TestCheckStateMachines_RULE08_DeadEnd(t *testing.T) {
 			// done is also reachable (conservative approach), but blocked has no exit
 		},
 	}
+}
+
+func TestCheckStateMachines_RULE08_DeadEnd(t *testing.T) {
+	spec := deadEndSpec()
 	st := BuildSymbolTable(spec)
 	findings := CheckStateMachines(spec, st)
 
 	r08 := findingsWithRule(findings, "RULE-08")
-	if len(r08) == 0 {
-		t.Fatal("expected RULE-08 for dead-end 'blocked'")
+	if len(r08) != 1 {
+		t.Fatalf("expected RULE-08 for dead-end 'blocked', got %v", r08)
+	}
+	want := "Dead-end state 'blocked' on 'Task' has no outgoing transition: no rule transitions out of 'blocked'; reachable via rules: BlockTask"
+	if r08[0].Message != want {
+		t.Errorf("message = %q, want %q", r08[0].Message, want)
+	}
+	if len(r08[0].Related) != 1 || r08[0].Related[0].Message != "rule 'BlockTask' sets 'Task.status' to 'blocked'" ||
+		r08[0].Related[0].Location.Path != "$.rules[1]" {
+		t.Errorf("related = %v", r08[0].Related)
+	}
+}
+
+func TestCheckStateMachines_RULE08_ConfiguredTerminal(t *testing.T) {
+	spec := deadEndSpec()
+	st := BuildSymbolTable(spec)
+	want := len(findingsWithRule(CheckStateMachines(spec, st), "RULE-08"))
+	if want == 0 {
+		t.Fatal("expected RULE-08 without configured terminal states")
+	}
+
+	// Values declared terminal for another entity or field change nothing.
+	st.TerminalStates = map[string]map[string][]string{
+		"Order": {"status": {"blocked"}},
+		"Task":  {"phase": {"blocked"}},
+	}
+	if r08 := findingsWithRule(CheckStateMachines(spec, st), "RULE-08"); len(r08) != want {
+		t.Errorf("expected %d RULE-08 findings, got %v", want, r08)
+	}
+
+	st.TerminalStates = map[string]map[string][]string{"Task": {"status": {"blocked"}}}
+	for _, f := range findingsWithRule(CheckStateMachines(spec, st), "RULE-08") {
+		if strings.Contains(f.Message, "'blocked'") {
+			t.Errorf("expected no RULE-08 for configured terminal 'blocked', got %s", f.Message)
+		}
 	}
 }
 
@@ -404,6 +540,124 @@ func TestCheckStateMachines_ChainedFieldAccess(t *testing.T) {
 	}
 }
 
+// ticketSpec creates Ticket with status open | active | review | done,
+// created open. Each rule sets the status to its value and requires the
+// given status, if any.
+func ticketSpec(rules ...[3]string) *ast.Spec {
+	spec := &ast.Spec{
+		File: "test.allium.json",
+		Entities: []ast.Entity{
+			{Name: "Ticket", Fields: []ast.Field{
+				{Name: "status", Type: ast.FieldType{Kind: "inline_enum", Values: []string{"open", "active", "review", "done"}}},
+			}},
+		},
+		Rules: []ast.Rule{{
+			Name:    "OpenTicket",
+			Trigger: ast.Trigger{Kind: "external_stimulus", Name: "open_ticket"},
+			Ensures: []ast.EnsuresClause{
+				{Kind: "entity_creation", Entity: "Ticket", Fields: map[string]ast.Expression{"status": litExpr("open")}},
+			},
+		}},
+	}
+	for _, r := range rules {
+		rule := ast.Rule{
+			Name:    r[0],
+			Trigger: ast.Trigger{Kind: "state_transition", Entity: "Ticket", Field: "status", Binding: "ticket"},
+			Ensures: []ast.EnsuresClause{{Kind: "state_change", Target: chain("ticket", "status"), Value: rawExpr(r[2])}},
+		}
+		if r[1] != "" {
+			rule.Requires = []ast.Expression{*comparisonExpr("=", chain("ticket", "status"), strLitExpr(r[1]))}
+		}
+		spec.Rules = append(spec.Rules, rule)
+	}
+	return spec
+}
+
+func TestCheckStateMachines_FromStateInference(t *testing.T) {
+	spec := ticketSpec([3]string{"StartTicket", "open", "active"}, [3]string{"CloseTicket", "review", "done"})
+	findings := CheckStateMachines(spec, BuildSymbolTable(spec))
+
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.Rule+" "+f.Message)
+	}
+	want := []string{
+		"RULE-07 Unreachable status value 'review' on 'Ticket': no rule creates or assigns it; nearest writers of 'status': OpenTicket, StartTicket, CloseTicket",
+		"RULE-07 Unreachable status value 'done' on 'Ticket': assigned only from unreachable states, by rules: CloseTicket",
+		"RULE-08 Dead-end state 'active' on 'Ticket' has no outgoing transition: no rule transitions out of 'active'; reachable via rules: StartTicket",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("findings = %q, want %q", got, want)
+	}
+
+	// Without the requires, the prior state is unknown and every value
+	// leads to done.
+	spec.Rules[2].Requires = nil
+	if r07 := findingsWithRule(CheckStateMachines(spec, BuildSymbolTable(spec)), "RULE-07"); len(r07) != 1 {
+		t.Errorf("expected RULE-07 only for 'review', got %v", r07)
+	}
+}
+
+func TestStateMachines_FromStateInference(t *testing.T) {
+	transitions := func(spec *ast.Spec) []Transition {
+		sm := StateMachines(spec, BuildSymbolTable(spec))
+		if len(sm) != 1 {
+			t.Fatalf("expected 1 state machine, got %+v", sm)
+		}
+		return sm[0].Transitions
+	}
+
+	// A trigger fires on the value it watches.
+	spec := ticketSpec([3]string{"ReviewTicket", "", "review"})
+	spec.Rules[1].Trigger.ToValue = "active"
+	want := []Transition{{From: "active", To: "review", Rules: []string{"ReviewTicket"}}}
+	if got := transitions(spec); !reflect.DeepEqual(got, want) {
+		t.Errorf("state_transition: transitions = %+v, want %+v", got, want)
+	}
+	spec.Rules[1].Trigger = ast.Trigger{Kind: "state_becomes", Entity: "Ticket", Field: "status", Binding: "ticket", Value: "active"}
+	if got := transitions(spec); !reflect.DeepEqual(got, want) {
+		t.Errorf("state_becomes: transitions = %+v, want %+v", got, want)
+	}
+
+	// Requires may allow several values, or rule some out.
+	spec = ticketSpec([3]string{"ReviewTicket", "", "review"})
+	spec.Rules[1].Requires = []ast.Expression{{Kind: "membership", Element: chain("ticket", "status"),
+		Collection: &ast.Expression{Kind: "set_literal", Elements: []ast.Expression{*strLitExpr("open"), *strLitExpr("active")}}}}
+	want = []Transition{
+		{From: "active", To: "review", Rules: []string{"ReviewTicket"}},
+		{From: "open", To: "review", Rules: []string{"ReviewTicket"}},
+	}
+	if got := transitions(spec); !reflect.DeepEqual(got, want) {
+		t.Errorf("membership: transitions = %+v, want %+v", got, want)
+	}
+	spec.Rules[1].Requires = []ast.Expression{*comparisonExpr("!=", chain("ticket", "status"), strLitExpr("done"))}
+	if got := transitions(spec); !reflect.DeepEqual(got, want) {
+		t.Errorf("inequality: transitions = %+v, want %+v", got, want)
+	}
+
+	// A conditional narrows each branch.
+	spec = ticketSpec()
+	spec.Rules = append(spec.Rules, ast.Rule{
+		Name:    "AdvanceTicket",
+		Trigger: ast.Trigger{Kind: "state_transition", Entity: "Ticket", Field: "status", Binding: "ticket"},
+		Ensures: []ast.EnsuresClause{{
+			Kind:      "conditional",
+			Condition: comparisonExpr("=", chain("ticket", "status"), strLitExpr("open")),
+			Then:      []ast.EnsuresClause{{Kind: "state_change", Target: chain("ticket", "status"), Value: rawExpr("active")}},
+			Else: []ast.EnsuresClause{{Kind: "conditional",
+				Condition: comparisonExpr("=", chain("ticket", "status"), strLitExpr("active")),
+				Then:      []ast.EnsuresClause{{Kind: "state_change", Target: chain("ticket", "status"), Value: rawExpr("done")}}}},
+		}},
+	})
+	want = []Transition{
+		{From: "active", To: "done", Rules: []string{"AdvanceTicket"}},
+		{From: "open", To: "active", Rules: []string{"AdvanceTicket"}},
+	}
+	if got := transitions(spec); !reflect.DeepEqual(got, want) {
+		t.Errorf("conditional: transitions = %+v, want %+v", got, want)
+	}
+}
+
 func TestBfsReachable(t *testing.T) {
 	transitions := map[string][]string{
 		"a": {"b"},
@@ -433,3 +687,71 @@ func TestBfsReachable_Cycle(t *testing.T) {
 		t.Error("cycle should still mark both as reachable")
 	}
 }
+
+func TestStateMachines(t *testing.T) {
+	spec := makeStateMachineSpec()
+	spec.Entities = append(spec.Entities, ast.Entity{Name: "Note", Fields: []ast.Field{
+		{Name: "text", Type: ast.FieldType{Kind: "primitive", Value: "String"}},
+	}})
+	// A status change on an unknown binding matches every entity's status
+	// field; its value is not an Order state, so it adds no transition.
+	spec.Rules = append(spec.Rules, ast.Rule{
+		Name:    "CloseTicket",
+		Trigger: ast.Trigger{Kind: "external_stimulus", Name: "close_ticket"},
+		Ensures: []ast.EnsuresClause{{Kind: "state_change", Target: fieldAccess("status"), Value: rawExpr("closed")}},
+	})
+	machines := StateMachines(spec, BuildSymbolTable(spec))
+
+	if len(machines) != 1 {
+		t.Fatalf("expected 1 state machine, got %d: %+v", len(machines), machines)
+	}
+	sm := machines[0]
+	if sm.Entity != "Order" || sm.Field != "status" {
+		t.Errorf("unexpected machine %s.%s", sm.Entity, sm.Field)
+	}
+	if !slices.Equal(sm.States, []string{"pending", "active", "done"}) {
+		t.Errorf("states = %v", sm.States)
+	}
+	if !slices.Equal(sm.Initial, []string{"pending"}) {
+		t.Errorf("initial = %v", sm.Initial)
+	}
+	// Neither state change names its prior state, so each is possible from
+	// every other value.
+	want := []Transition{
+		{From: "active", To: "done", Rules: []string{"CompleteOrder"}},
+		{From: "done", To: "active", Rules: []string{"ActivateOrder"}},
+		{From: "pending", To: "active", Rules: []string{"ActivateOrder"}},
+		{From: "pending", To: "done", Rules: []string{"CompleteOrder"}},
+	}
+	if !reflect.DeepEqual(sm.Transitions, want) {
+		t.Errorf("transitions = %v, want %v", sm.Transitions, want)
+	}
+	if len(sm.Terminal) != 0 {
+		t.Errorf("terminal = %v, want none", sm.Terminal)
+	}
+
+	st := BuildSymbolTable(spec)
+	st.TerminalStates = map[string]map[string][]string{"Order": {"status": {"done"}}}
+	if terminal := StateMachines(spec, st)[0].Terminal; !slices.Equal(terminal, []string{"done"}) {
+		t.Errorf("terminal = %v, want the declared terminal state", terminal)
+	}
+}
+
+func TestStateMachines_TerminalAndSharedTransitions(t *testing.T) {
+	spec := makeStateMachineSpec()
+	// A second rule completing orders shares the transitions to done; no
+	// rule leaves done once nothing reactivates orders.
+	spec.Rules[1].Ensures[0].Value = rawExpr("done")
+	machines := StateMachines(spec, BuildSymbolTable(spec))
+
+	want := []Transition{
+		{From: "active", To: "done", Rules: []string{"ActivateOrder", "CompleteOrder"}},
+		{From: "pending", To: "done", Rules: []string{"ActivateOrder", "CompleteOrder"}},
+	}
+	if !reflect.DeepEqual(machines[0].Transitions, want) {
+		t.Errorf("transitions = %v, want %v", machines[0].Transitions, want)
+	}
+	if !slices.Equal(machines[0].Terminal, []string{"done"}) {
+		t.Errorf("terminal = %v, want [done]", machines[0].Terminal)
+	}
+}
@@ -0,0 +1,12 @@
+package semantic
+
+import "github.com/foundry-zero/allium/internal/suggest"
+
+// didYouMean formats a closest-name suggestion for appending to a message,
+// or returns "" when there is no suggestion.
+func didYouMean(name string, candidates []string) string {
+	if s := suggest.Closest(name, candidates); s != "" {
+		return " (did you mean '" + s + "'?)"
+	}
+	return ""
+}
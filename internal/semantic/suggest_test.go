@@ -0,0 +1,14 @@
+package semantic
+
+import "testing"
+
+func TestDidYouMean(t *testing.T) {
+	candidates := []string{"SendWelcome", "ResetPassword", "LockAccount"}
+
+	if got := didYouMean("LockAcount", candidates); got != " (did you mean 'LockAccount'?)" {
+		t.Errorf("didYouMean = %q", got)
+	}
+	if got := didYouMean("xyz", candidates); got != "" {
+		t.Errorf("didYouMean(no match) = %q", got)
+	}
+}
@@ -1,7 +1,10 @@
 package semantic
 
 import (
+	"encoding/json"
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 
 	"github.com/foundry-zero/allium/internal/ast"
@@ -33,7 +36,7 @@ func CheckSumTypes(spec *ast.Spec, st *SymbolTable) []report.Finding {
 		if !hasVariants {
 			continue
 		}
-		for _, f := range entity.Fields {
+		for _, f := range st.ResolveFields(entity.Fields) {
 			if f.Type.Kind == "inline_enum" && isDiscriminatorField(f.Type.Values, variantNames) {
 				discriminators[entity.Name] = &discInfo{
 					entityIdx: i,
@@ -87,6 +90,11 @@ func CheckSumTypes(spec *ast.Spec, st *SymbolTable) []report.Finding {
 		}
 	}
 
+	// RULE-18: Variant-specific fields accessed only within type guards
+	for i, rule := range spec.Rules {
+		findings = checkRuleVariantAccess(findings, spec, st, rule, discriminators, fmt.Sprintf("$.rules[%d]", i))
+	}
+
 	// RULE-19: Entity creation must use variant name when discriminator exists
 	for i, rule := range spec.Rules {
 		for j, ec := range rule.Ensures {
@@ -137,13 +145,22 @@ func containsVariantName(enumValues []string, variantName string) bool {
 
 // snakeToPascal converts a snake_case string to PascalCase.
 func snakeToPascal(s string) string {
-	parts := strings.Split(s, "_")
-	for i, p := range parts {
-		if len(p) > 0 {
-			parts[i] = strings.ToUpper(p[:1]) + p[1:]
+	var b strings.Builder
+	b.Grow(len(s))
+	upper := true
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '_':
+			upper = true
+			continue
+		case upper && 'a' <= c && c <= 'z':
+			c -= 'a' - 'A'
 		}
+		b.WriteByte(c)
+		upper = false
 	}
-	return strings.Join(parts, "")
+	return b.String()
 }
 
 // checkCreationVariantUse checks RULE-19: ensures creating an entity with a
@@ -162,17 +179,30 @@ func checkCreationVariantUse(findings []report.Finding, ec ast.EnsuresClause, di
 	case "conditional":
 		for i, then := range ec.Then {
 			findings = checkCreationVariantUse(findings, then, discriminators,
-				fmt.Sprintf("%s.then[%d]", path, i), file)
+				ast.IndexPath(path, "then", i), file)
 		}
 		for i, el := range ec.Else {
 			findings = checkCreationVariantUse(findings, el, discriminators,
-				fmt.Sprintf("%s.else[%d]", path, i), file)
+				ast.IndexPath(path, "else", i), file)
 		}
 
 	case "iteration":
 		for i, body := range ec.Body {
 			findings = checkCreationVariantUse(findings, body, discriminators,
-				fmt.Sprintf("%s.body[%d]", path, i), file)
+				ast.IndexPath(path, "body", i), file)
+		}
+
+	case "let_binding":
+		// e.g. {kind: "let_binding", name: "node", value: {kind: "entity_creation", ...}}
+		if len(ec.Value) > 0 {
+			var inner ast.EnsuresClause
+			if err := json.Unmarshal(ec.Value, &inner); err == nil && inner.Kind == "entity_creation" {
+				findings = checkCreationVariantUse(findings, inner, discriminators, path+".value", file)
+			}
+		}
+		for i, body := range ec.Body {
+			findings = checkCreationVariantUse(findings, body, discriminators,
+				ast.IndexPath(path, "body", i), file)
 		}
 	}
 
@@ -184,3 +214,156 @@ type discInfo struct {
 	fieldName string
 	variants  []string
 }
+
+// checkRuleVariantAccess checks RULE-18: a rule reading a field that only
+// some variants of an entity declare, through a binding typed as the base
+// entity, must be guarded by a discriminator check ruling the other variants
+// out. Guards are the rule's requires and for clause condition, which hold
+// throughout the rule, and the conditions of ensures conditionals, which hold
+// in their then branch; an equality or inequality condition also holds
+// negated in its else branch.
+func checkRuleVariantAccess(findings []report.Finding, spec *ast.Spec, st *SymbolTable, rule ast.Rule,
+	discriminators map[string]*discInfo, path string) []report.Finding {
+	if len(discriminators) == 0 {
+		return findings
+	}
+	c := &variantAccess{spec: spec, st: st, discriminators: discriminators, findings: findings}
+	types := ruleFieldTypes(rule, spec, st)
+	facts := newCondFacts(ruleEnumLookup(st, types))
+	for j := range rule.Requires {
+		facts.falsify(&rule.Requires[j])
+	}
+	if fc := rule.ForClause; fc != nil {
+		facts.falsify(fc.Condition)
+	}
+
+	for j, lb := range rule.LetBindings {
+		c.expr(lb.Expression, types, facts, ast.NewPath(ast.IndexPath(path, "let_bindings", j)+".expression"))
+	}
+	for j := range rule.Requires {
+		c.expr(&rule.Requires[j], types, facts, ast.NewPath(ast.IndexPath(path, "requires", j)))
+	}
+	if fc := rule.ForClause; fc != nil {
+		c.expr(fc.Collection, types, facts, ast.NewPath(path+".for_clause.collection"))
+		c.expr(fc.Condition, types, facts, ast.NewPath(path+".for_clause.condition"))
+	}
+	for j, ec := range rule.Ensures {
+		c.ensures(ec, types, facts, ast.IndexPath(path, "ensures", j))
+	}
+	return c.findings
+}
+
+// variantAccess collects RULE-18 findings for one rule.
+type variantAccess struct {
+	spec           *ast.Spec
+	st             *SymbolTable
+	discriminators map[string]*discInfo
+	findings       []report.Finding
+}
+
+func (c *variantAccess) ensures(ec ast.EnsuresClause, types map[string]*ast.FieldType, facts *condFacts, path string) {
+	c.expr(ec.Target, types, facts, ast.NewPath(path+".target"))
+	c.expr(ec.Collection, types, facts, ast.NewPath(path+".collection"))
+	if len(ec.Value) > 0 {
+		// An entity_creation value keeps its fields under the same key as
+		// an expression's, so both are walked as expressions.
+		var value ast.Expression
+		if err := json.Unmarshal(ec.Value, &value); err == nil && value.Kind != "" {
+			c.expr(&value, types, facts, ast.NewPath(path+".value"))
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(ec.Fields)) {
+		e := ec.Fields[name]
+		c.expr(&e, types, facts, ast.NewPath(path+".fields."+name))
+	}
+	for _, name := range slices.Sorted(maps.Keys(ec.Arguments)) {
+		e := ec.Arguments[name]
+		c.expr(&e, types, facts, ast.NewPath(path+".arguments."+name))
+	}
+
+	switch ec.Kind {
+	case "conditional":
+		then := facts.clone()
+		then.falsify(ec.Condition)
+		c.expr(ec.Condition, types, then, ast.NewPath(path+".condition"))
+		for j, t := range ec.Then {
+			c.ensures(t, types, then, ast.IndexPath(path, "then", j))
+		}
+		els := facts.clone()
+		els.falsifyNegation(ec.Condition)
+		for j, e := range ec.Else {
+			c.ensures(e, types, els, ast.IndexPath(path, "else", j))
+		}
+		return
+	case "iteration":
+		var element *ast.FieldType
+		if ct := resolveFieldAccessType(ec.Collection, types, c.st); ct != nil && (ct.Kind == "set" || ct.Kind == "list") {
+			element = ct.Element
+		}
+		types = withBinding(types, ec.Binding, element)
+	case "let_binding":
+		types = withBinding(types, ec.Name, letValueType(ec.Value, types, c.st))
+	}
+	for j, body := range ec.Body {
+		c.ensures(body, types, facts, ast.IndexPath(path, "body", j))
+	}
+}
+
+func (c *variantAccess) expr(e *ast.Expression, types map[string]*ast.FieldType, facts *condFacts, path *ast.Path) {
+	ast.WalkExpression(e, path, func(e *ast.Expression, path *ast.Path) bool {
+		if e.Kind == "field_access" && e.Object != nil {
+			c.access(e, types, facts, path)
+		}
+		if e.Kind == "lambda" {
+			c.expr(e.Body, withBinding(types, e.Parameter, nil), facts, path.Field("body"))
+			return false
+		}
+		return true
+	})
+}
+
+// access reports a read of a variant field through a base entity binding
+// that the facts in force do not guard.
+func (c *variantAccess) access(e *ast.Expression, types map[string]*ast.FieldType, facts *condFacts, path *ast.Path) {
+	objType := resolveFieldAccessType(e.Object, types, c.st)
+	for objType != nil && objType.Kind == "optional" {
+		objType = objType.Inner
+	}
+	if objType == nil || objType.Kind != "entity_ref" {
+		return
+	}
+	disc, ok := c.discriminators[objType.Entity]
+	object := exprPath(e.Object)
+	if !ok || object == "" || memberType(c.st, objType.Entity, e.Field) != nil {
+		return
+	}
+
+	var declaring, variants []string // discriminator values and variant names
+	for _, value := range disc.variants {
+		v := lookupVariantByEnumValue(c.st, value)
+		if v != nil && slices.ContainsFunc(v.Fields, func(f ast.Field) bool { return f.Name == e.Field }) {
+			declaring = append(declaring, value)
+			variants = append(variants, v.Name)
+		}
+	}
+	if len(declaring) == 0 {
+		return // not a variant field
+	}
+
+	discPath := object + "." + disc.fieldName
+	for _, value := range disc.variants {
+		if !slices.Contains(declaring, value) && facts.mayHold(discPath, value) {
+			guard := fmt.Sprintf("%s = %s", discPath, declaring[0])
+			if len(declaring) > 1 {
+				guard = fmt.Sprintf("%s in {%s}", discPath, strings.Join(declaring, ", "))
+			}
+			c.findings = append(c.findings, report.NewError(
+				"RULE-18",
+				fmt.Sprintf("Field '%s' of %s is read from '%s' without a type guard; it needs %s",
+					e.Field, strings.Join(variants, " and "), object, guard),
+				report.Location{File: c.spec.File, Path: path.String()},
+			))
+			return
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package semantic
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/foundry-zero/allium/internal/ast"
@@ -167,6 +168,68 @@ func TestCheckSumTypes_RULE17_NoDiscriminator(t *testing.T) {
 	}
 }
 
+func TestCheckSumTypes_RULE18_VariantFieldAccess(t *testing.T) {
+	node := &ast.Expression{Kind: "field_access", Field: "node"}
+	kindIs := func(op, value string) *ast.Expression {
+		return &ast.Expression{Kind: "comparison", Operator: op,
+			Left:  &ast.Expression{Kind: "field_access", Object: node, Field: "kind"},
+			Right: &ast.Expression{Kind: "literal", Type: "enum_value", LitValue: json.RawMessage(`"` + value + `"`)}}
+	}
+	emit := []ast.EnsuresClause{{Kind: "trigger_emission", Name: "Visited", Arguments: map[string]ast.Expression{
+		"children": {Kind: "field_access", Object: node, Field: "children"},
+	}}}
+
+	tests := []struct {
+		name     string
+		entity   string
+		requires []ast.Expression
+		ensures  []ast.EnsuresClause
+		paths    []string
+	}{
+		{name: "unguarded", ensures: emit, paths: []string{"$.rules[1].ensures[0].arguments.children"}},
+		{name: "guarded by requires", requires: []ast.Expression{*kindIs("=", "Branch")}, ensures: emit},
+		{name: "other variant ruled out", requires: []ast.Expression{*kindIs("!=", "Leaf")}, ensures: emit},
+		{name: "binding typed as the variant", entity: "Branch", ensures: emit},
+		{
+			name:    "guarded by conditional",
+			ensures: []ast.EnsuresClause{{Kind: "conditional", Condition: kindIs("=", "Branch"), Then: emit, Else: emit}},
+			paths:   []string{"$.rules[1].ensures[0].else[0].arguments.children"},
+		},
+		{
+			name:    "else of the other variant",
+			ensures: []ast.EnsuresClause{{Kind: "conditional", Condition: kindIs("=", "Leaf"), Then: nil, Else: emit}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := sumTypeSpec()
+			entity := tt.entity
+			if entity == "" {
+				entity = "Node"
+			}
+			spec.Rules = append(spec.Rules, ast.Rule{
+				Name:     "VisitNode",
+				Trigger:  ast.Trigger{Kind: "entity_creation", Binding: "node", Entity: entity},
+				Requires: tt.requires,
+				Ensures:  tt.ensures,
+			})
+			r18 := findingsWithRule(CheckSumTypes(spec, BuildSymbolTable(spec)), "RULE-18")
+			if len(r18) != len(tt.paths) {
+				t.Fatalf("expected %d RULE-18, got %v", len(tt.paths), r18)
+			}
+			for i, f := range r18 {
+				if f.Location.Path != tt.paths[i] {
+					t.Errorf("path = %q, want %q", f.Location.Path, tt.paths[i])
+				}
+				if want := "Field 'children' of Branch is read from 'node' without a type guard; it needs node.kind = Branch"; f.Message != want {
+					t.Errorf("message = %q, want %q", f.Message, want)
+				}
+			}
+		})
+	}
+}
+
 func TestCheckSumTypes_RULE19_BaseEntityCreation(t *testing.T) {
 	spec := sumTypeSpec()
 	// Change creation to use base entity name "Node" instead of "Branch"
@@ -193,6 +256,25 @@ func TestCheckSumTypes_RULE19_BaseEntityCreationSnakeCase(t *testing.T) {
 	}
 }
 
+func TestCheckSumTypes_RULE19_LetBindingCreation(t *testing.T) {
+	spec := sumTypeSpec()
+	spec.Rules[0].Ensures[0] = ast.EnsuresClause{
+		Kind:  "let_binding",
+		Name:  "node",
+		Value: json.RawMessage(`{"kind": "entity_creation", "entity": "Node", "fields": {}}`),
+	}
+	st := BuildSymbolTable(spec)
+	findings := CheckSumTypes(spec, st)
+
+	r19 := findingsWithRule(findings, "RULE-19")
+	if len(r19) != 1 {
+		t.Fatalf("expected 1 RULE-19 for base entity created in a let binding, got %d: %v", len(r19), r19)
+	}
+	if r19[0].Location.Path != "$.rules[0].ensures[0].value" {
+		t.Errorf("unexpected path %s", r19[0].Location.Path)
+	}
+}
+
 func TestCheckSumTypes_RULE19_VariantCreation_OK(t *testing.T) {
 	spec := sumTypeSpec()
 	// Creating "Branch" (a variant) — should be fine
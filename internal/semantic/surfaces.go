@@ -13,6 +13,8 @@ import (
 //   - RULE-32: Facing and context bindings must be referenced in the surface body
 //   - RULE-33: When conditions must reference reachable fields
 //   - RULE-34: For iterations must target collection-typed fields
+//   - RULE-50: Guarantees must name declared rules, and their expressions
+//     read bound names and declared members
 func CheckSurfaces(spec *ast.Spec, st *SymbolTable) []report.Finding {
 	var findings []report.Finding
 
@@ -29,7 +31,7 @@ func CheckSurfaces(spec *ast.Spec, st *SymbolTable) []report.Finding {
 					findings = append(findings, report.NewError(
 						"RULE-29",
 						fmt.Sprintf("Unreachable path in exposes on surface '%s'", surface.Name),
-						report.Location{File: spec.File, Path: fmt.Sprintf("%s.exposes[%d]", basePath, j)},
+						report.Location{File: spec.File, Path: ast.IndexPath(basePath, "exposes", j)},
 					))
 				}
 			}
@@ -43,7 +45,7 @@ func CheckSurfaces(spec *ast.Spec, st *SymbolTable) []report.Finding {
 				findings = append(findings, report.NewError(
 					"RULE-32",
 					fmt.Sprintf("Unused binding '%s' in surface '%s'", surface.Facing.Binding, surface.Name),
-					report.Location{File: spec.File, Path: fmt.Sprintf("%s.facing.binding", basePath)},
+					report.Location{File: spec.File, Path: basePath + ".facing.binding"},
 				))
 			}
 		}
@@ -53,7 +55,7 @@ func CheckSurfaces(spec *ast.Spec, st *SymbolTable) []report.Finding {
 				findings = append(findings, report.NewError(
 					"RULE-32",
 					fmt.Sprintf("Unused binding '%s' in surface '%s'", surface.Context.Binding, surface.Name),
-					report.Location{File: spec.File, Path: fmt.Sprintf("%s.context.binding", basePath)},
+					report.Location{File: spec.File, Path: basePath + ".context.binding"},
 				))
 			}
 		}
@@ -64,13 +66,13 @@ func CheckSurfaces(spec *ast.Spec, st *SymbolTable) []report.Finding {
 				findings = append(findings, report.NewError(
 					"RULE-33",
 					fmt.Sprintf("When condition references unreachable field in surface '%s'", surface.Name),
-					report.Location{File: spec.File, Path: fmt.Sprintf("%s.exposes[%d].when", basePath, j)},
+					report.Location{File: spec.File, Path: ast.IndexPath(basePath, "exposes", j) + ".when"},
 				))
 			}
 		}
 		for j, p := range surface.Provides {
 			findings = checkProvidesWhenReachable(findings, p, bindings, surface.Name,
-				fmt.Sprintf("%s.provides[%d]", basePath, j), spec.File)
+				ast.IndexPath(basePath, "provides", j), spec.File)
 		}
 
 		// Build binding-to-type map for RULE-34 collection type checking
@@ -79,7 +81,12 @@ func CheckSurfaces(spec *ast.Spec, st *SymbolTable) []report.Finding {
 		// RULE-34: Check provides for_each collection types
 		for j, p := range surface.Provides {
 			findings = checkProvidesIteration(findings, p, st, surface.Name, bindings, bindingTypes,
-				fmt.Sprintf("%s.provides[%d]", basePath, j), spec.File)
+				ast.IndexPath(basePath, "provides", j), spec.File)
+		}
+
+		// RULE-50: Check guarantee rule references and expressions
+		for j, g := range surface.Guarantees {
+			findings = checkGuarantee(findings, spec, st, surface, g, ast.IndexPath(basePath, "guarantees", j))
 		}
 	}
 
@@ -139,7 +146,7 @@ func collectUsedBindings(s ast.Surface) map[string]bool {
 		collectProvidesRoots(p, used)
 	}
 	for _, g := range s.Guarantees {
-		_ = g // guarantees are descriptive, no expression refs
+		collectExprRoots(g.Expression, used)
 	}
 	for _, r := range s.Related {
 		collectExprRoots(r.ContextExpression, used)
@@ -167,30 +174,12 @@ func collectProvidesRoots(p ast.ProvidesItem, used map[string]bool) {
 }
 
 func collectExprRoots(expr *ast.Expression, used map[string]bool) {
-	if expr == nil {
-		return
-	}
-	root := findExprRoot(expr)
-	if root != "" {
-		used[root] = true
-	}
-	// Also recurse into sub-expressions for complex expressions
-	collectExprRoots(expr.Object, used)
-	collectExprRoots(expr.Left, used)
-	collectExprRoots(expr.Right, used)
-	collectExprRoots(expr.Target, used)
-	collectExprRoots(expr.Operand, used)
-	collectExprRoots(expr.Collection, used)
-	collectExprRoots(expr.Lambda, used)
-	collectExprRoots(expr.Condition, used)
-	collectExprRoots(expr.Body, used)
-	collectExprRoots(expr.Element, used)
-	for j := range expr.FuncArguments {
-		collectExprRoots(&expr.FuncArguments[j], used)
-	}
-	for j := range expr.Elements {
-		collectExprRoots(&expr.Elements[j], used)
-	}
+	ast.Walk(expr, func(e *ast.Expression) bool {
+		if root := findExprRoot(e); root != "" {
+			used[root] = true
+		}
+		return true
+	})
 }
 
 // allExprRootsReachable checks that every field_access root in an expression is in bindings.
@@ -229,13 +218,14 @@ func checkProvidesWhenReachable(findings []report.Finding, p ast.ProvidesItem, b
 		}
 		for j, item := range p.Items {
 			findings = checkProvidesWhenReachable(findings, item, innerBindings, surfaceName,
-				fmt.Sprintf("%s.items[%d]", path, j), file)
+				ast.IndexPath(path, "items", j), file)
 		}
 	}
 	return findings
 }
 
-// collectSurfaceBindingTypes returns a map from binding name to entity/type name.
+// collectSurfaceBindingTypes returns a map from binding name to entity/type
+// name, including let bindings that look up an entity.
 func collectSurfaceBindingTypes(s ast.Surface) map[string]string {
 	types := make(map[string]string)
 	if s.Facing.Binding != "" {
@@ -244,6 +234,11 @@ func collectSurfaceBindingTypes(s ast.Surface) map[string]string {
 	if s.Context != nil && s.Context.Binding != "" {
 		types[s.Context.Binding] = s.Context.Type
 	}
+	for _, lb := range s.LetBindings {
+		if lb.Expression != nil && lb.Expression.Kind == "join_lookup" && lb.Expression.Entity != "" {
+			types[lb.Name] = lb.Expression.Entity
+		}
+	}
 	return types
 }
 
@@ -261,7 +256,7 @@ func checkProvidesIteration(findings []report.Finding, p ast.ProvidesItem, st *S
 
 		for j, item := range p.Items {
 			findings = checkProvidesIteration(findings, item, st, surfaceName, bindings, bindingTypes,
-				fmt.Sprintf("%s.items[%d]", path, j), file)
+				ast.IndexPath(path, "items", j), file)
 		}
 	}
 	return findings
@@ -284,19 +279,21 @@ func isCollectionExpression(expr *ast.Expression, st *SymbolTable, bindingTypes
 			// First try resolving via binding types (binding name → entity type name)
 			if entityName, ok := bindingTypes[bindingName]; ok {
 				if entity := st.LookupEntity(entityName); entity != nil {
-					if isFieldPrimitive(entity.Fields, fieldName) {
+					fields := st.ResolveFields(entity.Fields)
+					if isFieldPrimitive(fields, fieldName) {
 						return false
 					}
-					return isCollectionField(entity.Fields, fieldName) || isRelationshipMany(entity.Relationships, fieldName)
+					return isCollectionField(fields, fieldName) || isRelationshipMany(entity.Relationships, fieldName)
 				}
 			}
 
 			// Fall back to direct entity lookup (binding name == entity name)
 			if entity := st.LookupEntity(bindingName); entity != nil {
-				if isFieldPrimitive(entity.Fields, fieldName) {
+				fields := st.ResolveFields(entity.Fields)
+				if isFieldPrimitive(fields, fieldName) {
 					return false
 				}
-				return isCollectionField(entity.Fields, fieldName) || isRelationshipMany(entity.Relationships, fieldName)
+				return isCollectionField(fields, fieldName) || isRelationshipMany(entity.Relationships, fieldName)
 			}
 		}
 	}
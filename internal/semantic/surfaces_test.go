@@ -295,6 +295,34 @@ func TestCheckSurfaces_RULE34_IterateOverString(t *testing.T) {
 	}
 }
 
+func TestCheckSurfaces_RULE34_LetBindingLookup(t *testing.T) {
+	spec := surfaceSpec()
+	spec.Surfaces[0].LetBindings = []ast.LetBinding{
+		{Name: "latest", Expression: &ast.Expression{Kind: "join_lookup", Entity: "Order"}},
+	}
+	spec.Surfaces[0].Provides = []ast.ProvidesItem{
+		{
+			Kind:    "for_each",
+			Binding: "s",
+			Collection: &ast.Expression{
+				Kind:   "field_access",
+				Object: &ast.Expression{Kind: "field_access", Field: "latest"},
+				Field:  "status",
+			},
+			Items: []ast.ProvidesItem{
+				{Kind: "action", Trigger: "submit_order"},
+			},
+		},
+	}
+	st := BuildSymbolTable(spec)
+	findings := CheckSurfaces(spec, st)
+
+	r34 := findingsWithRule(findings, "RULE-34")
+	if len(r34) != 1 {
+		t.Fatalf("expected RULE-34 for iterating over a String field of a let binding, got %v", r34)
+	}
+}
+
 func TestCheckSurfaces_RULE34_IterateOverList(t *testing.T) {
 	spec := surfaceSpec()
 	// for_each over order.items which is a list (valid)
@@ -2,10 +2,14 @@
 package semantic
 
 import (
+	"fmt"
+
 	"github.com/foundry-zero/allium/internal/ast"
 )
 
 // SymbolTable indexes all named declarations in a specification for fast lookup.
+// When several declarations of one kind share a name, the maps and LookupX
+// methods hold the first of them, and the others are listed in Duplicates.
 type SymbolTable struct {
 	Entities         map[string]*ast.Entity
 	ExternalEntities map[string]*ast.ExternalEntity
@@ -19,6 +23,39 @@ type SymbolTable struct {
 	Variants         map[string]*ast.Variant
 	UseDeclarations  map[string]*ast.UseDeclaration
 	ValueTypes       map[string]*ast.ValueType
+	TypeAliases      map[string]*ast.TypeAlias
+
+	// Duplicates lists every declaration whose name an earlier declaration of
+	// the same kind already took, grouped by section and in declaration order
+	// within each.
+	// Triggers are not included; rules sharing a trigger are grouped by it.
+	Duplicates []Duplicate
+
+	// Functions holds the signatures of callable black box functions. It
+	// starts with the built-ins; callers may replace it with an extended
+	// registry before running passes.
+	Functions *FunctionRegistry
+
+	// TerminalStates holds status values declared intentionally terminal by
+	// project configuration, by entity and then status field. RULE-08 does
+	// not report them. It is nil unless set by the caller.
+	TerminalStates map[string]map[string][]string
+
+	// InitialStates holds status values that instances created outside the
+	// spec, such as entities provided through given bindings, may start in,
+	// by entity and then status field. RULE-07 treats them as creation
+	// values. It is nil unless set by the caller.
+	InitialStates map[string]map[string][]string
+
+	// SensitiveFields holds members marked sensitive by project
+	// configuration, by type and then member, with the actors allowed to see
+	// each. RULE-62 checks them alongside those the spec's metadata marks. It
+	// is nil unless set by the caller.
+	SensitiveFields map[string]map[string][]string
+
+	// Naming holds the project's naming conventions, which WARN-28 checks.
+	// It is nil unless set by the caller, and then none are checked.
+	Naming *NamingConventions
 }
 
 // BuildSymbolTable constructs a SymbolTable from a parsed specification.
@@ -37,50 +74,62 @@ func BuildSymbolTable(spec *ast.Spec) *SymbolTable {
 		Variants:         make(map[string]*ast.Variant, len(spec.Variants)),
 		UseDeclarations:  make(map[string]*ast.UseDeclaration, len(spec.UseDeclarations)),
 		ValueTypes:       make(map[string]*ast.ValueType, len(spec.ValueTypes)),
+		TypeAliases:      make(map[string]*ast.TypeAlias, len(spec.TypeAliases)),
+		Functions:        BuiltinFunctions(),
 	}
 
-	for i := range spec.Entities {
-		st.Entities[spec.Entities[i].Name] = &spec.Entities[i]
-	}
-	for i := range spec.ExternalEntities {
-		st.ExternalEntities[spec.ExternalEntities[i].Name] = &spec.ExternalEntities[i]
-	}
+	index(st, "entities", st.Entities, spec.Entities, func(e *ast.Entity) string { return e.Name })
+	index(st, "external_entities", st.ExternalEntities, spec.ExternalEntities, func(e *ast.ExternalEntity) string { return e.Name })
+	index(st, "rules", st.Rules, spec.Rules, func(r *ast.Rule) string { return r.Name })
 	for i := range spec.Rules {
 		r := &spec.Rules[i]
-		st.Rules[r.Name] = r
 		triggerName := triggerKeyName(r)
 		if triggerName != "" {
 			st.Triggers[triggerName] = append(st.Triggers[triggerName], r)
 		}
 	}
-	for i := range spec.Actors {
-		st.Actors[spec.Actors[i].Name] = &spec.Actors[i]
-	}
-	for i := range spec.Surfaces {
-		st.Surfaces[spec.Surfaces[i].Name] = &spec.Surfaces[i]
-	}
-	for i := range spec.Config {
-		st.Config[spec.Config[i].Name] = &spec.Config[i]
-	}
-	for i := range spec.Given {
-		st.Given[spec.Given[i].Name] = &spec.Given[i]
-	}
-	for i := range spec.Enumerations {
-		st.Enumerations[spec.Enumerations[i].Name] = &spec.Enumerations[i]
-	}
-	for i := range spec.Variants {
-		st.Variants[spec.Variants[i].Name] = &spec.Variants[i]
-	}
-	for i := range spec.UseDeclarations {
-		st.UseDeclarations[spec.UseDeclarations[i].Alias] = &spec.UseDeclarations[i]
-	}
-	for i := range spec.ValueTypes {
-		st.ValueTypes[spec.ValueTypes[i].Name] = &spec.ValueTypes[i]
-	}
+	index(st, "actors", st.Actors, spec.Actors, func(a *ast.Actor) string { return a.Name })
+	index(st, "surfaces", st.Surfaces, spec.Surfaces, func(s *ast.Surface) string { return s.Name })
+	index(st, "config", st.Config, spec.Config, func(c *ast.ConfigParam) string { return c.Name })
+	index(st, "given", st.Given, spec.Given, func(g *ast.GivenBinding) string { return g.Name })
+	index(st, "enumerations", st.Enumerations, spec.Enumerations, func(e *ast.Enumeration) string { return e.Name })
+	index(st, "variants", st.Variants, spec.Variants, func(v *ast.Variant) string { return v.Name })
+	index(st, "use_declarations", st.UseDeclarations, spec.UseDeclarations, func(u *ast.UseDeclaration) string { return u.Alias })
+	index(st, "value_types", st.ValueTypes, spec.ValueTypes, func(v *ast.ValueType) string { return v.Name })
+	index(st, "type_aliases", st.TypeAliases, spec.TypeAliases, func(a *ast.TypeAlias) string { return a.Name })
 
 	return st
 }
 
+// Duplicate is a declaration whose name an earlier declaration of the same
+// kind already took.
+type Duplicate struct {
+	Section string // key of the spec section, e.g. "entities" or "use_declarations"
+	Name    string
+	First   int // index of the declaration the name resolves to
+	Index   int // index of the duplicate
+}
+
+// Path returns the JSONPath of the duplicate declaration.
+func (d Duplicate) Path() string {
+	return fmt.Sprintf("$.%s[%d]", d.Section, d.Index)
+}
+
+// index adds the declarations in items to m by name. The first declaration
+// of a name is kept; later ones are recorded in st.Duplicates.
+func index[T any](st *SymbolTable, section string, m map[string]*T, items []T, name func(*T) string) {
+	first := make(map[string]int, len(items))
+	for i := range items {
+		n := name(&items[i])
+		if j, ok := first[n]; ok {
+			st.Duplicates = append(st.Duplicates, Duplicate{Section: section, Name: n, First: j, Index: i})
+			continue
+		}
+		first[n] = i
+		m[n] = &items[i]
+	}
+}
+
 // triggerKeyName returns the trigger name used for grouping rules.
 // For external_stimulus and chained triggers this is the trigger name;
 // for other kinds we return empty (they are not grouped by trigger name).
@@ -171,12 +220,68 @@ func (st *SymbolTable) LookupValueType(name string) *ast.ValueType {
 	return st.ValueTypes[name]
 }
 
+// LookupTypeAlias returns the type alias with the given name, or nil.
+func (st *SymbolTable) LookupTypeAlias(name string) *ast.TypeAlias {
+	return st.TypeAliases[name]
+}
+
+// ResolveType expands type aliases in ft, including aliases nested inside
+// optional, set and list types. Undeclared aliases are left in place, as are
+// circular ones once every alias has been expanded without reaching a
+// concrete type.
+func (st *SymbolTable) ResolveType(ft ast.FieldType) ast.FieldType {
+	if len(st.TypeAliases) == 0 {
+		return ft
+	}
+	return st.resolveType(ft, 0)
+}
+
+func (st *SymbolTable) resolveType(ft ast.FieldType, depth int) ast.FieldType {
+	if depth > len(st.TypeAliases) {
+		return ft
+	}
+	switch ft.Kind {
+	case "alias":
+		if a := st.TypeAliases[ft.Name]; a != nil {
+			return st.resolveType(a.Type, depth+1)
+		}
+	case "optional":
+		if ft.Inner != nil {
+			inner := st.resolveType(*ft.Inner, depth)
+			ft.Inner = &inner
+		}
+	case "set", "list":
+		if ft.Element != nil {
+			elem := st.resolveType(*ft.Element, depth)
+			ft.Element = &elem
+		}
+	}
+	return ft
+}
+
+// ResolveFields returns fields with their type aliases expanded. The input
+// slice is returned unchanged when the spec declares no aliases.
+func (st *SymbolTable) ResolveFields(fields []ast.Field) []ast.Field {
+	if len(st.TypeAliases) == 0 {
+		return fields
+	}
+	resolved := make([]ast.Field, len(fields))
+	for i, f := range fields {
+		resolved[i] = ast.Field{Name: f.Name, Type: st.resolveType(f.Type, 0)}
+	}
+	return resolved
+}
+
 // LookupType returns true if name matches any type-like declaration:
-// entity, external entity, variant, use declaration, value type, or enumeration.
+// entity, external entity, variant, use declaration, value type, enumeration,
+// or type alias.
 func (st *SymbolTable) LookupType(name string) bool {
 	if st.LookupAnyEntity(name) {
 		return true
 	}
+	if _, ok := st.TypeAliases[name]; ok {
+		return true
+	}
 	if _, ok := st.ValueTypes[name]; ok {
 		return true
 	}
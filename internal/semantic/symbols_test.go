@@ -1,6 +1,7 @@
 package semantic
 
 import (
+	"slices"
 	"testing"
 
 	"github.com/foundry-zero/allium/internal/ast"
@@ -329,3 +330,62 @@ func TestPointersAreStable(t *testing.T) {
 		t.Error("symbol table entity pointer does not reference spec slice element")
 	}
 }
+
+func TestDuplicatesKeepFirstDeclaration(t *testing.T) {
+	spec := makeTestSpec()
+	spec.Entities = append(spec.Entities, ast.Entity{Name: "Account"}, ast.Entity{Name: "Account"})
+	spec.UseDeclarations = []ast.UseDeclaration{{Alias: "auth", Coordinate: "a"}, {Alias: "auth", Coordinate: "b"}}
+	st := BuildSymbolTable(spec)
+
+	if e := st.LookupEntity("Account"); e != &spec.Entities[0] {
+		t.Error("LookupEntity should return the first declaration")
+	}
+	if u := st.LookupUseDeclaration("auth"); u == nil || u.Coordinate != "a" {
+		t.Errorf("LookupUseDeclaration = %+v, want the first declaration", u)
+	}
+	want := []Duplicate{
+		{Section: "entities", Name: "Account", First: 0, Index: 2},
+		{Section: "entities", Name: "Account", First: 0, Index: 3},
+		{Section: "use_declarations", Name: "auth", First: 0, Index: 1},
+	}
+	if !slices.Equal(st.Duplicates, want) {
+		t.Errorf("Duplicates = %+v, want %+v", st.Duplicates, want)
+	}
+	if got := st.Duplicates[1].Path(); got != "$.entities[3]" {
+		t.Errorf("Path() = %q", got)
+	}
+	if len(BuildSymbolTable(makeTestSpec()).Duplicates) != 0 {
+		t.Error("expected no duplicates in the test spec")
+	}
+}
+
+func TestResolveType(t *testing.T) {
+	spec := &ast.Spec{
+		TypeAliases: []ast.TypeAlias{
+			{Name: "Email", Type: ast.FieldType{Kind: "primitive", Value: "String"}},
+			{Name: "Emails", Type: ast.FieldType{Kind: "list", Element: &ast.FieldType{Kind: "alias", Name: "Email"}}},
+			{Name: "Loop", Type: ast.FieldType{Kind: "optional", Inner: &ast.FieldType{Kind: "alias", Name: "Loop"}}},
+		},
+	}
+	st := BuildSymbolTable(spec)
+
+	got := st.ResolveType(ast.FieldType{Kind: "optional", Inner: &ast.FieldType{Kind: "alias", Name: "Emails"}})
+	if got.Kind != "optional" || got.Inner.Kind != "list" || got.Inner.Element.Kind != "primitive" || got.Inner.Element.Value != "String" {
+		t.Errorf("ResolveType(Emails?) = %+v", got)
+	}
+	if spec.TypeAliases[1].Type.Element.Kind != "alias" {
+		t.Error("ResolveType modified the alias declaration")
+	}
+
+	if got := st.ResolveType(ast.FieldType{Kind: "alias", Name: "Missing"}); got.Kind != "alias" {
+		t.Errorf("undeclared alias should be left in place, got %+v", got)
+	}
+
+	// A circular alias must terminate rather than recurse forever.
+	_ = st.ResolveType(ast.FieldType{Kind: "alias", Name: "Loop"})
+
+	fields := []ast.Field{{Name: "contact", Type: ast.FieldType{Kind: "alias", Name: "Email"}}}
+	if resolved := st.ResolveFields(fields); resolved[0].Type.Kind != "primitive" || fields[0].Type.Kind != "alias" {
+		t.Errorf("ResolveFields = %+v, input = %+v", resolved, fields)
+	}
+}
@@ -0,0 +1,109 @@
+package semantic
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/report"
+)
+
+// CheckTemporalConditions validates that temporal triggers can be scheduled.
+//
+//   - RULE-53: A temporal trigger's condition must order a Timestamp field of
+//     the bound entity, or a time or duration computed from one, against
+//     now with <, <=, > or >=. A condition that only tests equality, or
+//     compares values that are not times, never becomes true as time passes
+//
+// Conditions that RULE-41 rejects, or that read a derived value or
+// relationship from which the time may be computed, are not checked.
+func CheckTemporalConditions(spec *ast.Spec, st *SymbolTable) []report.Finding {
+	var findings []report.Finding
+
+	for i, rule := range spec.Rules {
+		t := rule.Trigger
+		if t.Kind != "temporal" || t.Binding == "" || t.Entity == "" || t.Condition == nil {
+			continue
+		}
+		if st.LookupUseDeclaration(t.Entity) != nil {
+			continue
+		}
+		members, ok := triggerEntityMembers(st, t.Entity)
+		if !ok {
+			continue
+		}
+		timed := false
+		for _, name := range extractFieldNames(t.Condition, t.Binding) {
+			if members.field(name) == nil {
+				timed = false
+				break
+			}
+			timed = timed || isTimestampField(members.fields, name)
+		}
+		if !timed {
+			continue
+		}
+
+		fieldTypes := ruleFieldTypes(rule, spec, st)
+		scheduled := false
+		walkExpression(t.Condition, func(e *ast.Expression) {
+			if !scheduled && isOrderingComparison(e) {
+				scheduled = comparesTimeWithNow(e, t.Binding, members, fieldTypes, st)
+			}
+		})
+		if !scheduled {
+			findings = append(findings, report.NewError(
+				"RULE-53",
+				fmt.Sprintf("Temporal trigger condition on '%s' never orders a Timestamp field of '%s' against now, so rule '%s' is never scheduled", t.Binding, t.Entity, rule.Name),
+				report.Location{File: spec.File, Path: fmt.Sprintf("$.rules[%d].trigger.condition", i)},
+			))
+		}
+	}
+
+	return findings
+}
+
+// isOrderingComparison reports whether e compares with <, <=, > or >=.
+func isOrderingComparison(e *ast.Expression) bool {
+	return e.Kind == "comparison" && slices.Contains([]string{"<", "<=", ">", ">="}, e.Operator)
+}
+
+// comparesTimeWithNow reports whether a comparison reads now and a Timestamp
+// field of the binding, with operands that are times, durations or of
+// unknown type: `user.locked_until <= now` or `now - order.placed_at > 7.days`.
+func comparesTimeWithNow(cmp *ast.Expression, binding string, m triggerMembers, fieldTypes map[string]*ast.FieldType, st *SymbolTable) bool {
+	if !readsNow(cmp) {
+		return false
+	}
+	reads := slices.ContainsFunc(extractFieldNames(cmp, binding), func(name string) bool {
+		return isTimestampField(m.fields, name)
+	})
+	if !reads {
+		return false
+	}
+	for _, side := range []*ast.Expression{cmp.Left, cmp.Right} {
+		if t := resolveExprType(side, fieldTypes, st); t != "" && !isTemporalType(t) {
+			return false
+		}
+	}
+	return true
+}
+
+// readsNow reports whether expr reads the current time, as the `now`
+// timestamp literal or a call to now().
+func readsNow(expr *ast.Expression) bool {
+	found := false
+	walkExpression(expr, func(e *ast.Expression) {
+		switch e.Kind {
+		case "function_call":
+			found = found || e.FuncName == "now"
+		case "literal":
+			var s string
+			if e.Type == "timestamp" && json.Unmarshal(e.LitValue, &s) == nil {
+				found = found || s == "now"
+			}
+		}
+	})
+	return found
+}
@@ -0,0 +1,68 @@
+package semantic
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/foundry-zero/allium/internal/ast"
+)
+
+func temporalTrigger(condition *ast.Expression) ast.Trigger {
+	return ast.Trigger{Kind: "temporal", Binding: "account", Entity: "Account", Condition: condition}
+}
+
+func TestCheckTemporalConditions_Scheduled(t *testing.T) {
+	nowCall := &ast.Expression{Kind: "function_call", FuncName: "now"}
+	for name, condition := range map[string]*ast.Expression{
+		"field before now":  comparisonExpr("<=", accountField("created_at"), tsLitExpr("now")),
+		"now after field":   comparisonExpr(">", nowCall, accountField("created_at")),
+		"field plus period": comparisonExpr("<=", arithmeticExpr("+", accountField("created_at"), durLitExpr("30.days")), tsLitExpr("now")),
+		"age exceeds":       comparisonExpr(">=", arithmeticExpr("-", nowCall, accountField("created_at")), durLitExpr("1.year")),
+		"within conjunction": {Kind: "boolean_logic", Operator: "and",
+			Left:  comparisonExpr("=", accountField("verified"), boolLitExpr(false)),
+			Right: comparisonExpr("<", accountField("created_at"), tsLitExpr("now"))},
+		// Left to RULE-41.
+		"no Timestamp field": comparisonExpr("=", accountField("name"), strLitExpr("x")),
+		"undeclared field":   comparisonExpr("=", accountField("nmae"), accountField("created_at")),
+		// The time may be computed from the derived value.
+		"derived value": comparisonExpr("=", accountField("is_dormant"), accountField("created_at")),
+	} {
+		spec := triggerSpec(temporalTrigger(condition))
+		if findings := CheckTemporalConditions(spec, BuildSymbolTable(spec)); len(findings) != 0 {
+			t.Errorf("%s: expected no findings, got %v", name, findings)
+		}
+	}
+}
+
+func TestCheckTemporalConditions_RULE53(t *testing.T) {
+	for name, condition := range map[string]*ast.Expression{
+		"equal to now": comparisonExpr("=", accountField("created_at"), tsLitExpr("now")),
+		"no now":       comparisonExpr("<", accountField("created_at"), tsLitExpr("2026-01-01T00:00:00Z")),
+		"strings compared": {Kind: "boolean_logic", Operator: "and",
+			Left:  &ast.Expression{Kind: "exists", Target: accountField("created_at")},
+			Right: comparisonExpr("<", accountField("name"), &ast.Expression{Kind: "function_call", FuncName: "now"})},
+		"now with no field": {Kind: "boolean_logic", Operator: "and",
+			Left:  &ast.Expression{Kind: "exists", Target: accountField("created_at")},
+			Right: comparisonExpr("<", tsLitExpr("2026-01-01T00:00:00Z"), tsLitExpr("now"))},
+	} {
+		spec := triggerSpec(temporalTrigger(condition))
+		findings := CheckTemporalConditions(spec, BuildSymbolTable(spec))
+		if len(findings) != 1 || findings[0].Rule != "RULE-53" {
+			t.Errorf("%s: expected 1 RULE-53 finding, got %v", name, findings)
+			continue
+		}
+		if findings[0].Location.Path != "$.rules[0].trigger.condition" {
+			t.Errorf("%s: path = %q", name, findings[0].Location.Path)
+		}
+	}
+}
+
+func TestCheckTemporalConditions_ReferenceExample(t *testing.T) {
+	spec, err := ast.LoadSpec(filepath.Join(projectRoot(), "schemas", "v1", "examples", "password-auth.allium.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if findings := CheckTemporalConditions(spec, BuildSymbolTable(spec)); len(findings) != 0 {
+		t.Errorf("expected no findings, got %v", findings)
+	}
+}
@@ -0,0 +1,298 @@
+package semantic
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/report"
+)
+
+// CheckTriggers validates the shape of triggers bound to an entity.
+//
+//   - RULE-41: The entity of a state_transition, state_becomes, temporal,
+//     derived_condition or entity_creation trigger must be declared. A
+//     state_transition or state_becomes trigger must watch an enum field of
+//     that entity for a declared value, a derived_condition trigger a derived
+//     value or Boolean field, and a temporal trigger's condition must read a
+//     Timestamp field of the bound entity
+//   - RULE-44: A trigger_emission must pass the parameters of the chained
+//     trigger it emits: no argument the trigger does not declare, and every
+//     parameter not marked optional
+func CheckTriggers(spec *ast.Spec, st *SymbolTable) []report.Finding {
+	var findings []report.Finding
+
+	for i, rule := range spec.Rules {
+		t := rule.Trigger
+		if t.Binding == "" || t.Entity == "" {
+			continue
+		}
+		path := fmt.Sprintf("$.rules[%d].trigger", i)
+
+		// Entities imported through use declarations are resolved in
+		// workspace mode (RULE-35); their fields are not known here.
+		if st.LookupUseDeclaration(t.Entity) != nil {
+			continue
+		}
+		members, ok := triggerEntityMembers(st, t.Entity)
+		if !ok {
+			findings = append(findings, report.NewError(
+				"RULE-41",
+				fmt.Sprintf("Trigger entity '%s' is not declared%s", t.Entity, didYouMean(t.Entity, entityLikeNames(spec))),
+				report.Location{File: spec.File, Path: path + ".entity"},
+			))
+			continue
+		}
+
+		switch t.Kind {
+		case "state_transition", "state_becomes":
+			findings = checkStateTrigger(findings, spec, st, t, members, path)
+		case "derived_condition":
+			findings = checkDerivedConditionTrigger(findings, spec, t, members, path)
+		case "temporal":
+			findings = checkTemporalTrigger(findings, spec, t, members, path)
+		}
+	}
+
+	for i, rule := range spec.Rules {
+		for j, ec := range rule.Ensures {
+			findings = walkEmissions(findings, ec, fmt.Sprintf("$.rules[%d].ensures[%d]", i, j),
+				func(findings []report.Finding, ec ast.EnsuresClause, path string) []report.Finding {
+					return checkEmissionArguments(findings, spec, st, ec, path)
+				})
+		}
+	}
+
+	return findings
+}
+
+// checkEmissionArguments checks the arguments of a trigger_emission against
+// the parameters of the chained trigger it emits. Parameters are taken from
+// the first rule consuming the trigger; disagreement between consumers is
+// RULE-06. An emission no rule consumes is WARN-20. Parameters declare no
+// types, so only argument names are checked.
+func checkEmissionArguments(findings []report.Finding, spec *ast.Spec, st *SymbolTable, ec ast.EnsuresClause, path string) []report.Finding {
+	var consumer *ast.Rule
+	for _, r := range st.Triggers[ec.Name] {
+		if r.Trigger.Kind == "chained" {
+			consumer = r
+			break
+		}
+	}
+	if consumer == nil {
+		return findings
+	}
+
+	params := consumer.Trigger.Parameters
+	var names []string
+	for _, p := range params {
+		names = append(names, p.Name)
+	}
+	args := slices.Sorted(maps.Keys(ec.Arguments))
+	for _, arg := range args {
+		if !slices.Contains(names, arg) {
+			findings = append(findings, report.NewError(
+				"RULE-44",
+				fmt.Sprintf("Trigger '%s' has no parameter '%s'%s", ec.Name, arg, didYouMean(arg, names)),
+				report.Location{File: spec.File, Path: path + ".arguments." + arg},
+			))
+		}
+	}
+	for _, p := range params {
+		if !p.Optional && !slices.Contains(args, p.Name) {
+			findings = append(findings, report.NewError(
+				"RULE-44",
+				fmt.Sprintf("Emission of trigger '%s' is missing argument '%s', required by rule '%s'", ec.Name, p.Name, consumer.Name),
+				report.Location{File: spec.File, Path: path},
+			))
+		}
+	}
+	return findings
+}
+
+// triggerMembers are the names a trigger binding can read on an entity.
+type triggerMembers struct {
+	fields  []ast.Field // aliases expanded
+	derived []string
+	related []string // relationships and projections
+}
+
+// names returns every member name, for suggestions.
+func (m triggerMembers) names(withFields, withDerived bool) []string {
+	var names []string
+	if withFields {
+		for _, f := range m.fields {
+			names = append(names, f.Name)
+		}
+	}
+	if withDerived {
+		names = append(names, m.derived...)
+	}
+	return names
+}
+
+func (m triggerMembers) field(name string) *ast.Field {
+	for i := range m.fields {
+		if m.fields[i].Name == name {
+			return &m.fields[i]
+		}
+	}
+	return nil
+}
+
+// triggerEntityMembers returns the members of the entity, variant or
+// external entity with the given name. A variant has its own members and
+// those of its base entity.
+func triggerEntityMembers(st *SymbolTable, name string) (triggerMembers, bool) {
+	var m triggerMembers
+	addEntity := func(e *ast.Entity) {
+		m.fields = append(m.fields, st.ResolveFields(e.Fields)...)
+		for _, dv := range e.DerivedValues {
+			m.derived = append(m.derived, dv.Name)
+		}
+		for _, r := range e.Relationships {
+			m.related = append(m.related, r.Name)
+		}
+		for _, p := range e.Projections {
+			m.related = append(m.related, p.Name)
+		}
+	}
+	if e := st.LookupEntity(name); e != nil {
+		addEntity(e)
+		return m, true
+	}
+	if v := st.LookupVariant(name); v != nil {
+		m.fields = append(m.fields, st.ResolveFields(v.Fields)...)
+		if base := st.LookupEntity(v.BaseEntity); base != nil {
+			addEntity(base)
+		}
+		return m, true
+	}
+	if e := st.LookupExternalEntity(name); e != nil {
+		m.fields = st.ResolveFields(e.Fields)
+		return m, true
+	}
+	return m, false
+}
+
+// entityLikeNames returns the names of the spec's entities, variants and
+// external entities.
+func entityLikeNames(spec *ast.Spec) []string {
+	var names []string
+	for _, e := range spec.Entities {
+		names = append(names, e.Name)
+	}
+	for _, v := range spec.Variants {
+		names = append(names, v.Name)
+	}
+	for _, e := range spec.ExternalEntities {
+		names = append(names, e.Name)
+	}
+	return names
+}
+
+// checkStateTrigger checks that a state_transition or state_becomes trigger
+// watches an enum field for one of its values.
+func checkStateTrigger(findings []report.Finding, spec *ast.Spec, st *SymbolTable, t ast.Trigger, m triggerMembers, path string) []report.Finding {
+	f := m.field(t.Field)
+	if f == nil {
+		return append(findings, report.NewError(
+			"RULE-41",
+			fmt.Sprintf("Trigger field '%s' is not declared on '%s'%s", t.Field, t.Entity, didYouMean(t.Field, m.names(true, false))),
+			report.Location{File: spec.File, Path: path + ".field"},
+		))
+	}
+	values, ok := enumValuesOf(st, f.Type)
+	if !ok {
+		return append(findings, report.NewError(
+			"RULE-41",
+			fmt.Sprintf("Trigger field '%s.%s' is not an enum; %s triggers watch a status field", t.Entity, t.Field, t.Kind),
+			report.Location{File: spec.File, Path: path + ".field"},
+		))
+	}
+	value, valuePath := t.ToValue, path+".to_value"
+	if t.Kind == "state_becomes" {
+		value, valuePath = t.Value, path+".value"
+	}
+	if !slices.Contains(values, value) {
+		findings = append(findings, report.NewError(
+			"RULE-41",
+			fmt.Sprintf("Trigger value '%s' is not a value of '%s.%s'%s", value, t.Entity, t.Field, didYouMean(value, values)),
+			report.Location{File: spec.File, Path: valuePath},
+		))
+	}
+	return findings
+}
+
+// enumValuesOf returns the values of an enum field type, optional or not.
+func enumValuesOf(st *SymbolTable, ft ast.FieldType) ([]string, bool) {
+	if ft.Kind == "optional" && ft.Inner != nil {
+		ft = *ft.Inner
+	}
+	switch ft.Kind {
+	case "inline_enum":
+		return ft.Values, true
+	case "named_enum":
+		if enum := st.LookupEnumeration(ft.Name); enum != nil {
+			return enum.Values, true
+		}
+	}
+	return nil, false
+}
+
+// checkDerivedConditionTrigger checks that a derived_condition trigger
+// watches a derived value or a Boolean field.
+func checkDerivedConditionTrigger(findings []report.Finding, spec *ast.Spec, t ast.Trigger, m triggerMembers, path string) []report.Finding {
+	if slices.Contains(m.derived, t.Field) {
+		return findings
+	}
+	f := m.field(t.Field)
+	if f == nil {
+		return append(findings, report.NewError(
+			"RULE-41",
+			fmt.Sprintf("Trigger field '%s' is not a derived value or field of '%s'%s", t.Field, t.Entity, didYouMean(t.Field, m.names(true, true))),
+			report.Location{File: spec.File, Path: path + ".field"},
+		))
+	}
+	if f.Type.Kind != "primitive" || f.Type.Value != "Boolean" {
+		findings = append(findings, report.NewError(
+			"RULE-41",
+			fmt.Sprintf("Trigger field '%s.%s' is not Boolean; derived_condition triggers fire when it becomes true", t.Entity, t.Field),
+			report.Location{File: spec.File, Path: path + ".field"},
+		))
+	}
+	return findings
+}
+
+// checkTemporalTrigger checks that a temporal trigger's condition reads only
+// declared members of the bound entity, at least one of them a Timestamp
+// field. A condition reading a derived value or relationship may compute its
+// time from it, so is not required to read a Timestamp field directly.
+func checkTemporalTrigger(findings []report.Finding, spec *ast.Spec, t ast.Trigger, m triggerMembers, path string) []report.Finding {
+	read := extractFieldNames(t.Condition, t.Binding)
+	timed := false
+	for _, name := range read {
+		switch {
+		case slices.Contains(m.derived, name), slices.Contains(m.related, name):
+			timed = true
+		case m.field(name) != nil:
+			timed = timed || isTimestampField(m.fields, name)
+		default:
+			findings = append(findings, report.NewError(
+				"RULE-41",
+				fmt.Sprintf("Temporal trigger condition reads '%s.%s', which is not declared on '%s'%s", t.Binding, name, t.Entity, didYouMean(name, m.names(true, true))),
+				report.Location{File: spec.File, Path: path + ".condition"},
+			))
+			timed = true // already reported
+		}
+	}
+	if !timed {
+		findings = append(findings, report.NewError(
+			"RULE-41",
+			fmt.Sprintf("Temporal trigger condition on '%s' reads no Timestamp field of '%s'", t.Binding, t.Entity),
+			report.Location{File: spec.File, Path: path + ".condition"},
+		))
+	}
+	return findings
+}
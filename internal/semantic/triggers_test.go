@@ -0,0 +1,231 @@
+package semantic
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/foundry-zero/allium/internal/ast"
+)
+
+// triggerSpec returns a spec whose Account entity has a status enum, a
+// Boolean, a Timestamp and a derived value, and one rule with the given
+// trigger.
+func triggerSpec(trigger ast.Trigger) *ast.Spec {
+	return &ast.Spec{
+		File: "test.allium.json",
+		Entities: []ast.Entity{
+			{
+				Name: "Account",
+				Fields: []ast.Field{
+					{Name: "status", Type: ast.FieldType{Kind: "named_enum", Name: "AccountStatus"}},
+					{Name: "verified", Type: ast.FieldType{Kind: "primitive", Value: "Boolean"}},
+					{Name: "created_at", Type: ast.FieldType{Kind: "primitive", Value: "Timestamp"}},
+					{Name: "name", Type: ast.FieldType{Kind: "primitive", Value: "String"}},
+				},
+				DerivedValues: []ast.DerivedValue{{Name: "is_dormant"}},
+			},
+		},
+		Variants: []ast.Variant{
+			{Name: "Business", BaseEntity: "Account", Fields: []ast.Field{
+				{Name: "tier", Type: ast.FieldType{Kind: "inline_enum", Values: []string{"basic", "plus"}}},
+			}},
+		},
+		ExternalEntities: []ast.ExternalEntity{
+			{Name: "Payment", Fields: []ast.Field{
+				{Name: "state", Type: ast.FieldType{Kind: "optional", Inner: &ast.FieldType{Kind: "inline_enum", Values: []string{"pending", "settled"}}}},
+			}},
+		},
+		Enumerations: []ast.Enumeration{
+			{Name: "AccountStatus", Values: []string{"active", "closed"}},
+		},
+		Rules: []ast.Rule{{Name: "OnAccount", Trigger: trigger}},
+	}
+}
+
+func accountField(field string) *ast.Expression {
+	return &ast.Expression{Kind: "field_access", Object: fieldAccess("account"), Field: field}
+}
+
+func TestCheckTriggers_Valid(t *testing.T) {
+	for _, trigger := range []ast.Trigger{
+		{Kind: "state_transition", Binding: "account", Entity: "Account", Field: "status", ToValue: "closed"},
+		{Kind: "state_becomes", Binding: "account", Entity: "Account", Field: "status", Value: "active"},
+		{Kind: "state_transition", Binding: "business", Entity: "Business", Field: "tier", ToValue: "plus"},
+		{Kind: "state_becomes", Binding: "business", Entity: "Business", Field: "status", Value: "closed"},
+		{Kind: "state_transition", Binding: "payment", Entity: "Payment", Field: "state", ToValue: "settled"},
+		{Kind: "derived_condition", Binding: "account", Entity: "Account", Field: "is_dormant"},
+		{Kind: "derived_condition", Binding: "account", Entity: "Account", Field: "verified"},
+		{Kind: "entity_creation", Binding: "account", Entity: "Account"},
+		{Kind: "temporal", Binding: "account", Entity: "Account",
+			Condition: comparisonExpr("<=", accountField("created_at"), tsLitExpr("now"))},
+		{Kind: "temporal", Binding: "account", Entity: "Account",
+			Condition: comparisonExpr("<=", accountField("is_dormant"), tsLitExpr("now"))},
+		{Kind: "external_stimulus", Name: "open_account"},
+	} {
+		spec := triggerSpec(trigger)
+		if findings := CheckTriggers(spec, BuildSymbolTable(spec)); len(findings) != 0 {
+			t.Errorf("%s trigger on %s.%s: expected no findings, got %v", trigger.Kind, trigger.Entity, trigger.Field, findings)
+		}
+	}
+}
+
+func TestCheckTriggers_RULE41(t *testing.T) {
+	tests := []struct {
+		name    string
+		trigger ast.Trigger
+		path    string
+		message string
+	}{
+		{
+			name:    "undeclared entity",
+			trigger: ast.Trigger{Kind: "entity_creation", Binding: "account", Entity: "Acount"},
+			path:    "$.rules[0].trigger.entity",
+			message: "did you mean 'Account'?",
+		},
+		{
+			name:    "undeclared field",
+			trigger: ast.Trigger{Kind: "state_transition", Binding: "account", Entity: "Account", Field: "state", ToValue: "closed"},
+			path:    "$.rules[0].trigger.field",
+			message: "did you mean 'status'?",
+		},
+		{
+			name:    "non-enum field",
+			trigger: ast.Trigger{Kind: "state_becomes", Binding: "account", Entity: "Account", Field: "name", Value: "closed"},
+			path:    "$.rules[0].trigger.field",
+			message: "is not an enum",
+		},
+		{
+			name:    "undeclared to_value",
+			trigger: ast.Trigger{Kind: "state_transition", Binding: "account", Entity: "Account", Field: "status", ToValue: "closd"},
+			path:    "$.rules[0].trigger.to_value",
+			message: "did you mean 'closed'?",
+		},
+		{
+			name:    "undeclared value",
+			trigger: ast.Trigger{Kind: "state_becomes", Binding: "payment", Entity: "Payment", Field: "state", Value: "paid"},
+			path:    "$.rules[0].trigger.value",
+			message: "is not a value of 'Payment.state'",
+		},
+		{
+			name:    "undeclared derived value",
+			trigger: ast.Trigger{Kind: "derived_condition", Binding: "account", Entity: "Account", Field: "is_dormnt"},
+			path:    "$.rules[0].trigger.field",
+			message: "did you mean 'is_dormant'?",
+		},
+		{
+			name:    "non-Boolean derived condition",
+			trigger: ast.Trigger{Kind: "derived_condition", Binding: "account", Entity: "Account", Field: "created_at"},
+			path:    "$.rules[0].trigger.field",
+			message: "is not Boolean",
+		},
+		{
+			name: "temporal condition reads undeclared field",
+			trigger: ast.Trigger{Kind: "temporal", Binding: "account", Entity: "Account",
+				Condition: comparisonExpr("<=", accountField("created"), tsLitExpr("now"))},
+			path:    "$.rules[0].trigger.condition",
+			message: "reads 'account.created'",
+		},
+		{
+			name: "temporal condition reads no Timestamp",
+			trigger: ast.Trigger{Kind: "temporal", Binding: "account", Entity: "Account",
+				Condition: comparisonExpr("==", accountField("name"), accountField("status"))},
+			path:    "$.rules[0].trigger.condition",
+			message: "reads no Timestamp field",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := triggerSpec(tt.trigger)
+			findings := findingsWithRule(CheckTriggers(spec, BuildSymbolTable(spec)), "RULE-41")
+			if len(findings) != 1 {
+				t.Fatalf("expected 1 RULE-41 finding, got %v", findings)
+			}
+			if findings[0].Location.Path != tt.path {
+				t.Errorf("path = %q, want %q", findings[0].Location.Path, tt.path)
+			}
+			if !strings.Contains(findings[0].Message, tt.message) {
+				t.Errorf("message %q does not contain %q", findings[0].Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestCheckTriggers_UseDeclaredEntity(t *testing.T) {
+	spec := triggerSpec(ast.Trigger{Kind: "state_transition", Binding: "invoice", Entity: "Invoice", Field: "status", ToValue: "paid"})
+	spec.UseDeclarations = []ast.UseDeclaration{{Coordinate: "./billing.allium", Alias: "Invoice"}}
+	if findings := CheckTriggers(spec, BuildSymbolTable(spec)); len(findings) != 0 {
+		t.Errorf("expected imported entity to be skipped, got %v", findings)
+	}
+}
+
+// emissionSpec returns a spec in which rule Lock emits AccountLocked with the
+// given arguments, nested in a conditional, and rule Notify consumes it with
+// the parameters account and reason (optional).
+func emissionSpec(args map[string]ast.Expression) *ast.Spec {
+	spec := triggerSpec(ast.Trigger{Kind: "external_stimulus", Name: "lock_account"})
+	spec.Rules[0].Ensures = []ast.EnsuresClause{{
+		Kind:      "conditional",
+		Condition: fieldAccess("account"),
+		Then:      []ast.EnsuresClause{{Kind: "trigger_emission", Name: "AccountLocked", Arguments: args}},
+	}}
+	spec.Rules = append(spec.Rules, ast.Rule{Name: "Notify", Trigger: ast.Trigger{
+		Kind: "chained", Name: "AccountLocked",
+		Parameters: []ast.TriggerParam{{Name: "account"}, {Name: "reason", Optional: true}},
+	}})
+	return spec
+}
+
+func TestCheckTriggers_RULE44(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		paths    []string
+		messages []string
+	}{
+		{name: "all parameters", args: []string{"account", "reason"}},
+		{name: "optional parameter omitted", args: []string{"account"}},
+		{
+			name:     "unknown argument",
+			args:     []string{"account", "reasn"},
+			paths:    []string{"$.rules[0].ensures[0].then[0].arguments.reasn"},
+			messages: []string{"has no parameter 'reasn' (did you mean 'reason'?)"},
+		},
+		{
+			name:     "missing required argument",
+			args:     []string{"acount"},
+			paths:    []string{"$.rules[0].ensures[0].then[0].arguments.acount", "$.rules[0].ensures[0].then[0]"},
+			messages: []string{"did you mean 'account'?", "missing argument 'account', required by rule 'Notify'"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := make(map[string]ast.Expression)
+			for _, a := range tt.args {
+				args[a] = *fieldAccess("account")
+			}
+			spec := emissionSpec(args)
+			findings := findingsWithRule(CheckTriggers(spec, BuildSymbolTable(spec)), "RULE-44")
+			if len(findings) != len(tt.paths) {
+				t.Fatalf("expected %d RULE-44 findings, got %v", len(tt.paths), findings)
+			}
+			for i, f := range findings {
+				if f.Location.Path != tt.paths[i] {
+					t.Errorf("path = %q, want %q", f.Location.Path, tt.paths[i])
+				}
+				if !strings.Contains(f.Message, tt.messages[i]) {
+					t.Errorf("message %q does not contain %q", f.Message, tt.messages[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCheckTriggers_RULE44_Unconsumed(t *testing.T) {
+	spec := emissionSpec(map[string]ast.Expression{"anything": *fieldAccess("account")})
+	spec.Rules = spec.Rules[:1]
+	if findings := findingsWithRule(CheckTriggers(spec, BuildSymbolTable(spec)), "RULE-44"); len(findings) != 0 {
+		t.Errorf("expected an unconsumed emission to be left to WARN-20, got %v", findings)
+	}
+}
@@ -9,21 +9,30 @@ import (
 
 // CheckUniqueness verifies that declarations which must be unique are not duplicated.
 //
-//   - RULE-06: Rules sharing a trigger name must have compatible parameters
+//   - RULE-06: Rules sharing a trigger name must have compatible parameters:
+//     the same names in the same order, each optional in all or none
 //   - RULE-23: Given binding names must be unique
 //   - RULE-26: Config parameter names must be unique
+//   - RULE-38: Unique constraints must name fields declared on their entity
+//   - RULE-52: Declarations of one kind, types of any kind, the members of an
+//     entity, external entity, variant or value type, and the values of an
+//     enumeration must not share a name
 func CheckUniqueness(spec *ast.Spec, st *SymbolTable) []report.Finding {
 	var findings []report.Finding
 
 	findings = checkTriggerCompatibility(findings, spec, st)
 	findings = checkGivenUniqueness(findings, spec)
 	findings = checkConfigUniqueness(findings, spec)
+	findings = checkUniqueConstraintFields(findings, spec)
+	findings = checkDeclarationUniqueness(findings, spec, st)
+	findings = checkMemberUniqueness(findings, spec)
 
 	return findings
 }
 
 // checkTriggerCompatibility checks RULE-06: rules sharing an external_stimulus or
-// chained trigger name must have the same parameter count and names.
+// chained trigger name must have the same parameter count and names, and
+// agree on which parameters are optional.
 func checkTriggerCompatibility(findings []report.Finding, spec *ast.Spec, st *SymbolTable) []report.Finding {
 	for triggerName, rules := range st.Triggers {
 		if len(rules) < 2 {
@@ -67,6 +76,22 @@ func checkTriggerCompatibility(findings []report.Finding, spec *ast.Spec, st *Sy
 							Path: fmt.Sprintf("$.rules[?(@.name=='%s')].trigger.parameters[%d]", other.Name, p),
 						},
 					))
+				} else if refParams[p].Optional != otherParams[p].Optional {
+					optional, required := ref.Name, other.Name
+					if otherParams[p].Optional {
+						optional, required = required, optional
+					}
+					findings = append(findings, report.NewError(
+						"RULE-06",
+						fmt.Sprintf(
+							"Rules sharing trigger '%s' disagree on parameter '%s': '%s' declares it optional but '%s' requires it",
+							triggerName, refParams[p].Name, optional, required,
+						),
+						report.Location{
+							File: spec.File,
+							Path: fmt.Sprintf("$.rules[?(@.name=='%s')].trigger.parameters[%d]", other.Name, p),
+						},
+					))
 				}
 			}
 		}
@@ -107,3 +132,201 @@ func checkConfigUniqueness(findings []report.Finding, spec *ast.Spec) []report.F
 	}
 	return findings
 }
+
+// checkUniqueConstraintFields checks RULE-38: every field named by an entity's
+// unique constraints is declared on that entity.
+func checkUniqueConstraintFields(findings []report.Finding, spec *ast.Spec) []report.Finding {
+	for i, e := range spec.Entities {
+		declared := make(map[string]bool, len(e.Fields))
+		for _, f := range e.Fields {
+			declared[f.Name] = true
+		}
+		for j, uc := range e.Unique {
+			for k, name := range uc.Fields {
+				if !declared[name] {
+					findings = append(findings, report.NewError(
+						"RULE-38",
+						fmt.Sprintf("Unique constraint on '%s' names undeclared field '%s'", e.Name, name),
+						report.Location{File: spec.File, Path: fmt.Sprintf("$.entities[%d].unique[%d].fields[%d]", i, j, k)},
+					))
+				}
+			}
+		}
+	}
+	return findings
+}
+
+// declarationKinds names the declaration in each spec section whose
+// duplicates RULE-52 reports. Given bindings, config parameters and type
+// aliases have rules of their own (RULE-23, RULE-26 and RULE-37).
+var declarationKinds = map[string]string{
+	"entities":          "entity",
+	"external_entities": "external entity",
+	"variants":          "variant",
+	"value_types":       "value type",
+	"enumerations":      "enumeration",
+	"use_declarations":  "use declaration",
+	"rules":             "rule",
+	"actors":            "actor",
+	"surfaces":          "surface",
+}
+
+// checkDeclarationUniqueness checks RULE-52 for declarations: no two of one
+// kind share a name, and no two types do whatever their kind, since a type
+// reference would not say which it means.
+func checkDeclarationUniqueness(findings []report.Finding, spec *ast.Spec, st *SymbolTable) []report.Finding {
+	for _, d := range st.Duplicates {
+		kind, ok := declarationKinds[d.Section]
+		if !ok {
+			continue
+		}
+		findings = append(findings, report.NewError(
+			"RULE-52",
+			fmt.Sprintf("Duplicate %s name '%s' (first at index %d)", kind, d.Name, d.First),
+			report.Location{File: spec.File, Path: d.Path() + ".name"},
+		))
+	}
+
+	type declaration struct{ section, name string }
+	var types []declaration
+	for _, e := range spec.Entities {
+		types = append(types, declaration{"entities", e.Name})
+	}
+	for _, e := range spec.ExternalEntities {
+		types = append(types, declaration{"external_entities", e.Name})
+	}
+	for _, v := range spec.Variants {
+		types = append(types, declaration{"variants", v.Name})
+	}
+	for _, v := range spec.ValueTypes {
+		types = append(types, declaration{"value_types", v.Name})
+	}
+	for _, e := range spec.Enumerations {
+		types = append(types, declaration{"enumerations", e.Name})
+	}
+	for _, u := range spec.UseDeclarations {
+		types = append(types, declaration{"use_declarations", u.Alias})
+	}
+
+	type claim struct {
+		section string
+		index   int
+	}
+	claimed := make(map[string]claim, len(types))
+	index := make(map[string]int)
+	for _, t := range types {
+		i := index[t.section]
+		index[t.section]++
+		first, ok := claimed[t.name]
+		if !ok {
+			claimed[t.name] = claim{t.section, i}
+			continue
+		}
+		if first.section == t.section {
+			continue // reported above
+		}
+		field := ".name"
+		if t.section == "use_declarations" {
+			field = ".alias"
+		}
+		findings = append(findings, report.NewError(
+			"RULE-52",
+			fmt.Sprintf("Duplicate type name '%s' (first declared as the %s at $.%s[%d])",
+				t.name, declarationKinds[first.section], first.section, first.index),
+			report.Location{File: spec.File, Path: fmt.Sprintf("$.%s[%d]%s", t.section, i, field)},
+		))
+	}
+	return findings
+}
+
+// checkMemberUniqueness checks RULE-52 for members: the fields,
+// relationships, projections and derived values of an entity are all read
+// by name, so no two of them may share one; nor may two values of an
+// enumeration or inline enum.
+func checkMemberUniqueness(findings []report.Finding, spec *ast.Spec) []report.Finding {
+	type member struct{ name, path string }
+	check := func(owner string, members []member) {
+		first := make(map[string]string, len(members))
+		for _, m := range members {
+			if prev, ok := first[m.name]; ok {
+				findings = append(findings, report.NewError(
+					"RULE-52",
+					fmt.Sprintf("%s declares '%s' more than once (first at %s)", owner, m.name, prev),
+					report.Location{File: spec.File, Path: m.path},
+				))
+				continue
+			}
+			first[m.name] = m.path
+		}
+	}
+	fields := func(path string, fs []ast.Field) []member {
+		var members []member
+		for j, f := range fs {
+			members = append(members, member{f.Name, fmt.Sprintf("%s.fields[%d].name", path, j)})
+			findings = checkInlineEnumValues(findings, spec, f, fmt.Sprintf("%s.fields[%d].type", path, j))
+		}
+		return members
+	}
+
+	for i, e := range spec.Entities {
+		path := fmt.Sprintf("$.entities[%d]", i)
+		members := fields(path, e.Fields)
+		for j, r := range e.Relationships {
+			members = append(members, member{r.Name, fmt.Sprintf("%s.relationships[%d].name", path, j)})
+		}
+		for j, p := range e.Projections {
+			members = append(members, member{p.Name, fmt.Sprintf("%s.projections[%d].name", path, j)})
+		}
+		for j, dv := range e.DerivedValues {
+			members = append(members, member{dv.Name, fmt.Sprintf("%s.derived_values[%d].name", path, j)})
+		}
+		check(fmt.Sprintf("Entity '%s'", e.Name), members)
+	}
+	for i, e := range spec.ExternalEntities {
+		check(fmt.Sprintf("External entity '%s'", e.Name), fields(fmt.Sprintf("$.external_entities[%d]", i), e.Fields))
+	}
+	for i, v := range spec.Variants {
+		check(fmt.Sprintf("Variant '%s'", v.Name), fields(fmt.Sprintf("$.variants[%d]", i), v.Fields))
+	}
+	for i, v := range spec.ValueTypes {
+		path := fmt.Sprintf("$.value_types[%d]", i)
+		members := fields(path, v.Fields)
+		for j, dv := range v.DerivedValues {
+			members = append(members, member{dv.Name, fmt.Sprintf("%s.derived_values[%d].name", path, j)})
+		}
+		check(fmt.Sprintf("Value type '%s'", v.Name), members)
+	}
+	for i, e := range spec.Enumerations {
+		var values []member
+		for j, v := range e.Values {
+			values = append(values, member{v, fmt.Sprintf("$.enumerations[%d].values[%d]", i, j)})
+		}
+		check(fmt.Sprintf("Enumeration '%s'", e.Name), values)
+	}
+	return findings
+}
+
+// checkInlineEnumValues checks RULE-52 for the inline enum, if any, of field
+// f, whose type is at path.
+func checkInlineEnumValues(findings []report.Finding, spec *ast.Spec, f ast.Field, path string) []report.Finding {
+	ft := f.Type
+	if ft.Kind == "optional" && ft.Inner != nil {
+		ft, path = *ft.Inner, path+".inner"
+	}
+	if ft.Kind != "inline_enum" {
+		return findings
+	}
+	seen := make(map[string]int, len(ft.Values))
+	for k, v := range ft.Values {
+		if prev, ok := seen[v]; ok {
+			findings = append(findings, report.NewError(
+				"RULE-52",
+				fmt.Sprintf("Field '%s' lists enum value '%s' more than once (first at index %d)", f.Name, v, prev),
+				report.Location{File: spec.File, Path: fmt.Sprintf("%s.values[%d]", path, k)},
+			))
+			continue
+		}
+		seen[v] = k
+	}
+	return findings
+}
@@ -1,6 +1,7 @@
 package semantic
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/foundry-zero/allium/internal/ast"
@@ -66,6 +67,29 @@ func TestCheckUniqueness_RULE06_DifferentParamNames(t *testing.T) {
 	}
 }
 
+func TestCheckUniqueness_RULE06_DifferentOptionality(t *testing.T) {
+	spec := &ast.Spec{
+		File: "test.allium.json",
+		Rules: []ast.Rule{
+			{Name: "R1", Trigger: ast.Trigger{Kind: "external_stimulus", Name: "trigger_o", Parameters: []ast.TriggerParam{{Name: "user"}, {Name: "note"}}}},
+			{Name: "R2", Trigger: ast.Trigger{Kind: "external_stimulus", Name: "trigger_o", Parameters: []ast.TriggerParam{{Name: "user"}, {Name: "note", Optional: true}}}},
+		},
+	}
+	st := BuildSymbolTable(spec)
+	findings := CheckUniqueness(spec, st)
+
+	r06 := findingsWithRule(findings, "RULE-06")
+	if len(r06) != 1 {
+		t.Fatalf("expected 1 RULE-06 for mismatched optionality, got %v", r06)
+	}
+	if want := "Rules sharing trigger 'trigger_o' disagree on parameter 'note': 'R2' declares it optional but 'R1' requires it"; r06[0].Message != want {
+		t.Errorf("message = %q, want %q", r06[0].Message, want)
+	}
+	if want := "$.rules[?(@.name=='R2')].trigger.parameters[1]"; r06[0].Location.Path != want {
+		t.Errorf("path = %q, want %q", r06[0].Location.Path, want)
+	}
+}
+
 func TestCheckUniqueness_RULE06_CompatibleParams(t *testing.T) {
 	spec := &ast.Spec{
 		File: "test.allium.json",
@@ -219,3 +243,132 @@ func TestCheckUniqueness_MultipleViolations(t *testing.T) {
 		t.Error("missing RULE-26")
 	}
 }
+
+func TestCheckUniqueness_RULE38(t *testing.T) {
+	spec := &ast.Spec{
+		File: "test.allium.json",
+		Entities: []ast.Entity{
+			{
+				Name: "User",
+				Fields: []ast.Field{
+					{Name: "email", Type: ast.FieldType{Kind: "primitive", Value: "String"}},
+					{Name: "tenant", Type: ast.FieldType{Kind: "primitive", Value: "String"}},
+				},
+				Unique: []ast.UniqueConstraint{
+					{Fields: []string{"email"}},
+					{Fields: []string{"tenant", "handle"}},
+				},
+			},
+		},
+	}
+	st := BuildSymbolTable(spec)
+	findings := findingsWithRule(CheckUniqueness(spec, st), "RULE-38")
+
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 RULE-38 finding, got %d: %v", len(findings), findings)
+	}
+	if findings[0].Location.Path != "$.entities[0].unique[1].fields[1]" {
+		t.Errorf("path = %q", findings[0].Location.Path)
+	}
+}
+
+func TestCheckUniqueness_RULE52(t *testing.T) {
+	str := ast.FieldType{Kind: "primitive", Value: "String"}
+	tests := []struct {
+		name    string
+		spec    *ast.Spec
+		path    string
+		message string
+	}{
+		{
+			name:    "two entities",
+			spec:    &ast.Spec{Entities: []ast.Entity{{Name: "User"}, {Name: "User"}}},
+			path:    "$.entities[1].name",
+			message: "Duplicate entity name 'User' (first at index 0)",
+		},
+		{
+			name:    "two rules",
+			spec:    &ast.Spec{Rules: []ast.Rule{{Name: "Close"}, {Name: "Open"}, {Name: "Close"}}},
+			path:    "$.rules[2].name",
+			message: "Duplicate rule name 'Close' (first at index 0)",
+		},
+		{
+			name:    "two surfaces",
+			spec:    &ast.Spec{Surfaces: []ast.Surface{{Name: "Dashboard"}, {Name: "Dashboard"}}},
+			path:    "$.surfaces[1].name",
+			message: "Duplicate surface name 'Dashboard'",
+		},
+		{
+			name:    "entity and value type",
+			spec:    &ast.Spec{Entities: []ast.Entity{{Name: "Money"}}, ValueTypes: []ast.ValueType{{Name: "Money"}}},
+			path:    "$.value_types[0].name",
+			message: "Duplicate type name 'Money' (first declared as the entity at $.entities[0])",
+		},
+		{
+			name:    "use declaration alias and enumeration",
+			spec:    &ast.Spec{Enumerations: []ast.Enumeration{{Name: "Currency", Values: []string{"eur"}}}, UseDeclarations: []ast.UseDeclaration{{Coordinate: "money/v1", Alias: "Currency"}}},
+			path:    "$.use_declarations[0].alias",
+			message: "first declared as the enumeration at $.enumerations[0]",
+		},
+		{
+			name:    "enumeration value",
+			spec:    &ast.Spec{Enumerations: []ast.Enumeration{{Name: "Currency", Values: []string{"eur", "usd", "eur"}}}},
+			path:    "$.enumerations[0].values[2]",
+			message: "Enumeration 'Currency' declares 'eur' more than once (first at $.enumerations[0].values[0])",
+		},
+		{
+			name: "inline enum value",
+			spec: &ast.Spec{Entities: []ast.Entity{{Name: "Order", Fields: []ast.Field{
+				{Name: "status", Type: ast.FieldType{Kind: "optional", Inner: &ast.FieldType{Kind: "inline_enum", Values: []string{"open", "open"}}}},
+			}}}},
+			path:    "$.entities[0].fields[0].type.inner.values[1]",
+			message: "Field 'status' lists enum value 'open' more than once (first at index 0)",
+		},
+		{
+			name:    "entity fields",
+			spec:    &ast.Spec{Entities: []ast.Entity{{Name: "User", Fields: []ast.Field{{Name: "email", Type: str}, {Name: "email", Type: str}}}}},
+			path:    "$.entities[0].fields[1].name",
+			message: "Entity 'User' declares 'email' more than once (first at $.entities[0].fields[0].name)",
+		},
+		{
+			name: "entity field and derived value",
+			spec: &ast.Spec{Entities: []ast.Entity{{Name: "User", Fields: []ast.Field{{Name: "active", Type: str}},
+				DerivedValues: []ast.DerivedValue{{Name: "active"}}}}},
+			path:    "$.entities[0].derived_values[0].name",
+			message: "Entity 'User' declares 'active' more than once",
+		},
+		{
+			name:    "variant fields",
+			spec:    &ast.Spec{Variants: []ast.Variant{{Name: "Card", Fields: []ast.Field{{Name: "number", Type: str}, {Name: "number", Type: str}}}}},
+			path:    "$.variants[0].fields[1].name",
+			message: "Variant 'Card' declares 'number' more than once",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := findingsWithRule(CheckUniqueness(tt.spec, BuildSymbolTable(tt.spec)), "RULE-52")
+			if len(findings) != 1 {
+				t.Fatalf("expected 1 RULE-52, got %v", findings)
+			}
+			if findings[0].Location.Path != tt.path {
+				t.Errorf("path = %q, want %q", findings[0].Location.Path, tt.path)
+			}
+			if !strings.Contains(findings[0].Message, tt.message) {
+				t.Errorf("message %q does not contain %q", findings[0].Message, tt.message)
+			}
+		})
+	}
+}
+
+func TestCheckUniqueness_RULE52_LeavesOtherRules(t *testing.T) {
+	spec := &ast.Spec{
+		Entities:    []ast.Entity{{Name: "Money"}},
+		Given:       []ast.GivenBinding{{Name: "a"}, {Name: "a"}},
+		Config:      []ast.ConfigParam{{Name: "x"}, {Name: "x"}},
+		TypeAliases: []ast.TypeAlias{{Name: "Money"}, {Name: "Money"}},
+	}
+	if findings := findingsWithRule(CheckUniqueness(spec, BuildSymbolTable(spec)), "RULE-52"); len(findings) != 0 {
+		t.Errorf("expected given, config and type alias duplicates left to RULE-23, 26 and 37, got %v", findings)
+	}
+}
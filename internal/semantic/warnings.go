@@ -1,7 +1,9 @@
 package semantic
 
 import (
+	"encoding/json"
 	"fmt"
+	"maps"
 	"slices"
 	"sort"
 	"strings"
@@ -10,8 +12,11 @@ import (
 	"github.com/foundry-zero/allium/internal/report"
 )
 
-// CheckWarnings detects all 19 warning conditions (WARN-01 through WARN-19).
-// All findings have Severity=SeverityWarning.
+// CheckWarnings detects all warning conditions (WARN-01 through WARN-20 and
+// WARN-22 through WARN-32; WARN-21 is raised by the checker when applying
+// suppressions).
+// All findings have Severity=SeverityWarning, except WARN-02, which is
+// informational.
 func CheckWarnings(spec *ast.Spec, st *SymbolTable) []report.Finding {
 	var findings []report.Finding
 
@@ -19,21 +24,33 @@ func CheckWarnings(spec *ast.Spec, st *SymbolTable) []report.Finding {
 	findings = checkWarn02OpenQuestions(findings, spec)
 	findings = checkWarn03DeferredNoHint(findings, spec)
 	findings = checkWarn04UnusedEntity(findings, spec, st)
-	findings = checkWarn05NeverFires(findings, spec)
+	findings = checkWarn05NeverFires(findings, spec, st)
 	findings = checkWarn06TemporalNoGuard(findings, spec)
 	findings = checkWarn07UnusedExposed(findings, spec, st)
-	findings = checkWarn08ImpossibleProvides(findings, spec)
+	findings = checkWarn08ImpossibleProvides(findings, spec, st)
 	findings = checkWarn09UnusedActor(findings, spec)
-	findings = checkWarn10SiblingCreation(findings, spec)
+	findings = checkWarn10UnguardedCreation(findings, spec, st)
 	findings = checkWarn11WeakProvides(findings, spec)
 	findings = checkWarn12OverlappingRequires(findings, spec, st)
-	findings = checkWarn13DerivedScope(findings, spec)
+	findings = checkWarn13DerivedScope(findings, spec, st)
 	findings = checkWarn14TrivialActor(findings, spec)
 	findings = checkWarn15EmptyConditionalPath(findings, spec)
 	findings = checkWarn16OptionalTemporal(findings, spec, st)
 	findings = checkWarn17RawWithActors(findings, spec, st)
 	findings = checkWarn18TransitionsOnCreation(findings, spec, st)
-	findings = checkWarn19DuplicateInlineEnums(findings, spec)
+	findings = checkWarn19DuplicateInlineEnums(findings, spec, st)
+	findings = checkWarn20UnconsumedEmission(findings, spec)
+	findings = checkWarn22OrderDependentEffects(findings, spec)
+	findings = checkWarn23ParameterShadowsGlobal(findings, spec)
+	findings = checkWarn24RequiredReferenceCycle(findings, spec)
+	findings = checkWarn25UnsuppliedTriggerEntity(findings, spec)
+	findings = checkWarn26NonExhaustiveEnumConditional(findings, spec, st)
+	findings = checkWarn27InconsistentRelationshipPair(findings, spec, st)
+	findings = checkWarn28NamingConventions(findings, spec, st)
+	findings = checkWarn29ShadowedBinding(findings, spec)
+	findings = checkWarn30UnreachableBranch(findings, spec, st)
+	findings = checkWarn31UnusedGlobal(findings, spec)
+	findings = checkWarn32LossyNarrowing(findings, spec, st)
 
 	return findings
 }
@@ -55,10 +72,11 @@ func checkWarn01ExternalNoSpec(findings []report.Finding, spec *ast.Spec, _ *Sym
 	return findings
 }
 
-// WARN-02: Open questions present.
+// WARN-02: Open questions present. Recording open questions is good practice,
+// so this is reported as info rather than a warning.
 func checkWarn02OpenQuestions(findings []report.Finding, spec *ast.Spec) []report.Finding {
 	if len(spec.OpenQuestions) > 0 {
-		findings = append(findings, report.NewWarning(
+		findings = append(findings, report.NewInfo(
 			"WARN-02",
 			fmt.Sprintf("Open questions present: %d unresolved", len(spec.OpenQuestions)),
 			report.Location{File: spec.File, Path: "$.open_questions"},
@@ -130,6 +148,9 @@ func collectReferencedEntities(spec *ast.Spec) map[string]bool {
 	for _, g := range spec.Given {
 		collectFieldTypeEntityRefs(g.Type, refs)
 	}
+	for _, a := range spec.TypeAliases {
+		collectFieldTypeEntityRefs(a.Type, refs)
+	}
 
 	// References from rules
 	for _, r := range spec.Rules {
@@ -197,13 +218,16 @@ func collectEnsuresEntityRefs(ec ast.EnsuresClause, refs map[string]bool) {
 	}
 }
 
-// WARN-05: Rule with contradictory requires (heuristic: two equality checks on same field with different values).
-func checkWarn05NeverFires(findings []report.Finding, spec *ast.Spec) []report.Finding {
+// WARN-05: Rule whose requires can never all hold. The requires, and the for
+// clause condition, are evaluated together as a conjunction (see
+// conditionContradiction), with enum-typed fields resolved through the rule's
+// bindings.
+func checkWarn05NeverFires(findings []report.Finding, spec *ast.Spec, st *SymbolTable) []report.Finding {
 	for i, rule := range spec.Rules {
-		if hasContradictoryRequires(rule.Requires) {
+		if _, reason := ruleFacts(rule, spec, st); reason != "" {
 			findings = append(findings, report.NewWarning(
 				"WARN-05",
-				fmt.Sprintf("Rule '%s' can never fire (contradictory requires)", rule.Name),
+				fmt.Sprintf("Rule '%s' can never fire (contradictory requires): %s", rule.Name, reason),
 				report.Location{File: spec.File, Path: fmt.Sprintf("$.rules[%d].requires", i)},
 			))
 		}
@@ -211,74 +235,216 @@ func checkWarn05NeverFires(findings []report.Finding, spec *ast.Spec) []report.F
 	return findings
 }
 
-func hasContradictoryRequires(requires []ast.Expression) bool {
-	// Simple heuristic: collect equality constraints on the same field
-	type constraint struct {
-		field string
-		value string
+// ruleFacts returns the constraints that hold whenever a rule fires: those of
+// its requires and for clause condition. It also returns the first
+// contradiction among them, or "".
+func ruleFacts(rule ast.Rule, spec *ast.Spec, st *SymbolTable) (*condFacts, string) {
+	facts := newCondFacts(ruleEnumLookup(st, ruleFieldTypes(rule, spec, st)))
+	for j := range rule.Requires {
+		if reason := facts.falsify(&rule.Requires[j]); reason != "" {
+			return facts, reason
+		}
+	}
+	if fc := rule.ForClause; fc != nil {
+		return facts, facts.falsify(fc.Condition)
 	}
-	var constraints []constraint
+	return facts, ""
+}
 
-	for _, req := range requires {
-		if req.Kind == "comparison" && req.Operator == "=" {
-			field := extractSimpleFieldName(req.Left)
-			value := extractSimpleFieldName(req.Right)
-			if field != "" && value != "" {
-				constraints = append(constraints, constraint{field, value})
+// WARN-06: Temporal trigger without re-firing guard.
+func checkWarn06TemporalNoGuard(findings []report.Finding, spec *ast.Spec) []report.Finding {
+	for i, rule := range spec.Rules {
+		if rule.Trigger.Kind == "temporal" && len(rule.Requires) == 0 {
+			findings = append(findings, report.NewWarning(
+				"WARN-06",
+				fmt.Sprintf("Temporal rule '%s' has no re-firing guard", rule.Name),
+				report.Location{File: spec.File, Path: fmt.Sprintf("$.rules[%d]", i)},
+			))
+		}
+	}
+	return findings
+}
+
+// WARN-07: Surface exposes an entity field that no rule reads or writes.
+// Only direct binding.field exposures are checked, and only when the binding's
+// type resolves to an entity declaring that field. Field use is tracked by
+// name, so a field read anywhere under the same name counts as used.
+func checkWarn07UnusedExposed(findings []report.Finding, spec *ast.Spec, st *SymbolTable) []report.Finding {
+	if len(spec.Surfaces) == 0 {
+		return findings
+	}
+	used := collectRuleFieldUses(spec)
+
+	for i, s := range spec.Surfaces {
+		bindingTypes := collectSurfaceBindingTypes(s)
+		for j, exp := range s.Exposes {
+			e := exp.Expression
+			if e == nil || e.Kind != "field_access" || e.Object == nil ||
+				e.Object.Kind != "field_access" || e.Object.Object != nil {
+				continue
 			}
+			entity := surfaceBindingEntity(st, bindingTypes[e.Object.Field])
+			if entity == nil || used[e.Field] || !slices.ContainsFunc(entity.Fields, func(f ast.Field) bool { return f.Name == e.Field }) {
+				continue
+			}
+			findings = append(findings, report.NewWarning(
+				"WARN-07",
+				fmt.Sprintf("Surface '%s' exposes '%s.%s', which no rule reads or writes", s.Name, entity.Name, e.Field),
+				report.Location{File: spec.File, Path: ast.IndexPath(fmt.Sprintf("$.surfaces[%d]", i), "exposes", j)},
+			))
 		}
 	}
+	return findings
+}
+
+// surfaceBindingEntity resolves a surface binding type to an entity, looking
+// through actors to the entity that identifies them.
+func surfaceBindingEntity(st *SymbolTable, typeName string) *ast.Entity {
+	if e := st.LookupEntity(typeName); e != nil {
+		return e
+	}
+	if a := st.LookupActor(typeName); a != nil {
+		return st.LookupEntity(a.IdentifiedBy.Entity)
+	}
+	return nil
+}
 
-	// Check for same field with different values
-	for a := 0; a < len(constraints); a++ {
-		for b := a + 1; b < len(constraints); b++ {
-			if constraints[a].field == constraints[b].field && constraints[a].value != constraints[b].value {
-				return true
+// collectRuleFieldUses returns the names of fields read or written by rules
+// (triggers, for clauses, let bindings, requires and ensures) and by derived
+// values.
+func collectRuleFieldUses(spec *ast.Spec) map[string]bool {
+	used := make(map[string]bool)
+	collect := func(expr *ast.Expression) {
+		walkExpression(expr, func(e *ast.Expression) {
+			if e.Kind == "field_access" {
+				used[e.Field] = true
 			}
+		})
+	}
+
+	for _, r := range spec.Rules {
+		if r.Trigger.Field != "" {
+			used[r.Trigger.Field] = true
+		}
+		collect(r.Trigger.Condition)
+		if r.ForClause != nil {
+			collect(r.ForClause.Collection)
+			collect(r.ForClause.Condition)
+		}
+		for _, lb := range r.LetBindings {
+			collect(lb.Expression)
+		}
+		for i := range r.Requires {
+			collect(&r.Requires[i])
+		}
+		for _, ec := range r.Ensures {
+			collectEnsuresFieldUses(ec, used, collect)
 		}
 	}
-	return false
+	for _, e := range spec.Entities {
+		for _, dv := range e.DerivedValues {
+			collect(dv.Expression)
+		}
+	}
+	for _, vt := range spec.ValueTypes {
+		for _, dv := range vt.DerivedValues {
+			collect(dv.Expression)
+		}
+	}
+	return used
 }
 
-func extractSimpleFieldName(expr *ast.Expression) string {
-	if expr == nil {
-		return ""
+func collectEnsuresFieldUses(ec ast.EnsuresClause, used map[string]bool, collect func(*ast.Expression)) {
+	collect(ec.Target)
+	collect(ec.Condition)
+	collect(ec.Collection)
+	if len(ec.Value) > 0 {
+		// A value is an expression, or for let_binding possibly an entity creation.
+		var valExpr ast.Expression
+		if err := json.Unmarshal(ec.Value, &valExpr); err == nil && valExpr.Kind != "" {
+			collect(&valExpr)
+		}
+		var valEnsures ast.EnsuresClause
+		if err := json.Unmarshal(ec.Value, &valEnsures); err == nil && valEnsures.Kind == "entity_creation" {
+			collectEnsuresFieldUses(valEnsures, used, collect)
+		}
 	}
-	if expr.Kind == "field_access" && expr.Object == nil {
-		return expr.Field
+	for name, expr := range ec.Fields {
+		used[name] = true
+		collect(&expr)
 	}
-	if expr.Kind == "literal" {
-		return extractLiteralValue(expr)
+	for _, expr := range ec.Arguments {
+		collect(&expr)
+	}
+	for _, sub := range ec.Then {
+		collectEnsuresFieldUses(sub, used, collect)
+	}
+	for _, sub := range ec.Else {
+		collectEnsuresFieldUses(sub, used, collect)
+	}
+	for _, sub := range ec.Body {
+		collectEnsuresFieldUses(sub, used, collect)
 	}
-	return ""
 }
 
-// WARN-06: Temporal trigger without re-firing guard.
-func checkWarn06TemporalNoGuard(findings []report.Finding, spec *ast.Spec) []report.Finding {
-	for i, rule := range spec.Rules {
-		if rule.Trigger.Kind == "temporal" && len(rule.Requires) == 0 {
-			findings = append(findings, report.NewWarning(
-				"WARN-06",
-				fmt.Sprintf("Temporal rule '%s' has no re-firing guard", rule.Name),
-				report.Location{File: spec.File, Path: fmt.Sprintf("$.rules[%d]", i)},
-			))
+// WARN-08: Provides action whose when condition can never hold. Conditions are
+// evaluated symbolically (see conditionContradiction), so only contradictions
+// between literals, equality constraints and enum values are detected.
+func checkWarn08ImpossibleProvides(findings []report.Finding, spec *ast.Spec, st *SymbolTable) []report.Finding {
+	for i, s := range spec.Surfaces {
+		enums := surfaceEnumLookup(st, collectSurfaceBindingTypes(s))
+		for j, p := range s.Provides {
+			findings = checkImpossibleProvides(findings, p, s.Name, enums,
+				ast.IndexPath(fmt.Sprintf("$.surfaces[%d]", i), "provides", j), spec.File)
 		}
 	}
 	return findings
 }
 
-// WARN-07: Surface exposes a field not used by any rule (stub — full analysis complex).
-func checkWarn07UnusedExposed(findings []report.Finding, _ *ast.Spec, _ *SymbolTable) []report.Finding {
-	// Full implementation would track which entity fields are read/written by rules
-	// and compare with surface exposes. This is complex and deferred.
+func checkImpossibleProvides(findings []report.Finding, p ast.ProvidesItem, surfaceName string, enums enumLookup, path, file string) []report.Finding {
+	if p.Kind == "for_each" {
+		for j, item := range p.Items {
+			findings = checkImpossibleProvides(findings, item, surfaceName, enums, ast.IndexPath(path, "items", j), file)
+		}
+		return findings
+	}
+	if reason := conditionContradiction(p.When, enums); reason != "" {
+		findings = append(findings, report.NewWarning(
+			"WARN-08",
+			fmt.Sprintf("Provides '%s' on surface '%s' can never be offered: %s", p.Trigger, surfaceName, reason),
+			report.Location{File: file, Path: path + ".when"},
+		))
+	}
 	return findings
 }
 
-// WARN-08: Provides with always-false when condition (heuristic).
-func checkWarn08ImpossibleProvides(findings []report.Finding, _ *ast.Spec) []report.Finding {
-	// Detecting always-false conditions requires symbolic evaluation.
-	// Deferred to future enhancement.
-	return findings
+// surfaceEnumLookup resolves binding.field paths in a surface to the enum the
+// field is declared as, through the entity the binding's type names.
+func surfaceEnumLookup(st *SymbolTable, bindingTypes map[string]string) enumLookup {
+	return func(e *ast.Expression) (string, []string) {
+		if e == nil || e.Kind != "field_access" || e.Object == nil ||
+			e.Object.Kind != "field_access" || e.Object.Object != nil {
+			return "", nil
+		}
+		entity := surfaceBindingEntity(st, bindingTypes[e.Object.Field])
+		if entity == nil {
+			return "", nil
+		}
+		for _, f := range st.ResolveFields(entity.Fields) {
+			if f.Name != e.Field {
+				continue
+			}
+			switch f.Type.Kind {
+			case "inline_enum":
+				return entity.Name + "." + f.Name, f.Type.Values
+			case "named_enum":
+				if en := st.LookupEnumeration(f.Type.Name); en != nil {
+					return en.Name, en.Values
+				}
+			}
+		}
+		return "", nil
+	}
 }
 
 // WARN-09: Actor not referenced in any surface facing clause.
@@ -300,9 +466,151 @@ func checkWarn09UnusedActor(findings []report.Finding, spec *ast.Spec) []report.
 	return findings
 }
 
-// WARN-10: Sibling rule creates entity without duplicate guard (stub).
-func checkWarn10SiblingCreation(findings []report.Finding, _ *ast.Spec) []report.Finding {
-	// Complex heuristic requiring cross-rule analysis. Deferred.
+// WARN-10: Rule creates an entity with unique constraints without first checking,
+// with exists, that no instance with the same unique field values is present.
+// A guard is an exists test anywhere in the rule's requires, for clause
+// condition or an enclosing conditional, on a lookup of the entity (directly or
+// through a let binding) that matches on every field of the constraint. The
+// polarity of the test is not checked.
+func checkWarn10UnguardedCreation(findings []report.Finding, spec *ast.Spec, st *SymbolTable) []report.Finding {
+	hasConstraints := false
+	for _, e := range spec.Entities {
+		if len(e.Unique) > 0 {
+			hasConstraints = true
+			break
+		}
+	}
+	if !hasConstraints {
+		return findings
+	}
+
+	for i, rule := range spec.Rules {
+		g := creationGuards{lets: make(map[string]*ast.Expression, len(rule.LetBindings))}
+		for _, lb := range rule.LetBindings {
+			g.lets[lb.Name] = lb.Expression
+		}
+		for j := range rule.Requires {
+			g.conditions = append(g.conditions, &rule.Requires[j])
+		}
+		if rule.ForClause != nil && rule.ForClause.Condition != nil {
+			g.conditions = append(g.conditions, rule.ForClause.Condition)
+		}
+		for j, ec := range rule.Ensures {
+			findings = checkUnguardedCreation(findings, ec, g, st, rule.Name,
+				ast.IndexPath(fmt.Sprintf("$.rules[%d]", i), "ensures", j), spec.File)
+		}
+	}
+	return findings
+}
+
+// creationGuards holds the conditions in scope at an ensures clause and the
+// let bindings an exists test may refer to.
+type creationGuards struct {
+	conditions []*ast.Expression
+	lets       map[string]*ast.Expression
+}
+
+// with returns a copy of g with an additional condition in scope.
+func (g creationGuards) with(cond *ast.Expression) creationGuards {
+	g.conditions = append(slices.Clip(g.conditions), cond)
+	return g
+}
+
+// withLet returns a copy of g with an additional let binding in scope.
+func (g creationGuards) withLet(name string, expr *ast.Expression) creationGuards {
+	lets := make(map[string]*ast.Expression, len(g.lets)+1)
+	for k, v := range g.lets {
+		lets[k] = v
+	}
+	lets[name] = expr
+	g.lets = lets
+	return g
+}
+
+// covers reports whether some exists test in scope looks up entityName by all
+// of fields.
+func (g creationGuards) covers(entityName string, fields []string) bool {
+	found := false
+	for _, cond := range g.conditions {
+		walkExpression(cond, func(e *ast.Expression) {
+			if found || e.Kind != "exists" {
+				return
+			}
+			lookup := e.Target
+			if lookup != nil && lookup.Kind == "field_access" && lookup.Object == nil {
+				lookup = g.lets[lookup.Field]
+			}
+			if lookup == nil || lookup.Kind != "join_lookup" || lookup.Entity != entityName {
+				return
+			}
+			for _, f := range fields {
+				if _, ok := lookup.Fields[f]; !ok {
+					return
+				}
+			}
+			found = true
+		})
+	}
+	return found
+}
+
+func checkUnguardedCreation(findings []report.Finding, ec ast.EnsuresClause, g creationGuards, st *SymbolTable, ruleName, path, file string) []report.Finding {
+	switch ec.Kind {
+	case "entity_creation":
+		entity := st.LookupEntity(ec.Entity)
+		if entity == nil {
+			if v := st.LookupVariant(ec.Entity); v != nil {
+				entity = st.LookupEntity(v.BaseEntity)
+			}
+		}
+		if entity == nil {
+			return findings
+		}
+		for _, uc := range entity.Unique {
+			if g.covers(entity.Name, uc.Fields) || g.covers(ec.Entity, uc.Fields) {
+				continue
+			}
+			findings = append(findings, report.NewWarning(
+				"WARN-10",
+				fmt.Sprintf("Rule '%s' creates '%s' without checking that no '%s' with the same %s exists",
+					ruleName, ec.Entity, entity.Name, strings.Join(uc.Fields, ", ")),
+				report.Location{File: file, Path: path},
+			))
+		}
+
+	case "conditional":
+		inner := g
+		if ec.Condition != nil {
+			inner = g.with(ec.Condition)
+		}
+		for i, sub := range ec.Then {
+			findings = checkUnguardedCreation(findings, sub, inner, st, ruleName, ast.IndexPath(path, "then", i), file)
+		}
+		for i, sub := range ec.Else {
+			findings = checkUnguardedCreation(findings, sub, inner, st, ruleName, ast.IndexPath(path, "else", i), file)
+		}
+
+	case "iteration":
+		for i, sub := range ec.Body {
+			findings = checkUnguardedCreation(findings, sub, g, st, ruleName, ast.IndexPath(path, "body", i), file)
+		}
+
+	case "let_binding":
+		inner := g
+		if len(ec.Value) > 0 {
+			var valExpr ast.Expression
+			if err := json.Unmarshal(ec.Value, &valExpr); err == nil && valExpr.Kind == "join_lookup" {
+				inner = g.withLet(ec.Name, &valExpr)
+			}
+			var valEnsures ast.EnsuresClause
+			if err := json.Unmarshal(ec.Value, &valEnsures); err == nil && valEnsures.Kind == "entity_creation" {
+				findings = checkUnguardedCreation(findings, valEnsures, g, st, ruleName, path+".value", file)
+			}
+		}
+		for i, sub := range ec.Body {
+			findings = checkUnguardedCreation(findings, sub, inner, st, ruleName, ast.IndexPath(path, "body", i), file)
+		}
+	}
 	return findings
 }
 
@@ -336,12 +644,132 @@ func checkWarn12OverlappingRequires(findings []report.Finding, _ *ast.Spec, st *
 	return findings
 }
 
-// WARN-13: Derived value referencing out-of-entity fields (stub).
-func checkWarn13DerivedScope(findings []report.Finding, _ *ast.Spec) []report.Finding {
-	// Would require tracking which fields belong to which entity. Deferred.
+// WARN-13: Derived value references a field that is neither declared on its
+// own entity (or value type) nor reachable through the entity's relationships.
+// A root name must be a field, relationship, projection or derived value of
+// the owner, a derived value parameter, a lambda parameter, or config or a
+// given binding. Paths through relationships are followed to the target
+// entity; paths into fields of other types are not checked.
+func checkWarn13DerivedScope(findings []report.Finding, spec *ast.Spec, st *SymbolTable) []report.Finding {
+	for i, e := range spec.Entities {
+		for j, dv := range e.DerivedValues {
+			findings = checkDerivedScope(findings, st, e.Name, entityMembers(&e), dv,
+				fmt.Sprintf("$.entities[%d].derived_values[%d].expression", i, j), spec.File)
+		}
+	}
+	for i, vt := range spec.ValueTypes {
+		members := make(map[string]*ast.Relationship)
+		for _, f := range vt.Fields {
+			members[f.Name] = nil
+		}
+		for _, dv := range vt.DerivedValues {
+			members[dv.Name] = nil
+		}
+		for j, dv := range vt.DerivedValues {
+			findings = checkDerivedScope(findings, st, vt.Name, members, dv,
+				fmt.Sprintf("$.value_types[%d].derived_values[%d].expression", i, j), spec.File)
+		}
+	}
 	return findings
 }
 
+// entityMembers maps the names an entity declares (fields, relationships,
+// projections and derived values) to the relationship they traverse, or nil.
+func entityMembers(e *ast.Entity) map[string]*ast.Relationship {
+	members := make(map[string]*ast.Relationship)
+	for _, f := range e.Fields {
+		members[f.Name] = nil
+	}
+	for _, p := range e.Projections {
+		members[p.Name] = nil
+	}
+	for _, dv := range e.DerivedValues {
+		members[dv.Name] = nil
+	}
+	for k := range e.Relationships {
+		members[e.Relationships[k].Name] = &e.Relationships[k]
+	}
+	return members
+}
+
+func checkDerivedScope(findings []report.Finding, st *SymbolTable, owner string, members map[string]*ast.Relationship, dv ast.DerivedValue, path, file string) []report.Finding {
+	locals := make(map[string]bool)
+	for _, p := range dv.Parameters {
+		locals[p] = true
+	}
+	reported := make(map[string]bool)
+	inner := make(map[*ast.Expression]bool)
+	walkScopedExpression(dv.Expression, locals, func(e *ast.Expression, locals map[string]bool) {
+		if inner[e] || exprPath(e) == "" {
+			return
+		}
+		for o := e.Object; o != nil; o = o.Object {
+			inner[o] = true
+		}
+		segs := strings.Split(exprPath(e), ".")
+		root := segs[0]
+		if locals[root] || root == "config" || st.LookupGiven(root) != nil {
+			return
+		}
+		rel, ok := members[root]
+		if !ok {
+			msg := fmt.Sprintf("Derived value '%s' on '%s' references '%s', which is not declared on '%s' or reachable through its relationships",
+				dv.Name, owner, root, owner)
+			if !reported[msg] {
+				reported[msg] = true
+				findings = append(findings, report.NewWarning("WARN-13", msg, report.Location{File: file, Path: path}))
+			}
+			return
+		}
+		for k := 1; rel != nil && k < len(segs); k++ {
+			target := st.LookupEntity(rel.TargetEntity)
+			if target == nil {
+				return // external or undeclared (RULE-03); fields not tracked
+			}
+			next, ok := entityMembers(target)[segs[k]]
+			if !ok {
+				msg := fmt.Sprintf("Derived value '%s' on '%s' references '%s', but '%s' has no field '%s'",
+					dv.Name, owner, strings.Join(segs[:k+1], "."), target.Name, segs[k])
+				if !reported[msg] {
+					reported[msg] = true
+					findings = append(findings, report.NewWarning("WARN-13", msg, report.Location{File: file, Path: path}))
+				}
+				return
+			}
+			rel = next
+		}
+	})
+	return findings
+}
+
+// walkScopedExpression calls fn for every node of expr, passing the names
+// bound by enclosing lambdas in addition to locals. Like ast.Walk it keeps an
+// explicit stack, each entry holding the names in scope at its node.
+func walkScopedExpression(expr *ast.Expression, locals map[string]bool, fn func(*ast.Expression, map[string]bool)) {
+	type frame struct {
+		expr   *ast.Expression
+		locals map[string]bool
+	}
+	if expr == nil {
+		return
+	}
+	stack := []frame{{expr, locals}}
+	for len(stack) > 0 {
+		f := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		fn(f.expr, f.locals)
+		inner := f.locals
+		if f.expr.Kind == "lambda" && f.expr.Parameter != "" {
+			inner = copyScope(inner)
+			inner[f.expr.Parameter] = true
+		}
+		children := f.expr.Children()
+		for i := len(children) - 1; i >= 0; i-- {
+			stack = append(stack, frame{children[i], inner})
+		}
+	}
+}
+
 // WARN-14: Trivial actor identified_by condition (always true/false).
 func checkWarn14TrivialActor(findings []report.Finding, spec *ast.Spec) []report.Finding {
 	for i, a := range spec.Actors {
@@ -413,7 +841,7 @@ func checkWarn16OptionalTemporal(findings []report.Finding, spec *ast.Spec, st *
 
 		if ent := st.LookupEntity(entityName); ent != nil {
 			for fieldName := range fieldSet {
-				for _, f := range ent.Fields {
+				for _, f := range st.ResolveFields(ent.Fields) {
 					if f.Name == fieldName && f.Type.Kind == "optional" {
 						findings = append(findings, report.NewWarning(
 							"WARN-16",
@@ -448,26 +876,10 @@ func extractFieldNames(expr *ast.Expression, binding string) []string {
 
 // walkExpression calls fn for every node in the expression tree.
 func walkExpression(expr *ast.Expression, fn func(*ast.Expression)) {
-	if expr == nil {
-		return
-	}
-	fn(expr)
-	walkExpression(expr.Object, fn)
-	walkExpression(expr.Left, fn)
-	walkExpression(expr.Right, fn)
-	walkExpression(expr.Operand, fn)
-	walkExpression(expr.Target, fn)
-	walkExpression(expr.Condition, fn)
-	walkExpression(expr.Lambda, fn)
-	walkExpression(expr.Collection, fn)
-	walkExpression(expr.Element, fn)
-	walkExpression(expr.Body, fn)
-	for i := range expr.FuncArguments {
-		walkExpression(&expr.FuncArguments[i], fn)
-	}
-	for i := range expr.Elements {
-		walkExpression(&expr.Elements[i], fn)
-	}
+	ast.Walk(expr, func(e *ast.Expression) bool {
+		fn(e)
+		return true
+	})
 }
 
 // WARN-17: Surface using raw entity type in facing when actors exist for that entity.
@@ -538,39 +950,997 @@ func checkWarn18TransitionsOnCreation(findings []report.Finding, spec *ast.Spec,
 	return findings
 }
 
-// WARN-19: Multiple fields with identical inline enum literal sets.
-func checkWarn19DuplicateInlineEnums(findings []report.Finding, spec *ast.Spec) []report.Finding {
+// WARN-19: Multiple fields with identical inline enum literal sets. The first
+// warning for each set suggests extracting a named enumeration and using it
+// for every field with the set.
+func checkWarn19DuplicateInlineEnums(findings []report.Finding, spec *ast.Spec, st *SymbolTable) []report.Finding {
+	extracted := make(map[string]bool) // names suggested so far
 	for i, entity := range spec.Entities {
 		// Collect inline enum value sets
 		type enumInfo struct {
-			fieldName string
-			values    string // sorted, joined for comparison
+			field  int
+			values string // sorted, joined for comparison
 		}
 		var enums []enumInfo
 
-		for _, f := range entity.Fields {
+		for j, f := range entity.Fields {
 			if f.Type.Kind == "inline_enum" {
-				sorted := make([]string, len(f.Type.Values))
-				copy(sorted, f.Type.Values)
-				sort.Strings(sorted)
-				enums = append(enums, enumInfo{f.Name, strings.Join(sorted, "|")})
+				enums = append(enums, enumInfo{j, inlineEnumKey(f.Type.Values)})
 			}
 		}
 
 		// Check for duplicates
-		seen := make(map[string]string) // values -> first field name
+		seen := make(map[string]int) // values -> first field index
+		suggested := make(map[string]bool)
 		for _, e := range enums {
-			if first, ok := seen[e.values]; ok {
+			first, ok := seen[e.values]
+			if !ok {
+				seen[e.values] = e.field
+				continue
+			}
+			w := report.NewWarning(
+				"WARN-19",
+				fmt.Sprintf("Multiple identical inline enums on '%s' (fields '%s' and '%s') — consider a named enum",
+					entity.Name, entity.Fields[first].Name, entity.Fields[e.field].Name),
+				report.Location{File: spec.File, Path: fmt.Sprintf("$.entities[%d]", i)},
+			)
+			if !suggested[e.values] {
+				suggested[e.values] = true
+				w = suggestNamedEnum(w, spec, st, i, first, e.values, extracted)
+			}
+			findings = append(findings, w)
+		}
+	}
+	return findings
+}
+
+// suggestNamedEnum attaches to w the extraction of the inline enum of field
+// first on entity i into a named enumeration, used by every field of the
+// entity whose inline enum has the same sorted values.
+func suggestNamedEnum(w report.Finding, spec *ast.Spec, st *SymbolTable, i, first int, values string, extracted map[string]bool) report.Finding {
+	entity := spec.Entities[i]
+	base := entity.Name + pascalCase(entity.Fields[first].Name)
+	name := base
+	for n := 2; st.LookupType(name) || extracted[name]; n++ {
+		name = fmt.Sprintf("%s%d", base, n)
+	}
+	extracted[name] = true
+
+	edits := []report.Edit{report.AddEdit(fmt.Sprintf("$.enumerations[%d]", len(spec.Enumerations)),
+		ast.Enumeration{Name: name, Values: entity.Fields[first].Type.Values})}
+	for j, f := range entity.Fields {
+		if f.Type.Kind == "inline_enum" && inlineEnumKey(f.Type.Values) == values {
+			edits = append(edits, report.ReplaceEdit(fmt.Sprintf("$.entities[%d].fields[%d].type", i, j),
+				ast.FieldType{Kind: "named_enum", Name: name}))
+		}
+	}
+	return w.WithSuggestion(fmt.Sprintf("Extract enumeration '%s' and use it for these fields", name), edits...)
+}
+
+// inlineEnumKey identifies an inline enum by its sorted values.
+func inlineEnumKey(values []string) string {
+	sorted := slices.Clone(values)
+	sort.Strings(sorted)
+	return strings.Join(sorted, "|")
+}
+
+// pascalCase turns a snake_case name such as "payment_status" into
+// "PaymentStatus".
+func pascalCase(name string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		if part != "" {
+			b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	return b.String()
+}
+
+// WARN-20: Rule emits a chained trigger that no rule consumes.
+func checkWarn20UnconsumedEmission(findings []report.Finding, spec *ast.Spec) []report.Finding {
+	consumed := make(map[string]bool)
+	var chainedNames []string
+	for _, r := range spec.Rules {
+		if r.Trigger.Kind == "chained" && !consumed[r.Trigger.Name] {
+			consumed[r.Trigger.Name] = true
+			chainedNames = append(chainedNames, r.Trigger.Name)
+		}
+	}
+
+	for i, rule := range spec.Rules {
+		for j, ec := range rule.Ensures {
+			findings = walkEmissions(findings, ec, fmt.Sprintf("$.rules[%d].ensures[%d]", i, j),
+				func(findings []report.Finding, ec ast.EnsuresClause, path string) []report.Finding {
+					if consumed[ec.Name] {
+						return findings
+					}
+					return append(findings, report.NewWarning(
+						"WARN-20",
+						fmt.Sprintf("Rule '%s' emits trigger '%s' but no rule consumes it as a chained trigger%s",
+							rule.Name, ec.Name, didYouMean(ec.Name, chainedNames)),
+						report.Location{File: spec.File, Path: path + ".name"},
+					))
+				})
+		}
+	}
+	return findings
+}
+
+// walkEmissions calls fn for every trigger_emission clause in an ensures tree,
+// including those nested in conditional, iteration, and let_binding bodies.
+func walkEmissions(findings []report.Finding, ec ast.EnsuresClause, path string,
+	fn func([]report.Finding, ast.EnsuresClause, string) []report.Finding) []report.Finding {
+	if ec.Kind == "trigger_emission" {
+		findings = fn(findings, ec, path)
+	}
+	for j, then := range ec.Then {
+		findings = walkEmissions(findings, then, ast.IndexPath(path, "then", j), fn)
+	}
+	for j, el := range ec.Else {
+		findings = walkEmissions(findings, el, ast.IndexPath(path, "else", j), fn)
+	}
+	for j, body := range ec.Body {
+		findings = walkEmissions(findings, body, ast.IndexPath(path, "body", j), fn)
+	}
+	return findings
+}
+
+// WARN-22: Ensures clause depends on the effect of an earlier sibling clause.
+// Ensures are declarative, but implementations apply them in order, so reading
+// a field another clause changes, or referencing an entity another clause
+// removes, assumes a sequencing the spec does not state. Paths are compared
+// as written (e.g. "order.status"); aliasing through let bindings is not
+// tracked. Effects in one branch of a conditional do not affect the other.
+func checkWarn22OrderDependentEffects(findings []report.Finding, spec *ast.Spec) []report.Finding {
+	for i, rule := range spec.Rules {
+		fx := &ensuresEffects{}
+		findings = fx.check(findings, rule.Name, rule.Ensures, fmt.Sprintf("$.rules[%d]", i), "ensures", spec.File)
+	}
+	return findings
+}
+
+// ensuresEffect is a write made by an ensures clause: a state change or set
+// mutation of a field path, or the removal of an entity binding.
+type ensuresEffect struct {
+	path    string
+	removal bool
+	at      string // JSON path of the clause making the change
+}
+
+type ensuresEffects struct {
+	effects []ensuresEffect
+}
+
+func (fx *ensuresEffects) check(findings []report.Finding, ruleName string, clauses []ast.EnsuresClause, base, field, file string) []report.Finding {
+	for j, ec := range clauses {
+		path := ast.IndexPath(base, field, j)
+		findings = fx.checkReads(findings, ruleName, ensuresReads(ec), path, file)
+
+		switch ec.Kind {
+		case "state_change", "set_mutation":
+			if p := exprPath(ec.Target); p != "" {
+				fx.effects = append(fx.effects, ensuresEffect{path: p, at: path})
+			}
+		case "entity_removal":
+			if p := exprPath(ec.Target); p != "" {
+				fx.effects = append(fx.effects, ensuresEffect{path: p, removal: true, at: path})
+			}
+		case "conditional":
+			before := len(fx.effects)
+			findings = fx.check(findings, ruleName, ec.Then, path, "then", file)
+			thenEffects := slices.Clone(fx.effects[before:])
+			fx.effects = fx.effects[:before]
+			findings = fx.check(findings, ruleName, ec.Else, path, "else", file)
+			fx.effects = append(fx.effects, thenEffects...)
+		default:
+			findings = fx.check(findings, ruleName, ec.Body, path, "body", file)
+		}
+	}
+	return findings
+}
+
+func (fx *ensuresEffects) checkReads(findings []report.Finding, ruleName string, reads []string, path, file string) []report.Finding {
+	reported := make(map[string]bool)
+	for _, read := range reads {
+		for _, eff := range fx.effects {
+			var msg string
+			switch {
+			case eff.removal && (read == eff.path || strings.HasPrefix(read, eff.path+".")):
+				msg = fmt.Sprintf("Rule '%s' references '%s' after %s removes it; the result depends on the order ensures are applied", ruleName, read, eff.at)
+			case !eff.removal && read == eff.path:
+				msg = fmt.Sprintf("Rule '%s' reads '%s' after %s changes it; the result depends on the order ensures are applied", ruleName, read, eff.at)
+			default:
+				continue
+			}
+			if reported[msg] {
+				continue
+			}
+			reported[msg] = true
+			findings = append(findings, report.NewWarning(
+				"WARN-22",
+				msg,
+				report.Location{File: file, Path: path},
+			))
+		}
+	}
+	return findings
+}
+
+// ensuresReads returns the field paths an ensures clause reads itself, not
+// counting nested clauses or the targets it writes.
+func ensuresReads(ec ast.EnsuresClause) []string {
+	var reads []string
+	collect := func(expr *ast.Expression) {
+		// Record only whole paths: "order.total", not also its prefix "order".
+		inner := make(map[*ast.Expression]bool)
+		walkExpression(expr, func(e *ast.Expression) {
+			p := exprPath(e)
+			if p == "" || inner[e] {
+				return
+			}
+			for o := e.Object; o != nil; o = o.Object {
+				inner[o] = true
+			}
+			if !slices.Contains(reads, p) {
+				reads = append(reads, p)
+			}
+		})
+	}
+	collect(ec.Condition)
+	collect(ec.Collection)
+	if len(ec.Value) > 0 {
+		var valExpr ast.Expression
+		if err := json.Unmarshal(ec.Value, &valExpr); err == nil && valExpr.Kind != "" {
+			collect(&valExpr)
+		}
+		var valEnsures ast.EnsuresClause
+		if err := json.Unmarshal(ec.Value, &valEnsures); err == nil && valEnsures.Kind == "entity_creation" {
+			for _, expr := range valEnsures.Fields {
+				collect(&expr)
+			}
+		}
+	}
+	for _, expr := range ec.Fields {
+		collect(&expr)
+	}
+	for _, expr := range ec.Arguments {
+		collect(&expr)
+	}
+	// Map iteration order is random; keep messages deterministic.
+	sort.Strings(reads)
+	return reads
+}
+
+// WARN-23: Trigger parameter shares its name with a given binding, config
+// parameter or default instance. RULE-11 accepts either declaration, so a
+// reader cannot tell which one the rule body refers to.
+func checkWarn23ParameterShadowsGlobal(findings []report.Finding, spec *ast.Spec) []report.Finding {
+	type declaration struct{ kind, path string }
+	globals := make(map[string]declaration)
+	declare := func(name, kind, path string) {
+		if _, ok := globals[name]; !ok && name != "" {
+			globals[name] = declaration{kind, path}
+		}
+	}
+	for i, g := range spec.Given {
+		declare(g.Name, "given binding", fmt.Sprintf("$.given[%d]", i))
+	}
+	for i, c := range spec.Config {
+		declare(c.Name, "config parameter", fmt.Sprintf("$.config[%d]", i))
+	}
+	for i, d := range spec.Defaults {
+		declare(d.Name, "default instance", fmt.Sprintf("$.defaults[%d]", i))
+	}
+
+	for i, rule := range spec.Rules {
+		for j, p := range rule.Trigger.Parameters {
+			g, ok := globals[p.Name]
+			if !ok {
+				continue
+			}
+			path := fmt.Sprintf("$.rules[%d].trigger.parameters[%d]", i, j)
+			findings = append(findings, report.NewWarning(
+				"WARN-23",
+				fmt.Sprintf("Trigger parameter '%s' of rule '%s' at %s shares its name with %s '%s' at %s",
+					p.Name, rule.Name, path, g.kind, p.Name, g.path),
+				report.Location{File: spec.File, Path: path},
+			))
+		}
+	}
+	return findings
+}
+
+// WARN-24: Entities reference each other through required fields, so none
+// of them can be created before the others.
+func checkWarn24RequiredReferenceCycle(findings []report.Finding, spec *ast.Spec) []report.Finding {
+	for _, cycle := range requiredReferenceCycles(spec) {
+		first := cycle[0]
+		findings = append(findings, report.NewWarning(
+			"WARN-24",
+			fmt.Sprintf("Required references form a cycle, so no instance can be created before the others exist: %s",
+				describeReferenceCycle(spec, cycle)),
+			report.Location{File: spec.File, Path: fmt.Sprintf("$.entities[%d].fields[%d]", first.entity, first.field)},
+		))
+	}
+	return findings
+}
+
+// WARN-25: A surface offers an external stimulus, but a rule handling it
+// requires fields of an entity parameter the surface has no way to supply.
+// An argument is supplied when it is given an expression, or when the
+// surface binds or exposes a value of that name for the actor to pass on.
+func checkWarn25UnsuppliedTriggerEntity(findings []report.Finding, spec *ast.Spec) []report.Finding {
+	handlers := make(map[string][]ast.Rule)
+	for _, r := range spec.Rules {
+		if r.Trigger.Kind == "external_stimulus" {
+			handlers[r.Trigger.Name] = append(handlers[r.Trigger.Name], r)
+		}
+	}
+	if len(handlers) == 0 {
+		return findings
+	}
+
+	for i, s := range spec.Surfaces {
+		available := collectSurfaceBindings(s)
+		for _, exp := range s.Exposes {
+			if exp.Expression != nil && exp.Expression.Kind == "field_access" {
+				available[exp.Expression.Field] = true
+			}
+		}
+		for j, p := range s.Provides {
+			findings = checkUnsuppliedTriggerEntity(findings, p, s, handlers, available,
+				ast.IndexPath(fmt.Sprintf("$.surfaces[%d]", i), "provides", j), spec.File)
+		}
+	}
+	return findings
+}
+
+func checkUnsuppliedTriggerEntity(findings []report.Finding, p ast.ProvidesItem, s ast.Surface, handlers map[string][]ast.Rule, available map[string]bool, path, file string) []report.Finding {
+	if p.Kind == "for_each" {
+		if p.Binding != "" {
+			available = maps.Clone(available)
+			available[p.Binding] = true
+		}
+		for j, item := range p.Items {
+			findings = checkUnsuppliedTriggerEntity(findings, item, s, handlers, available, ast.IndexPath(path, "items", j), file)
+		}
+		return findings
+	}
+
+	args := make(map[string]ast.ProvideArgument, len(p.Arguments))
+	for _, a := range p.Arguments {
+		args[a.Name] = a
+	}
+	for _, rule := range handlers[p.Trigger] {
+		for _, param := range rule.Trigger.Parameters {
+			if a, ok := args[param.Name]; ok && (a.Expression != nil || available[param.Name]) {
+				continue
+			}
+			field := requiredParameterField(rule, param.Name)
+			if field == "" {
+				continue
+			}
+			findings = append(findings, report.NewWarning(
+				"WARN-25",
+				fmt.Sprintf("Surface '%s' provides '%s' to %s, but rule '%s' requires '%s.%s' and the surface has no '%s' to supply",
+					s.Name, p.Trigger, s.Facing.Type, rule.Name, param.Name, field, param.Name),
+				report.Location{File: file, Path: path},
+			))
+		}
+	}
+	return findings
+}
+
+// requiredParameterField returns the first field of the trigger parameter
+// name that the rule's requires clauses read, or "" if they read none.
+func requiredParameterField(rule ast.Rule, name string) string {
+	for i := range rule.Requires {
+		if fields := extractFieldNames(&rule.Requires[i], name); len(fields) > 0 {
+			return fields[0]
+		}
+	}
+	return ""
+}
+
+// WARN-26: Conditional ensures branch on an enum field without handling every
+// value. A chain is a conditional whose else holds only another conditional,
+// and so on. A chain of at least two branches, each testing the same field for
+// equality with, or membership in, a set of values, and with no final else is
+// checked like a switch statement. Values the trigger or the rule's requires
+// rule out need not be handled.
+func checkWarn26NonExhaustiveEnumConditional(findings []report.Finding, spec *ast.Spec, st *SymbolTable) []report.Finding {
+	for i, rule := range spec.Rules {
+		types := ruleFieldTypes(rule, spec, st)
+		for j, ec := range rule.Ensures {
+			findings = checkEnumConditionals(findings, spec, st, rule, ec, types, fmt.Sprintf("$.rules[%d].ensures[%d]", i, j))
+		}
+	}
+	return findings
+}
+
+// checkEnumConditionals checks the conditional chains in an ensures tree,
+// typing iteration and let bindings for the clauses in their body.
+func checkEnumConditionals(findings []report.Finding, spec *ast.Spec, st *SymbolTable, rule ast.Rule, ec ast.EnsuresClause,
+	types map[string]*ast.FieldType, path string) []report.Finding {
+	switch ec.Kind {
+	case "conditional":
+		findings = checkEnumConditionalChain(findings, spec, st, rule, ec, types, path)
+		// The links of the chain are not chains of their own; their branches
+		// may hold other chains.
+		for {
+			for j, then := range ec.Then {
+				findings = checkEnumConditionals(findings, spec, st, rule, then, types, ast.IndexPath(path, "then", j))
+			}
+			if len(ec.Else) != 1 || ec.Else[0].Kind != "conditional" {
+				break
+			}
+			ec, path = ec.Else[0], ast.IndexPath(path, "else", 0)
+		}
+		for j, el := range ec.Else {
+			findings = checkEnumConditionals(findings, spec, st, rule, el, types, ast.IndexPath(path, "else", j))
+		}
+		return findings
+	case "iteration":
+		var element *ast.FieldType
+		if ct := resolveFieldAccessType(ec.Collection, types, st); ct != nil && (ct.Kind == "set" || ct.Kind == "list") {
+			element = ct.Element
+		}
+		types = withBinding(types, ec.Binding, element)
+	case "let_binding":
+		types = withBinding(types, ec.Name, letValueType(ec.Value, types, st))
+	}
+	for j, body := range ec.Body {
+		findings = checkEnumConditionals(findings, spec, st, rule, body, types, ast.IndexPath(path, "body", j))
+	}
+	return findings
+}
+
+func checkEnumConditionalChain(findings []report.Finding, spec *ast.Spec, st *SymbolTable, rule ast.Rule, ec ast.EnsuresClause,
+	types map[string]*ast.FieldType, path string) []report.Finding {
+	var subject *ast.Expression
+	var handled []string
+	branches := 0
+	for c := &ec; ; c = &c.Else[0] {
+		tested, values, ok := enumBranch(c.Condition)
+		if !ok || subject != nil && exprPath(tested) != exprPath(subject) {
+			return findings
+		}
+		subject = tested
+		handled = append(handled, values...)
+		branches++
+		if len(c.Else) == 0 {
+			break
+		}
+		if len(c.Else) != 1 || c.Else[0].Kind != "conditional" {
+			return findings // a final else handles the rest
+		}
+	}
+	if branches < 2 {
+		return findings
+	}
+	enums := ruleEnumLookup(st, types)
+	name, values := enums(subject)
+	if name == "" {
+		return findings
+	}
+
+	var missing []string
+	for _, v := range possibleValues(rule, subject, values, enums) {
+		if !slices.Contains(handled, v) {
+			missing = append(missing, v)
+		}
+	}
+	if len(missing) == 0 {
+		return findings
+	}
+	return append(findings, report.NewWarning(
+		"WARN-26",
+		fmt.Sprintf("Conditional on '%s' in rule '%s' does not handle %s of %s; add a branch or an else",
+			exprPath(subject), rule.Name, strings.Join(missing, ", "), name),
+		report.Location{File: spec.File, Path: path},
+	))
+}
+
+// enumBranch returns the field path a condition tests and the values it
+// accepts, for a condition of the form path = value, path in {values}, or a
+// disjunction of these on one path.
+func enumBranch(cond *ast.Expression) (*ast.Expression, []string, bool) {
+	if cond == nil {
+		return nil, nil, false
+	}
+	switch cond.Kind {
+	case "comparison":
+		if cond.Operator != "=" {
+			return nil, nil, false
+		}
+		tested, lit := cond.Left, cond.Right
+		if exprPath(tested) == "" {
+			tested, lit = lit, tested
+		}
+		key, ok := literalKey(lit)
+		if !ok || exprPath(tested) == "" {
+			return nil, nil, false
+		}
+		return tested, []string{unquoteLiteral(key)}, true
+	case "membership":
+		keys, ok := literalSet(cond.Collection)
+		if !ok || exprPath(cond.Element) == "" {
+			return nil, nil, false
+		}
+		values := make([]string, len(keys))
+		for i, k := range keys {
+			values[i] = unquoteLiteral(k)
+		}
+		return cond.Element, values, true
+	case "boolean_logic":
+		if cond.Operator != "or" {
+			return nil, nil, false
+		}
+		left, lv, ok := enumBranch(cond.Left)
+		if !ok {
+			return nil, nil, false
+		}
+		right, rv, ok := enumBranch(cond.Right)
+		if !ok || exprPath(left) != exprPath(right) {
+			return nil, nil, false
+		}
+		return left, append(lv, rv...), true
+	}
+	return nil, nil, false
+}
+
+// possibleValues narrows the values of the enum subject is typed as to those
+// it may hold when the rule fires: the value a state trigger watching it
+// fires on, and those the rule's requires allow.
+func possibleValues(rule ast.Rule, subject *ast.Expression, values []string, enums enumLookup) []string {
+	path := exprPath(subject)
+	if t := rule.Trigger; t.Binding != "" && path == t.Binding+"."+t.Field {
+		switch t.Kind {
+		case "state_transition":
+			return []string{t.ToValue}
+		case "state_becomes":
+			return []string{t.Value}
+		}
+	}
+	facts := newCondFacts(enums)
+	for i := range rule.Requires {
+		if facts.falsify(&rule.Requires[i]) != "" {
+			return values // contradictory requires are reported elsewhere
+		}
+	}
+	return slices.DeleteFunc(slices.Clone(values), func(v string) bool {
+		return !facts.mayHold(path, v)
+	})
+}
+
+// ruleEnumLookup resolves field paths in a rule to the enum their field is
+// declared as, through the types of the rule's bindings.
+func ruleEnumLookup(st *SymbolTable, types map[string]*ast.FieldType) enumLookup {
+	return func(e *ast.Expression) (string, []string) {
+		ft := resolveFieldAccessType(e, types, st)
+		if ft == nil {
+			return "", nil
+		}
+		if ft.Kind == "optional" && ft.Inner != nil {
+			ft = ft.Inner
+		}
+		values, ok := enumValuesOf(st, *ft)
+		if !ok {
+			return "", nil
+		}
+		if ft.Kind == "named_enum" {
+			return ft.Name, values
+		}
+		return exprPath(e), values
+	}
+}
+
+// WARN-27: Two relationships of an entity are read through the same foreign
+// key, but one has cardinality one and the other many. A foreign key either
+// lets several instances refer to the same entity or it does not, so one of
+// the pair misdescribes it.
+func checkWarn27InconsistentRelationshipPair(findings []report.Finding, spec *ast.Spec, st *SymbolTable) []report.Finding {
+	for i, e := range spec.Entities {
+		seen := make(map[foreignKey]ast.Relationship)
+		for j, rel := range e.Relationships {
+			fk, _ := relationshipForeignKey(st, e.Name, rel)
+			if fk == nil {
+				continue
+			}
+			first, ok := seen[*fk]
+			if !ok {
+				seen[*fk] = rel
+				continue
+			}
+			if first.Cardinality != rel.Cardinality {
 				findings = append(findings, report.NewWarning(
-					"WARN-19",
-					fmt.Sprintf("Multiple identical inline enums on '%s' (fields '%s' and '%s') — consider a named enum",
-						entity.Name, first, e.fieldName),
-					report.Location{File: spec.File, Path: fmt.Sprintf("$.entities[%d]", i)},
+					"WARN-27",
+					fmt.Sprintf("Relationships '%s' (%s) and '%s' (%s) of '%s' are both read through '%s.%s', so cannot differ in cardinality",
+						first.Name, first.Cardinality, rel.Name, rel.Cardinality, e.Name, fk.entity, fk.field),
+					report.Location{File: spec.File, Path: fmt.Sprintf("$.entities[%d].relationships[%d].cardinality", i, j)},
 				))
-			} else {
-				seen[e.values] = e.fieldName
 			}
 		}
 	}
 	return findings
 }
+
+// boundName is a name in scope in a rule, with the kind of declaration that
+// bound it, as in "given binding", and its path.
+type boundName struct{ kind, path string }
+
+// WARN-29: A for, let, iteration or lambda binding in a rule shadows a name
+// already in scope: a given binding, config parameter, default instance,
+// trigger parameter or binding, or an enclosing binding. Trigger parameters
+// sharing a global name are WARN-23.
+func checkWarn29ShadowedBinding(findings []report.Finding, spec *ast.Spec) []report.Finding {
+	globals := make(map[string]boundName)
+	for i, g := range spec.Given {
+		globals[g.Name] = boundName{"given binding", fmt.Sprintf("$.given[%d]", i)}
+	}
+	for i, c := range spec.Config {
+		globals[c.Name] = boundName{"config parameter", fmt.Sprintf("$.config[%d]", i)}
+	}
+	for i, d := range spec.Defaults {
+		globals[d.Name] = boundName{"default instance", fmt.Sprintf("$.defaults[%d]", i)}
+	}
+
+	for i, rule := range spec.Rules {
+		path := fmt.Sprintf("$.rules[%d]", i)
+		scope := maps.Clone(globals)
+		bind := func(scope map[string]boundName, name, kind, at string) {
+			if name == "" {
+				return
+			}
+			if outer, ok := scope[name]; ok {
+				findings = append(findings, report.NewWarning(
+					"WARN-29",
+					fmt.Sprintf("%s '%s' of rule '%s' shadows the %s '%s' at %s", kind, name, rule.Name, outer.kind, name, outer.path),
+					report.Location{File: spec.File, Path: at},
+				))
+			}
+			scope[name] = boundName{strings.ToLower(kind[:1]) + kind[1:], at}
+		}
+		var walkExpr func(expr *ast.Expression, scope map[string]boundName, at *ast.Path)
+		walkExpr = func(expr *ast.Expression, scope map[string]boundName, at *ast.Path) {
+			ast.WalkExpression(expr, at, func(e *ast.Expression, at *ast.Path) bool {
+				if e.Kind != "lambda" {
+					return true
+				}
+				inner := maps.Clone(scope)
+				bind(inner, e.Parameter, "Lambda parameter", at.Field("parameter").String())
+				walkExpr(e.Body, inner, at.Field("body"))
+				return false
+			})
+		}
+		var walkEnsures func(ec ast.EnsuresClause, scope map[string]boundName, at string)
+		walkEnsures = func(ec ast.EnsuresClause, scope map[string]boundName, at string) {
+			for _, e := range ec.Expressions(at) {
+				walkExpr(e.Expr, scope, ast.NewPath(e.Path))
+			}
+			for j, then := range ec.Then {
+				walkEnsures(then, scope, ast.IndexPath(at, "then", j))
+			}
+			for j, el := range ec.Else {
+				walkEnsures(el, scope, ast.IndexPath(at, "else", j))
+			}
+			switch ec.Kind {
+			case "iteration":
+				scope = maps.Clone(scope)
+				bind(scope, ec.Binding, "Iteration binding", at+".binding")
+			case "let_binding":
+				scope = maps.Clone(scope)
+				bind(scope, ec.Name, "Let binding", at+".name")
+			}
+			for j, body := range ec.Body {
+				walkEnsures(body, scope, ast.IndexPath(at, "body", j))
+			}
+		}
+
+		// Trigger parameters and bindings are the outermost names of a rule.
+		for j, p := range rule.Trigger.Parameters {
+			scope[p.Name] = boundName{"trigger parameter", fmt.Sprintf("%s.trigger.parameters[%d]", path, j)}
+		}
+		if rule.Trigger.Binding != "" {
+			scope[rule.Trigger.Binding] = boundName{"trigger binding", path + ".trigger.binding"}
+		}
+		walkExpr(rule.Trigger.Condition, scope, ast.NewPath(path+".trigger.condition"))
+		if fc := rule.ForClause; fc != nil {
+			walkExpr(fc.Collection, scope, ast.NewPath(path+".for_clause.collection"))
+			bind(scope, fc.Binding, "For binding", path+".for_clause.binding")
+			walkExpr(fc.Condition, scope, ast.NewPath(path+".for_clause.condition"))
+		}
+		for j, lb := range rule.LetBindings {
+			at := ast.IndexPath(path, "let_bindings", j)
+			walkExpr(lb.Expression, scope, ast.NewPath(at+".expression"))
+			bind(scope, lb.Name, "Let binding", at+".name")
+		}
+		for j := range rule.Requires {
+			walkExpr(&rule.Requires[j], scope, ast.NewPath(ast.IndexPath(path, "requires", j)))
+		}
+		for j, ec := range rule.Ensures {
+			walkEnsures(ec, scope, ast.IndexPath(path, "ensures", j))
+		}
+	}
+	return findings
+}
+
+// WARN-30: Conditional ensures branch that can never be taken. Each
+// conditional's condition is evaluated against the constraints of the rule's
+// requires and the enclosing conditionals: the then branch is unreachable
+// when the condition cannot hold, and a non-empty else branch when the
+// condition cannot fail. Rules whose requires contradict each other are left
+// to WARN-05.
+func checkWarn30UnreachableBranch(findings []report.Finding, spec *ast.Spec, st *SymbolTable) []report.Finding {
+	for i, rule := range spec.Rules {
+		facts, reason := ruleFacts(rule, spec, st)
+		if reason != "" {
+			continue
+		}
+		for j, ec := range rule.Ensures {
+			findings = checkUnreachableBranches(findings, spec.File, rule.Name, ec, facts, ast.IndexPath(fmt.Sprintf("$.rules[%d]", i), "ensures", j))
+		}
+	}
+	return findings
+}
+
+func checkUnreachableBranches(findings []report.Finding, file, ruleName string, ec ast.EnsuresClause, facts *condFacts, path string) []report.Finding {
+	if ec.Kind != "conditional" {
+		for j, body := range ec.Body {
+			findings = checkUnreachableBranches(findings, file, ruleName, body, facts, ast.IndexPath(path, "body", j))
+		}
+		return findings
+	}
+
+	then := facts.clone()
+	if reason := then.falsify(ec.Condition); reason != "" {
+		findings = append(findings, report.NewWarning(
+			"WARN-30",
+			fmt.Sprintf("Conditional in rule '%s' never takes its then branch: %s", ruleName, reason),
+			report.Location{File: file, Path: path + ".then"},
+		))
+	} else {
+		for j, t := range ec.Then {
+			findings = checkUnreachableBranches(findings, file, ruleName, t, then, ast.IndexPath(path, "then", j))
+		}
+	}
+	if len(ec.Else) == 0 {
+		return findings
+	}
+	els := facts.clone()
+	if reason := els.falsifyNegation(ec.Condition); reason != "" {
+		return append(findings, report.NewWarning(
+			"WARN-30",
+			fmt.Sprintf("Conditional in rule '%s' never takes its else branch: %s", ruleName, reason),
+			report.Location{File: file, Path: path + ".else"},
+		))
+	}
+	for j, e := range ec.Else {
+		findings = checkUnreachableBranches(findings, file, ruleName, e, els, ast.IndexPath(path, "else", j))
+	}
+	return findings
+}
+
+// WARN-31: Config parameter or given binding that no expression references.
+// A config parameter is used when an expression reads config.name, or the bare
+// name as rules may; a given binding when an expression's root is its name.
+// As for WARN-04, use is tracked by name across the whole spec, so a name
+// declared twice, which RULE-23 or RULE-26 reports, is warned about once, at
+// its first declaration.
+func checkWarn31UnusedGlobal(findings []report.Finding, spec *ast.Spec) []report.Finding {
+	if len(spec.Config) == 0 && len(spec.Given) == 0 {
+		return findings
+	}
+	roots, configRefs := collectSpecRoots(spec)
+
+	reported := make(map[string]bool)
+	for i, c := range spec.Config {
+		if !roots[c.Name] && !configRefs[c.Name] && !reported[c.Name] {
+			reported[c.Name] = true
+			findings = append(findings, report.NewWarning(
+				"WARN-31",
+				fmt.Sprintf("Unused config parameter '%s'", c.Name),
+				report.Location{File: spec.File, Path: fmt.Sprintf("$.config[%d]", i)},
+			))
+		}
+	}
+	clear(reported)
+	for i, g := range spec.Given {
+		if !roots[g.Name] && !reported[g.Name] {
+			reported[g.Name] = true
+			findings = append(findings, report.NewWarning(
+				"WARN-31",
+				fmt.Sprintf("Unused given binding '%s'", g.Name),
+				report.Location{File: spec.File, Path: fmt.Sprintf("$.given[%d]", i)},
+			))
+		}
+	}
+	return findings
+}
+
+// collectSpecRoots returns the root names of the field access chains in every
+// expression of the spec, and the config parameters read as config.name.
+func collectSpecRoots(spec *ast.Spec) (roots, configRefs map[string]bool) {
+	roots = make(map[string]bool)
+	configRefs = make(map[string]bool)
+	visit := func(e *ast.Expression, _ *ast.Path) bool {
+		if root := findExprRoot(e); root != "" {
+			roots[root] = true
+		}
+		if e.Kind == "field_access" && e.Object != nil &&
+			e.Object.Kind == "field_access" && e.Object.Object == nil && e.Object.Field == "config" {
+			configRefs[e.Field] = true
+		}
+		return true
+	}
+	walk := func(e *ast.Expression) { ast.WalkExpression(e, ast.NewPath(""), visit) }
+
+	for _, e := range spec.Entities {
+		for _, p := range e.Projections {
+			walk(p.Condition)
+		}
+		for _, dv := range e.DerivedValues {
+			walk(dv.Expression)
+		}
+	}
+	for _, vt := range spec.ValueTypes {
+		for _, dv := range vt.DerivedValues {
+			walk(dv.Expression)
+		}
+	}
+	for _, c := range spec.Config {
+		walk(c.DefaultValue)
+	}
+	for _, d := range spec.Defaults {
+		for _, name := range slices.Sorted(maps.Keys(d.Fields)) {
+			e := d.Fields[name]
+			walk(&e)
+		}
+	}
+	for _, a := range spec.Actors {
+		walk(a.IdentifiedBy.Condition)
+	}
+	for _, r := range spec.Rules {
+		walk(r.Trigger.Condition)
+		if fc := r.ForClause; fc != nil {
+			walk(fc.Collection)
+			walk(fc.Condition)
+		}
+		for _, lb := range r.LetBindings {
+			walk(lb.Expression)
+		}
+		for j := range r.Requires {
+			walk(&r.Requires[j])
+		}
+		for _, ec := range r.Ensures {
+			ast.WalkEnsures(&ec, "", visit)
+		}
+	}
+	var walkProvides func(p ast.ProvidesItem)
+	walkProvides = func(p ast.ProvidesItem) {
+		walk(p.When)
+		walk(p.Collection)
+		for _, arg := range p.Arguments {
+			walk(arg.Expression)
+		}
+		for _, item := range p.Items {
+			walkProvides(item)
+		}
+	}
+	for _, s := range spec.Surfaces {
+		if s.Context != nil {
+			walk(s.Context.Condition)
+		}
+		for _, lb := range s.LetBindings {
+			walk(lb.Expression)
+		}
+		for _, exp := range s.Exposes {
+			walk(exp.Expression)
+			walk(exp.When)
+		}
+		for _, p := range s.Provides {
+			walkProvides(p)
+		}
+		for _, g := range s.Guarantees {
+			walk(g.Expression)
+		}
+		for _, r := range s.Related {
+			walk(r.ContextExpression)
+			walk(r.When)
+		}
+		for _, t := range s.Timeout {
+			walk(t.When)
+		}
+	}
+	return roots, configRefs
+}
+
+// WARN-32: Decimal value assigned to an Integer field, by a state change or
+// an entity creation in a rule's ensures. The fractional part is lost, so the
+// spec should say how it is rounded. Values are typed as for RULE-12, so
+// arithmetic mixing an Integer with a Decimal yields a Decimal. Default
+// instances are held to their field types by RULE-47 instead.
+func checkWarn32LossyNarrowing(findings []report.Finding, spec *ast.Spec, st *SymbolTable) []report.Finding {
+	for i, rule := range spec.Rules {
+		types := ruleFieldTypes(rule, spec, st)
+		for j, ec := range rule.Ensures {
+			findings = checkEnsuresNarrowing(findings, spec, st, ec, types, fmt.Sprintf("$.rules[%d].ensures[%d]", i, j))
+		}
+	}
+	return findings
+}
+
+// checkEnsuresNarrowing checks the state changes and creations in an ensures
+// tree, typing iteration and let bindings for their body as
+// checkStateChangeTargets does.
+func checkEnsuresNarrowing(findings []report.Finding, spec *ast.Spec, st *SymbolTable, ec ast.EnsuresClause,
+	types map[string]*ast.FieldType, path string) []report.Finding {
+	switch ec.Kind {
+	case "state_change":
+		var value ast.Expression
+		if json.Unmarshal(ec.Value, &value) == nil && narrowsToInteger(resolveFieldAccessType(ec.Target, types, st), &value, types, st) {
+			findings = append(findings, report.NewWarning(
+				"WARN-32",
+				fmt.Sprintf("State change assigns a Decimal value to Integer field %s, losing its fractional part; round it explicitly or make the field Decimal", operandName(ec.Target)),
+				report.Location{File: spec.File, Path: path + ".value"},
+			))
+		}
+	case "entity_creation":
+		findings = checkCreationNarrowing(findings, spec, st, ec, types, path)
+	case "iteration":
+		var element *ast.FieldType
+		if ct := resolveFieldAccessType(ec.Collection, types, st); ct != nil && (ct.Kind == "set" || ct.Kind == "list") {
+			element = ct.Element
+		}
+		types = withBinding(types, ec.Binding, element)
+	case "let_binding":
+		var inner ast.EnsuresClause
+		if json.Unmarshal(ec.Value, &inner) == nil && inner.Kind == "entity_creation" {
+			findings = checkCreationNarrowing(findings, spec, st, inner, types, path+".value")
+		}
+		types = withBinding(types, ec.Name, letValueType(ec.Value, types, st))
+	}
+	for j, then := range ec.Then {
+		findings = checkEnsuresNarrowing(findings, spec, st, then, types, ast.IndexPath(path, "then", j))
+	}
+	for j, el := range ec.Else {
+		findings = checkEnsuresNarrowing(findings, spec, st, el, types, ast.IndexPath(path, "else", j))
+	}
+	for j, body := range ec.Body {
+		findings = checkEnsuresNarrowing(findings, spec, st, body, types, ast.IndexPath(path, "body", j))
+	}
+	return findings
+}
+
+// checkCreationNarrowing checks the fields an entity_creation sets.
+func checkCreationNarrowing(findings []report.Finding, spec *ast.Spec, st *SymbolTable, ec ast.EnsuresClause,
+	types map[string]*ast.FieldType, path string) []report.Finding {
+	members, ok := triggerEntityMembers(st, ec.Entity)
+	if !ok {
+		return findings
+	}
+	for _, name := range slices.Sorted(maps.Keys(ec.Fields)) {
+		f := members.field(name)
+		value := ec.Fields[name]
+		if f != nil && narrowsToInteger(&f.Type, &value, types, st) {
+			findings = append(findings, report.NewWarning(
+				"WARN-32",
+				fmt.Sprintf("Creation of '%s' sets Integer field '%s' to a Decimal value, losing its fractional part; round it explicitly or make the field Decimal", ec.Entity, name),
+				report.Location{File: spec.File, Path: path + ".fields." + name},
+			))
+		}
+	}
+	return findings
+}
+
+// narrowsToInteger reports whether value is a Decimal assigned to a field of
+// type ft that is Integer or an optional Integer.
+func narrowsToInteger(ft *ast.FieldType, value *ast.Expression, types map[string]*ast.FieldType, st *SymbolTable) bool {
+	if ft != nil && ft.Kind == "optional" {
+		ft = ft.Inner
+	}
+	if ft == nil || ft.Kind != "primitive" || ft.Value != "Integer" {
+		return false
+	}
+	return resolveExprType(value, types, st) == "Decimal"
+}
@@ -1,6 +1,10 @@
 package semantic
 
 import (
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/foundry-zero/allium/internal/ast"
@@ -57,7 +61,7 @@ func warningSpec() *ast.Spec {
 				Name:    "SubmitOrder",
 				Trigger: ast.Trigger{Kind: "external_stimulus", Name: "submit_order"},
 				Ensures: []ast.EnsuresClause{
-					{Kind: "state_change"},
+					{Kind: "state_change", Target: &ast.Expression{Kind: "field_access", Object: &ast.Expression{Kind: "field_access", Field: "order"}, Field: "status"}},
 				},
 			},
 		},
@@ -264,6 +268,71 @@ func TestCheckWarnings_WARN05_ConsistentRequires(t *testing.T) {
 	}
 }
 
+// orderRule returns warningSpec with a rule on order creation with the given
+// requires and ensures.
+func orderRule(requires []ast.Expression, ensures ...ast.EnsuresClause) *ast.Spec {
+	spec := warningSpec()
+	spec.Rules = []ast.Rule{{
+		Name:     "OnOrder",
+		Trigger:  ast.Trigger{Kind: "entity_creation", Binding: "order", Entity: "Order"},
+		Requires: requires,
+		Ensures:  ensures,
+	}}
+	return spec
+}
+
+func TestCheckWarnings_WARN05_Constraints(t *testing.T) {
+	total := chain("order", "total")
+	notIn := func(values ...string) *ast.Expression {
+		set := &ast.Expression{Kind: "set_literal"}
+		for _, v := range values {
+			set.Elements = append(set.Elements, *enumLitExpr(v))
+		}
+		return &ast.Expression{Kind: "not", Operand: &ast.Expression{Kind: "membership", Element: chain("order", "status"), Collection: set}}
+	}
+	between5and10 := andExpr("and", comparisonExpr(">", total, intLitExpr(5)), comparisonExpr("<", total, intLitExpr(10)))
+
+	tests := []struct {
+		name     string
+		requires []*ast.Expression
+		never    bool
+	}{
+		{"disjoint bounds", []*ast.Expression{comparisonExpr(">", total, intLitExpr(100)), comparisonExpr("<=", total, intLitExpr(50))}, true},
+		{"value outside bound", []*ast.Expression{comparisonExpr("=", total, intLitExpr(5)), comparisonExpr("<=", intLitExpr(10), total)}, true},
+		{"range excluded", []*ast.Expression{comparisonExpr(">=", total, intLitExpr(1)), comparisonExpr("<", total, intLitExpr(3)),
+			comparisonExpr("!=", total, intLitExpr(1)), comparisonExpr("!=", total, intLitExpr(2))}, true},
+		{"enum exhausted", []*ast.Expression{notIn("pending", "shipped"), comparisonExpr("!=", chain("order", "status"), enumLitExpr("delivered"))}, true},
+		{"boolean field", []*ast.Expression{chain("order", "paid"), {Kind: "not", Operand: chain("order", "paid")}}, true},
+		{"negated or", []*ast.Expression{{Kind: "not", Operand: andExpr("or",
+			comparisonExpr(">", total, intLitExpr(5)), comparisonExpr("<", total, intLitExpr(10)))}}, true},
+		{"negated and", []*ast.Expression{comparisonExpr("=", total, intLitExpr(7)), {Kind: "not", Operand: between5and10}}, true},
+		{"literal ordering", []*ast.Expression{comparisonExpr(">", intLitExpr(3), intLitExpr(4))}, true},
+		{"narrow range", []*ast.Expression{comparisonExpr(">", total, intLitExpr(5)), comparisonExpr("<", total, intLitExpr(7))}, false},
+		{"range partly excluded", []*ast.Expression{comparisonExpr(">=", total, intLitExpr(1)), comparisonExpr("<=", total, intLitExpr(2)),
+			comparisonExpr("!=", total, intLitExpr(1))}, false},
+		{"enum value left", []*ast.Expression{notIn("pending", "shipped")}, false},
+		{"negated and holds", []*ast.Expression{comparisonExpr("=", total, intLitExpr(3)), {Kind: "not", Operand: between5and10}}, false},
+		{"fields compared", []*ast.Expression{comparisonExpr("<", total, chain("order", "limit")), comparisonExpr(">", total, chain("order", "limit"))}, false},
+	}
+	for _, tt := range tests {
+		var requires []ast.Expression
+		for _, r := range tt.requires {
+			requires = append(requires, *r)
+		}
+		spec := orderRule(requires)
+		w05 := warnFindings(CheckWarnings(spec, BuildSymbolTable(spec)), "WARN-05")
+		if got := len(w05) == 1; got != tt.never {
+			t.Errorf("%s: WARN-05 reported = %v, want %v (%v)", tt.name, got, tt.never, w05)
+		}
+	}
+
+	spec := orderRule([]ast.Expression{*comparisonExpr(">", total, intLitExpr(100)), *comparisonExpr("<=", total, intLitExpr(50))})
+	w05 := warnFindings(CheckWarnings(spec, BuildSymbolTable(spec)), "WARN-05")
+	if want := "Rule 'OnOrder' can never fire (contradictory requires): contradictory constraints order.total > 100 and order.total <= 50"; len(w05) != 1 || w05[0].Message != want {
+		t.Errorf("got %v, want %q", w05, want)
+	}
+}
+
 // ---- WARN-06 ----
 
 func TestCheckWarnings_WARN06_TemporalNoGuard(t *testing.T) {
@@ -342,6 +411,208 @@ func TestCheckWarnings_WARN09_ActorUsedInFacing(t *testing.T) {
 	}
 }
 
+// ---- WARN-07 ----
+
+func TestCheckWarnings_WARN07_UnusedExposed(t *testing.T) {
+	spec := warningSpec()
+	spec.Surfaces[0].Exposes = append(spec.Surfaces[0].Exposes,
+		ast.ExposesItem{Expression: &ast.Expression{Kind: "field_access", Object: &ast.Expression{Kind: "field_access", Field: "order"}, Field: "total"}},
+		ast.ExposesItem{Expression: &ast.Expression{Kind: "field_access", Object: &ast.Expression{Kind: "field_access", Field: "viewer"}, Field: "name"}},
+	)
+	st := BuildSymbolTable(spec)
+	findings := CheckWarnings(spec, st)
+
+	w07 := warnFindings(findings, "WARN-07")
+	if len(w07) != 2 {
+		t.Fatalf("expected WARN-07 for order.total and viewer.name, got %v", w07)
+	}
+	if w07[0].Location.Path != "$.surfaces[0].exposes[1]" {
+		t.Errorf("path = %q", w07[0].Location.Path)
+	}
+	// viewer is a Customer actor, identified by User.
+	if w07[1].Message != "Surface 'OrderView' exposes 'User.name', which no rule reads or writes" {
+		t.Errorf("message = %q", w07[1].Message)
+	}
+}
+
+func TestCheckWarnings_WARN07_FieldUsedByRules(t *testing.T) {
+	spec := warningSpec()
+	spec.Surfaces[0].Exposes = append(spec.Surfaces[0].Exposes,
+		ast.ExposesItem{Expression: &ast.Expression{Kind: "field_access", Object: &ast.Expression{Kind: "field_access", Field: "order"}, Field: "total"}},
+		ast.ExposesItem{Expression: &ast.Expression{Kind: "field_access", Object: &ast.Expression{Kind: "field_access", Field: "order"}, Field: "created_at"}},
+		// Relationships and unknown fields are not entity fields and are skipped.
+		ast.ExposesItem{Expression: &ast.Expression{Kind: "field_access", Object: &ast.Expression{Kind: "field_access", Field: "order"}, Field: "customer"}},
+	)
+	// total is written on creation; created_at is read by a derived value.
+	spec.Rules = append(spec.Rules, ast.Rule{
+		Name:    "PlaceOrder",
+		Trigger: ast.Trigger{Kind: "external_stimulus", Name: "place_order"},
+		Ensures: []ast.EnsuresClause{
+			{Kind: "entity_creation", Entity: "Order", Fields: map[string]ast.Expression{"total": litExpr("0")}},
+		},
+	})
+	spec.Entities[0].DerivedValues = []ast.DerivedValue{
+		{Name: "age", Expression: &ast.Expression{Kind: "field_access", Field: "created_at"}},
+	}
+	st := BuildSymbolTable(spec)
+	findings := CheckWarnings(spec, st)
+
+	if w07 := warnFindings(findings, "WARN-07"); len(w07) != 0 {
+		t.Errorf("expected no WARN-07, got %v", w07)
+	}
+}
+
+// ---- WARN-08 ----
+
+func orderStatusIs(value string) *ast.Expression {
+	lit := litExpr(value)
+	return comparisonExpr("=", &ast.Expression{Kind: "field_access", Object: &ast.Expression{Kind: "field_access", Field: "order"}, Field: "status"}, &lit)
+}
+
+func TestCheckWarnings_WARN08_ImpossibleProvides(t *testing.T) {
+	falseLit := ast.Expression{Kind: "literal", Type: "boolean", LitValue: json.RawMessage("false")}
+	tests := []struct {
+		name   string
+		when   *ast.Expression
+		reason string
+	}{
+		{"false literal", &falseLit, "the condition is the literal false"},
+		{"conflicting equalities", &ast.Expression{Kind: "boolean_logic", Operator: "and", Left: orderStatusIs("pending"), Right: orderStatusIs("shipped")},
+			`contradictory constraints order.status = "pending" and order.status = "shipped"`},
+		{"value outside enum", orderStatusIs("cancelled"), `"cancelled" is not a value of Order.status (pending | shipped | delivered)`},
+		{"equal and not equal", &ast.Expression{Kind: "boolean_logic", Operator: "and", Left: orderStatusIs("pending"),
+			Right: &ast.Expression{Kind: "comparison", Operator: "!=", Left: orderStatusIs("pending").Left, Right: orderStatusIs("pending").Right}},
+			`contradictory constraints order.status = "pending" and order.status != "pending"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := warningSpec()
+			spec.Surfaces[0].Provides = append(spec.Surfaces[0].Provides, ast.ProvidesItem{
+				Kind:       "for_each",
+				Binding:    "item",
+				Collection: fieldAccess("items"),
+				Items:      []ast.ProvidesItem{{Kind: "action", Trigger: "ship_order", When: tt.when}},
+			})
+			st := BuildSymbolTable(spec)
+			w08 := warnFindings(CheckWarnings(spec, st), "WARN-08")
+			if len(w08) != 1 {
+				t.Fatalf("expected 1 WARN-08, got %v", w08)
+			}
+			if w08[0].Location.Path != "$.surfaces[0].provides[1].items[0].when" {
+				t.Errorf("path = %q", w08[0].Location.Path)
+			}
+			want := "Provides 'ship_order' on surface 'OrderView' can never be offered: " + tt.reason
+			if w08[0].Message != want {
+				t.Errorf("message = %q, want %q", w08[0].Message, want)
+			}
+		})
+	}
+}
+
+func TestCheckWarnings_WARN08_SatisfiableProvides(t *testing.T) {
+	spec := warningSpec()
+	spec.Surfaces[0].Provides = append(spec.Surfaces[0].Provides,
+		ast.ProvidesItem{Kind: "action", Trigger: "ship_order", When: orderStatusIs("pending")},
+		// One satisfiable alternative is enough.
+		ast.ProvidesItem{Kind: "action", Trigger: "deliver_order", When: &ast.Expression{
+			Kind: "boolean_logic", Operator: "or",
+			Left:  &ast.Expression{Kind: "boolean_logic", Operator: "and", Left: orderStatusIs("pending"), Right: orderStatusIs("shipped")},
+			Right: orderStatusIs("shipped"),
+		}},
+	)
+	st := BuildSymbolTable(spec)
+	if w08 := warnFindings(CheckWarnings(spec, st), "WARN-08"); len(w08) != 0 {
+		t.Errorf("expected no WARN-08, got %v", w08)
+	}
+}
+
+// ---- WARN-10 ----
+
+// uniqueUserSpec declares User with a unique email and a rule creating users
+// under the given requires and ensures.
+func uniqueUserSpec(requires []ast.Expression, ensures ...ast.EnsuresClause) *ast.Spec {
+	return &ast.Spec{
+		File: "test.allium.json",
+		Entities: []ast.Entity{
+			{
+				Name: "User",
+				Fields: []ast.Field{
+					{Name: "email", Type: ast.FieldType{Kind: "primitive", Value: "String"}},
+				},
+				Unique: []ast.UniqueConstraint{{Fields: []string{"email"}}},
+			},
+		},
+		Rules: []ast.Rule{
+			{
+				Name:     "Register",
+				Trigger:  ast.Trigger{Kind: "external_stimulus", Name: "register", Parameters: []ast.TriggerParam{{Name: "email"}}},
+				Requires: requires,
+				Ensures:  ensures,
+			},
+		},
+	}
+}
+
+func createUser() ast.EnsuresClause {
+	return ast.EnsuresClause{Kind: "entity_creation", Entity: "User", Fields: map[string]ast.Expression{"email": *fieldAccess("email")}}
+}
+
+func userByEmail() *ast.Expression {
+	return &ast.Expression{Kind: "join_lookup", Entity: "User", Fields: map[string]ast.Expression{"email": *fieldAccess("email")}}
+}
+
+func TestCheckWarnings_WARN10_UnguardedCreation(t *testing.T) {
+	spec := uniqueUserSpec(nil, createUser())
+	findings := warnFindings(CheckWarnings(spec, BuildSymbolTable(spec)), "WARN-10")
+
+	if len(findings) != 1 {
+		t.Fatalf("expected 1 WARN-10, got %v", findings)
+	}
+	if findings[0].Location.Path != "$.rules[0].ensures[0]" {
+		t.Errorf("path = %q", findings[0].Location.Path)
+	}
+	if findings[0].Message != "Rule 'Register' creates 'User' without checking that no 'User' with the same email exists" {
+		t.Errorf("message = %q", findings[0].Message)
+	}
+}
+
+func TestCheckWarnings_WARN10_Guarded(t *testing.T) {
+	notExists := ast.Expression{Kind: "not", Operand: &ast.Expression{Kind: "exists", Target: userByEmail()}}
+	lookupJSON, _ := json.Marshal(userByEmail())
+
+	cases := map[string]*ast.Spec{
+		"requires": uniqueUserSpec([]ast.Expression{notExists}, createUser()),
+		"conditional": uniqueUserSpec(nil, ast.EnsuresClause{
+			Kind: "conditional", Condition: &notExists, Then: []ast.EnsuresClause{createUser()},
+		}),
+		"ensures let": uniqueUserSpec(nil, ast.EnsuresClause{
+			Kind: "let_binding", Name: "existing", Value: lookupJSON,
+			Body: []ast.EnsuresClause{{
+				Kind:      "conditional",
+				Condition: &ast.Expression{Kind: "not", Operand: &ast.Expression{Kind: "exists", Target: fieldAccess("existing")}},
+				Then:      []ast.EnsuresClause{createUser()},
+			}},
+		}),
+	}
+	for name, spec := range cases {
+		if findings := warnFindings(CheckWarnings(spec, BuildSymbolTable(spec)), "WARN-10"); len(findings) != 0 {
+			t.Errorf("%s: expected no WARN-10, got %v", name, findings)
+		}
+	}
+}
+
+func TestCheckWarnings_WARN10_GuardOnOtherField(t *testing.T) {
+	// The lookup must match every field of the constraint.
+	spec := uniqueUserSpec([]ast.Expression{{Kind: "not", Operand: &ast.Expression{Kind: "exists", Target: &ast.Expression{
+		Kind: "join_lookup", Entity: "User", Fields: map[string]ast.Expression{"name": *fieldAccess("email")},
+	}}}}, createUser())
+	spec.Rules[0].LetBindings = []ast.LetBinding{{Name: "existing", Expression: userByEmail()}}
+
+	if findings := warnFindings(CheckWarnings(spec, BuildSymbolTable(spec)), "WARN-10"); len(findings) != 1 {
+		t.Errorf("expected WARN-10 when the guard looks up another field, got %v", findings)
+	}
+}
+
 // ---- WARN-12 ----
 
 func TestCheckWarnings_WARN12_OverlappingRequires(t *testing.T) {
@@ -379,6 +650,48 @@ func TestCheckWarnings_WARN12_DisjointTriggers(t *testing.T) {
 	}
 }
 
+// ---- WARN-13 ----
+
+func TestCheckWarnings_WARN13_DerivedScope(t *testing.T) {
+	spec := warningSpec()
+	spec.Entities[0].DerivedValues = []ast.DerivedValue{
+		// discount_rate is not an Order field.
+		{Name: "discounted", Expression: arithmeticExpr("*", fieldAccess("total"), fieldAccess("discount_rate"))},
+		// customer is a relationship to User, which has no email field.
+		{Name: "contact", Expression: &ast.Expression{Kind: "field_access", Object: fieldAccess("customer"), Field: "email"}},
+	}
+	st := BuildSymbolTable(spec)
+	w13 := warnFindings(CheckWarnings(spec, st), "WARN-13")
+	if len(w13) != 2 {
+		t.Fatalf("expected 2 WARN-13, got %v", w13)
+	}
+	if w13[0].Location.Path != "$.entities[0].derived_values[0].expression" ||
+		w13[0].Message != "Derived value 'discounted' on 'Order' references 'discount_rate', which is not declared on 'Order' or reachable through its relationships" {
+		t.Errorf("unexpected finding: %s at %s", w13[0].Message, w13[0].Location.Path)
+	}
+	if w13[1].Message != "Derived value 'contact' on 'Order' references 'customer.email', but 'User' has no field 'email'" {
+		t.Errorf("unexpected finding: %s", w13[1].Message)
+	}
+}
+
+func TestCheckWarnings_WARN13_InScope(t *testing.T) {
+	spec := warningSpec()
+	spec.Entities[0].DerivedValues = []ast.DerivedValue{
+		{Name: "customer_name", Expression: &ast.Expression{Kind: "field_access", Object: fieldAccess("customer"), Field: "name"}},
+		{Name: "scaled", Parameters: []string{"factor"}, Expression: arithmeticExpr("*", fieldAccess("total"), fieldAccess("factor"))},
+		{Name: "capped", Expression: comparisonExpr("<=", fieldAccess("scaled"), &ast.Expression{Kind: "field_access", Object: fieldAccess("config"), Field: "max_total"})},
+		{Name: "any_large", Expression: &ast.Expression{
+			Kind:       "collection_op",
+			Collection: fieldAccess("customer"),
+			Lambda:     &ast.Expression{Kind: "lambda", Parameter: "c", Body: &ast.Expression{Kind: "field_access", Object: fieldAccess("c"), Field: "name"}},
+		}},
+	}
+	st := BuildSymbolTable(spec)
+	if w13 := warnFindings(CheckWarnings(spec, st), "WARN-13"); len(w13) != 0 {
+		t.Errorf("expected no WARN-13, got %v", w13)
+	}
+}
+
 // ---- WARN-14 ----
 
 func TestCheckWarnings_WARN14_TrivialActor(t *testing.T) {
@@ -674,6 +987,45 @@ func TestCheckWarnings_WARN19_DuplicateInlineEnums(t *testing.T) {
 	}
 }
 
+func TestCheckWarnings_WARN19_SuggestsNamedEnum(t *testing.T) {
+	spec := warningSpec()
+	values := []string{"pending", "shipped", "delivered"}
+	spec.Enumerations = []ast.Enumeration{{Name: "OrderStatus", Values: []string{"open"}}}
+	spec.Entities[0].Fields = append(spec.Entities[0].Fields,
+		ast.Field{Name: "previous_status", Type: ast.FieldType{Kind: "inline_enum", Values: []string{"delivered", "pending", "shipped"}}},
+		ast.Field{Name: "requested_status", Type: ast.FieldType{Kind: "inline_enum", Values: values}},
+	)
+	st := BuildSymbolTable(spec)
+	w19 := warnFindings(CheckWarnings(spec, st), "WARN-19")
+	if len(w19) != 2 {
+		t.Fatalf("expected 2 WARN-19, got %v", w19)
+	}
+	if len(w19[1].Suggestions) != 0 {
+		t.Errorf("only the first warning for a set should carry the suggestion, got %+v", w19[1].Suggestions)
+	}
+	if len(w19[0].Suggestions) != 1 {
+		t.Fatalf("expected one suggestion, got %+v", w19[0].Suggestions)
+	}
+	s := w19[0].Suggestions[0]
+	// OrderStatus is taken, so the enumeration is numbered.
+	if s.Description != "Extract enumeration 'OrderStatus2' and use it for these fields" {
+		t.Errorf("unexpected description %q", s.Description)
+	}
+	want := []string{
+		`add $.enumerations[1] {"name":"OrderStatus2","values":["pending","shipped","delivered"]}`,
+		`replace $.entities[0].fields[0].type {"kind":"named_enum","name":"OrderStatus2"}`,
+		`replace $.entities[0].fields[3].type {"kind":"named_enum","name":"OrderStatus2"}`,
+		`replace $.entities[0].fields[4].type {"kind":"named_enum","name":"OrderStatus2"}`,
+	}
+	var got []string
+	for _, e := range s.Edits {
+		got = append(got, e.Op+" "+e.Path+" "+string(e.Value))
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("edits = %q, want %q", got, want)
+	}
+}
+
 func TestCheckWarnings_WARN19_UniqueInlineEnums(t *testing.T) {
 	spec := warningSpec()
 	spec.Entities[0].Fields = append(spec.Entities[0].Fields, ast.Field{
@@ -689,6 +1041,319 @@ func TestCheckWarnings_WARN19_UniqueInlineEnums(t *testing.T) {
 	}
 }
 
+// ---- WARN-20 ----
+
+func TestCheckWarnings_WARN20_UnconsumedEmission(t *testing.T) {
+	spec := warningSpec()
+	spec.Rules = append(spec.Rules,
+		ast.Rule{
+			Name:    "ShipOrder",
+			Trigger: ast.Trigger{Kind: "external_stimulus", Name: "ship_order"},
+			Ensures: []ast.EnsuresClause{
+				{
+					Kind:      "conditional",
+					Condition: boolLitExpr(true),
+					Then: []ast.EnsuresClause{
+						{Kind: "trigger_emission", Name: "OrderShiped"},
+					},
+				},
+				{Kind: "trigger_emission", Name: "OrderShipped"},
+			},
+		},
+		ast.Rule{
+			Name:    "NotifyShipment",
+			Trigger: ast.Trigger{Kind: "chained", Name: "OrderShipped"},
+			Ensures: []ast.EnsuresClause{{Kind: "state_change"}},
+		},
+	)
+	st := BuildSymbolTable(spec)
+	findings := CheckWarnings(spec, st)
+
+	w20 := warnFindings(findings, "WARN-20")
+	if len(w20) != 1 {
+		t.Fatalf("expected 1 WARN-20, got %d: %v", len(w20), w20)
+	}
+	if w20[0].Location.Path != "$.rules[1].ensures[0].then[0].name" {
+		t.Errorf("path = %q", w20[0].Location.Path)
+	}
+	if !strings.Contains(w20[0].Message, "did you mean 'OrderShipped'") {
+		t.Errorf("expected suggestion in message: %q", w20[0].Message)
+	}
+}
+
+// ---- WARN-22 ----
+
+func orderField(field string) *ast.Expression {
+	return &ast.Expression{Kind: "field_access", Object: fieldAccess("order"), Field: field}
+}
+
+func TestCheckWarnings_WARN22_OrderDependentEffects(t *testing.T) {
+	spec := warningSpec()
+	spec.Rules = append(spec.Rules, ast.Rule{
+		Name:    "CancelOrder",
+		Trigger: ast.Trigger{Kind: "external_stimulus", Name: "cancel_order"},
+		Ensures: []ast.EnsuresClause{
+			{Kind: "state_change", Target: orderField("status"), Value: rawExpr("pending")},
+			{
+				Kind:      "conditional",
+				Condition: comparisonExpr("=", orderField("status"), &ast.Expression{Kind: "literal", Type: "string", LitValue: json.RawMessage(`"pending"`)}),
+				Then:      []ast.EnsuresClause{{Kind: "entity_removal", Target: fieldAccess("order")}},
+			},
+			{Kind: "trigger_emission", Name: "OrderCancelled", Arguments: map[string]ast.Expression{"total": *orderField("total")}},
+		},
+	})
+	st := BuildSymbolTable(spec)
+	w22 := warnFindings(CheckWarnings(spec, st), "WARN-22")
+	if len(w22) != 2 {
+		t.Fatalf("expected 2 WARN-22, got %v", w22)
+	}
+	if w22[0].Location.Path != "$.rules[1].ensures[1]" ||
+		w22[0].Message != "Rule 'CancelOrder' reads 'order.status' after $.rules[1].ensures[0] changes it; the result depends on the order ensures are applied" {
+		t.Errorf("unexpected state change finding: %s at %s", w22[0].Message, w22[0].Location.Path)
+	}
+	if w22[1].Location.Path != "$.rules[1].ensures[2]" ||
+		w22[1].Message != "Rule 'CancelOrder' references 'order.total' after $.rules[1].ensures[1].then[0] removes it; the result depends on the order ensures are applied" {
+		t.Errorf("unexpected removal finding: %s at %s", w22[1].Message, w22[1].Location.Path)
+	}
+}
+
+func TestCheckWarnings_WARN22_IndependentEffects(t *testing.T) {
+	spec := warningSpec()
+	spec.Rules = append(spec.Rules, ast.Rule{
+		Name:    "ShipOrder",
+		Trigger: ast.Trigger{Kind: "external_stimulus", Name: "ship_order"},
+		Ensures: []ast.EnsuresClause{
+			// A clause may read the field it changes, and reads before a change
+			// do not depend on it.
+			{Kind: "trigger_emission", Name: "OrderShipped", Arguments: map[string]ast.Expression{"status": *orderField("status")}},
+			{Kind: "state_change", Target: orderField("total"), Value: json.RawMessage(`{"kind": "field_access", "object": {"kind": "field_access", "field": "order"}, "field": "total"}`)},
+			// Effects in one branch do not reach the other.
+			{
+				Kind:      "conditional",
+				Condition: boolLitExpr(true),
+				Then:      []ast.EnsuresClause{{Kind: "entity_removal", Target: fieldAccess("order")}},
+				Else:      []ast.EnsuresClause{{Kind: "state_change", Target: orderField("status"), Value: rawExpr("shipped")}},
+			},
+		},
+	})
+	st := BuildSymbolTable(spec)
+	if w22 := warnFindings(CheckWarnings(spec, st), "WARN-22"); len(w22) != 0 {
+		t.Errorf("expected no WARN-22, got %v", w22)
+	}
+}
+
+// ---- WARN-23 ----
+
+func TestCheckWarnings_WARN23_ParameterShadowsGlobal(t *testing.T) {
+	spec := warningSpec()
+	spec.Given = []ast.GivenBinding{{Name: "store", Type: ast.FieldType{Kind: "entity_ref", Entity: "User"}}}
+	spec.Config = []ast.ConfigParam{{Name: "limit", Type: ast.FieldType{Kind: "primitive", Value: "Integer"}}}
+	spec.Defaults = []ast.Default{{Entity: "User", Name: "admin"}}
+	spec.Rules = append(spec.Rules, ast.Rule{
+		Name: "PlaceOrder",
+		Trigger: ast.Trigger{Kind: "external_stimulus", Name: "place_order", Parameters: []ast.TriggerParam{
+			{Name: "admin"}, {Name: "quantity"}, {Name: "store"},
+		}},
+		Ensures: []ast.EnsuresClause{{Kind: "trigger_emission", Name: "OrderPlaced"}},
+	})
+	st := BuildSymbolTable(spec)
+	w23 := warnFindings(CheckWarnings(spec, st), "WARN-23")
+	if len(w23) != 2 {
+		t.Fatalf("expected 2 WARN-23, got %v", w23)
+	}
+	if w23[0].Location.Path != "$.rules[1].trigger.parameters[0]" ||
+		w23[0].Message != "Trigger parameter 'admin' of rule 'PlaceOrder' at $.rules[1].trigger.parameters[0] shares its name with default instance 'admin' at $.defaults[0]" {
+		t.Errorf("unexpected default finding: %s at %s", w23[0].Message, w23[0].Location.Path)
+	}
+	if w23[1].Location.Path != "$.rules[1].trigger.parameters[2]" ||
+		w23[1].Message != "Trigger parameter 'store' of rule 'PlaceOrder' at $.rules[1].trigger.parameters[2] shares its name with given binding 'store' at $.given[0]" {
+		t.Errorf("unexpected given finding: %s at %s", w23[1].Message, w23[1].Location.Path)
+	}
+}
+
+// ---- WARN-24 ----
+
+func TestCheckWarnings_WARN24_RequiredReferenceCycle(t *testing.T) {
+	spec := warningSpec()
+	ref := func(entity string) ast.FieldType { return ast.FieldType{Kind: "entity_ref", Entity: entity} }
+	spec.Entities[0].Fields = append(spec.Entities[0].Fields, ast.Field{Name: "buyer", Type: ref("User")})
+	spec.Entities[1].Fields = append(spec.Entities[1].Fields,
+		ast.Field{Name: "first_order", Type: ref("Order")},
+		// Optional and collection references can be filled in later.
+		ast.Field{Name: "referrer", Type: ast.FieldType{Kind: "optional", Inner: &ast.FieldType{Kind: "entity_ref", Entity: "User"}}},
+		ast.Field{Name: "orders", Type: ast.FieldType{Kind: "set", Element: &ast.FieldType{Kind: "entity_ref", Entity: "Order"}}},
+	)
+	spec.Entities = append(spec.Entities, ast.Entity{Name: "Employee", Fields: []ast.Field{{Name: "manager", Type: ref("Employee")}}})
+
+	st := BuildSymbolTable(spec)
+	w24 := warnFindings(CheckWarnings(spec, st), "WARN-24")
+	if len(w24) != 2 {
+		t.Fatalf("expected 2 WARN-24, got %v", w24)
+	}
+	if w24[0].Location.Path != "$.entities[0].fields[3]" ||
+		w24[0].Message != "Required references form a cycle, so no instance can be created before the others exist: Order.buyer -> User.first_order -> Order" {
+		t.Errorf("unexpected cycle finding: %s at %s", w24[0].Message, w24[0].Location.Path)
+	}
+	if w24[1].Location.Path != "$.entities[2].fields[0]" || !strings.HasSuffix(w24[1].Message, ": Employee.manager -> Employee") {
+		t.Errorf("unexpected self-reference finding: %s at %s", w24[1].Message, w24[1].Location.Path)
+	}
+}
+
+// ---- WARN-25 ----
+
+func TestCheckWarnings_WARN25_UnsuppliedTriggerEntity(t *testing.T) {
+	spec := warningSpec()
+	access := func(root, field string) *ast.Expression {
+		return &ast.Expression{Kind: "field_access", Object: &ast.Expression{Kind: "field_access", Field: root}, Field: field}
+	}
+	spec.Rules = append(spec.Rules,
+		ast.Rule{
+			Name:     "CancelOrder",
+			Trigger:  ast.Trigger{Kind: "external_stimulus", Name: "cancel_order", Parameters: []ast.TriggerParam{{Name: "order"}, {Name: "invoice"}, {Name: "reason"}}},
+			Requires: []ast.Expression{*access("order", "status"), *access("invoice", "paid")},
+		},
+		ast.Rule{
+			Name:     "ReviewOrder",
+			Trigger:  ast.Trigger{Kind: "external_stimulus", Name: "review_order", Parameters: []ast.TriggerParam{{Name: "line"}}},
+			Requires: []ast.Expression{*access("line", "total")},
+		},
+	)
+	spec.Surfaces[0].Provides = append(spec.Surfaces[0].Provides,
+		// order is the surface context; invoice is entered by the actor but
+		// nothing on the surface names one; reason is never read as an entity.
+		ast.ProvidesItem{Kind: "action", Trigger: "cancel_order", Arguments: []ast.ProvideArgument{{Name: "order"}, {Name: "invoice"}}},
+		ast.ProvidesItem{Kind: "for_each", Binding: "line", Collection: access("order", "lines"), Items: []ast.ProvidesItem{
+			{Kind: "action", Trigger: "review_order", Arguments: []ast.ProvideArgument{{Name: "line"}}},
+		}},
+		ast.ProvidesItem{Kind: "action", Trigger: "review_order"},
+	)
+
+	st := BuildSymbolTable(spec)
+	w25 := warnFindings(CheckWarnings(spec, st), "WARN-25")
+	if len(w25) != 2 {
+		t.Fatalf("expected 2 WARN-25, got %v", w25)
+	}
+	if w25[0].Location.Path != "$.surfaces[0].provides[1]" ||
+		w25[0].Message != "Surface 'OrderView' provides 'cancel_order' to Customer, but rule 'CancelOrder' requires 'invoice.paid' and the surface has no 'invoice' to supply" {
+		t.Errorf("unexpected finding: %s at %s", w25[0].Message, w25[0].Location.Path)
+	}
+	if w25[1].Location.Path != "$.surfaces[0].provides[3]" {
+		t.Errorf("expected the action outside for_each to warn, got %s", w25[1].Location.Path)
+	}
+}
+
+// ---- WARN-26 ----
+
+func TestCheckWarnings_WARN26_NonExhaustiveEnumConditional(t *testing.T) {
+	status := &ast.Expression{Kind: "field_access", Object: &ast.Expression{Kind: "field_access", Field: "order"}, Field: "status"}
+	is := func(values ...string) *ast.Expression {
+		if len(values) == 1 {
+			return &ast.Expression{Kind: "comparison", Operator: "=", Left: status,
+				Right: &ast.Expression{Kind: "literal", Type: "enum_value", LitValue: json.RawMessage(strconv.Quote(values[0]))}}
+		}
+		set := &ast.Expression{Kind: "set_literal"}
+		for _, v := range values {
+			set.Elements = append(set.Elements, ast.Expression{Kind: "literal", Type: "enum_value", LitValue: json.RawMessage(strconv.Quote(v))})
+		}
+		return &ast.Expression{Kind: "membership", Element: status, Collection: set}
+	}
+	emit := []ast.EnsuresClause{{Kind: "trigger_emission", Name: "Noted"}}
+	chain := func(final []ast.EnsuresClause, conds ...*ast.Expression) ast.EnsuresClause {
+		ec := ast.EnsuresClause{Kind: "conditional", Condition: conds[len(conds)-1], Then: emit, Else: final}
+		for i := len(conds) - 2; i >= 0; i-- {
+			ec = ast.EnsuresClause{Kind: "conditional", Condition: conds[i], Then: emit, Else: []ast.EnsuresClause{ec}}
+		}
+		return ec
+	}
+
+	tests := []struct {
+		name     string
+		requires []ast.Expression
+		ensures  ast.EnsuresClause
+		message  string
+	}{
+		{
+			name:    "missing value",
+			ensures: chain(nil, is("pending"), is("shipped")),
+			message: "Conditional on 'order.status' in rule 'HandleOrder' does not handle delivered of order.status; add a branch or an else",
+		},
+		{name: "all values", ensures: chain(nil, is("pending"), is("shipped", "delivered"))},
+		{name: "final else", ensures: chain(emit, is("pending"), is("shipped"))},
+		{name: "single branch", ensures: chain(nil, is("pending"))},
+		{
+			name:     "value ruled out by requires",
+			requires: []ast.Expression{{Kind: "comparison", Operator: "!=", Left: status, Right: is("delivered").Right}},
+			ensures:  chain(nil, is("pending"), is("shipped")),
+		},
+		{
+			name: "nested in a branch",
+			ensures: ast.EnsuresClause{Kind: "conditional", Condition: &ast.Expression{Kind: "comparison", Operator: ">",
+				Left:  &ast.Expression{Kind: "field_access", Object: status.Object, Field: "total"},
+				Right: &ast.Expression{Kind: "literal", Type: "integer", LitValue: json.RawMessage("0")}},
+				Then: []ast.EnsuresClause{chain(nil, is("pending"), is("delivered"))}},
+			message: "does not handle shipped",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := warningSpec()
+			spec.Rules = append(spec.Rules, ast.Rule{
+				Name:     "HandleOrder",
+				Trigger:  ast.Trigger{Kind: "entity_creation", Binding: "order", Entity: "Order"},
+				Requires: tt.requires,
+				Ensures:  []ast.EnsuresClause{tt.ensures},
+			})
+			w26 := warnFindings(CheckWarnings(spec, BuildSymbolTable(spec)), "WARN-26")
+			if tt.message == "" {
+				if len(w26) != 0 {
+					t.Errorf("expected no WARN-26, got %v", w26)
+				}
+				return
+			}
+			if len(w26) != 1 || !strings.Contains(w26[0].Message, tt.message) {
+				t.Fatalf("expected WARN-26 containing %q, got %v", tt.message, w26)
+			}
+		})
+	}
+}
+
+func TestCheckWarnings_WARN27_InconsistentRelationshipPair(t *testing.T) {
+	rel := func(name, cardinality string) ast.Relationship {
+		return ast.Relationship{Name: name, TargetEntity: "Order", ForeignKey: "account", Cardinality: cardinality}
+	}
+	tests := []struct {
+		name    string
+		rels    []ast.Relationship
+		message string
+	}{
+		{name: "single relationship", rels: []ast.Relationship{rel("orders", "many")}},
+		{name: "same cardinality", rels: []ast.Relationship{rel("orders", "many"), rel("all_orders", "many")}},
+		{
+			name:    "one and many",
+			rels:    []ast.Relationship{rel("orders", "many"), rel("order", "one")},
+			message: "Relationships 'orders' (many) and 'order' (one) of 'Account' are both read through 'Order.account'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := relationshipSpec(tt.rels...)
+			w27 := warnFindings(CheckWarnings(spec, BuildSymbolTable(spec)), "WARN-27")
+			if tt.message == "" {
+				if len(w27) != 0 {
+					t.Errorf("expected no WARN-27, got %v", w27)
+				}
+				return
+			}
+			if len(w27) != 1 || !strings.Contains(w27[0].Message, tt.message) {
+				t.Fatalf("expected WARN-27 containing %q, got %v", tt.message, w27)
+			}
+			if w27[0].Location.Path != "$.entities[0].relationships[1].cardinality" {
+				t.Errorf("path = %q", w27[0].Location.Path)
+			}
+		})
+	}
+}
+
 // ---- Clean spec: no warnings on baseline ----
 
 func TestCheckWarnings_Clean(t *testing.T) {
@@ -717,7 +1382,7 @@ func TestCheckWarnings_EmptySpec(t *testing.T) {
 	}
 }
 
-// ---- All findings are warnings ----
+// ---- All findings but WARN-02 are warnings ----
 
 func TestCheckWarnings_AllFindingsAreWarnings(t *testing.T) {
 	spec := warningSpec()
@@ -729,8 +1394,198 @@ func TestCheckWarnings_AllFindingsAreWarnings(t *testing.T) {
 	findings := CheckWarnings(spec, st)
 
 	for _, f := range findings {
-		if f.Severity != report.SeverityWarning {
-			t.Errorf("expected warning severity, got %s for %s", f.Severity, f.Rule)
+		want := report.SeverityWarning
+		if f.Rule == "WARN-02" {
+			want = report.SeverityInfo
+		}
+		if f.Severity != want {
+			t.Errorf("expected %s severity, got %s for %s", want, f.Severity, f.Rule)
+		}
+	}
+}
+
+// ---- WARN-29 ----
+
+func TestCheckWarnings_WARN29_ShadowedBinding(t *testing.T) {
+	spec := warningSpec()
+	spec.Given = []ast.GivenBinding{{Name: "store", Type: ast.FieldType{Kind: "entity_ref", Entity: "User"}}}
+	spec.Config = []ast.ConfigParam{{Name: "limit", Type: ast.FieldType{Kind: "primitive", Value: "Integer"}}}
+	anyItem := func(param string) *ast.Expression {
+		return &ast.Expression{Kind: "collection_op", Operation: "any", Collection: chain("order", "items"),
+			Lambda: &ast.Expression{Kind: "lambda", Parameter: param, Body: fieldAccess(param)}}
+	}
+	spec.Rules = append(spec.Rules, ast.Rule{
+		Name:        "CloseOrder",
+		Trigger:     ast.Trigger{Kind: "external_stimulus", Name: "close_order", Parameters: []ast.TriggerParam{{Name: "order"}}},
+		ForClause:   &ast.ForClause{Binding: "store", Collection: fieldAccess("stores")},
+		LetBindings: []ast.LetBinding{{Name: "total", Expression: anyItem("item")}, {Name: "total", Expression: fieldAccess("order")}},
+		Requires:    []ast.Expression{*anyItem("limit"), *anyItem("item")},
+		Ensures: []ast.EnsuresClause{
+			{Kind: "iteration", Binding: "order", Collection: fieldAccess("orders"), Body: []ast.EnsuresClause{
+				{Kind: "let_binding", Name: "item", Value: json.RawMessage(`{"kind": "literal", "type": "integer", "value": 1}`),
+					Body: []ast.EnsuresClause{{Kind: "state_change", Target: chain("order", "status"), Value: json.RawMessage(`{"kind": "collection_op", "operation": "any", "collection": {"kind": "field_access", "object": null, "field": "items"}, "lambda": {"kind": "lambda", "parameter": "item", "body": {"kind": "field_access", "object": null, "field": "item"}}}`)}},
+				},
+			}},
+		},
+	})
+	w29 := warnFindings(CheckWarnings(spec, BuildSymbolTable(spec)), "WARN-29")
+
+	want := []struct{ path, message string }{
+		{"$.rules[1].for_clause.binding", "For binding 'store' of rule 'CloseOrder' shadows the given binding 'store' at $.given[0]"},
+		{"$.rules[1].let_bindings[1].name", "Let binding 'total' of rule 'CloseOrder' shadows the let binding 'total' at $.rules[1].let_bindings[0].name"},
+		{"$.rules[1].requires[0].lambda.parameter", "Lambda parameter 'limit' of rule 'CloseOrder' shadows the config parameter 'limit' at $.config[0]"},
+		{"$.rules[1].ensures[0].binding", "Iteration binding 'order' of rule 'CloseOrder' shadows the trigger parameter 'order' at $.rules[1].trigger.parameters[0]"},
+		{"$.rules[1].ensures[0].body[0].body[0].value.lambda.parameter", "Lambda parameter 'item' of rule 'CloseOrder' shadows the let binding 'item' at $.rules[1].ensures[0].body[0].name"},
+	}
+	if len(w29) != len(want) {
+		t.Fatalf("expected %d WARN-29, got %v", len(want), w29)
+	}
+	for i, w := range want {
+		if w29[i].Location.Path != w.path || w29[i].Message != w.message {
+			t.Errorf("finding %d = %q at %s, want %q at %s", i, w29[i].Message, w29[i].Location.Path, w.message, w.path)
+		}
+	}
+}
+
+// ---- WARN-30 ----
+
+func TestCheckWarnings_WARN30_UnreachableBranch(t *testing.T) {
+	status, total := chain("order", "status"), chain("order", "total")
+	assign := ast.EnsuresClause{Kind: "state_change", Target: chain("order", "status"), Value: json.RawMessage(`{"kind": "literal", "type": "enum_value", "value": "shipped"}`)}
+	conditional := func(cond *ast.Expression, then []ast.EnsuresClause, els ...ast.EnsuresClause) ast.EnsuresClause {
+		return ast.EnsuresClause{Kind: "conditional", Condition: cond, Then: then, Else: els}
+	}
+	spec := orderRule(
+		[]ast.Expression{*comparisonExpr("=", status, enumLitExpr("pending")), *comparisonExpr(">", total, intLitExpr(100))},
+		conditional(comparisonExpr("=", status, enumLitExpr("shipped")), []ast.EnsuresClause{assign}),
+		conditional(comparisonExpr(">", total, intLitExpr(50)), []ast.EnsuresClause{assign}, assign),
+		conditional(comparisonExpr(">", total, intLitExpr(200)), []ast.EnsuresClause{
+			conditional(comparisonExpr("<", total, intLitExpr(150)), []ast.EnsuresClause{assign}),
+		}, assign),
+		// Reachable either way, or always taken with no else.
+		conditional(comparisonExpr(">", total, intLitExpr(150)), []ast.EnsuresClause{assign}, assign),
+		conditional(comparisonExpr("!=", status, enumLitExpr("delivered")), []ast.EnsuresClause{assign}),
+	)
+	w30 := warnFindings(CheckWarnings(spec, BuildSymbolTable(spec)), "WARN-30")
+
+	want := []struct{ path, message string }{
+		{"$.rules[0].ensures[0].then", "Conditional in rule 'OnOrder' never takes its then branch: contradictory constraints order.status = \"pending\" and order.status = \"shipped\""},
+		{"$.rules[0].ensures[1].else", "Conditional in rule 'OnOrder' never takes its else branch: contradictory constraints order.total > 100 and order.total <= 50"},
+		{"$.rules[0].ensures[2].then[0].then", "Conditional in rule 'OnOrder' never takes its then branch: contradictory constraints order.total > 100 and order.total > 200 and order.total < 150"},
+	}
+	if len(w30) != len(want) {
+		t.Fatalf("expected %d WARN-30, got %v", len(want), w30)
+	}
+	for i, w := range want {
+		if w30[i].Location.Path != w.path || w30[i].Message != w.message {
+			t.Errorf("finding %d = %q at %s, want %q at %s", i, w30[i].Message, w30[i].Location.Path, w.message, w.path)
+		}
+	}
+
+	// Contradictory requires are WARN-05 alone.
+	spec.Rules[0].Requires = append(spec.Rules[0].Requires, *comparisonExpr("<", total, intLitExpr(0)))
+	if w30 := warnFindings(CheckWarnings(spec, BuildSymbolTable(spec)), "WARN-30"); len(w30) != 0 {
+		t.Errorf("expected no WARN-30 with contradictory requires, got %v", w30)
+	}
+}
+
+// ---- WARN-31 ----
+
+func TestCheckWarnings_WARN31_UnusedGlobal(t *testing.T) {
+	integer := ast.FieldType{Kind: "primitive", Value: "Integer"}
+	spec := warningSpec()
+	spec.Config = []ast.ConfigParam{
+		{Name: "max_total", Type: integer},
+		{Name: "min_total", Type: integer, DefaultValue: chain("config", "floor")},
+		{Name: "floor", Type: integer},
+		{Name: "grace", Type: integer},
+		{Name: "unused_limit", Type: integer},
+	}
+	spec.Given = []ast.GivenBinding{
+		{Name: "store", Type: ast.FieldType{Kind: "entity_ref", Entity: "User"}},
+		{Name: "notifier", Type: ast.FieldType{Kind: "entity_ref", Entity: "User"}},
+	}
+	spec.Rules[0].Requires = []ast.Expression{*comparisonExpr("<", chain("order", "total"), chain("config", "max_total"))}
+	spec.Rules[0].Ensures = append(spec.Rules[0].Ensures, ast.EnsuresClause{Kind: "conditional",
+		Condition: comparisonExpr(">", chain("order", "total"), fieldAccess("grace")),
+		Then:      []ast.EnsuresClause{{Kind: "state_change", Target: chain("store", "name")}}})
+	spec.Surfaces[0].Exposes = append(spec.Surfaces[0].Exposes, ast.ExposesItem{Expression: chain("config", "min_total")})
+
+	w31 := warnFindings(CheckWarnings(spec, BuildSymbolTable(spec)), "WARN-31")
+	want := []struct{ path, message string }{
+		{"$.config[4]", "Unused config parameter 'unused_limit'"},
+		{"$.given[1]", "Unused given binding 'notifier'"},
+	}
+	if len(w31) != len(want) {
+		t.Fatalf("expected %d WARN-31, got %v", len(want), w31)
+	}
+	for i, w := range want {
+		if w31[i].Location.Path != w.path || w31[i].Message != w.message {
+			t.Errorf("finding %d = %q at %s, want %q at %s", i, w31[i].Message, w31[i].Location.Path, w.message, w.path)
+		}
+	}
+}
+
+func TestCheckWarnings_WARN31_DuplicateName(t *testing.T) {
+	// A name declared twice, as RULE-23 and RULE-26 report, is one unused name.
+	spec := warningSpec()
+	spec.Config = []ast.ConfigParam{
+		{Name: "session_timeout", Type: ast.FieldType{Kind: "primitive", Value: "Duration"}},
+		{Name: "session_timeout", Type: ast.FieldType{Kind: "primitive", Value: "Integer"}},
+	}
+	spec.Given = []ast.GivenBinding{
+		{Name: "store", Type: ast.FieldType{Kind: "entity_ref", Entity: "User"}},
+		{Name: "store", Type: ast.FieldType{Kind: "entity_ref", Entity: "User"}},
+	}
+
+	w31 := warnFindings(CheckWarnings(spec, BuildSymbolTable(spec)), "WARN-31")
+	want := []struct{ path, message string }{
+		{"$.config[0]", "Unused config parameter 'session_timeout'"},
+		{"$.given[0]", "Unused given binding 'store'"},
+	}
+	if len(w31) != len(want) {
+		t.Fatalf("expected %d WARN-31, got %v", len(want), w31)
+	}
+	for i, w := range want {
+		if w31[i].Location.Path != w.path || w31[i].Message != w.message {
+			t.Errorf("finding %d = %q at %s, want %q at %s", i, w31[i].Message, w31[i].Location.Path, w.message, w.path)
+		}
+	}
+}
+
+// ---- WARN-32 ----
+
+func TestCheckWarnings_WARN32_LossyNarrowing(t *testing.T) {
+	decimal := ast.FieldType{Kind: "primitive", Value: "Decimal"}
+	scaled, _ := json.Marshal(arithmeticExpr("*", chain("order", "total"), decLitExpr(1.1)))
+	rate, _ := json.Marshal(chain("order", "rate"))
+	whole, _ := json.Marshal(arithmeticExpr("*", chain("order", "total"), intLitExpr(2)))
+	spec := nullSpec(nil,
+		ast.EnsuresClause{Kind: "state_change", Target: chain("order", "total"), Value: scaled},
+		ast.EnsuresClause{Kind: "conditional", Condition: existsExpr(chain("order", "coupon")),
+			Then: []ast.EnsuresClause{{Kind: "entity_creation", Entity: "Coupon", Fields: map[string]ast.Expression{
+				"code": *strLitExpr("x"), "discount": *chain("order", "rate")}}}},
+		// Widening and Integer arithmetic lose nothing.
+		ast.EnsuresClause{Kind: "state_change", Target: chain("order", "rate"), Value: whole},
+		ast.EnsuresClause{Kind: "state_change", Target: chain("order", "total"), Value: whole},
+		// The target of an untyped binding is not known.
+		ast.EnsuresClause{Kind: "state_change", Target: chain("request", "total"), Value: rate},
+	)
+	spec.Entities[0].Fields = append(spec.Entities[0].Fields, ast.Field{Name: "rate", Type: decimal})
+
+	w32 := warnFindings(CheckWarnings(spec, BuildSymbolTable(spec)), "WARN-32")
+	want := []struct{ path, message string }{
+		{"$.rules[0].ensures[0].value",
+			"State change assigns a Decimal value to Integer field 'order.total', losing its fractional part; round it explicitly or make the field Decimal"},
+		{"$.rules[0].ensures[1].then[0].fields.discount",
+			"Creation of 'Coupon' sets Integer field 'discount' to a Decimal value, losing its fractional part; round it explicitly or make the field Decimal"},
+	}
+	if len(w32) != len(want) {
+		t.Fatalf("expected %d WARN-32, got %v", len(want), w32)
+	}
+	for i, w := range want {
+		if w32[i].Location.Path != w.path || w32[i].Message != w.message {
+			t.Errorf("finding %d = %q at %s, want %q at %s", i, w32[i].Message, w32[i].Location.Path, w.message, w.path)
 		}
 	}
 }
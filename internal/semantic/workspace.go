@@ -0,0 +1,256 @@
+package semantic
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/report"
+)
+
+// WorkspaceMember is a single spec loaded as part of a workspace.
+type WorkspaceMember struct {
+	Path    string
+	Spec    *ast.Spec
+	Symbols *SymbolTable
+}
+
+// Workspace indexes a set of specs validated together so that
+// use_declaration coordinates can be resolved across files.
+type Workspace struct {
+	Members []*WorkspaceMember
+
+	// Coordinates, when not nil, holds the coordinates a bundle manifest
+	// publishes its members under, mapped to nil for a member that is missing
+	// or did not load. Coordinates other than paths then resolve only
+	// through it, and those it does not list are reported as RULE-59.
+	Coordinates map[string]*WorkspaceMember
+
+	// External holds the specs fetched with AddFetched for coordinates that
+	// no member resolves. They are not checked themselves, and resolve only
+	// the coordinate each was fetched for.
+	External []*WorkspaceMember
+
+	byPath      map[string]*WorkspaceMember
+	byName      map[string][]*WorkspaceMember
+	fetched     map[string]*WorkspaceMember
+	fetchErrors map[string]error
+}
+
+// NewWorkspace creates an empty workspace.
+func NewWorkspace() *Workspace {
+	return &Workspace{
+		byPath:      make(map[string]*WorkspaceMember),
+		byName:      make(map[string][]*WorkspaceMember),
+		fetched:     make(map[string]*WorkspaceMember),
+		fetchErrors: make(map[string]error),
+	}
+}
+
+// Add registers a parsed spec loaded from path and builds its symbol table.
+func (w *Workspace) Add(path string, spec *ast.Spec) *WorkspaceMember {
+	m := &WorkspaceMember{Path: path, Spec: spec, Symbols: BuildSymbolTable(spec)}
+	w.Members = append(w.Members, m)
+
+	abs := absPath(path)
+	w.byPath[abs] = m
+	w.byPath[strings.TrimSuffix(abs, ".json")] = m
+
+	for _, name := range memberNames(path, spec) {
+		w.byName[name] = append(w.byName[name], m)
+	}
+	return m
+}
+
+// AddFetched registers a spec fetched from path, such as a registry URL, as
+// the target of coordinate.
+func (w *Workspace) AddFetched(coordinate, path string, spec *ast.Spec) *WorkspaceMember {
+	m := &WorkspaceMember{Path: path, Spec: spec, Symbols: BuildSymbolTable(spec)}
+	w.External = append(w.External, m)
+	w.fetched[coordinate] = m
+	return m
+}
+
+// FetchFailed records why the spec for coordinate could not be fetched, so
+// that its importers report the cause.
+func (w *Workspace) FetchFailed(coordinate string, err error) {
+	w.fetchErrors[coordinate] = err
+}
+
+// Unresolved returns, in order of first use, the coordinates that members
+// import and that neither resolve nor are paths, and so may be fetched.
+// A bundle's coordinates resolve only through its manifest and are never
+// unresolved in this sense.
+func (w *Workspace) Unresolved() []string {
+	if w.Coordinates != nil {
+		return nil
+	}
+	var coordinates []string
+	seen := make(map[string]bool)
+	for _, m := range w.Members {
+		for _, u := range m.Spec.UseDeclarations {
+			c := u.Coordinate
+			if c == "" || seen[c] || isPathCoordinate(c) || w.Resolve(m.Path, c) != nil {
+				continue
+			}
+			seen[c] = true
+			coordinates = append(coordinates, c)
+		}
+	}
+	return coordinates
+}
+
+// memberNames returns the short names a coordinate may use to refer to a spec:
+// the spec's declared file name and that name without the .allium extension.
+func memberNames(path string, spec *ast.Spec) []string {
+	file := spec.File
+	if file == "" {
+		file = strings.TrimSuffix(filepath.Base(path), ".json")
+	}
+	return []string{file, strings.TrimSuffix(file, ".allium")}
+}
+
+// Resolve returns the workspace member that a use_declaration coordinate
+// refers to, or nil if the coordinate does not name a workspace spec.
+//
+// Relative coordinates ("./billing.allium") resolve against the directory of
+// the importing file. Other coordinates resolve by their final segment, so
+// "org.example:billing" and "github.com/acme/billing@1.2.0" both match a spec
+// whose file is "billing.allium". In a bundle, such coordinates resolve to
+// the member the manifest lists them for. Coordinates that match no member
+// resolve to the spec fetched for them, if any.
+func (w *Workspace) Resolve(fromPath, coordinate string) *WorkspaceMember {
+	if coordinate == "" {
+		return nil
+	}
+
+	if isPathCoordinate(coordinate) {
+		target := coordinate
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(absPath(fromPath)), target)
+		}
+		target = filepath.Clean(target)
+		if m, ok := w.byPath[target]; ok {
+			return m
+		}
+		return w.byPath[strings.TrimSuffix(target, ".json")]
+	}
+
+	if w.Coordinates != nil {
+		return w.Coordinates[coordinate]
+	}
+	name := coordinate
+	if i := strings.LastIndexAny(name, "/:"); i >= 0 {
+		name = name[i+1:]
+	}
+	if i := strings.Index(name, "@"); i >= 0 {
+		name = name[:i]
+	}
+	if candidates := w.byName[name]; len(candidates) == 1 {
+		return candidates[0]
+	}
+	return w.fetched[coordinate]
+}
+
+// isPathCoordinate reports whether a coordinate is a filesystem path
+// (relative to the importing spec or absolute) rather than a registry name.
+func isPathCoordinate(coordinate string) bool {
+	return strings.HasPrefix(coordinate, "./") || strings.HasPrefix(coordinate, "../") ||
+		filepath.IsAbs(coordinate)
+}
+
+func absPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	return abs
+}
+
+// exportsType returns true if the spec declares name as an entity, value type,
+// variant, or enumeration that importing specs may reference.
+func exportsType(st *SymbolTable, name string) bool {
+	return st.LookupEntity(name) != nil || st.LookupValueType(name) != nil ||
+		st.LookupVariant(name) != nil || st.LookupEnumeration(name) != nil
+}
+
+// CheckWorkspaceReferences resolves a member's use_declarations against the
+// workspace (RULE-35):
+//
+//   - every coordinate must resolve to a spec in the workspace
+//   - once all imports resolve, every external entity must be exported by one
+//     of the imported specs
+//
+// In a bundle, a coordinate that is not a path and is not listed in the
+// manifest is reported as RULE-59 instead.
+func CheckWorkspaceReferences(ws *Workspace, m *WorkspaceMember) []report.Finding {
+	var findings []report.Finding
+	spec := m.Spec
+
+	var imported []*WorkspaceMember
+	var aliases []string
+	allResolved := true
+	for i, u := range spec.UseDeclarations {
+		if u.Coordinate == "" {
+			// Reported by CheckReferences.
+			allResolved = false
+			continue
+		}
+		target := ws.Resolve(m.Path, u.Coordinate)
+		if _, listed := ws.Coordinates[u.Coordinate]; target == nil && ws.Coordinates != nil && !listed && !isPathCoordinate(u.Coordinate) {
+			allResolved = false
+			findings = append(findings, report.NewError(
+				"RULE-59",
+				fmt.Sprintf("Use declaration '%s' coordinate '%s' is not listed in the bundle manifest", u.Alias, u.Coordinate),
+				report.Location{File: spec.File, Path: fmt.Sprintf("$.use_declarations[%d].coordinate", i)},
+			))
+			continue
+		}
+		if err := ws.fetchErrors[u.Coordinate]; target == nil && err != nil {
+			allResolved = false
+			findings = append(findings, report.NewError(
+				"RULE-35",
+				fmt.Sprintf("Use declaration '%s' coordinate '%s' could not be fetched: %v", u.Alias, u.Coordinate, err),
+				report.Location{File: spec.File, Path: fmt.Sprintf("$.use_declarations[%d].coordinate", i)},
+			))
+			continue
+		}
+		if target == nil {
+			allResolved = false
+			findings = append(findings, report.NewError(
+				"RULE-35",
+				fmt.Sprintf("Use declaration '%s' coordinate '%s' does not resolve to any spec in the workspace", u.Alias, u.Coordinate),
+				report.Location{File: spec.File, Path: fmt.Sprintf("$.use_declarations[%d].coordinate", i)},
+			))
+			continue
+		}
+		imported = append(imported, target)
+		aliases = append(aliases, u.Alias)
+	}
+
+	if !allResolved || len(imported) == 0 {
+		return findings
+	}
+
+	sort.Strings(aliases)
+	for i, ee := range spec.ExternalEntities {
+		exported := false
+		for _, target := range imported {
+			if exportsType(target.Symbols, ee.Name) {
+				exported = true
+				break
+			}
+		}
+		if !exported {
+			findings = append(findings, report.NewError(
+				"RULE-35",
+				fmt.Sprintf("External entity '%s' is not exported by any imported spec (%s)", ee.Name, strings.Join(aliases, ", ")),
+				report.Location{File: spec.File, Path: fmt.Sprintf("$.external_entities[%d]", i)},
+			))
+		}
+	}
+
+	return findings
+}
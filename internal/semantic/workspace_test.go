@@ -0,0 +1,156 @@
+package semantic
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/foundry-zero/allium/internal/ast"
+)
+
+func workspaceSpecs() (*ast.Spec, *ast.Spec) {
+	billing := &ast.Spec{
+		File: "billing.allium",
+		Entities: []ast.Entity{
+			{Name: "Invoice", Fields: []ast.Field{{Name: "total", Type: ast.FieldType{Kind: "primitive", Value: "Integer"}}}},
+		},
+		Enumerations: []ast.Enumeration{{Name: "Currency", Values: []string{"eur", "usd"}}},
+	}
+	orders := &ast.Spec{
+		File: "orders.allium",
+		UseDeclarations: []ast.UseDeclaration{
+			{Coordinate: "org.example:billing", Alias: "billing"},
+		},
+		ExternalEntities: []ast.ExternalEntity{
+			{Name: "Invoice"},
+		},
+	}
+	return billing, orders
+}
+
+func TestWorkspaceResolve(t *testing.T) {
+	billing, orders := workspaceSpecs()
+	ws := NewWorkspace()
+	target := ws.Add(filepath.Join("specs", "billing.allium.json"), billing)
+	from := filepath.Join("specs", "orders.allium.json")
+	ws.Add(from, orders)
+
+	tests := []struct {
+		coordinate string
+		want       bool
+	}{
+		{"org.example:billing", true},
+		{"github.com/acme/billing@1.2.0", true},
+		{"billing.allium", true},
+		{"./billing.allium", true},
+		{"./billing.allium.json", true},
+		{"../billing.allium", false},
+		{"org.example:payments", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		got := ws.Resolve(from, tt.coordinate)
+		if (got == target) != tt.want {
+			t.Errorf("Resolve(%q) = %v, want resolved=%v", tt.coordinate, got, tt.want)
+		}
+	}
+}
+
+func TestWorkspaceResolveAmbiguousName(t *testing.T) {
+	billing, _ := workspaceSpecs()
+	ws := NewWorkspace()
+	ws.Add(filepath.Join("a", "billing.allium.json"), billing)
+	ws.Add(filepath.Join("b", "billing.allium.json"), billing)
+
+	if got := ws.Resolve("orders.allium.json", "org.example:billing"); got != nil {
+		t.Errorf("expected ambiguous coordinate to stay unresolved, got %s", got.Path)
+	}
+}
+
+func TestCheckWorkspaceReferences_Clean(t *testing.T) {
+	billing, orders := workspaceSpecs()
+	ws := NewWorkspace()
+	ws.Add("billing.allium.json", billing)
+	m := ws.Add("orders.allium.json", orders)
+
+	findings := CheckWorkspaceReferences(ws, m)
+	if len(findings) != 0 {
+		t.Errorf("expected 0 findings, got %v", findings)
+	}
+}
+
+func TestCheckWorkspaceReferences_UnresolvedCoordinate(t *testing.T) {
+	_, orders := workspaceSpecs()
+	ws := NewWorkspace()
+	m := ws.Add("orders.allium.json", orders)
+
+	findings := CheckWorkspaceReferences(ws, m)
+	f := findingWithRule(findings, "RULE-35")
+	if f == nil {
+		t.Fatal("expected RULE-35 for unresolved coordinate")
+	}
+	if f.Location.Path != "$.use_declarations[0].coordinate" {
+		t.Errorf("path = %q", f.Location.Path)
+	}
+	// External entity checks are skipped while imports are unresolved.
+	if n := len(findingsWithRule(findings, "RULE-35")); n != 1 {
+		t.Errorf("expected 1 RULE-35 finding, got %d", n)
+	}
+}
+
+func TestCheckWorkspaceReferences_ExternalNotExported(t *testing.T) {
+	billing, orders := workspaceSpecs()
+	orders.ExternalEntities = append(orders.ExternalEntities, ast.ExternalEntity{Name: "Refund"})
+	ws := NewWorkspace()
+	ws.Add("billing.allium.json", billing)
+	m := ws.Add("orders.allium.json", orders)
+
+	findings := CheckWorkspaceReferences(ws, m)
+	if n := len(findingsWithRule(findings, "RULE-35")); n != 1 {
+		t.Fatalf("expected 1 RULE-35 finding, got %d: %v", n, findings)
+	}
+	if findings[0].Location.Path != "$.external_entities[1]" {
+		t.Errorf("path = %q", findings[0].Location.Path)
+	}
+}
+
+func TestWorkspaceFetched(t *testing.T) {
+	billing, orders := workspaceSpecs()
+	orders.UseDeclarations = append(orders.UseDeclarations,
+		ast.UseDeclaration{Coordinate: "./tax.allium", Alias: "tax"},
+		ast.UseDeclaration{Coordinate: "org.example:billing", Alias: "again"},
+		ast.UseDeclaration{Coordinate: "org.example:shipping@2.0.0", Alias: "shipping"})
+	ws := NewWorkspace()
+	m := ws.Add("orders.allium.json", orders)
+
+	// Path coordinates are not fetched, and each coordinate is listed once.
+	if got := ws.Unresolved(); !slices.Equal(got, []string{"org.example:billing", "org.example:shipping@2.0.0"}) {
+		t.Errorf("Unresolved = %q", got)
+	}
+
+	fetched := ws.AddFetched("org.example:billing", "registry/org.example/billing.allium.json", billing)
+	ws.FetchFailed("org.example:shipping@2.0.0", errors.New("503 Service Unavailable"))
+	if got := ws.Resolve(m.Path, "org.example:billing"); got != fetched {
+		t.Errorf("Resolve(fetched) = %v", got)
+	}
+	// A fetched spec resolves only its own coordinate, not by name.
+	if got := ws.Resolve(m.Path, "billing.allium"); got != nil {
+		t.Errorf("Resolve(billing.allium) = %s, want nil", got.Path)
+	}
+	if got := ws.Unresolved(); !slices.Equal(got, []string{"org.example:shipping@2.0.0"}) {
+		t.Errorf("Unresolved after fetching = %q", got)
+	}
+
+	var messages []string
+	for _, f := range CheckWorkspaceReferences(ws, m) {
+		messages = append(messages, f.Message)
+	}
+	want := []string{
+		"Use declaration 'tax' coordinate './tax.allium' does not resolve to any spec in the workspace",
+		"Use declaration 'shipping' coordinate 'org.example:shipping@2.0.0' could not be fetched: 503 Service Unavailable",
+	}
+	if !slices.Equal(messages, want) {
+		t.Errorf("findings = %q, want %q", messages, want)
+	}
+}
@@ -0,0 +1,189 @@
+// Package semver parses and orders semantic versions (https://semver.org),
+// as declared in a spec's metadata.spec_version.
+package semver
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version, MAJOR.MINOR.PATCH with an optional
+// pre-release and build metadata, as in "1.4.0-rc.1+build.7".
+type Version struct {
+	Major, Minor, Patch int
+	Pre                 []string // dot-separated pre-release identifiers
+	Build               string   // build metadata, ignored when ordering
+}
+
+// Parse parses a semantic version. A leading "v" and missing minor or patch
+// components are errors, as the specification requires.
+func Parse(s string) (Version, error) {
+	var v Version
+	rest, build, hasBuild := strings.Cut(s, "+")
+	if hasBuild {
+		if !validIdentifiers(build, false) {
+			return Version{}, fmt.Errorf("invalid build metadata %q in version %q", build, s)
+		}
+		v.Build = build
+	}
+	core, pre, hasPre := strings.Cut(rest, "-")
+	if hasPre {
+		if !validIdentifiers(pre, true) {
+			return Version{}, fmt.Errorf("invalid pre-release %q in version %q", pre, s)
+		}
+		v.Pre = strings.Split(pre, ".")
+	}
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return Version{}, fmt.Errorf("version %q is not of the form MAJOR.MINOR.PATCH", s)
+	}
+	var nums [3]int
+	for i, p := range parts {
+		n, ok := numeric(p)
+		if !ok {
+			return Version{}, fmt.Errorf("version %q has an invalid component %q", s, p)
+		}
+		nums[i] = n
+	}
+	v.Major, v.Minor, v.Patch = nums[0], nums[1], nums[2]
+	return v, nil
+}
+
+// Normalize returns the semantic version a near miss such as "v1.2" or "1"
+// stands for, with a leading "v" removed and missing components zero, or ""
+// if s is not one.
+func Normalize(s string) string {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+	core, suffix := s, ""
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		core, suffix = s[:i], s[i:]
+	}
+	parts := strings.Split(core, ".")
+	for len(parts) < 3 {
+		parts = append(parts, "0")
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return ""
+		}
+		parts[i] = strconv.Itoa(n)
+	}
+	normalized := strings.Join(parts, ".") + suffix
+	if _, err := Parse(normalized); err != nil {
+		return ""
+	}
+	return normalized
+}
+
+// numeric parses a numeric identifier, which has no leading zeros.
+func numeric(s string) (int, bool) {
+	if s == "" || len(s) > 1 && s[0] == '0' {
+		return 0, false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return 0, false
+		}
+	}
+	n, err := strconv.Atoi(s)
+	return n, err == nil
+}
+
+// validIdentifiers reports whether s is a dot-separated list of non-empty
+// identifiers of ASCII letters, digits and hyphens; numeric pre-release
+// identifiers must not have leading zeros.
+func validIdentifiers(s string, pre bool) bool {
+	for _, id := range strings.Split(s, ".") {
+		if id == "" {
+			return false
+		}
+		digits := true
+		for _, r := range id {
+			switch {
+			case r >= '0' && r <= '9':
+			case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '-':
+				digits = false
+			default:
+				return false
+			}
+		}
+		if pre && digits && len(id) > 1 && id[0] == '0' {
+			return false
+		}
+	}
+	return true
+}
+
+// String renders the version, e.g. "1.4.0-rc.1+build.7".
+func (v Version) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if len(v.Pre) > 0 {
+		s += "-" + strings.Join(v.Pre, ".")
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+	return s
+}
+
+// Compare orders versions by precedence: by major, minor and patch, then a
+// pre-release before its release, comparing pre-release identifiers in
+// turn. Build metadata is ignored.
+func (v Version) Compare(w Version) int {
+	if c := cmp.Compare(v.Major, w.Major); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.Minor, w.Minor); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(v.Patch, w.Patch); c != 0 {
+		return c
+	}
+	switch {
+	case len(v.Pre) == 0 && len(w.Pre) == 0:
+		return 0
+	case len(v.Pre) == 0:
+		return 1
+	case len(w.Pre) == 0:
+		return -1
+	}
+	for i := 0; i < len(v.Pre) && i < len(w.Pre); i++ {
+		a, aNum := numeric(v.Pre[i])
+		b, bNum := numeric(w.Pre[i])
+		var c int
+		switch {
+		case aNum && bNum:
+			c = cmp.Compare(a, b)
+		case aNum:
+			c = -1
+		case bNum:
+			c = 1
+		default:
+			c = strings.Compare(v.Pre[i], w.Pre[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(v.Pre), len(w.Pre))
+}
+
+// NextMajor returns the release after v that may break compatibility with
+// it: the next major version, or for a 0.y.z version, which may change
+// incompatibly in any minor release, the next minor version.
+func (v Version) NextMajor() Version {
+	if v.Major == 0 {
+		return Version{Minor: v.Minor + 1}
+	}
+	return Version{Major: v.Major + 1}
+}
+
+// IsMajorIncrement reports whether w may break compatibility with v: w is
+// at least v.NextMajor() or a pre-release of it.
+func (v Version) IsMajorIncrement(w Version) bool {
+	next := v.NextMajor()
+	return Version{Major: w.Major, Minor: w.Minor, Patch: w.Patch}.Compare(next) >= 0
+}
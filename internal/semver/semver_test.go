@@ -0,0 +1,92 @@
+package semver
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	for _, s := range []string{"0.0.0", "1.2.3", "10.20.30", "1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-0.3.7", "1.0.0-x-y.z", "1.0.0+20130313144700", "1.0.0-beta+exp.sha.5114f85"} {
+		v, err := Parse(s)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", s, err)
+			continue
+		}
+		if v.String() != s {
+			t.Errorf("Parse(%q).String() = %q", s, v.String())
+		}
+	}
+	for _, s := range []string{"", "1", "1.2", "v1.2.3", "1.2.3.4", "01.2.3", "1.02.3", "1.2.-3", "1.2.3-", "1.2.3-01", "1.2.3-a..b", "1.2.3+", "1.2.3+a_b", " 1.2.3"} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", s)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]string{
+		"1.2.3":      "1.2.3",
+		"v1.2.3":     "1.2.3",
+		"1.2":        "1.2.0",
+		"v2":         "2.0.0",
+		"1.2-rc.1":   "1.2.0-rc.1",
+		"01.2.3":     "1.2.3",
+		"latest":     "",
+		"1.2.3.4":    "",
+		"1.2.3-rc..": "",
+	}
+	for in, want := range tests {
+		if got := Normalize(in); got != want {
+			t.Errorf("Normalize(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	// Ordered by precedence, from the specification's examples.
+	ordered := []string{"0.9.0", "1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta", "1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1", "1.1.0", "2.0.0"}
+	var versions []Version
+	for _, s := range ordered {
+		v, err := Parse(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		versions = append(versions, v)
+	}
+	shuffled := slices.Clone(versions)
+	slices.Reverse(shuffled)
+	slices.SortFunc(shuffled, Version.Compare)
+	for i := range versions {
+		if shuffled[i].String() != versions[i].String() {
+			t.Fatalf("sorted = %v, want %v", shuffled, versions)
+		}
+	}
+	a, _ := Parse("1.0.0+build.1")
+	b, _ := Parse("1.0.0+build.2")
+	if a.Compare(b) != 0 {
+		t.Error("expected build metadata to be ignored")
+	}
+}
+
+func TestIsMajorIncrement(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{"1.2.3", "2.0.0", true},
+		{"1.2.3", "2.0.0-rc.1", true},
+		{"1.2.3", "3.1.0", true},
+		{"1.2.3", "1.3.0", false},
+		{"1.2.3", "1.2.4", false},
+		{"0.3.1", "0.4.0", true},
+		{"0.3.1", "1.0.0", true},
+		{"0.3.1", "0.3.2", false},
+	}
+	for _, tt := range tests {
+		from, _ := Parse(tt.from)
+		to, _ := Parse(tt.to)
+		if got := from.IsMajorIncrement(to); got != tt.want {
+			t.Errorf("%s.IsMajorIncrement(%s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
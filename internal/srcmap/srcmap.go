@@ -0,0 +1,312 @@
+// Package srcmap maps the values of a JSON document to where they appear in
+// its source. A Map records the span of every value, keyed by the JSONPath
+// findings use, so a finding's path can be shown as a line and column range
+// and a fix can rewrite a single value without reformatting the file.
+package srcmap
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Pos is a point in a source file.
+type Pos struct {
+	Offset int // byte offset from the start of the file
+	Line   int // 1-based line number
+	Column int // 1-based column, counted in characters
+}
+
+// Span is the source of a JSON value: Start is its first byte and End the
+// point just past its last.
+type Span struct {
+	Start Pos
+	End   Pos
+}
+
+// Text returns the source of the value spanned in data.
+func (s Span) Text(data []byte) []byte {
+	return data[s.Start.Offset:s.End.Offset]
+}
+
+// Map maps JSONPath expressions, in the form used by findings
+// (e.g. "$.rules[3].requires[1]" or "$.rules[0].ensures[0].fields.status"),
+// to the span of the value at that path.
+type Map map[string]Span
+
+// Lookup returns the span of path, falling back to the nearest enclosing
+// value when path itself is not indexed (e.g. filter expressions like
+// "$.rules[?(@.name=='X')]" resolve to "$.rules").
+func (m Map) Lookup(path string) (Span, bool) {
+	for path != "" {
+		if span, ok := m[path]; ok {
+			return span, true
+		}
+		path = parentPath(path)
+	}
+	return Span{}, false
+}
+
+// parentPath strips the last segment from a JSONPath.
+func parentPath(path string) string {
+	if path == "$" {
+		return ""
+	}
+	cut := max(strings.LastIndexByte(path, '.'), strings.LastIndexByte(path, '['))
+	if strings.HasSuffix(path, "]") {
+		// Skip over a bracketed segment, which may itself contain dots.
+		depth := 0
+		for i := len(path) - 1; i >= 0; i-- {
+			switch path[i] {
+			case ']':
+				depth++
+			case '[':
+				depth--
+			}
+			if depth == 0 {
+				cut = i
+				break
+			}
+		}
+	}
+	if cut <= 0 {
+		return "$"
+	}
+	return path[:cut]
+}
+
+// Build scans a JSON document and records the span of every value, keyed by
+// JSONPath. The document must be syntactically valid.
+func Build(data []byte) (Map, error) {
+	s := &scanner{data: data, line: 1, col: 1, spans: make(Map)}
+	s.skipSpace()
+	if _, err := s.value("$"); err != nil {
+		return nil, err
+	}
+	return s.spans, nil
+}
+
+// Decode is Build that also decodes the document, in the same scan, as
+// encoding/json does into an any, except that numbers are json.Number. The
+// document must be syntactically valid (see json.Valid); like Build, Decode
+// only reports the errors it happens to meet.
+func Decode(data []byte) (any, Map, error) {
+	s := &scanner{data: data, line: 1, col: 1, spans: make(Map), decode: true}
+	s.skipSpace()
+	v, err := s.value("$")
+	if err != nil {
+		return nil, nil, err
+	}
+	return v, s.spans, nil
+}
+
+type scanner struct {
+	data      []byte
+	off       int
+	line, col int
+	spans     Map
+	decode    bool // build the values scanned, for Decode
+}
+
+// pos returns the point the scanner has reached.
+func (s *scanner) pos() Pos {
+	return Pos{Offset: s.off, Line: s.line, Column: s.col}
+}
+
+// advance moves past n bytes, tracking line and column.
+func (s *scanner) advance(n int) {
+	end := min(s.off+n, len(s.data))
+	for s.off < end {
+		r, size := utf8.DecodeRune(s.data[s.off:])
+		if r == '\n' {
+			s.line++
+			s.col = 1
+		} else {
+			s.col++
+		}
+		s.off += size
+	}
+}
+
+func (s *scanner) skipSpace() {
+	for s.off < len(s.data) {
+		switch s.data[s.off] {
+		case ' ', '\t', '\r', '\n':
+			s.advance(1)
+		default:
+			return
+		}
+	}
+}
+
+func (s *scanner) errorf(format string, args ...any) error {
+	return fmt.Errorf("line %d, column %d: %s", s.line, s.col, fmt.Sprintf(format, args...))
+}
+
+func (s *scanner) expect(c byte) error {
+	s.skipSpace()
+	if s.off >= len(s.data) || s.data[s.off] != c {
+		return s.errorf("expected %q", c)
+	}
+	s.advance(1)
+	return nil
+}
+
+// value records the span of the value at path and consumes it, returning
+// the value when decoding.
+func (s *scanner) value(path string) (any, error) {
+	if s.off >= len(s.data) {
+		return nil, s.errorf("unexpected end of input")
+	}
+	start := s.pos()
+	v, err := s.consume(path)
+	if err != nil {
+		return nil, err
+	}
+	s.spans[path] = Span{Start: start, End: s.pos()}
+	return v, nil
+}
+
+func (s *scanner) consume(path string) (any, error) {
+	switch s.data[s.off] {
+	case '{':
+		return s.object(path)
+	case '[':
+		return s.array(path)
+	case '"':
+		return s.str()
+	default:
+		start := s.off
+		for s.off < len(s.data) && !strings.ContainsRune(",]} \t\r\n", rune(s.data[s.off])) {
+			s.advance(1)
+		}
+		if s.off == start {
+			return nil, s.errorf("unexpected character %q", s.data[s.off])
+		}
+		if !s.decode {
+			return nil, nil
+		}
+		switch lit := string(s.data[start:s.off]); lit {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		default:
+			return json.Number(lit), nil
+		}
+	}
+}
+
+func (s *scanner) object(path string) (any, error) {
+	var obj map[string]any
+	if s.decode {
+		obj = make(map[string]any)
+	}
+	s.advance(1) // '{'
+	s.skipSpace()
+	if s.off < len(s.data) && s.data[s.off] == '}' {
+		s.advance(1)
+		return obj, nil
+	}
+	for {
+		s.skipSpace()
+		key, err := s.str()
+		if err != nil {
+			return nil, err
+		}
+		if err := s.expect(':'); err != nil {
+			return nil, err
+		}
+		s.skipSpace()
+		v, err := s.value(path + "." + key)
+		if err != nil {
+			return nil, err
+		}
+		if s.decode {
+			obj[key] = v
+		}
+		s.skipSpace()
+		if s.off >= len(s.data) {
+			return nil, s.errorf("unexpected end of object")
+		}
+		switch s.data[s.off] {
+		case ',':
+			s.advance(1)
+		case '}':
+			s.advance(1)
+			return obj, nil
+		default:
+			return nil, s.errorf("expected ',' or '}'")
+		}
+	}
+}
+
+func (s *scanner) array(path string) (any, error) {
+	var arr []any
+	if s.decode {
+		arr = []any{}
+	}
+	s.advance(1) // '['
+	s.skipSpace()
+	if s.off < len(s.data) && s.data[s.off] == ']' {
+		s.advance(1)
+		return arr, nil
+	}
+	for i := 0; ; i++ {
+		s.skipSpace()
+		v, err := s.value(path + "[" + strconv.Itoa(i) + "]")
+		if err != nil {
+			return nil, err
+		}
+		if s.decode {
+			arr = append(arr, v)
+		}
+		s.skipSpace()
+		if s.off >= len(s.data) {
+			return nil, s.errorf("unexpected end of array")
+		}
+		switch s.data[s.off] {
+		case ',':
+			s.advance(1)
+		case ']':
+			s.advance(1)
+			return arr, nil
+		default:
+			return nil, s.errorf("expected ',' or ']'")
+		}
+	}
+}
+
+// str consumes a JSON string and returns its decoded value.
+func (s *scanner) str() (string, error) {
+	if s.off >= len(s.data) || s.data[s.off] != '"' {
+		return "", s.errorf("expected string")
+	}
+	start := s.off
+	escaped := false
+	s.advance(1)
+	for s.off < len(s.data) {
+		switch s.data[s.off] {
+		case '\\':
+			escaped = true
+			s.advance(2)
+		case '"':
+			s.advance(1)
+			if !escaped {
+				return string(s.data[start+1 : s.off-1]), nil
+			}
+			var v string
+			if err := json.Unmarshal(s.data[start:s.off], &v); err != nil {
+				return "", s.errorf("invalid string: %v", err)
+			}
+			return v, nil
+		default:
+			s.advance(1)
+		}
+	}
+	return "", s.errorf("unterminated string")
+}
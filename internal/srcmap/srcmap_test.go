@@ -0,0 +1,139 @@
+package srcmap
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const doc = `{
+  "version": "1",
+  "rules": [
+    {
+      "name": "Café",
+      "ensures": [ { "kind": "entity_creation", "fields": { "status": "a\"b" } } ]
+    }
+  ],
+  "empty": [],
+  "n": -1.5e3
+}`
+
+func TestBuild(t *testing.T) {
+	m, err := Build([]byte(doc))
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	tests := []struct {
+		path      string
+		line, col int
+	}{
+		{"$", 1, 1},
+		{"$.version", 2, 14},
+		{"$.rules", 3, 12},
+		{"$.rules[0]", 4, 5},
+		{"$.rules[0].name", 5, 15},
+		{"$.rules[0].ensures[0]", 6, 20},
+		{"$.rules[0].ensures[0].fields.status", 6, 71},
+		{"$.empty", 9, 12},
+		{"$.n", 10, 8},
+	}
+	for _, tt := range tests {
+		span, ok := m[tt.path]
+		if !ok {
+			t.Errorf("missing span for %s", tt.path)
+			continue
+		}
+		if span.Start.Line != tt.line || span.Start.Column != tt.col {
+			t.Errorf("%s at %d:%d, want %d:%d", tt.path, span.Start.Line, span.Start.Column, tt.line, tt.col)
+		}
+		if doc[span.Start.Offset] == ' ' {
+			t.Errorf("%s offset %d points at whitespace", tt.path, span.Start.Offset)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	v, m, err := Decode([]byte(doc))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	built, _ := Build([]byte(doc))
+	if !reflect.DeepEqual(m, built) {
+		t.Error("Decode's map differs from Build's")
+	}
+
+	var want any
+	dec := json.NewDecoder(strings.NewReader(doc))
+	dec.UseNumber()
+	if err := dec.Decode(&want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("Decode = %#v, want %#v", v, want)
+	}
+
+	v, _, err = Decode([]byte(`["a\/b\u00e9", true, false, null, {}]`))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if want := []any{"a/bé", true, false, nil, map[string]any{}}; !reflect.DeepEqual(v, want) {
+		t.Errorf("Decode = %#v, want %#v", v, want)
+	}
+}
+
+func TestBuildEnd(t *testing.T) {
+	m, err := Build([]byte(doc))
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+	for path, want := range map[string]string{
+		"$.version":                           `"1"`,
+		"$.rules[0].ensures[0].fields":        `{ "status": "a\"b" }`,
+		"$.rules[0].ensures[0].fields.status": `"a\"b"`,
+		"$.empty":                             `[]`,
+		"$.n":                                 `-1.5e3`,
+	} {
+		if got := string(m[path].Text([]byte(doc))); got != want {
+			t.Errorf("%s spans %q, want %q", path, got, want)
+		}
+	}
+	if end := m["$"].End; end.Offset != len(doc) || end.Line != 11 || end.Column != 2 {
+		t.Errorf("document ends at %+v, want offset %d at 11:2", end, len(doc))
+	}
+	// A value spanning lines ends on its last line.
+	if end := m["$.rules"].End; end.Line != 8 || end.Column != 4 {
+		t.Errorf("$.rules ends at %d:%d, want 8:4", end.Line, end.Column)
+	}
+}
+
+func TestBuildInvalid(t *testing.T) {
+	for _, doc := range []string{`{"a": `, `[1 2]`, `{"a" 1}`, `"open`} {
+		if _, err := Build([]byte(doc)); err == nil {
+			t.Errorf("Build(%q) should fail", doc)
+		}
+	}
+}
+
+func TestLookupFallsBackToParent(t *testing.T) {
+	m, err := Build([]byte(doc))
+	if err != nil {
+		t.Fatalf("Build: %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"$.rules[0].requires[2]", "$.rules[0]"},
+		{"$.rules[?(@.name=='Café')].trigger", "$.rules"},
+		{"$.unknown", "$"},
+	}
+	for _, tt := range tests {
+		got, ok := m.Lookup(tt.path)
+		if !ok || got != m[tt.want] {
+			t.Errorf("Lookup(%q) = %+v, want span of %s", tt.path, got, tt.want)
+		}
+	}
+}
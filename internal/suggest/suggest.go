@@ -0,0 +1,41 @@
+// Package suggest finds plausible corrections for misspelt names and values.
+package suggest
+
+// Closest returns the candidate most similar to name by edit distance,
+// or "" if no candidate is close enough to be a plausible typo.
+// Ties resolve to the earliest candidate.
+func Closest(name string, candidates []string) string {
+	best := ""
+	bestDist := max(2, len(name)/3) + 1
+	for _, c := range candidates {
+		if c == name {
+			continue
+		}
+		if d := Distance(name, c); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+// Distance returns the Levenshtein edit distance between two strings.
+func Distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
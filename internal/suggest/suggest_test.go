@@ -0,0 +1,35 @@
+package suggest
+
+import "testing"
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"SendWelcome", "SendWelcome", 0},
+		{"SendWelcom", "SendWelcome", 1},
+	}
+	for _, tt := range tests {
+		if got := Distance(tt.a, tt.b); got != tt.want {
+			t.Errorf("Distance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestClosest(t *testing.T) {
+	candidates := []string{"SendWelcome", "ResetPassword", "LockAccount"}
+
+	if got := Closest("SendWelcom", candidates); got != "SendWelcome" {
+		t.Errorf("Closest(typo) = %q", got)
+	}
+	if got := Closest("Unrelated", candidates); got != "" {
+		t.Errorf("Closest(unrelated) = %q, want empty", got)
+	}
+	if got := Closest("SendWelcome", candidates); got != "" {
+		t.Errorf("Closest(exact) = %q, want empty", got)
+	}
+}
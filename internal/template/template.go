@@ -0,0 +1,163 @@
+// Package template loads spec templates: the skeleton an organization's
+// specs follow, such as the sections every spec populates, the actors and
+// surfaces it declares, and the prefixes declared names start with.
+package template
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+
+	"github.com/foundry-zero/allium/internal/ast"
+)
+
+// Template is a parsed spec template. Sections are named by their key in
+// the spec document, e.g. "actors" or "external_entities".
+type Template struct {
+	// RequiredSections lists the sections every spec must populate.
+	RequiredSections []string `json:"required_sections,omitempty"`
+
+	// AllowedSections, if set, lists the other sections a spec may
+	// populate. A populated section in neither list is extra.
+	AllowedSections []string `json:"allowed_sections,omitempty"`
+
+	// Required lists, by section, name patterns that some declaration in the
+	// section must match, e.g. {"actors": ["Admin"], "surfaces":
+	// ["*Dashboard"]}. Patterns use path.Match syntax.
+	Required map[string][]string `json:"required,omitempty"`
+
+	// Prefixes lists, by section, the prefixes declared names must start
+	// with, e.g. {"rules": ["Admin", "Customer"]}. A name must start with one
+	// of the prefixes of its section.
+	Prefixes map[string][]string `json:"prefixes,omitempty"`
+}
+
+// Section is a top-level section of a spec.
+type Section struct {
+	Key string // e.g. "actors"
+
+	// Names returns the names of the section's declarations in order, or
+	// nil for sections whose entries are not named.
+	Names func(*ast.Spec) []string
+	// Len returns the number of entries in the section.
+	Len func(*ast.Spec) int
+}
+
+// Named reports whether the section's declarations have names.
+func (s Section) Named() bool { return s.Names != nil }
+
+func names[T any](items []T, name func(T) string) []string {
+	out := make([]string, len(items))
+	for i, it := range items {
+		out[i] = name(it)
+	}
+	return out
+}
+
+// Sections lists the sections of a spec in document order.
+var Sections = []Section{
+	{"use_declarations", func(s *ast.Spec) []string {
+		return names(s.UseDeclarations, func(u ast.UseDeclaration) string { return u.Alias })
+	}, func(s *ast.Spec) int { return len(s.UseDeclarations) }},
+	{"given", func(s *ast.Spec) []string {
+		return names(s.Given, func(g ast.GivenBinding) string { return g.Name })
+	}, func(s *ast.Spec) int { return len(s.Given) }},
+	{"external_entities", func(s *ast.Spec) []string {
+		return names(s.ExternalEntities, func(e ast.ExternalEntity) string { return e.Name })
+	}, func(s *ast.Spec) int { return len(s.ExternalEntities) }},
+	{"value_types", func(s *ast.Spec) []string {
+		return names(s.ValueTypes, func(v ast.ValueType) string { return v.Name })
+	}, func(s *ast.Spec) int { return len(s.ValueTypes) }},
+	{"type_aliases", func(s *ast.Spec) []string {
+		return names(s.TypeAliases, func(a ast.TypeAlias) string { return a.Name })
+	}, func(s *ast.Spec) int { return len(s.TypeAliases) }},
+	{"enumerations", func(s *ast.Spec) []string {
+		return names(s.Enumerations, func(e ast.Enumeration) string { return e.Name })
+	}, func(s *ast.Spec) int { return len(s.Enumerations) }},
+	{"entities", func(s *ast.Spec) []string {
+		return names(s.Entities, func(e ast.Entity) string { return e.Name })
+	}, func(s *ast.Spec) int { return len(s.Entities) }},
+	{"variants", func(s *ast.Spec) []string {
+		return names(s.Variants, func(v ast.Variant) string { return v.Name })
+	}, func(s *ast.Spec) int { return len(s.Variants) }},
+	{"config", func(s *ast.Spec) []string {
+		return names(s.Config, func(c ast.ConfigParam) string { return c.Name })
+	}, func(s *ast.Spec) int { return len(s.Config) }},
+	{"defaults", func(s *ast.Spec) []string {
+		return names(s.Defaults, func(d ast.Default) string { return d.Name })
+	}, func(s *ast.Spec) int { return len(s.Defaults) }},
+	{"rules", func(s *ast.Spec) []string {
+		return names(s.Rules, func(r ast.Rule) string { return r.Name })
+	}, func(s *ast.Spec) int { return len(s.Rules) }},
+	{"actors", func(s *ast.Spec) []string {
+		return names(s.Actors, func(a ast.Actor) string { return a.Name })
+	}, func(s *ast.Spec) int { return len(s.Actors) }},
+	{"surfaces", func(s *ast.Spec) []string {
+		return names(s.Surfaces, func(sf ast.Surface) string { return sf.Name })
+	}, func(s *ast.Spec) int { return len(s.Surfaces) }},
+	{"deferred", func(s *ast.Spec) []string {
+		return names(s.Deferred, func(d ast.Deferred) string { return d.Name })
+	}, func(s *ast.Spec) int { return len(s.Deferred) }},
+	{"open_questions", nil, func(s *ast.Spec) int { return len(s.OpenQuestions) }},
+	{"suppressions", nil, func(s *ast.Spec) int { return len(s.Suppressions) }},
+}
+
+// LookupSection returns the section with the given key, or false if there
+// is none.
+func LookupSection(key string) (Section, bool) {
+	i := slices.IndexFunc(Sections, func(s Section) bool { return s.Key == key })
+	if i < 0 {
+		return Section{}, false
+	}
+	return Sections[i], true
+}
+
+// Load reads the template file at path. Unknown keys, unknown sections and
+// invalid patterns are rejected so that a mistake in the template is not
+// silently ignored.
+func Load(path string) (*Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read template: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var t Template
+	if err := dec.Decode(&t); err != nil {
+		return nil, fmt.Errorf("parse template %s: %w", path, err)
+	}
+	if err := t.validate(); err != nil {
+		return nil, fmt.Errorf("template %s: %w", path, err)
+	}
+	return &t, nil
+}
+
+func (t *Template) validate() error {
+	for _, key := range append(slices.Clone(t.RequiredSections), t.AllowedSections...) {
+		if _, ok := LookupSection(key); !ok {
+			return fmt.Errorf("unknown section %q", key)
+		}
+	}
+	for field, bySection := range map[string]map[string][]string{"required": t.Required, "prefixes": t.Prefixes} {
+		for key, values := range bySection {
+			s, ok := LookupSection(key)
+			if !ok {
+				return fmt.Errorf("unknown section %q in %s", key, field)
+			}
+			if !s.Named() {
+				return fmt.Errorf("section %q in %s has no names", key, field)
+			}
+			if field == "required" {
+				for _, p := range values {
+					if _, err := path.Match(p, ""); err != nil {
+						return fmt.Errorf("invalid pattern %q for %s: %w", p, key, err)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
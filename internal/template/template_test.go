@@ -0,0 +1,59 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTemplate(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "template.json")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	tmpl, err := Load(writeTemplate(t, `{
+		"required_sections": ["entities", "surfaces"],
+		"allowed_sections": ["config"],
+		"required": {"actors": ["Admin", "*Customer"]},
+		"prefixes": {"rules": ["Admin"]}
+	}`))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(tmpl.RequiredSections) != 2 || tmpl.Required["actors"][1] != "*Customer" || tmpl.Prefixes["rules"][0] != "Admin" {
+		t.Errorf("unexpected template %+v", tmpl)
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	for content, want := range map[string]string{
+		`{"required_section": ["entities"]}`:      "unknown field",
+		`{"required_sections": ["entity"]}`:       `unknown section "entity"`,
+		`{"prefixes": {"rule": ["Admin"]}}`:       `unknown section "rule" in prefixes`,
+		`{"required": {"open_questions": ["*"]}}`: `section "open_questions" in required has no names`,
+		`{"required": {"actors": ["[Admin"]}}`:    `invalid pattern "[Admin" for actors`,
+	} {
+		_, err := Load(writeTemplate(t, content))
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Load(%s) error = %v, want %q", content, err, want)
+		}
+	}
+	if _, err := Load(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestSections(t *testing.T) {
+	if _, ok := LookupSection("external_entities"); !ok {
+		t.Error("external_entities is not a section")
+	}
+	if s, _ := LookupSection("open_questions"); s.Named() {
+		t.Error("open_questions entries have no names")
+	}
+}
@@ -0,0 +1,191 @@
+// Package allium is the public Go API of the Allium validator. It loads
+// Allium specifications (.allium.json), builds their symbol tables, and
+// checks them against the JSON Schema and the semantic rules, as
+// allium-check does, so that other tools can embed the checker.
+//
+// # Stability
+//
+// The functions, types and constants declared in this package, and the
+// fields of the types it aliases (Spec and the AST types reachable from it,
+// SymbolTable, Report, Finding, Suggestion, Edit, Location and RuleInfo),
+// follow semantic versioning: within a major version they are neither
+// removed nor changed incompatibly, although fields, options and functions
+// may be added.
+//
+// Rule and warning IDs are stable; a rule is never renumbered or reused for
+// a different check. Which findings a spec produces is not: new rules and
+// warnings are added, and existing ones are refined, in minor versions.
+// Finding messages are for people and may change at any time, so tools
+// should match on Finding.Rule and Location.Path rather than on Message.
+//
+// Packages under internal/ have no compatibility guarantee.
+package allium
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/foundry-zero/allium/internal/ast"
+	"github.com/foundry-zero/allium/internal/checker"
+	"github.com/foundry-zero/allium/internal/report"
+	"github.com/foundry-zero/allium/internal/schema"
+	"github.com/foundry-zero/allium/internal/semantic"
+)
+
+// Spec is a parsed Allium specification.
+type Spec = ast.Spec
+
+// SymbolTable indexes the declarations of a spec by name.
+type SymbolTable = semantic.SymbolTable
+
+// Report is the result of checking one spec: its errors, warnings, info
+// findings, hints and suppressed findings, and their counts.
+type Report = report.Report
+
+// Finding is a single error, warning, info finding or hint, identified by its rule ID, e.g.
+// "RULE-08" or "WARN-16". Problems reading or parsing a file are reported
+// under "INPUT", and JSON Schema violations under "SCHEMA".
+type Finding = report.Finding
+
+// Suggestion is a mechanical fix offered with a finding, made of edits to
+// the spec document.
+type Suggestion = report.Suggestion
+
+// Edit adds, replaces or removes the value at a JSONPath in a spec document.
+type Edit = report.Edit
+
+// Location identifies where a finding occurred: a JSONPath into the spec
+// and, when known, its line and column.
+type Location = report.Location
+
+// Group is the findings of one rule with the same severity and message,
+// reported once with all of their locations.
+type Group = report.Group
+
+// Severity is the severity of a finding.
+type Severity = report.Severity
+
+// Finding severities.
+const (
+	SeverityError   = report.SeverityError
+	SeverityWarning = report.SeverityWarning
+	SeverityInfo    = report.SeverityInfo
+	SeverityHint    = report.SeverityHint
+)
+
+// RuleInfo describes a rule or warning the checker may report.
+type RuleInfo = checker.RuleInfo
+
+// LoadSpec reads and parses the spec file at path.
+func LoadSpec(path string) (*Spec, error) {
+	return ast.LoadSpec(path)
+}
+
+// ParseSpec parses a spec from its JSON encoding.
+func ParseSpec(data []byte) (*Spec, error) {
+	return ast.ParseSpec(data)
+}
+
+// BuildSymbolTable indexes the declarations of spec by name.
+func BuildSymbolTable(spec *Spec) *SymbolTable {
+	return semantic.BuildSymbolTable(spec)
+}
+
+// Rules returns the catalog of every rule and warning, rules first, each in
+// numeric order.
+func Rules() []RuleInfo {
+	return checker.Rules()
+}
+
+// GroupFindings groups findings with the same rule, severity and message, in
+// the order of each group's first finding. With a positive limit, a group
+// lists at most limit locations and counts the rest in its Omitted field.
+func GroupFindings(findings []Finding, limit int) []Group {
+	return report.GroupFindings(findings, limit)
+}
+
+// SupportedVersions returns the schema versions the checker validates, oldest
+// first. A spec declaring another version is reported as a SCHEMA error.
+func SupportedVersions() []string {
+	return schema.SupportedVersions()
+}
+
+// Options controls a check. The zero value runs every rule and warning
+// without project configuration.
+type Options struct {
+	// SchemaOnly checks only the JSON Schema, skipping the semantic rules.
+	SchemaOnly bool
+
+	// Rules, if non-empty, restricts the check to these rule and warning
+	// IDs, e.g. "RULE-12" or "WARN-05".
+	Rules []string
+
+	// Path, if set, is a JSONPath such as "$.rules[12]" restricting the
+	// reported findings to that subtree.
+	Path string
+
+	// DiscoverConfig applies the project configuration (.alliumcheck.json)
+	// found in the directory of each checked file or a parent directory.
+	DiscoverConfig bool
+
+	// Against, if set, is the previous version of the spec. Changes from it
+	// that break its consumers are reported as RULE-42 errors.
+	Against *Spec
+}
+
+func (o Options) checkOptions() checker.CheckOptions {
+	return checker.CheckOptions{
+		SchemaOnly:     o.SchemaOnly,
+		RuleIDs:        o.Rules,
+		PathFilter:     o.Path,
+		DiscoverConfig: o.DiscoverConfig,
+		Against:        o.Against,
+	}
+}
+
+// Checker checks specs. It compiles the JSON Schema once, so a long-running
+// tool should create one Checker and reuse it. A Checker is safe for
+// concurrent use.
+type Checker struct {
+	c *checker.Checker
+}
+
+// NewChecker returns a Checker with every rule and warning available.
+func NewChecker() (*Checker, error) {
+	c, err := checker.NewChecker()
+	if err != nil {
+		return nil, err
+	}
+	return &Checker{c: c}, nil
+}
+
+// Check checks the spec file at path. A file that cannot be read or parsed
+// is reported as an INPUT error in the report.
+func (c *Checker) Check(path string, opts Options) *Report {
+	return c.c.Check(path, opts.checkOptions())
+}
+
+// CheckSource checks spec content held in memory, such as an unsaved editor
+// buffer. The path only labels the report and its findings.
+func (c *Checker) CheckSource(path string, data []byte, opts Options) *Report {
+	return c.c.CheckSource(path, data, opts.checkOptions())
+}
+
+// CheckSpec checks a spec built in memory. The JSON Schema is not checked,
+// and findings have paths but no lines.
+func (c *Checker) CheckSpec(spec *Spec, opts Options) *Report {
+	return c.c.CheckSpec(spec, opts.checkOptions())
+}
+
+var defaultChecker = sync.OnceValues(NewChecker)
+
+// Check checks the spec file at path with a Checker shared by the package.
+// The error is non-nil only if the checker cannot be initialized; problems
+// with the file itself are reported as INPUT errors in the report.
+func Check(path string, opts Options) (*Report, error) {
+	c, err := defaultChecker()
+	if err != nil {
+		return nil, fmt.Errorf("allium: %w", err)
+	}
+	return c.Check(path, opts), nil
+}
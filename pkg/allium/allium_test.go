@@ -0,0 +1,94 @@
+package allium_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/foundry-zero/allium/pkg/allium"
+)
+
+const refExample = "../../schemas/v1/examples/password-auth.allium.json"
+
+func TestCheck(t *testing.T) {
+	r, err := allium.Check(refExample, allium.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.SchemaValid || r.HasErrors() || r.Summary.WarningCount != 4 {
+		t.Errorf("unexpected report: %+v", r.Summary)
+	}
+
+	r, err = allium.Check(refExample, allium.Options{Rules: []string{"WARN-16"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Warnings) != 1 || r.Warnings[0].Rule != "WARN-16" || r.Warnings[0].Severity != allium.SeverityWarning {
+		t.Errorf("expected only WARN-16, got %v", r.Warnings)
+	}
+
+	r, err = allium.Check("missing.allium.json", allium.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Errors) != 1 || r.Errors[0].Rule != "INPUT" {
+		t.Errorf("expected an INPUT error, got %v", r.Errors)
+	}
+}
+
+func TestCheckerSourceAndSpec(t *testing.T) {
+	c, err := allium.NewChecker()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(refExample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := c.CheckSource("buffer.allium.json", data, allium.Options{}); r.File != "buffer.allium.json" || r.HasErrors() {
+		t.Errorf("CheckSource: %+v", r)
+	}
+
+	spec, err := allium.ParseSpec(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := allium.BuildSymbolTable(spec)
+	if _, ok := st.Entities["User"]; !ok {
+		t.Error("symbol table has no User entity")
+	}
+	spec.Entities = spec.Entities[1:]
+	if r := c.CheckSpec(spec, allium.Options{Against: mustLoad(t)}); !hasRule(r.Errors, "RULE-42") {
+		t.Errorf("expected RULE-42 for a removed entity, got %v", r.Errors)
+	}
+}
+
+func TestSupportedVersions(t *testing.T) {
+	if versions := allium.SupportedVersions(); len(versions) == 0 || versions[0] != "1" {
+		t.Errorf("SupportedVersions() = %v", versions)
+	}
+}
+
+func TestRules(t *testing.T) {
+	rules := allium.Rules()
+	if len(rules) == 0 || rules[0].ID != "RULE-01" {
+		t.Errorf("unexpected catalog start: %v", rules[:1])
+	}
+}
+
+func mustLoad(t *testing.T) *allium.Spec {
+	t.Helper()
+	spec, err := allium.LoadSpec(refExample)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return spec
+}
+
+func hasRule(findings []allium.Finding, rule string) bool {
+	for _, f := range findings {
+		if f.Rule == rule {
+			return true
+		}
+	}
+	return false
+}